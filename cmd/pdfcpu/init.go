@@ -40,6 +40,7 @@ func initAttachCmdMap() commandMap {
 		"add":     {processAddAttachmentsCommand, nil, "", ""},
 		"remove":  {processRemoveAttachmentsCommand, nil, "", ""},
 		"extract": {processExtractAttachmentsCommand, nil, "", ""},
+		"audit":   {processAuditAttachmentsCommand, nil, "", ""},
 	} {
 		m.register(k, v)
 	}
@@ -94,6 +95,10 @@ func initFormCmdMap() commandMap {
 		"export":    {processExportFormCommand, nil, "", ""},
 		"fill":      {processFillFormCommand, nil, "", ""},
 		"multifill": {processMultiFillFormCommand, nil, "", ""},
+		"flatten":   {processFlattenFormCommand, nil, "", ""},
+		"rename":    {processRenameFormCommand, nil, "", ""},
+		"xfa":       {processXFAFormCommand, nil, "", ""},
+		"removexfa": {processRemoveXFAFormCommand, nil, "", ""},
 	} {
 		m.register(k, v)
 	}
@@ -127,6 +132,7 @@ func initPagesCmdMap() commandMap {
 	for k, v := range map[string]command{
 		"insert": {processInsertPagesCommand, nil, "", ""},
 		"remove": {processRemovePagesCommand, nil, "", ""},
+		"splice": {processSplicePagesCommand, nil, "", ""},
 	} {
 		m.register(k, v)
 	}
@@ -220,6 +226,7 @@ func initCommandMap() {
 		"changeopw":     {processChangeOwnerPasswordCommand, nil, usageChangeOwnerPW, usageLongChangeOwnerPW},
 		"changeupw":     {processChangeUserPasswordCommand, nil, usageChangeUserPW, usageLongChangeUserPW},
 		"collect":       {processCollectCommand, nil, usageCollect, usageLongCollect},
+		"complexity":    {processPageStatsCommand, nil, usagePageStats, usageLongPageStats},
 		"config":        {printConfiguration, nil, usageConfig, usageLongConfig},
 		"create":        {processCreateCommand, nil, usageCreate, usageLongCreate},
 		"crop":          {processCropCommand, nil, usageCrop, usageLongCrop},
@@ -262,6 +269,9 @@ func initCommandMap() {
 
 func initFlags() {
 
+	axesUsage := "variable font axis coordinates, eg. \"wght:700, wdth:75\""
+	flag.StringVar(&axes, "axes", "", axesUsage)
+
 	bookmarksUsage := "create bookmarks while merging"
 	flag.BoolVar(&bookmarks, "bookmarks", true, bookmarksUsage)
 	flag.BoolVar(&bookmarks, "b", true, bookmarksUsage)
@@ -271,6 +281,16 @@ func initFlags() {
 	flag.StringVar(&conf, "conf", "", confUsage)
 	flag.StringVar(&conf, "c", "", confUsage)
 
+	dividerUsage := "insert divider pages while merging"
+	flag.BoolVar(&divider, "divider", false, dividerUsage)
+	flag.BoolVar(&divider, "d", false, dividerUsage)
+
+	tocUsage := "insert a table of contents page while merging"
+	flag.BoolVar(&toc, "toc", false, tocUsage)
+
+	healUsage := "extract text: rejoin hyphenated words and assemble paragraphs"
+	flag.BoolVar(&heal, "heal", false, healUsage)
+
 	jsonUsage := "produce JSON output"
 	flag.BoolVar(&json, "json", false, jsonUsage)
 	flag.BoolVar(&json, "j", false, jsonUsage)
@@ -283,7 +303,7 @@ func initFlags() {
 	flag.BoolVar(&links, "links", false, linksUsage)
 	flag.BoolVar(&links, "l", false, linksUsage)
 
-	modeUsage := "validate: strict|relaxed; extract: image|font|content|page|meta; encrypt: rc4|aes, stamp:text|image/pdf"
+	modeUsage := "validate: strict|relaxed; extract: image|font|content|page|meta|text; encrypt: rc4|aes, stamp:text|image/pdf/shape/svg, collect: duplex|collate"
 	flag.StringVar(&mode, "mode", "", modeUsage)
 	flag.StringVar(&mode, "m", "", modeUsage)
 
@@ -291,6 +311,16 @@ func initFlags() {
 	flag.StringVar(&selectedPages, "pages", "", selectedPagesUsage)
 	flag.StringVar(&selectedPages, "p", "", selectedPagesUsage)
 
+	nameUsage := "split, extract page: output file name template; stamp, watermark remove: watermark name"
+	flag.StringVar(&nameTemplate, "name", "", nameUsage)
+	flag.StringVar(&nameTemplate, "n", "", nameUsage)
+
+	regionUsage := "stamp, watermark remove: remove all content intersecting this rectangle"
+	flag.StringVar(&region, "region", "", regionUsage)
+
+	fillUsage := "stamp, watermark remove: paint region white"
+	flag.BoolVar(&fill, "fill", false, fillUsage)
+
 	permUsage := "encrypt, perm set: none|all"
 	flag.StringVar(&perm, "perm", "none", permUsage)
 
@@ -308,6 +338,9 @@ func initFlags() {
 	statsUsage := "optimize: create a csv file for stats"
 	flag.StringVar(&fileStats, "stats", "", statsUsage)
 
+	reportUsage := "optimize: print a size report"
+	flag.BoolVar(&report, "report", false, reportUsage)
+
 	unitUsage := "info: po|in|cm|mm"
 	flag.StringVar(&unit, "unit", "", unitUsage)
 	flag.StringVar(&unit, "u", "", unitUsage)
@@ -24,10 +24,12 @@ import (
 
 var (
 	fileStats, mode, selectedPages  string
+	axes, nameTemplate, region      string
 	upw, opw, key, perm, unit, conf string
 	verbose, veryVerbose            bool
 	links, quiet, sorted, bookmarks bool
-	json, replaceBookmarks          bool
+	json, replaceBookmarks, divider bool
+	toc, heal, report, fill         bool
 	needStackTrace                  = true
 	cmdMap                          commandMap
 )
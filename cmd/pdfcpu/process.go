@@ -72,6 +72,11 @@ func ensureCSVExtension(filename string) {
 	}
 }
 
+func hasBookmarkExtension(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".json" || ext == ".csv" || ext == ".md" || ext == ".txt"
+}
+
 func printHelp(conf *model.Configuration) {
 	switch len(flag.Args()) {
 
@@ -215,14 +220,16 @@ func processOptimizeCommand(conf *model.Configuration) {
 		fmt.Fprintf(os.Stdout, "stats will be appended to %s\n", fileStats)
 	}
 
-	process(cli.OptimizeCommand(inFile, outFile, conf))
+	cmd := cli.OptimizeCommand(inFile, outFile, conf)
+	cmd.BoolVal = report
+	process(cmd)
 }
 
 func processSplitCommand(conf *model.Configuration) {
 	if mode == "" {
 		mode = "span"
 	}
-	mode = extractModeCompletion(mode, []string{"span", "bookmark"})
+	mode = extractModeCompletion(mode, []string{"span", "bookmark", "size", "text"})
 	if mode == "" || len(flag.Args()) < 2 || len(flag.Args()) > 3 || selectedPages != "" {
 		fmt.Fprintf(os.Stderr, "%s\n\n", usageSplit)
 		os.Exit(1)
@@ -233,23 +240,86 @@ func processSplitCommand(conf *model.Configuration) {
 		ensurePDFExtension(inFile)
 	}
 
-	span := 0
+	outDir := flag.Arg(1)
 
-	if mode == "span" {
-		span = 1
-		var err error
+	if mode == "text" {
+		if len(flag.Args()) != 3 {
+			fmt.Fprintf(os.Stderr, "%s\n\n", usageSplit)
+			os.Exit(1)
+		}
+		cmd := cli.SplitByTextCommand(inFile, outDir, flag.Arg(2), conf)
+		cmd.StringVal = nameTemplate
+		process(cmd)
+		return
+	}
+
+	if mode == "size" {
+		if len(flag.Args()) != 3 {
+			fmt.Fprintf(os.Stderr, "%s\n\n", usageSplit)
+			os.Exit(1)
+		}
+		maxBytes, err := parseByteSize(flag.Arg(2))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "split: size is a positive numeric value optionally followed by KB, MB or GB, eg. 10MB")
+			os.Exit(1)
+		}
+		process(cli.SplitBySizeCommand(inFile, outDir, maxBytes, conf))
+		return
+	}
+
+	if mode == "bookmark" {
+		level := 1
 		if len(flag.Args()) == 3 {
-			span, err = strconv.Atoi(flag.Arg(2))
-			if err != nil || span < 1 {
-				fmt.Fprintln(os.Stderr, "split: span is a numeric value >= 1")
+			var err error
+			level, err = strconv.Atoi(flag.Arg(2))
+			if err != nil || level < 1 {
+				fmt.Fprintln(os.Stderr, "split: level is a numeric value >= 1")
 				os.Exit(1)
 			}
 		}
+		cmd := cli.SplitByBookmarksCommand(inFile, outDir, level, conf)
+		cmd.StringVal = nameTemplate
+		process(cmd)
+		return
 	}
 
-	outDir := flag.Arg(1)
+	span := 1
+	if len(flag.Args()) == 3 {
+		var err error
+		span, err = strconv.Atoi(flag.Arg(2))
+		if err != nil || span < 1 {
+			fmt.Fprintln(os.Stderr, "split: span is a numeric value >= 1")
+			os.Exit(1)
+		}
+	}
 
-	process(cli.SplitCommand(inFile, outDir, span, conf))
+	cmd := cli.SplitCommand(inFile, outDir, span, conf)
+	cmd.StringVal = nameTemplate
+
+	process(cmd)
+}
+
+// parseByteSize parses a size like 500KB or 10MB using binary multiples.
+func parseByteSize(s string) (int, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	unit := 1
+	for _, u := range []struct {
+		suffix string
+		factor int
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.factor
+			break
+		}
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if i < 1 {
+		return 0, errors.New("size must be > 0")
+	}
+	return i * unit, nil
 }
 
 func sortFiles(filesIn []string) {
@@ -324,6 +394,12 @@ func processMergeCommand(conf *model.Configuration) {
 	}
 
 	conf.CreateBookmarks = bookmarks
+	if divider {
+		conf.DividerPages = true
+	}
+	if toc {
+		conf.TOCPage = true
+	}
 
 	var cmd *cli.Command
 
@@ -354,7 +430,7 @@ func extractModeCompletion(modePrefix string, modes []string) string {
 }
 
 func processExtractCommand(conf *model.Configuration) {
-	mode = extractModeCompletion(mode, []string{"image", "font", "page", "content", "meta"})
+	mode = extractModeCompletion(mode, []string{"image", "font", "page", "content", "meta", "text"})
 	if len(flag.Args()) != 2 || mode == "" {
 		fmt.Fprintf(os.Stderr, "%s\n\n", usageExtract)
 		os.Exit(1)
@@ -384,6 +460,7 @@ func processExtractCommand(conf *model.Configuration) {
 
 	case "page":
 		cmd = cli.ExtractPagesCommand(inFile, outDir, pages, conf)
+		cmd.StringVal = nameTemplate
 
 	case "content":
 		cmd = cli.ExtractContentCommand(inFile, outDir, pages, conf)
@@ -391,6 +468,9 @@ func processExtractCommand(conf *model.Configuration) {
 	case "meta":
 		cmd = cli.ExtractMetadataCommand(inFile, outDir, conf)
 
+	case "text":
+		cmd = cli.ExtractTextCommand(inFile, outDir, pages, heal, conf)
+
 	default:
 		fmt.Fprintf(os.Stderr, "unknown extract mode: %s\n", mode)
 		os.Exit(1)
@@ -439,6 +519,19 @@ func processListAttachmentsCommand(conf *model.Configuration) {
 	process(cli.ListAttachmentsCommand(inFile, conf))
 }
 
+func processAuditAttachmentsCommand(conf *model.Configuration) {
+	if len(flag.Args()) != 1 || selectedPages != "" {
+		fmt.Fprintf(os.Stderr, "usage: %s\n", usageAttachAudit)
+		os.Exit(1)
+	}
+
+	inFile := flag.Arg(0)
+	if conf.CheckFileNameExt {
+		ensurePDFExtension(inFile)
+	}
+	process(cli.AuditAttachmentsCommand(inFile, json, conf))
+}
+
 func processAddAttachmentsCommand(conf *model.Configuration) {
 	if len(flag.Args()) < 2 || selectedPages != "" {
 		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageAttachAdd)
@@ -778,8 +871,8 @@ func addWatermarks(conf *model.Configuration, onTop bool) {
 		os.Exit(1)
 	}
 
-	if mode != "text" && mode != "image" && mode != "pdf" {
-		fmt.Fprintln(os.Stderr, "mode has to be one of: text, image or pdf")
+	if mode != "text" && mode != "image" && mode != "pdf" && mode != "shape" && mode != "svg" {
+		fmt.Fprintln(os.Stderr, "mode has to be one of: text, image, pdf, shape or svg")
 		os.Exit(1)
 	}
 
@@ -799,6 +892,12 @@ func addWatermarks(conf *model.Configuration, onTop bool) {
 
 	case "pdf":
 		wm, err = pdfcpu.ParsePDFWatermarkDetails(flag.Arg(0), flag.Arg(1), onTop, conf.Unit)
+
+	case "shape":
+		wm, err = pdfcpu.ParseShapeWatermarkDetails(flag.Arg(0), flag.Arg(1), onTop, conf.Unit)
+
+	case "svg":
+		wm, err = pdfcpu.ParseSVGWatermarkDetails(flag.Arg(0), flag.Arg(1), onTop, conf.Unit)
 	default:
 		err = errors.Errorf("unsupported wm type: %s\n", mode)
 	}
@@ -847,7 +946,7 @@ func updateWatermarks(conf *model.Configuration, onTop bool) {
 		os.Exit(1)
 	}
 
-	if mode != "text" && mode != "image" && mode != "pdf" {
+	if mode != "text" && mode != "image" && mode != "pdf" && mode != "shape" && mode != "svg" {
 		fmt.Fprintf(os.Stderr, "%s\n\n", u)
 		os.Exit(1)
 	}
@@ -868,6 +967,12 @@ func updateWatermarks(conf *model.Configuration, onTop bool) {
 
 	case "pdf":
 		wm, err = pdfcpu.ParsePDFWatermarkDetails(flag.Arg(0), flag.Arg(1), onTop, conf.Unit)
+
+	case "shape":
+		wm, err = pdfcpu.ParseShapeWatermarkDetails(flag.Arg(0), flag.Arg(1), onTop, conf.Unit)
+
+	case "svg":
+		wm, err = pdfcpu.ParseSVGWatermarkDetails(flag.Arg(0), flag.Arg(1), onTop, conf.Unit)
 	default:
 		err = errors.Errorf("unsupported wm type: %s\n", mode)
 	}
@@ -934,7 +1039,20 @@ func removeWatermarks(conf *model.Configuration, onTop bool) {
 		ensurePDFExtension(outFile)
 	}
 
-	process(cli.RemoveWatermarksCommand(inFile, outFile, selectedPages, conf))
+	cmd := cli.RemoveWatermarksCommand(inFile, outFile, selectedPages, conf)
+	cmd.StringVal = nameTemplate
+
+	if region != "" {
+		box, err := api.Box(region, conf.Unit)
+		if err != nil || box.Rect == nil {
+			fmt.Fprintf(os.Stderr, "problem with flag region: %s, expected: [llx lly urx ury]\n", region)
+			os.Exit(1)
+		}
+		cmd.Box = box
+		cmd.BoolVal = fill
+	}
+
+	process(cmd)
 }
 
 func processRemoveStampsCommand(conf *model.Configuration) {
@@ -1031,7 +1149,7 @@ func processInsertPagesCommand(conf *model.Configuration) {
 	}
 
 	// Set default to insert pages before selected pages.
-	if mode != "" && mode != "before" && mode != "after" {
+	if mode != "" && mode != "before" && mode != "after" && mode != "recto" {
 		fmt.Fprintf(os.Stderr, "%s\n\n", usagePagesInsert)
 		os.Exit(1)
 	}
@@ -1039,6 +1157,34 @@ func processInsertPagesCommand(conf *model.Configuration) {
 	process(cli.InsertPagesCommand(inFile, outFile, pages, conf, mode))
 }
 
+func processSplicePagesCommand(conf *model.Configuration) {
+	if len(flag.Args()) != 3 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usagePagesSplice)
+		os.Exit(1)
+	}
+
+	inFile := flag.Arg(0)
+	srcFile := flag.Arg(2)
+	if conf.CheckFileNameExt {
+		ensurePDFExtension(inFile)
+		ensurePDFExtension(srcFile)
+	}
+
+	afterPage, err := strconv.Atoi(flag.Arg(1))
+	if err != nil || afterPage < 0 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usagePagesSplice)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(selectedPages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "problem with flag selectedPages: %v\n", err)
+		os.Exit(1)
+	}
+
+	process(cli.InsertPagesFromCommand(inFile, srcFile, afterPage, pages, conf))
+}
+
 func processRemovePagesCommand(conf *model.Configuration) {
 	if len(flag.Args()) == 0 || len(flag.Args()) > 2 || selectedPages == "" {
 		fmt.Fprintf(os.Stderr, "%s\n\n", usagePagesRemove)
@@ -1107,7 +1253,7 @@ func processRotateCommand(conf *model.Configuration) {
 	process(cli.RotateCommand(inFile, outFile, rotation, selectedPages, conf))
 }
 
-func parseAfterNUpDetails(nup *model.NUp, argInd int, filenameOut string) []string {
+func parseAfterNUpDetails(nup *model.NUp, argInd int, filenameOut string, mixed bool) []string {
 	if nup.PageGrid {
 		cols, err := strconv.Atoi(flag.Arg(argInd))
 		if err != nil {
@@ -1138,12 +1284,34 @@ func parseAfterNUpDetails(nup *model.NUp, argInd int, filenameOut string) []stri
 	}
 
 	filenameIn := flag.Arg(argInd)
+	if mixed {
+		// PDF inputs may come with their own page selection eg. in.pdf:1-3
+		filenameIn = nupInputFileName(filenameIn)
+	}
 	if !hasPDFExtension(filenameIn) && !model.ImageFileName(filenameIn) {
 		fmt.Fprintf(os.Stderr, "inFile has to be a PDF or one or a sequence of image files: %s\n", filenameIn)
 		os.Exit(1)
 	}
 
-	filenamesIn := []string{filenameIn}
+	filenamesIn := []string{flag.Arg(argInd)}
+
+	if mixed && len(flag.Args()) > argInd+1 && hasPDFArg(argInd) {
+		// Any sequence of PDF and image files rendered at a common scale.
+		for i := argInd + 1; i < len(flag.Args()); i++ {
+			arg := flag.Args()[i]
+			if !hasPDFExtension(nupInputFileName(arg)) {
+				ensureImageExtension(arg)
+			}
+			filenamesIn = append(filenamesIn, arg)
+		}
+		for _, fn := range filenamesIn {
+			if nupInputFileName(fn) == filenameOut {
+				fmt.Fprintln(os.Stderr, "inFile and outFile can't be the same.")
+				os.Exit(1)
+			}
+		}
+		return filenamesIn
+	}
 
 	if hasPDFExtension(filenameIn) {
 		if len(flag.Args()) > argInd+1 {
@@ -1170,6 +1338,25 @@ func parseAfterNUpDetails(nup *model.NUp, argInd int, filenameOut string) []stri
 	return filenamesIn
 }
 
+// nupInputFileName returns the file name of an n-up input argument stripping any page selection.
+func nupInputFileName(arg string) string {
+	in, err := api.ParseNUpInput(arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "problem with page selection of %s: %v\n", arg, err)
+		os.Exit(1)
+	}
+	return in.FileName
+}
+
+func hasPDFArg(startInd int) bool {
+	for i := startInd; i < len(flag.Args()); i++ {
+		if hasPDFExtension(nupInputFileName(flag.Arg(i))) {
+			return true
+		}
+	}
+	return false
+}
+
 func processNUpCommand(conf *model.Configuration) {
 	if len(flag.Args()) < 3 {
 		fmt.Fprintf(os.Stderr, "%s\n\n", usageNUp)
@@ -1203,7 +1390,7 @@ func processNUpCommand(conf *model.Configuration) {
 	// pdfcpu nup outFile n inFile|imageFiles...
 	// If no optional 'description' argument provided use default nup configuration.
 
-	inFiles := parseAfterNUpDetails(nup, argInd, outFile)
+	inFiles := parseAfterNUpDetails(nup, argInd, outFile, true)
 	process(cli.NUpCommand(inFiles, outFile, pages, nup, conf))
 }
 
@@ -1241,7 +1428,7 @@ func processGridCommand(conf *model.Configuration) {
 	// pdfcpu grid outFile m n inFile|imageFiles...
 	// If no optional 'description' argument provided use default nup configuration.
 
-	inFiles := parseAfterNUpDetails(nup, argInd, outFile)
+	inFiles := parseAfterNUpDetails(nup, argInd, outFile, true)
 	process(cli.NUpCommand(inFiles, outFile, pages, nup, conf))
 }
 
@@ -1279,7 +1466,7 @@ func processBookletCommand(conf *model.Configuration) {
 	// pdfcpu booklet outFile n inFile|imageFiles...
 	// If no optional 'description' argument provided use default nup configuration.
 
-	inFiles := parseAfterNUpDetails(nup, argInd, outFile)
+	inFiles := parseAfterNUpDetails(nup, argInd, outFile, false)
 	n := nup.Grid.Width * nup.Grid.Height
 	if n != 2 && n != 4 {
 		fmt.Fprintf(os.Stderr, "%s\n", errInvalidBookletID)
@@ -1306,6 +1493,26 @@ func processDiplayUnit(conf *model.Configuration) {
 	}
 }
 
+func processPageStatsCommand(conf *model.Configuration) {
+	if len(flag.Args()) != 1 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usagePageStats)
+		os.Exit(1)
+	}
+
+	inFile := flag.Arg(0)
+	if conf.CheckFileNameExt {
+		ensurePDFExtension(inFile)
+	}
+
+	selectedPages, err := api.ParsePageSelection(selectedPages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "problem with flag selectedPages: %v\n", err)
+		os.Exit(1)
+	}
+
+	process(cli.PageStatsCommand(inFile, selectedPages, conf))
+}
+
 func processInfoCommand(conf *model.Configuration) {
 	if len(flag.Args()) < 1 {
 		fmt.Fprintf(os.Stderr, "%s\n\n", usageInfo)
@@ -1341,6 +1548,20 @@ func processInfoCommand(conf *model.Configuration) {
 }
 
 func processListFontsCommand(conf *model.Configuration) {
+	if len(flag.Args()) > 1 || selectedPages != "" {
+		fmt.Fprintf(os.Stderr, "usage: %s\n", usageFontsList)
+		os.Exit(1)
+	}
+
+	if len(flag.Args()) == 1 {
+		inFile := flag.Arg(0)
+		if conf.CheckFileNameExt {
+			ensurePDFExtension(inFile)
+		}
+		process(cli.ListFontInfosCommand(inFile, conf))
+		return
+	}
+
 	process(cli.ListFontsCommand(conf))
 }
 
@@ -1360,6 +1581,10 @@ func processInstallFontsCommand(conf *model.Configuration) {
 		fmt.Fprintln(os.Stderr, "Please supply a *.ttf or *.tcc fontname!")
 		os.Exit(1)
 	}
+	if axes != "" {
+		process(cli.InstallFontInstancesCommand(fileNames, axes, conf))
+		return
+	}
 	process(cli.InstallFontsCommand(fileNames, conf))
 }
 
@@ -1503,7 +1728,54 @@ func processRemovePropertiesCommand(conf *model.Configuration) {
 	process(cli.RemovePropertiesCommand(inFile, "", keys, conf))
 }
 
+func processReorderPagesCommand(conf *model.Configuration) {
+	mode = extractModeCompletion(mode, []string{"duplex", "collate"})
+
+	minArgs, pageOrder := 1, api.PageOrderDuplex
+	if mode == "collate" {
+		minArgs, pageOrder = 2, api.PageOrderCollate
+	}
+
+	if mode == "" || len(flag.Args()) < minArgs || len(flag.Args()) > minArgs+1 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageCollect)
+		os.Exit(1)
+	}
+
+	inFile := flag.Arg(0)
+	if conf.CheckFileNameExt {
+		ensurePDFExtension(inFile)
+	}
+
+	n := 0
+	if pageOrder == api.PageOrderCollate {
+		var err error
+		if n, err = strconv.Atoi(flag.Arg(1)); err != nil || n < 1 {
+			fmt.Fprintf(os.Stderr, "n must be a positive integer: %s\n", flag.Arg(1))
+			os.Exit(1)
+		}
+	}
+
+	outFile := ""
+	if len(flag.Args()) == minArgs+1 {
+		outFile = flag.Arg(minArgs)
+		ensurePDFExtension(outFile)
+	}
+
+	selectedPages, err := api.ParsePageSelection(selectedPages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "problem with flag selectedPages: %v\n", err)
+		os.Exit(1)
+	}
+
+	process(cli.ReorderPagesCommand(inFile, outFile, selectedPages, pageOrder, n, conf))
+}
+
 func processCollectCommand(conf *model.Configuration) {
+	if mode != "" {
+		processReorderPagesCommand(conf)
+		return
+	}
+
 	if len(flag.Args()) < 1 || len(flag.Args()) > 2 || selectedPages == "" {
 		fmt.Fprintf(os.Stderr, "%s\n\n", usageCollect)
 		os.Exit(1)
@@ -1982,6 +2254,26 @@ func processResetFormCommand(conf *model.Configuration) {
 	process(cli.ResetFormCommand(inFile, outFile, fieldIDs, conf))
 }
 
+func processFlattenFormCommand(conf *model.Configuration) {
+	if len(flag.Args()) == 0 || len(flag.Args()) > 2 || selectedPages != "" {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageFormFlatten)
+		os.Exit(1)
+	}
+
+	inFile := flag.Arg(0)
+	if conf.CheckFileNameExt {
+		ensurePDFExtension(inFile)
+	}
+
+	outFile := inFile
+	if len(flag.Args()) == 2 {
+		outFile = flag.Arg(1)
+		ensurePDFExtension(outFile)
+	}
+
+	process(cli.FlattenFormCommand(inFile, outFile, conf))
+}
+
 func processExportFormCommand(conf *model.Configuration) {
 	if len(flag.Args()) == 0 || len(flag.Args()) > 2 || selectedPages != "" {
 		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageFormExport)
@@ -2027,6 +2319,68 @@ func processFillFormCommand(conf *model.Configuration) {
 	process(cli.FillFormCommand(inFile, inFileJSON, outFile, conf))
 }
 
+func processRenameFormCommand(conf *model.Configuration) {
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 || selectedPages != "" {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageFormRename)
+		os.Exit(1)
+	}
+
+	inFile := flag.Arg(0)
+	if conf.CheckFileNameExt {
+		ensurePDFExtension(inFile)
+	}
+
+	inFileJSON := flag.Arg(1)
+	ensureJSONExtension(inFileJSON)
+
+	outFile := inFile
+	if len(flag.Args()) == 3 {
+		outFile = flag.Arg(2)
+		ensurePDFExtension(outFile)
+	}
+
+	process(cli.RenameFormCommand(inFile, inFileJSON, outFile, conf))
+}
+
+func processXFAFormCommand(conf *model.Configuration) {
+	if len(flag.Args()) == 0 || len(flag.Args()) > 2 || selectedPages != "" {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageFormXFA)
+		os.Exit(1)
+	}
+
+	inFile := flag.Arg(0)
+	if conf.CheckFileNameExt {
+		ensurePDFExtension(inFile)
+	}
+
+	outDir := ""
+	if len(flag.Args()) == 2 {
+		outDir = flag.Arg(1)
+	}
+
+	process(cli.XFAFormCommand(inFile, outDir, conf))
+}
+
+func processRemoveXFAFormCommand(conf *model.Configuration) {
+	if len(flag.Args()) == 0 || len(flag.Args()) > 2 || selectedPages != "" {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageFormRemoveXFA)
+		os.Exit(1)
+	}
+
+	inFile := flag.Arg(0)
+	if conf.CheckFileNameExt {
+		ensurePDFExtension(inFile)
+	}
+
+	outFile := inFile
+	if len(flag.Args()) == 2 {
+		outFile = flag.Arg(1)
+		ensurePDFExtension(outFile)
+	}
+
+	process(cli.RemoveXFAFormCommand(inFile, outFile, conf))
+}
+
 func processMultiFillFormCommand(conf *model.Configuration) {
 	if mode == "" {
 		mode = "single"
@@ -2286,7 +2640,10 @@ func processImportBookmarksCommand(conf *model.Configuration) {
 	}
 
 	inFileJSON := flag.Arg(1)
-	ensureJSONExtension(inFileJSON)
+	if !hasBookmarkExtension(inFileJSON) {
+		fmt.Fprintf(os.Stderr, "%s needs one of these extensions: \".json\", \".csv\", \".md\", \".txt\".\n", inFileJSON)
+		os.Exit(1)
+	}
 
 	outFile := ""
 	if len(flag.Args()) == 3 {
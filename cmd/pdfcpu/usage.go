@@ -26,13 +26,14 @@ Usage:
 The commands are:
 
    annotations   list, remove page annotations
-   attachments   list, add, remove, extract embedded file attachments
+   attachments   list, add, remove, extract, audit embedded file attachments
    booklet       arrange pages onto larger sheets of paper to make a booklet or zine
    bookmarks     list, import, export, remove bookmarks
    boxes         list, add, remove page boundaries for selected pages
    changeopw     change owner password
    changeupw     change user password
    collect       create custom sequence of selected pages
+   complexity    print page content metrics as JSON
    config        print configuration
    create        create PDF content including forms via JSON
    crop          set cropbox for selected pages
@@ -98,37 +99,67 @@ The validation modes are:
  strict ... validates against PDF 32000-1:2008 (PDF 1.7)
 relaxed ... (default) like strict but doesn't complain about common seen spec violations.`
 
-	usageOptimize     = "usage: pdfcpu optimize [-stats csvFile] inFile [outFile]" + generalFlags
+	usageOptimize     = "usage: pdfcpu optimize [-stats csvFile] [-report] inFile [outFile]" + generalFlags
 	usageLongOptimize = `Read inFile, remove redundant page resources like embedded fonts and images and write the result to outFile.
 
      stats ... appends a stats line to a csv file with information about the usage of root and page entries.
                useful for batch optimization and debugging PDFs.
+    report ... prints the size of images, fonts, content, metadata and structure before and after
+               along with the largest objects, useful for deciding what to target next.
     inFile ... input PDF file
    outFile ... output PDF file`
 
-	usageSplit     = "usage: pdfcpu split [-m(ode) span|bookmark] inFile outDir [span]" + generalFlags
-	usageLongSplit = `Generate a set of PDFs for the input file in outDir according to given span value or along bookmarks.
+	usageSplit     = "usage: pdfcpu split [-m(ode) span|bookmark|size|text] [-n(ame) template] inFile outDir [span|level|size|pattern]" + generalFlags
+	usageLongSplit = `Generate a set of PDFs for the input file in outDir according to given span value, along bookmarks, by size or by text.
 
       mode ... split mode (defaults to span)
+      name ... output file name template, see below
     inFile ... input PDF file
     outDir ... output directory
       span ... split span in pages (default: 1) for mode "span"
+     level ... outline level (default: 1) for mode "bookmark"
+      size ... max file size for mode "size", eg. 500KB, 10MB
+   pattern ... regular expression for mode "text", eg. "Invoice No: (\d+)"
       
 The split modes are:
 
       span     ... Split into PDF files with span pages each (default).
                    span itself defaults to 1 resulting in single page PDF files.
   
-      bookmark ... Split into PDF files representing sections defined by existing bookmarks.
-                   span will be ignored.
-                   Assumption: inFile contains an outline dictionary.`
+      bookmark ... Split into PDF files representing sections defined by existing bookmarks
+                   down to level, named after the bookmark titles.
+                   Assumption: inFile contains an outline dictionary.
+
+      size     ... Split into PDF files not exceeding size each, eg. for mail attachments.
+                   Splitting happens at page boundaries.
+
+      text     ... Split into PDF files each starting with a page whose text matches pattern.
+                   %text resolves to the match or to its first capture group.
+` + usageNameTemplate
+
+	usageNameTemplate = `
+The output file name template may contain these tokens:
+
+      %basename     ... input file name without extension
+      %d            ... first page, eg. %04d => 0007
+      %page         ... page span, eg. 7-9
+      %bookmark     ... bookmark title
+      %text         ... matched text
+      %formfield(f) ... value of form field f located on the pages of the output file
+      %bates(start) ... Bates number of the first page counting from start, eg. %06bates(1000)
+      %%            ... a literal %
+
+e.g. pdfcpu split -n "%basename_%04d" in.pdf outDir
+`
 
-	usageMerge     = "usage: pdfcpu merge [-m(ode) create|append] [-s(ort) -b(ookmarks)] outFile inFile..." + generalFlags
+	usageMerge     = "usage: pdfcpu merge [-m(ode) create|append] [-s(ort) -b(ookmarks) -d(ivider) -toc] outFile inFile..." + generalFlags
 	usageLongMerge = `Concatenate a sequence of PDFs/inFiles into outFile.
 
       mode ... merge mode (defaults to create)
       sort ... sort inFiles by file name
  bookmarks ... create bookmarks
+   divider ... insert a divider page showing the file name in front of each merged file
+       toc ... insert a table of contents page linking to each merged file (requires bookmarks)
    outFile ... output PDF file
     inFile ... a list of PDF files subject to concatenation.
     
@@ -161,11 +192,14 @@ Skip bookmark creation like so: -bookmarks=false`
 
         e.g. -3,5,7- or 4-7,!6 or 1-,!5 or odd,n1`
 
-	usageExtract     = "usage: pdfcpu extract -m(ode) i(mage)|f(ont)|c(ontent)|p(age)|m(eta) [-p(ages) selectedPages] inFile outDir" + generalFlags
-	usageLongExtract = `Export inFile's images, fonts, content or pages into outDir.
+	usageExtract     = "usage: pdfcpu extract -m(ode) i(mage)|f(ont)|c(ontent)|p(age)|m(eta)|t(ext) [-p(ages) selectedPages] [-n(ame) template] [-heal] inFile outDir" + generalFlags
+	usageLongExtract = `Export inFile's images, fonts, content, text or pages into outDir.
 
       mode ... extraction mode
      pages ... Please refer to "pdfcpu selectedpages"
+      name ... output file name template for mode page, please refer to "pdfcpu help split"
+      heal ... for mode text: rejoin words hyphenated across line breaks,
+               merge strings split by kerning and assemble lines into paragraphs
     inFile ... input PDF file
     outDir ... output directory
 
@@ -176,6 +210,7 @@ Skip bookmark creation like so: -bookmarks=false`
 content ... extract raw page content
    page ... extract single page PDFs
    meta ... extract all metadata (page selection does not apply)
+   text ... extract page text
    
 `
 
@@ -191,20 +226,26 @@ content ... extract raw page content
 	usageAttachList    = "pdfcpu attachments list    inFile"
 	usageAttachAdd     = "pdfcpu attachments add     inFile file..."
 	usageAttachRemove  = "pdfcpu attachments remove  inFile [file...]"
-	usageAttachExtract = "pdfcpu attachments extract inFile outDir [file...]" + generalFlags
+	usageAttachExtract = "pdfcpu attachments extract inFile outDir [file...]"
+	usageAttachAudit   = "pdfcpu attachments audit   [-j(son)] inFile" + generalFlags
 
 	usageAttach = "usage: " + usageAttachList +
 		"\n       " + usageAttachAdd +
 		"\n       " + usageAttachRemove +
-		"\n       " + usageAttachExtract
+		"\n       " + usageAttachExtract +
+		"\n       " + usageAttachAudit
 
 	usageLongAttach = `Manage embedded file attachments.
 
     inFile ... input PDF file
       file ... attachment
     outDir ... output directory
+      json ... produce JSON output
     
     Remove all attachments: pdfcpu attach remove test.pdf
+
+    Audit reports size, SHA-256, declared MIME type and encryption status of each attachment
+    without extracting it.
     `
 
 	usagePortfolioList    = "pdfcpu portfolio list    inFile"
@@ -273,15 +314,20 @@ content ... extract raw page content
     opwOld ... old owner password (provide user password on initial changeopw)
     opwNew ... new owner password`
 
-	usageStampMode = `There are 3 different kinds of stamps:
+	usageStampMode = `There are 5 different kinds of stamps:
 
    1) text based:
       -mode text string			
          eg. pdfcpu stamp add -mode text -- "Hello gopher!" "" in.pdf out.pdf
          Use the following format strings:
-               %p ... current page number
-               %P ... total pages
+               %p, %PageNumber ... current page number
+               %P, %PageCount  ... total pages
+               %t, %Now        ... timestamp, see timestamp, locale and timezone below
+               %Filename       ... input file name
+               %Info(key)      ... info dict entry like Title, Author or a custom property
          eg. pdfcpu stamp add -mode text -- "Page %p of %P" "sc:1.0 abs, pos:bc, rot:0" in.pdf out.pdf
+         eg. pdfcpu stamp add -mode text -- "%t" "timestamp:Monday\, 2 January 2006 15:04, locale:de, timezone:Europe/Vienna" in.pdf out.pdf
+         eg. pdfcpu stamp add -mode text -- "%Info(Title) - %Filename" "pos:tc, rot:0" in.pdf out.pdf
    
    2) image based
       -mode image imageFileName
@@ -293,17 +339,36 @@ content ... extract raw page content
          eg. pdfcpu stamp add -mode pdf -- "stamp.pdf:3" "" in.pdf out.pdf ... stamp each page of in.pdf with page 3 of stamp.pdf
          Omit page# for multistamping:
          eg. pdfcpu stamp add -mode pdf -- "stamp.pdf" "" in.pdf out.pdf   ... stamp each page of in.pdf with corresponding page of stamp.pdf
+
+   4) shape based
+      -mode shape shape
+         line dx dy      ... line from (0,0) to (dx,dy) eg. a rule line
+         rect w h        ... rectangle
+         roundrect w h r ... rectangle with rounded corners of radius r
+         ellipse w h     ... ellipse
+         Shapes are drawn true to size (sc:1 abs) without rotation unless configured otherwise.
+         eg. pdfcpu stamp add -mode shape -- "roundrect 300 120 10" "pos:tc, off:0 -50, mode:2, linew:2, fillc:#FFFFCC" in.pdf out.pdf
+
+   5) SVG based
+      -mode svg svgFileName
+         Paths, basic shapes, text, fills, strokes, opacity and transforms are converted into PDF vector graphics.
+         eg. pdfcpu stamp add -mode svg -- "logo.svg" "sc:.3, pos:tr, rot:0" in.pdf out.pdf
    `
 
-	usageWatermarkMode = `There are 3 different kinds of watermarks:
+	usageWatermarkMode = `There are 5 different kinds of watermarks:
 
    1) text based:
       -mode text string			
          eg. pdfcpu watermark add -mode text -- "Hello gopher!" "" in.pdf out.pdf
          Use the following format strings:
-               %p ... current page number
-               %P ... total pages
+               %p, %PageNumber ... current page number
+               %P, %PageCount  ... total pages
+               %t, %Now        ... timestamp, see timestamp, locale and timezone below
+               %Filename       ... input file name
+               %Info(key)      ... info dict entry like Title, Author or a custom property
          eg. pdfcpu watermark add -mode text -- "Page %p of %P" "sc:1.0 abs, pos:bc, rot:0" in.pdf out.pdf
+         eg. pdfcpu watermark add -mode text -- "%t" "timestamp:Monday\, 2 January 2006 15:04, locale:de, timezone:Europe/Vienna" in.pdf out.pdf
+         eg. pdfcpu watermark add -mode text -- "%Info(Title) - %Filename" "pos:tc, rot:0" in.pdf out.pdf
    
    2) image based
       -mode image imageFileName
@@ -316,6 +381,20 @@ content ... extract raw page content
          Omit page# for multistamping:
          eg. pdfcpu watermark add -mode pdf -- "stamp.pdf" "" in.pdf out.pdf   ... watermark each page of in.pdf with corresponding page of stamp.pdf
 
+   4) shape based
+      -mode shape shape
+         line dx dy      ... line from (0,0) to (dx,dy) eg. a rule line
+         rect w h        ... rectangle
+         roundrect w h r ... rectangle with rounded corners of radius r
+         ellipse w h     ... ellipse
+         Shapes are drawn true to size (sc:1 abs) without rotation unless configured otherwise.
+         eg. pdfcpu watermark add -mode shape -- "rect 500 700" "mode:1, linew:3, strokec:#0000FF" in.pdf out.pdf
+
+   5) SVG based
+      -mode svg svgFileName
+         Paths, basic shapes, text, fills, strokes, opacity and transforms are converted into PDF vector graphics.
+         eg. pdfcpu watermark add -mode svg -- "logo.svg" "sc:.3, pos:tr, rot:0" in.pdf out.pdf
+
 
    A watermark is the first content that gets rendered for a page.
    The visibility of the watermark depends on the transparency of all layers rendered on top.
@@ -339,15 +418,21 @@ content ... extract raw page content
                            bl|bottom-left  bc|bottom-center   br|bottom-right
 
    offset:           (dx dy) in given display unit eg. '15 20'
+                     or relative to the anchor box eg. '5% -2%'
+
+   pagebox:          anchor box for position and offset, one of media, crop (default), trim, bleed, art
+                     eg. "pos:br, off:-5% 5%, pagebox:trim" keeps a stamp inside the trim box of documents with asymmetric boxes.
    
    scalefactor:      0.0 < i <= 1.0 {r|rel} | 0.0 < i {a|abs}
                     
    aligntext:        l|left, c|center, r|right, j|justified (for text watermarks only)
 
-   fillcolor:        color value to be used when rendering text, see also rendermode
+   fillcolor:        color value to be used when rendering text or shapes, see also rendermode
                      for backwards compatibility "color" is also accepted.
    
-   strokecolor:      color value to be used when rendering text, see also rendermode
+   strokecolor:      color value to be used when rendering text or shapes, see also rendermode
+
+   linewidth:        line width for stroking shapes in given display unit > 0 (for shape watermarks only)
    
    backgroundcolor:  color value for visualization of the bounding box background for text.
                      "bgcolor" is also accepted. 
@@ -361,6 +446,12 @@ content ... extract raw page content
    
    opacity:          where 0.0 <= x <= 1.0
 
+   blendmode:        Normal, Multiply, Screen, Overlay, Darken, Lighten, ColorDodge, ColorBurn,
+                     HardLight, SoftLight, Difference, Exclusion, Hue, Saturation, Color, Luminosity
+                     eg. Multiply lets a stamp dim the underlying content instead of covering it.
+
+   group:            paint as transparency group: any of isolated knockout, or none
+
    mode, rendermode: 0 ... fill (applies fill color)
                      1 ... stroke (applies stroke color)
                      2 ... fill & stroke (applies both fill and stroke colors)
@@ -382,6 +473,22 @@ content ... extract raw page content
 
    url:              Add link annotation for stamps only (omit https://)
 
+   name:             Identify this watermark for selective removal and update, see "remove -name"
+                     (defaults to watermark or stamp)
+
+   stencil:          Render a 1-bit image as stencil mask painted in fill color (on/off, true/false, t/f)
+                     White and transparent pixels leave the page untouched (for image watermarks only)
+
+   timestamp:        layout for %t based on the reference time "Mon Jan 2 15:04:05 MST 2006",
+                     eg. "2 January 2006 15:04", escape commas: "Monday\, 2 January 2006"
+                     (defaults to timestampFormat of your config file)
+
+   locale:           language of month and day names for %t: en, de, es, fr, it, nl, pt, sv
+                     (defaults to timestampLocale of your config file)
+
+   timezone:         IANA time zone name for %t eg. Europe/Vienna, UTC
+                     (defaults to timestampTimeZone of your config file or local time)
+
 A color value: 3 color intensities, where 0.0 < i < 1.0, eg 1.0, 
                or the hex RGB value: #RRGGBB, eg #FF0000 = red
 
@@ -390,13 +497,15 @@ All configuration string parameters support completion.
 e.g. "pos:bl, off: 20 5"   "rot:45"                 "op:0.5, sc:0.5 abs, rot:0"
      "d:2"                 "sc:.75 abs, points:48"  "rot:-90, scale:0.75 rel"
      "f:Courier, sc:0.75, str: 0.5 0.0 0.0, rot:20"
+     "op:.8, blend:Multiply, group:isolated"
+     "name:draft, rot:45"
 
 
 `
 
-	usageStampAdd    = "pdfcpu stamp add    [-p(ages) selectedPages] -m(ode) text|image|pdf -- string|file description inFile [outFile]"
-	usageStampUpdate = "pdfcpu stamp update [-p(ages) selectedPages] -m(ode) text|image|pdf -- string|file description inFile [outFile]"
-	usageStampRemove = "pdfcpu stamp remove [-p(ages) selectedPages] inFile [outFile]" + generalFlags
+	usageStampAdd    = "pdfcpu stamp add    [-p(ages) selectedPages] -m(ode) text|image|pdf|shape|svg -- string|file|shape description inFile [outFile]"
+	usageStampUpdate = "pdfcpu stamp update [-p(ages) selectedPages] -m(ode) text|image|pdf|shape|svg -- string|file|shape description inFile [outFile]"
+	usageStampRemove = "pdfcpu stamp remove [-p(ages) selectedPages] [-n(ame) name | -region rect [-fill]] inFile [outFile]" + generalFlags
 
 	usageStamp = "usage: " + usageStampAdd +
 		"\n       " + usageStampUpdate +
@@ -407,19 +516,24 @@ e.g. "pos:bl, off: 20 5"   "rot:45"                 "op:0.5, sc:0.5 abs, rot:0"
       pages ... Please refer to "pdfcpu selectedpages"
         upw ... user password
         opw ... owner password
-       mode ... text, image, PDF
+       mode ... text, image, PDF, shape
      string ... display string for text based watermarks
        file ... image or PDF file
+      shape ... vector primitive for shape based watermarks
 description ... fontname, points, position, offset, scalefactor, aligntext, rotation, 
-                diagonal, opacity, rendermode, strokecolor, fillcolor, bgcolor, margins, border
+                diagonal, opacity, blendmode, group, rendermode, strokecolor, fillcolor, linewidth, bgcolor,
+                margins, border, timestamp, locale, timezone, pagebox, name
+       name ... remove only the watermark added with this name
+     region ... remove all content intersecting this rectangle, eg. "[0 742 595 842]"
+       fill ... paint region white after removal
      inFile ... input PDF file
     outFile ... output PDF file
 
 ` + usageStampMode + usageWMDescription
 
-	usageWatermarkAdd    = "pdfcpu watermark add    [-p(ages) selectedPages] -m(ode) text|image|pdf -- string|file description inFile [outFile]"
-	usageWatermarkUpdate = "pdfcpu watermark update [-p(ages) selectedPages] -m(ode) text|image|pdf -- string|file description inFile [outFile]"
-	usageWatermarkRemove = "pdfcpu watermark remove [-p(ages) selectedPages] inFile [outFile]" + generalFlags
+	usageWatermarkAdd    = "pdfcpu watermark add    [-p(ages) selectedPages] -m(ode) text|image|pdf|shape|svg -- string|file|shape description inFile [outFile]"
+	usageWatermarkUpdate = "pdfcpu watermark update [-p(ages) selectedPages] -m(ode) text|image|pdf|shape|svg -- string|file|shape description inFile [outFile]"
+	usageWatermarkRemove = "pdfcpu watermark remove [-p(ages) selectedPages] [-n(ame) name | -region rect [-fill]] inFile [outFile]" + generalFlags
 
 	usageWatermark = "usage: " + usageWatermarkAdd +
 		"\n       " + usageWatermarkUpdate +
@@ -428,11 +542,16 @@ description ... fontname, points, position, offset, scalefactor, aligntext, rota
 	usageLongWatermark = `Process watermarking for selected pages. 
 
       pages ... Please refer to "pdfcpu selectedpages"
-       mode ... text, image, PDF
+       mode ... text, image, PDF, shape
      string ... display string for text based watermarks
        file ... image or PDF file
+      shape ... vector primitive for shape based watermarks
 description ... fontname, points, position, offset, scalefactor, aligntext, rotation,
-                diagonal, opacity, rendermode, strokecolor, fillcolor, bgcolor, margins, border
+                diagonal, opacity, blendmode, group, rendermode, strokecolor, fillcolor, linewidth, bgcolor,
+                margins, border, timestamp, locale, timezone, pagebox, name
+       name ... remove only the watermark added with this name
+     region ... remove all content intersecting this rectangle, eg. "[0 742 595 842]"
+       fill ... paint region white after removal
      inFile ... input PDF file
     outFile ... output PDF file
 
@@ -442,6 +561,7 @@ description ... fontname, points, position, offset, scalefactor, aligntext, rota
 	usageLongImportImages = `Turn image files into a PDF page sequence and write the result to outFile.
 If outFile already exists the page sequence will be appended.
 Each imageFile will be rendered to a separate page.
+Images are processed and written one at a time which keeps memory usage low even for large directories: "pdfcpu import photos.pdf photos/*.jpg"
 In its simplest form this converts an image into a PDF: "pdfcpu import img.pdf img.jpg"
 
 description ... dimensions, format, position, offset, scale factor, boxes
@@ -491,17 +611,22 @@ description ... dimensions, format, position, offset, scale factor, boxes
        "f:A4, pos:c, dpi:300"                     ... render the image centered on A4 respecting a destination resolution of 300 dpi.
        `
 
-	usagePagesInsert = "pdfcpu pages insert [-p(ages) selectedPages] [-m(ode) before|after] inFile [outFile]"
-	usagePagesRemove = "pdfcpu pages remove  -p(ages) selectedPages  inFile [outFile]" + generalFlags
+	usagePagesInsert = "pdfcpu pages insert [-p(ages) selectedPages] [-m(ode) before|after|recto] inFile [outFile]"
+	usagePagesRemove = "pdfcpu pages remove  -p(ages) selectedPages  inFile [outFile]"
+	usagePagesSplice = "pdfcpu pages splice [-p(ages) selectedPages] inFile afterPage srcFile" + generalFlags
 
 	usagePages = "usage: " + usagePagesInsert +
-		"\n       " + usagePagesRemove
+		"\n       " + usagePagesRemove +
+		"\n       " + usagePagesSplice
 
 	usageLongPages = `Manage pages.
 
-      pages ... Please refer to "pdfcpu selectedpages"
-       mode ... before, after (default: before)
+      pages ... Please refer to "pdfcpu selectedpages", for splice the pages of srcFile
+       mode ... before, after, recto (default: before)
+                recto inserts only where needed for selected pages to start on an odd page
      inFile ... input PDF file
+  afterPage ... insert the pages of srcFile following this page of inFile, 0 inserts in front
+    srcFile ... PDF file providing the pages to be inserted into inFile
     outFile ... output PDF file
 
 `
@@ -516,17 +641,23 @@ description ... dimensions, format, position, offset, scale factor, boxes
 
 `
 
-	usageNUp     = "usage: pdfcpu nup [-p(ages) selectedPages] -- [description] outFile n inFile|imageFiles..." + generalFlags
+	usageNUp     = "usage: pdfcpu nup [-p(ages) selectedPages] -- [description] outFile n inFile|imageFiles|inFiles..." + generalFlags
 	usageLongNUp = `Rearrange existing PDF pages or images into a sequence of page grids.
 This reduces the number of pages and therefore the required print time.
 If the input is one imageFile a single page n-up PDF gets generated.
+Links to external targets and form fields of the input pages are preserved.
+JPEG images are rendered according to their Exif orientation along with their embedded ICC profile.
+A mix of PDF and image files gets rendered at a common scale preserving the natural size of all sources.
 
-      pages ... inFile only, please refer to "pdfcpu selectedpages"
+      pages ... PDF files only, please refer to "pdfcpu selectedpages"
 description ... dimensions, format, orientation
     outFile ... output PDF file
           n ... the n-Up value (see below for details)
      inFile ... input PDF file
  imageFiles ... input image file(s)
+    inFiles ... input PDF and image files
+
+Each PDF inFile may come with its own page selection overriding pages, eg. pdfcpu nup out.pdf 4 a.pdf:1-3 b.pdf:even
 
                               portrait landscape
  Supported values for n: 2 ...  1x2       2x1
@@ -554,9 +685,43 @@ description ... dimensions, format, orientation
                             dr ... down right
                             ld ... left down
                             dl ... down left
+                            ru ... right up
+                            ur ... up right
+                            lu ... left up
+                            ul ... up left
                      Orientation applies to PDF input files only.
+    fillorder:       shortcut for common orientations:
+                            rowmajor      ... rd
+                            columnmajor   ... dr
+                            righttoleft   ... ld (eg. for right-to-left languages)
+                            bottomup      ... ru
+                            boustrophedon ... rd, every other row right to left
+    snake:           reverse the direction of every other row or column (on/off, true/false, t/f)
+    rotation:        auto ... rotate sources into the orientation of their cell (=default)
+                     off  ... preserve the orientation of sources
+    autoorient:      choose the sheet orientation per sheet from the aspect ratios of its pages (on/off, true/false, t/f)
+                     Turns the sheet and its grid whenever most pages would need rotation otherwise.
+    alignment:       alignment of sources within their cell:
+                     one of tl,tc,tr,l,c,r,bl,bc,br (default: c)
+    cutandstack:     order pages for cut and stack (on/off, true/false, t/f)
+                     After cutting the sheets stack the piles in cell order.
+    repeat:          fill each sheet with copies of one page eg. for labels and business cards (on/off, true/false, t/f)
+    uniform:         render all pages at a common scale preserving their relative sizes (on/off, true/false, t/f)
+                     Always on for a mix of PDF and image files.
+    cropmarks:       draw crop marks into the margin (on/off, true/false, t/f)
+    regmarks:        draw registration marks into the margin (on/off, true/false, t/f)
+    trimguides:      draw cut lines along the trim edges of all cells across gutters and margins (on/off, true/false, t/f)
+    bleed:           extend content beyond its trim box: float >= 0 in given display unit, must not exceed margin
     border:          Print border (on/off, true/false, t/f) 
     margin:          for n-up content: float >= 0 in given display unit
+    sheetmargin:     outer sheet margin surrounding the grid: floats >= 0 in given display unit
+                     i       ... set all four margins
+                     i j     ... set top/bottom margins to i, left/right margins to j
+                     i j k   ... set top margin to i, left/right margins to j, bottom margin to k
+                     i j k l ... set top, right, bottom, left margins
+    spacing:         gutter between adjacent grid cells: floats >= 0 in given display unit
+                     i       ... set gutter between rows and columns
+                     i j     ... set gutter between rows to i, gutter between columns to j
     backgroundcolor: backgound color for margin > 0.
                      "bgcolor" is also accepted.
 
@@ -578,6 +743,34 @@ Examples: pdfcpu nup out.pdf 4 in.pdf
            Rearrange all jpg files into 2x2 grids and write result to out.pdf using the Tabloid format
            and the default orientation.
 
+          pdfcpu nup -- "fillorder:rtl" out.pdf 4 in.pdf
+           Rearrange pages of in.pdf into 2x2 grids filled from right to left.
+
+          pdfcpu nup -- "rotation:off, alignment:tl" out.pdf 4 in.pdf
+           Rearrange pages of in.pdf into 2x2 grids preserving their orientation,
+           aligning each page with the upper left corner of its cell.
+
+          pdfcpu nup -- "autoorient:on" out.pdf 2 slides.pdf
+           Rearrange pages of slides.pdf 2 per sheet using portrait sheets for landscape pages
+           and landscape sheets for portrait pages.
+
+          pdfcpu nup -- "cutandstack:on" out.pdf 4 in.pdf
+           Rearrange pages of in.pdf into 2x2 grids for cut and stack.
+           For 200 pages sheet 1 holds the pages 1, 51, 101 and 151.
+
+          pdfcpu nup -- "repeat:on, border:off, margin:10, bleed:3, cropmarks:on, regmarks:on" out.pdf 8 card.pdf
+           Step and repeat each page of card.pdf 8 times per sheet including crop marks, registration marks and bleed.
+
+          pdfcpu nup -u mm -- "repeat:on, border:off, margin:0, sheetmargin:12, spacing:4 6, trimguides:on" out.pdf 10 ticket.pdf
+           Step and repeat each page of ticket.pdf 10 times per sheet using 4mm gutters between rows, 6mm gutters between columns
+           and cut lines along the trim edges of each ticket.
+
+          pdfcpu nup -u mm -- "border:off, margin:0, sheetmargin:20 15, spacing:3" out.pdf 12 *.jpg
+           Rearrange all jpg files into contact sheets using 3mm gutters and outer margins of 20mm (top/bottom) and 15mm (left/right).
+
+          pdfcpu nup out.pdf 4 report.pdf scan.jpg
+           Rearrange the pages of report.pdf and scan.jpg into 2x2 grids at a common scale.
+
 `
 
 	usageBooklet     = "usage: pdfcpu booklet [-p(ages) selectedPages] -- [description] outFile n inFile|imageFiles..." + generalFlags
@@ -601,6 +794,11 @@ You bind your paper in eight sheet folios each making up 32 pages of your book.
 Each sheet is going to make four pages of your book, gets printed on both sides and folded in half.
 For such a multi folio booklet set 'multifolio:on' and play around with 'foliosize' which defaults to 8.
 
+Print shops producing saddle-stitched jobs specify the signature size in pages instead, eg. 'signature:16'.
+Since the inner sheets of a folded signature push out, use 'creep' to shift the pages of the innermost sheet
+toward the fold, the pages of the sheets in between get shifted proportionally.
+Use 'bindingshift' to shift all pages away from the fold making room for binding.
+
 n=4: Four of your pages fit on one side of a sheet (eg statement on ledger, A5 on A3, A6 on A4)
 Assemble by printing on both sides, then cutting the sheets horizontally.
 The sets of pages on the bottom of the sheet are rotated so that the cut side of the
@@ -624,6 +822,10 @@ set of pages after the top set of pages in the booklet. Then fold the half sheet
                      "papersize" is also accepted.
    multifolio:       Generate multi folio booklet (on/off, true/false, t/f) for n=2 and PDF input only.
    foliosize:        folio size for multi folio booklets only (default:8)
+   signature:        signature size in pages, a multiple of 4 for n=2 or 8 for n=4, implies multifolio:on
+   creep:            creep compensation for the innermost sheet of a signature (float >= 0 in given display unit)
+   bindingshift:     shift of all pages away from the fold (float >= 0 in given display unit)
+   autoorient:       choose the sheet orientation per signature from the aspect ratios of its pages (on/off, true/false, t/f)
    border:           Print border (on/off, true/false, t/f) 
    guides:           Print folding and cutting lines (on/off, true/false, t/f)
    margin:           Apply content margin (float >= 0 in given display unit)
@@ -644,13 +846,21 @@ Examples: pdfcpu booklet -- "formsize:Letter" out.pdf 2 in.pdf
           pdfcpu booklet -- "formsize:A4, multifolio:on" hardbackbook.pdf 2 in.pdf
            Arrange pages of in.pdf 2 per sheetside as sequence of folios covering 4*foliosize pages each.
            See also: https://www.instructables.com/How-to-bind-your-own-Hardback-Book/
+
+          pdfcpu booklet -- "formsize:A4, signature:16, creep:2, bindingshift:8" saddlestitch.pdf 2 in.pdf
+           Arrange pages of in.pdf 2 per sheetside as sequence of 16 page signatures compensating for creep.
+
+          pdfcpu booklet -- "formsize:A4, signature:8, autoorient:on" mixed.pdf 2 in.pdf
+           Arrange pages of in.pdf 2 per sheetside as sequence of 8 page signatures
+           turning the sheets of each signature to match the orientation of its pages.
 `
 
-	usageGrid     = "usage: pdfcpu grid [-p(ages) selectedPages] -- [description] outFile m n inFile|imageFiles..." + generalFlags
+	usageGrid     = "usage: pdfcpu grid [-p(ages) selectedPages] -- [description] outFile m n inFile|imageFiles|inFiles..." + generalFlags
 	usageLongGrid = `Rearrange PDF pages or images for enhanced browsing experience.
 For a PDF inputfile each output page represents a grid of input pages.
 For image inputfiles each output page shows all images laid out onto grids of given paper size. 
 This command produces poster like PDF pages convenient for page and image browsing. 
+JPEG images are rendered according to their Exif orientation along with their embedded ICC profile.
 
       pages ... Please refer to "pdfcpu selectedpages"
 description ... dimensions, format, orientation
@@ -659,6 +869,9 @@ description ... dimensions, format, orientation
           n ... grid columns
      inFile ... input PDF file
  imageFiles ... input image file(s)
+    inFiles ... input PDF and image files rendered at a common scale
+
+Each PDF inFile may come with its own page selection overriding pages, eg. pdfcpu grid out.pdf 2 2 a.pdf:1-3 b.pdf:even
 
     <description> is a comma separated configuration string containing:
 
@@ -680,6 +893,15 @@ description ... dimensions, format, orientation
                   Orientation applies to PDF input files only.
     border:       Print border (on/off, true/false, t/f) 
     margin:       Apply content margin (float >= 0 in given display unit)
+    sheetmargin:  Outer sheet margin surrounding the grid (floats >= 0 in given display unit)
+                  i       ... set all four margins
+                  i j     ... set top/bottom margins to i, left/right margins to j
+                  i j k   ... set top margin to i, left/right margins to j, bottom margin to k
+                  i j k l ... set top, right, bottom, left margins
+    spacing:      Gutter between adjacent grid cells (floats >= 0 in given display unit)
+                  i       ... set gutter between rows and columns
+                  i j     ... set gutter between rows to i, gutter between columns to j
+    trimguides:   Draw cut lines along the trim edges of all cells across gutters and margins (on/off, true/false, t/f)
 
 All configuration string parameters support completion.
 
@@ -698,6 +920,9 @@ Examples: pdfcpu grid out.pdf 1 10 in.pdf
           pdfcpu grid -- "d:400 400" out.pdf 8 6 *.jpg
            Arrange imagefiles onto a 8x6 page grid and write result to out.pdf using a grid cell size of 400x400.
 
+          pdfcpu grid -u mm -- "d:50 50, bo:off, ma:0, sheetm:15, spacing:2" out.pdf 5 4 *.jpg
+           Create contact sheets using tight 2mm gutters between images and a generous 15mm outer margin.
+
 `
 
 	paperSizes = `This is a list of predefined paper sizes:
@@ -780,16 +1005,41 @@ Examples: pdfcpu grid out.pdf 1 10 in.pdf
     json ... Produce JSON output
   inFile ... a list of PDF input files`
 
-	usageFontsList       = "pdfcpu fonts list"
-	usageFontsInstall    = "pdfcpu fonts install fontFiles..."
+	usagePageStats     = "usage: pdfcpu complexity [-p(ages) selectedPages] inFile" + generalFlags
+	usageLongPageStats = `Print page content metrics as JSON for estimating render cost.
+
+   pages ... Please refer to "pdfcpu selectedpages"
+  inFile ... PDF input file
+
+For each page the following metrics are reported:
+
+  contentBytes ... size of the decoded content including form XObjects
+     operators ... number of content stream operators, also by operator
+  pathSegments ... number of path construction segments
+  paintedPaths ... number of path painting operations
+      textRuns ... number of text showing operations
+        images ... number of painted images, their samples and area in square points
+         forms ... number of painted form XObjects
+      shadings ... number of painted shadings`
+
+	usageFontsList       = "pdfcpu fonts list [inFile]"
+	usageFontsInstall    = "pdfcpu fonts install [-axes coords] fontFiles..."
 	usageFontsCheatSheet = "pdfcpu fonts cheatsheet fontFiles..."
 
 	usageFonts = "usage: " + usageFontsList +
 		"\n       " + usageFontsInstall +
 		"\n       " + usageFontsCheatSheet
-	usageLongFonts = `Print a list of supported fonts (includes the 14 PDF core fonts).
+	usageLongFonts = `Print a list of supported fonts (includes the 14 PDF core fonts)
+or the fonts embedded in inFile including their license (embedding permissions as of OS/2 fsType).
 Install given True Type fonts(.ttf) or True Type collections(.ttc) for usage in stamps/watermarks.
-Create single page PDF cheat sheets in current dir.`
+Create single page PDF cheat sheets in current dir.
+
+    inFile ... input PDF file
+    coords ... axis coordinates for installing a static instance of a variable font, eg. "wght:700, wdth:75"
+               The instance is named after its coordinates, eg. RobotoFlex-Regular_700wght_75wdth
+
+The config entry fontLicensePolicy (warn, ignore, refuse) controls embedding installed fonts
+whose license forbids embedding or subsetting.`
 
 	usageKeywordsList   = "pdfcpu keywords list    inFile"
 	usageKeywordsAdd    = "pdfcpu keywords add     inFile keyword..."
@@ -830,13 +1080,21 @@ nameValuePair ... 'name = value'
 
          remove all properties: pdfcpu properties remove test.pdf
      `
-	usageCollect     = "usage: pdfcpu collect -p(ages) selectedPages inFile [outFile]" + generalFlags
+	usageCollect = "usage: pdfcpu collect -p(ages) selectedPages inFile [outFile]" +
+		"\n       pdfcpu collect -m(ode) duplex [-p(ages) selectedPages] inFile [outFile]" +
+		"\n       pdfcpu collect -m(ode) collate [-p(ages) selectedPages] inFile n [outFile]" + generalFlags
 	usageLongCollect = `Create custom sequence of selected pages. 
 
         pages ... Please refer to "pdfcpu selectedpages"
+         mode ... duplex:  odd pages followed by even pages in reverse order for manual duplex printing
+                  collate: n complete copies of the selected pages, one after the other
        inFile ... input PDF file
+            n ... number of copies
       outFile ... output PDF file
   
+  Eg. pdfcpu collect -m duplex in.pdf out.pdf
+      pdfcpu collect -m collate -p 1-4 in.pdf 3 out.pdf
+  
   `
 
 	usageBoxDescription = `
@@ -1024,6 +1282,10 @@ For more info on json syntax & samples please refer to :
 	usageFormExport       = "pdfcpu form export inFile [outFileJSON]"
 	usageFormFill         = "pdfcpu form fill inFile inFileJSON [outFile]"
 	usageFormMultiFill    = "pdfcpu form multifill [-m(ode) single|merge] inFile inFileData outDir [outName]"
+	usageFormFlatten      = "pdfcpu form flatten inFile [outFile]"
+	usageFormRename       = "pdfcpu form rename inFile inFileJSON [outFile]"
+	usageFormXFA          = "pdfcpu form xfa inFile [outDir]"
+	usageFormRemoveXFA    = "pdfcpu form removexfa inFile [outFile]"
 
 	usageForm = "usage: " + usageFormListFields +
 		"\n       " + usageFormRemoveFields +
@@ -1032,7 +1294,11 @@ For more info on json syntax & samples please refer to :
 		"\n       " + usageFormReset +
 		"\n       " + usageFormExport +
 		"\n\n       " + usageFormFill +
-		"\n       " + usageFormMultiFill + generalFlags
+		"\n       " + usageFormMultiFill +
+		"\n\n       " + usageFormFlatten +
+		"\n       " + usageFormRename +
+		"\n\n       " + usageFormXFA +
+		"\n       " + usageFormRemoveXFA + generalFlags
 
 	usageLongForm = `Manage PDF forms.
 
@@ -1086,6 +1352,9 @@ Supported usecases:
          a) Export your form into in.json and edit the field values.
          b) Optionally trim down each field to id or name and value(s).
          c) "pdfcpu form fill in.pdf in.json out.pdf" fills in.pdf with form data from in.json and writes the result to out.pdf.
+            Radio buttons, comboboxes and listboxes accept option export values as well as display texts.
+            Push buttons get a new icon by adding {"id": "...", "image": "logo.png"} to "pushbutton".
+            Icon images are located relative to the directory of in.json.
 
    or
 
@@ -1113,6 +1382,20 @@ Supported usecases:
             The first line identifies fields via id or name in in.json.
          c) "pdfcpu form multifill -m merge in.pdf in.csv outDir" creates a single output PDF in outDir.
 
+   10) Flatten a form before archiving:
+         "pdfcpu form flatten in.pdf out.pdf" burns all field values into the page content and removes the form.
+         Missing field appearances get generated.
+
+   11) Rename form fields eg. before merging or importing into a CRM:
+         "pdfcpu form rename in.pdf names.json out.pdf" renames fields as mapped by names.json, eg. {"firstName": "contact_first_name"}.
+         Fields are identified by id or fully qualified name. JavaScript referring to renamed fields gets updated.
+
+   12) Neutralize XFA forms eg. for archives rejecting them:
+         "pdfcpu form xfa in.pdf" reports whether in.pdf contains an XFA form and lists its XDP packets.
+         "pdfcpu form xfa in.pdf outDir" extracts the XDP packets (template, datasets, ..) into outDir.
+         "pdfcpu form removexfa in.pdf out.pdf" drops the XFA form so that viewers use the static AcroForm fallback.
+         Dynamic XFA forms usually lack a meaningful AcroForm fallback.
+
 
    (For syntax and details please refer to pdfcpu/pkg/api/test/form_test.go)`
 
@@ -1306,7 +1589,7 @@ description ... scalefactor, dimensions, formsize, enforce, border, bgcolor
    See also the related commands: poster, ndown`
 
 	usageBookmarksList   = "pdfcpu bookmarks list   inFile"
-	usageBookmarksImport = "pdfcpu bookmarks import [-r(eplace)] inFile inFileJSON [outFile]"
+	usageBookmarksImport = "pdfcpu bookmarks import [-r(eplace)] inFile inFileJSON|inFileCSV|inFileText [outFile]"
 	usageBookmarksExport = "pdfcpu bookmarks export inFile [outFileJSON]"
 	usageBookmarksRemove = "pdfcpu bookmarks remove inFile [outFile]"
 
@@ -1319,7 +1602,26 @@ description ... scalefactor, dimensions, formsize, enforce, border, bgcolor
 
       inFile      ... input PDF file
       inFileJSON  ... input JSON file
+      inFileCSV   ... input CSV file (.csv)
+      inFileText  ... input indented text or Markdown file (.md, .txt)
       outFile     ... output PDF file
       outFileJSON ... output PDF file
+
+   CSV records: level, title, page{, style{, color}}
+      level ... outline level starting at 1
+      style ... any combination of bold and italic
+      color ... 3 color intensities or #RRGGBB
+      eg. level,title,page,style
+          1,Part 1,1,bold
+          2,"Chapter 1, Introduction",2
+
+   Text: one bookmark per line, a title followed by a page number.
+      The level of a Markdown heading is the number of leading #,
+      otherwise the level is given by the indentation of the line.
+      Markdown emphasis sets the style: *italic*, **bold**, ***bold italic***
+      eg. # **Part 1** ..... 1
+          ## Chapter 1 ..... 2
+          - Appendix 10
+            - Glossary 12
 `
 )
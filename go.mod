@@ -10,6 +10,10 @@ require (
 	golang.org/x/image v0.12.0
 	golang.org/x/text v0.13.0
 	gopkg.in/yaml.v2 v2.4.0
+	software.sslmate.com/src/go-pkcs12 v0.4.0
 )
 
-require github.com/rivo/uniseg v0.4.4 // indirect
+require (
+	github.com/rivo/uniseg v0.4.4 // indirect
+	golang.org/x/crypto v0.11.0 // indirect
+)
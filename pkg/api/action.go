@@ -0,0 +1,238 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Actions returns the document level actions and the page level actions of selected pages of rs.
+func Actions(rs io.ReadSeeker, selectedPages []string, conf *model.Configuration) ([]pdfcpu.Action, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: Actions: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTACTIONS
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.Actions(ctx, pages)
+}
+
+// SetAction registers action for trigger in rs and writes the result to w.
+// Page level actions are set for selected pages, document level actions ignore selectedPages.
+// Use pdfcpu.PrintAction for a document printing itself on opening.
+func SetAction(rs io.ReadSeeker, w io.Writer, selectedPages []string, trigger pdfcpu.ActionTrigger, action types.Dict, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: SetAction: missing rs")
+	}
+
+	if action == nil {
+		return errors.New("pdfcpu: SetAction: missing action")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.SETACTIONS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !trigger.PageLevel() {
+		if err := pdfcpu.SetAction(ctx, trigger, 0, action); err != nil {
+			return err
+		}
+	} else {
+		if err := ctx.EnsurePageCount(); err != nil {
+			return err
+		}
+
+		pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+		if err != nil {
+			return err
+		}
+
+		for _, i := range sortedPages(pages) {
+			if err := pdfcpu.SetAction(ctx, trigger, i, action); err != nil {
+				return err
+			}
+		}
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// SetActionFile registers action for trigger in inFile and writes the result to outFile.
+func SetActionFile(inFile, outFile string, selectedPages []string, trigger pdfcpu.ActionTrigger, action types.Dict, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return SetAction(f1, f2, selectedPages, trigger, action, conf)
+}
+
+// RemoveActions removes the actions for triggers or all actions if triggers is empty from rs and writes the result to w.
+// Page level actions are removed from selected pages only.
+func RemoveActions(rs io.ReadSeeker, w io.Writer, selectedPages []string, triggers []pdfcpu.ActionTrigger, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: RemoveActions: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.REMOVEACTIONS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+	if err != nil {
+		return err
+	}
+
+	c, err := pdfcpu.RemoveActions(ctx, triggers, pages)
+	if err != nil {
+		return err
+	}
+
+	if c == 0 {
+		return errors.New("pdfcpu: no actions removed")
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// RemoveActionsFile removes the actions for triggers or all actions if triggers is empty from inFile and writes the result to outFile.
+func RemoveActionsFile(inFile, outFile string, selectedPages []string, triggers []pdfcpu.ActionTrigger, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return RemoveActions(f1, f2, selectedPages, triggers, conf)
+}
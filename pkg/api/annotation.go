@@ -56,6 +56,7 @@ func Annotations(rs io.ReadSeeker, selectedPages []string, conf *model.Configura
 }
 
 // AddAnnotations adds annotations for selected pages in rs and writes the result to w.
+// Annotation coordinates are in page space unless wrapped by model.NewViewSpaceAnnotation.
 func AddAnnotations(rs io.ReadSeeker, w io.Writer, selectedPages []string, ann model.AnnotationRenderer, conf *model.Configuration) error {
 	if rs == nil {
 		return errors.New("pdfcpu: AddAnnotations: missing rs")
@@ -208,6 +209,7 @@ func AddAnnotationsFile(inFile, outFile string, selectedPages []string, ar model
 }
 
 // AddAnnotationsMap adds annotations in m to corresponding pages of rs and writes the result to w.
+// Annotation coordinates are in page space unless wrapped by model.NewViewSpaceAnnotation.
 func AddAnnotationsMap(rs io.ReadSeeker, w io.Writer, m map[int][]model.AnnotationRenderer, conf *model.Configuration) error {
 	if rs == nil {
 		return errors.New("pdfcpu: AddAnnotationsMap: missing rs")
@@ -508,3 +510,33 @@ func RemoveAnnotationsFile(inFile, outFile string, selectedPages, idsAndTypes []
 
 	return RemoveAnnotations(f1, f2, selectedPages, idsAndTypes, objNrs, conf)
 }
+
+// RestyleLinks applies border and highlight style ls to all link annotations of selected pages
+// of a PDF context read from rs and writes the result to w.
+// Use a zero border width to remove visible link borders.
+func RestyleLinks(rs io.ReadSeeker, w io.Writer, selectedPages []string, ls model.LinkStyle, conf *model.Configuration) error {
+	return modifyContext(rs, w, model.RESTYLELINKS, conf, func(ctx *model.Context) error {
+		pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+		if err != nil {
+			return err
+		}
+
+		n, err := pdfcpu.RestyleLinks(ctx, pages, ls)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.New("pdfcpu: RestyleLinks: No links found")
+		}
+
+		return nil
+	})
+}
+
+// RestyleLinksFile applies border and highlight style ls to all link annotations of selected pages
+// of inFile and writes the result to outFile.
+func RestyleLinksFile(inFile, outFile string, selectedPages []string, ls model.LinkStyle, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return RestyleLinks(rs, w, selectedPages, ls, conf)
+	})
+}
@@ -75,6 +75,34 @@ func ReadContextFile(inFile string) (*model.Context, error) {
 	return ctx, err
 }
 
+// ReadSharedContext reads and validates a context from rs and freezes it,
+// so it may serve read-only operations like pdfcpu.Info, pdfcpu.ExtractPageText or pdfcpu.ExtractPage
+// from multiple goroutines concurrently. See model.Context.Freeze.
+func ReadSharedContext(rs io.ReadSeeker, conf *model.Configuration) (*model.Context, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: ReadSharedContext: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pdfcpu.DetectWatermarks(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Freeze(); err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
 // ValidateContext validates ctx.
 func ValidateContext(ctx *model.Context) error {
 	return validate.XRefTable(ctx.XRefTable)
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/form"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// RegenerateAppearances regenerates the appearance streams of form fields and creates missing appearance streams
+// for markup annotations of selected pages of rs and writes the result to w.
+// Use this after changing field values or for viewers not synthesizing missing appearances.
+func RegenerateAppearances(rs io.ReadSeeker, w io.Writer, selectedPages []string, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: RegenerateAppearances: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.REGENERATEAPPEARANCES
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+	if err != nil {
+		return err
+	}
+
+	if _, err := form.RefreshFormFieldAppearances(ctx, pages); err != nil {
+		return err
+	}
+
+	if _, err := pdfcpu.GenerateAnnotationAppearances(ctx, pages); err != nil {
+		return err
+	}
+
+	if log.StatsEnabled() {
+		log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// RegenerateAppearancesFile regenerates the appearance streams of form fields and creates missing appearance streams
+// for markup annotations of selected pages of inFile and writes the result to outFile.
+func RegenerateAppearancesFile(inFile, outFile string, selectedPages []string, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+	}
+	logWritingTo(outFile)
+
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return RegenerateAppearances(f1, f2, selectedPages, conf)
+}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 	"github.com/pkg/errors"
 )
@@ -47,6 +48,38 @@ func Attachments(rs io.ReadSeeker, conf *model.Configuration) ([]model.Attachmen
 	return ctx.ListAttachments()
 }
 
+// AttachmentInfos returns encryption status, size, SHA-256 and declared MIME type
+// of all embedded files of a PDF context read from rs without extracting them.
+func AttachmentInfos(rs io.ReadSeeker, conf *model.Configuration) ([]model.AttachmentInfo, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: AttachmentInfos: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.AUDITATTACHMENTS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.AttachmentInfos()
+}
+
+// AttachmentInfosFile returns encryption status, size, SHA-256 and declared MIME type
+// of all embedded files of inFile without extracting them.
+func AttachmentInfosFile(inFile string, conf *model.Configuration) ([]model.AttachmentInfo, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return AttachmentInfos(f, conf)
+}
+
 // AddAttachments embeds files into a PDF context read from rs and writes the result to w.
 // file is either a file name or a file name and a description separated by a comma.
 func AddAttachments(rs io.ReadSeeker, w io.Writer, files []string, coll bool, conf *model.Configuration) error {
@@ -164,6 +197,80 @@ func AddAttachmentsFile(inFile, outFile string, files []string, coll bool, conf
 	return AddAttachments(f1, f2, files, coll, conf)
 }
 
+// AddJobTicket embeds the JDF job ticket read from rTicket as associated file into a PDF context read from rs
+// and writes the result to w.
+// fileName names the attachment (default: JobTicket.jdf), use the extension .xjdf for XJDF job tickets.
+func AddJobTicket(rs io.ReadSeeker, w io.Writer, rTicket io.Reader, fileName string, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: AddJobTicket: missing rs")
+	}
+
+	if rTicket == nil {
+		return errors.New("pdfcpu: AddJobTicket: missing rTicket")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.ADDATTACHMENTS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if _, err = pdfcpu.AddJobTicket(ctx, rTicket, fileName, nil); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// AddJobTicketFile embeds the JDF job ticket jdfFile as associated file into inFile and writes the result to outFile.
+func AddJobTicketFile(inFile, outFile, jdfFile string, conf *model.Configuration) (err error) {
+	var f0, f1, f2 *os.File
+
+	if f0, err = os.Open(jdfFile); err != nil {
+		return err
+	}
+	defer f0.Close()
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return AddJobTicket(f1, f2, f0, filepath.Base(jdfFile), conf)
+}
+
 // RemoveAttachments deletes embedded files from a PDF context read from rs and writes the result to w.
 func RemoveAttachments(rs io.ReadSeeker, w io.Writer, files []string, conf *model.Configuration) error {
 	if rs == nil {
@@ -0,0 +1,269 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// BatchStep processes the PDF stream read from rs and writes the result to w.
+// Most api functions operating on an io.ReadSeeker qualify, eg.
+//
+//	func(rs io.ReadSeeker, w io.Writer, conf *model.Configuration) error {
+//		return api.AddWatermarks(rs, w, nil, wm, conf)
+//	}
+type BatchStep func(rs io.ReadSeeker, w io.Writer, conf *model.Configuration) error
+
+// BatchPolicy defines how to deal with already existing output files.
+type BatchPolicy int
+
+// The available batch policies.
+const (
+	BatchSkip      BatchPolicy = iota // Skip input files whose output file already exists.
+	BatchOverwrite                    // Overwrite existing output files.
+	BatchFail                         // Treat existing output files as error.
+)
+
+// Batch represents a pipeline of steps to be applied to all PDF files in a directory tree.
+type Batch struct {
+	InDir   string               // Root of the directory tree to be processed.
+	OutDir  string               // Root of the output directory tree. If empty files get processed in place.
+	Steps   []BatchStep          // The pipeline applied to each file.
+	Workers int                  // Number of files processed concurrently, defaults to runtime.NumCPU().
+	Policy  BatchPolicy          // How to deal with existing output files, ignored for in place processing.
+	Conf    *model.Configuration // Each step gets passed a copy.
+}
+
+// BatchResult represents the outcome of processing a single file.
+type BatchResult struct {
+	InFile   string
+	OutFile  string
+	Skipped  bool
+	Err      error
+	Duration time.Duration
+}
+
+// BatchReport summarizes a batch run.
+type BatchReport struct {
+	Processed int
+	Skipped   int
+	Failed    int
+	Results   []BatchResult // In order of the processed input files.
+	Duration  time.Duration
+}
+
+func (r BatchReport) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d files processed, %d skipped, %d failed in %.2fs\n",
+		r.Processed, r.Skipped, r.Failed, r.Duration.Seconds()))
+	for _, res := range r.Results {
+		if res.Err != nil {
+			sb.WriteString(fmt.Sprintf("%s: %v\n", res.InFile, res.Err))
+		}
+	}
+	return sb.String()
+}
+
+// batchInFiles returns the PDF files of the directory tree rooted at inDir excluding the subtree outDir.
+func batchInFiles(inDir, outDir string) ([]string, error) {
+	if outDir != "" {
+		outDir = filepath.Clean(outDir)
+	}
+	var ss []string
+	err := filepath.WalkDir(inDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && filepath.Clean(path) == outDir {
+			// Don't pick up the results of earlier runs.
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".pdf") {
+			ss = append(ss, path)
+		}
+		return nil
+	})
+	return ss, err
+}
+
+func (b Batch) outFile(inFile string) (string, error) {
+	if b.OutDir == "" {
+		return inFile, nil
+	}
+	rel, err := filepath.Rel(b.InDir, inFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(b.OutDir, rel), nil
+}
+
+func (b Batch) runSteps(inFile, outFile string) error {
+	bb, err := os.ReadFile(inFile)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range b.Steps {
+		c := *b.Conf
+		buf := &bytes.Buffer{}
+		if err := step(bytes.NewReader(bb), buf, &c); err != nil {
+			return err
+		}
+		bb = buf.Bytes()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outFile), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Never leave a partially written output file behind.
+	f, err := os.CreateTemp(filepath.Dir(outFile), filepath.Base(outFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpFile := f.Name()
+
+	if _, err = f.Write(bb); err == nil {
+		err = f.Chmod(0644)
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(tmpFile, outFile)
+	}
+	if err != nil {
+		os.Remove(tmpFile)
+	}
+
+	return err
+}
+
+func (b Batch) process(inFile string) BatchResult {
+	from := time.Now()
+
+	res := BatchResult{InFile: inFile}
+
+	outFile, err := b.outFile(inFile)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.OutFile = outFile
+
+	if outFile != inFile {
+		if _, err := os.Stat(outFile); err == nil {
+			switch b.Policy {
+			case BatchSkip:
+				res.Skipped = true
+				return res
+			case BatchFail:
+				res.Err = errors.Errorf("pdfcpu: batch: output file already exists: %s", outFile)
+				return res
+			}
+		}
+	}
+
+	if log.CLIEnabled() {
+		log.CLI.Printf("processing %s\n", inFile)
+	}
+
+	res.Err = b.runSteps(inFile, outFile)
+	res.Duration = time.Since(from)
+
+	return res
+}
+
+// ProcessBatch applies the steps of b to all PDF files found in the directory tree rooted at b.InDir.
+// Failing files do not stop the batch, their errors are recorded in the returned report.
+func ProcessBatch(b Batch) (*BatchReport, error) {
+	if b.InDir == "" {
+		return nil, errors.New("pdfcpu: ProcessBatch: missing inDir")
+	}
+
+	if len(b.Steps) == 0 {
+		return nil, errors.New("pdfcpu: ProcessBatch: missing steps")
+	}
+
+	if b.Conf == nil {
+		b.Conf = model.NewDefaultConfiguration()
+	}
+
+	if b.Workers <= 0 {
+		b.Workers = runtime.NumCPU()
+	}
+
+	if b.OutDir != "" && filepath.Clean(b.OutDir) == filepath.Clean(b.InDir) {
+		b.OutDir = ""
+	}
+
+	from := time.Now()
+
+	inFiles, err := batchInFiles(b.InDir, b.OutDir)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &BatchReport{Results: make([]BatchResult, len(inFiles))}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for i := 0; i < b.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				rep.Results[j] = b.process(inFiles[j])
+			}
+		}()
+	}
+
+	for i := range inFiles {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, res := range rep.Results {
+		switch {
+		case res.Err != nil:
+			rep.Failed++
+		case res.Skipped:
+			rep.Skipped++
+		default:
+			rep.Processed++
+		}
+	}
+
+	rep.Duration = time.Since(from)
+
+	return rep, nil
+}
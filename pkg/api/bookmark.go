@@ -19,6 +19,8 @@ package api
 import (
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
@@ -117,8 +119,9 @@ func ExportBookmarksFile(inFilePDF, outFileJSON string, conf *model.Configuratio
 	return ExportBookmarksJSON(f1, f2, inFilePDF, conf)
 }
 
-// ImportBookmarks creates/replaces outlines in rs and writes the result to w.
-func ImportBookmarks(rs io.ReadSeeker, rd io.Reader, w io.Writer, replace bool, conf *model.Configuration) error {
+type bookmarkImporter func(ctx *model.Context, rd io.Reader, replace bool) (bool, error)
+
+func importBookmarks(rs io.ReadSeeker, rd io.Reader, w io.Writer, replace bool, conf *model.Configuration, imp bookmarkImporter) error {
 	if rs == nil {
 		return errors.New("pdfcpu: ImportBookmarks: missing rs")
 	}
@@ -143,7 +146,7 @@ func ImportBookmarks(rs io.ReadSeeker, rd io.Reader, w io.Writer, replace bool,
 		return err
 	}
 
-	ok, err := pdfcpu.ImportBookmarks(ctx, rd, replace)
+	ok, err := imp(ctx, rd, replace)
 	if err != nil {
 		return err
 	}
@@ -154,7 +157,25 @@ func ImportBookmarks(rs io.ReadSeeker, rd io.Reader, w io.Writer, replace bool,
 	return WriteContext(ctx, w)
 }
 
-// ImportBookmarks creates/replaces outlines in inFilePDF and writes the result to outFilePDF.
+// ImportBookmarks creates/replaces outlines in rs as provided by JSON in rd and writes the result to w.
+func ImportBookmarks(rs io.ReadSeeker, rd io.Reader, w io.Writer, replace bool, conf *model.Configuration) error {
+	return importBookmarks(rs, rd, w, replace, conf, pdfcpu.ImportBookmarks)
+}
+
+// ImportBookmarksCSV creates/replaces outlines in rs as provided by CSV records in rd and writes the result to w.
+// Each record is made up of: level, title, page{, style{, color}}
+func ImportBookmarksCSV(rs io.ReadSeeker, rd io.Reader, w io.Writer, replace bool, conf *model.Configuration) error {
+	return importBookmarks(rs, rd, w, replace, conf, pdfcpu.ImportBookmarksCSV)
+}
+
+// ImportBookmarksText creates/replaces outlines in rs as provided by indented text or Markdown in rd and writes the result to w.
+// Each line is made up of a title followed by a page number.
+func ImportBookmarksText(rs io.ReadSeeker, rd io.Reader, w io.Writer, replace bool, conf *model.Configuration) error {
+	return importBookmarks(rs, rd, w, replace, conf, pdfcpu.ImportBookmarksText)
+}
+
+// ImportBookmarksFile creates/replaces outlines in inFilePDF and writes the result to outFilePDF.
+// The format of inFileJSON depends on its extension: .csv for CSV, .md or .txt for indented text or Markdown, JSON otherwise.
 func ImportBookmarksFile(inFilePDF, inFileJSON, outFilePDF string, replace bool, conf *model.Configuration) (err error) {
 	var f0, f1, f2 *os.File
 
@@ -195,7 +216,15 @@ func ImportBookmarksFile(inFilePDF, inFileJSON, outFilePDF string, replace bool,
 		}
 	}()
 
-	return ImportBookmarks(f0, f1, f2, replace, conf)
+	imp := pdfcpu.ImportBookmarks
+	switch strings.ToLower(filepath.Ext(inFileJSON)) {
+	case ".csv":
+		imp = pdfcpu.ImportBookmarksCSV
+	case ".md", ".txt":
+		imp = pdfcpu.ImportBookmarksText
+	}
+
+	return importBookmarks(f0, f1, f2, replace, conf, imp)
 }
 
 // AddBookmarks adds a single bookmark outline layer to the PDF context read from rs and writes the result to w.
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// PageChecksums returns normalized checksums for selected pages of rs keyed by page number.
+// Checksums do not depend on object numbers, compression or timestamps
+// and may be compared across different versions of a document in order to detect changed pages.
+func PageChecksums(rs io.ReadSeeker, selectedPages []string, conf *model.Configuration) (map[int]string, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: PageChecksums: missing rs")
+	}
+
+	ctx, err := ReadContext(rs, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.PageChecksums(ctx, pages)
+}
+
+// PageChecksumsFile returns normalized checksums for selected pages of inFile keyed by page number.
+func PageChecksumsFile(inFile string, selectedPages []string, conf *model.Configuration) (map[int]string, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return PageChecksums(f, selectedPages, conf)
+}
+
+// ChangedPages returns the sorted numbers of all pages of rs2 whose checksums differ from the corresponding pages of rs1.
+// Pages beyond the page count of rs1 are reported as changed.
+func ChangedPages(rs1, rs2 io.ReadSeeker, conf *model.Configuration) ([]int, error) {
+	m1, err := PageChecksums(rs1, nil, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	m2, err := PageChecksums(rs2, nil, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []int
+	for pageNr, s := range m2 {
+		if m1[pageNr] != s {
+			pages = append(pages, pageNr)
+		}
+	}
+	sort.Ints(pages)
+
+	return pages, nil
+}
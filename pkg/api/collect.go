@@ -23,9 +23,38 @@ import (
 
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 	"github.com/pkg/errors"
 )
 
+func readForCollect(rs io.ReadSeeker, conf *model.Configuration) (*model.Context, error) {
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
+func writeCollection(ctx *model.Context, pages []int, w io.Writer) error {
+	ctxDest, err := pdfcpu.ExtractPages(ctx, pages, false)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Configuration.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctxDest); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctxDest, w)
+}
+
 // Collect creates a custom PDF page sequence for selected pages of rs and writes the result to w.
 func Collect(rs io.ReadSeeker, w io.Writer, selectedPages []string, conf *model.Configuration) error {
 	if rs == nil {
@@ -37,26 +66,149 @@ func Collect(rs io.ReadSeeker, w io.Writer, selectedPages []string, conf *model.
 	}
 	conf.Cmd = model.COLLECT
 
-	fromStart := time.Now()
-	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, fromStart)
+	ctx, err := readForCollect(rs, conf)
 	if err != nil {
 		return err
 	}
 
-	if err := ctx.EnsurePageCount(); err != nil {
+	pages, err := PagesForPageCollection(ctx.PageCount, selectedPages)
+	if err != nil {
 		return err
 	}
 
-	pages, err := PagesForPageCollection(ctx.PageCount, selectedPages)
+	return writeCollection(ctx, pages, w)
+}
+
+// ReorderPages writes the pages of rs in the sequence given by order to w.
+// order holds page numbers and may contain a page multiple times or omit pages, eg. []int{3, 1, 1, 2}.
+func ReorderPages(rs io.ReadSeeker, w io.Writer, order []int, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: ReorderPages: missing rs")
+	}
+
+	if len(order) == 0 {
+		return errors.New("pdfcpu: ReorderPages: missing page order")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.COLLECT
+
+	ctx, err := readForCollect(rs, conf)
 	if err != nil {
 		return err
 	}
 
-	ctxDest, err := pdfcpu.ExtractPages(ctx, pages, false)
+	for _, i := range order {
+		if i < 1 || i > ctx.PageCount {
+			return errors.Errorf("pdfcpu: ReorderPages: invalid page number: %d", i)
+		}
+	}
+
+	return writeCollection(ctx, order, w)
+}
+
+// PageOrderMode defines a predefined page sequence.
+type PageOrderMode int
+
+// The available page order modes.
+const (
+	PageOrderDuplex  PageOrderMode = iota // Odd pages followed by even pages in reverse order for manual duplex printing.
+	PageOrderCollate                      // n copies of all pages, one complete copy after the other.
+)
+
+// PageOrder returns the sequence of pages according to mode where n is the number of copies to collate.
+// For manual duplex printing of an odd number of pages a 0 marks the blank back of the last sheet.
+func PageOrder(pages []int, mode PageOrderMode, n int) ([]int, error) {
+	var order []int
+
+	switch mode {
+
+	case PageOrderDuplex:
+		var even []int
+		for i, p := range pages {
+			if i%2 == 0 {
+				order = append(order, p)
+				continue
+			}
+			even = append(even, p)
+		}
+		if len(pages)%2 > 0 {
+			// The second pass needs one page per sheet.
+			even = append(even, 0)
+		}
+		for i := len(even) - 1; i >= 0; i-- {
+			order = append(order, even[i])
+		}
+
+	case PageOrderCollate:
+		if n < 1 {
+			return nil, errors.Errorf("pdfcpu: invalid number of copies: %d", n)
+		}
+		for i := 0; i < n; i++ {
+			order = append(order, pages...)
+		}
+
+	default:
+		return nil, errors.Errorf("pdfcpu: invalid page order mode: %d", mode)
+	}
+
+	return order, nil
+}
+
+// ReorderPagesForMode writes selected pages of rs in the sequence defined by mode to w.
+// n is the number of copies for PageOrderCollate.
+func ReorderPagesForMode(rs io.ReadSeeker, w io.Writer, selectedPages []string, mode PageOrderMode, n int, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: ReorderPagesForMode: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.REORDERPAGES
+
+	ctx, err := readForCollect(rs, conf)
+	if err != nil {
+		return err
+	}
+
+	pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+	if err != nil {
+		return err
+	}
+
+	order, err := PageOrder(sortedPages(pages), mode, n)
+	if err != nil {
+		return err
+	}
+
+	// Extract all pages but blank ones which get inserted afterwards.
+	var pageNrs []int
+	blank := types.IntSet{}
+	for _, p := range order {
+		if p == 0 {
+			blank[len(pageNrs)] = true
+			continue
+		}
+		pageNrs = append(pageNrs, p)
+	}
+
+	ctxDest, err := pdfcpu.ExtractPages(ctx, pageNrs, false)
 	if err != nil {
 		return err
 	}
 
+	if len(blank) > 0 {
+		if err := ctxDest.EnsurePageCount(); err != nil {
+			return err
+		}
+		if err := ctxDest.InsertBlankPages(blank, model.BlankPages{}); err != nil {
+			return err
+		}
+	}
+
 	if conf.ValidationMode != model.ValidationNone {
 		if err = ValidateContext(ctxDest); err != nil {
 			return err
@@ -66,8 +218,7 @@ func Collect(rs io.ReadSeeker, w io.Writer, selectedPages []string, conf *model.
 	return WriteContext(ctxDest, w)
 }
 
-// CollectFile creates a custom PDF page sequence for inFile and writes the result to outFile.
-func CollectFile(inFile, outFile string, selectedPages []string, conf *model.Configuration) (err error) {
+func collectFile(inFile, outFile string, collect func(rs io.ReadSeeker, w io.Writer) error) (err error) {
 	tmpFile := inFile + ".tmp"
 	if outFile != "" && inFile != outFile {
 		tmpFile = outFile
@@ -107,5 +258,28 @@ func CollectFile(inFile, outFile string, selectedPages []string, conf *model.Con
 		}
 	}()
 
-	return Collect(f1, f2, selectedPages, conf)
+	return collect(f1, f2)
+}
+
+// CollectFile creates a custom PDF page sequence for inFile and writes the result to outFile.
+func CollectFile(inFile, outFile string, selectedPages []string, conf *model.Configuration) error {
+	return collectFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return Collect(rs, w, selectedPages, conf)
+	})
+}
+
+// ReorderPagesFile writes the pages of inFile in the sequence given by order to outFile.
+// order holds page numbers and may contain a page multiple times or omit pages.
+func ReorderPagesFile(inFile, outFile string, order []int, conf *model.Configuration) error {
+	return collectFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return ReorderPages(rs, w, order, conf)
+	})
+}
+
+// ReorderPagesForModeFile writes selected pages of inFile in the sequence defined by mode to outFile.
+// n is the number of copies for PageOrderCollate.
+func ReorderPagesForModeFile(inFile, outFile string, selectedPages []string, mode PageOrderMode, n int, conf *model.Configuration) error {
+	return collectFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return ReorderPagesForMode(rs, w, selectedPages, mode, n, conf)
+	})
 }
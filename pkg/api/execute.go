@@ -0,0 +1,417 @@
+/*
+	Copyright 2024 The pdfcpu Authors.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// Operation is a serializable descriptor of a pdfcpu operation.
+// It corresponds to a pdfcpu cli command invocation and may be stored (eg. in a job queue) and replayed using Execute:
+//
+//	{"op": "rotate", "inFile": "in.pdf", "outFile": "out.pdf", "pages": ["1-3"], "params": {"rotation": 90}}
+//
+// Params holds the operation specific parameters, see Operations.
+type Operation struct {
+	Op      string          `json:"op"`
+	InFile  string          `json:"inFile,omitempty"`
+	InFiles []string        `json:"inFiles,omitempty"`
+	OutFile string          `json:"outFile,omitempty"`
+	OutDir  string          `json:"outDir,omitempty"`
+	Pages   []string        `json:"pages,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type watermarkParams struct {
+	Mode   string `json:"mode"` // text, image, pdf, shape, svg
+	Value  string `json:"value"`
+	Desc   string `json:"desc"`
+	Update bool   `json:"update"`
+}
+
+type operation func(op Operation, conf *model.Configuration) error
+
+var operations = map[string]operation{
+	"validate": func(op Operation, conf *model.Configuration) error {
+		return ValidateFile(op.InFile, conf)
+	},
+	"optimize": func(op Operation, conf *model.Configuration) error {
+		return OptimizeFile(op.InFile, op.OutFile, conf)
+	},
+	"merge": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Append bool `json:"append"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		if p.Append {
+			return MergeAppendFile(op.InFiles, op.OutFile, conf)
+		}
+		return MergeCreateFile(op.InFiles, op.OutFile, conf)
+	},
+	"split": func(op Operation, conf *model.Configuration) error {
+		p := struct {
+			Span int `json:"span"`
+		}{Span: 1}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		return SplitFile(op.InFile, op.OutDir, p.Span, conf)
+	},
+	"trim": func(op Operation, conf *model.Configuration) error {
+		return TrimFile(op.InFile, op.OutFile, op.Pages, conf)
+	},
+	"collect": func(op Operation, conf *model.Configuration) error {
+		return CollectFile(op.InFile, op.OutFile, op.Pages, conf)
+	},
+	"removepages": func(op Operation, conf *model.Configuration) error {
+		return RemovePagesFile(op.InFile, op.OutFile, op.Pages, conf)
+	},
+	"rotate": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Rotation int `json:"rotation"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		if p.Rotation%90 != 0 {
+			return errors.Errorf("pdfcpu: rotate: rotation must be a multiple of 90: %d", p.Rotation)
+		}
+		return RotateFile(op.InFile, op.OutFile, p.Rotation, op.Pages, conf)
+	},
+	"crop": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Desc string `json:"desc"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		box, err := model.ParseBox(p.Desc, conf.Unit)
+		if err != nil {
+			return err
+		}
+		return CropFile(op.InFile, op.OutFile, op.Pages, box, conf)
+	},
+	"nup": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			N    int    `json:"n"`
+			Desc string `json:"desc"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		nup, err := PDFNUpConfig(p.N, p.Desc)
+		if err != nil {
+			return err
+		}
+		inFiles := op.InFiles
+		if op.InFile != "" {
+			inFiles = []string{op.InFile}
+		}
+		return NUpFile(inFiles, op.OutFile, op.Pages, nup, conf)
+	},
+	"watermark": func(op Operation, conf *model.Configuration) error {
+		return op.addWatermarks(false, conf)
+	},
+	"stamp": func(op Operation, conf *model.Configuration) error {
+		return op.addWatermarks(true, conf)
+	},
+	"removewatermarks": func(op Operation, conf *model.Configuration) error {
+		return op.removeWatermarks(conf)
+	},
+	"removestamps": func(op Operation, conf *model.Configuration) error {
+		return op.removeWatermarks(conf)
+	},
+	"encrypt": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			UserPW  string `json:"upw"`
+			OwnerPW string `json:"opw"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		c := *conf
+		c.UserPW, c.OwnerPW = p.UserPW, p.OwnerPW
+		return EncryptFile(op.InFile, op.OutFile, &c)
+	},
+	"decrypt": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			UserPW  string `json:"upw"`
+			OwnerPW string `json:"opw"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		c := *conf
+		c.UserPW, c.OwnerPW = p.UserPW, p.OwnerPW
+		return DecryptFile(op.InFile, op.OutFile, &c)
+	},
+	"extract": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Mode string `json:"mode"` // image, font, page, content, text, meta
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		switch p.Mode {
+		case "image":
+			return ExtractImagesFile(op.InFile, op.OutDir, op.Pages, conf)
+		case "font":
+			return ExtractFontsFile(op.InFile, op.OutDir, op.Pages, conf)
+		case "page":
+			return ExtractPagesFile(op.InFile, op.OutDir, op.Pages, conf)
+		case "content":
+			return ExtractContentFile(op.InFile, op.OutDir, op.Pages, conf)
+		case "text":
+			return ExtractTextFile(op.InFile, op.OutDir, op.Pages, false, conf)
+		case "meta":
+			return ExtractMetadataFile(op.InFile, op.OutDir, conf)
+		}
+		return errors.Errorf("pdfcpu: extract: unsupported mode: %q", p.Mode)
+	},
+	"addattachments": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Files []string `json:"files"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		return AddAttachmentsFile(op.InFile, op.OutFile, p.Files, false, conf)
+	},
+	"removeattachments": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Files []string `json:"files"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		return RemoveAttachmentsFile(op.InFile, op.OutFile, p.Files, conf)
+	},
+	"addproperties": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Properties map[string]string `json:"properties"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		return AddPropertiesFile(op.InFile, op.OutFile, p.Properties, conf)
+	},
+	"removeproperties": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Keys []string `json:"keys"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		return RemovePropertiesFile(op.InFile, op.OutFile, p.Keys, conf)
+	},
+	"addkeywords": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Keywords []string `json:"keywords"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		return AddKeywordsFile(op.InFile, op.OutFile, p.Keywords, conf)
+	},
+	"removekeywords": func(op Operation, conf *model.Configuration) error {
+		var p struct {
+			Keywords []string `json:"keywords"`
+		}
+		if err := op.decodeParams(&p); err != nil {
+			return err
+		}
+		return RemoveKeywordsFile(op.InFile, op.OutFile, p.Keywords, conf)
+	},
+	"removebookmarks": func(op Operation, conf *model.Configuration) error {
+		return RemoveBookmarksFile(op.InFile, op.OutFile, conf)
+	},
+}
+
+// Operations returns the names of all operations supported by Execute:
+//
+//	validate
+//	optimize
+//	merge              params: append
+//	split              params: span
+//	trim, collect, removepages
+//	rotate             params: rotation
+//	crop               params: desc
+//	nup                params: n, desc
+//	watermark, stamp   params: mode (text, image, pdf, shape, svg), value, desc, update
+//	removewatermarks, removestamps       params: name
+//	encrypt, decrypt   params: upw, opw
+//	extract            params: mode (image, font, page, content, text, meta)
+//	addattachments, removeattachments    params: files
+//	addproperties      params: properties
+//	removeproperties   params: keys
+//	addkeywords, removekeywords          params: keywords
+//	removebookmarks
+func Operations() []string {
+	ss := make([]string, 0, len(operations))
+	for k := range operations {
+		ss = append(ss, k)
+	}
+	sort.Strings(ss)
+	return ss
+}
+
+func (op Operation) decodeParams(v interface{}) error {
+	if len(op.Params) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(op.Params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errors.Wrapf(err, "pdfcpu: %s: invalid params", op.Op)
+	}
+	return nil
+}
+
+func (op Operation) addWatermarks(onTop bool, conf *model.Configuration) error {
+	var p watermarkParams
+	if err := op.decodeParams(&p); err != nil {
+		return err
+	}
+
+	var (
+		wm  *model.Watermark
+		err error
+	)
+
+	switch p.Mode {
+	case "", "text":
+		wm, err = TextWatermark(p.Value, p.Desc, onTop, p.Update, conf.Unit)
+	case "image":
+		wm, err = ImageWatermark(p.Value, p.Desc, onTop, p.Update, conf.Unit)
+	case "pdf":
+		wm, err = PDFWatermark(p.Value, p.Desc, onTop, p.Update, conf.Unit)
+	case "shape":
+		wm, err = ShapeWatermark(p.Value, p.Desc, onTop, p.Update, conf.Unit)
+	case "svg":
+		wm, err = SVGWatermark(p.Value, p.Desc, onTop, p.Update, conf.Unit)
+	default:
+		return errors.Errorf("pdfcpu: %s: unsupported mode: %q", op.Op, p.Mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	return AddWatermarksFile(op.InFile, op.OutFile, op.Pages, wm, conf)
+}
+
+func (op Operation) removeWatermarks(conf *model.Configuration) error {
+	var p struct {
+		Name string `json:"name"`
+	}
+	if err := op.decodeParams(&p); err != nil {
+		return err
+	}
+	if p.Name != "" {
+		return RemoveWatermarkFile(op.InFile, op.OutFile, op.Pages, p.Name, conf)
+	}
+	return RemoveWatermarksFile(op.InFile, op.OutFile, op.Pages, conf)
+}
+
+func (op Operation) validate() error {
+	if _, ok := operations[op.Op]; !ok {
+		return errors.Errorf("pdfcpu: unsupported operation: %q", op.Op)
+	}
+	switch op.Op {
+	case "merge":
+		if len(op.InFiles) == 0 {
+			return errors.Errorf("pdfcpu: %s: missing inFiles", op.Op)
+		}
+	case "nup":
+		if op.InFile == "" && len(op.InFiles) == 0 {
+			return errors.Errorf("pdfcpu: %s: missing inFile", op.Op)
+		}
+	default:
+		if op.InFile == "" {
+			return errors.Errorf("pdfcpu: %s: missing inFile", op.Op)
+		}
+	}
+	switch op.Op {
+	case "split", "extract":
+		if op.OutDir == "" {
+			return errors.Errorf("pdfcpu: %s: missing outDir", op.Op)
+		}
+	}
+	return nil
+}
+
+// ParseOperation parses a JSON encoded operation descriptor.
+func ParseOperation(bb []byte) (*Operation, error) {
+	var op Operation
+	if err := json.Unmarshal(bb, &op); err != nil {
+		return nil, errors.Wrap(err, "pdfcpu: invalid operation")
+	}
+	op.Op = strings.ToLower(strings.TrimSpace(op.Op))
+	if err := op.validate(); err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+// ParseOperations parses a JSON array of operation descriptors from r.
+func ParseOperations(r io.Reader) ([]Operation, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, errors.Wrap(err, "pdfcpu: invalid operation list")
+	}
+	ops := make([]Operation, len(raw))
+	for i, bb := range raw {
+		op, err := ParseOperation(bb)
+		if err != nil {
+			return nil, errors.Wrapf(err, "operation %d", i+1)
+		}
+		ops[i] = *op
+	}
+	return ops, nil
+}
+
+// Execute runs the operation described by op.
+func Execute(op Operation, conf *model.Configuration) error {
+	op.Op = strings.ToLower(strings.TrimSpace(op.Op))
+	if err := op.validate(); err != nil {
+		return err
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	return operations[op.Op](op, conf)
+}
+
+// ExecuteAll runs ops in sequence and stops on the first error.
+func ExecuteAll(ops []Operation, conf *model.Configuration) error {
+	for i, op := range ops {
+		if err := Execute(op, conf); err != nil {
+			return errors.Wrapf(err, "operation %d (%s)", i+1, op.Op)
+		}
+	}
+	return nil
+}
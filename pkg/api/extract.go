@@ -237,10 +237,23 @@ func ExtractFontsFile(inFile, outDir string, selectedPages []string, conf *model
 
 // ExtractPages generates single page PDF files from rs in outDir for selected pages.
 func ExtractPages(rs io.ReadSeeker, outDir, fileName string, selectedPages []string, conf *model.Configuration) error {
+	return ExtractPagesWithTemplate(rs, outDir, fileName, "", selectedPages, conf)
+}
+
+// ExtractPagesWithTemplate generates single page PDF files from rs in outDir for selected pages.
+// Output file names are generated using tmpl, eg. "%basename_%04d", see pdfcpu.ResolveFileNameTemplate.
+// If tmpl is empty the default naming scheme applies.
+func ExtractPagesWithTemplate(rs io.ReadSeeker, outDir, fileName, tmpl string, selectedPages []string, conf *model.Configuration) error {
 	if rs == nil {
 		return errors.New("pdfcpu: ExtractPages: missing rs")
 	}
 
+	if tmpl != "" {
+		if err := pdfcpu.ValidateFileNameTemplate(tmpl); err != nil {
+			return err
+		}
+	}
+
 	if conf == nil {
 		conf = model.NewDefaultConfiguration()
 		conf.Cmd = model.EXTRACTPAGES
@@ -269,7 +282,13 @@ func ExtractPages(rs io.ReadSeeker, outDir, fileName string, selectedPages []str
 		return nil
 	}
 
+	ff, err := formFieldValues(ctx, tmpl)
+	if err != nil {
+		return err
+	}
+
 	fileName = strings.TrimSuffix(filepath.Base(fileName), ".pdf")
+	fileNames := map[string]bool{}
 
 	for i, v := range pages {
 		if !v {
@@ -280,6 +299,12 @@ func ExtractPages(rs io.ReadSeeker, outDir, fileName string, selectedPages []str
 			return err
 		}
 		outFile := filepath.Join(outDir, fmt.Sprintf("%s_page_%d.pdf", fileName, i))
+		if tmpl != "" {
+			vars := pdfcpu.FileNameVars{BaseName: fileName, From: i, Thru: i, FormFields: ff(i, i)}
+			if outFile, err = templatedOutPath(outDir, tmpl, vars, fileNames); err != nil {
+				return err
+			}
+		}
 		logWritingTo(outFile)
 		if err := WriteContextFile(ctxNew, outFile); err != nil {
 			return err
@@ -298,6 +323,13 @@ func ExtractPages(rs io.ReadSeeker, outDir, fileName string, selectedPages []str
 
 // ExtractPagesFile generates single page PDF files from inFile in outDir for selected pages.
 func ExtractPagesFile(inFile, outDir string, selectedPages []string, conf *model.Configuration) error {
+	return ExtractPagesFileWithTemplate(inFile, outDir, "", selectedPages, conf)
+}
+
+// ExtractPagesFileWithTemplate generates single page PDF files from inFile in outDir for selected pages.
+// Output file names are generated using tmpl, eg. "%basename_%04d", see pdfcpu.ResolveFileNameTemplate.
+// If tmpl is empty the default naming scheme applies.
+func ExtractPagesFileWithTemplate(inFile, outDir, tmpl string, selectedPages []string, conf *model.Configuration) error {
 	f, err := os.Open(inFile)
 	if err != nil {
 		return err
@@ -308,7 +340,7 @@ func ExtractPagesFile(inFile, outDir string, selectedPages []string, conf *model
 		log.CLI.Printf("extracting pages from %s into %s/ ...\n", inFile, outDir)
 	}
 
-	return ExtractPages(f, outDir, filepath.Base(inFile), selectedPages, conf)
+	return ExtractPagesWithTemplate(f, outDir, filepath.Base(inFile), tmpl, selectedPages, conf)
 }
 
 // ExtractContent dumps "PDF source" files from rs into outDir for selected pages.
@@ -394,6 +426,84 @@ func ExtractContentFile(inFile, outDir string, selectedPages []string, conf *mod
 	return ExtractContent(f, outDir, inFile, selectedPages, conf)
 }
 
+// ExtractText dumps the text of selected pages from rs into outDir.
+// If heal is true words hyphenated across line breaks get rejoined,
+// strings split by kerning adjustments get merged and lines get assembled into paragraphs.
+func ExtractText(rs io.ReadSeeker, outDir, fileName string, selectedPages []string, heal bool, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: ExtractText: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.EXTRACTTEXT
+
+	fromStart := time.Now()
+	ctx, durRead, durVal, durOpt, err := ReadValidateAndOptimize(rs, conf, fromStart)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	fromWrite := time.Now()
+	pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+	if err != nil {
+		return err
+	}
+
+	fileName = strings.TrimSuffix(filepath.Base(fileName), ".pdf")
+
+	for p, v := range pages {
+		if !v {
+			continue
+		}
+
+		s, err := pdfcpu.ExtractPageText(ctx, p, heal)
+		if err != nil {
+			return err
+		}
+		if s == "" {
+			continue
+		}
+
+		outFile := filepath.Join(outDir, fmt.Sprintf("%s_Text_page_%d.txt", fileName, p))
+		logWritingTo(outFile)
+		if err := os.WriteFile(outFile, []byte(s+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+	if log.StatsEnabled() {
+		log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	}
+	model.TimingStats("write text", durRead, durVal, durOpt, durWrite, durTotal)
+
+	return nil
+}
+
+// ExtractTextFile dumps the text of selected pages from inFile into outDir.
+// If heal is true words hyphenated across line breaks get rejoined,
+// strings split by kerning adjustments get merged and lines get assembled into paragraphs.
+func ExtractTextFile(inFile, outDir string, selectedPages []string, heal bool, conf *model.Configuration) error {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if log.CLIEnabled() {
+		log.CLI.Printf("extracting text from %s into %s/ ...\n", inFile, outDir)
+	}
+
+	return ExtractText(f, outDir, inFile, selectedPages, heal, conf)
+}
+
 // ExtractMetadata dumps all metadata dict entries for rs into outDir.
 func ExtractMetadata(rs io.ReadSeeker, outDir, fileName string, conf *model.Configuration) error {
 	if rs == nil {
@@ -19,9 +19,11 @@ package api
 import (
 	"bytes"
 	"fmt"
-
+	"io"
+	"os"
 	"path/filepath"
 	"sort"
+	"time"
 	"unicode/utf8"
 
 	"github.com/mjuen/pdfcpu/pkg/font"
@@ -60,6 +62,36 @@ func ListFonts() ([]string, error) {
 	return append(sscf, ssuf...), nil
 }
 
+// FontInfos returns the embedded fonts of rs including their embedding permissions.
+func FontInfos(rs io.ReadSeeker, conf *model.Configuration) ([]pdfcpu.FontInfo, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: FontInfos: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTFONTS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.FontInfos(ctx)
+}
+
+// FontInfosFile returns the embedded fonts of inFile including their embedding permissions.
+func FontInfosFile(inFile string, conf *model.Configuration) ([]pdfcpu.FontInfo, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return FontInfos(f, conf)
+}
+
 // InstallFonts installs true type fonts for embedding.
 func InstallFonts(fileNames []string) error {
 	if log.CLIEnabled() {
@@ -88,6 +120,28 @@ func InstallFonts(fileNames []string) error {
 	return font.LoadUserFonts()
 }
 
+// InstallFontInstances installs static instances of variable true type fonts for embedding.
+// coords holds the axis coordinates of the instance to be created, eg. "wght": 700, "wdth": 75.
+// Each instance gets installed using a PostScript name reflecting its coordinates, eg. "RobotoFlex-Regular_700wght_75wdth".
+func InstallFontInstances(fileNames []string, coords map[string]float64) error {
+	if log.CLIEnabled() {
+		log.CLI.Printf("installing to %s...", font.UserFontDir)
+	}
+
+	for _, fn := range fileNames {
+		if filepath.Ext(fn) != ".ttf" {
+			continue
+		}
+		if _, err := font.InstallTrueTypeFontInstance(font.UserFontDir, fn, coords); err != nil {
+			if log.CLIEnabled() {
+				log.CLI.Printf("%v", err)
+			}
+		}
+	}
+
+	return font.LoadUserFonts()
+}
+
 func rowLabel(xRefTable *model.XRefTable, i int, td model.TextDescriptor, baseFontName, baseFontKey string, buf *bytes.Buffer, mb *types.Rectangle, left bool) {
 	x := 39.
 	if !left {
@@ -303,16 +303,443 @@ func UnlockFormFieldsFile(inFile, outFile string, fieldIDsOrNames []string, conf
 	return UnlockFormFields(f1, f2, fieldIDsOrNames, conf)
 }
 
+// SetFormFieldAccess sets or clears the read-only and required flags of form fields in rs and writes the result to w.
+func SetFormFieldAccess(rs io.ReadSeeker, w io.Writer, fieldIDsOrNames []string, fa form.FieldAccess, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: SetFormFieldAccess: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.SETFORMFIELDACCESS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	ok, err := form.SetFormFieldAccess(ctx, fieldIDsOrNames, fa)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoFormFieldsAffected
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// SetFormFieldAccessFile sets or clears the read-only and required flags of form fields of inFile and writes the result to outFile.
+func SetFormFieldAccessFile(inFile, outFile string, fieldIDsOrNames []string, fa form.FieldAccess, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+	}
+	logWritingTo(outFile)
+
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return SetFormFieldAccess(f1, f2, fieldIDsOrNames, fa, conf)
+}
+
+// SetSignatureFieldLock declares the form fields of rs locked once signature field sigFieldIDOrName gets signed and writes the result to w.
+func SetSignatureFieldLock(rs io.ReadSeeker, w io.Writer, sigFieldIDOrName string, fl form.FieldLock, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: SetSignatureFieldLock: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.SETSIGNATUREFIELDLOCK
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	if err := form.SetSignatureFieldLock(ctx, sigFieldIDOrName, fl); err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// SetSignatureFieldLockFile declares the form fields of inFile locked once signature field sigFieldIDOrName gets signed and writes the result to outFile.
+func SetSignatureFieldLockFile(inFile, outFile, sigFieldIDOrName string, fl form.FieldLock, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+	}
+	logWritingTo(outFile)
+
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return SetSignatureFieldLock(f1, f2, sigFieldIDOrName, fl, conf)
+}
+
 // ResetFormFields resets form fields of rs and writes the result to w.
 func ResetFormFields(rs io.ReadSeeker, w io.Writer, fieldIDsOrNames []string, conf *model.Configuration) error {
 	if rs == nil {
-		return errors.New("pdfcpu: ResetFormFields: missing rs")
+		return errors.New("pdfcpu: ResetFormFields: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.RESETFORMFIELDS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	ok, err := form.ResetFormFields(ctx, fieldIDsOrNames)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoFormFieldsAffected
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// ResetFormFieldsFile resets form fields of inFile and writes the result to outFile.
+func ResetFormFieldsFile(inFile, outFile string, fieldIDsOrNames []string, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+	}
+	logWritingTo(outFile)
+
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return ResetFormFields(f1, f2, fieldIDsOrNames, conf)
+}
+
+// AddFormFields adds the form fields collected in ff to existing pages of rs and writes the result to w.
+func AddFormFields(rs io.ReadSeeker, w io.Writer, ff *create.FormFields, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: AddFormFields: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.ADDFORMFIELDS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	if err := create.AddFormFields(ctx, ff); err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// AddFormFieldsFile adds the form fields collected in ff to existing pages of inFile and writes the result to outFile.
+func AddFormFieldsFile(inFile, outFile string, ff *create.FormFields, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+	}
+	logWritingTo(outFile)
+
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return AddFormFields(f1, f2, ff, conf)
+}
+
+// RenameFormFields renames the form fields of rs as mapped by m and writes the result to w.
+// m maps fully qualified field names or field ids to new names.
+func RenameFormFields(rs io.ReadSeeker, w io.Writer, m map[string]string, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: RenameFormFields: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.RENAMEFORMFIELDS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	c, err := form.RenameFormFields(ctx, m)
+	if err != nil {
+		return err
+	}
+	if c == 0 {
+		return ErrNoFormFieldsAffected
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// RenameFormFieldsFile renames the form fields of inFile as mapped by m and writes the result to outFile.
+func RenameFormFieldsFile(inFile, outFile string, m map[string]string, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+	}
+	logWritingTo(outFile)
+
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return RenameFormFields(f1, f2, m, conf)
+}
+
+// FieldNameMap returns the field name map read from the JSON object provided by rd eg. {"oldName": "newName"}.
+func FieldNameMap(rd io.Reader) (map[string]string, error) {
+	if rd == nil {
+		return nil, errors.New("pdfcpu: FieldNameMap: missing rd")
+	}
+
+	bb, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(bb) {
+		return nil, ErrInvalidJSON
+	}
+
+	m := map[string]string{}
+	if err := json.Unmarshal(bb, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// RenameFormFieldsJSONFile renames the form fields of inFilePDF as mapped by inFileJSON and writes the result to outFilePDF.
+func RenameFormFieldsJSONFile(inFilePDF, inFileJSON, outFilePDF string, conf *model.Configuration) error {
+	f, err := os.Open(inFileJSON)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	m, err := FieldNameMap(f)
+	if err != nil {
+		return err
+	}
+
+	return RenameFormFieldsFile(inFilePDF, outFilePDF, m, conf)
+}
+
+// FlattenForm burns the form fields of rs into the page content, removes the form and writes the result to w.
+func FlattenForm(rs io.ReadSeeker, w io.Writer, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: FlattenForm: missing rs")
 	}
 
 	if conf == nil {
 		conf = model.NewDefaultConfiguration()
 	}
-	conf.Cmd = model.RESETFORMFIELDS
+	conf.Cmd = model.FLATTENFORM
 
 	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
 	if err != nil {
@@ -323,7 +750,7 @@ func ResetFormFields(rs io.ReadSeeker, w io.Writer, fieldIDsOrNames []string, co
 		return err
 	}
 
-	ok, err := form.ResetFormFields(ctx, fieldIDsOrNames)
+	ok, err := form.FlattenForm(ctx)
 	if err != nil {
 		return err
 	}
@@ -340,8 +767,8 @@ func ResetFormFields(rs io.ReadSeeker, w io.Writer, fieldIDsOrNames []string, co
 	return WriteContext(ctx, w)
 }
 
-// ResetFormFieldsFile resets form fields of inFile and writes the result to outFile.
-func ResetFormFieldsFile(inFile, outFile string, fieldIDsOrNames []string, conf *model.Configuration) (err error) {
+// FlattenFormFile burns the form fields of inFile into the page content, removes the form and writes the result to outFile.
+func FlattenFormFile(inFile, outFile string, conf *model.Configuration) (err error) {
 	var f1, f2 *os.File
 
 	if f1, err = os.Open(inFile); err != nil {
@@ -379,7 +806,7 @@ func ResetFormFieldsFile(inFile, outFile string, fieldIDsOrNames []string, conf
 		}
 	}()
 
-	return ResetFormFields(f1, f2, fieldIDsOrNames, conf)
+	return FlattenForm(f1, f2, conf)
 }
 
 // ExportForm extracts form data originating from source from rs.
@@ -479,14 +906,199 @@ func ExportFormFile(inFilePDF, outFileJSON string, conf *model.Configuration) (e
 	return ExportFormJSON(f1, f2, inFilePDF, conf)
 }
 
-// FillForm populates the form rs with data from rd and writes the result to w.
-func FillForm(rs io.ReadSeeker, rd io.Reader, w io.Writer, conf *model.Configuration) error {
+// ExportFormSchema returns a JSON schema for the form data of rs originating from source.
+func ExportFormSchema(rs io.ReadSeeker, source string, conf *model.Configuration) (*form.Schema, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: ExportFormSchema: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.EXPORTFORMFIELDS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	s, ok, err := form.ExportFormSchema(ctx.XRefTable, source)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNoFormFieldsAffected
+	}
+
+	return s, nil
+}
+
+// ExportFormSchemaJSON writes a JSON schema for the form data of rs originating from source to w.
+// The schema describes the JSON format consumed by FillForm.
+func ExportFormSchemaJSON(rs io.ReadSeeker, w io.Writer, source string, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: ExportFormSchemaJSON: missing rs")
+	}
+
+	if w == nil {
+		return errors.New("pdfcpu: ExportFormSchemaJSON: missing w")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.EXPORTFORMFIELDS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	ok, err := form.ExportFormSchemaJSON(ctx.XRefTable, source, w)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoFormFieldsAffected
+	}
+
+	return nil
+}
+
+// ExportFormSchemaFile writes a JSON schema for the form data of inFilePDF to outFileJSON.
+func ExportFormSchemaFile(inFilePDF, outFileJSON string, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFilePDF); err != nil {
+		return err
+	}
+
+	if f2, err = os.Create(outFileJSON); err != nil {
+		f1.Close()
+		return err
+	}
+	logWritingTo(outFileJSON)
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+	}()
+
+	return ExportFormSchemaJSON(f1, f2, inFilePDF, conf)
+}
+
+// iconLookupMap returns the push button icons of icons by name.
+// Images may be used for more than one push button.
+func iconLookupMap(icons map[string]io.Reader) form.IconLookup {
+	bb := map[string][]byte{}
+	return func(name string) (io.Reader, error) {
+		if b, ok := bb[name]; ok {
+			return bytes.NewReader(b), nil
+		}
+		rd, ok := icons[name]
+		if !ok {
+			return nil, errors.Errorf("pdfcpu: missing push button icon: %s", name)
+		}
+		b, err := io.ReadAll(rd)
+		if err != nil {
+			return nil, err
+		}
+		bb[name] = b
+		return bytes.NewReader(b), nil
+	}
+}
+
+// iconLookupDir returns the push button icons by their file names relative to dir.
+// Absolute paths and paths leaving dir are rejected.
+func iconLookupDir(dir string) form.IconLookup {
+	return func(name string) (io.Reader, error) {
+		if !filepath.IsLocal(name) {
+			return nil, errors.Errorf("pdfcpu: invalid push button icon path: %s", name)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(b), nil
+	}
+}
+
+// fillForm populates ctx and returns the resulting field value changes if audit is true or the configuration asks for an audit trail.
+func fillForm(
+	ctx *model.Context,
+	fillDetails func(id, name string, fieldType form.FieldType, format form.DataFormat) ([]string, bool, bool),
+	imgs map[string]*form.Page,
+	icons form.IconLookup,
+	format form.DataFormat,
+	audit bool) (*form.Audit, error) {
+
+	audit = audit || ctx.Configuration.FormAuditTrail
+
+	var before []form.Field
+	if audit {
+		ff, _, err := form.FormFields(ctx)
+		if err != nil {
+			return nil, err
+		}
+		before = ff
+	}
+
+	ok, pp, err := form.FillForm(ctx, fillDetails, imgs, icons, format)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNoFormFieldsAffected
+	}
+
+	if _, _, err := create.UpdatePageTree(ctx, pp, nil); err != nil {
+		return nil, err
+	}
+
+	if !audit {
+		return nil, nil
+	}
+
+	after, _, err := form.FormFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a := form.NewAudit(before, after, time.Now())
+
+	if ctx.Configuration.FormAuditTrail {
+		if err := form.RecordAudit(ctx, a); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+func fillFormJSON(rs io.ReadSeeker, rd io.Reader, w io.Writer, icons form.IconLookup, audit bool, conf *model.Configuration) (*form.Audit, error) {
 	if rs == nil {
-		return errors.New("pdfcpu: FillForm: missing rs")
+		return nil, errors.New("pdfcpu: FillForm: missing rs")
 	}
 
 	if rd == nil {
-		return errors.New("pdfcpu: FillForm: missing rd")
+		return nil, errors.New("pdfcpu: FillForm: missing rd")
 	}
 
 	if conf == nil {
@@ -496,70 +1108,85 @@ func FillForm(rs io.ReadSeeker, rd io.Reader, w io.Writer, conf *model.Configura
 
 	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	ctx.RemoveSignature()
 
 	if err := ctx.EnsurePageCount(); err != nil {
-		return err
+		return nil, err
 	}
 
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, rd); err != nil {
-		return err
+		return nil, err
 	}
 
 	bb := buf.Bytes()
 
 	if !json.Valid(bb) {
-		return ErrInvalidJSON
+		return nil, ErrInvalidJSON
 	}
 
 	formGroup := form.FormGroup{}
 
 	if err := json.Unmarshal(bb, &formGroup); err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(formGroup.Forms) == 0 {
-		return ErrNoFormData
+		return nil, ErrNoFormData
 	}
 
 	f := formGroup.Forms[0]
 
-	ok, pp, err := form.FillForm(ctx, form.FillDetails(&f, nil), f.Pages, form.JSON)
+	a, err := fillForm(ctx, form.FillDetails(&f, nil), f.Pages, icons, form.JSON, audit)
 	if err != nil {
-		return err
-	}
-	if !ok {
-		return ErrNoFormFieldsAffected
-	}
-
-	if _, _, err := create.UpdatePageTree(ctx, pp, nil); err != nil {
-		return err
+		return nil, err
 	}
 
 	if conf.ValidationMode != model.ValidationNone {
 		if err = ValidateContext(ctx); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return WriteContext(ctx, w)
+	if err := WriteContext(ctx, w); err != nil {
+		return nil, err
+	}
+
+	return a, nil
 }
 
-// FillFormFile populates the form inFilePDF with data from inFileJSON and writes the result to outFilePDF.
-func FillFormFile(inFilePDF, inFileJSON, outFilePDF string, conf *model.Configuration) (err error) {
+// FillForm populates the form rs with data from rd and writes the result to w.
+func FillForm(rs io.ReadSeeker, rd io.Reader, w io.Writer, conf *model.Configuration) error {
+	_, err := fillFormJSON(rs, rd, w, nil, false, conf)
+	return err
+}
+
+// FillFormWithIcons populates the form rs with data from rd and writes the result to w.
+// Push button icons are taken from icons by the image names used in rd.
+func FillFormWithIcons(rs io.ReadSeeker, rd io.Reader, w io.Writer, icons map[string]io.Reader, conf *model.Configuration) error {
+	_, err := fillFormJSON(rs, rd, w, iconLookupMap(icons), false, conf)
+	return err
+}
+
+// FillFormAudit populates the form rs with data from rd, writes the result to w and returns the resulting field value changes.
+// If conf.FormAuditTrail is set the changes also get recorded into the XMP metadata of w.
+func FillFormAudit(rs io.ReadSeeker, rd io.Reader, w io.Writer, conf *model.Configuration) (*form.Audit, error) {
+	return fillFormJSON(rs, rd, w, nil, true, conf)
+}
+
+func fillFormFile(inFilePDF, inFileJSON, outFilePDF string, audit bool, conf *model.Configuration) (a *form.Audit, err error) {
 	var f0, f1, f2 *os.File
 
 	if f0, err = os.Open(inFileJSON); err != nil {
-		return err
+		return nil, err
 	}
 
 	if f1, err = os.Open(inFilePDF); err != nil {
 		f0.Close()
-		return err
+		return nil, err
 	}
 	rs := f1
 
@@ -572,7 +1199,7 @@ func FillFormFile(inFilePDF, inFileJSON, outFilePDF string, conf *model.Configur
 	if f2, err = os.Create(tmpFile); err != nil {
 		f1.Close()
 		f0.Close()
-		return err
+		return nil, err
 	}
 
 	defer func() {
@@ -599,7 +1226,20 @@ func FillFormFile(inFilePDF, inFileJSON, outFilePDF string, conf *model.Configur
 		}
 	}()
 
-	return FillForm(rs, f0, f2, conf)
+	return fillFormJSON(rs, f0, f2, iconLookupDir(filepath.Dir(inFileJSON)), audit, conf)
+}
+
+// FillFormFile populates the form inFilePDF with data from inFileJSON and writes the result to outFilePDF.
+// Push button icons are image files relative to the directory of inFileJSON.
+func FillFormFile(inFilePDF, inFileJSON, outFilePDF string, conf *model.Configuration) error {
+	_, err := fillFormFile(inFilePDF, inFileJSON, outFilePDF, false, conf)
+	return err
+}
+
+// FillFormAuditFile populates the form inFilePDF with data from inFileJSON, writes the result to outFilePDF
+// and returns the resulting field value changes.
+func FillFormAuditFile(inFilePDF, inFileJSON, outFilePDF string, conf *model.Configuration) (*form.Audit, error) {
+	return fillFormFile(inFilePDF, inFileJSON, outFilePDF, true, conf)
 }
 
 func parseFormGroup(rd io.Reader) (*form.FormGroup, error) {
@@ -643,7 +1283,7 @@ func mergeForms(outDir, fileName string, outFiles []string, conf *model.Configur
 	return nil
 }
 
-func multiFillFormJSON(inFilePDF string, rd io.Reader, outDir, fileName string, merge bool, conf *model.Configuration) error {
+func multiFillFormJSON(inFilePDF string, rd io.Reader, icons form.IconLookup, outDir, fileName string, merge bool, conf *model.Configuration) error {
 	formGroup, err := parseFormGroup(rd)
 	if err != nil {
 		return err
@@ -668,15 +1308,7 @@ func multiFillFormJSON(inFilePDF string, rd io.Reader, outDir, fileName string,
 			return err
 		}
 
-		ok, pp, err := form.FillForm(ctx, form.FillDetails(&f, nil), f.Pages, form.JSON)
-		if err != nil {
-			return err
-		}
-		if !ok {
-			return ErrNoFormFieldsAffected
-		}
-
-		if _, _, err := create.UpdatePageTree(ctx, pp, nil); err != nil {
+		if _, err := fillForm(ctx, form.FillDetails(&f, nil), f.Pages, icons, form.JSON, false); err != nil {
 			return err
 		}
 
@@ -736,7 +1368,7 @@ func parseCSVLines(rd io.Reader) ([][]string, error) {
 	return csvLines, nil
 }
 
-func multiFillFormCSV(inFilePDF string, rd io.Reader, outDir, fileName string, merge bool, conf *model.Configuration) error {
+func multiFillFormCSV(inFilePDF string, rd io.Reader, icons form.IconLookup, outDir, fileName string, merge bool, conf *model.Configuration) error {
 	csvLines, err := parseCSVLines(rd)
 	if err != nil {
 		return err
@@ -767,15 +1399,7 @@ func multiFillFormCSV(inFilePDF string, rd io.Reader, outDir, fileName string, m
 			return err
 		}
 
-		ok, pp, err := form.FillForm(ctx, form.FillDetails(nil, fieldMap), imgPageMap, form.CSV)
-		if err != nil {
-			return err
-		}
-		if !ok {
-			return ErrNoFormFieldsAffected
-		}
-
-		if _, _, err := create.UpdatePageTree(ctx, pp, nil); err != nil {
+		if _, err := fillForm(ctx, form.FillDetails(nil, fieldMap), imgPageMap, icons, form.CSV, false); err != nil {
 			return err
 		}
 
@@ -802,8 +1426,7 @@ func multiFillFormCSV(inFilePDF string, rd io.Reader, outDir, fileName string, m
 	return nil
 }
 
-// MultiFillForm populates multiples instances of inFilePDF's form with data from rd and writes the result to outDir.
-func MultiFillForm(inFilePDF string, rd io.Reader, outDir, fileName string, format form.DataFormat, merge bool, conf *model.Configuration) error {
+func multiFillForm(inFilePDF string, rd io.Reader, icons form.IconLookup, outDir, fileName string, format form.DataFormat, merge bool, conf *model.Configuration) error {
 	if conf == nil {
 		conf = model.NewDefaultConfiguration()
 	}
@@ -812,13 +1435,19 @@ func MultiFillForm(inFilePDF string, rd io.Reader, outDir, fileName string, form
 	fileName = strings.TrimSuffix(filepath.Base(fileName), ".pdf")
 
 	if format == form.JSON {
-		return multiFillFormJSON(inFilePDF, rd, outDir, fileName, merge, conf)
+		return multiFillFormJSON(inFilePDF, rd, icons, outDir, fileName, merge, conf)
 	}
 
-	return multiFillFormCSV(inFilePDF, rd, outDir, fileName, merge, conf)
+	return multiFillFormCSV(inFilePDF, rd, icons, outDir, fileName, merge, conf)
+}
+
+// MultiFillForm populates multiples instances of inFilePDF's form with data from rd and writes the result to outDir.
+func MultiFillForm(inFilePDF string, rd io.Reader, outDir, fileName string, format form.DataFormat, merge bool, conf *model.Configuration) error {
+	return multiFillForm(inFilePDF, rd, nil, outDir, fileName, format, merge, conf)
 }
 
 // MultiFillFormFile populates multiples instances of inFilePDFs form with data from inFileData and writes the result to outDir.
+// Push button icons are image files relative to the directory of inFileData.
 func MultiFillFormFile(inFilePDF, inFileData, outDir, outFilePDF string, merge bool, conf *model.Configuration) (err error) {
 	format := form.JSON
 	if strings.HasSuffix(strings.ToLower(inFileData), ".csv") {
@@ -849,5 +1478,5 @@ func MultiFillFormFile(inFilePDF, inFileData, outDir, outFilePDF string, merge b
 		log.CLI.Printf("filling multiple forms via %s based on %s data from %s into %s/%s ...\n", inFilePDF, s, inFileData, outDir, outFileBase)
 	}
 
-	return MultiFillForm(inFilePDF, f, outDir, outFileBase, format, merge, conf)
+	return multiFillForm(inFilePDF, f, iconLookupDir(filepath.Dir(inFileData)), outDir, outFileBase, format, merge, conf)
 }
@@ -0,0 +1,286 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/form"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// FormDataExport represents the export of form field values of all PDF files in a directory tree into one table.
+type FormDataExport struct {
+	InDir   string               // Root of the directory tree to be processed.
+	Fields  []string             // Field ids or names making up the columns, for JSON defaults to all fields.
+	Format  form.DataFormat      // CSV or JSON lines.
+	Workers int                  // Number of files processed concurrently, defaults to runtime.NumCPU().
+	Conf    *model.Configuration // Each file gets processed using a copy.
+}
+
+// formDataRow represents the field values of a single file.
+type formDataRow struct {
+	File   string            `json:"file"`
+	Fields map[string]string `json:"fields"`
+	Error  string            `json:"error,omitempty"`
+}
+
+func readFormFieldValues(inFile string, fieldNames []string, conf *model.Configuration) (map[string]string, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ctx, _, _, err := readAndValidate(f, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	fs, _, err := form.FormFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]string{}
+
+	if len(fieldNames) == 0 {
+		for _, f := range fs {
+			m[f.Name] = f.V
+		}
+		return m, nil
+	}
+
+	for _, fn := range fieldNames {
+		for _, f := range fs {
+			if f.Name == fn || f.ID == fn {
+				m[fn] = f.V
+				break
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (e FormDataExport) process(inFile string) (formDataRow, BatchResult) {
+	from := time.Now()
+
+	rel, err := filepath.Rel(e.InDir, inFile)
+	if err != nil {
+		rel = inFile
+	}
+	row := formDataRow{File: filepath.ToSlash(rel)}
+	res := BatchResult{InFile: inFile}
+
+	if log.CLIEnabled() {
+		log.CLI.Printf("reading %s\n", inFile)
+	}
+
+	c := *e.Conf
+	row.Fields, res.Err = readFormFieldValues(inFile, e.Fields, &c)
+	if res.Err != nil {
+		row.Error = res.Err.Error()
+	}
+
+	res.Duration = time.Since(from)
+
+	return row, res
+}
+
+type formDataWriter interface {
+	write(row formDataRow) error
+	flush() error
+}
+
+type csvFormDataWriter struct {
+	w      *csv.Writer
+	fields []string
+}
+
+func (cw csvFormDataWriter) write(row formDataRow) error {
+	rec := make([]string, 0, len(cw.fields)+2)
+	rec = append(rec, row.File)
+	for _, fn := range cw.fields {
+		rec = append(rec, row.Fields[fn])
+	}
+	return cw.w.Write(append(rec, row.Error))
+}
+
+func (cw csvFormDataWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type jsonFormDataWriter struct {
+	enc *json.Encoder
+}
+
+func (jw jsonFormDataWriter) write(row formDataRow) error {
+	if row.Fields == nil {
+		row.Fields = map[string]string{}
+	}
+	return jw.enc.Encode(row)
+}
+
+func (jw jsonFormDataWriter) flush() error {
+	return nil
+}
+
+func (e FormDataExport) writer(w io.Writer) (formDataWriter, error) {
+	if e.Format == form.JSON {
+		return jsonFormDataWriter{enc: json.NewEncoder(w)}, nil
+	}
+
+	cw := csv.NewWriter(w)
+	header := append(append([]string{"file"}, e.Fields...), "error")
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+
+	return csvFormDataWriter{w: cw, fields: e.Fields}, nil
+}
+
+// ExportFormData writes one row of form field values per PDF file found in the directory tree rooted at e.InDir to w.
+// CSV output starts with a header line: file, the selected fields, error.
+// JSON output consists of JSON lines: {"file": ..., "fields": {...}, "error": ...}.
+// Failing files do not stop the export, their errors are recorded in the corresponding row and in the returned report.
+func ExportFormData(e FormDataExport, w io.Writer) (*BatchReport, error) {
+	if e.InDir == "" {
+		return nil, errors.New("pdfcpu: ExportFormData: missing inDir")
+	}
+
+	if w == nil {
+		return nil, errors.New("pdfcpu: ExportFormData: missing w")
+	}
+
+	if e.Format == form.CSV && len(e.Fields) == 0 {
+		return nil, errors.New("pdfcpu: ExportFormData: missing fields for CSV")
+	}
+
+	if e.Conf == nil {
+		e.Conf = model.NewDefaultConfiguration()
+	}
+	e.Conf.Cmd = model.EXPORTFORMFIELDS
+
+	if e.Workers <= 0 {
+		e.Workers = runtime.NumCPU()
+	}
+
+	from := time.Now()
+
+	inFiles, err := batchInFiles(e.InDir, "")
+	if err != nil {
+		return nil, err
+	}
+
+	fw, err := e.writer(w)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &BatchReport{Results: make([]BatchResult, len(inFiles))}
+	rows := make([]formDataRow, len(inFiles))
+
+	jobs := make(chan int)
+	done := make(chan int)
+
+	for i := 0; i < e.Workers; i++ {
+		go func() {
+			for j := range jobs {
+				rows[j], rep.Results[j] = e.process(inFiles[j])
+				done <- j
+			}
+		}()
+	}
+
+	go func() {
+		for i := range inFiles {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	// Write rows in input order as soon as they become available.
+	ready := make([]bool, len(inFiles))
+	next := 0
+	var werr error
+
+	for range inFiles {
+		ready[<-done] = true
+		for ; next < len(inFiles) && ready[next]; next++ {
+			if werr == nil {
+				werr = fw.write(rows[next])
+			}
+			rows[next] = formDataRow{}
+		}
+	}
+
+	if werr != nil {
+		return nil, werr
+	}
+
+	if err := fw.flush(); err != nil {
+		return nil, err
+	}
+
+	for _, res := range rep.Results {
+		if res.Err != nil {
+			rep.Failed++
+			continue
+		}
+		rep.Processed++
+	}
+
+	rep.Duration = time.Since(from)
+
+	return rep, nil
+}
+
+// ExportFormDataFile writes one row of form field values per PDF file found in the directory tree rooted at e.InDir to outFile.
+func ExportFormDataFile(e FormDataExport, outFile string) (rep *BatchReport, err error) {
+	var f *os.File
+
+	if f, err = os.Create(outFile); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(outFile)
+			return
+		}
+		err = f.Close()
+	}()
+
+	logWritingTo(outFile)
+
+	return ExportFormData(e, f)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// AddHeaderFooter adds header and footer hf to all pages selected in rs and writes the result to w.
+// Any header and footer added before gets replaced.
+func AddHeaderFooter(rs io.ReadSeeker, w io.Writer, selectedPages []string, hf *model.HeaderFooter, conf *model.Configuration) error {
+	if hf == nil {
+		return errors.New("pdfcpu: AddHeaderFooter: missing header/footer")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.OptimizeDuplicateContentStreams = false
+
+	return modifyContext(rs, w, model.ADDWATERMARKS, conf, func(ctx *model.Context) error {
+		pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+		if err != nil {
+			return err
+		}
+		return pdfcpu.AddHeaderFooter(ctx, pages, hf)
+	})
+}
+
+// AddHeaderFooterFile adds header and footer hf to all pages selected in inFile and writes the result to outFile.
+func AddHeaderFooterFile(inFile, outFile string, selectedPages []string, hf *model.HeaderFooter, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return AddHeaderFooter(rs, w, selectedPages, hf, conf)
+	})
+}
+
+// RemoveHeaderFooter removes header and footer from all pages selected in rs and writes the result to w.
+func RemoveHeaderFooter(rs io.ReadSeeker, w io.Writer, selectedPages []string, conf *model.Configuration) error {
+	return modifyContext(rs, w, model.REMOVEWATERMARKS, conf, func(ctx *model.Context) error {
+		pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+		if err != nil {
+			return err
+		}
+		return pdfcpu.RemoveHeaderFooter(ctx, pages)
+	})
+}
+
+// RemoveHeaderFooterFile removes header and footer from all pages selected in inFile and writes the result to outFile.
+func RemoveHeaderFooterFile(inFile, outFile string, selectedPages []string, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return RemoveHeaderFooter(rs, w, selectedPages, conf)
+	})
+}
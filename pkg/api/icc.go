@@ -0,0 +1,229 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// ICCProfiles returns all ICC profiles of rs used by ICCBased color spaces or output intents.
+func ICCProfiles(rs io.ReadSeeker, conf *model.Configuration) ([]pdfcpu.ICCProfile, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: ICCProfiles: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.ICCProfiles(ctx)
+}
+
+// ICCProfilesFile returns all ICC profiles of inFile used by ICCBased color spaces or output intents.
+func ICCProfilesFile(inFile string, conf *model.Configuration) ([]pdfcpu.ICCProfile, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ICCProfiles(f, conf)
+}
+
+// ExtractICCProfiles writes ICC profiles of rs into outDir.
+// If objNrs is empty all profiles get extracted.
+func ExtractICCProfiles(rs io.ReadSeeker, outDir, fileName string, objNrs []int, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: ExtractICCProfiles: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if len(objNrs) == 0 {
+		pp, err := pdfcpu.ICCProfiles(ctx)
+		if err != nil {
+			return err
+		}
+		for _, p := range pp {
+			objNrs = append(objNrs, p.ObjNr)
+		}
+	}
+
+	fileName = strings.TrimSuffix(filepath.Base(fileName), ".pdf")
+
+	for _, objNr := range objNrs {
+		bb, err := pdfcpu.ICCProfileData(ctx, objNr)
+		if err != nil {
+			return err
+		}
+		outFile := filepath.Join(outDir, fmt.Sprintf("%s_%d.icc", fileName, objNr))
+		logWritingTo(outFile)
+		if err := os.WriteFile(outFile, bb, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExtractICCProfilesFile writes ICC profiles of inFile into outDir.
+// If objNrs is empty all profiles get extracted.
+func ExtractICCProfilesFile(inFile, outDir string, objNrs []int, conf *model.Configuration) error {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if log.CLIEnabled() {
+		log.CLI.Printf("extracting ICC profiles from %s into %s/ ...\n", inFile, outDir)
+	}
+
+	return ExtractICCProfiles(f, outDir, filepath.Base(inFile), objNrs, conf)
+}
+
+// ReplaceICCProfiles replaces ICC profiles of rs with the ICC profile read from rd and writes the result to w.
+// If objNrs is empty all profiles with a matching number of color components get replaced.
+func ReplaceICCProfiles(rs io.ReadSeeker, w io.Writer, rd io.Reader, objNrs []int, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: ReplaceICCProfiles: missing rs")
+	}
+
+	if rd == nil {
+		return errors.New("pdfcpu: ReplaceICCProfiles: missing rd")
+	}
+
+	bb, err := io.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	fromStart := time.Now()
+	ctx, durRead, durVal, durOpt, err := ReadValidateAndOptimize(rs, conf, fromStart)
+	if err != nil {
+		return err
+	}
+
+	from := time.Now()
+
+	replaced, err := pdfcpu.ReplaceICCProfiles(ctx, bb, objNrs)
+	if err != nil {
+		return err
+	}
+
+	if len(replaced) == 0 {
+		return errors.New("pdfcpu: no matching ICC profiles found")
+	}
+
+	if log.CLIEnabled() {
+		log.CLI.Printf("replaced %d ICC profile(s)\n", len(replaced))
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	durReplace := time.Since(from).Seconds()
+	fromWrite := time.Now()
+
+	if err = WriteContext(ctx, w); err != nil {
+		return err
+	}
+
+	durWrite := durReplace + time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+	logOperationStats(ctx, "replace ICC profiles, write", durRead, durVal, durOpt, durWrite, durTotal)
+
+	return nil
+}
+
+// ReplaceICCProfilesFile replaces ICC profiles of inFile with the ICC profile iccFile and writes the result to outFile.
+// If objNrs is empty all profiles with a matching number of color components get replaced.
+func ReplaceICCProfilesFile(inFile, outFile, iccFile string, objNrs []int, conf *model.Configuration) (err error) {
+	var f0, f1, f2 *os.File
+
+	if f0, err = os.Open(iccFile); err != nil {
+		return err
+	}
+	defer f0.Close()
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return ReplaceICCProfiles(f1, f2, f0, objNrs, conf)
+}
@@ -18,6 +18,7 @@ package api
 
 import (
 	"io"
+	"os"
 	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
@@ -54,3 +55,106 @@ func Images(rs io.ReadSeeker, selectedPages []string, conf *model.Configuration)
 
 	return ii, err
 }
+
+// AlternateImages returns the alternates of the image with object number objNr of rs.
+func AlternateImages(rs io.ReadSeeker, objNr int, conf *model.Configuration) ([]pdfcpu.AlternateImage, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: AlternateImages: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTIMAGES
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.AlternateImages(ctx, objNr)
+}
+
+// AddAlternateImage adds the image read from rImg as an alternate to the image with object number objNr of rs
+// and writes the result to w.
+// Use defaultForPrinting for a high resolution version of a screen image.
+func AddAlternateImage(rs io.ReadSeeker, w io.Writer, objNr int, rImg io.Reader, defaultForPrinting bool, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: AddAlternateImage: missing rs")
+	}
+
+	if rImg == nil {
+		return errors.New("pdfcpu: AddAlternateImage: missing rImg")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.ADDALTERNATEIMAGE
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if _, err = pdfcpu.AddAlternateImage(ctx, objNr, rImg, defaultForPrinting); err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// AddAlternateImageFile adds imageFile as an alternate to the image with object number objNr of inFile
+// and writes the result to outFile.
+func AddAlternateImageFile(inFile, outFile string, objNr int, imageFile string, defaultForPrinting bool, conf *model.Configuration) (err error) {
+	var f0, f1, f2 *os.File
+
+	if f0, err = os.Open(imageFile); err != nil {
+		return err
+	}
+	defer f0.Close()
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return AddAlternateImage(f1, f2, objNr, f0, defaultForPrinting, conf)
+}
@@ -33,9 +33,64 @@ func Import(s string, u types.DisplayUnit) (*pdfcpu.Import, error) {
 	return pdfcpu.ParseImportDetails(s, u)
 }
 
+// ImageSource returns the next image to be imported or io.EOF if there are no more images.
+// Images implementing io.Closer get closed once they have been processed.
+type ImageSource func() (io.Reader, error)
+
+// ImageSourceForReaders returns an ImageSource for imgs.
+func ImageSourceForReaders(imgs []io.Reader) ImageSource {
+	i := 0
+	return func() (io.Reader, error) {
+		if i == len(imgs) {
+			return nil, io.EOF
+		}
+		i++
+		return imgs[i-1], nil
+	}
+}
+
+// ImageSourceForFiles returns an ImageSource opening imgFiles one at a time.
+func ImageSourceForFiles(imgFiles []string) ImageSource {
+	i := 0
+	return func() (io.Reader, error) {
+		if i == len(imgFiles) {
+			return nil, io.EOF
+		}
+		i++
+		return os.Open(imgFiles[i-1])
+	}
+}
+
 // ImportImages appends PDF pages containing images to rs and writes the result to w.
 // If rs == nil a new PDF file will be written to w.
 func ImportImages(rs io.ReadSeeker, w io.Writer, imgs []io.Reader, imp *pdfcpu.Import, conf *model.Configuration) error {
+	return ImportImagesFromSource(rs, w, ImageSourceForReaders(imgs), imp, conf)
+}
+
+func importImage(ctx *model.Context, r io.Reader, pagesIndRef *types.IndirectRef, pagesDict types.Dict, imp *pdfcpu.Import) error {
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	indRef, err := pdfcpu.NewPageForImage(ctx.XRefTable, r, pagesIndRef, imp)
+	if err != nil {
+		return err
+	}
+
+	if err = model.AppendPageTree(indRef, 1, pagesDict); err != nil {
+		return err
+	}
+
+	ctx.PageCount++
+
+	// Write out the image and release its data.
+	return pdfcpu.FlushPageImages(ctx, *indRef)
+}
+
+// ImportImagesFromSource appends PDF pages containing images provided by next to rs and writes the result to w.
+// If rs == nil a new PDF file will be written to w.
+// Images are processed one at a time and written to w right away which keeps memory bounded for large numbers of images.
+func ImportImagesFromSource(rs io.ReadSeeker, w io.Writer, next ImageSource, imp *pdfcpu.Import, conf *model.Configuration) error {
 	if conf == nil {
 		conf = model.NewDefaultConfiguration()
 	}
@@ -70,18 +125,28 @@ func ImportImages(rs io.ReadSeeker, w io.Writer, imgs []io.Reader, imp *pdfcpu.I
 		return err
 	}
 
-	for _, r := range imgs {
+	if f, ok := w.(*os.File); ok {
+		// In order to retrieve the written file size.
+		ctx.Write.Fp = f
+	}
+	ctx.Write.Writer = bufio.NewWriter(w)
+	defer ctx.Write.Flush()
+
+	if err = pdfcpu.BeginWrite(ctx); err != nil {
+		return err
+	}
 
-		indRef, err := pdfcpu.NewPageForImage(ctx.XRefTable, r, pagesIndRef, imp)
+	for {
+		r, err := next()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			return err
 		}
-
-		if err = model.AppendPageTree(indRef, 1, pagesDict); err != nil {
+		if err = importImage(ctx, r, pagesIndRef, pagesDict, imp); err != nil {
 			return err
 		}
-
-		ctx.PageCount++
 	}
 
 	if conf.ValidationMode != model.ValidationNone {
@@ -90,7 +155,7 @@ func ImportImages(rs io.ReadSeeker, w io.Writer, imgs []io.Reader, imp *pdfcpu.I
 		}
 	}
 
-	if err = WriteContext(ctx, w); err != nil {
+	if err = pdfcpu.Write(ctx); err != nil {
 		return err
 	}
 
@@ -112,25 +177,6 @@ func fileExists(filename string) bool {
 
 }
 
-func prepImgFiles(imgFiles []string, f1 *os.File) ([]io.ReadCloser, []io.Reader, error) {
-	rc := make([]io.ReadCloser, len(imgFiles))
-	rr := make([]io.Reader, len(imgFiles))
-
-	for i, fn := range imgFiles {
-		f, err := os.Open(fn)
-		if err != nil {
-			if f1 != nil {
-				f1.Close()
-			}
-			return nil, nil, err
-		}
-		rc[i] = f
-		rr[i] = bufio.NewReader(f)
-	}
-
-	return rc, rr, nil
-}
-
 func logImportImages(s, outFile string) {
 	if log.CLIEnabled() {
 		log.CLI.Printf("%s to %s...\n", s, outFile)
@@ -155,11 +201,6 @@ func ImportImagesFile(imgFiles []string, outFile string, imp *pdfcpu.Import, con
 		logImportImages("writing", outFile)
 	}
 
-	rc, rr, err := prepImgFiles(imgFiles, f1)
-	if err != nil {
-		return err
-	}
-
 	if f2, err = os.Create(tmpFile); err != nil {
 		if f1 != nil {
 			f1.Close()
@@ -174,9 +215,6 @@ func ImportImagesFile(imgFiles []string, outFile string, imp *pdfcpu.Import, con
 				f1.Close()
 				os.Remove(tmpFile)
 			}
-			for _, f := range rc {
-				f.Close()
-			}
 			return
 		}
 		if err = f2.Close(); err != nil {
@@ -190,12 +228,7 @@ func ImportImagesFile(imgFiles []string, outFile string, imp *pdfcpu.Import, con
 				return
 			}
 		}
-		for _, f := range rc {
-			if err := f.Close(); err != nil {
-				return
-			}
-		}
 	}()
 
-	return ImportImages(rs, f2, rr, imp, conf)
+	return ImportImagesFromSource(rs, f2, ImageSourceForFiles(imgFiles), imp, conf)
 }
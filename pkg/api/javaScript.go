@@ -0,0 +1,217 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// AnalyzeJavaScript extracts all scripts of rs and returns a static analysis risk report.
+func AnalyzeJavaScript(rs io.ReadSeeker, conf *model.Configuration) (*pdfcpu.JavaScriptReport, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: AnalyzeJavaScript: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.ANALYZEJAVASCRIPT
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.AnalyzeJavaScript(ctx)
+}
+
+// AnalyzeJavaScriptFile extracts all scripts of inFile and returns a static analysis risk report.
+func AnalyzeJavaScriptFile(inFile string, conf *model.Configuration) (*pdfcpu.JavaScriptReport, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return AnalyzeJavaScript(f, conf)
+}
+
+// Scripts returns all document, page, annotation and form field level JavaScript actions of rs.
+func Scripts(rs io.ReadSeeker, conf *model.Configuration) ([]pdfcpu.Script, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: Scripts: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTJAVASCRIPT
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.Scripts(ctx)
+}
+
+// ScriptsFile returns all document, page, annotation and form field level JavaScript actions of inFile.
+func ScriptsFile(inFile string, conf *model.Configuration) ([]pdfcpu.Script, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Scripts(f, conf)
+}
+
+// RemoveScripts removes the JavaScript actions of rs selected by f or all JavaScript actions if f is nil
+// and writes the result to w.
+// Actions chained to removed JavaScript actions eg. submit form actions are preserved.
+func RemoveScripts(rs io.ReadSeeker, w io.Writer, f func(s pdfcpu.Script) bool, conf *model.Configuration) error {
+	return modifyContext(rs, w, model.REMOVEJAVASCRIPT, conf, func(ctx *model.Context) error {
+		c, err := pdfcpu.RemoveScripts(ctx, f)
+		if err != nil {
+			return err
+		}
+		if c == 0 {
+			return errors.New("pdfcpu: no scripts removed")
+		}
+		return nil
+	})
+}
+
+// RemoveScriptsFile removes the JavaScript actions of inFile selected by f or all JavaScript actions if f is nil
+// and writes the result to outFile.
+func RemoveScriptsFile(inFile, outFile string, f func(s pdfcpu.Script) bool, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return RemoveScripts(f1, f2, f, conf)
+}
+
+// ReplaceScripts replaces the source of all JavaScript actions of rs for which f returns true by the returned source
+// and writes the result to w.
+func ReplaceScripts(rs io.ReadSeeker, w io.Writer, f func(s pdfcpu.Script) (string, bool), conf *model.Configuration) error {
+	if f == nil {
+		return errors.New("pdfcpu: ReplaceScripts: missing f")
+	}
+	return modifyContext(rs, w, model.REPLACEJAVASCRIPT, conf, func(ctx *model.Context) error {
+		c, err := pdfcpu.ReplaceScripts(ctx, f)
+		if err != nil {
+			return err
+		}
+		if c == 0 {
+			return errors.New("pdfcpu: no scripts replaced")
+		}
+		return nil
+	})
+}
+
+// ReplaceScriptsFile replaces the source of all JavaScript actions of inFile for which f returns true by the returned source
+// and writes the result to outFile.
+func ReplaceScriptsFile(inFile, outFile string, f func(s pdfcpu.Script) (string, bool), conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return ReplaceScripts(f1, f2, f, conf)
+}
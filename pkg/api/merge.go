@@ -29,15 +29,15 @@ import (
 	"github.com/pkg/errors"
 )
 
-// appendTo appends inFile to ctxDest's page tree.
-func appendTo(rs io.ReadSeeker, fName string, ctxDest *model.Context) error {
+// appendTo appends inFile to ctxDest's page tree and returns the named destinations of inFile renamed while merging.
+func appendTo(rs io.ReadSeeker, fName string, ctxDest *model.Context) (map[string]string, error) {
 	ctxSource, _, _, err := readAndValidate(rs, ctxDest.Configuration, time.Now())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Merge source context into dest context.
-	return pdfcpu.MergeXRefTables(fName, ctxSource, ctxDest)
+	return pdfcpu.MergeXRefTablesRenamingDests(fName, ctxSource, ctxDest)
 }
 
 // MergeRaw merges a sequence of PDF streams and writes the result to w.
@@ -56,6 +56,7 @@ func MergeRaw(rsc []io.ReadSeeker, w io.Writer, conf *model.Configuration) error
 	conf.Cmd = model.MERGECREATE
 	conf.ValidationMode = model.ValidationRelaxed
 	conf.CreateBookmarks = false
+	conf.DividerPages = false
 
 	ctxDest, _, _, err := readAndValidate(rsc[0], conf, time.Now())
 	if err != nil {
@@ -65,7 +66,7 @@ func MergeRaw(rsc []io.ReadSeeker, w io.Writer, conf *model.Configuration) error
 	ctxDest.EnsureVersionForWriting()
 
 	for i, f := range rsc[1:] {
-		if err = appendTo(f, strconv.Itoa(i), ctxDest); err != nil {
+		if _, err = appendTo(f, strconv.Itoa(i), ctxDest); err != nil {
 			return err
 		}
 	}
@@ -77,6 +78,192 @@ func MergeRaw(rsc []io.ReadSeeker, w io.Writer, conf *model.Configuration) error
 	return WriteContext(ctxDest, w)
 }
 
+func appendToStream(rs io.ReadSeeker, title string, sm *pdfcpu.StreamMerger, conf *model.Configuration) error {
+	ctxSource, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// ctxSource is no longer needed once its pages have been written.
+	return sm.Append(ctxSource, title)
+}
+
+// MergeStream merges a sequence of PDF streams and writes the result to w.
+// Unlike MergeRaw each source gets written right after it has been read
+// which keeps memory usage bounded regardless of the number of sources.
+// Only pages are merged, document level structures like forms or outlines of the sources get dropped.
+// If conf.CreateBookmarks is set a bookmark for each source is created.
+func MergeStream(rsc []io.ReadSeeker, w io.Writer, conf *model.Configuration) error {
+	if rsc == nil {
+		return errors.New("pdfcpu: MergeStream: missing rsc")
+	}
+
+	if w == nil {
+		return errors.New("pdfcpu: MergeStream: missing w")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.MERGECREATE
+	conf.ValidationMode = model.ValidationRelaxed
+
+	sm, err := pdfcpu.NewStreamMerger(w, conf)
+	if err != nil {
+		return err
+	}
+
+	for i, rs := range rsc {
+		if err := appendToStream(rs, strconv.Itoa(i), sm, conf); err != nil {
+			return err
+		}
+	}
+
+	return sm.Close()
+}
+
+// MergeStreamFile merges inFiles and writes the result to outFile.
+// The input files are read one at a time and released once their pages have been written.
+// Only pages are merged, document level structures like forms or outlines of the input files get dropped.
+// If conf.CreateBookmarks is set a bookmark for each input file is created.
+func MergeStreamFile(inFiles []string, outFile string, conf *model.Configuration) (err error) {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.MERGECREATE
+	conf.ValidationMode = model.ValidationRelaxed
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		cerr := f.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	logWritingTo(outFile)
+
+	sm, err := pdfcpu.NewStreamMerger(f, conf)
+	if err != nil {
+		return err
+	}
+
+	for _, fName := range inFiles {
+		if err := func() error {
+			f, err := os.Open(fName)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			if log.CLIEnabled() {
+				log.CLI.Println(fName)
+			}
+
+			return appendToStream(f, filepath.Base(fName), sm, conf)
+		}(); err != nil {
+			return err
+		}
+	}
+
+	return sm.Close()
+}
+
+// zipPageNrs returns the page sequence interleaving n1 pages with n2 subsequent pages.
+func zipPageNrs(n1, n2 int) []int {
+	pageNrs := make([]int, 0, n1+n2)
+	for i := 1; i <= n1 || i <= n2; i++ {
+		if i <= n1 {
+			pageNrs = append(pageNrs, i)
+		}
+		if i <= n2 {
+			pageNrs = append(pageNrs, n1+i)
+		}
+	}
+	return pageNrs
+}
+
+// MergeZip merges rs1 and rs2 by interleaving their pages (A1, B1, A2, B2, ...) and writes the result to w.
+// Any remaining pages of the longer input get appended.
+// This recombines separately scanned front and back sides of duplex documents.
+func MergeZip(rs1, rs2 io.ReadSeeker, w io.Writer, conf *model.Configuration) error {
+	if rs1 == nil || rs2 == nil {
+		return errors.New("pdfcpu: MergeZip: missing rs")
+	}
+
+	if w == nil {
+		return errors.New("pdfcpu: MergeZip: missing w")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.MERGECREATE
+	conf.ValidationMode = model.ValidationRelaxed
+	conf.CreateBookmarks = false
+	conf.DividerPages = false
+
+	ctxDest, _, _, err := readAndValidate(rs1, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctxDest.EnsureVersionForWriting()
+
+	n1 := ctxDest.PageCount
+
+	if _, err = appendTo(rs2, "2", ctxDest); err != nil {
+		return err
+	}
+
+	ctx, err := pdfcpu.ExtractPages(ctxDest, zipPageNrs(n1, ctxDest.PageCount-n1), false)
+	if err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// MergeZipFile merges inFile1 and inFile2 by interleaving their pages (A1, B1, A2, B2, ...) and writes the result to outFile.
+func MergeZipFile(inFile1, inFile2, outFile string, conf *model.Configuration) (err error) {
+	f1, err := os.Open(inFile1)
+	if err != nil {
+		return err
+	}
+	defer f1.Close()
+
+	f2, err := os.Open(inFile2)
+	if err != nil {
+		return err
+	}
+	defer f2.Close()
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		cerr := f.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	logWritingTo(outFile)
+	return MergeZip(f1, f2, f, conf)
+}
+
 func prepDestContext(destFile string, rs io.ReadSeeker, conf *model.Configuration) (*model.Context, error) {
 	ctxDest, _, _, err := readAndValidate(rs, conf, time.Now())
 	if err != nil {
@@ -130,7 +317,16 @@ func Merge(destFile string, inFiles []string, w io.Writer, conf *model.Configura
 		return err
 	}
 
+	// Keep track of the page span of each merged file for resolving links between them.
+	files := map[string]pdfcpu.MergedFile{
+		filepath.Base(destFile): {FirstPage: 1, PageCount: ctxDest.PageCount},
+	}
+
+	toc := []pdfcpu.TOCEntry{{Title: filepath.Base(destFile), PageNr: 1}}
+
 	for _, fName := range inFiles {
+		firstPage := ctxDest.PageCount + 1
+		var renames map[string]string
 		if err := func() error {
 			f, err := os.Open(fName)
 			if err != nil {
@@ -141,7 +337,7 @@ func Merge(destFile string, inFiles []string, w io.Writer, conf *model.Configura
 			if log.CLIEnabled() {
 				log.CLI.Println(fName)
 			}
-			if err = appendTo(f, filepath.Base(fName), ctxDest); err != nil {
+			if renames, err = appendTo(f, filepath.Base(fName), ctxDest); err != nil {
 				return err
 			}
 
@@ -150,6 +346,20 @@ func Merge(destFile string, inFiles []string, w io.Writer, conf *model.Configura
 		}(); err != nil {
 			return err
 		}
+		if _, ok := files[filepath.Base(fName)]; !ok {
+			files[filepath.Base(fName)] = pdfcpu.MergedFile{FirstPage: firstPage, PageCount: ctxDest.PageCount - firstPage + 1, DestRenames: renames}
+		}
+		toc = append(toc, pdfcpu.TOCEntry{Title: filepath.Base(fName), PageNr: firstPage})
+	}
+
+	if err := pdfcpu.ResolveGoToRLinks(ctxDest, files); err != nil {
+		return err
+	}
+
+	if conf.CreateBookmarks && conf.TOCPage {
+		if err := pdfcpu.InsertTOCPages(ctxDest, toc); err != nil {
+			return err
+		}
 	}
 
 	if err := OptimizeContext(ctxDest); err != nil {
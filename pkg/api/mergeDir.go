@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// SortMode defines the order in which files get merged.
+type SortMode int
+
+// The available sort modes.
+const (
+	SortByName    SortMode = iota // Lexical order of the relative path.
+	SortByModTime                 // Modification time, oldest first.
+	SortNatural                   // Natural order of the relative path where digit sequences compare numerically, eg. 2.pdf < 10.pdf
+)
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// naturalLess compares s1 and s2 treating digit sequences as numbers.
+func naturalLess(s1, s2 string) bool {
+	i, j := 0, 0
+	for i < len(s1) && j < len(s2) {
+		if isDigit(s1[i]) && isDigit(s2[j]) {
+			i0, j0 := i, j
+			for i < len(s1) && isDigit(s1[i]) {
+				i++
+			}
+			for j < len(s2) && isDigit(s2[j]) {
+				j++
+			}
+			n1, n2 := strings.TrimLeft(s1[i0:i], "0"), strings.TrimLeft(s2[j0:j], "0")
+			if len(n1) != len(n2) {
+				return len(n1) < len(n2)
+			}
+			if n1 != n2 {
+				return n1 < n2
+			}
+			continue
+		}
+		if s1[i] != s2[j] {
+			return s1[i] < s2[j]
+		}
+		i++
+		j++
+	}
+	if len(s1)-i != len(s2)-j {
+		return len(s1)-i < len(s2)-j
+	}
+	// Equal except for leading zeros.
+	return s1 < s2
+}
+
+type dirEntry struct {
+	path, relPath string
+	modTime       time.Time
+}
+
+// FilesForDir walks the directory tree rooted at dir and returns all files whose name matches pattern in the order defined by sortBy.
+// pattern is a glob pattern as supported by filepath.Match, eg. "*.pdf" which is also the default.
+func FilesForDir(dir, pattern string, sortBy SortMode) ([]string, error) {
+	if pattern == "" {
+		pattern = "*.pdf"
+	}
+
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, errors.Errorf("pdfcpu: invalid pattern: %s", pattern)
+	}
+
+	var ee []dirEntry
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ok, _ := filepath.Match(pattern, d.Name()); !ok {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		ee = append(ee, dirEntry{path: path, relPath: filepath.ToSlash(relPath), modTime: fi.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var less func(e1, e2 dirEntry) bool
+
+	switch sortBy {
+	case SortByName:
+		less = func(e1, e2 dirEntry) bool { return e1.relPath < e2.relPath }
+	case SortByModTime:
+		less = func(e1, e2 dirEntry) bool {
+			if !e1.modTime.Equal(e2.modTime) {
+				return e1.modTime.Before(e2.modTime)
+			}
+			return e1.relPath < e2.relPath
+		}
+	case SortNatural:
+		less = func(e1, e2 dirEntry) bool { return naturalLess(e1.relPath, e2.relPath) }
+	default:
+		return nil, errors.Errorf("pdfcpu: invalid sort mode: %d", sortBy)
+	}
+
+	sort.SliceStable(ee, func(i, j int) bool { return less(ee[i], ee[j]) })
+
+	ss := make([]string, len(ee))
+	for i, e := range ee {
+		ss[i] = e.path
+	}
+
+	return ss, nil
+}
+
+// MergeDir merges all files of the directory tree rooted at dir matching pattern in the order defined by sortBy
+// and writes the result to outFile. An existing outFile located within dir does not take part in the merge.
+func MergeDir(dir, pattern string, sortBy SortMode, outFile string, conf *model.Configuration) error {
+	if pattern == "" {
+		pattern = "*.pdf"
+	}
+
+	inFiles, err := FilesForDir(dir, pattern, sortBy)
+	if err != nil {
+		return err
+	}
+
+	outAbs, err := filepath.Abs(outFile)
+	if err != nil {
+		return err
+	}
+
+	ff := inFiles[:0]
+	for _, f := range inFiles {
+		if abs, err := filepath.Abs(f); err == nil && abs == outAbs {
+			continue
+		}
+		ff = append(ff, f)
+	}
+
+	if len(ff) == 0 {
+		return errors.Errorf("pdfcpu: no files matching %q found in %s", pattern, dir)
+	}
+
+	return MergeCreateFile(ff, outFile, conf)
+}
@@ -19,6 +19,7 @@ package api
 import (
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/log"
@@ -153,10 +154,237 @@ func NUp(rs io.ReadSeeker, w io.Writer, imgFiles, selectedPages []string, nup *m
 	return nil
 }
 
+func appendNUpImage(ctx *model.Context, in model.NUpInput) error {
+	f, err := os.Open(in.FileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	pagesIndRef, err := ctx.Pages()
+	if err != nil {
+		return err
+	}
+
+	pagesDict, err := ctx.DereferenceDict(*pagesIndRef)
+	if err != nil {
+		return err
+	}
+
+	indRef, err := pdfcpu.NewPageForImageAtResolution(ctx.XRefTable, f, pagesIndRef, in.DPI)
+	if err != nil {
+		return err
+	}
+
+	if err = model.AppendPageTree(indRef, 1, pagesDict); err != nil {
+		return err
+	}
+
+	ctx.PageCount++
+
+	return nil
+}
+
+func appendNUpPDF(ctx *model.Context, in model.NUpInput) (types.IntSet, error) {
+	f, err := os.Open(in.FileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ctxSource, _, _, err := readAndValidate(f, ctx.Configuration, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctxSource.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	pages, err := PagesForPageSelection(ctxSource.PageCount, in.Pages, true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := ctx.PageCount
+
+	if err = pdfcpu.MergeXRefTables(in.FileName, ctxSource, ctx); err != nil {
+		return nil, err
+	}
+
+	m := types.IntSet{}
+	for pageNr, v := range pages {
+		if v {
+			m[offset+pageNr] = true
+		}
+	}
+
+	return m, nil
+}
+
+// NUpFromMixed creates n-up pages for a sequence of PDF and image files.
+// All sources get rendered at a common scale preserving their natural sizes
+// adjusted by the scale factor of their input.
+func NUpFromMixed(conf *model.Configuration, inputs []model.NUpInput, nup *model.NUp) (*model.Context, error) {
+	if nup.PageDim == nil {
+		// Set default paper size.
+		nup.PageDim = types.PaperSize[nup.PageSize]
+	}
+
+	ctx, err := pdfcpu.CreateContextWithXRefTable(conf, nup.PageDim)
+	if err != nil {
+		return nil, err
+	}
+
+	// PDF inputs get merged into ctx.
+	ctx.Read = &model.ReadContext{ObjectStreams: types.IntSet{}, XRefStreams: types.IntSet{}}
+	ctx.LinearizationObjs = types.IntSet{}
+	ctx.ResetOptimizationContext()
+
+	pages := types.IntSet{}
+	nup.PageScales = map[int]float64{}
+
+	for _, in := range inputs {
+		first := ctx.PageCount + 1
+
+		if model.ImageFileName(in.FileName) {
+			if err := appendNUpImage(ctx, in); err != nil {
+				return nil, err
+			}
+			pages[ctx.PageCount] = true
+		} else {
+			m, err := appendNUpPDF(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			for pageNr := range m {
+				pages[pageNr] = true
+			}
+		}
+
+		if in.Scale > 0 && in.Scale != 1 {
+			for pageNr := first; pageNr <= ctx.PageCount; pageNr++ {
+				nup.PageScales[pageNr] = in.Scale
+			}
+		}
+	}
+
+	nup.ImgInputFile = false
+	nup.Uniform = true
+
+	if err = pdfcpu.NUpFromPDF(ctx, pages, nup); err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
+// NUpMixed rearranges the pages of PDF files and images into page grids and writes the result to w.
+// Eg. a 600 dpi scan and a vector page end up at the same scale, see model.NUpInput for per input hints.
+func NUpMixed(inputs []model.NUpInput, w io.Writer, nup *model.NUp, conf *model.Configuration) error {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.NUP
+	conf.CreateBookmarks = false
+	conf.DividerPages = false
+
+	if log.InfoEnabled() {
+		log.Info.Printf("%s", nup)
+	}
+
+	ctx, err := NUpFromMixed(conf, inputs, nup)
+	if err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err = WriteContext(ctx, w); err != nil {
+		return err
+	}
+
+	if log.StatsEnabled() {
+		log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	}
+
+	return nil
+}
+
+// NUpMixedFile rearranges the pages of PDF files and images into page grids and writes the result to outFile.
+func NUpMixedFile(inputs []model.NUpInput, outFile string, nup *model.NUp, conf *model.Configuration) (err error) {
+	f, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	logWritingTo(outFile)
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			return
+		}
+		err = f.Close()
+	}()
+
+	return NUpMixed(inputs, f, nup, conf)
+}
+
+// ParseNUpInput parses an n-up input file optionally followed by a page selection for PDF files, eg. "in.pdf:1-3,5".
+func ParseNUpInput(s string) (model.NUpInput, error) {
+	in := model.NUpInput{FileName: s}
+	i := strings.LastIndex(s, ":")
+	if i < 0 || !strings.HasSuffix(strings.ToLower(s[:i]), ".pdf") {
+		return in, nil
+	}
+	pages, err := ParsePageSelection(s[i+1:])
+	if err != nil {
+		return in, err
+	}
+	in.FileName, in.Pages = s[:i], pages
+	return in, nil
+}
+
+func mixedNUpInput(inFiles []string) bool {
+	if len(inFiles) < 2 {
+		return false
+	}
+	for _, fn := range inFiles {
+		if !model.ImageFileName(fn) {
+			return true
+		}
+	}
+	return false
+}
+
 // NUpFile rearranges PDF pages or images into page grids and writes the result to outFile.
+// A sequence of PDF and image files gets rendered at a common scale, see NUpMixedFile.
+// A PDF inFile may carry its own page selection overriding selectedPages, eg. "in.pdf:1-3,5", see ParseNUpInput.
 func NUpFile(inFiles []string, outFile string, selectedPages []string, nup *model.NUp, conf *model.Configuration) (err error) {
 	var f1, f2 *os.File
 
+	inputs := make([]model.NUpInput, len(inFiles))
+	for i, fn := range inFiles {
+		if inputs[i], err = ParseNUpInput(fn); err != nil {
+			return err
+		}
+		if inputs[i].Pages == nil {
+			inputs[i].Pages = selectedPages
+		}
+	}
+
+	if mixedNUpInput(inFiles) {
+		return NUpMixedFile(inputs, outFile, nup, conf)
+	}
+
+	if len(inputs) == 1 {
+		inFiles, selectedPages = []string{inputs[0].FileName}, inputs[0].Pages
+	}
+
 	if !nup.ImgInputFile {
 		// Nup from a PDF page.
 		if f1, err = os.Open(inFiles[0]); err != nil {
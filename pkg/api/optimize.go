@@ -17,6 +17,7 @@
 package api
 
 import (
+	"bytes"
 	"io"
 	"os"
 	"time"
@@ -118,3 +119,66 @@ func OptimizeFile(inFile, outFile string, conf *model.Configuration) (err error)
 
 	return Optimize(f1, f2, conf)
 }
+
+// OptimizeWithReport reads a PDF stream from rs, writes the optimized PDF stream to w
+// and returns a report attributing the file size to object categories before and after optimization
+// including the n largest objects.
+func OptimizeWithReport(rs io.ReadSeeker, w io.Writer, n int, conf *model.Configuration) (*pdfcpu.OptimizeReport, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: OptimizeWithReport: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.OPTIMIZE
+
+	ctx, err := ReadContext(rs, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := pdfcpu.CreateSizeReport(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = rs.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = Optimize(rs, &buf, conf); err != nil {
+		return nil, err
+	}
+
+	if ctx, err = ReadContext(bytes.NewReader(buf.Bytes()), conf); err != nil {
+		return nil, err
+	}
+
+	after, err := pdfcpu.CreateSizeReport(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	return &pdfcpu.OptimizeReport{Before: before, After: after}, nil
+}
+
+// OptimizeFileWithReport reads inFile, writes the optimized PDF to outFile
+// and returns a report attributing the file size to object categories before and after optimization
+// including the n largest objects.
+// If outFile is not provided then inFile gets overwritten.
+func OptimizeFileWithReport(inFile, outFile string, n int, conf *model.Configuration) (*pdfcpu.OptimizeReport, error) {
+	var r *pdfcpu.OptimizeReport
+
+	err := processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) (err error) {
+		r, err = OptimizeWithReport(rs, w, n, conf)
+		return err
+	})
+
+	return r, err
+}
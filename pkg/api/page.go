@@ -17,11 +17,12 @@
 package api
 
 import (
+	"bytes"
 	"io"
 	"os"
 	"time"
 
-	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 	"github.com/pkg/errors"
@@ -33,16 +34,80 @@ func InsertPages(rs io.ReadSeeker, w io.Writer, selectedPages []string, before b
 		return errors.New("pdfcpu: InsertPages: missing rs")
 	}
 
+	return InsertBlankPages(rs, w, selectedPages, &model.BlankPages{Before: before}, conf)
+}
+
+// InsertPagesFile inserts a blank page before or after every inFile page selected and writes the result to w.
+func InsertPagesFile(inFile, outFile string, selectedPages []string, before bool, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return InsertPages(f1, f2, selectedPages, before, conf)
+}
+
+// InsertBlankPages inserts blank pages for selected pages of rs as configured by bp and writes the result to w.
+// Unless bp.PageSize is set a blank page takes over MediaBox, CropBox and Rotate of its adjacent page.
+func InsertBlankPages(rs io.ReadSeeker, w io.Writer, selectedPages []string, bp *model.BlankPages, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: InsertBlankPages: missing rs")
+	}
+
+	if bp == nil {
+		bp = &model.BlankPages{}
+	}
+
+	if bp.PageSize != "" && bp.PageDim == nil {
+		dim, _, err := types.ParsePageFormat(bp.PageSize)
+		if err != nil {
+			return err
+		}
+		bp.PageDim = dim
+	}
+
 	if conf == nil {
 		conf = model.NewDefaultConfiguration()
 	}
 	conf.Cmd = model.INSERTPAGESAFTER
-	if before {
+	if bp.Before || bp.Recto {
 		conf.Cmd = model.INSERTPAGESBEFORE
 	}
 
-	fromStart := time.Now()
-	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, fromStart)
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
 	if err != nil {
 		return err
 	}
@@ -56,33 +121,21 @@ func InsertPages(rs io.ReadSeeker, w io.Writer, selectedPages []string, before b
 		return err
 	}
 
-	if err = ctx.InsertBlankPages(pages, before); err != nil {
+	if err = ctx.InsertBlankPages(pages, *bp); err != nil {
 		return err
 	}
 
-	if log.StatsEnabled() {
-		log.Stats.Printf("XRefTable:\n%s\n", ctx)
-	}
-
 	if conf.ValidationMode != model.ValidationNone {
 		if err = ValidateContext(ctx); err != nil {
 			return err
 		}
 	}
 
-	if err = WriteContext(ctx, w); err != nil {
-		return err
-	}
-
-	if log.StatsEnabled() {
-		log.Stats.Printf("XRefTable:\n%s\n", ctx)
-	}
-
-	return nil
+	return WriteContext(ctx, w)
 }
 
-// InsertPagesFile inserts a blank page before or after every inFile page selected and writes the result to w.
-func InsertPagesFile(inFile, outFile string, selectedPages []string, before bool, conf *model.Configuration) (err error) {
+// InsertBlankPagesFile inserts blank pages for selected pages of inFile as configured by bp and writes the result to outFile.
+func InsertBlankPagesFile(inFile, outFile string, selectedPages []string, bp *model.BlankPages, conf *model.Configuration) (err error) {
 	var f1, f2 *os.File
 
 	if f1, err = os.Open(inFile); err != nil {
@@ -121,7 +174,7 @@ func InsertPagesFile(inFile, outFile string, selectedPages []string, before bool
 		}
 	}()
 
-	return InsertPages(f1, f2, selectedPages, before, conf)
+	return InsertBlankPages(f1, f2, selectedPages, bp, conf)
 }
 
 // RemovePages removes selected pages from rs and writes the result to w.
@@ -281,3 +334,132 @@ func PageDimsFile(inFile string) ([]types.Dim, error) {
 
 	return PageDims(f, model.NewDefaultConfiguration())
 }
+
+// pagesContext returns a context holding the pages pageNrs of ctx ready for merging.
+func pagesContext(ctx *model.Context, pageNrs []int, conf *model.Configuration) (*model.Context, error) {
+	ctxPages, err := pdfcpu.ExtractPages(ctx, pageNrs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := WriteContext(ctxPages, &buf); err != nil {
+		return nil, err
+	}
+
+	ctxPages, _, _, err = readAndValidate(bytes.NewReader(buf.Bytes()), conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return ctxPages, ctxPages.EnsurePageCount()
+}
+
+// InsertPagesFrom inserts the selected pages of the PDF stream rsSrc into the PDF stream rs
+// following page afterPage and writes the result to w.
+// If afterPage is 0 the pages are inserted in front of the first page.
+// If no pages are selected all pages of rsSrc are inserted.
+func InsertPagesFrom(rs, rsSrc io.ReadSeeker, w io.Writer, afterPage int, sourcePages []string, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: InsertPagesFrom: missing rs")
+	}
+
+	if rsSrc == nil {
+		return errors.New("pdfcpu: InsertPagesFrom: missing rsSrc")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.INSERTPAGESFROM
+
+	ctxSrc, _, _, err := readAndValidate(rsSrc, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctxSrc.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	if len(sourcePages) > 0 {
+		pages, err := PagesForPageSelection(ctxSrc.PageCount, sourcePages, true, true)
+		if err != nil {
+			return err
+		}
+		if len(pages) == 0 {
+			return errors.New("pdfcpu: InsertPagesFrom: no source pages selected")
+		}
+		if ctxSrc, err = pagesContext(ctxSrc, sortedPages(pages), conf); err != nil {
+			return err
+		}
+	}
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	ctx.EnsureVersionForWriting()
+
+	if err := pdfcpu.InsertPagesFrom(ctxSrc, ctx, afterPage); err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err = OptimizeContext(ctx); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// InsertPagesFromFile inserts the selected pages of source into target following page afterPage.
+// If afterPage is 0 the pages are inserted in front of the first page.
+// If no pages are selected all pages of source are inserted.
+func InsertPagesFromFile(target, source string, afterPage int, sourcePages []string, conf *model.Configuration) (err error) {
+	var f0, f1, f2 *os.File
+
+	if f0, err = os.Open(source); err != nil {
+		return err
+	}
+	defer f0.Close()
+
+	if f1, err = os.Open(target); err != nil {
+		return err
+	}
+
+	tmpFile := target + ".tmp"
+	logWritingTo(target)
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			os.Remove(tmpFile)
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		err = os.Rename(tmpFile, target)
+	}()
+
+	return InsertPagesFrom(f1, f0, f2, afterPage, sourcePages, conf)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+func pageStatsContext(rs io.ReadSeeker, selectedPages []string, conf *model.Configuration) (*model.Context, types.IntSet, error) {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTPAGESTATS
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, nil, err
+	}
+
+	pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ctx, pages, nil
+}
+
+// PageStats returns content metrics for selected pages of rs useful for estimating render cost.
+func PageStats(rs io.ReadSeeker, selectedPages []string, conf *model.Configuration) ([]pdfcpu.PageStats, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: PageStats: missing rs")
+	}
+
+	ctx, pages, err := pageStatsContext(rs, selectedPages, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.PageContentStatsForPages(ctx, pages)
+}
+
+// PageStatsFile returns content metrics for selected pages of inFile useful for estimating render cost.
+func PageStatsFile(inFile string, selectedPages []string, conf *model.Configuration) ([]pdfcpu.PageStats, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return PageStats(f, selectedPages, conf)
+}
+
+// PageStatsJSON writes content metrics for selected pages of rs as JSON to w.
+func PageStatsJSON(rs io.ReadSeeker, w io.Writer, source string, selectedPages []string, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: PageStatsJSON: missing rs")
+	}
+
+	if w == nil {
+		return errors.New("pdfcpu: PageStatsJSON: missing w")
+	}
+
+	ctx, pages, err := pageStatsContext(rs, selectedPages, conf)
+	if err != nil {
+		return err
+	}
+
+	return pdfcpu.PageContentStatsJSON(ctx, source, pages, w)
+}
+
+// PageStatsJSONFile writes content metrics for selected pages of inFile as JSON to w.
+func PageStatsJSONFile(inFile string, w io.Writer, selectedPages []string, conf *model.Configuration) error {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return PageStatsJSON(f, w, inFile, selectedPages, conf)
+}
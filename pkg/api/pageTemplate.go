@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// processFile reads inFile, applies f and writes the result to outFile or inFile if outFile is empty.
+func processFile(inFile, outFile string, f func(rs io.ReadSeeker, w io.Writer) error) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return f(f1, f2)
+}
+
+// modifyContext reads rs, applies f and writes the result to w.
+func modifyContext(rs io.ReadSeeker, w io.Writer, cmd model.CommandMode, conf *model.Configuration, f func(ctx *model.Context) error) error {
+	if rs == nil {
+		return errors.New("pdfcpu: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = cmd
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	if err := f(ctx); err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// PageTemplates returns the names of all named page templates of rs.
+func PageTemplates(rs io.ReadSeeker, conf *model.Configuration) ([]string, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: PageTemplates: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTPAGETEMPLATES
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.ListPageTemplates(ctx)
+}
+
+// PageTemplatesFile returns the names of all named page templates of inFile.
+func PageTemplatesFile(inFile string, conf *model.Configuration) ([]string, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return PageTemplates(f, conf)
+}
+
+// AddPageTemplate adds a named page template based on page pageNr of rs and writes the result to w.
+func AddPageTemplate(rs io.ReadSeeker, w io.Writer, name string, pageNr int, conf *model.Configuration) error {
+	return modifyContext(rs, w, model.ADDPAGETEMPLATES, conf, func(ctx *model.Context) error {
+		return pdfcpu.AddPageTemplate(ctx, name, pageNr)
+	})
+}
+
+// AddPageTemplateFile adds a named page template based on page pageNr of inFile and writes the result to outFile.
+func AddPageTemplateFile(inFile, outFile, name string, pageNr int, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return AddPageTemplate(rs, w, name, pageNr, conf)
+	})
+}
+
+// RemovePageTemplates removes named page templates from rs and writes the result to w.
+// All page templates get removed if names is empty.
+func RemovePageTemplates(rs io.ReadSeeker, w io.Writer, names []string, conf *model.Configuration) error {
+	return modifyContext(rs, w, model.REMOVEPAGETEMPLATES, conf, func(ctx *model.Context) error {
+		_, err := pdfcpu.RemovePageTemplates(ctx, names)
+		return err
+	})
+}
+
+// RemovePageTemplatesFile removes named page templates from inFile and writes the result to outFile.
+// All page templates get removed if names is empty.
+func RemovePageTemplatesFile(inFile, outFile string, names []string, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return RemovePageTemplates(rs, w, names, conf)
+	})
+}
+
+// SpawnPageTemplate inserts n instances of the named page template name after page afterPage of rs
+// and writes the result to w. Use afterPage = -1 to append the new pages.
+func SpawnPageTemplate(rs io.ReadSeeker, w io.Writer, name string, afterPage, n int, conf *model.Configuration) error {
+	if n < 1 {
+		return errors.Errorf("pdfcpu: SpawnPageTemplate: invalid number of instances: %d", n)
+	}
+	return modifyContext(rs, w, model.SPAWNPAGETEMPLATES, conf, func(ctx *model.Context) error {
+		if afterPage < 0 {
+			afterPage = ctx.PageCount
+		}
+		for i := 0; i < n; i++ {
+			if err := pdfcpu.SpawnPageTemplate(ctx, name, afterPage+i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SpawnPageTemplateFile inserts n instances of the named page template name after page afterPage of inFile
+// and writes the result to outFile. Use afterPage = -1 to append the new pages.
+func SpawnPageTemplateFile(inFile, outFile, name string, afterPage, n int, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return SpawnPageTemplate(rs, w, name, afterPage, n, conf)
+	})
+}
+
+// PushDownPageAttrs stores all inheritable page attributes (Resources, MediaBox, CropBox, Rotate) of rs
+// with each page instead of intermediate page tree nodes and writes the result to w.
+func PushDownPageAttrs(rs io.ReadSeeker, w io.Writer, conf *model.Configuration) error {
+	return modifyContext(rs, w, model.INHERITPAGEATTRS, conf, pdfcpu.PushDownPageAttrs)
+}
+
+// PushDownPageAttrsFile stores all inheritable page attributes (Resources, MediaBox, CropBox, Rotate) of inFile
+// with each page instead of intermediate page tree nodes and writes the result to outFile.
+func PushDownPageAttrsFile(inFile, outFile string, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return PushDownPageAttrs(rs, w, conf)
+	})
+}
+
+// PullUpPageAttrs moves inheritable page attributes (Resources, MediaBox, CropBox, Rotate) of rs
+// shared by all kids of a page tree node up into this node and writes the result to w.
+func PullUpPageAttrs(rs io.ReadSeeker, w io.Writer, conf *model.Configuration) error {
+	return modifyContext(rs, w, model.INHERITPAGEATTRS, conf, pdfcpu.PullUpPageAttrs)
+}
+
+// PullUpPageAttrsFile moves inheritable page attributes (Resources, MediaBox, CropBox, Rotate) of inFile
+// shared by all kids of a page tree node up into this node and writes the result to outFile.
+func PullUpPageAttrsFile(inFile, outFile string, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return PullUpPageAttrs(rs, w, conf)
+	})
+}
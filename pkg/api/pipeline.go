@@ -0,0 +1,213 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// ContextOp modifies a Context in place.
+// A sequence of ContextOps may be applied to a single Context using Pipeline
+// which avoids reading and writing the document for each operation.
+type ContextOp func(ctx *model.Context) error
+
+func selectedPagesForContext(ctx *model.Context, selectedPages []string) (types.IntSet, error) {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+	return PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+}
+
+// RotateOp returns a ContextOp rotating selected pages clockwise by rotation degrees.
+func RotateOp(rotation int, selectedPages []string) ContextOp {
+	return func(ctx *model.Context) error {
+		ctx.Cmd = model.ROTATE
+		pages, err := selectedPagesForContext(ctx, selectedPages)
+		if err != nil {
+			return err
+		}
+		return pdfcpu.RotatePages(ctx, pages, rotation)
+	}
+}
+
+// WatermarkOp returns a ContextOp applying wm to selected pages.
+// Use this for stamps as well.
+func WatermarkOp(selectedPages []string, wm *model.Watermark) ContextOp {
+	return func(ctx *model.Context) error {
+		if wm == nil {
+			return errors.New("pdfcpu: missing watermark configuration")
+		}
+		ctx.Cmd = model.ADDWATERMARKS
+		pages, err := selectedPagesForContext(ctx, selectedPages)
+		if err != nil {
+			return err
+		}
+		return pdfcpu.AddWatermarks(ctx, pages, wm)
+	}
+}
+
+// OptimizeOp returns a ContextOp optimizing the current state of a Context,
+// eg. for getting rid of redundant resources introduced by preceding operations.
+func OptimizeOp() ContextOp {
+	return func(ctx *model.Context) error {
+		ctx.ResetOptimizationContext()
+		return OptimizeContext(ctx)
+	}
+}
+
+// EncryptOp returns a ContextOp marking a Context for encryption using ownerPW and the optional userPW.
+// Encryption takes place when the Context gets written regardless of any operations following EncryptOp.
+// Algorithm, key length and permissions are taken from the Context's configuration.
+func EncryptOp(userPW, ownerPW string) ContextOp {
+	return func(ctx *model.Context) error {
+		if ctx.Encrypt != nil {
+			return errors.New("pdfcpu: this file is already encrypted")
+		}
+		if ownerPW == "" {
+			return errors.New("pdfcpu: please provide owner password and optional user password")
+		}
+		ctx.UserPW, ctx.OwnerPW = userPW, ownerPW
+		ctx.EncryptOnWrite = true
+		return nil
+	}
+}
+
+// ApplyContextOps applies ops to ctx in order.
+// The Context is prepared for encryption on write if requested by any of ops, see EncryptOp.
+func ApplyContextOps(ctx *model.Context, ops ...ContextOp) error {
+	for i, op := range ops {
+		if op == nil {
+			return errors.Errorf("pdfcpu: pipeline: missing operation #%d", i+1)
+		}
+		if err := op(ctx); err != nil {
+			return err
+		}
+	}
+	if ctx.EncryptOnWrite {
+		// Operations following EncryptOp set their own command mode.
+		ctx.Cmd = model.ENCRYPT
+	}
+	return nil
+}
+
+// Pipeline reads a PDF stream from rs, applies ops in order to the resulting Context and writes the result to w.
+func Pipeline(rs io.ReadSeeker, w io.Writer, conf *model.Configuration, ops ...ContextOp) error {
+	if rs == nil {
+		return errors.New("pdfcpu: Pipeline: missing rs")
+	}
+
+	if len(ops) == 0 {
+		return errors.New("pdfcpu: Pipeline: missing operations")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	// Operations may modify the configuration, eg. EncryptOp.
+	c := *conf
+	conf = &c
+
+	// Content streams shared between pages would get modified more than once by page based operations.
+	conf.OptimizeDuplicateContentStreams = false
+
+	fromStart := time.Now()
+	ctx, durRead, durVal, durOpt, err := ReadValidateAndOptimize(rs, conf, fromStart)
+	if err != nil {
+		return err
+	}
+
+	from := time.Now()
+
+	if err = ApplyContextOps(ctx, ops...); err != nil {
+		return err
+	}
+
+	if log.StatsEnabled() {
+		log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	durOps := time.Since(from).Seconds()
+	fromWrite := time.Now()
+
+	if err = WriteContext(ctx, w); err != nil {
+		return err
+	}
+
+	durWrite := durOps + time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+	logOperationStats(ctx, "pipeline, write", durRead, durVal, durOpt, durWrite, durTotal)
+
+	return nil
+}
+
+// PipelineFile reads inFile, applies ops in order to the resulting Context and writes the result to outFile.
+func PipelineFile(inFile, outFile string, conf *model.Configuration, ops ...ContextOp) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return Pipeline(f1, f2, conf, ops...)
+}
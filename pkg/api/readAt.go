@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// ReadContextAt reads the PDF of given size accessible by byte ranges via ra, eg. a remote file served by HTTP range requests.
+// Only the cross reference table, the trailer, the catalog and the page tree root are read,
+// any other object is read from ra on first access (see model.Configuration.LazyLoading).
+// The returned context is not validated since validation would read the whole file.
+// ra has to remain accessible for the lifetime of the context.
+func ReadContextAt(ra io.ReaderAt, size int64, conf *model.Configuration) (*model.Context, error) {
+	if ra == nil {
+		return nil, errors.New("pdfcpu: ReadContextAt: missing ra")
+	}
+
+	if size <= 0 {
+		return nil, errors.New("pdfcpu: ReadContextAt: invalid size")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	c := *conf
+	c.LazyLoading = true
+
+	ctx, err := pdfcpu.Read(io.NewSectionReader(ra, 0, size), &c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	// Needed for migrating form fields of extracted pages.
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	if o, found := rootDict.Find("AcroForm"); found {
+		if ctx.Form, err = ctx.DereferenceDict(o); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctx, nil
+}
+
+// ExtractPagesAt writes a PDF consisting of the pages selected from the PDF of given size accessible by byte ranges via ra to w.
+// Only the cross reference table, the catalog, the page tree nodes leading to the selected pages
+// and the objects used by the selected pages are read from ra.
+// This enables serving the first pages of huge remote files fast.
+func ExtractPagesAt(ra io.ReaderAt, size int64, w io.Writer, selectedPages []string, conf *model.Configuration) error {
+	if w == nil {
+		return errors.New("pdfcpu: ExtractPagesAt: missing w")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.EXTRACTPAGES
+
+	fromStart := time.Now()
+	ctx, err := ReadContextAt(ra, size, conf)
+	if err != nil {
+		return err
+	}
+	durRead := time.Since(fromStart).Seconds()
+
+	fromWrite := time.Now()
+	pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+	if err != nil {
+		return err
+	}
+
+	pageNrs := []int{}
+	for i := 1; i <= ctx.PageCount; i++ {
+		if pages[i] {
+			pageNrs = append(pageNrs, i)
+		}
+	}
+	if len(pageNrs) == 0 {
+		return errors.New("pdfcpu: ExtractPagesAt: no pages selected")
+	}
+
+	ctxDest, err := pdfcpu.ExtractPages(ctx, pageNrs, false)
+	if err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err := ValidateContext(ctxDest); err != nil {
+			return err
+		}
+	}
+
+	if err := WriteContext(ctxDest, w); err != nil {
+		return err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+	if log.StatsEnabled() {
+		log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	}
+	model.TimingStats("extract pages", durRead, 0, 0, durWrite, durTotal)
+
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+	Copyright 2023 The pdfcpu Authors.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/validate"
+	"github.com/pkg/errors"
+)
+
+// RepairContext reads a potentially corrupt PDF stream from rs and returns the recovered context
+// along with a machine readable report of every fix applied.
+func RepairContext(rs io.ReadSeeker, conf *model.Configuration) (*model.Context, *pdfcpu.RepairReport, error) {
+	if rs == nil {
+		return nil, nil, errors.New("pdfcpu: RepairContext: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.REPAIR
+	conf.ValidationMode = model.ValidationRelaxed
+
+	ctx, rep, err := pdfcpu.Repair(rs, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = validate.XRefTable(ctx.XRefTable); err != nil {
+		return nil, rep, err
+	}
+
+	return ctx, rep, nil
+}
+
+// Repair reads a potentially corrupt PDF stream from rs, rebuilds its cross reference table if necessary
+// and writes the recovered PDF stream to w.
+func Repair(rs io.ReadSeeker, w io.Writer, conf *model.Configuration) (*pdfcpu.RepairReport, error) {
+	if w == nil {
+		return nil, errors.New("pdfcpu: Repair: missing w")
+	}
+
+	ctx, rep, err := RepairContext(rs, conf)
+	if err != nil {
+		return rep, err
+	}
+
+	if err = OptimizeContext(ctx); err != nil {
+		return rep, err
+	}
+
+	if log.CLIEnabled() {
+		log.CLI.Printf("%d objects found, %d fixes applied\n", rep.ObjectsFound, len(rep.Fixes))
+	}
+
+	return rep, WriteContext(ctx, w)
+}
+
+// RepairFile repairs inFile and writes the result to outFile.
+// If outFile is not provided then inFile gets overwritten
+// which leads to the same result as when inFile equals outFile.
+func RepairFile(inFile, outFile string, conf *model.Configuration) (rep *pdfcpu.RepairReport, err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return nil, err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return Repair(f1, f2, conf)
+}
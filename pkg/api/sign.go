@@ -0,0 +1,175 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/sign"
+	"github.com/pkg/errors"
+)
+
+// Sign digitally signs rs as PAdES baseline B-B signature and writes the result to w.
+// The signature gets added as increment leaving existing signatures intact.
+func Sign(rs io.ReadSeeker, w io.Writer, opts *sign.Options, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: Sign: missing rs")
+	}
+
+	if opts == nil {
+		return errors.New("pdfcpu: Sign: missing options")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.SIGN
+
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	bb, err := io.ReadAll(rs)
+	if err != nil {
+		return err
+	}
+
+	ctx, _, _, err := readAndValidate(bytes.NewReader(bb), conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := sign.Prepare(ctx, opts); err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	if n := len(bb); n > 0 && bb[n-1] != '\n' && bb[n-1] != '\r' {
+		bb = append(bb, '\n')
+	}
+
+	offset := len(bb)
+
+	ctx.Write.Increment = true
+	ctx.Write.Offset = int64(offset)
+
+	buf := bytes.NewBuffer(bb)
+	if err := WriteIncrement(ctx, buf); err != nil {
+		return err
+	}
+
+	// Fill in byte range and signature.
+	bb = buf.Bytes()
+	if err := sign.Sign(bb, offset, opts); err != nil {
+		return err
+	}
+
+	_, err = w.Write(bb)
+	return err
+}
+
+// SignFile digitally signs inFile as PAdES baseline B-B signature and writes the result to outFile.
+// Use sign.LoadPKCS12File for signing with the key and certificates of a PKCS#12 file.
+func SignFile(inFile, outFile string, opts *sign.Options, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return Sign(f1, f2, opts, conf)
+}
+
+// ValidateSignatures returns a validation report for each signature of rs.
+func ValidateSignatures(rs io.ReadSeeker, conf *model.Configuration) ([]sign.SignatureReport, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: ValidateSignatures: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.VALIDATESIGNATURES
+
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	bb, err := io.ReadAll(rs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, _, _, err := readAndValidate(bytes.NewReader(bb), conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return sign.ValidateSignatures(ctx, bb)
+}
+
+// ValidateSignaturesFile returns a validation report for each signature of inFile.
+func ValidateSignaturesFile(inFile string, conf *model.Configuration) ([]sign.SignatureReport, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ValidateSignatures(f, conf)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Snapshot extracts rectangular regions of page pageNr of rs into a standalone PDF and writes the result to w.
+// Each region given in points relative to the lower left corner of the displayed page results in one page
+// holding the page content clipped to the region, eg. for pulling figures or signature areas out of a document.
+func Snapshot(rs io.ReadSeeker, w io.Writer, pageNr int, regions []*types.Rectangle, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: Snapshot: missing rs")
+	}
+
+	if len(regions) == 0 {
+		return errors.New("pdfcpu: Snapshot: missing regions")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.EXTRACTPAGES
+
+	fromStart := time.Now()
+	ctx, durRead, durVal, durOpt, err := ReadValidateAndOptimize(rs, conf, fromStart)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	if pageNr < 1 || pageNr > ctx.PageCount {
+		return errors.Errorf("pdfcpu: Snapshot: invalid page number: %d", pageNr)
+	}
+
+	fromWrite := time.Now()
+
+	ctxDest, err := pdfcpu.SnapshotPage(ctx, pageNr, regions)
+	if err != nil {
+		return err
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctxDest); err != nil {
+			return err
+		}
+	}
+
+	if err = WriteContext(ctxDest, w); err != nil {
+		return err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+	logOperationStats(ctx, "snapshot, write", durRead, durVal, durOpt, durWrite, durTotal)
+
+	return nil
+}
+
+// SnapshotFile extracts rectangular regions of page pageNr of inFile into a standalone PDF and writes the result to outFile.
+func SnapshotFile(inFile, outFile string, pageNr int, regions []*types.Rectangle, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	if f2, err = os.Create(outFile); err != nil {
+		f1.Close()
+		return err
+	}
+	logWritingTo(outFile)
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		err = f1.Close()
+	}()
+
+	return Snapshot(f1, f2, pageNr, regions, conf)
+}
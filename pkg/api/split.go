@@ -21,13 +21,16 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/log"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/form"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 	"github.com/pkg/errors"
 )
 
@@ -61,14 +64,61 @@ func spanFileName(fileName string, from, thru int) string {
 	return fn + "-" + strconv.Itoa(thru) + ".pdf"
 }
 
-func splitOutPath(outDir, fileName string, forBookmark bool, from, thru int) string {
-	p := filepath.Join(outDir, fileName+".pdf")
-	if !forBookmark {
-		p = filepath.Join(outDir, spanFileName(fileName, from, thru))
+// templatedFileName resolves tmpl for vars and makes sure no output file gets overwritten.
+func templatedFileName(tmpl string, vars pdfcpu.FileNameVars, fileNames map[string]bool) (string, error) {
+	fn, err := pdfcpu.ResolveFileNameTemplate(tmpl, vars)
+	if err != nil {
+		return "", err
+	}
+	if fileNames[fn] {
+		return "", errors.Errorf("pdfcpu: file name template \"%s\" produces duplicate file name: %s", tmpl, fn)
+	}
+	fileNames[fn] = true
+	return fn, nil
+}
+
+// formFieldValues returns a function providing the values of the form fields located on a page span of ctx
+// if tmpl refers to form fields, see pdfcpu.FileNameVars.
+func formFieldValues(ctx *model.Context, tmpl string) (func(from, thru int) map[string]string, error) {
+	if !pdfcpu.UsesFormFields(tmpl) || ctx.Form == nil {
+		return func(from, thru int) map[string]string { return nil }, nil
+	}
+
+	fs, _, err := form.FormFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(from, thru int) map[string]string {
+		m := map[string]string{}
+		for pageNr := from; pageNr <= thru; pageNr++ {
+			for _, f := range fs {
+				if !types.IntMemberOf(pageNr, f.Pages) {
+					continue
+				}
+				for _, k := range []string{f.ID, f.Name} {
+					if _, ok := m[k]; !ok {
+						m[k] = f.V
+					}
+				}
+			}
+		}
+		return m
+	}, nil
+}
+
+// templatedOutPath resolves tmpl for vars and makes sure no output file gets overwritten.
+func templatedOutPath(outDir, tmpl string, vars pdfcpu.FileNameVars, fileNames map[string]bool) (string, error) {
+	fn, err := templatedFileName(tmpl, vars, fileNames)
+	if err != nil {
+		return "", err
 	}
-	return p
+	return filepath.Join(outDir, fn), nil
 }
 
+// pageSpanWriter writes the pages from thru of ctx to a file named fileName.
+type pageSpanWriter func(ctx *model.Context, from, thru int, fileName string) error
+
 func writePageSpan(ctx *model.Context, from, thru int, outPath string) error {
 	ps, err := pageSpan(ctx, from, thru)
 	if err != nil {
@@ -78,6 +128,12 @@ func writePageSpan(ctx *model.Context, from, thru int, outPath string) error {
 	return pdfcpu.WriteReader(outPath, ps.Reader)
 }
 
+func pageSpanDirWriter(outDir string) pageSpanWriter {
+	return func(ctx *model.Context, from, thru int, fileName string) error {
+		return writePageSpan(ctx, from, thru, filepath.Join(outDir, fileName))
+	}
+}
+
 func context(rs io.ReadSeeker, conf *model.Configuration) (*model.Context, error) {
 	if conf == nil {
 		conf = model.NewDefaultConfiguration()
@@ -96,27 +152,86 @@ func context(rs io.ReadSeeker, conf *model.Configuration) (*model.Context, error
 	return ctx, nil
 }
 
-func pageSpansSplitAlongBookmarks(ctx *model.Context) ([]*PageSpan, error) {
-	pss := []*PageSpan{}
+// bookmarksForLevel flattens the outline of ctx down to level returning all bookmarks in document order
+// with their page spans adjusted to the resulting sequence of sections.
+// A bookmark whose first kid starts on the same page is represented by this kid.
+func bookmarksForLevel(ctx *model.Context, level int) ([]pdfcpu.Bookmark, error) {
+	if level < 1 {
+		return nil, errors.Errorf("pdfcpu: invalid bookmark level: %d", level)
+	}
 
 	bms, err := pdfcpu.Bookmarks(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, bm := range bms {
+	var (
+		flatten func(bms []pdfcpu.Bookmark, depth int)
+		ss      []pdfcpu.Bookmark
+	)
+
+	flatten = func(bms []pdfcpu.Bookmark, depth int) {
+		for _, bm := range bms {
+			kids := bm.Kids
+			if depth == level {
+				kids = nil
+			}
+			if len(kids) == 0 || kids[0].PageFrom != bm.PageFrom {
+				ss = append(ss, pdfcpu.Bookmark{Title: bm.Title, PageFrom: bm.PageFrom})
+			}
+			flatten(kids, depth+1)
+		}
+	}
+
+	flatten(bms, 1)
 
-		from, thru := bm.PageFrom, bm.PageThru
-		if thru == 0 {
-			thru = ctx.PageCount
+	for i := range ss {
+		if i == len(ss)-1 {
+			ss[i].PageThru = ctx.PageCount
+			break
 		}
+		ss[i].PageThru = ss[i].PageFrom
+		if next := ss[i+1].PageFrom; next > ss[i].PageFrom {
+			ss[i].PageThru = next - 1
+		}
+	}
 
-		ps, err := pageSpan(ctx, from, thru)
+	return ss, nil
+}
+
+// bookmarkFileName returns a file name for title usable on all platforms.
+func bookmarkFileName(title string, fileNames map[string]bool) string {
+	fn := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(" /\\:*?\"<>|", r) {
+			return '_'
+		}
+		return r
+	}, title)
+	if fn == "" {
+		fn = "bookmark"
+	}
+	s := fn
+	for i := 2; fileNames[s]; i++ {
+		s = fn + "_" + strconv.Itoa(i)
+	}
+	fileNames[s] = true
+	return s + ".pdf"
+}
+
+func pageSpansSplitAlongBookmarks(ctx *model.Context, level int) ([]*PageSpan, error) {
+	pss := []*PageSpan{}
+
+	bms, err := bookmarksForLevel(ctx, level)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bm := range bms {
+		ps, err := pageSpan(ctx, bm.PageFrom, bm.PageThru)
 		if err != nil {
 			return nil, err
 		}
 		pss = append(pss, ps)
-
 	}
 
 	return pss, nil
@@ -151,22 +266,31 @@ func pageSpans(ctx *model.Context, span int) ([]*PageSpan, error) {
 	return pss, nil
 }
 
-func writePageSpansSplitAlongBookmarks(ctx *model.Context, outDir string) error {
-	forBookmark := true
+func writePageSpansSplitAlongBookmarks(ctx *model.Context, level int, fileName, tmpl string, write pageSpanWriter) error {
+	bms, err := bookmarksForLevel(ctx, level)
+	if err != nil {
+		return err
+	}
 
-	bms, err := pdfcpu.Bookmarks(ctx)
+	ff, err := formFieldValues(ctx, tmpl)
 	if err != nil {
 		return err
 	}
 
+	baseName := strings.TrimSuffix(filepath.Base(fileName), ".pdf")
+	fileNames := map[string]bool{}
+
 	for _, bm := range bms {
-		fileName := strings.Replace(bm.Title, " ", "_", -1)
-		from, thru := bm.PageFrom, bm.PageThru
-		if thru == 0 {
-			thru = ctx.PageCount
+		var fn string
+		if tmpl == "" {
+			fn = bookmarkFileName(bm.Title, fileNames)
+		} else {
+			vars := pdfcpu.FileNameVars{BaseName: baseName, From: bm.PageFrom, Thru: bm.PageThru, Bookmark: bm.Title, FormFields: ff(bm.PageFrom, bm.PageThru)}
+			if fn, err = templatedFileName(tmpl, vars, fileNames); err != nil {
+				return err
+			}
 		}
-		path := splitOutPath(outDir, fileName, forBookmark, from, thru)
-		if err := writePageSpan(ctx, from, thru, path); err != nil {
+		if err := write(ctx, bm.PageFrom, bm.PageThru, fn); err != nil {
 			return err
 		}
 	}
@@ -174,14 +298,30 @@ func writePageSpansSplitAlongBookmarks(ctx *model.Context, outDir string) error
 	return nil
 }
 
-func writePageSpans(ctx *model.Context, span int, outDir, fileName string) error {
-	forBookmark := false
+func writePageSpans(ctx *model.Context, span int, fileName, tmpl string, write pageSpanWriter) error {
+	ff, err := formFieldValues(ctx, tmpl)
+	if err != nil {
+		return err
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(fileName), ".pdf")
+	fileNames := map[string]bool{}
+
+	writeSpan := func(from, thru int) error {
+		fn := spanFileName(fileName, from, thru)
+		if tmpl != "" {
+			var err error
+			vars := pdfcpu.FileNameVars{BaseName: baseName, From: from, Thru: thru, FormFields: ff(from, thru)}
+			if fn, err = templatedFileName(tmpl, vars, fileNames); err != nil {
+				return err
+			}
+		}
+		return write(ctx, from, thru, fn)
+	}
 
 	for i := 0; i < ctx.PageCount/span; i++ {
 		start := i * span
-		from, thru := start+1, start+span
-		path := splitOutPath(outDir, fileName, forBookmark, from, thru)
-		if err := writePageSpan(ctx, from, thru, path); err != nil {
+		if err := writeSpan(start+1, start+span); err != nil {
 			return err
 		}
 	}
@@ -189,9 +329,7 @@ func writePageSpans(ctx *model.Context, span int, outDir, fileName string) error
 	// A possible last file has less than span pages.
 	if ctx.PageCount%span > 0 {
 		start := (ctx.PageCount / span) * span
-		from, thru := start+1, ctx.PageCount
-		path := splitOutPath(outDir, fileName, forBookmark, from, thru)
-		if err := writePageSpan(ctx, from, thru, path); err != nil {
+		if err := writeSpan(start+1, ctx.PageCount); err != nil {
 			return err
 		}
 	}
@@ -214,7 +352,7 @@ func SplitRaw(rs io.ReadSeeker, span int, conf *model.Configuration) ([]*PageSpa
 	}
 
 	if span == 0 {
-		return pageSpansSplitAlongBookmarks(ctx)
+		return pageSpansSplitAlongBookmarks(ctx, 1)
 	}
 	return pageSpans(ctx, span)
 }
@@ -224,19 +362,36 @@ func SplitRaw(rs io.ReadSeeker, span int, conf *model.Configuration) ([]*PageSpa
 // If span == 0 we split along given bookmarks (level 1 only).
 // Default span: 1
 func Split(rs io.ReadSeeker, outDir, fileName string, span int, conf *model.Configuration) error {
+	return SplitWithTemplate(rs, outDir, fileName, "", span, conf)
+}
+
+// SplitWithTemplate generates a sequence of PDF files in outDir for the PDF stream read from rs obeying given split span.
+// Output file names are generated using tmpl, eg. "%basename_%04d", see pdfcpu.ResolveFileNameTemplate.
+// If tmpl is empty the default naming scheme applies.
+// If span == 1 splitting results in single page PDFs.
+// If span == 0 we split along given bookmarks (level 1 only).
+func SplitWithTemplate(rs io.ReadSeeker, outDir, fileName, tmpl string, span int, conf *model.Configuration) error {
 	if rs == nil {
 		return errors.New("pdfcpu: Split: missing rs")
 	}
 
+	if tmpl != "" {
+		if err := pdfcpu.ValidateFileNameTemplate(tmpl); err != nil {
+			return err
+		}
+	}
+
 	ctx, err := context(rs, conf)
 	if err != nil {
 		return err
 	}
 
+	write := pageSpanDirWriter(outDir)
+
 	if span == 0 {
-		return writePageSpansSplitAlongBookmarks(ctx, outDir)
+		return writePageSpansSplitAlongBookmarks(ctx, 1, fileName, tmpl, write)
 	}
-	return writePageSpans(ctx, span, outDir, fileName)
+	return writePageSpans(ctx, span, fileName, tmpl, write)
 }
 
 // SplitFile generates a sequence of PDF files in outDir for inFile obeying given split span.
@@ -244,6 +399,305 @@ func Split(rs io.ReadSeeker, outDir, fileName string, span int, conf *model.Conf
 // If span == 0 we split along given bookmarks (level 1 only).
 // Default span: 1
 func SplitFile(inFile, outDir string, span int, conf *model.Configuration) error {
+	return SplitFileWithTemplate(inFile, outDir, "", span, conf)
+}
+
+// SplitFileWithTemplate generates a sequence of PDF files in outDir for inFile obeying given split span.
+// Output file names are generated using tmpl, eg. "%basename_%04d", see pdfcpu.ResolveFileNameTemplate.
+// If tmpl is empty the default naming scheme applies.
+// If span == 1 splitting results in single page PDFs.
+// If span == 0 we split along given bookmarks (level 1 only).
+func SplitFileWithTemplate(inFile, outDir, tmpl string, span int, conf *model.Configuration) (err error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return err
+	}
+	if log.CLIEnabled() {
+		log.CLI.Printf("splitting %s to %s/...\n", inFile, outDir)
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			return
+		}
+		err = f.Close()
+	}()
+
+	return SplitWithTemplate(f, outDir, filepath.Base(inFile), tmpl, span, conf)
+}
+
+// SplitByBookmarks generates a sequence of PDF files in outDir for the PDF stream read from rs
+// cutting at every bookmark down to given outline level starting with 1.
+// Output files are named after the bookmark titles unless tmpl is given, see pdfcpu.ResolveFileNameTemplate.
+func SplitByBookmarks(rs io.ReadSeeker, outDir, fileName, tmpl string, level int, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: SplitByBookmarks: missing rs")
+	}
+
+	if tmpl != "" {
+		if err := pdfcpu.ValidateFileNameTemplate(tmpl); err != nil {
+			return err
+		}
+	}
+
+	ctx, err := context(rs, conf)
+	if err != nil {
+		return err
+	}
+
+	return writePageSpansSplitAlongBookmarks(ctx, level, fileName, tmpl, pageSpanDirWriter(outDir))
+}
+
+// SplitFileByBookmarks generates a sequence of PDF files in outDir for inFile
+// cutting at every bookmark down to given outline level starting with 1.
+// Output files are named after the bookmark titles unless tmpl is given, see pdfcpu.ResolveFileNameTemplate.
+func SplitFileByBookmarks(inFile, outDir, tmpl string, level int, conf *model.Configuration) (err error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return err
+	}
+	if log.CLIEnabled() {
+		log.CLI.Printf("splitting %s to %s/...\n", inFile, outDir)
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			return
+		}
+		err = f.Close()
+	}()
+
+	return SplitByBookmarks(f, outDir, filepath.Base(inFile), tmpl, level, conf)
+}
+
+// textSection represents a page span starting with a page whose text matches a split pattern.
+type textSection struct {
+	from, thru int
+	text       string // The matched text or the first submatch if re contains a capture group.
+}
+
+// textSections returns the sections of ctx resulting from cutting before every page whose text matches re.
+// Pages preceding the first match make up a leading section with empty text.
+func textSections(ctx *model.Context, re *regexp.Regexp) ([]textSection, error) {
+	var (
+		ss    []textSection
+		match bool
+	)
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		s, err := pdfcpu.ExtractPageText(ctx, i, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if m := re.FindStringSubmatch(s); m != nil {
+			text := m[0]
+			if len(m) > 1 {
+				text = m[1]
+			}
+			ss = append(ss, textSection{from: i, thru: i, text: strings.TrimSpace(text)})
+			match = true
+			continue
+		}
+
+		if len(ss) == 0 {
+			ss = append(ss, textSection{from: i, thru: i})
+			continue
+		}
+		ss[len(ss)-1].thru = i
+	}
+
+	if !match {
+		return nil, errors.Errorf("pdfcpu: no page matching %q", re.String())
+	}
+
+	return ss, nil
+}
+
+func writePageSpansSplitAlongText(ctx *model.Context, re *regexp.Regexp, fileName, tmpl string, write pageSpanWriter) error {
+	ss, err := textSections(ctx, re)
+	if err != nil {
+		return err
+	}
+
+	ff, err := formFieldValues(ctx, tmpl)
+	if err != nil {
+		return err
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(fileName), ".pdf")
+	fileNames := map[string]bool{}
+
+	for _, s := range ss {
+		fn := spanFileName(fileName, s.from, s.thru)
+		if tmpl != "" {
+			vars := pdfcpu.FileNameVars{BaseName: baseName, From: s.from, Thru: s.thru, Text: s.text, FormFields: ff(s.from, s.thru)}
+			if fn, err = templatedFileName(tmpl, vars, fileNames); err != nil {
+				return err
+			}
+		}
+		if err := write(ctx, s.from, s.thru, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SplitByText generates a sequence of PDF files in outDir for the PDF stream read from rs
+// starting a new file with every page whose extracted text matches the regular expression pattern, eg. "Invoice No: (\d+)".
+// Pages preceding the first match end up in a file of their own.
+// Output file names are generated using tmpl, eg. "%basename_%text", see pdfcpu.ResolveFileNameTemplate.
+// %text resolves to the matched text or to the first submatch if pattern contains a capture group.
+// If tmpl is empty the default naming scheme applies.
+func SplitByText(rs io.ReadSeeker, outDir, fileName, pattern, tmpl string, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: SplitByText: missing rs")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return errors.Errorf("pdfcpu: SplitByText: invalid pattern: %v", err)
+	}
+
+	if tmpl != "" {
+		if err := pdfcpu.ValidateFileNameTemplate(tmpl); err != nil {
+			return err
+		}
+	}
+
+	ctx, err := context(rs, conf)
+	if err != nil {
+		return err
+	}
+
+	return writePageSpansSplitAlongText(ctx, re, fileName, tmpl, pageSpanDirWriter(outDir))
+}
+
+// SplitFileByText generates a sequence of PDF files in outDir for inFile
+// starting a new file with every page whose extracted text matches the regular expression pattern.
+// Output file names are generated using tmpl, see SplitByText.
+func SplitFileByText(inFile, outDir, pattern, tmpl string, conf *model.Configuration) (err error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return err
+	}
+	if log.CLIEnabled() {
+		log.CLI.Printf("splitting %s to %s/...\n", inFile, outDir)
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			return
+		}
+		err = f.Close()
+	}()
+
+	return SplitByText(f, outDir, filepath.Base(inFile), pattern, tmpl, conf)
+}
+
+// pageSpansBySize returns consecutive page spans of ctx each resulting in a PDF file not exceeding maxBytes.
+// The size of a span is measured including all resources it depends on.
+func pageSpansBySize(ctx *model.Context, maxBytes int64) ([]*PageSpan, error) {
+	var pss []*PageSpan
+
+	for from := 1; from <= ctx.PageCount; {
+
+		spans := map[int]*PageSpan{}
+
+		fits := func(thru int) (bool, error) {
+			ps, err := pageSpan(ctx, from, thru)
+			if err != nil {
+				return false, err
+			}
+			if int64(ps.Reader.(*bytes.Buffer).Len()) > maxBytes {
+				return false, nil
+			}
+			spans[thru] = ps
+			return true, nil
+		}
+
+		ok, err := fits(from)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.Errorf("pdfcpu: page %d exceeds size limit of %d bytes", from, maxBytes)
+		}
+
+		// Find the largest span using exponential followed by binary search.
+		lo, hi, n := from, ctx.PageCount+1, 1
+		for lo+n < hi {
+			ok, err := fits(lo + n)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				hi = lo + n
+				break
+			}
+			lo += n
+			n *= 2
+		}
+		for lo+1 < hi {
+			mid := (lo + hi) / 2
+			ok, err := fits(mid)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+
+		pss = append(pss, spans[lo])
+		from = lo + 1
+	}
+
+	return pss, nil
+}
+
+// SplitBySize generates a sequence of PDF files in outDir for the PDF stream read from rs
+// each not exceeding maxBytes, eg. to satisfy mail attachment limits.
+// Splitting happens at page boundaries, each part includes all resources needed by its pages.
+func SplitBySize(rs io.ReadSeeker, outDir, fileName string, maxBytes int64, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: SplitBySize: missing rs")
+	}
+
+	if maxBytes <= 0 {
+		return errors.Errorf("pdfcpu: SplitBySize: invalid size limit: %d", maxBytes)
+	}
+
+	ctx, err := context(rs, conf)
+	if err != nil {
+		return err
+	}
+
+	pss, err := pageSpansBySize(ctx, maxBytes)
+	if err != nil {
+		return err
+	}
+
+	for _, ps := range pss {
+		outPath := filepath.Join(outDir, spanFileName(fileName, ps.From, ps.Thru))
+		logWritingTo(outPath)
+		if err := pdfcpu.WriteReader(outPath, ps.Reader); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SplitBySizeFile generates a sequence of PDF files in outDir for inFile each not exceeding maxBytes.
+// Splitting happens at page boundaries, each part includes all resources needed by its pages.
+func SplitBySizeFile(inFile, outDir string, maxBytes int64, conf *model.Configuration) (err error) {
 	f, err := os.Open(inFile)
 	if err != nil {
 		return err
@@ -260,5 +714,5 @@ func SplitFile(inFile, outDir string, span int, conf *model.Configuration) error
 		err = f.Close()
 	}()
 
-	return Split(f, outDir, filepath.Base(inFile), span, conf)
+	return SplitBySize(f, outDir, filepath.Base(inFile), maxBytes, conf)
 }
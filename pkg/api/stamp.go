@@ -19,6 +19,7 @@ package api
 import (
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/log"
@@ -221,6 +222,59 @@ func AddWatermarksSliceMapFile(inFile, outFile string, m map[int][]*model.Waterm
 	return AddWatermarksSliceMap(f1, f2, m, conf)
 }
 
+// AddMultiStamps applies stamps to rs in one pass and writes the result to w.
+func AddMultiStamps(rs io.ReadSeeker, w io.Writer, stamps []pdfcpu.MultiStamp, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: AddMultiStamps: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	if len(stamps) == 0 {
+		return errors.New("pdfcpu: missing stamps")
+	}
+
+	m, err := pdfcpu.MultiStampWatermarks(stamps, conf.Unit)
+	if err != nil {
+		return err
+	}
+
+	return AddWatermarksSliceMap(rs, w, m, conf)
+}
+
+// AddMultiStampsFile applies the stamps defined in inFileStamps to inFile in one pass and writes the result to outFile.
+// inFileStamps is either a CSV file (*.csv) or a JSON file, see pdfcpu.ParseMultiStampsCSV and pdfcpu.ParseMultiStampsJSON.
+func AddMultiStampsFile(inFile, inFileStamps, outFile string, conf *model.Configuration) (err error) {
+	f, err := os.Open(inFileStamps)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var stamps []pdfcpu.MultiStamp
+	if strings.HasSuffix(strings.ToLower(inFileStamps), ".csv") {
+		stamps, err = pdfcpu.ParseMultiStampsCSV(f)
+	} else {
+		stamps, err = pdfcpu.ParseMultiStampsJSON(f)
+	}
+	if err != nil {
+		return err
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	m, err := pdfcpu.MultiStampWatermarks(stamps, conf.Unit)
+	if err != nil {
+		return err
+	}
+
+	return AddWatermarksSliceMapFile(inFile, outFile, m, conf)
+}
+
 // AddWatermarks adds watermarks to all pages selected in rs and writes the result to w.
 func AddWatermarks(rs io.ReadSeeker, w io.Writer, selectedPages []string, wm *model.Watermark, conf *model.Configuration) error {
 	if rs == nil {
@@ -281,6 +335,111 @@ func AddWatermarks(rs io.ReadSeeker, w io.Writer, selectedPages []string, wm *mo
 	return nil
 }
 
+// PageWatermark pairs a page selection with the watermark or stamp to apply to it.
+type PageWatermark struct {
+	Pages     []string
+	Watermark *model.Watermark
+}
+
+// AddWatermarksBatch applies all watermarks in batch to their selected pages of rs in a single read/write cycle
+// and writes the result to w.
+func AddWatermarksBatch(rs io.ReadSeeker, w io.Writer, batch []PageWatermark, conf *model.Configuration) error {
+	if len(batch) == 0 {
+		return errors.New("pdfcpu: AddWatermarksBatch: missing watermarks")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.OptimizeDuplicateContentStreams = false
+
+	return modifyContext(rs, w, model.ADDWATERMARKS, conf, func(ctx *model.Context) error {
+		pages := make([]types.IntSet, len(batch))
+		for i, pw := range batch {
+			if pw.Watermark == nil {
+				return errors.Errorf("pdfcpu: AddWatermarksBatch: missing watermark #%d", i+1)
+			}
+			var err error
+			if pages[i], err = PagesForPageSelection(ctx.PageCount, pw.Pages, true, true); err != nil {
+				return err
+			}
+			if len(pw.Pages) > 0 && len(pages[i]) == 0 {
+				// An empty page set would select all pages.
+				return errors.Errorf("pdfcpu: AddWatermarksBatch: no pages selected for watermark #%d", i+1)
+			}
+		}
+
+		for i, pw := range batch {
+			if err := pdfcpu.AddWatermarks(ctx, pages[i], pw.Watermark); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// AddWatermarksBatchFile applies all watermarks in batch to their selected pages of inFile in a single read/write cycle
+// and writes the result to outFile.
+func AddWatermarksBatchFile(inFile, outFile string, batch []PageWatermark, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return AddWatermarksBatch(rs, w, batch, conf)
+	})
+}
+
+// WatermarkFunc returns the watermark or stamp for page pageNr with visible dimensions pageDim.
+// A nil watermark leaves the page untouched.
+type WatermarkFunc func(pageNr int, pageDim types.Dim) (*model.Watermark, error)
+
+// AddWatermarksFunc adds the watermarks computed by f to all selected pages of rs and writes the result to w.
+func AddWatermarksFunc(rs io.ReadSeeker, w io.Writer, selectedPages []string, f WatermarkFunc, conf *model.Configuration) error {
+	if f == nil {
+		return errors.New("pdfcpu: AddWatermarksFunc: missing watermark func")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.OptimizeDuplicateContentStreams = false
+
+	return modifyContext(rs, w, model.ADDWATERMARKS, conf, func(ctx *model.Context) error {
+		pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+		if err != nil {
+			return err
+		}
+
+		dims, err := ctx.PageDims()
+		if err != nil {
+			return err
+		}
+
+		for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+			if len(pages) > 0 && !pages[pageNr] {
+				continue
+			}
+			wm, err := f(pageNr, dims[pageNr-1])
+			if err != nil {
+				return err
+			}
+			if wm == nil {
+				continue
+			}
+			if err := pdfcpu.AddWatermarks(ctx, types.IntSet{pageNr: true}, wm); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// AddWatermarksFuncFile adds the watermarks computed by f to all selected pages of inFile and writes the result to outFile.
+func AddWatermarksFuncFile(inFile, outFile string, selectedPages []string, f WatermarkFunc, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return AddWatermarksFunc(rs, w, selectedPages, f, conf)
+	})
+}
+
 // AddWatermarksFile adds watermarks to all selected pages of inFile and writes the result to outFile.
 func AddWatermarksFile(inFile, outFile string, selectedPages []string, wm *model.Watermark, conf *model.Configuration) (err error) {
 	var f1, f2 *os.File
@@ -422,6 +581,110 @@ func RemoveWatermarksFile(inFile, outFile string, selectedPages []string, conf *
 	return RemoveWatermarks(f1, f2, selectedPages, conf)
 }
 
+// RemoveWatermarksInRegion removes all content intersecting r from selected pages of rs and writes the result to w.
+// Use this for watermarks, stamps or headers not created by pdfcpu. r is given in user space.
+// If fill is true r gets painted white.
+func RemoveWatermarksInRegion(rs io.ReadSeeker, w io.Writer, selectedPages []string, r *types.Rectangle, fill bool, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: RemoveWatermarksInRegion: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.REMOVEWATERMARKS
+
+	fromStart := time.Now()
+	ctx, durRead, durVal, durOpt, err := ReadValidateAndOptimize(rs, conf, fromStart)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	from := time.Now()
+	pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+	if err != nil {
+		return err
+	}
+
+	c, err := pdfcpu.RemoveRegion(ctx, pages, r, fill)
+	if err != nil {
+		return err
+	}
+	if c == 0 && !fill {
+		return errors.New("pdfcpu: no content found in region")
+	}
+
+	if log.StatsEnabled() {
+		log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	durStamp := time.Since(from).Seconds()
+	fromWrite := time.Now()
+
+	if err = WriteContext(ctx, w); err != nil {
+		return err
+	}
+
+	durWrite := durStamp + time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+	logOperationStats(ctx, "watermark, write", durRead, durVal, durOpt, durWrite, durTotal)
+
+	return nil
+}
+
+// RemoveWatermarksInRegionFile removes all content intersecting r from selected pages of inFile and writes the result to outFile.
+func RemoveWatermarksInRegionFile(inFile, outFile string, selectedPages []string, r *types.Rectangle, fill bool, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return RemoveWatermarksInRegion(f1, f2, selectedPages, r, fill, conf)
+}
+
 // HasWatermarks checks rs for watermarks.
 func HasWatermarks(rs io.ReadSeeker, conf *model.Configuration) (bool, error) {
 	if rs == nil {
@@ -505,6 +768,43 @@ func PDFWatermark(fileName, desc string, onTop, update bool, u types.DisplayUnit
 	return wm, nil
 }
 
+// ShapeWatermark returns a shape watermark configuration.
+func ShapeWatermark(shape, desc string, onTop, update bool, u types.DisplayUnit) (*model.Watermark, error) {
+	wm, err := pdfcpu.ParseShapeWatermarkDetails(shape, desc, onTop, u)
+	if err != nil {
+		return nil, err
+	}
+
+	wm.Update = update
+
+	return wm, nil
+}
+
+// SVGWatermark returns an SVG watermark configuration.
+func SVGWatermark(fileName, desc string, onTop, update bool, u types.DisplayUnit) (*model.Watermark, error) {
+	wm, err := pdfcpu.ParseSVGWatermarkDetails(fileName, desc, onTop, u)
+	if err != nil {
+		return nil, err
+	}
+
+	wm.Update = update
+
+	return wm, nil
+}
+
+// SVGWatermarkForReader returns an SVG watermark configuration for r.
+func SVGWatermarkForReader(r io.Reader, desc string, onTop, update bool, u types.DisplayUnit) (*model.Watermark, error) {
+	wm, err := pdfcpu.ParseSVGWatermarkDetails("", desc, onTop, u)
+	if err != nil {
+		return nil, err
+	}
+
+	wm.Update = update
+	wm.Image = r
+
+	return wm, nil
+}
+
 // AddTextWatermarksFile adds text stamps/watermarks to all selected pages of inFile and writes the result to outFile.
 func AddTextWatermarksFile(inFile, outFile string, selectedPages []string, onTop bool, text, desc string, conf *model.Configuration) error {
 	unit := types.POINTS
@@ -565,6 +865,36 @@ func AddPDFWatermarksFile(inFile, outFile string, selectedPages []string, onTop
 	return AddWatermarksFile(inFile, outFile, selectedPages, wm, conf)
 }
 
+// AddShapeWatermarksFile adds shape stamps/watermarks to all selected pages of inFile and writes the result to outFile.
+func AddShapeWatermarksFile(inFile, outFile string, selectedPages []string, onTop bool, shape, desc string, conf *model.Configuration) error {
+	unit := types.POINTS
+	if conf != nil {
+		unit = conf.Unit
+	}
+
+	wm, err := ShapeWatermark(shape, desc, onTop, false, unit)
+	if err != nil {
+		return err
+	}
+
+	return AddWatermarksFile(inFile, outFile, selectedPages, wm, conf)
+}
+
+// AddSVGWatermarksFile adds SVG stamps/watermarks to all selected pages of inFile and writes the result to outFile.
+func AddSVGWatermarksFile(inFile, outFile string, selectedPages []string, onTop bool, fileName, desc string, conf *model.Configuration) error {
+	unit := types.POINTS
+	if conf != nil {
+		unit = conf.Unit
+	}
+
+	wm, err := SVGWatermark(fileName, desc, onTop, false, unit)
+	if err != nil {
+		return err
+	}
+
+	return AddWatermarksFile(inFile, outFile, selectedPages, wm, conf)
+}
+
 // UpdateTextWatermarksFile adds text stamps/watermarks to all selected pages of inFile and writes the result to outFile.
 func UpdateTextWatermarksFile(inFile, outFile string, selectedPages []string, onTop bool, text, desc string, conf *model.Configuration) error {
 	unit := types.POINTS
@@ -607,3 +937,55 @@ func UpdatePDFWatermarksFile(inFile, outFile string, selectedPages []string, onT
 
 	return AddWatermarksFile(inFile, outFile, selectedPages, wm, conf)
 }
+
+// UpdateShapeWatermarksFile adds shape stamps/watermarks to all selected pages of inFile and writes the result to outFile.
+func UpdateShapeWatermarksFile(inFile, outFile string, selectedPages []string, onTop bool, shape, desc string, conf *model.Configuration) error {
+	unit := types.POINTS
+	if conf != nil {
+		unit = conf.Unit
+	}
+
+	wm, err := ShapeWatermark(shape, desc, onTop, true, unit)
+	if err != nil {
+		return err
+	}
+
+	return AddWatermarksFile(inFile, outFile, selectedPages, wm, conf)
+}
+
+// UpdateSVGWatermarksFile adds SVG stamps/watermarks to all selected pages of inFile and writes the result to outFile.
+func UpdateSVGWatermarksFile(inFile, outFile string, selectedPages []string, onTop bool, fileName, desc string, conf *model.Configuration) error {
+	unit := types.POINTS
+	if conf != nil {
+		unit = conf.Unit
+	}
+
+	wm, err := SVGWatermark(fileName, desc, onTop, true, unit)
+	if err != nil {
+		return err
+	}
+
+	return AddWatermarksFile(inFile, outFile, selectedPages, wm, conf)
+}
+
+// RemoveWatermark removes the watermark identified by name from all pages selected in rs and writes the result to w.
+func RemoveWatermark(rs io.ReadSeeker, w io.Writer, selectedPages []string, name string, conf *model.Configuration) error {
+	if name == "" {
+		return errors.New("pdfcpu: RemoveWatermark: missing name")
+	}
+
+	return modifyContext(rs, w, model.REMOVEWATERMARKS, conf, func(ctx *model.Context) error {
+		pages, err := PagesForPageSelection(ctx.PageCount, selectedPages, true, true)
+		if err != nil {
+			return err
+		}
+		return pdfcpu.RemoveWatermark(ctx, pages, name)
+	})
+}
+
+// RemoveWatermarkFile removes the watermark identified by name from all pages selected in inFile and writes the result to outFile.
+func RemoveWatermarkFile(inFile, outFile string, selectedPages []string, name string, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return RemoveWatermark(rs, w, selectedPages, name, conf)
+	})
+}
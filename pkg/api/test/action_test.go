@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+)
+
+func TestActions(t *testing.T) {
+	msg := "TestActions"
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+	outFile := filepath.Join(outDir, "actions.pdf")
+
+	actions := func(selectedPages []string) []pdfcpu.Action {
+		t.Helper()
+		f, err := os.Open(outFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		defer f.Close()
+		aa, err := api.Actions(f, selectedPages, nil)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		return aa
+	}
+
+	// Print on opening.
+	if err := api.SetActionFile(inFile, outFile, nil, pdfcpu.DocOpen, pdfcpu.PrintAction(), nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	js, err := pdfcpu.JavaScriptAction(`app.alert("Bye (for now)");`)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.SetActionFile(outFile, "", nil, pdfcpu.DocWillClose, js, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.SetActionFile(outFile, "", []string{"1"}, pdfcpu.PageOpen, pdfcpu.NamedAction("NextPage"), nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.SetActionFile(outFile, "", []string{"1"}, pdfcpu.PageClose, js, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	aa := actions(nil)
+	if len(aa) != 4 {
+		t.Fatalf("%s: want 4 actions, got %v\n", msg, aa)
+	}
+	if a := aa[0]; a.Trigger != pdfcpu.DocOpen || a.Type != "JavaScript" {
+		t.Fatalf("%s: unexpected open action: %v\n", msg, a)
+	}
+	if a := aa[1]; a.Trigger != pdfcpu.DocWillClose || a.Value != `app.alert("Bye (for now)");` {
+		t.Fatalf("%s: unexpected will close action: %v\n", msg, a)
+	}
+	if a := aa[2]; a.Trigger != pdfcpu.PageOpen || a.PageNr != 1 || a.Type != "Named" || a.Value != "NextPage" {
+		t.Fatalf("%s: unexpected page open action: %v\n", msg, a)
+	}
+
+	// Remove page actions only.
+	if err := api.RemoveActionsFile(outFile, "", nil, []pdfcpu.ActionTrigger{pdfcpu.PageOpen, pdfcpu.PageClose}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if aa := actions(nil); len(aa) != 2 {
+		t.Fatalf("%s: want 2 actions, got %v\n", msg, aa)
+	}
+
+	// Remove all remaining actions.
+	if err := api.RemoveActionsFile(outFile, "", nil, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if aa := actions(nil); len(aa) != 0 {
+		t.Fatalf("%s: want no actions, got %v\n", msg, aa)
+	}
+
+	if err := api.RemoveActionsFile(outFile, "", nil, nil, nil); err == nil {
+		t.Fatalf("%s: want error removing non existing actions\n", msg)
+	}
+}
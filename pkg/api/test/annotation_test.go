@@ -429,3 +429,243 @@ func TestAddLinkAnnotationWithDest(t *testing.T) {
 		t.Fatalf("%s add: %v\n", msg, err)
 	}
 }
+
+func TestRegenerateAppearances(t *testing.T) {
+	msg := "TestRegenerateAppearances"
+
+	fn := "test.pdf"
+	copyFile(t, filepath.Join(inDir, fn), filepath.Join(outDir, fn))
+	inFile := filepath.Join(outDir, fn)
+	outFile := filepath.Join(outDir, "testRegenerateAppearances.pdf")
+
+	// Add a text annotation lacking an appearance stream to page 1.
+	if err := api.AddAnnotationsFile(inFile, "", []string{"1"}, textAnn, nil, false); err != nil {
+		t.Fatalf("%s add: %v\n", msg, err)
+	}
+
+	if err := api.RegenerateAppearancesFile(inFile, outFile, nil, nil); err != nil {
+		t.Fatalf("%s regenerate: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s readContext: %v\n", msg, err)
+	}
+
+	d, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s pageDict: %v\n", msg, err)
+	}
+
+	annots, err := ctx.DereferenceArray(d["Annots"])
+	if err != nil || len(annots) != 1 {
+		t.Fatalf("%s annots: %v\n", msg, err)
+	}
+
+	d, err = ctx.DereferenceDict(annots[0])
+	if err != nil {
+		t.Fatalf("%s annot: %v\n", msg, err)
+	}
+
+	ap := d.DictEntry("AP")
+	if ap == nil || ap.IndirectRefEntry("N") == nil {
+		t.Fatalf("%s: missing normal appearance\n", msg)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s validate: %v\n", msg, err)
+	}
+
+	// Regenerate the appearance streams of form fields.
+	inFile = filepath.Join(samplesDir, "form", "demo", "english.pdf")
+	outFile = filepath.Join(outDir, "testRegenerateFormAppearances.pdf")
+	if err := api.RegenerateAppearancesFile(inFile, outFile, nil, nil); err != nil {
+		t.Fatalf("%s regenerate form: %v\n", msg, err)
+	}
+}
+
+func TestViewSpaceCoordinates(t *testing.T) {
+	msg := "TestViewSpaceCoordinates"
+
+	cropBox := types.NewRectangle(10, 20, 210, 120)
+	r := types.NewRectangle(30, 40, 50, 100)
+
+	for _, tt := range []struct {
+		rot  int
+		want *types.Rectangle
+	}{
+		{0, types.NewRectangle(20, 20, 40, 80)},
+		{90, types.NewRectangle(20, 160, 80, 180)},
+		{180, types.NewRectangle(160, 20, 180, 80)},
+		{270, types.NewRectangle(20, 20, 80, 40)},
+		{-90, types.NewRectangle(20, 20, 80, 40)},
+	} {
+		got := model.PageRectToView(r, cropBox, tt.rot)
+		if !got.Equals(*tt.want) {
+			t.Fatalf("%s rot=%d: want %s, got %s\n", msg, tt.rot, tt.want, got)
+		}
+		if r1 := model.ViewRectToPage(got, cropBox, tt.rot); !r1.Equals(*r) {
+			t.Fatalf("%s rot=%d: roundtrip: want %s, got %s\n", msg, tt.rot, r, r1)
+		}
+	}
+}
+
+func TestAddAnnotationInViewSpace(t *testing.T) {
+	msg := "TestAddAnnotationInViewSpace"
+	inFile := filepath.Join(inDir, "test.pdf")
+	outFile := filepath.Join(outDir, "viewSpaceAnnotation.pdf")
+
+	if err := api.RotateFile(inFile, outFile, 90, []string{"1"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	pbs, err := ctx.PageBoundaries(types.IntSet{1: true})
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	cropBox := pbs[0].CropBox()
+
+	// A link in the upper left corner of page 1 as displayed.
+	rView := types.NewRectangle(0, cropBox.Width()-100, 100, cropBox.Width())
+	ann := model.NewViewSpaceAnnotation(model.NewLinkAnnotation(*rView, nil, nil, "https://pdfcpu.io", "ID1", 0, nil, false))
+
+	if err := api.AddAnnotationsFile(outFile, "", []string{"1"}, ann, nil, false); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx, err = api.ReadContextFile(outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	d, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	annots, err := ctx.DereferenceArray(d["Annots"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	var r *types.Rectangle
+	for _, o := range annots {
+		d1, err := ctx.DereferenceDict(o)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if nm := d1.StringEntry("NM"); nm != nil && *nm == "ID1" {
+			if r, err = types.RectForArray(d1.ArrayEntry("Rect")); err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+		}
+	}
+	if r == nil {
+		t.Fatalf("%s: missing annotation\n", msg)
+	}
+
+	// The upper left corner of the rotated view corresponds to the lower left corner of the page.
+	want := types.NewRectangle(cropBox.LL.X, cropBox.LL.Y, cropBox.LL.X+100, cropBox.LL.Y+100)
+	if !r.Equals(*want) {
+		t.Fatalf("%s: want %s, got %s\n", msg, want, r)
+	}
+}
+
+func linkDicts(t *testing.T, fileName string, pageNr int) []types.Dict {
+	t.Helper()
+
+	ctx, err := api.ReadContextFile(fileName)
+	if err != nil {
+		t.Fatalf("readContext: %v\n", err)
+	}
+
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		t.Fatalf("pageDict: %v\n", err)
+	}
+
+	annots, err := ctx.DereferenceArray(d["Annots"])
+	if err != nil {
+		t.Fatalf("annots: %v\n", err)
+	}
+
+	dd := []types.Dict{}
+	for _, o := range annots {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			t.Fatalf("annot: %v\n", err)
+		}
+		if st := d.Subtype(); st != nil && *st == "Link" {
+			dd = append(dd, d)
+		}
+	}
+
+	return dd
+}
+
+func TestLinkStyles(t *testing.T) {
+	msg := "TestLinkStyles"
+
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "LinkStyles.pdf")
+
+	ls := model.LinkStyle{
+		Width:     2,
+		Style:     model.BorderDashed,
+		Dash:      []float64{4, 2},
+		Color:     &color.Blue,
+		Highlight: model.LinkHighlightOutline,
+	}
+
+	ann := model.NewStyledLinkAnnotation(*types.NewRectangle(100, 100, 300, 130), nil, nil, "https://pdfcpu.io", "link1", 0, ls)
+	if err := api.AddAnnotationsFile(inFile, outFile, []string{"1"}, ann, nil, false); err != nil {
+		t.Fatalf("%s add: %v\n", msg, err)
+	}
+
+	dd := linkDicts(t, outFile, 1)
+	if len(dd) != 1 {
+		t.Fatalf("%s: want 1 link, got %d\n", msg, len(dd))
+	}
+	d := dd[0]
+	bs := d.DictEntry("BS")
+	if bs == nil || bs.NameEntry("S") == nil || *bs.NameEntry("S") != "D" || len(bs.ArrayEntry("D")) != 2 {
+		t.Fatalf("%s: corrupt border style: %v\n", msg, bs)
+	}
+	if h := d.NameEntry("H"); h == nil || *h != "O" {
+		t.Fatalf("%s: want highlight mode O, got %v\n", msg, h)
+	}
+	if len(d.ArrayEntry("C")) != 3 {
+		t.Fatalf("%s: missing border color\n", msg)
+	}
+
+	// Remove all visible link borders.
+	if err := api.RestyleLinksFile(outFile, "", nil, model.LinkStyle{Highlight: model.LinkHighlightNone}, nil); err != nil {
+		t.Fatalf("%s restyle: %v\n", msg, err)
+	}
+
+	d = linkDicts(t, outFile, 1)[0]
+	if w, ok := d.DictEntry("BS")["W"].(types.Float); !ok || w != 0 {
+		t.Fatalf("%s: want border width 0, got %v\n", msg, w)
+	}
+	if d.ArrayEntry("C") != nil {
+		t.Fatalf("%s: border color should be removed\n", msg)
+	}
+	if h := d.NameEntry("H"); h == nil || *h != "N" {
+		t.Fatalf("%s: want highlight mode N, got %v\n", msg, h)
+	}
+
+	if err := api.RestyleLinksFile(outFile, "", []string{"2"}, model.LinkStyle{}, nil); err == nil {
+		t.Fatalf("%s: restyling a page without links should fail\n", msg)
+	}
+
+	for _, ls := range []model.LinkStyle{
+		{Width: -1},
+		{Dash: []float64{1, 2, 3}},
+		{Highlight: "X"},
+	} {
+		if err := api.RestyleLinksFile(outFile, "", nil, ls, nil); err == nil {
+			t.Fatalf("%s: invalid link style %v should fail\n", msg, ls)
+		}
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"os"
 	"path/filepath"
@@ -25,6 +27,7 @@ import (
 	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 )
 
@@ -113,6 +116,61 @@ func TestAttachments(t *testing.T) {
 	}
 }
 
+func TestAttachmentInfos(t *testing.T) {
+	msg := "TestAttachmentInfos"
+
+	fileName := filepath.Join(outDir, "attachmentInfos.pdf")
+	if err := copyFile(t, filepath.Join(inDir, "go.pdf"), fileName); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	files := []string{filepath.Join(inDir, "golang.pdf"), filepath.Join(resDir, "test.wav")}
+	if err := api.AddAttachmentsFile(fileName, "", files, false, nil); err != nil {
+		t.Fatalf("%s add attachments: %v\n", msg, err)
+	}
+
+	want := map[string]string{}
+	for _, f := range files {
+		bb, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		sum := sha256.Sum256(bb)
+		want[filepath.Base(f)] = hex.EncodeToString(sum[:])
+	}
+
+	check := func(conf *model.Configuration, encryption string) {
+		t.Helper()
+		aii, err := api.AttachmentInfosFile(fileName, conf)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if len(aii) != len(want) {
+			t.Fatalf("%s: want %d attachments, got %d\n", msg, len(want), len(aii))
+		}
+		for _, ai := range aii {
+			if ai.SHA256 != want[ai.FileName] {
+				t.Fatalf("%s: %s: sha256 mismatch: %s\n", msg, ai.FileName, ai.SHA256)
+			}
+			if ai.DeclaredSize == nil || *ai.DeclaredSize != ai.Size {
+				t.Fatalf("%s: %s: size mismatch\n", msg, ai.FileName)
+			}
+			if ai.Encrypted != (encryption != "") || ai.Encryption != encryption {
+				t.Fatalf("%s: %s: want encryption %q, got %q\n", msg, ai.FileName, encryption, ai.Encryption)
+			}
+		}
+	}
+
+	check(nil, "")
+
+	conf := model.NewAESConfiguration("upw", "opw", 256)
+	if err := api.EncryptFile(fileName, "", conf); err != nil {
+		t.Fatalf("%s encrypt: %v\n", msg, err)
+	}
+
+	check(model.NewAESConfiguration("upw", "opw", 256), "AES-256")
+}
+
 // timeEqualsTimeFromDateTime returns true if t1 equals t2
 // working on the assumption that t2 is restored from a PDF
 // date string that does not have a way to include nanoseconds.
@@ -260,3 +318,52 @@ func TestAttachmentsLowLevel(t *testing.T) {
 
 	removeAttachment(t, msg, outFile, a, ctx)
 }
+
+func TestAddJobTicket(t *testing.T) {
+	msg := "TestAddJobTicket"
+	inFile := filepath.Join(inDir, "go.pdf")
+	outFile := filepath.Join(outDir, "jobTicket.pdf")
+	jdfFile := filepath.Join(outDir, "Finishing.jdf")
+
+	jdf := `<?xml version="1.0" encoding="UTF-8"?>
+<JDF xmlns="http://www.CIP4.org/JDFSchema_1_1" Type="Product" JobID="4711" Status="Waiting" Version="1.7"/>`
+	if err := os.WriteFile(jdfFile, []byte(jdf), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.AddJobTicketFile(inFile, outFile, jdfFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	aii, err := api.AttachmentInfosFile(outFile, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(aii) != 1 || aii[0].FileName != "Finishing.jdf" || aii[0].MimeType != pdfcpu.JDFMimeType || aii[0].Size != len(jdf) {
+		t.Fatalf("%s: unexpected attachments: %v\n", msg, aii)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	af, err := ctx.DereferenceArray(ctx.RootDict["AF"])
+	if err != nil || len(af) != 1 {
+		t.Fatalf("%s: want 1 associated file, got %v\n", msg, af)
+	}
+	d, err := ctx.DereferenceDict(af[0])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if rel := d.NameEntry("AFRelationship"); rel == nil || *rel != "Supplement" {
+		t.Fatalf("%s: want relationship Supplement, got %v\n", msg, rel)
+	}
+
+	if err := api.AddJobTicket(strings.NewReader(""), io.Discard, strings.NewReader(jdf), "ticket.xml", nil); err == nil {
+		t.Fatalf("%s: want error for invalid job ticket file name\n", msg)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+func TestProcessBatch(t *testing.T) {
+	msg := "TestProcessBatch"
+
+	batchInDir := filepath.Join(outDir, "batchIn")
+	batchOutDir := filepath.Join(outDir, "batchOut")
+	for _, dir := range []string{batchInDir, batchOutDir} {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(batchInDir, "sub"), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, fn := range []string{"test.pdf", "Walden.pdf", filepath.Join("sub", "5116.DCT_Filter.pdf")} {
+		if err := copyFile(t, filepath.Join(inDir, filepath.Base(fn)), filepath.Join(batchInDir, fn)); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	// A corrupt file must not stop the batch.
+	if err := os.WriteFile(filepath.Join(batchInDir, "sub", "corrupt.pdf"), []byte("%PDF-1.7\ngarbage"), 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	wm, err := api.TextWatermark("Batch", "", false, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	b := api.Batch{
+		InDir:  batchInDir,
+		OutDir: batchOutDir,
+		Steps: []api.BatchStep{
+			api.Optimize,
+			func(rs io.ReadSeeker, w io.Writer, conf *model.Configuration) error {
+				return api.AddWatermarks(rs, w, nil, wm, conf)
+			},
+		},
+		Workers: 2,
+		Conf:    conf,
+	}
+
+	rep, err := api.ProcessBatch(b)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if rep.Processed != 3 || rep.Failed != 1 || rep.Skipped != 0 {
+		t.Fatalf("%s: unexpected report: %s\n", msg, rep)
+	}
+
+	outFile := filepath.Join(batchOutDir, "sub", "5116.DCT_Filter.pdf")
+	if err := api.ValidateFile(outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Existing output files are skipped by default.
+	if rep, err = api.ProcessBatch(b); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if rep.Processed != 0 || rep.Failed != 1 || rep.Skipped != 3 {
+		t.Fatalf("%s: unexpected report: %s\n", msg, rep)
+	}
+
+	// A stale temp file does not get in the way.
+	staleFile := filepath.Join(batchOutDir, "test.pdf.tmp")
+	if err := os.MkdirAll(staleFile, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	b.Policy = api.BatchOverwrite
+	if rep, err = api.ProcessBatch(b); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if rep.Processed != 3 {
+		t.Fatalf("%s: unexpected report: %s\n", msg, rep)
+	}
+
+	// No temp files are left behind.
+	tmpFiles, err := filepath.Glob(filepath.Join(batchOutDir, "*.tmp"))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(tmpFiles) != 1 || tmpFiles[0] != staleFile {
+		t.Fatalf("%s: unexpected temp files: %v\n", msg, tmpFiles)
+	}
+}
+
+func TestProcessBatchOutDirInInDir(t *testing.T) {
+	msg := "TestProcessBatchOutDirInInDir"
+
+	batchInDir := filepath.Join(outDir, "batchNested")
+	batchOutDir := filepath.Join(batchInDir, "out")
+	if err := os.RemoveAll(batchInDir); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := os.MkdirAll(batchInDir, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for _, fn := range []string{"test.pdf", "Walden.pdf"} {
+		if err := copyFile(t, filepath.Join(inDir, fn), filepath.Join(batchInDir, fn)); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	b := api.Batch{
+		InDir:  batchInDir,
+		OutDir: batchOutDir,
+		Steps:  []api.BatchStep{api.Optimize},
+		Policy: api.BatchOverwrite,
+		Conf:   conf,
+	}
+
+	// Output files of earlier runs are no input files.
+	for i := 0; i < 2; i++ {
+		rep, err := api.ProcessBatch(b)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if rep.Processed != 2 || rep.Failed != 0 {
+			t.Fatalf("%s: run %d: unexpected report: %s\n", msg, i+1, rep)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(batchOutDir, "out")); !os.IsNotExist(err) {
+		t.Fatalf("%s: nested output directory created\n", msg)
+	}
+}
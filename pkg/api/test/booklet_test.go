@@ -17,6 +17,7 @@ limitations under the License.
 package test
 
 import (
+	"bytes"
 	"path/filepath"
 	"testing"
 
@@ -159,3 +160,57 @@ func TestBooklet(t *testing.T) {
 		testBooklet(t, tt.msg, tt.inFiles, tt.outFile, tt.selectedPages, tt.desc, tt.n, tt.isImg)
 	}
 }
+
+func bookletPageContents(t *testing.T, fileName string) [][]byte {
+	t.Helper()
+	ctx, err := api.ReadContextFile(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", fileName, err)
+	}
+	var bbs [][]byte
+	for i := 1; i <= ctx.PageCount; i++ {
+		d, _, _, err := ctx.PageDict(i, false)
+		if err != nil {
+			t.Fatalf("%s: %v\n", fileName, err)
+		}
+		bb, err := ctx.PageContent(d)
+		if err != nil {
+			t.Fatalf("%s: %v\n", fileName, err)
+		}
+		bbs = append(bbs, bb)
+	}
+	return bbs
+}
+
+func TestBookletSignatures(t *testing.T) {
+	msg := "TestBookletSignatures"
+	outDir := filepath.Join("..", "..", "samples", "booklet")
+	inFiles := []string{filepath.Join(inDir, "pike-stanford.pdf")}
+	outFile1 := filepath.Join(outDir, "BookletSignatures.pdf")
+	outFile2 := filepath.Join(outDir, "BookletSignaturesCreep.pdf")
+
+	// 56 pages make 3 signatures of 16 pages (4 sheets each) and one of 8 pages (2 sheets).
+	testBooklet(t, msg, inFiles, outFile1, nil, "p:A4, signature:16", 2, false)
+	testBooklet(t, msg, inFiles, outFile2, nil, "p:A4, signature:16, creep:2, bindingshift:0", 2, false)
+
+	bbs1, bbs2 := bookletPageContents(t, outFile1), bookletPageContents(t, outFile2)
+	if len(bbs1) != 28 || len(bbs2) != 28 {
+		t.Fatalf("%s: want 28 pages, got %d and %d\n", msg, len(bbs1), len(bbs2))
+	}
+
+	// The outermost sheet of a signature does not creep, the innermost does.
+	for i, want := range map[int]bool{0: true, 1: true, 6: false, 7: false, 8: true} {
+		if got := bytes.Equal(bbs1[i], bbs2[i]); got != want {
+			t.Fatalf("%s: page %d: want unchanged=%t\n", msg, i+1, want)
+		}
+	}
+
+	// A 4-up sheet holds 8 pages.
+	nup, err := api.PDFBookletConfig(4, "p:A4, signature:12")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.BookletFile(inFiles, outFile1, nil, nup, nil); err == nil {
+		t.Fatalf("%s: want error for invalid signature size\n", msg)
+	}
+}
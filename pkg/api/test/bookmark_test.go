@@ -19,6 +19,7 @@ package test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -187,3 +188,87 @@ func TestImportBookmarks(t *testing.T) {
 		t.Fatalf("%s: %v\n", msg, err)
 	}
 }
+
+func checkImportedBookmarks(t *testing.T, msg, fileName string) {
+	t.Helper()
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	bms, err := api.Bookmarks(f, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if len(bms) != 2 || len(bms[0].Kids) != 2 || len(bms[0].Kids[1].Kids) != 1 || len(bms[1].Kids) != 0 {
+		t.Fatalf("%s: unexpected bookmark tree: %v\n", msg, bms)
+	}
+
+	bm := bms[0]
+	if bm.Title != "Part 1" || bm.PageFrom != 1 || !bm.Bold || bm.Italic {
+		t.Fatalf("%s: unexpected bookmark: %+v\n", msg, bm)
+	}
+	if bm = bms[0].Kids[1]; bm.Title != "Chapter 2, Details" || bm.PageFrom != 3 || !bm.Italic {
+		t.Fatalf("%s: unexpected bookmark: %+v\n", msg, bm)
+	}
+	if bm = bms[0].Kids[1].Kids[0]; bm.Title != "Section 2.1" || bm.PageFrom != 4 {
+		t.Fatalf("%s: unexpected bookmark: %+v\n", msg, bm)
+	}
+	if bm = bms[1]; bm.Title != "Part 2" || bm.PageFrom != 5 {
+		t.Fatalf("%s: unexpected bookmark: %+v\n", msg, bm)
+	}
+}
+
+func TestImportBookmarksCSVAndText(t *testing.T) {
+	msg := "TestImportBookmarksCSVAndText"
+	inFile := filepath.Join(inDir, "WaldenFull.pdf")
+
+	for _, tt := range []struct {
+		fileName, content string
+	}{
+		{"bookmarks.csv",
+			"level,title,page,style,color\n" +
+				"1,Part 1,1,bold\n" +
+				"2,Chapter 1,2\n" +
+				"2,\"Chapter 2, Details\",3,italic,#0000FF\n" +
+				"3,Section 2.1,4\n" +
+				"1,Part 2,5\n"},
+		{"bookmarks.md",
+			"# **Part 1** ........ 1\n" +
+				"## Chapter 1 ........ 2\n" +
+				"## *Chapter 2, Details* | 3\n" +
+				"### Section 2.1 ..... 4\n" +
+				"\n" +
+				"# Part 2 5\n"},
+		{"bookmarks.txt",
+			"- **Part 1** 1\n" +
+				"  - Chapter 1 2\n" +
+				"  - _Chapter 2, Details_ 3\n" +
+				"\t\t- Section 2.1 4\n" +
+				"- Part 2 5\n"},
+	} {
+		inFileBms := filepath.Join(outDir, tt.fileName)
+		if err := os.WriteFile(inFileBms, []byte(tt.content), os.ModePerm); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		outFile := filepath.Join(outDir, "ImportedBookmarks.pdf")
+		if err := api.ImportBookmarksFile(inFile, inFileBms, outFile, true, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.fileName, err)
+		}
+		if err := api.ValidateFile(outFile, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.fileName, err)
+		}
+		checkImportedBookmarks(t, msg+" "+tt.fileName, outFile)
+	}
+
+	// Levels must not skip.
+	if _, err := pdfcpu.ParseBookmarksCSV(strings.NewReader("1,Part 1,1\n3,Section,2\n")); err == nil {
+		t.Fatalf("%s: skipped level should fail\n", msg)
+	}
+	if _, err := pdfcpu.ParseBookmarksText(strings.NewReader("# Introduction\n")); err == nil {
+		t.Fatalf("%s: missing page should fail\n", msg)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+)
+
+func TestPageChecksums(t *testing.T) {
+	msg := "TestPageChecksums"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	optFile := filepath.Join(outDir, "WaldenChecksums.pdf")
+	wmFile := filepath.Join(outDir, "WaldenChecksumsWM.pdf")
+
+	m1, err := api.PageChecksumsFile(inFile, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(m1) != 2 || m1[1] == m1[2] {
+		t.Fatalf("%s: unexpected checksums: %v\n", msg, m1)
+	}
+
+	// Rewriting the file renumbers objects but leaves the pages unchanged.
+	if err := api.OptimizeFile(inFile, optFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	m2, err := api.PageChecksumsFile(optFile, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if !reflect.DeepEqual(m1, m2) {
+		t.Fatalf("%s: checksums changed after optimization:\n%v\n%v\n", msg, m1, m2)
+	}
+
+	if err := api.AddTextWatermarksFile(optFile, wmFile, []string{"2"}, true, "Changed", "", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	f1, err := os.Open(optFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f1.Close()
+
+	f2, err := os.Open(wmFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f2.Close()
+
+	pages, err := api.ChangedPages(f1, f2, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if !reflect.DeepEqual(pages, []int{2}) {
+		t.Fatalf("%s: want changed pages [2], got %v\n", msg, pages)
+	}
+}
@@ -18,6 +18,7 @@ package test
 
 import (
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
@@ -70,3 +71,93 @@ func TestCollectLowLevel(t *testing.T) {
 		t.Fatalf("%s write: %v\n", msg, err)
 	}
 }
+
+func TestReorderPages(t *testing.T) {
+	msg := "TestReorderPages"
+
+	inFile := filepath.Join(inDir, "pike-stanford.pdf")
+	outFile := filepath.Join(outDir, "myReorderedPages.pdf")
+
+	// Reverse the first three pages, repeat page 1 and omit all other pages.
+	if err := api.ReorderPagesFile(inFile, outFile, []int{3, 2, 1, 1}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	n, err := api.PageCountFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n != 4 {
+		t.Fatalf("%s: want 4 pages, got %d\n", msg, n)
+	}
+
+	if err := api.ReorderPagesFile(inFile, outFile, []int{1, 99}, nil); err == nil {
+		t.Fatalf("%s: want error for invalid page number\n", msg)
+	}
+}
+
+func TestPageOrder(t *testing.T) {
+	msg := "TestPageOrder"
+
+	for _, tt := range []struct {
+		pages []int
+		mode  api.PageOrderMode
+		n     int
+		want  []int
+	}{
+		{[]int{1, 2, 3, 4, 5, 6}, api.PageOrderDuplex, 0, []int{1, 3, 5, 6, 4, 2}},
+		{[]int{1, 2, 3, 4, 5}, api.PageOrderDuplex, 0, []int{1, 3, 5, 0, 4, 2}},
+		{[]int{2, 3}, api.PageOrderCollate, 3, []int{2, 3, 2, 3, 2, 3}},
+	} {
+		got, err := api.PageOrder(tt.pages, tt.mode, tt.n)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: want %v, got %v\n", msg, tt.want, got)
+		}
+	}
+
+	if _, err := api.PageOrder([]int{1}, api.PageOrderCollate, 0); err == nil {
+		t.Fatalf("%s: want error for invalid number of copies\n", msg)
+	}
+}
+
+func TestReorderPagesForMode(t *testing.T) {
+	msg := "TestReorderPagesForMode"
+
+	inFile := filepath.Join(inDir, "pike-stanford.pdf")
+
+	for _, tt := range []struct {
+		outFile       string
+		selectedPages []string
+		mode          api.PageOrderMode
+		n             int
+		wantPages     int
+	}{
+		// 5 pages on 3 sheets need a blank back for the last sheet.
+		{"myDuplexPages.pdf", []string{"1-5"}, api.PageOrderDuplex, 0, 6},
+		{"myCollatedPages.pdf", []string{"1-3"}, api.PageOrderCollate, 2, 6},
+	} {
+		outFile := filepath.Join(outDir, tt.outFile)
+		if err := api.ReorderPagesForModeFile(inFile, outFile, tt.selectedPages, tt.mode, tt.n, nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		if err := api.ValidateFile(outFile, nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		n, err := api.PageCountFile(outFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if n != tt.wantPages {
+			t.Fatalf("%s %s: want %d pages, got %d\n", msg, tt.outFile, tt.wantPages, n)
+		}
+	}
+}
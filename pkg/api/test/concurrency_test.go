@@ -17,11 +17,15 @@ limitations under the License.
 package test
 
 import (
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
 func TestDisableConfigDir(t *testing.T) {
@@ -47,3 +51,64 @@ func TestDisableConfigDir_Parallel(t *testing.T) {
 	wg.Wait()
 	t.Log("DisableConfigDir passed")
 }
+
+func TestSharedContext(t *testing.T) {
+	msg := "TestSharedContext"
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+
+	f, err := os.Open(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	c := *conf
+	c.LazyLoading = true
+
+	ctx, err := api.ReadSharedContext(f, &c)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	want := make([]string, ctx.PageCount+1)
+	for i := 1; i <= ctx.PageCount; i++ {
+		if want[i], err = pdfcpu.ExtractPageText(ctx, i, false); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pdfcpu.Info(ctx, inFile, nil); err != nil {
+				t.Errorf("%s: %v\n", msg, err)
+				return
+			}
+			for i := 1; i <= ctx.PageCount; i++ {
+				s, err := pdfcpu.ExtractPageText(ctx, i, false)
+				if err != nil {
+					t.Errorf("%s: %v\n", msg, err)
+					return
+				}
+				if s != want[i] {
+					t.Errorf("%s: page %d: unexpected text\n", msg, i)
+				}
+				ctxDest, err := pdfcpu.ExtractPage(ctx, i)
+				if err != nil {
+					t.Errorf("%s: %v\n", msg, err)
+					return
+				}
+				if err := api.ValidateContext(ctxDest); err != nil {
+					t.Errorf("%s: %v\n", msg, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := ctx.IndRefForNewObject(types.NewDict()); err != model.ErrFrozen {
+		t.Fatalf("%s: want ErrFrozen, got %v\n", msg, err)
+	}
+}
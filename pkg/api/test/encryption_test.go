@@ -17,8 +17,11 @@ limitations under the License.
 package test
 
 import (
+	"bytes"
+	"encoding/hex"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -191,3 +194,254 @@ func TestEncryption(t *testing.T) {
 		testEncryption(t, fileName, "aes", 256)
 	}
 }
+
+func TestEncryptionPDF20(t *testing.T) {
+	msg := "TestEncryptionPDF20"
+
+	bb, err := os.ReadFile(filepath.Join(inDir, "test.pdf"))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Turn into a PDF 2.0 file.
+	copy(bb, "%PDF-2.0")
+	fileName := filepath.Join(outDir, "pdf20.pdf")
+	if err := os.WriteFile(fileName, bb, 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// AES-256 encryption of PDF 2.0 files uses security handler revision 6.
+	conf := model.NewAESConfiguration("upw", "opw", 256)
+	if err := api.EncryptFile(fileName, "", conf); err != nil {
+		t.Fatalf("%s: encrypt: %v\n", msg, err)
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	ctx, err := api.ReadContext(f, model.NewAESConfiguration("upw", "", 256))
+	f.Close()
+	if err != nil {
+		t.Fatalf("%s: read: %v\n", msg, err)
+	}
+	if v := ctx.VersionString(); v != "2.0" {
+		t.Fatalf("%s: want version 2.0, got %s\n", msg, v)
+	}
+	if ctx.E.R != 6 {
+		t.Fatalf("%s: want R 6, got %d\n", msg, ctx.E.R)
+	}
+
+	conf = model.NewAESConfiguration("", "opw", 256)
+	if err := api.DecryptFile(fileName, "", conf); err != nil {
+		t.Fatalf("%s: decrypt: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(fileName, nil); err != nil {
+		t.Fatalf("%s: validate: %v\n", msg, err)
+	}
+}
+
+func TestDecryptionAES256R6(t *testing.T) {
+	msg := "TestDecryptionAES256R6"
+
+	// aes256R6.pdf has been encrypted by an independent implementation of the
+	// revision 6 security handler (ISO 32000-2 Algorithms 2.B, 8, 9 and 10)
+	// using the user password "u fi" and the owner password "ö" repeated 100 times
+	// truncated to 127 bytes.
+	inFile := filepath.Join(resDir, "aes256R6.pdf")
+
+	readContext := func(upw, opw string) (*model.Context, error) {
+		f, err := os.Open(inFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		defer f.Close()
+		return api.ReadContext(f, model.NewAESConfiguration(upw, opw, 256))
+	}
+
+	for _, tt := range []struct {
+		upw, opw string
+	}{
+		{"u fi", ""},
+		{"u\u00A0\uFB01", ""},          // SASLprep maps NBSP to space and normalizes the fi ligature.
+		{"u \u00ADfi", ""},             // SASLprep maps soft hyphen to nothing.
+		{"", strings.Repeat("ö", 100)}, // 200 bytes truncated to 127 bytes.
+	} {
+		ctx, err := readContext(tt.upw, tt.opw)
+		if err != nil {
+			t.Fatalf("%s: read with %q/%q: %v\n", msg, tt.upw, tt.opw, err)
+		}
+		if ctx.E.R != 6 {
+			t.Fatalf("%s: want R 6, got %d\n", msg, ctx.E.R)
+		}
+	}
+
+	if _, err := readContext("u  fi", ""); err == nil {
+		t.Fatalf("%s: want error for wrong password\n", msg)
+	}
+
+	outFile := filepath.Join(outDir, "aes256R6.pdf")
+	if err := api.DecryptFile(inFile, outFile, model.NewAESConfiguration("", strings.Repeat("ö", 100), 256)); err != nil {
+		t.Fatalf("%s: decrypt: %v\n", msg, err)
+	}
+	bb, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for _, s := range []string{"(Known answer R6) Tj", "/Title<" + hex.EncodeToString([]byte("AES-256 R6 known answer")) + ">"} {
+		if !bytes.Contains(bb, []byte(s)) {
+			t.Fatalf("%s: missing decrypted %s\n", msg, s)
+		}
+	}
+}
+
+func TestPasswordProvider(t *testing.T) {
+	msg := "TestPasswordProvider"
+
+	fileName := filepath.Join(outDir, "passwordProvider.pdf")
+	if err := copyFile(t, filepath.Join(inDir, "5116.DCT_Filter.pdf"), fileName); err != nil {
+		t.Fatalf("%s copyFile: %v\n", msg, err)
+	}
+
+	if err := api.EncryptFile(fileName, "", model.NewAESConfiguration("upw", "opw", 256)); err != nil {
+		t.Fatalf("%s: encrypt: %v\n", msg, err)
+	}
+
+	var fileNames []string
+	passwords := []string{"wrong", "upw"}
+
+	conf := model.NewDefaultConfiguration()
+	conf.PasswordProvider = func(fileName string, attempt int) (string, string, bool) {
+		fileNames = append(fileNames, fileName)
+		if attempt > len(passwords) {
+			return "", "", false
+		}
+		return passwords[attempt-1], "", true
+	}
+
+	if err := api.ValidateFile(fileName, conf); err != nil {
+		t.Fatalf("%s: validate: %v\n", msg, err)
+	}
+	if len(fileNames) != 2 || fileNames[1] != fileName {
+		t.Fatalf("%s: want 2 attempts for %s, got %v\n", msg, fileName, fileNames)
+	}
+	if conf.UserPW != "" || conf.OwnerPW != "" {
+		t.Fatalf("%s: provided passwords leaked into conf: upw=%q opw=%q\n", msg, conf.UserPW, conf.OwnerPW)
+	}
+
+	// Changing the user password requires the owner password.
+	fileNames = nil
+	conf = model.NewAESConfiguration("", "", 256)
+	conf.PasswordProvider = func(fileName string, attempt int) (string, string, bool) {
+		fileNames = append(fileNames, fileName)
+		if attempt > 1 {
+			return "", "", false
+		}
+		return "upw", "opw", true
+	}
+	if err := api.ChangeUserPasswordFile(fileName, "", "upw", "upwNew", conf); err != nil {
+		t.Fatalf("%s: change upw: %v\n", msg, err)
+	}
+	if len(fileNames) != 1 {
+		t.Fatalf("%s: want 1 attempt, got %d\n", msg, len(fileNames))
+	}
+	if conf.OwnerPW != "" {
+		t.Fatalf("%s: provided owner password leaked into conf: %q\n", msg, conf.OwnerPW)
+	}
+
+	// The owner password supplied by the provider remains in effect.
+	for _, pw := range [][]string{{"upwNew", ""}, {"", "opw"}} {
+		if err := api.ValidateFile(fileName, model.NewAESConfiguration(pw[0], pw[1], 256)); err != nil {
+			t.Fatalf("%s: validate upw=%q opw=%q: %v\n", msg, pw[0], pw[1], err)
+		}
+	}
+
+	// Give up after the provider runs out of passwords.
+	fileNames, passwords = nil, []string{"upw"}
+	conf = model.NewDefaultConfiguration()
+	conf.PasswordProvider = func(fileName string, attempt int) (string, string, bool) {
+		fileNames = append(fileNames, fileName)
+		if attempt > len(passwords) {
+			return "", "", false
+		}
+		return passwords[attempt-1], "", true
+	}
+	if err := api.ValidateFile(fileName, conf); err == nil {
+		t.Fatalf("%s: want error for wrong password\n", msg)
+	}
+	if len(fileNames) != 2 {
+		t.Fatalf("%s: want 2 attempts, got %d\n", msg, len(fileNames))
+	}
+}
+
+func TestEncryptionObjectStreams(t *testing.T) {
+	msg := "TestEncryptionObjectStreams"
+
+	// read.go.pdf uses a cross reference table.
+	inFile := filepath.Join(inDir, "read.go.pdf")
+	outFile := filepath.Join(outDir, "read.go_enc.pdf")
+
+	for _, streams := range []bool{false, true} {
+		conf := model.NewAESConfiguration("upw", "opw", 256)
+		conf.EncryptUsingObjectStreams = streams
+		if err := api.EncryptFile(inFile, outFile, conf); err != nil {
+			t.Fatalf("%s: encrypt: %v\n", msg, err)
+		}
+
+		conf = model.NewAESConfiguration("upw", "opw", 256)
+		if err := api.ValidateFile(outFile, conf); err != nil {
+			t.Fatalf("%s: validate: %v\n", msg, err)
+		}
+
+		f, err := os.Open(outFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		ctx, err := api.ReadContext(f, conf)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: read: %v\n", msg, err)
+		}
+
+		// Encrypted files keep using a cross reference table unless object streams are asked for.
+		if ctx.Read.UsingObjectStreams != streams || ctx.Read.UsingXRefStreams != streams {
+			t.Fatalf("%s: want object streams: %t, got: %t\n", msg, streams, ctx.Read.UsingObjectStreams)
+		}
+	}
+}
+
+func TestEncryptionLinearized(t *testing.T) {
+	msg := "TestEncryptionLinearized"
+	inFile := filepath.Join(inDir, "go.pdf")
+	outFile := filepath.Join(outDir, "goLinearizedEnc.pdf")
+
+	// Optimize, encrypt and linearize in a single pass.
+	for _, conf := range []*model.Configuration{
+		model.NewRC4Configuration("upw", "opw", 128),
+		model.NewAESConfiguration("upw", "opw", 256),
+	} {
+		conf.WriteLinearized = true
+		if err := api.EncryptFile(inFile, outFile, conf); err != nil {
+			t.Fatalf("%s: encrypt: %v\n", msg, err)
+		}
+
+		if list, err := listPermissions(t, outFile); err == nil {
+			t.Fatalf("%s: list permissions w/o pw %s: %v\n", msg, outFile, list)
+		}
+
+		conf.WriteLinearized = false
+		ctx := checkLinearized(t, msg, outFile, conf)
+		if ctx.PageCount != 23 {
+			t.Fatalf("%s: want 23 pages, got %d\n", msg, ctx.PageCount)
+		}
+
+		if err := api.DecryptFile(outFile, "", conf); err != nil {
+			t.Fatalf("%s: decrypt: %v\n", msg, err)
+		}
+
+		if err := api.ValidateFile(outFile, nil); err != nil {
+			t.Fatalf("%s: validate: %v\n", msg, err)
+		}
+	}
+}
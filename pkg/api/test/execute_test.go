@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+)
+
+func TestExecute(t *testing.T) {
+	msg := "TestExecute"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile1 := filepath.Join(outDir, "WaldenExecute1.pdf")
+	outFile2 := filepath.Join(outDir, "WaldenExecute2.pdf")
+	outFile3 := filepath.Join(outDir, "WaldenExecute3.pdf")
+
+	jobs := fmt.Sprintf(`[
+	{"op": "rotate", "inFile": %q, "outFile": %q, "pages": ["1"], "params": {"rotation": 90}},
+	{"op": "stamp", "inFile": %q, "outFile": %q, "params": {"mode": "text", "value": "Draft", "desc": "scale:.5"}},
+	{"op": "addKeywords", "inFile": %q, "outFile": %q, "params": {"keywords": ["queued"]}}
+]`, inFile, outFile1, outFile1, outFile2, outFile2, outFile3)
+
+	ops, err := api.ParseOperations(strings.NewReader(jobs))
+	if err != nil {
+		t.Fatalf("%s parse: %v\n", msg, err)
+	}
+	if len(ops) != 3 {
+		t.Fatalf("%s: want 3 operations, got %d\n", msg, len(ops))
+	}
+
+	// Descriptors survive a serialization roundtrip.
+	bb, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatalf("%s marshal: %v\n", msg, err)
+	}
+	if ops, err = api.ParseOperations(strings.NewReader(string(bb))); err != nil {
+		t.Fatalf("%s reparse: %v\n", msg, err)
+	}
+
+	if err := api.ExecuteAll(ops, conf); err != nil {
+		t.Fatalf("%s execute: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile1)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	d, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if r := d.IntEntry("Rotate"); r == nil || *r != 90 {
+		t.Fatalf("%s: page 1 not rotated\n", msg)
+	}
+
+	ok, err := api.HasWatermarksFile(outFile3, conf)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if !ok {
+		t.Fatalf("%s: missing stamps\n", msg)
+	}
+
+	kk, err := listKeywordsFile(t, outFile3, conf)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(kk) != 1 || kk[0] != "queued" {
+		t.Fatalf("%s: want keyword queued, got %v\n", msg, kk)
+	}
+
+	for _, s := range []string{
+		`{"op": "frobnicate", "inFile": "in.pdf"}`,
+		`{"op": "rotate", "outFile": "out.pdf"}`,
+		`{"op": "split", "inFile": "in.pdf"}`,
+	} {
+		if _, err := api.ParseOperation([]byte(s)); err == nil {
+			t.Fatalf("%s: expected error parsing %s\n", msg, s)
+		}
+	}
+
+	// Unknown parameters are rejected.
+	op := api.Operation{Op: "rotate", InFile: inFile, OutFile: outFile1, Params: json.RawMessage(`{"rotate": 90}`)}
+	if err := api.Execute(op, conf); err == nil {
+		t.Fatalf("%s: expected error for unknown param\n", msg)
+	}
+}
@@ -259,6 +259,29 @@ func TestExtractContent(t *testing.T) {
 	}
 }
 
+func TestExtractText(t *testing.T) {
+	msg := "TestExtractText"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outDir := filepath.Join(outDir, "text")
+	if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Extract the text of page 2 as is and then healed.
+	for _, heal := range []bool{false, true} {
+		if err := api.ExtractTextFile(inFile, outDir, []string{"2"}, heal, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, inFile, err)
+		}
+		bb, err := os.ReadFile(filepath.Join(outDir, "Walden_Text_page_2.txt"))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if ok := strings.Contains(string(bb), "This is a delicious evening, when the whole body is one sense, and imbibes"); ok != heal {
+			t.Fatalf("%s heal=%t: unexpected text:\n%s\n", msg, heal, bb)
+		}
+	}
+}
+
 func TestExtractContentLowLevel(t *testing.T) {
 	msg := "TestExtractContentLowLevel"
 	inFile := filepath.Join(inDir, "5116.DCT_Filter.pdf")
@@ -319,3 +342,53 @@ func TestExtractMetadataLowLevel(t *testing.T) {
 			md.ObjNr, md.ParentObjNr, md.ParentType, string(bb))
 	}
 }
+
+func TestSnapshot(t *testing.T) {
+	msg := "TestSnapshot"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "WaldenSnapshot.pdf")
+
+	regions := []*types.Rectangle{
+		types.NewRectangle(50, 500, 350, 700),
+		types.NewRectangle(-100, -100, 100, 50), // gets clipped to the page.
+	}
+
+	if err := api.SnapshotFile(inFile, outFile, 1, regions, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	dims, err := api.PageDimsFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	want := []types.Dim{{Width: 300, Height: 200}, {Width: 100, Height: 50}}
+	if len(dims) != len(want) {
+		t.Fatalf("%s: want %d pages, got %d\n", msg, len(want), len(dims))
+	}
+	for i, d := range dims {
+		if d != want[i] {
+			t.Fatalf("%s: page %d: want %v, got %v\n", msg, i+1, want[i], d)
+		}
+	}
+
+	// Regions are relative to the page as displayed.
+	rotFile := filepath.Join(outDir, "WaldenRotated.pdf")
+	if err := api.RotateFile(inFile, rotFile, 90, []string{"1"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	d0, err := api.PageDimsFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	r := types.NewRectangle(0, 0, d0[0].Height, 100)
+	if err := api.SnapshotFile(rotFile, outFile, 1, []*types.Rectangle{r}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if dims, err = api.PageDimsFile(outFile); err != nil || len(dims) != 1 || dims[0].Width != d0[0].Height {
+		t.Fatalf("%s: want width %.2f, got %v %v\n", msg, d0[0].Height, dims, err)
+	}
+
+	if err := api.SnapshotFile(inFile, outFile, 1, []*types.Rectangle{types.NewRectangle(5000, 5000, 5100, 5100)}, nil); err == nil {
+		t.Fatalf("%s: want error for region outside of page\n", msg)
+	}
+}
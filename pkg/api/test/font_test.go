@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
@@ -154,3 +155,60 @@ func TestUserFontDemoPDF(t *testing.T) {
 		}
 	}
 }
+
+func TestFontLicense(t *testing.T) {
+	msg := "TestFontLicense"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "FontLicense.pdf")
+	fontName := "Roboto-Regular"
+
+	desc := "font:" + fontName + ", scale:.5"
+	if err := api.AddTextWatermarksFile(inFile, outFile, nil, true, "Licensed", desc, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ff, err := api.FontInfosFile(outFile, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	var found bool
+	for _, fi := range ff {
+		if !strings.HasSuffix(fi.Name, fontName) {
+			continue
+		}
+		found = true
+		if fi.FontFile != "FontFile2" || !fi.HasFSType || fi.License() != "installable" {
+			t.Fatalf("%s: unexpected font info: %s\n", msg, fi)
+		}
+	}
+	if !found {
+		t.Fatalf("%s: missing embedded font %s\n", msg, fontName)
+	}
+
+	// Pretend the user font's license forbids embedding.
+	font.UserFontMetricsLock.Lock()
+	ttf := font.UserFontMetrics[fontName]
+	restricted := ttf
+	restricted.FSType = font.FSTypeRestricted
+	font.UserFontMetrics[fontName] = restricted
+	font.UserFontMetricsLock.Unlock()
+
+	defer func() {
+		font.UserFontMetricsLock.Lock()
+		font.UserFontMetrics[fontName] = ttf
+		font.UserFontMetricsLock.Unlock()
+	}()
+
+	conf := model.NewDefaultConfiguration()
+	conf.FontLicensePolicy = model.FontLicenseRefuse
+	err = api.AddTextWatermarksFile(inFile, outFile, nil, true, "Licensed", desc, conf)
+	if err == nil || !strings.Contains(err.Error(), "forbids embedding") {
+		t.Fatalf("%s: expected license error, got: %v\n", msg, err)
+	}
+
+	conf = model.NewDefaultConfiguration()
+	conf.FontLicensePolicy = model.FontLicenseWarn
+	if err := api.AddTextWatermarksFile(inFile, outFile, nil, true, "Licensed", desc, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+}
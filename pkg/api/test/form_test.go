@@ -17,14 +17,23 @@ limitations under the License.
 package test
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/create"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/form"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/primitives"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
 /**************************************************************
@@ -189,6 +198,91 @@ func TestExportForm(t *testing.T) {
 	}
 }
 
+func fieldSchemaForID(s *form.Schema, id string) *form.Schema {
+	if s == nil || s.Items == nil {
+		return nil
+	}
+	for _, fs := range s.Items.AnyOf {
+		if fs.Properties["id"].Const == id {
+			return fs
+		}
+	}
+	return nil
+}
+
+func TestExportFormSchema(t *testing.T) {
+	msg := "TestExportFormSchema"
+	inFile := filepath.Join(samplesDir, "form", "demoSinglePage", "english.pdf")
+	outFile := filepath.Join(samplesDir, "form", "export", "english.schema.json")
+
+	if err := api.ExportFormSchemaFile(inFile, outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	fg, _, err := form.ExportForm(ctx.XRefTable, inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	f := fg.Forms[0]
+	if len(f.TextFields) == 0 || len(f.ListBoxes) == 0 {
+		t.Fatalf("%s: missing text fields or list boxes\n", msg)
+	}
+
+	// Make the first text field required and limit its length.
+	tf := f.TextFields[0]
+	ss := strings.Split(tf.ID, ".")
+	objNr, err := strconv.Atoi(ss[len(ss)-1])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	d, err := ctx.DereferenceDict(*types.NewIndirectRef(objNr, 0))
+	if err != nil || d == nil {
+		t.Fatalf("%s: missing text field %s\n", msg, tf.ID)
+	}
+	d["Ff"] = types.Integer(primitives.FieldRequired)
+	d["MaxLen"] = types.Integer(20)
+
+	s, ok, err := form.ExportFormSchema(ctx.XRefTable, inFile)
+	if err != nil || !ok {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	fs := s.Definitions["form"]
+	if fs == nil {
+		t.Fatalf("%s: missing form definition\n", msg)
+	}
+
+	tfs := fs.Properties["textfield"]
+	v := fieldSchemaForID(tfs, tf.ID)
+	if v == nil {
+		t.Fatalf("%s: missing schema for text field %s\n", msg, tf.ID)
+	}
+	if v := v.Properties["value"]; v.MaxLength != 20 || v.MinLength != 1 {
+		t.Fatalf("%s: text field %s: want maxLength 20 and minLength 1, got %d %d\n", msg, tf.ID, v.MaxLength, v.MinLength)
+	}
+	if len(tfs.AllOf) != 1 || tfs.AllOf[0].Contains.Properties["id"].Const != tf.ID {
+		t.Fatalf("%s: text field %s should be required\n", msg, tf.ID)
+	}
+
+	for _, lb := range f.ListBoxes {
+		v := fieldSchemaForID(fs.Properties["listbox"], lb.ID)
+		if v == nil {
+			t.Fatalf("%s: missing schema for list box %s\n", msg, lb.ID)
+		}
+		if opts := v.Properties["values"].Items.Enum; len(opts) != len(lb.Options) {
+			t.Fatalf("%s: list box %s: want options %v, got %v\n", msg, lb.ID, lb.Options, opts)
+		}
+	}
+}
+
 func TestFillForm(t *testing.T) {
 
 	inDir := filepath.Join(samplesDir, "form", "demoSinglePage")
@@ -305,3 +399,751 @@ func TestMultiFillFormCSVMerged(t *testing.T) {
 		}
 	}
 }
+
+func formFieldDict(t *testing.T, ctx *model.Context, name string) types.Dict {
+	t.Helper()
+
+	a, err := ctx.DereferenceArray(ctx.Form["Fields"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", name, err)
+	}
+	for _, o := range a {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			t.Fatalf("%s: %v\n", name, err)
+		}
+		if s, err := types.StringOrHexLiteral(d["T"]); err == nil && s != nil && *s == name {
+			return d
+		}
+	}
+	t.Fatalf("%s: field not found\n", name)
+	return nil
+}
+
+func TestFillFormButtonsAndChoices(t *testing.T) {
+	msg := "TestFillFormButtonsAndChoices"
+	inFile := filepath.Join(samplesDir, "form", "demoSinglePage", "english.pdf")
+	outFile := filepath.Join(outDir, "FillFormButtonsAndChoices.pdf")
+
+	ctx, err := api.ReadContextFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Radio buttons out of sync with their value.
+	rbg := formFieldDict(t, ctx, "gender1")
+	for _, o := range rbg.ArrayEntry("Kids") {
+		d, _ := ctx.DereferenceDict(o)
+		d["AS"] = types.Name("Off")
+	}
+
+	// Combobox options made of export values and display texts.
+	cb := formFieldDict(t, ctx, "city12")
+	cb["Opt"] = types.Array{
+		types.Array{types.StringLiteral("LON"), types.StringLiteral("London")},
+		types.Array{types.StringLiteral("SFO"), types.StringLiteral("San Francisco")},
+		types.Array{types.StringLiteral("SYD"), types.StringLiteral("Sidney")},
+	}
+	cb["V"] = types.StringLiteral("SYD")
+
+	// A push button.
+	pageDict, pageIndRef, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	pbIndRef, err := ctx.IndRefForNewObject(types.Dict(map[string]types.Object{
+		"Type":    types.Name("Annot"),
+		"Subtype": types.Name("Widget"),
+		"FT":      types.Name("Btn"),
+		"Ff":      types.Integer(primitives.FieldPushbutton),
+		"T":       types.StringLiteral("logo"),
+		"Rect":    types.NewNumberArray(400, 700, 500, 750),
+		"P":       *pageIndRef,
+	}))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	annots, err := ctx.DereferenceArray(pageDict["Annots"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	pageDict["Annots"] = append(annots, *pbIndRef)
+	fields, err := ctx.DereferenceArray(ctx.Form["Fields"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	ctx.Form["Fields"] = append(fields, *pbIndRef)
+
+	if err := api.WriteContextFile(ctx, outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	fg := form.FormGroup{Forms: []form.Form{{
+		RadioButtonGroups: []*form.RadioButtonGroup{{Name: "gender1", Value: "non-binary"}},
+		ComboBoxes:        []*form.ComboBox{{Name: "city12", Value: "London"}},
+		ListBoxes:         []*form.ListBox{{Name: "city11", Values: []string{"Vienna"}}},
+		PushButtons:       []*form.PushButton{{Name: "logo", Image: "qr.png"}},
+	}}}
+	bb, err := json.Marshal(fg)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	rd := bytes.NewReader(bb)
+
+	img, err := os.Open(filepath.Join(resDir, "qr.png"))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer img.Close()
+
+	f, err := os.Open(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	var buf bytes.Buffer
+	err = api.FillFormWithIcons(f, rd, &buf, map[string]io.Reader{"qr.png": img}, nil)
+	f.Close()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := os.WriteFile(outFile, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx, err = api.ReadContextFile(outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// The matching radio button is on.
+	var on int
+	for _, o := range formFieldDict(t, ctx, "gender1").ArrayEntry("Kids") {
+		d, _ := ctx.DereferenceDict(o)
+		if as := d.NameEntry("AS"); as != nil && *as != "Off" {
+			if s, _ := types.DecodeName(*as); s != "non-binary" {
+				t.Fatalf("%s: radio button %s is on\n", msg, *as)
+			}
+			on++
+		}
+	}
+	if on != 1 {
+		t.Fatalf("%s: want 1 radio button on, got %d\n", msg, on)
+	}
+
+	// Display texts resolve into export values.
+	cb = formFieldDict(t, ctx, "city12")
+	if s, _ := types.StringOrHexLiteral(cb["V"]); s == nil || *s != "LON" {
+		t.Fatalf("%s: want combobox value LON, got %v\n", msg, cb["V"])
+	}
+
+	lb := formFieldDict(t, ctx, "city11")
+	if ss, ok := lb["V"].(types.Array); !ok || len(ss) != 1 {
+		t.Fatalf("%s: want 1 listbox value, got %v\n", msg, lb["V"])
+	}
+
+	pb := formFieldDict(t, ctx, "logo")
+	mk, err := ctx.DereferenceDict(pb["MK"])
+	if err != nil || mk == nil || mk.IndirectRefEntry("I") == nil {
+		t.Fatalf("%s: missing push button icon\n", msg)
+	}
+	if ap := pb.DictEntry("AP"); ap == nil || ap.IndirectRefEntry("N") == nil {
+		t.Fatalf("%s: missing push button appearance\n", msg)
+	}
+
+	// Icon files are resolved relative to the directory of the JSON file.
+	dir := filepath.Join(outDir, "pushButtonIcons")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := copyFile(t, filepath.Join(resDir, "qr.png"), filepath.Join(dir, "qr.png")); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	jsonFile := filepath.Join(dir, "fill.json")
+	for _, tt := range []struct {
+		image string
+		ok    bool
+	}{
+		{"qr.png", true},
+		{filepath.Join(dir, "qr.png"), false},
+		{filepath.Join("..", "pushButtonIcons", "qr.png"), false},
+	} {
+		fg := form.FormGroup{Forms: []form.Form{{PushButtons: []*form.PushButton{{Name: "logo", Image: tt.image}}}}}
+		bb, err := json.Marshal(fg)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if err := os.WriteFile(jsonFile, bb, 0644); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		err = api.FillFormFile(outFile, jsonFile, filepath.Join(outDir, "FillFormPushButton.pdf"), nil)
+		if tt.ok && err != nil {
+			t.Fatalf("%s: %s: %v\n", msg, tt.image, err)
+		}
+		if !tt.ok && err == nil {
+			t.Fatalf("%s: %s: want error\n", msg, tt.image)
+		}
+	}
+}
+
+func TestFlattenForm(t *testing.T) {
+	msg := "TestFlattenForm"
+	inFile := filepath.Join(samplesDir, "form", "demoSinglePage", "english.pdf")
+	outFile := filepath.Join(outDir, "FlattenForm.pdf")
+
+	// Drop the appearance of a text field in order to have it generated.
+	ctx, err := api.ReadContextFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	formFieldDict(t, ctx, "firstName1").Delete("AP")
+	if err := api.WriteContextFile(ctx, outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.FlattenFormFile(outFile, "", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err = api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if _, found := ctx.RootDict.Find("AcroForm"); found {
+		t.Fatalf("%s: AcroForm not removed\n", msg)
+	}
+	d, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	a, err := ctx.DereferenceArray(d["Annots"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for _, o := range a {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if st := d.Subtype(); st != nil && *st == "Widget" {
+			t.Fatalf("%s: widget not removed\n", msg)
+		}
+	}
+
+	// 12 fields, some of them with multiple widgets.
+	if c := strings.Count(pageContent(t, ctx, 1), " Do Q"); c < 12 {
+		t.Fatalf("%s: want at least 12 flattened widgets, got %d\n", msg, c)
+	}
+
+	if err := api.FlattenFormFile(outFile, "", nil); err == nil {
+		t.Fatalf("%s: expected error for missing form\n", msg)
+	}
+}
+
+func TestAddFormFields(t *testing.T) {
+	msg := "TestAddFormFields"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "AddFormFields.pdf")
+
+	ff := create.NewFormFields().
+		AddTextField(1, &primitives.TextField{
+			ID:       "name",
+			Tip:      "Your name",
+			Default:  "John Doe",
+			Position: [2]float64{100, 700},
+			Width:    200,
+			Required: true,
+			Label:    &primitives.TextFieldLabel{TextField: primitives.TextField{Value: "Name:"}, Width: 60, Position: "left"},
+		}).
+		AddCheckBox(1, &primitives.CheckBox{ID: "subscribe", Position: [2]float64{100, 650}, Width: 12, Value: true}).
+		AddRadioButtonGroup(1, &primitives.RadioButtonGroup{
+			ID:       "size",
+			Width:    80,
+			Position: [2]float64{100, 600},
+			Value:    "M",
+			Buttons: &primitives.Buttons{
+				Values: []string{"S", "M", "L"},
+				Label:  &primitives.TextFieldLabel{TextField: primitives.TextField{Value: "x"}, Width: 20, Position: "right"},
+			},
+		}).
+		AddComboBox(1, &primitives.ComboBox{ID: "country", Position: [2]float64{100, 550}, Width: 100, Options: []string{"Austria", "Germany"}}).
+		AddListBox(1, &primitives.ListBox{ID: "colors", Position: [2]float64{100, 450}, Width: 100, Height: 60, Options: []string{"Red", "Green", "Blue"}}).
+		AddSignatureField(1, &primitives.SignatureField{ID: "signature", Tip: "Sign here", Position: [2]float64{100, 350}, Width: 200, Height: 50})
+
+	if err := api.AddFormFieldsFile(inFile, outFile, ff, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, id := range []string{"name", "subscribe", "size", "country", "colors", "signature"} {
+		formFieldDict(t, ctx, id)
+	}
+
+	if ff := formFieldDict(t, ctx, "name").IntEntry("Ff"); ff == nil || primitives.FieldFlags(*ff)&primitives.FieldRequired == 0 {
+		t.Fatalf("%s: name: missing required flag\n", msg)
+	}
+
+	sig := formFieldDict(t, ctx, "signature")
+	if ft := sig.NameEntry("FT"); ft == nil || *ft != "Sig" {
+		t.Fatalf("%s: signature: want FT Sig\n", msg)
+	}
+
+	// Adding to a non existing page fails.
+	ff = create.NewFormFields().AddTextField(99, &primitives.TextField{ID: "x", Position: [2]float64{10, 10}, Width: 50})
+	if err := api.AddFormFieldsFile(inFile, outFile, ff, nil); err == nil {
+		t.Fatalf("%s: expected error for invalid page\n", msg)
+	}
+
+	// Field ids must be unique across the form.
+	ff = create.NewFormFields().AddTextField(1, &primitives.TextField{ID: "name", Position: [2]float64{10, 10}, Width: 50})
+	if err := api.AddFormFieldsFile(outFile, filepath.Join(outDir, "AddFormFieldsDup.pdf"), ff, nil); err == nil {
+		t.Fatalf("%s: expected error for duplicate field\n", msg)
+	}
+}
+
+func TestRenameFormFields(t *testing.T) {
+	msg := "TestRenameFormFields"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "RenameFormFields.pdf")
+
+	ff := create.NewFormFields().
+		AddTextField(1, &primitives.TextField{ID: "firstName", Position: [2]float64{100, 700}, Width: 200}).
+		AddTextField(1, &primitives.TextField{ID: "lastName", Position: [2]float64{100, 650}, Width: 200})
+
+	if err := api.AddFormFieldsFile(inFile, outFile, ff, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	js, err := pdfcpu.JavaScriptAction(`this.getField("firstName").value = this.getField('lastName').value; var s = "firstNameX";`)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.SetActionFile(outFile, "", nil, pdfcpu.DocOpen, js, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Rename via a JSON mapping.
+	m, err := api.FieldNameMap(strings.NewReader(`{"firstName": "contact_first_name", "lastName": "contact_last_name"}`))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.RenameFormFieldsFile(outFile, "", m, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	formFieldDict(t, ctx, "contact_first_name")
+	formFieldDict(t, ctx, "contact_last_name")
+
+	f, err := os.Open(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+	aa, err := api.Actions(f, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	want := `this.getField("contact_first_name").value = this.getField('contact_last_name').value; var s = "firstNameX";`
+	if len(aa) != 1 || aa[0].Value != want {
+		t.Fatalf("%s: JavaScript not updated: %v\n", msg, aa)
+	}
+
+	// Renaming an unknown field fails.
+	if err := api.RenameFormFieldsFile(outFile, filepath.Join(outDir, "RenameFormFieldsErr.pdf"), map[string]string{"firstName": "x"}, nil); err == nil {
+		t.Fatalf("%s: expected error for unknown field\n", msg)
+	}
+
+	// Renaming into an existing name fails.
+	if err := api.RenameFormFieldsFile(outFile, filepath.Join(outDir, "RenameFormFieldsErr.pdf"), map[string]string{"contact_first_name": "contact_last_name"}, nil); err == nil {
+		t.Fatalf("%s: expected error for duplicate field name\n", msg)
+	}
+}
+
+func fillFormAudit(t *testing.T, inFile, outFile, json string, conf *model.Configuration) *form.Audit {
+	t.Helper()
+
+	bb, err := os.ReadFile(inFile)
+	if err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	var buf bytes.Buffer
+	a, err := api.FillFormAudit(bytes.NewReader(bb), strings.NewReader(json), &buf, conf)
+	if err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	if err := os.WriteFile(outFile, buf.Bytes(), os.ModePerm); err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	return a
+}
+
+func TestFillFormAudit(t *testing.T) {
+	msg := "TestFillFormAudit"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "FillFormAudit.pdf")
+
+	ff := create.NewFormFields().
+		AddTextField(1, &primitives.TextField{ID: "firstName", Position: [2]float64{100, 700}, Width: 200}).
+		AddTextField(1, &primitives.TextField{ID: "lastName", Position: [2]float64{100, 650}, Width: 200, Value: "Evans"})
+
+	if err := api.AddFormFieldsFile(inFile, outFile, ff, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	conf := model.NewDefaultConfiguration()
+	conf.FormAuditTrail = true
+
+	a := fillFormAudit(t, outFile, outFile, `{"forms": [{"textfield": [{"name": "firstName", "value": "Rick"}, {"name": "lastName", "value": "Evans"}]}]}`, conf)
+	if len(a.Changes) != 1 || a.Changes[0].Name != "firstName" || a.Changes[0].Old != "" || a.Changes[0].New != "Rick" {
+		t.Fatalf("%s: unexpected changes: %v\n", msg, a)
+	}
+
+	a = fillFormAudit(t, outFile, outFile, `{"forms": [{"textfield": [{"name": "firstName", "value": "Jim"}]}]}`, conf)
+	if len(a.Changes) != 1 || a.Changes[0].Old != "Rick" || a.Changes[0].New != "Jim" {
+		t.Fatalf("%s: unexpected changes: %v\n", msg, a)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Both runs are recorded into the XMP history.
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	sd, _, err := ctx.DereferenceStreamDict(rootDict["Metadata"])
+	if err != nil || sd == nil {
+		t.Fatalf("%s: missing metadata: %v\n", msg, err)
+	}
+	if err := sd.Decode(); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	s := string(sd.Content)
+	if !strings.Contains(s, form.XMPNamespaceFormAudit) || strings.Count(s, "<pdfcpuFormAudit:When>") != 2 ||
+		!strings.Contains(s, "<pdfcpuFormAudit:Old>Rick</pdfcpuFormAudit:Old>") {
+		t.Fatalf("%s: unexpected metadata:\n%s\n", msg, s)
+	}
+}
+
+func TestXFA(t *testing.T) {
+	msg := "TestXFA"
+	inFile := filepath.Join(outDir, "XFA.pdf")
+	outFile := filepath.Join(outDir, "XFARemoved.pdf")
+
+	xRefTable, err := pdfcpu.CreateFormDemoXRef()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.WriteContextFile(pdfcpu.CreateContext(xRefTable, nil), inFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	xfa, err := api.XFAFile(inFile, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if xfa == nil || len(xfa.Packets) != 2 || xfa.Packets[0].Name != "xdp:xdp" || xfa.Fields == 0 {
+		t.Fatalf("%s: unexpected XFA: %v\n", msg, xfa)
+	}
+
+	xfaDir := filepath.Join(outDir, "xfa")
+	if err := os.MkdirAll(xfaDir, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ExtractXFAFile(inFile, xfaDir, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	bb, err := os.ReadFile(filepath.Join(xfaDir, "XFA_XFA_01_xdp_xdp.xml"))
+	if err != nil || !strings.HasPrefix(string(bb), "<xdp:xdp") {
+		t.Fatalf("%s: missing XDP packet: %v\n", msg, err)
+	}
+
+	if err := api.RemoveXFAFile(inFile, outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if xfa, err := api.XFAFile(outFile, nil); err != nil || xfa != nil {
+		t.Fatalf("%s: XFA not removed: %v\n", msg, err)
+	}
+
+	// The AcroForm fallback remains intact.
+	ss, err := listFormFieldsFile(t, outFile, nil)
+	if err != nil || len(ss) == 0 {
+		t.Fatalf("%s: missing form fields: %v\n", msg, err)
+	}
+
+	if err := api.RemoveXFAFile(outFile, "", nil); err != api.ErrNoXFA {
+		t.Fatalf("%s: expected ErrNoXFA, got: %v\n", msg, err)
+	}
+}
+
+func TestExportFormData(t *testing.T) {
+	msg := "TestExportFormData"
+
+	dataDir := filepath.Join(outDir, "formData")
+	if err := os.RemoveAll(dataDir); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := os.MkdirAll(filepath.Join(dataDir, "sub"), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	formFile := filepath.Join(outDir, "FormData.pdf")
+	ff := create.NewFormFields().
+		AddTextField(1, &primitives.TextField{ID: "firstName", Position: [2]float64{100, 700}, Width: 200}).
+		AddTextField(1, &primitives.TextField{ID: "lastName", Position: [2]float64{100, 650}, Width: 200})
+	if err := api.AddFormFieldsFile(filepath.Join(inDir, "Walden.pdf"), formFile, ff, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, tt := range []struct{ fileName, first, last string }{
+		{"a.pdf", "Rick", "Evans"},
+		{filepath.Join("sub", "b.pdf"), "Jim", "Doe, Jr."},
+	} {
+		json := `{"forms": [{"textfield": [{"name": "firstName", "value": "` + tt.first + `"}, {"name": "lastName", "value": "` + tt.last + `"}]}]}`
+		fillFormAudit(t, formFile, filepath.Join(dataDir, tt.fileName), json, nil)
+	}
+
+	// Files without form or corrupt files get reported but do not stop the export.
+	if err := copyFile(t, filepath.Join(inDir, "Walden.pdf"), filepath.Join(dataDir, "c.pdf")); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	e := api.FormDataExport{InDir: dataDir, Fields: []string{"firstName", "lastName"}, Format: form.CSV, Workers: 2}
+
+	var buf bytes.Buffer
+	rep, err := api.ExportFormData(e, &buf)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if rep.Processed != 2 || rep.Failed != 1 {
+		t.Fatalf("%s: unexpected report: %s\n", msg, rep)
+	}
+
+	ss := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(ss) != 4 ||
+		ss[0] != "file,firstName,lastName,error" ||
+		ss[1] != "a.pdf,Rick,Evans," ||
+		!strings.HasPrefix(ss[2], "c.pdf,,,") ||
+		ss[3] != `sub/b.pdf,Jim,"Doe, Jr.",` {
+		t.Fatalf("%s: unexpected CSV:\n%s\n", msg, buf.String())
+	}
+
+	// JSON lines including all fields.
+	e.Fields, e.Format = nil, form.JSON
+	outFile := filepath.Join(outDir, "formData.jsonl")
+	if _, err := api.ExportFormDataFile(e, outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	bb, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	ss = strings.Split(strings.TrimSpace(string(bb)), "\n")
+	if len(ss) != 3 {
+		t.Fatalf("%s: unexpected JSON lines:\n%s\n", msg, bb)
+	}
+	var row struct {
+		File   string            `json:"file"`
+		Fields map[string]string `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(ss[2]), &row); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if row.File != "sub/b.pdf" || row.Fields["firstName"] != "Jim" || row.Fields["lastName"] != "Doe, Jr." {
+		t.Fatalf("%s: unexpected row: %s\n", msg, ss[2])
+	}
+}
+
+func TestFillFormFormatAndCalculate(t *testing.T) {
+	msg := "TestFillFormFormatAndCalculate"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "FillFormFormatAndCalculate.pdf")
+
+	ff := create.NewFormFields().
+		AddTextField(1, &primitives.TextField{ID: "price", Position: [2]float64{100, 700}, Width: 200}).
+		AddTextField(1, &primitives.TextField{ID: "tax", Position: [2]float64{100, 650}, Width: 200}).
+		AddTextField(1, &primitives.TextField{ID: "total", Position: [2]float64{100, 600}, Width: 200}).
+		AddTextField(1, &primitives.TextField{ID: "due", Position: [2]float64{100, 550}, Width: 200})
+
+	if err := api.AddFormFieldsFile(inFile, outFile, ff, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Set up format and calculation scripts the way Acrobat does.
+	for id, aa := range map[string]map[string]string{
+		"price": {"F": `AFNumber_Format(2, 0, 0, 0, "$", true);`},
+		"tax":   {"F": `AFNumber_Format(2, 2, 2, 0, "", false);`},
+		"total": {"F": `AFNumber_Format(2, 2, 0, 0, " €", false);`, "C": `AFSimple_Calculate("SUM", new Array ("price", "tax"));`},
+		"due":   {"F": `AFDate_FormatEx("mmm d, yyyy");`},
+	} {
+		d := types.Dict{}
+		for k, js := range aa {
+			if d[k], err = pdfcpu.JavaScriptAction(js); err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+		}
+		formFieldDict(t, ctx, id)["AA"] = d
+	}
+
+	fields, err := ctx.DereferenceArray(ctx.Form["Fields"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for _, o := range fields {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if s, err := types.StringOrHexLiteral(d["T"]); err == nil && s != nil && *s == "total" {
+			ctx.Form["CO"] = types.Array{o}
+		}
+	}
+
+	if err := api.WriteContextFile(ctx, outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	json := `{"forms": [{"textfield": [
+		{"name": "price", "value": "1234.5"},
+		{"name": "tax", "value": "(12,25)"},
+		{"name": "due", "value": "2023-05-01"}]}]}`
+	fillFormAudit(t, outFile, outFile, json, nil)
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx, err = api.ReadContextFile(outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, tt := range []struct{ id, v, text string }{
+		{"price", "1234.5", "$1,234.50"},
+		{"tax", "-12.25", "(12,25)"},
+		{"total", "1222.25", "1.222,25"},
+		{"due", "May 1, 2023", "May 1, 2023"},
+	} {
+		d := formFieldDict(t, ctx, tt.id)
+		v, err := types.StringOrHexLiteral(d["V"])
+		if err != nil || v == nil || *v != tt.v {
+			t.Fatalf("%s: %s: want V %q, got %v\n", msg, tt.id, tt.v, d["V"])
+		}
+		sd, _, err := ctx.DereferenceStreamDict(d.DictEntry("AP")["N"])
+		if err != nil || sd == nil {
+			t.Fatalf("%s: %s: missing appearance: %v\n", msg, tt.id, err)
+		}
+		if err := sd.Decode(); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if !bytes.Contains(sd.Content, []byte("("+strings.NewReplacer("(", `\(`, ")", `\)`).Replace(tt.text))) {
+			t.Fatalf("%s: %s: want %q rendered, got:\n%s\n", msg, tt.id, tt.text, sd.Content)
+		}
+	}
+}
+
+func TestSetFormFieldAccess(t *testing.T) {
+	msg := "TestSetFormFieldAccess"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "SetFormFieldAccess.pdf")
+
+	ff := create.NewFormFields().
+		AddTextField(1, &primitives.TextField{ID: "firstName", Position: [2]float64{100, 700}, Width: 200}).
+		AddTextField(1, &primitives.TextField{ID: "lastName", Position: [2]float64{100, 650}, Width: 200}).
+		AddComboBox(1, &primitives.ComboBox{ID: "country", Position: [2]float64{100, 600}, Width: 100, Options: []string{"Austria", "Germany"}}).
+		AddSignatureField(1, &primitives.SignatureField{ID: "signature1", Position: [2]float64{100, 450}, Width: 200, Height: 50}).
+		AddSignatureField(1, &primitives.SignatureField{ID: "signature2", Position: [2]float64{100, 350}, Width: 200, Height: 50})
+
+	if err := api.AddFormFieldsFile(inFile, outFile, ff, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	fillFormAudit(t, outFile, outFile, `{"forms": [{"textfield": [{"name": "firstName", "value": "Rick"}]}]}`, nil)
+
+	yes, no := true, false
+
+	// Freeze the part filled in by the first signer and require the rest.
+	if err := api.SetFormFieldAccessFile(outFile, "", []string{"firstName", "country"}, form.FieldAccess{ReadOnly: &yes}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.SetFormFieldAccessFile(outFile, "", []string{"lastName"}, form.FieldAccess{ReadOnly: &no, Required: &yes}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Signing signature2 locks everything but signature2.
+	fl := form.FieldLock{Action: form.FieldLockExclude, Fields: []string{"signature2"}, P: 1}
+	if err := api.SetSignatureFieldLockFile(outFile, "", "signature2", fl, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, tt := range []struct {
+		id                 string
+		readOnly, required bool
+	}{
+		{"firstName", true, false},
+		{"lastName", false, true},
+		{"country", true, false},
+	} {
+		i := primitives.FieldFlags(0)
+		if ff := formFieldDict(t, ctx, tt.id).IntEntry("Ff"); ff != nil {
+			i = primitives.FieldFlags(*ff)
+		}
+		if i&primitives.FieldReadOnly > 0 != tt.readOnly || i&primitives.FieldRequired > 0 != tt.required {
+			t.Fatalf("%s: %s: unexpected field flags: %d\n", msg, tt.id, i)
+		}
+	}
+
+	if _, found := formFieldDict(t, ctx, "country").Find("AP"); !found {
+		t.Fatalf("%s: country: missing appearance\n", msg)
+	}
+
+	d, err := ctx.DereferenceDict(formFieldDict(t, ctx, "signature2")["Lock"])
+	if err != nil || d == nil {
+		t.Fatalf("%s: signature2: missing lock dict: %v\n", msg, err)
+	}
+	a := d.ArrayEntry("Fields")
+	if n := d.NameEntry("Action"); n == nil || *n != "Exclude" || len(a) != 1 || d.IntEntry("P") == nil {
+		t.Fatalf("%s: signature2: unexpected lock dict: %s\n", msg, d)
+	}
+
+	// Only signature fields take a lock dict.
+	if err := api.SetSignatureFieldLockFile(outFile, filepath.Join(outDir, "SetFormFieldAccessErr.pdf"), "firstName", form.FieldLock{Action: form.FieldLockAll}, nil); err == nil {
+		t.Fatalf("%s: expected error for non signature field\n", msg)
+	}
+}
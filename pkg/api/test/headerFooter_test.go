@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+)
+
+func pageContent(t *testing.T, ctx *model.Context, pageNr int) string {
+	t.Helper()
+
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil || d == nil {
+		t.Fatalf("page %d: %v\n", pageNr, err)
+	}
+	bb, err := ctx.PageContent(d)
+	if err != nil {
+		t.Fatalf("page %d: %v\n", pageNr, err)
+	}
+	return string(bb)
+}
+
+func TestHeaderFooter(t *testing.T) {
+	msg := "TestHeaderFooter"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "HeaderFooter.pdf")
+
+	hf := model.DefaultHeaderFooter()
+	hf.Header = model.HFSlots{Left: "Walden", Right: "Page %p of %P"}
+	hf.EvenHeader = &model.HFSlots{Left: "Page %p of %P", Right: "Walden"}
+	hf.Footer = model.HFSlots{Center: "Confidential"}
+
+	if err := api.AddHeaderFooterFile(inFile, outFile, nil, hf, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Replace the header and footer.
+	if err := api.AddHeaderFooterFile(outFile, "", nil, hf, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for pageNr := 1; pageNr <= 2; pageNr++ {
+		s := pageContent(t, ctx, pageNr)
+		if c := strings.Count(s, "/Subtype /Header"); c != 2 {
+			t.Fatalf("%s: page %d: want 2 header slots, got %d\n", msg, pageNr, c)
+		}
+		if c := strings.Count(s, "/Subtype /Footer"); c != 1 {
+			t.Fatalf("%s: page %d: want 1 footer slot, got %d\n", msg, pageNr, c)
+		}
+		if s := pageStampContent(t, ctx, pageNr); !strings.Contains(s, "Confidential") {
+			t.Fatalf("%s: page %d: missing footer\n", msg, pageNr)
+		}
+	}
+	if s := pageStampContent(t, ctx, 2); !strings.Contains(s, "Page 2 of 2") {
+		t.Fatalf("%s: page 2: missing page number\n", msg)
+	}
+
+	// Stamps survive removing header and footer.
+	if err := api.AddTextWatermarksFile(outFile, "", nil, true, "Draft", "", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.RemoveHeaderFooterFile(outFile, "", nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err = api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	s := pageContent(t, ctx, 1)
+	if strings.Contains(s, "/Subtype /Header") || strings.Contains(s, "/Subtype /Footer") {
+		t.Fatalf("%s: header/footer not removed\n", msg)
+	}
+	if !strings.Contains(s, "/Subtype /Watermark") {
+		t.Fatalf("%s: stamp removed\n", msg)
+	}
+
+	if err := api.RemoveHeaderFooterFile(outFile, "", nil, nil); err == nil {
+		t.Fatalf("%s: expected error for missing header/footer\n", msg)
+	}
+
+	if err := api.AddHeaderFooterFile(inFile, outFile, nil, model.DefaultHeaderFooter(), nil); err == nil {
+		t.Fatalf("%s: expected error for missing content\n", msg)
+	}
+
+	hf.PageBox = "trim"
+	if err := api.AddHeaderFooterFile(inFile, outFile, nil, hf, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	hf.PageBox = "foo"
+	if err := api.AddHeaderFooterFile(inFile, outFile, nil, hf, nil); err == nil {
+		t.Fatalf("%s: expected error for invalid page box\n", msg)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+)
+
+func TestICCProfiles(t *testing.T) {
+	msg := "TestICCProfiles"
+	inFile := filepath.Join(inDir, "text_annotations.pdf")
+	outFile := filepath.Join(outDir, "text_annotationsICC.pdf")
+
+	pp, err := api.ICCProfilesFile(inFile, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(pp) != 4 {
+		t.Fatalf("%s: want 4 profiles, got %d\n", msg, len(pp))
+	}
+
+	if err := api.ExtractICCProfilesFile(inFile, outDir, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	var rgbObjNr, grayObjNr int
+	for _, p := range pp {
+		fi, err := os.Stat(filepath.Join(outDir, "text_annotations_"+strconv.Itoa(p.ObjNr)+".icc"))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if int(fi.Size()) != p.Size {
+			t.Fatalf("%s: obj#%d: want %d bytes, got %d\n", msg, p.ObjNr, p.Size, fi.Size())
+		}
+		if p.Description == "Generic RGB Profile" {
+			rgbObjNr = p.ObjNr
+		}
+		if p.N == 1 {
+			grayObjNr = p.ObjNr
+		}
+	}
+
+	iccFile := filepath.Join(outDir, "text_annotations_"+strconv.Itoa(rgbObjNr)+".icc")
+
+	// A RGB profile may not replace a gray profile.
+	if err := api.ReplaceICCProfilesFile(inFile, outFile, iccFile, []int{grayObjNr}, nil); err == nil {
+		t.Fatalf("%s: replacing a gray profile with a RGB profile should fail\n", msg)
+	}
+
+	// Replace all RGB profiles.
+	if err := api.ReplaceICCProfilesFile(inFile, outFile, iccFile, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if pp, err = api.ICCProfilesFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for _, p := range pp {
+		if p.N == 3 && p.Description != "Generic RGB Profile" {
+			t.Fatalf("%s: obj#%d has not been replaced: %s\n", msg, p.ObjNr, p)
+		}
+		if p.N == 1 && p.Description == "Generic RGB Profile" {
+			t.Fatalf("%s: obj#%d should not have been replaced: %s\n", msg, p.ObjNr, p)
+		}
+	}
+}
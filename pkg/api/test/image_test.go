@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+)
+
+func TestAlternateImages(t *testing.T) {
+	msg := "TestAlternateImages"
+	outFile := filepath.Join(outDir, "alternateImages.pdf")
+	strippedFile := filepath.Join(outDir, "alternateImagesStripped.pdf")
+
+	// A screen image.
+	if err := api.ImportImagesFile([]string{filepath.Join(resDir, "logoSmall.png")}, outFile, pdfcpu.DefaultImportConfig(), nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	images := func(fileName string) map[int]model.Image {
+		t.Helper()
+		f, err := os.Open(fileName)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		defer f.Close()
+		mm, err := api.Images(f, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if len(mm) != 1 || len(mm[0]) != 1 {
+			t.Fatalf("%s: want 1 image, got %v\n", msg, mm)
+		}
+		return mm[0]
+	}
+
+	var objNr int
+	for objNr = range images(outFile) {
+	}
+
+	// Add two alternates, the second one for printing.
+	for _, fileName := range []string{"logoVerySmall.png", "mountain.jpg"} {
+		if err := api.AddAlternateImageFile(outFile, "", objNr, filepath.Join(resDir, fileName), fileName == "mountain.jpg", nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if img := images(outFile)[objNr]; img.Alternates != 2 {
+		t.Fatalf("%s: want 2 alternates, got %d\n", msg, img.Alternates)
+	}
+
+	f, err := os.Open(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	aa, err := api.AlternateImages(f, objNr, nil)
+	f.Close()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(aa) != 2 || aa[0].DefaultForPrinting || !aa[1].DefaultForPrinting {
+		t.Fatalf("%s: unexpected alternates: %v\n", msg, aa)
+	}
+
+	// Optimizing may strip alternates.
+	conf := model.NewDefaultConfiguration()
+	conf.StripAlternateImages = true
+	if err := api.OptimizeFile(outFile, strippedFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if img := images(strippedFile)[objNr]; img.Alternates != 0 {
+		t.Fatalf("%s: want no alternates, got %d\n", msg, img.Alternates)
+	}
+
+	fi1, err := os.Stat(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	fi2, err := os.Stat(strippedFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if fi2.Size() >= fi1.Size() {
+		t.Fatalf("%s: want smaller file, got %d >= %d bytes\n", msg, fi2.Size(), fi1.Size())
+	}
+}
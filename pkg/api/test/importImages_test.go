@@ -19,6 +19,9 @@ package test
 import (
 	"bufio"
 	"bytes"
+	"image"
+	"image/color"
+	"image/png"
 	"io"
 	"os"
 	"path/filepath"
@@ -26,6 +29,7 @@ import (
 
 	"github.com/mjuen/pdfcpu/pkg/api"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
@@ -119,3 +123,124 @@ func TestMemBasedWriterPanic(t *testing.T) {
 	}
 
 }
+
+// imageStreamDicts returns all image XObjects of the PDF in bb.
+func imageStreamDicts(t *testing.T, bb []byte) []types.StreamDict {
+	t.Helper()
+	ctx, err := api.ReadContext(bytes.NewReader(bb), model.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sds []types.StreamDict
+	for _, entry := range ctx.Table {
+		if entry == nil {
+			continue
+		}
+		sd, ok := entry.Object.(types.StreamDict)
+		if ok && sd.Subtype() != nil && *sd.Subtype() == "Image" {
+			sds = append(sds, sd)
+		}
+	}
+	return sds
+}
+
+func pngBytes(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImportImagesWithAlpha(t *testing.T) {
+	msg := "TestImportImagesWithAlpha"
+
+	// A paletted image with a transparent palette entry.
+	pal := image.NewPaletted(image.Rect(0, 0, 20, 20), color.Palette{color.Transparent, color.RGBA{R: 0xFF, A: 0xFF}})
+	for y := 5; y < 15; y++ {
+		for x := 5; x < 15; x++ {
+			pal.SetColorIndex(x, y, 1)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := api.ImportImages(nil, &buf, []io.Reader{bytes.NewReader(pngBytes(t, pal))}, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	var found bool
+	for _, sd := range imageStreamDicts(t, buf.Bytes()) {
+		if _, ok := sd.Find("SMask"); ok {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("%s: missing soft mask\n", msg)
+	}
+}
+
+type closeCounter struct {
+	io.Reader
+	closed *int
+}
+
+func (c closeCounter) Close() error {
+	*c.closed++
+	return nil
+}
+
+func TestImportImagesFromSource(t *testing.T) {
+	msg := "TestImportImagesFromSource"
+
+	// Generate images on demand.
+	const n = 25
+	var i, closed int
+	next := func() (io.Reader, error) {
+		if i == n {
+			return nil, io.EOF
+		}
+		i++
+		img := image.NewGray(image.Rect(0, 0, 10+i, 10))
+		img.SetGray(0, 0, color.Gray{Y: uint8(i)})
+		return closeCounter{bytes.NewReader(pngBytes(t, img)), &closed}, nil
+	}
+
+	var buf bytes.Buffer
+	if err := api.ImportImagesFromSource(nil, &buf, next, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if closed != n {
+		t.Fatalf("%s: want %d closed images, got %d\n", msg, n, closed)
+	}
+
+	ctx, err := api.ReadContext(bytes.NewReader(buf.Bytes()), model.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateContext(ctx); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if ctx.PageCount != n {
+		t.Fatalf("%s: want %d pages, got %d\n", msg, n, ctx.PageCount)
+	}
+	if sds := imageStreamDicts(t, buf.Bytes()); len(sds) != n {
+		t.Fatalf("%s: want %d images, got %d\n", msg, n, len(sds))
+	}
+
+	// Append to an existing file using a file based source.
+	inFile := filepath.Join(outDir, "ImportImagesFromSource.pdf")
+	if err := os.WriteFile(inFile, buf.Bytes(), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	imgFiles := []string{filepath.Join(resDir, "mountain.jpg"), filepath.Join(resDir, "logoSmall.png")}
+	if err := api.ImportImagesFile(imgFiles, inFile, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(inFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if pc, err := api.PageCountFile(inFile); err != nil || pc != n+2 {
+		t.Fatalf("%s: want %d pages, got %d %v\n", msg, n+2, pc, err)
+	}
+}
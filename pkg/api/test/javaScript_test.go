@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+func hasFinding(sa pdfcpu.ScriptAnalysis, rule string) bool {
+	for _, f := range sa.Findings {
+		if f.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAnalyzeJavaScript(t *testing.T) {
+	msg := "TestAnalyzeJavaScript"
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+	outFile := filepath.Join(outDir, "analyzeJavaScript.pdf")
+
+	payload := strings.Repeat("%u9090", 50)
+	js1, err := pdfcpu.JavaScriptAction(`var s = unescape("` + payload + `"); this.exportDataObject({cName: "a.exe", nLaunch: 2}); eval(unescape("%61"));`)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	js2, err := pdfcpu.JavaScriptAction(`app.alert("Hello");`)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.SetActionFile(inFile, outFile, nil, pdfcpu.DocOpen, js1, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.SetActionFile(outFile, "", []string{"1"}, pdfcpu.PageOpen, js2, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	r, err := api.AnalyzeJavaScriptFile(outFile, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if r.Risk != pdfcpu.RiskHigh {
+		t.Fatalf("%s: want risk high, got %s\n%s", msg, r.Risk, r)
+	}
+
+	var doc, page *pdfcpu.ScriptAnalysis
+	for i, sa := range r.Scripts {
+		switch {
+		case sa.Location == pdfcpu.ScriptDocument && sa.Trigger == "OpenAction":
+			doc = &r.Scripts[i]
+		case sa.Location == pdfcpu.ScriptPage && sa.PageNr == 1 && sa.Trigger == "O":
+			page = &r.Scripts[i]
+		}
+	}
+	if doc == nil || page == nil {
+		t.Fatalf("%s: missing scripts\n%s", msg, r)
+	}
+
+	for _, rule := range []string{pdfcpu.RuleSuspiciousAPI, pdfcpu.RuleEvalChain, pdfcpu.RuleDecoding, pdfcpu.RuleEscapes} {
+		if !hasFinding(*doc, rule) {
+			t.Fatalf("%s: open action: missing finding %s\n%s", msg, rule, r)
+		}
+	}
+
+	if page.Risk != pdfcpu.RiskNone || len(page.Findings) > 0 {
+		t.Fatalf("%s: page open: unexpected findings\n%s", msg, r)
+	}
+
+	// A file without JavaScript.
+	r, err = api.AnalyzeJavaScriptFile(filepath.Join(inDir, "Walden.pdf"), nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(r.Scripts) != 0 || r.Risk != pdfcpu.RiskNone {
+		t.Fatalf("%s: Walden: want no scripts\n%s", msg, r)
+	}
+}
+
+func TestRemoveScripts(t *testing.T) {
+	msg := "TestRemoveScripts"
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+	outFile := filepath.Join(outDir, "removeScripts.pdf")
+
+	js, err := pdfcpu.JavaScriptAction(`app.alert("Hello");`)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// A script followed by a submit form action.
+	js["Next"] = types.Dict(map[string]types.Object{
+		"Type": types.Name("Action"),
+		"S":    types.Name("SubmitForm"),
+		"F": types.Dict(map[string]types.Object{
+			"FS": types.Name("URL"),
+			"F":  types.StringLiteral("https://example.com/submit"),
+		}),
+	})
+
+	if err := api.SetActionFile(inFile, outFile, nil, pdfcpu.DocWillClose, js, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.SetActionFile(outFile, "", []string{"1"}, pdfcpu.PageOpen, pdfcpu.PrintAction(), nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ss, err := api.ScriptsFile(outFile, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(ss) != 2 {
+		t.Fatalf("%s: want 2 scripts, got %d\n", msg, len(ss))
+	}
+
+	// Replace the document level script.
+	f := func(s pdfcpu.Script) (string, bool) {
+		return `app.alert("Bye");`, s.Location == pdfcpu.ScriptDocument
+	}
+	if err := api.ReplaceScriptsFile(outFile, "", f, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Remove the page level script only.
+	if err := api.RemoveScriptsFile(outFile, "", func(s pdfcpu.Script) bool { return s.Location == pdfcpu.ScriptPage }, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ss, err = api.ScriptsFile(outFile, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(ss) != 1 || ss[0].Source != `app.alert("Bye");` {
+		t.Fatalf("%s: unexpected scripts: %v\n", msg, ss)
+	}
+
+	// Remove all remaining scripts.
+	if err := api.RemoveScriptsFile(outFile, "", nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ss, err = api.ScriptsFile(outFile, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(ss) != 0 {
+		t.Fatalf("%s: want no scripts, got %v\n", msg, ss)
+	}
+
+	// The submit form action survives.
+	fi, err := os.Open(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer fi.Close()
+	aa, err := api.Actions(fi, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(aa) != 1 || aa[0].Trigger != pdfcpu.DocWillClose || aa[0].Type != "SubmitForm" {
+		t.Fatalf("%s: want submit form action, got %v\n", msg, aa)
+	}
+
+	if err := api.RemoveScriptsFile(outFile, "", nil, nil); err == nil {
+		t.Fatalf("%s: expected error for missing scripts\n", msg)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+)
+
+// sparseFile simulates a huge file consisting of head followed by zeros up to offset off followed by tail.
+type sparseFile struct {
+	head, tail []byte
+	off, pos   int64
+}
+
+func (f *sparseFile) size() int64 {
+	return f.off + int64(len(f.tail))
+}
+
+func (f *sparseFile) Read(p []byte) (int, error) {
+	if f.pos >= f.size() {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && f.pos < f.size() {
+		var c int
+		switch {
+		case f.pos < int64(len(f.head)):
+			c = copy(p[n:], f.head[f.pos:])
+		case f.pos < f.off:
+			c = len(p) - n
+			if gap := f.off - f.pos; int64(c) > gap {
+				c = int(gap)
+			}
+			for i := n; i < n+c; i++ {
+				p[i] = 0
+			}
+		default:
+			c = copy(p[n:], f.tail[f.pos-f.off:])
+		}
+		n += c
+		f.pos += int64(c)
+	}
+	return n, nil
+}
+
+func (f *sparseFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.size()
+	}
+	if offset < 0 {
+		return 0, errors.New("sparseFile: negative offset")
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+func TestLargeFileOffsets(t *testing.T) {
+	msg := "TestLargeFileOffsets"
+	inFile := filepath.Join(inDir, "test.pdf")
+
+	for _, tt := range []struct {
+		name        string
+		off         int64
+		xRefStream  bool
+		objectStmns bool
+	}{
+		{"xref table beyond 4GB", 5 << 30, false, false},
+		{"xref stream beyond 4GB", 5 << 30, true, true},
+		{"xref table beyond 10 digits", 12 << 30, false, false},
+		{"xref stream beyond 10 digits", 12 << 30, true, true},
+	} {
+		c := *conf
+		c.WriteXRefStream = tt.xRefStream
+		c.WriteObjectStream = tt.objectStmns
+
+		ctx, err := api.ReadContextFile(inFile)
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.name, err)
+		}
+		ctx.Configuration = &c
+		if err := api.ValidateContext(ctx); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.name, err)
+		}
+		pageCount := ctx.PageCount
+
+		// Write as if preceded by tt.off bytes.
+		ctx.Write.Offset = tt.off
+		buf := &bytes.Buffer{}
+		if err := api.WriteContext(ctx, buf); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.name, err)
+		}
+
+		f := &sparseFile{head: []byte("%PDF-1.7\n"), tail: buf.Bytes(), off: tt.off}
+
+		ctx, err = api.ReadContext(f, model.NewDefaultConfiguration())
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.name, err)
+		}
+		if err := api.ValidateContext(ctx); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.name, err)
+		}
+		if ctx.PageCount != pageCount {
+			t.Fatalf("%s %s: want %d pages, got %d\n", msg, tt.name, pageCount, ctx.PageCount)
+		}
+		if err := api.OptimizeContext(ctx); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.name, err)
+		}
+
+		// Every object must have been read from beyond the 4GB boundary.
+		for objNr, e := range ctx.Table {
+			if e.Free || e.Compressed || e.Offset == nil || objNr == 0 {
+				continue
+			}
+			if *e.Offset < tt.off {
+				t.Fatalf("%s %s: obj#%d: unexpected offset %d\n", msg, tt.name, objNr, *e.Offset)
+			}
+		}
+	}
+}
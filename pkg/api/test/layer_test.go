@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// writeLayeredPDF writes a copy of inFile with page 1 showing content of a visible and a hidden layer.
+func writeLayeredPDF(t *testing.T, inFile, outFile string) {
+	t.Helper()
+
+	ctx, err := api.ReadContextFile(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		t.Fatal(err)
+	}
+
+	ocg := func(name string) types.IndirectRef {
+		ir, err := ctx.IndRefForNewObject(types.Dict(map[string]types.Object{
+			"Type": types.Name("OCG"),
+			"Name": types.StringLiteral(name),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *ir
+	}
+
+	visible, hidden := ocg("Visible"), ocg("Hidden")
+
+	ctx.RootDict["OCProperties"] = types.Dict(map[string]types.Object{
+		"OCGs": types.Array{visible, hidden},
+		"D": types.Dict(map[string]types.Object{
+			"Order": types.Array{visible, hidden},
+			"OFF":   types.Array{hidden},
+		}),
+	})
+
+	// A form XObject belonging to the hidden layer.
+	sd, err := ctx.NewStreamDictForBuf([]byte("0 0 m 10 10 l S"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd.InsertName("Type", "XObject")
+	sd.InsertName("Subtype", "Form")
+	sd.Insert("BBox", types.NewNumberArray(0, 0, 10, 10))
+	sd.Insert("OC", hidden)
+	if err := sd.Encode(); err != nil {
+		t.Fatal(err)
+	}
+	form, err := ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resDict, err := ctx.DereferenceDict(d["Resources"])
+	if err != nil || resDict == nil {
+		t.Fatalf("missing page resources: %v", err)
+	}
+	resDict["Properties"] = types.Dict(map[string]types.Object{
+		"oc1": visible,
+		"oc2": hidden,
+		"md": types.Dict(map[string]types.Object{
+			"Type": types.Name("OCMD"),
+			"OCGs": types.Array{hidden},
+		}),
+	})
+	xObjDict, err := ctx.DereferenceDict(resDict["XObject"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xObjDict == nil {
+		xObjDict = types.NewDict()
+		resDict["XObject"] = xObjDict
+	}
+	xObjDict["HiddenForm"] = *form
+
+	content := "/OC /oc1 BDC 1 2 m 3 4 l S EMC\n" +
+		"/OC /oc2 BDC /Span <</ActualText (x)>> BDC 5 6 m 7 8 l S EMC EMC\n" +
+		"/OC /md BDC 9 10 m 11 12 l S EMC\n" +
+		"/HiddenForm Do\n"
+	sd, err = ctx.NewStreamDictForBuf([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sd.Encode(); err != nil {
+		t.Fatal(err)
+	}
+	ir, err := ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch o := d["Contents"].(type) {
+	case types.IndirectRef:
+		d["Contents"] = types.Array{o, *ir}
+	case types.Array:
+		d["Contents"] = append(o, *ir)
+	default:
+		d["Contents"] = *ir
+	}
+
+	if err := api.WriteContextFile(ctx, outFile); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoveHiddenLayers(t *testing.T) {
+	msg := "TestRemoveHiddenLayers"
+	inFile := filepath.Join(outDir, "layers.pdf")
+	outFile := filepath.Join(outDir, "layersRemoved.pdf")
+
+	writeLayeredPDF(t, filepath.Join(inDir, "go.pdf"), inFile)
+
+	if err := api.ValidateFile(inFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.RemoveHiddenLayersFile(inFile, outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	d, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	bb, err := ctx.PageContent(d)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if !bytes.Contains(bb, []byte("/oc1")) {
+		t.Fatalf("%s: visible layer content missing\n", msg)
+	}
+	for _, s := range []string{"/oc2", "/md", "/HiddenForm", "/ActualText"} {
+		if bytes.Contains(bb, []byte(s)) {
+			t.Fatalf("%s: hidden layer content %s not removed\n", msg, s)
+		}
+	}
+
+	ocProps, err := ctx.DereferenceDict(ctx.RootDict["OCProperties"])
+	if err != nil || ocProps == nil {
+		t.Fatalf("%s: missing OCProperties: %v\n", msg, err)
+	}
+	ocgs, err := ctx.DereferenceArray(ocProps["OCGs"])
+	if err != nil || len(ocgs) != 1 {
+		t.Fatalf("%s: want 1 OCG, got %v\n", msg, ocgs)
+	}
+	dDict, err := ctx.DereferenceDict(ocProps["D"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if _, found := dDict.Find("OFF"); found {
+		t.Fatalf("%s: hidden layer still listed: %v\n", msg, dDict)
+	}
+
+	// No hidden OCGs left.
+	if err := api.RemoveHiddenLayersFile(outFile, "", model.NewDefaultConfiguration()); err == nil {
+		t.Fatalf("%s: want error for missing hidden layers\n", msg)
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+func loadedObjects(ctx *model.Context) int {
+	var c int
+	for _, entry := range ctx.Table {
+		if !entry.Free && entry.Object != nil {
+			c++
+		}
+	}
+	return c
+}
+
+func TestLazyLoading(t *testing.T) {
+	msg := "TestLazyLoading"
+	inFile := filepath.Join(inDir, "WaldenFull.pdf")
+	outFile := filepath.Join(outDir, "WaldenFullLazy.pdf")
+
+	f, err := os.Open(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	ctx, err := api.ReadContext(f, conf)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	eager := loadedObjects(ctx)
+
+	c := *conf
+	c.LazyLoading = true
+
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	ctx, err = api.ReadContext(f, &c)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if lazy := loadedObjects(ctx); lazy >= eager {
+		t.Fatalf("%s: %d objects loaded lazily, want < %d\n", msg, lazy, eager)
+	}
+
+	if err := api.ValidateContext(ctx); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if ctx.PageCount != 249 {
+		t.Fatalf("%s: pageCount want:249 got:%d\n", msg, ctx.PageCount)
+	}
+
+	// Stamp page 1.
+	wm, err := api.TextWatermark("Draft", "", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	w, err := os.Create(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.AddWatermarks(f, w, []string{"1"}, wm, &c); err != nil {
+		w.Close()
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	n, err := api.PageCountFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n != 249 {
+		t.Fatalf("%s: pageCount want:249 got:%d\n", msg, n)
+	}
+}
+
+func TestLazyLoadingWriteTwice(t *testing.T) {
+	msg := "TestLazyLoadingWriteTwice"
+	inFile := filepath.Join(inDir, "WaldenFull.pdf")
+
+	f, err := os.Open(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	c := *conf
+	c.LazyLoading = true
+
+	ctx, err := api.ReadContext(f, &c)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateContext(ctx); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Modify the content stream of page 1 in memory.
+	d, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	ir := d.IndirectRefEntry("Contents")
+	if ir == nil {
+		t.Fatalf("%s: missing page content\n", msg)
+	}
+	sd, _, err := ctx.DereferenceStreamDict(*ir)
+	if err != nil || sd == nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	content := []byte("BT /F1 12 Tf (lazily modified) Tj ET")
+	sd.Delete("Filter")
+	sd.FilterPipeline = nil
+	sd.Content, sd.Raw = content, content
+	l := int64(len(content))
+	sd.StreamLength = &l
+	sd.Update("Length", types.Integer(l))
+	ctx.Table[ir.ObjectNumber.Value()].Object = *sd
+
+	// Writing twice must not lose in-memory changes to streams loaded from the source file.
+	var pages [2][][]byte
+	for i := range pages {
+		var buf bytes.Buffer
+		ctx.ResetWriteContext()
+		if err := api.WriteContext(ctx, &buf); err != nil {
+			t.Fatalf("%s: write #%d: %v\n", msg, i+1, err)
+		}
+		ctxOut, err := api.ReadContext(bytes.NewReader(buf.Bytes()), conf)
+		if err != nil {
+			t.Fatalf("%s: read #%d: %v\n", msg, i+1, err)
+		}
+		if err := api.ValidateContext(ctxOut); err != nil {
+			t.Fatalf("%s: validate #%d: %v\n", msg, i+1, err)
+		}
+		if ctxOut.PageCount != 249 {
+			t.Fatalf("%s: write #%d: pageCount want:249 got:%d\n", msg, i+1, ctxOut.PageCount)
+		}
+		for pageNr := 1; pageNr <= 3; pageNr++ {
+			d, _, _, err := ctxOut.PageDict(pageNr, false)
+			if err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+			bb, err := ctxOut.PageContent(d)
+			if err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+			pages[i] = append(pages[i], bb)
+		}
+	}
+
+	if !bytes.Equal(pages[0][0], content) {
+		t.Fatalf("%s: write #1 lost the modified content stream\n", msg)
+	}
+	for i := range pages[0] {
+		if !bytes.Equal(pages[0][i], pages[1][i]) {
+			t.Fatalf("%s: page %d: writes differ\n", msg, i+1)
+		}
+	}
+}
+
+// countingReaderAt records the number of bytes read.
+type countingReaderAt struct {
+	ra io.ReaderAt
+	n  int64
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.ra.ReadAt(p, off)
+	r.n += int64(n)
+	return n, err
+}
+
+func TestExtractPagesAt(t *testing.T) {
+	msg := "TestExtractPagesAt"
+	inFile := filepath.Join(inDir, "WaldenFull.pdf")
+	outFile := filepath.Join(outDir, "WaldenFullFirstPages.pdf")
+
+	f, err := os.Open(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ra := &countingReaderAt{ra: f}
+
+	w, err := os.Create(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ExtractPagesAt(ra, fi.Size(), w, []string{"1-2"}, nil); err != nil {
+		w.Close()
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ra.n >= fi.Size()/2 {
+		t.Fatalf("%s: read %d of %d bytes\n", msg, ra.n, fi.Size())
+	}
+
+	if err := api.ValidateFile(outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	n, err := api.PageCountFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n != 2 {
+		t.Fatalf("%s: pageCount want:2 got:%d\n", msg, n)
+	}
+
+	ctx, err := api.ReadContextAt(f, fi.Size(), nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if ctx.PageCount != 249 {
+		t.Fatalf("%s: pageCount want:249 got:%d\n", msg, ctx.PageCount)
+	}
+}
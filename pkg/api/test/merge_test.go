@@ -18,12 +18,17 @@ package test
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
 func TestMergeCreateNew(t *testing.T) {
@@ -143,3 +148,876 @@ func TestMergeRaw(t *testing.T) {
 		t.Fatalf("%s: %v\n", msg, err)
 	}
 }
+
+func TestMergeResolveGoToRLinks(t *testing.T) {
+	msg := "TestMergeResolveGoToRLinks"
+
+	// a.pdf links to page 2 of b.pdf.
+	fileA := filepath.Join(outDir, "a.pdf")
+	fileB := filepath.Join(outDir, "b.pdf")
+	if err := copyFile(t, filepath.Join(inDir, "Walden.pdf"), fileB); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(filepath.Join(inDir, "test.pdf"))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	pageDict, pageIndRef, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	annot := types.Dict(map[string]types.Object{
+		"Type":    types.Name("Annot"),
+		"Subtype": types.Name("Link"),
+		"Rect":    types.NewNumberArray(0, 0, 100, 100),
+		"P":       *pageIndRef,
+		"A": types.Dict(map[string]types.Object{
+			"S": types.Name("GoToR"),
+			"F": types.StringLiteral("b.pdf"),
+			"D": types.Array{types.Integer(1), types.Name("Fit")},
+		}),
+	})
+	annotIndRef, err := ctx.IndRefForNewObject(annot)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	pageDict["Annots"] = types.Array{*annotIndRef}
+	if err := api.WriteContextFile(ctx, fileA); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	outFile := filepath.Join(outDir, "outGoToR.pdf")
+	if err := api.MergeCreateFile([]string{fileA, fileB}, outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx, err = api.ReadContextFile(outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	pageDict, _, _, err = ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	annots, err := ctx.DereferenceArray(pageDict["Annots"])
+	if err != nil || len(annots) != 1 {
+		t.Fatalf("%s: missing link annotation: %v\n", msg, err)
+	}
+	d, err := ctx.DereferenceDict(annots[0])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	a, err := ctx.DereferenceDict(d["A"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if s := a.NameEntry("S"); s == nil || *s != "GoTo" {
+		t.Fatalf("%s: want GoTo action, got: %s\n", msg, a)
+	}
+
+	// Page 2 of b.pdf is page 3 of the merged file.
+	want, err := ctx.PageDictIndRef(3)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	arr, err := ctx.DereferenceArray(a["D"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if got, ok := arr[0].(types.IndirectRef); !ok || got.ObjectNumber != want.ObjectNumber {
+		t.Fatalf("%s: want dest page obj %d, got: %v\n", msg, want.ObjectNumber, arr[0])
+	}
+}
+
+func mergedFormFieldNames(t *testing.T, msg, fileName string) map[string][]int {
+	t.Helper()
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	fields, err := api.FormFields(f, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	m := map[string][]int{}
+	for _, field := range fields {
+		m[field.Name] = append(m[field.Name], field.Pages...)
+	}
+	return m
+}
+
+func TestMergeFormFieldCollision(t *testing.T) {
+	msg := "TestMergeFormFieldCollision"
+	inFile := filepath.Join(samplesDir, "form", "demoSinglePage", "person.pdf")
+	inFiles := []string{inFile, inFile}
+
+	names := mergedFormFieldNames(t, msg, inFile)
+
+	for _, tt := range []struct {
+		mode    model.FieldCollisionMode
+		outFile string
+	}{
+		{model.FieldCollisionRename, "formFieldsRenamed.pdf"},
+		{model.FieldCollisionMerge, "formFieldsMerged.pdf"},
+		{model.FieldCollisionError, "formFieldsError.pdf"},
+	} {
+		c := model.NewDefaultConfiguration()
+		c.FormFieldCollision = tt.mode
+		outFile := filepath.Join(outDir, tt.outFile)
+
+		err := api.MergeCreateFile(inFiles, outFile, c)
+		if tt.mode == model.FieldCollisionError {
+			if err == nil {
+				t.Fatalf("%s: merging colliding form fields should fail\n", msg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		if err := api.ValidateFile(outFile, nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		m := mergedFormFieldNames(t, msg, outFile)
+
+		for name := range names {
+			pages, ok := m[name]
+			if !ok {
+				t.Fatalf("%s: %s: missing field %s\n", msg, tt.outFile, name)
+			}
+			if tt.mode == model.FieldCollisionRename {
+				if _, ok := m[name+"_2"]; !ok {
+					t.Fatalf("%s: %s: missing renamed field %s_2\n", msg, tt.outFile, name)
+				}
+				continue
+			}
+			// Merged fields have widgets on both pages.
+			if len(pages) != 2 {
+				t.Fatalf("%s: %s: field %s: want pages [1 2], got %v\n", msg, tt.outFile, name, pages)
+			}
+		}
+
+		if tt.mode == model.FieldCollisionMerge && len(m) != len(names) {
+			t.Fatalf("%s: %s: want %d fields, got %d\n", msg, tt.outFile, len(names), len(m))
+		}
+	}
+}
+
+func TestMergeStream(t *testing.T) {
+	msg := "TestMergeStream"
+	inFiles := []string{
+		filepath.Join(inDir, "Acroforms2.pdf"),
+		filepath.Join(inDir, "adobe_errata.pdf"),
+		filepath.Join(inDir, "Walden.pdf"),
+	}
+	outFile := filepath.Join(outDir, "mergeStream.pdf")
+
+	want := 0
+	for _, fName := range inFiles {
+		n, err := api.PageCountFile(fName)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		want += n
+	}
+
+	// Each input file gets released once its pages have been written.
+	if err := api.MergeStreamFile(inFiles, outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	n, err := api.PageCountFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n != want {
+		t.Fatalf("%s: want %d pages, got %d\n", msg, want, n)
+	}
+
+	f, err := os.Open(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	bms, err := api.Bookmarks(f, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(bms) != len(inFiles) {
+		t.Fatalf("%s: want %d bookmarks, got %d\n", msg, len(inFiles), len(bms))
+	}
+
+	// Merge streams.
+	var rsc []io.ReadSeeker
+	for _, fName := range inFiles {
+		f, err := os.Open(fName)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		defer f.Close()
+		rsc = append(rsc, f)
+	}
+
+	// MergeStream honors conf.CreateBookmarks like MergeStreamFile.
+	for _, createBookmarks := range []bool{true, false} {
+		c := model.NewDefaultConfiguration()
+		c.CreateBookmarks = createBookmarks
+
+		buf := &bytes.Buffer{}
+		if err := api.MergeStream(rsc, buf, c); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if c.CreateBookmarks != createBookmarks {
+			t.Fatalf("%s: CreateBookmarks modified\n", msg)
+		}
+
+		if err := api.Validate(bytes.NewReader(buf.Bytes()), conf); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		bms, err := api.Bookmarks(bytes.NewReader(buf.Bytes()), nil)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		want := 0
+		if createBookmarks {
+			want = len(inFiles)
+		}
+		if len(bms) != want {
+			t.Fatalf("%s: want %d bookmarks, got %d\n", msg, want, len(bms))
+		}
+	}
+}
+
+func TestMergeZip(t *testing.T) {
+	msg := "TestMergeZip"
+	inFile1 := filepath.Join(inDir, "adobe_errata.pdf")
+	inFile2 := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "mergeZip.pdf")
+
+	if err := api.MergeZipFile(inFile1, inFile2, outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	m1, err := api.PageChecksumsFile(inFile1, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	m2, err := api.PageChecksumsFile(inFile2, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	m, err := api.PageChecksumsFile(outFile, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// A1, B1, A2, B2, A3, A4, ...
+	var want []string
+	for i := 1; i <= len(m1) || i <= len(m2); i++ {
+		if i <= len(m1) {
+			want = append(want, m1[i])
+		}
+		if i <= len(m2) {
+			want = append(want, m2[i])
+		}
+	}
+
+	if len(m) != len(want) {
+		t.Fatalf("%s: want %d pages, got %d\n", msg, len(want), len(m))
+	}
+	for i, s := range want {
+		if m[i+1] != s {
+			t.Fatalf("%s: unexpected page %d\n", msg, i+1)
+		}
+	}
+}
+
+func TestMergeDividerPages(t *testing.T) {
+	msg := "TestMergeDividerPages"
+	inFiles := []string{
+		filepath.Join(inDir, "Acroforms2.pdf"),
+		filepath.Join(inDir, "adobe_errata.pdf"),
+		filepath.Join(inDir, "Walden.pdf"),
+	}
+	outFile := filepath.Join(outDir, "mergeDivider.pdf")
+
+	// Bookmarks point to the divider page in front of each appended file.
+	var pageFrom []int
+	want := 0
+	for i, fName := range inFiles {
+		pageFrom = append(pageFrom, want+1)
+		if i > 0 {
+			want++
+		}
+		n, err := api.PageCountFile(fName)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		want += n
+	}
+
+	conf := model.NewDefaultConfiguration()
+	conf.DividerPages = true
+	conf.DividerPageText = "Exhibit"
+
+	if err := api.MergeCreateFile(inFiles, outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	n, err := api.PageCountFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n != want {
+		t.Fatalf("%s: want %d pages, got %d\n", msg, want, n)
+	}
+
+	f, err := os.Open(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	bms, err := api.Bookmarks(f, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(bms) != len(inFiles) {
+		t.Fatalf("%s: want %d bookmarks, got %d\n", msg, len(inFiles), len(bms))
+	}
+	for i, bm := range bms {
+		if bm.PageFrom != pageFrom[i] {
+			t.Fatalf("%s: bookmark %q: want page %d, got %d\n", msg, bm.Title, pageFrom[i], bm.PageFrom)
+		}
+	}
+}
+
+func TestMergeTOCPage(t *testing.T) {
+	msg := "TestMergeTOCPage"
+	inFiles := []string{
+		filepath.Join(inDir, "Acroforms2.pdf"),
+		filepath.Join(inDir, "adobe_errata.pdf"),
+		filepath.Join(inDir, "Walden.pdf"),
+	}
+	outFile := filepath.Join(outDir, "mergeTOC.pdf")
+
+	// The table of contents page precedes the merged files.
+	var pageFrom []int
+	want := 1
+	for _, fName := range inFiles {
+		pageFrom = append(pageFrom, want+1)
+		n, err := api.PageCountFile(fName)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		want += n
+	}
+
+	conf := model.NewDefaultConfiguration()
+	conf.CreateBookmarks = true
+	conf.TOCPage = true
+
+	if err := api.MergeCreateFile(inFiles, outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if ctx.PageCount != want {
+		t.Fatalf("%s: want %d pages, got %d\n", msg, want, ctx.PageCount)
+	}
+
+	d, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	annots, err := ctx.DereferenceArray(d["Annots"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(annots) != len(inFiles) {
+		t.Fatalf("%s: want %d links, got %d\n", msg, len(inFiles), len(annots))
+	}
+
+	// Each link jumps to the first page of its merged file.
+	for i, o := range annots {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		ir := d.ArrayEntry("Dest")[0].(types.IndirectRef)
+		pageNr, err := ctx.PageNumber(ir.ObjectNumber.Value())
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if pageNr != pageFrom[i] {
+			t.Fatalf("%s: link %d: want page %d, got %d\n", msg, i, pageFrom[i], pageNr)
+		}
+	}
+}
+
+func TestMergeStructTree(t *testing.T) {
+	msg := "TestMergeStructTree"
+	inFiles := []string{
+		filepath.Join(inDir, "go.pdf"),
+		filepath.Join(inDir, "CenterOfWhy.pdf"),
+	}
+	outFile := filepath.Join(outDir, "mergeStructTree.pdf")
+
+	if err := api.MergeCreateFile(inFiles, outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	d, err := ctx.DereferenceDict(rootDict["StructTreeRoot"])
+	if err != nil || d == nil {
+		t.Fatalf("%s: missing StructTreeRoot\n", msg)
+	}
+	d, err = ctx.DereferenceDict(d["ParentTree"])
+	if err != nil || d == nil {
+		t.Fatalf("%s: missing ParentTree\n", msg)
+	}
+
+	keys := map[int]bool{}
+	nums := d.ArrayEntry("Nums")
+	for i := 0; i < len(nums); i += 2 {
+		keys[nums[i].(types.Integer).Value()] = true
+	}
+
+	// Each page refers to its own parent tree entry.
+	pageKeys := map[int]bool{}
+	for i := 1; i <= ctx.PageCount; i++ {
+		d, _, _, err := ctx.PageDict(i, false)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		k := d.IntEntry("StructParents")
+		if k == nil {
+			t.Fatalf("%s: page %d: missing StructParents\n", msg, i)
+		}
+		if !keys[*k] {
+			t.Fatalf("%s: page %d: StructParents %d missing in ParentTree\n", msg, i, *k)
+		}
+		if pageKeys[*k] {
+			t.Fatalf("%s: page %d: duplicate StructParents %d\n", msg, i, *k)
+		}
+		pageKeys[*k] = true
+	}
+}
+
+func TestMergeDeduplicateStreams(t *testing.T) {
+	msg := "TestMergeDeduplicateStreams"
+	inFile := filepath.Join(inDir, "T4.pdf")
+	outFile := filepath.Join(outDir, "mergeDedup.pdf")
+
+	ctxDest, err := api.ReadContextFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	ctxDest.CreateBookmarks = false
+
+	ctxSrc, err := api.ReadContextFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := pdfcpu.MergeXRefTables("T4.pdf", ctxSrc, ctxDest); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// The font programs and images of the second copy are redundant.
+	n, err := pdfcpu.DeduplicateStreams(ctxDest)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n == 0 {
+		t.Fatalf("%s: want removed duplicate streams\n", msg)
+	}
+
+	if err := api.WriteContextFile(ctxDest, outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+}
+
+func TestOptimizeDeduplicateStreams(t *testing.T) {
+	msg := "TestOptimizeDeduplicateStreams"
+	inFile := filepath.Join(inDir, "T4.pdf")
+	mergedFile := filepath.Join(outDir, "mergeNoDedup.pdf")
+
+	conf := model.NewDefaultConfiguration()
+	conf.CreateBookmarks = false
+	if err := api.MergeCreateFile([]string{inFile, inFile}, mergedFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Deduplication is optional.
+	var sizes []int64
+	for _, dedup := range []bool{false, true} {
+		conf := model.NewDefaultConfiguration()
+		conf.DeduplicateStreams = dedup
+		outFile := filepath.Join(outDir, fmt.Sprintf("optimizeDedup%t.pdf", dedup))
+		if err := api.OptimizeFile(mergedFile, outFile, conf); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		fi, err := os.Stat(outFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		sizes = append(sizes, fi.Size())
+	}
+	if sizes[1] >= sizes[0] {
+		t.Fatalf("%s: want smaller file with deduplication, got %v\n", msg, sizes)
+	}
+
+	// References to duplicates within objects not yet loaded get updated too.
+	f, err := os.Open(mergedFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	conf = model.NewDefaultConfiguration()
+	conf.LazyLoading = true
+	ctx, err := api.ReadContext(f, conf)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Load the last page only.
+	if err := ctx.EnsurePageCount(); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if _, _, _, err := ctx.PageDict(ctx.PageCount, true); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	n, err := pdfcpu.DeduplicateStreams(ctx)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n == 0 {
+		t.Fatalf("%s: want removed duplicate streams\n", msg)
+	}
+
+	outFile := filepath.Join(outDir, "mergeDedupLazy.pdf")
+	if err := api.WriteContextFile(ctx, outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+}
+
+func namedDestPageNr(t *testing.T, ctx *model.Context, name string) int {
+	t.Helper()
+	v, ok := ctx.Names["Dests"].Value(name)
+	if !ok {
+		t.Fatalf("missing named destination: %s\n", name)
+	}
+	o, err := ctx.Dereference(v)
+	if err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	if d, ok := o.(types.Dict); ok {
+		o = d["D"]
+	}
+	arr, err := ctx.DereferenceArray(o)
+	if err != nil || len(arr) == 0 {
+		t.Fatalf("corrupt named destination %s: %v\n", name, err)
+	}
+	ir := arr[0].(types.IndirectRef)
+	pageNr, err := ctx.PageNumber(ir.ObjectNumber.Value())
+	if err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	return pageNr
+}
+
+func TestMergeNamedDests(t *testing.T) {
+	msg := "TestMergeNamedDests"
+	inFile := filepath.Join(inDir, "ECSTR11-01.pdf")
+	outFile := filepath.Join(outDir, "mergeNamedDests.pdf")
+
+	n, err := api.PageCountFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Merging a file with itself results in clashing named destinations.
+	if err := api.MergeCreateFile([]string{inFile, inFile}, outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := ctx.LocateNameTree("Dests", false); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Named destinations of links must resolve to pages of the same merged file.
+	var links int
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		d, _, _, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		annots, err := ctx.DereferenceArray(d["Annots"])
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		for _, o := range annots {
+			d, err := ctx.DereferenceDict(o)
+			if err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+			dest, found := d.Find("Dest")
+			if !found {
+				a, err := ctx.DereferenceDict(d["A"])
+				if err != nil || a == nil {
+					continue
+				}
+				dest = a["D"]
+			}
+			name, err := types.StringOrHexLiteral(dest)
+			if err != nil {
+				continue
+			}
+			destPageNr := namedDestPageNr(t, ctx, *name)
+			if (pageNr-1)/n != (destPageNr-1)/n {
+				t.Fatalf("%s: link on page %d to %s resolves to page %d\n", msg, pageNr, *name, destPageNr)
+			}
+			links++
+		}
+	}
+
+	if links == 0 {
+		t.Fatalf("%s: no named destinations found\n", msg)
+	}
+}
+
+// writeNamedDestLink writes inFile to outFile adding the named destination name for page 1
+// and a link on page 1 to the named destination name of remoteFile.
+func writeNamedDestLink(t *testing.T, inFile, outFile, name, remoteFile string) {
+	t.Helper()
+	msg := "writeNamedDestLink"
+
+	ctx, err := api.ReadContextFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	pageDict, pageIndRef, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := ctx.LocateNameTree("Dests", true); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := ctx.Names["Dests"].Add(ctx.XRefTable, name, types.Array{*pageIndRef, types.Name("Fit")}, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := ctx.BindNameTrees(); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	annot := types.Dict(map[string]types.Object{
+		"Type":    types.Name("Annot"),
+		"Subtype": types.Name("Link"),
+		"Rect":    types.NewNumberArray(0, 0, 100, 100),
+		"P":       *pageIndRef,
+		"A": types.Dict(map[string]types.Object{
+			"S": types.Name("GoToR"),
+			"F": types.StringLiteral(remoteFile),
+			"D": types.StringLiteral(name),
+		}),
+	})
+	annotIndRef, err := ctx.IndRefForNewObject(annot)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	pageDict["Annots"] = types.Array{*annotIndRef}
+
+	if err := api.WriteContextFile(ctx, outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+}
+
+func TestMergeResolveGoToRNamedDests(t *testing.T) {
+	msg := "TestMergeResolveGoToRNamedDests"
+
+	// a.pdf and b.pdf both define the named destination "target" and link to each other's "target".
+	fileA := filepath.Join(outDir, "namedDestsA.pdf")
+	fileB := filepath.Join(outDir, "namedDestsB.pdf")
+	writeNamedDestLink(t, filepath.Join(inDir, "test.pdf"), fileA, "target", "namedDestsB.pdf")
+	writeNamedDestLink(t, filepath.Join(inDir, "Walden.pdf"), fileB, "target", "namedDestsA.pdf")
+
+	n, err := api.PageCountFile(fileA)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	outFile := filepath.Join(outDir, "outGoToRNamedDests.pdf")
+	if err := api.MergeCreateFile([]string{fileA, fileB}, outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := ctx.LocateNameTree("Dests", false); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// The link on the first page of each file must resolve to the first page of the other file.
+	for _, tt := range []struct {
+		pageNr, wantPageNr int
+	}{
+		{1, n + 1},
+		{n + 1, 1},
+	} {
+		d, _, _, err := ctx.PageDict(tt.pageNr, false)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		annots, err := ctx.DereferenceArray(d["Annots"])
+		if err != nil || len(annots) == 0 {
+			t.Fatalf("%s: missing link annotation on page %d: %v\n", msg, tt.pageNr, err)
+		}
+		d, err = ctx.DereferenceDict(annots[len(annots)-1])
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		a, err := ctx.DereferenceDict(d["A"])
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if s := a.NameEntry("S"); s == nil || *s != "GoTo" {
+			t.Fatalf("%s: want GoTo action on page %d, got: %s\n", msg, tt.pageNr, a)
+		}
+		name, err := types.StringOrHexLiteral(a["D"])
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if got := namedDestPageNr(t, ctx, *name); got != tt.wantPageNr {
+			t.Fatalf("%s: link on page %d to %s resolves to page %d, want %d\n", msg, tt.pageNr, *name, got, tt.wantPageNr)
+		}
+	}
+}
+
+func TestMergeDir(t *testing.T) {
+	msg := "TestMergeDir"
+	dir := filepath.Join(outDir, "mergeDir")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Create the files in reverse natural order.
+	fileNames := []string{"sub/part1.pdf", "part10.pdf", "part2.pdf", "notes.txt"}
+	modTime := time.Now().Add(-time.Hour)
+	for i, fn := range fileNames {
+		fn = filepath.Join(dir, filepath.FromSlash(fn))
+		if err := copyFile(t, filepath.Join(inDir, "Walden.pdf"), fn); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if err := os.Chtimes(fn, modTime, modTime.Add(time.Duration(-i)*time.Minute)); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		sortBy api.SortMode
+		want   []string
+	}{
+		{api.SortByName, []string{"part10.pdf", "part2.pdf", "sub/part1.pdf"}},
+		{api.SortByModTime, []string{"part2.pdf", "part10.pdf", "sub/part1.pdf"}},
+		{api.SortNatural, []string{"part2.pdf", "part10.pdf", "sub/part1.pdf"}},
+	} {
+		ff, err := api.FilesForDir(dir, "", tt.sortBy)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if len(ff) != len(tt.want) {
+			t.Fatalf("%s %d: want %v, got %v\n", msg, tt.sortBy, tt.want, ff)
+		}
+		for i, f := range ff {
+			if f != filepath.Join(dir, filepath.FromSlash(tt.want[i])) {
+				t.Fatalf("%s %d: want %v, got %v\n", msg, tt.sortBy, tt.want, ff)
+			}
+		}
+	}
+
+	// The result file is located within dir and gets skipped on subsequent runs.
+	outFile := filepath.Join(dir, "out.pdf")
+	for i := 0; i < 2; i++ {
+		if err := api.MergeDir(dir, "*.pdf", api.SortNatural, outFile, nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if err := api.ValidateFile(outFile, nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		n, err := api.PageCountFile(outFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if n != 6 {
+			t.Fatalf("%s: want 6 pages, got %d\n", msg, n)
+		}
+	}
+
+	if err := api.MergeDir(dir, "*.xyz", api.SortByName, outFile, nil); err == nil {
+		t.Fatalf("%s: missing error for empty merge\n", msg)
+	}
+}
@@ -17,11 +17,22 @@ limitations under the License.
 package test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"math"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
 func testNUp(t *testing.T, msg string, inFiles []string, outFile string, selectedPages []string, desc string, n int, isImg bool) {
@@ -102,3 +113,656 @@ func TestNUp(t *testing.T) {
 		testNUp(t, tt.msg, tt.inFiles, tt.outFile, tt.selectedPages, tt.desc, tt.n, tt.isImg)
 	}
 }
+
+func TestNUpFillOrder(t *testing.T) {
+	msg := "TestNUpFillOrder"
+
+	// Expected sequences of grid cells as (col, row) counted from the upper left corner.
+	for _, tt := range []struct {
+		desc  string
+		cells [][2]int
+	}{
+		{"fillorder:rowmajor", [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}}},
+		{"fillorder:columnmajor", [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}}},
+		{"fillorder:rtl", [][2]int{{1, 0}, {0, 0}, {1, 1}, {0, 1}}},
+		{"fillorder:bottomup", [][2]int{{0, 1}, {1, 1}, {0, 0}, {1, 0}}},
+		{"fillorder:boustrophedon", [][2]int{{0, 0}, {1, 0}, {1, 1}, {0, 1}}},
+		{"orientation:ul", [][2]int{{1, 1}, {1, 0}, {0, 1}, {0, 0}}},
+		{"orientation:dr, snake:on", [][2]int{{0, 0}, {0, 1}, {1, 1}, {1, 0}}},
+	} {
+		nup, err := api.PDFNUpConfig(4, "form:A4, "+tt.desc)
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.desc, err)
+		}
+		rr := nup.RectsForGrid()
+		if len(rr) != len(tt.cells) {
+			t.Fatalf("%s %s: want %d cells, got %d\n", msg, tt.desc, len(tt.cells), len(rr))
+		}
+		for i, r := range rr {
+			col := int(r.LL.X / r.Width())
+			row := int(nup.Grid.Height) - 1 - int(r.LL.Y/r.Height())
+			if col != tt.cells[i][0] || row != tt.cells[i][1] {
+				t.Fatalf("%s %s: cell %d: want %v, got [%d %d]\n", msg, tt.desc, i, tt.cells[i], col, row)
+			}
+		}
+	}
+
+	testNUp(t, msg,
+		[]string{filepath.Join(inDir, "WaldenFull.pdf")},
+		filepath.Join(outDir, "NUpRightToLeft.pdf"),
+		nil,
+		"fillorder:rtl",
+		4,
+		false)
+}
+
+func TestNUpCellAlignment(t *testing.T) {
+	msg := "TestNUpCellAlignment"
+
+	for _, tt := range []struct {
+		desc       string
+		rSrc       *types.Rectangle
+		sx, dx, dy float64
+	}{
+		// A landscape source gets rotated into a portrait cell.
+		{"", types.RectForDim(200, 100), 0, 100, 0},
+		{"rotation:off, alignment:tl", types.RectForDim(200, 100), .5, 0, 150},
+		{"rotation:off, alignment:c", types.RectForDim(200, 100), .5, 0, 75},
+		{"alignment:br", types.RectForDim(100, 400), .5, 50, 0},
+		{"alignment:tc", types.RectForDim(100, 400), .5, 25, 0},
+	} {
+		nup, err := api.PDFNUpConfig(2, "border:off, margin:0")
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.desc, err)
+		}
+		if tt.desc != "" {
+			if err := pdfcpu.ParseNUpDetails(tt.desc, nup); err != nil {
+				t.Fatalf("%s %s: %v\n", msg, tt.desc, err)
+			}
+		}
+
+		var buf bytes.Buffer
+		model.NUpTilePDFBytes(&buf, tt.rSrc, types.RectForDim(100, 200), "Fm0", nup, false, true)
+
+		// q a b c d e f cm /Fm0 Do Q
+		ss := strings.Fields(buf.String())
+		if len(ss) != 11 || ss[7] != "cm" {
+			t.Fatalf("%s %s: unexpected content: %s\n", msg, tt.desc, buf.String())
+		}
+		m := make([]float64, 6)
+		for i := range m {
+			if m[i], err = strconv.ParseFloat(ss[i+1], 64); err != nil {
+				t.Fatalf("%s %s: %v\n", msg, tt.desc, err)
+			}
+		}
+		if math.Abs(m[0]-tt.sx) > .001 || math.Abs(m[4]-tt.dx) > .001 || math.Abs(m[5]-tt.dy) > .001 {
+			t.Fatalf("%s %s: want sx=%.2f dx=%.2f dy=%.2f, got %v\n", msg, tt.desc, tt.sx, tt.dx, tt.dy, m)
+		}
+	}
+}
+
+func TestNUpCutAndStack(t *testing.T) {
+	msg := "TestNUpCutAndStack"
+	outFile := filepath.Join(outDir, "NUpCutAndStack.pdf")
+
+	testNUp(t, msg,
+		[]string{filepath.Join(inDir, "WaldenFull.pdf")},
+		outFile,
+		[]string{"1-10"},
+		"cutandstack:on",
+		4,
+		false)
+
+	// 10 pages on 3 sheets: after cutting the stacks of each cell position collate.
+	want := [][]string{
+		{"/Fm1 ", "/Fm4 ", "/Fm7 ", "/Fm10 "},
+		{"/Fm2 ", "/Fm5 ", "/Fm8 "},
+		{"/Fm3 ", "/Fm6 ", "/Fm9 "},
+	}
+
+	bbs := bookletPageContents(t, outFile)
+	if len(bbs) != len(want) {
+		t.Fatalf("%s: want %d sheets, got %d\n", msg, len(want), len(bbs))
+	}
+
+	for i, bb := range bbs {
+		s := string(bb)
+		if c := strings.Count(s, " Do"); c != len(want[i]) {
+			t.Fatalf("%s: sheet %d: want %d pages, got %d\n", msg, i+1, len(want[i]), c)
+		}
+		pos := -1
+		for _, fm := range want[i] {
+			j := strings.Index(s, fm)
+			if j <= pos {
+				t.Fatalf("%s: sheet %d: %s missing or out of order\n", msg, i+1, fm)
+			}
+			pos = j
+		}
+	}
+}
+
+func TestNUpStepAndRepeat(t *testing.T) {
+	msg := "TestNUpStepAndRepeat"
+	outFile := filepath.Join(outDir, "NUpStepAndRepeat.pdf")
+
+	testNUp(t, msg,
+		[]string{filepath.Join(inDir, "WaldenFull.pdf")},
+		outFile,
+		[]string{"1-2"},
+		"repeat:on, border:off, margin:10, bleed:3, cropmarks:on, regmarks:on",
+		8,
+		false)
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if ctx.PageCount != 2 {
+		t.Fatalf("%s: want 2 sheets, got %d\n", msg, ctx.PageCount)
+	}
+
+	// All copies share a single form.
+	_, _, inhPAttrs, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	xObjs, err := ctx.DereferenceDict(inhPAttrs.Resources["XObject"])
+	if err != nil || len(xObjs) != 1 {
+		t.Fatalf("%s: want 1 form, got %d\n", msg, len(xObjs))
+	}
+
+	for i, bb := range bookletPageContents(t, outFile) {
+		s := string(bb)
+		fm := "/Fm" + strconv.Itoa(i+1) + " Do"
+		if c := strings.Count(s, fm); c != 8 {
+			t.Fatalf("%s: sheet %d: want 8 copies of page %d, got %d\n", msg, i+1, i+1, c)
+		}
+		if strings.Count(s, " Do") != 8 {
+			t.Fatalf("%s: sheet %d: unexpected content\n", msg, i+1)
+		}
+		if !strings.Contains(s, "1 1 1 1 K") {
+			t.Fatalf("%s: sheet %d: missing marks\n", msg, i+1)
+		}
+	}
+
+	nup, err := api.PDFNUpConfig(4, "margin:2, bleed:3")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.NUpFile([]string{filepath.Join(inDir, "WaldenFull.pdf")}, outFile, []string{"1"}, nup, nil); err == nil {
+		t.Fatalf("%s: bleed exceeding margin should fail\n", msg)
+	}
+}
+
+func TestNUpPreservesLinks(t *testing.T) {
+	msg := "TestNUpPreservesLinks"
+	inFile := filepath.Join(outDir, "NUpLinks.pdf")
+	outFile := filepath.Join(outDir, "NUpLinksOut.pdf")
+
+	copyFile(t, filepath.Join(inDir, "WaldenFull.pdf"), inFile)
+
+	r := types.NewRectangle(0, 0, 100, 100)
+	ann := model.NewLinkAnnotation(*r, nil, nil, "https://pdfcpu.io", "ID1", 0, nil, false)
+	if err := api.AddAnnotationsFile(inFile, "", []string{"1-4"}, ann, nil, false); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	nup, err := api.PDFNUpConfig(4, "border:off")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.NUpFile([]string{inFile}, outFile, []string{"1-4"}, nup, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	d, pageIndRef, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	annots, err := ctx.DereferenceArray(d["Annots"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	i := 0
+	for _, o := range annots {
+		d1, err := ctx.DereferenceDict(o)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if nm := d1.StringEntry("NM"); nm == nil || *nm != "ID1" {
+			continue
+		}
+		if ir := d1.IndirectRefEntry("P"); ir == nil || ir.ObjectNumber != pageIndRef.ObjectNumber {
+			t.Fatalf("%s: link %d: not bound to sheet\n", msg, i+1)
+		}
+		r1, err := types.RectForArray(d1.ArrayEntry("Rect"))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		cell := nup.RectsForGrid()[i]
+		inside := r1.LL.X >= cell.LL.X && r1.LL.Y >= cell.LL.Y && r1.UR.X <= cell.UR.X && r1.UR.Y <= cell.UR.Y
+		if !inside || r1.Width() >= r.Width() {
+			t.Fatalf("%s: link %d: %s not scaled into cell %s\n", msg, i+1, r1, cell)
+		}
+		i++
+	}
+	if i != 4 {
+		t.Fatalf("%s: want 4 links, got %d\n", msg, i)
+	}
+}
+
+func TestNUpSheetMarginAndSpacing(t *testing.T) {
+	msg := "TestNUpSheetMarginAndSpacing"
+	inFile := filepath.Join(inDir, "WaldenFull.pdf")
+	outFile := filepath.Join(outDir, "NUpSheetMarginAndSpacing.pdf")
+
+	nup, err := api.PDFNUpConfig(4, "formsize:A4, border:off, margin:0, sheetmargin:20 10, spacing:4 6")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.NUpFile([]string{inFile}, outFile, []string{"1-4"}, nup, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// 2x2 grid filled right down.
+	rr := nup.RectsForGrid()
+	w, h := nup.PageDim.Width, nup.PageDim.Height
+	if rr[0].LL.X != 10 || rr[0].UR.Y != h-20 || rr[3].UR.X != w-10 || rr[3].LL.Y != 20 {
+		t.Fatalf("%s: grid ignores sheet margin: %s %s\n", msg, rr[0], rr[3])
+	}
+	if gap := rr[1].LL.X - rr[0].UR.X; math.Abs(gap-6) > .01 {
+		t.Fatalf("%s: want column gutter 6, got %.2f\n", msg, gap)
+	}
+	if gap := rr[0].LL.Y - rr[2].UR.Y; math.Abs(gap-4) > .01 {
+		t.Fatalf("%s: want row gutter 4, got %.2f\n", msg, gap)
+	}
+
+	// The grid sheet grows by sheet margins and gutters.
+	outFile = filepath.Join(outDir, "GridSheetMarginAndSpacing.pdf")
+	nup, err = api.PDFGridConfig(1, 2, "border:off, margin:0, sheetmargin:10, spacing:5")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.NUpFile([]string{inFile}, outFile, []string{"1-2"}, nup, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	ctx, err := api.ReadContextFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	dims, err := ctx.PageDims()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if ctx, err = api.ReadContextFile(outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	dims1, err := ctx.PageDims()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if want := 2*dims[0].Width + 25; math.Abs(dims1[0].Width-want) > .01 {
+		t.Fatalf("%s: want sheet width %.2f, got %.2f\n", msg, want, dims1[0].Width)
+	}
+	if want := dims[0].Height + 20; math.Abs(dims1[0].Height-want) > .01 {
+		t.Fatalf("%s: want sheet height %.2f, got %.2f\n", msg, want, dims1[0].Height)
+	}
+
+	nup, err = api.PDFNUpConfig(4, "formsize:A4, sheetmargin:300")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.NUpFile([]string{inFile}, outFile, []string{"1"}, nup, nil); err == nil {
+		t.Fatalf("%s: sheet margins exceeding the sheet should fail\n", msg)
+	}
+
+	if _, err := api.PDFNUpConfig(4, "spacing:1 2 3"); err == nil {
+		t.Fatalf("%s: spacing takes at most 2 values\n", msg)
+	}
+}
+
+func TestNUpTrimGuides(t *testing.T) {
+	msg := "TestNUpTrimGuides"
+	inFile := filepath.Join(inDir, "WaldenFull.pdf")
+	outFile := filepath.Join(outDir, "NUpTrimGuides.pdf")
+
+	testNUp(t, msg, []string{inFile}, outFile, []string{"1"}, "formsize:A4, repeat:on, border:off, margin:0, sheetmargin:20 10, spacing:4 6, trimguides:on", 4, false)
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Each of the 4 vertical and 4 horizontal trim edges is cut across the outer margins and the gutter.
+	re := regexp.MustCompile(`([\d.]+) ([\d.]+) m ([\d.]+) ([\d.]+) l`)
+	mm := re.FindAllStringSubmatch(pageContent(t, ctx, 1), -1)
+	if len(mm) != 24 {
+		t.Fatalf("%s: want 24 cut lines, got %d\n", msg, len(mm))
+	}
+	for _, m := range mm {
+		x1, _ := strconv.ParseFloat(m[1], 64)
+		y1, _ := strconv.ParseFloat(m[2], 64)
+		x2, _ := strconv.ParseFloat(m[3], 64)
+		y2, _ := strconv.ParseFloat(m[4], 64)
+		if l := math.Abs(x2-x1) + math.Abs(y2-y1); l > 20.01 {
+			t.Fatalf("%s: cut line crossing a cell: %s\n", msg, m[0])
+		}
+	}
+}
+
+// exifJPEG returns a w x h JPEG carrying an Exif orientation tag and an sRGB ICC profile stub.
+func exifJPEG(t *testing.T, w, h, orientation int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	bb := buf.Bytes()
+
+	segment := func(marker byte, data []byte) []byte {
+		seg := []byte{0xFF, marker, 0, 0}
+		binary.BigEndian.PutUint16(seg[2:], uint16(len(data)+2))
+		return append(seg, data...)
+	}
+
+	// APP1: TIFF header (big endian) and IFD0 with a single orientation entry.
+	exif := []byte("Exif\x00\x00MM\x00\x2A\x00\x00\x00\x08\x00\x01")
+	exif = append(exif, 0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, byte(orientation), 0x00, 0x00)
+	exif = append(exif, 0, 0, 0, 0)
+
+	// APP2: a single chunk ICC profile with a minimal header.
+	icc := make([]byte, 132)
+	copy(icc[16:], "RGB ")
+	copy(icc[36:], "acsp")
+	app2 := append([]byte("ICC_PROFILE\x00\x01\x01"), icc...)
+
+	var out []byte
+	out = append(out, bb[:2]...)
+	out = append(out, segment(0xE1, exif)...)
+	out = append(out, segment(0xE2, app2)...)
+	return append(out, bb[2:]...)
+}
+
+func TestNUpImageOrientationAndICC(t *testing.T) {
+	msg := "TestNUpImageOrientationAndICC"
+
+	bb := exifJPEG(t, 40, 20, 6)
+
+	o, err := model.JPEGOrientation(bytes.NewReader(bb))
+	if err != nil || o != 6 {
+		t.Fatalf("%s: want orientation 6, got %d %v\n", msg, o, err)
+	}
+
+	imgFile := filepath.Join(outDir, "exif6.jpg")
+	if err := os.WriteFile(imgFile, bb, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	outFile := filepath.Join(outDir, "NUpImageOrientation.pdf")
+	testNUp(t, msg, []string{imgFile, imgFile}, outFile, nil, "form:A4, border:off", 2, true)
+
+	bb, err = os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	sds := imageStreamDicts(t, bb)
+	if len(sds) == 0 {
+		t.Fatalf("%s: missing image\n", msg)
+	}
+	for _, sd := range sds {
+		arr, ok := sd.Find("ColorSpace")
+		if a, isArr := arr.(types.Array); !ok || !isArr || a[0].String() != "ICCBased" {
+			t.Fatalf("%s: want ICCBased color space, got %v\n", msg, arr)
+		}
+	}
+
+	ctx, err := api.ReadContext(bytes.NewReader(bb), model.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	var found bool
+	for _, entry := range ctx.Table {
+		if entry == nil {
+			continue
+		}
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok || sd.Subtype() == nil || *sd.Subtype() != "Form" {
+			continue
+		}
+		if err := sd.Decode(); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if strings.Contains(string(sd.Content), "0 -1 1 0 0 1 cm") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("%s: image not rendered upright\n", msg)
+	}
+}
+
+// tileScales returns the scale factors of the n-up tiles rendered onto page 1 of the PDF file fileName.
+func tileScales(t *testing.T, fileName string) []float64 {
+	t.Helper()
+
+	ctx, err := api.ReadContextFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb, err := ctx.PageContent(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ff []float64
+	re := regexp.MustCompile(`q (\S+) (\S+) \S+ \S+ \S+ \S+ cm /Fm\d+ Do Q`)
+	for _, m := range re.FindAllStringSubmatch(string(bb), -1) {
+		a, _ := strconv.ParseFloat(m[1], 64)
+		b, _ := strconv.ParseFloat(m[2], 64)
+		ff = append(ff, math.Hypot(a, b))
+	}
+	return ff
+}
+
+func TestNUpMixed(t *testing.T) {
+	msg := "TestNUpMixed"
+
+	// A 600 dpi scan of 2x1 inches.
+	imgFile := filepath.Join(outDir, "scan600.png")
+	if err := os.WriteFile(imgFile, pngBytes(t, image.NewGray(image.Rect(0, 0, 1200, 600))), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	pdfFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "NUpMixed.pdf")
+
+	for _, tt := range []struct {
+		scale float64
+		ratio float64
+	}{
+		{0, 1},
+		{2, 2},
+	} {
+		nup, err := api.PDFNUpConfig(2, "form:A4, border:off")
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		inputs := []model.NUpInput{
+			{FileName: pdfFile, Pages: []string{"1"}},
+			{FileName: imgFile, DPI: 600, Scale: tt.scale},
+		}
+		if err := api.NUpMixedFile(inputs, outFile, nup, nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if err := api.ValidateFile(outFile, nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		ff := tileScales(t, outFile)
+		if len(ff) != 2 {
+			t.Fatalf("%s: want 2 tiles, got %d\n", msg, len(ff))
+		}
+		if math.Abs(ff[1]/ff[0]-tt.ratio) > 0.01 {
+			t.Fatalf("%s: want scale ratio %.2f, got %.4f\n", msg, tt.ratio, ff[1]/ff[0])
+		}
+	}
+
+	// NUpFile detects mixed inputs.
+	nup, err := api.PDFNUpConfig(4, "")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.NUpFile([]string{pdfFile, imgFile}, outFile, nil, nup, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n, err := api.PageCountFile(outFile); err != nil || n != 1 {
+		t.Fatalf("%s: want 1 page, got %d %v\n", msg, n, err)
+	}
+}
+
+func TestNUpPageSelectionPerInput(t *testing.T) {
+	msg := "TestNUpPageSelectionPerInput"
+	inFile1 := filepath.Join(inDir, "Walden.pdf")
+	inFile2 := filepath.Join(inDir, "WaldenFull.pdf")
+	outFile := filepath.Join(outDir, "NUpPerInput.pdf")
+
+	for _, tt := range []struct {
+		s        string
+		fileName string
+		pages    []string
+	}{
+		{"in.pdf", "in.pdf", nil},
+		{"in.pdf:1-3,5", "in.pdf", []string{"1-3", "5"}},
+		{`C:\in.pdf`, `C:\in.pdf`, nil},
+		{`C:\in.pdf:odd`, `C:\in.pdf`, []string{"odd"}},
+		{"in.png", "in.png", nil},
+	} {
+		in, err := api.ParseNUpInput(tt.s)
+		if err != nil {
+			t.Fatalf("%s: %s: %v\n", msg, tt.s, err)
+		}
+		if in.FileName != tt.fileName || strings.Join(in.Pages, ",") != strings.Join(tt.pages, ",") {
+			t.Fatalf("%s: %s: got %s %v\n", msg, tt.s, in.FileName, in.Pages)
+		}
+	}
+	if _, err := api.ParseNUpInput("in.pdf:a-b"); err == nil {
+		t.Fatalf("%s: expected error for invalid page selection\n", msg)
+	}
+
+	nup, err := api.PDFNUpConfig(2, "")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Page 2 of inFile1 and pages 1-3 of inFile2 make 4 tiles.
+	inFiles := []string{inFile1 + ":2", inFile2 + ":1-3"}
+	if err := api.NUpFile(inFiles, outFile, nil, nup, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n, err := api.PageCountFile(outFile); err != nil || n != 2 {
+		t.Fatalf("%s: want 2 pages, got %d %v\n", msg, n, err)
+	}
+
+	// Inputs without own page selection fall back to the global one.
+	inFiles = []string{inFile1, inFile2 + ":1-5"}
+	if err := api.NUpFile(inFiles, outFile, []string{"1"}, nup, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n, err := api.PageCountFile(outFile); err != nil || n != 3 {
+		t.Fatalf("%s: want 3 pages, got %d %v\n", msg, n, err)
+	}
+
+	// A single input with page selection.
+	if err := api.NUpFile([]string{inFile2 + ":1-4"}, outFile, nil, nup, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if n, err := api.PageCountFile(outFile); err != nil || n != 2 {
+		t.Fatalf("%s: want 2 pages, got %d %v\n", msg, n, err)
+	}
+}
+
+func TestNUpAutoOrient(t *testing.T) {
+	msg := "TestNUpAutoOrient"
+	inFile := filepath.Join(outDir, "AutoOrientIn.pdf")
+	outFile := filepath.Join(outDir, "NUpAutoOrient.pdf")
+
+	// 4 portrait pages followed by 4 landscape pages.
+	if err := api.TrimFile(filepath.Join(inDir, "WaldenFull.pdf"), inFile, []string{"1-8"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.RotateFile(inFile, "", 90, []string{"5-8"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	sheets := func(want ...bool) {
+		t.Helper()
+		dims, err := api.PageDimsFile(outFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if len(dims) != len(want) {
+			t.Fatalf("%s: want %d sheets, got %d\n", msg, len(want), len(dims))
+		}
+		for i, d := range dims {
+			if d.Landscape() != want[i] {
+				t.Fatalf("%s: sheet %d: want landscape=%t, got %s\n", msg, i+1, want[i], d)
+			}
+		}
+	}
+
+	// Without autoorient all sheets share the A4 portrait layout.
+	testNUp(t, msg, []string{inFile}, outFile, nil, "form:A4", 2, false)
+	sheets(false, false, false, false)
+
+	// Portrait pages go 2 up onto landscape sheets and vice versa.
+	testNUp(t, msg, []string{inFile}, outFile, nil, "form:A4, autoorient:on", 2, false)
+	sheets(true, true, false, false)
+
+	// A common scale requires a common orientation.
+	testNUp(t, msg, []string{inFile}, outFile, []string{"1-6"}, "form:A4, autoorient:on, uniform:on", 2, false)
+	sheets(true, true, true)
+
+	// Booklet sheets get oriented per signature.
+	nup, err := api.PDFBookletConfig(2, "form:A4, signature:4, autoorient:on")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.BookletFile([]string{inFile}, outFile, nil, nup, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	sheets(true, true, false, false)
+
+	// Signatures mixing portrait and landscape pages: 3 portrait and 1 landscape pages
+	// followed by a tie keeping the configured portrait sheet.
+	if err := api.TrimFile(filepath.Join(inDir, "WaldenFull.pdf"), inFile, []string{"1-8"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.RotateFile(inFile, "", 90, []string{"4-6"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if nup, err = api.PDFBookletConfig(2, "form:A4, signature:4, autoorient:on"); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.BookletFile([]string{inFile}, outFile, nil, nup, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	sheets(true, true, false, false)
+
+	if _, err := api.PDFGridConfig(2, 2, "autoorient:on"); err == nil {
+		t.Fatalf("%s: want error for grid\n", msg)
+	}
+}
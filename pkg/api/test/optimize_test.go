@@ -17,10 +17,16 @@ limitations under the License.
 package test
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
 func TestOptimize(t *testing.T) {
@@ -41,3 +47,270 @@ func TestOptimize(t *testing.T) {
 		t.Fatalf("%s: %v\n", msg, err)
 	}
 }
+
+func readHybridTestContext(t *testing.T, msg, fileName string, reader15 bool) *model.Context {
+	t.Helper()
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.Reader15 = reader15
+
+	ctx, err := api.ReadContext(f, conf)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	return ctx
+}
+
+func TestOptimizeHybridXref(t *testing.T) {
+	msg := "TestOptimizeHybridXref"
+	fileName := "go.pdf"
+	inFile := filepath.Join(inDir, fileName)
+	outFile := filepath.Join(outDir, "goHybrid.pdf")
+
+	conf := model.NewDefaultConfiguration()
+	conf.WriteHybridXref = true
+
+	if err := api.OptimizeFile(inFile, outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx := readHybridTestContext(t, msg, outFile, true)
+	if !ctx.Read.Hybrid || !ctx.Read.UsingObjectStreams {
+		t.Fatalf("%s: want hybrid file using object streams\n", msg)
+	}
+
+	// Pre V1.5 readers ignore the cross reference stream but still get to see all pages.
+	ctx = readHybridTestContext(t, msg, outFile, false)
+	if ctx.PageCount != 23 {
+		t.Fatalf("%s: want 23 pages, got %d\n", msg, ctx.PageCount)
+	}
+}
+
+func TestOptimizeSimplifyPaths(t *testing.T) {
+	msg := "TestOptimizeSimplifyPaths"
+	inFile := filepath.Join(inDir, "grid_example.pdf")
+	outFile := filepath.Join(outDir, "grid_example_simplified.pdf")
+
+	conf := model.NewDefaultConfiguration()
+	conf.SimplifyPaths = true
+
+	if err := api.OptimizeFile(inFile, outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	fi1, err := os.Stat(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	fi2, err := os.Stat(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if fi2.Size() >= fi1.Size() {
+		t.Fatalf("%s: want smaller file, got %d >= %d bytes\n", msg, fi2.Size(), fi1.Size())
+	}
+}
+
+func formXObjectCount(t *testing.T, fileName string) int {
+	t.Helper()
+	ctx, err := api.ReadContextFile(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", fileName, err)
+	}
+	c := 0
+	for _, entry := range ctx.Table {
+		if sd, ok := entry.Object.(types.StreamDict); ok && sd.Subtype() != nil && *sd.Subtype() == "Form" {
+			c++
+		}
+	}
+	return c
+}
+
+func TestOptimizeInlineFormXObjects(t *testing.T) {
+	msg := "TestOptimizeInlineFormXObjects"
+	inFile := filepath.Join(inDir, "CenterOfWhy.pdf")
+	outFile := filepath.Join(outDir, "CenterOfWhy_inlined.pdf")
+
+	conf := model.NewDefaultConfiguration()
+	conf.InlineFormXObjects = true
+
+	if err := api.OptimizeFile(inFile, outFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	c1, c2 := formXObjectCount(t, inFile), formXObjectCount(t, outFile)
+	if c2 >= c1 {
+		t.Fatalf("%s: want fewer form XObjects, got %d >= %d\n", msg, c2, c1)
+	}
+}
+
+func checkSizeReport(t *testing.T, msg string, r *pdfcpu.SizeReport, n int) {
+	t.Helper()
+
+	if r.FileSize <= 0 {
+		t.Fatalf("%s: want file size > 0, got %d\n", msg, r.FileSize)
+	}
+
+	var sum int64
+	for _, cat := range pdfcpu.SizeCategories {
+		sum += r.Categories[cat]
+	}
+	if sum < r.FileSize {
+		t.Fatalf("%s: categories account for %d of %d bytes\n", msg, sum, r.FileSize)
+	}
+
+	if len(r.Largest) == 0 || len(r.Largest) > n {
+		t.Fatalf("%s: want 1..%d largest objects, got %d\n", msg, n, len(r.Largest))
+	}
+	for i := 1; i < len(r.Largest); i++ {
+		if r.Largest[i].Size > r.Largest[i-1].Size {
+			t.Fatalf("%s: largest objects not sorted by size\n", msg)
+		}
+	}
+}
+
+func TestOptimizeReport(t *testing.T) {
+	msg := "TestOptimizeReport"
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+	outFile := filepath.Join(outDir, "Acroforms2_report.pdf")
+
+	r, err := api.OptimizeFileWithReport(inFile, outFile, 5, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	checkSizeReport(t, msg+" before", r.Before, 5)
+	checkSizeReport(t, msg+" after", r.After, 5)
+
+	fi, err := os.Stat(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if fi.Size() != r.After.FileSize {
+		t.Fatalf("%s: want after size %d, got %d\n", msg, fi.Size(), r.After.FileSize)
+	}
+
+	if s := r.String(); !strings.Contains(s, pdfcpu.SizeImages) || !strings.Contains(s, "largest objects after") {
+		t.Fatalf("%s: incomplete report:\n%s\n", msg, s)
+	}
+}
+
+func checkLinearized(t *testing.T, msg, fileName string, conf *model.Configuration) *model.Context {
+	t.Helper()
+
+	if err := api.ValidateFile(fileName, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	bb, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	ctx, err := api.ReadContext(f, conf)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if !ctx.Read.Linearized || len(ctx.LinearizationObjs) != 1 {
+		t.Fatalf("%s: want linearized file\n", msg)
+	}
+
+	var d types.Dict
+	for objNr := range ctx.LinearizationObjs {
+		if d, err = ctx.DereferenceDict(*types.NewIndirectRef(objNr, 0)); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	if l := d.IntEntry("L"); l == nil || *l != len(bb) {
+		t.Fatalf("%s: want L=%d, got %v\n", msg, len(bb), l)
+	}
+
+	if n := d.IntEntry("N"); n == nil || *n != ctx.PageCount {
+		t.Fatalf("%s: want N=%d, got %v\n", msg, ctx.PageCount, n)
+	}
+
+	_, ir, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if o := d.IntEntry("O"); o == nil || *o != ir.ObjectNumber.Value() {
+		t.Fatalf("%s: want O=%d, got %v\n", msg, ir.ObjectNumber.Value(), o)
+	}
+
+	// T points to the white-space character preceding the first entry of the main xref table.
+	if tt := d.IntEntry("T"); tt == nil || !strings.HasPrefix(string(bb[*tt+1:]), "0000000000 65535 f") {
+		t.Fatalf("%s: corrupt main xref table offset T=%v\n", msg, tt)
+	}
+
+	// The primary hint stream is located right in front of the first page section.
+	a := d.ArrayEntry("H")
+	if len(a) != 2 {
+		t.Fatalf("%s: corrupt primary hint stream location H=%v\n", msg, a)
+	}
+	off, length := a[0].(types.Integer).Value(), a[1].(types.Integer).Value()
+	if !strings.HasPrefix(string(bb[off+length:]), fmt.Sprintf("%d 0 obj", ir.ObjectNumber.Value())) {
+		t.Fatalf("%s: corrupt primary hint stream location H=%v\n", msg, a)
+	}
+
+	return ctx
+}
+
+func TestOptimizeLinearized(t *testing.T) {
+	msg := "TestOptimizeLinearized"
+
+	for _, fileName := range []string{"go.pdf", "Acroforms2.pdf", "CenterOfWhy.pdf"} {
+		inFile := filepath.Join(inDir, fileName)
+		outFile := filepath.Join(outDir, "linearized_"+fileName)
+
+		conf := model.NewDefaultConfiguration()
+		conf.WriteLinearized = true
+
+		if err := api.OptimizeFile(inFile, outFile, conf); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		ctx := checkLinearized(t, msg, outFile, nil)
+
+		n, err := api.PageCountFile(inFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if ctx.PageCount != n {
+			t.Fatalf("%s %s: want %d pages, got %d\n", msg, fileName, n, ctx.PageCount)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+)
+
+func TestPageStats(t *testing.T) {
+	msg := "TestPageStats"
+	inFile := filepath.Join(inDir, "TheGoProgrammingLanguageCh1.pdf")
+
+	pss, err := api.PageStatsFile(inFile, []string{"1-3"}, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(pss) != 3 {
+		t.Fatalf("%s: want 3 pages, got %d\n", msg, len(pss))
+	}
+
+	// Page 1 paints two clipped images.
+	if ps := pss[0]; ps.Images != 2 || ps.ImagePixels == 0 || ps.ImageArea == 0 || ps.PathSegments != 8 {
+		t.Fatalf("%s: unexpected stats for page 1: %+v\n", msg, ps)
+	}
+
+	// Page 2 shows text using 3 TJ operators.
+	if ps := pss[1]; ps.TextRuns != 3 || ps.OperatorCounts["TJ"] != 3 || ps.Images != 0 {
+		t.Fatalf("%s: unexpected stats for page 2: %+v\n", msg, ps)
+	}
+
+	var buf bytes.Buffer
+	if err := api.PageStatsJSONFile(inFile, &buf, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	var r pdfcpu.PageStatsReport
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(r.Pages) != 59 || r.Header.Source != "TheGoProgrammingLanguageCh1.pdf" {
+		t.Fatalf("%s: unexpected JSON report: %s\n", msg, r.Header.Source)
+	}
+}
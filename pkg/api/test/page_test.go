@@ -21,6 +21,8 @@ import (
 	"testing"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
 func TestInsertRemovePages(t *testing.T) {
@@ -63,3 +65,236 @@ func TestInsertRemovePages(t *testing.T) {
 		t.Fatalf("%s %s: pageCount want:%d got:%d\n", msg, inFile, n1, n2)
 	}
 }
+
+func TestInsertBlankPages(t *testing.T) {
+	msg := "TestInsertBlankPages"
+	outFile := filepath.Join(outDir, "blankPages.pdf")
+
+	// Blank pages take over the rotated page geometry of their adjacent page.
+	inFile := filepath.Join(inDir, "testRot.pdf")
+	if err := api.InsertBlankPagesFile(inFile, outFile, nil, &model.BlankPages{}, nil); err != nil {
+		t.Fatalf("%s %s: %v\n", msg, outFile, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	dims, err := api.PageDimsFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(dims) != 2 || dims[0] != dims[1] || !dims[1].Landscape() {
+		t.Fatalf("%s: want 2 identical landscape pages, got %v\n", msg, dims)
+	}
+
+	// Same for InsertPages.
+	if err := api.InsertPagesFile(inFile, outFile, nil, false, nil); err != nil {
+		t.Fatalf("%s %s: %v\n", msg, outFile, err)
+	}
+	if dims, err = api.PageDimsFile(outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(dims) != 2 || dims[0] != dims[1] || !dims[1].Landscape() {
+		t.Fatalf("%s: InsertPages: want 2 identical landscape pages, got %v\n", msg, dims)
+	}
+
+	// Blank A5 pages.
+	if err := api.InsertBlankPagesFile(inFile, outFile, nil, &model.BlankPages{Before: true, PageSize: "A5"}, nil); err != nil {
+		t.Fatalf("%s %s: %v\n", msg, outFile, err)
+	}
+	if dims, err = api.PageDimsFile(outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if a5 := types.PaperSize["A5"]; len(dims) != 2 || *a5 != dims[0] {
+		t.Fatalf("%s: want A5 as first page, got %v\n", msg, dims)
+	}
+
+	// Let pages 2 and 3 start on recto pages: 1 b 2 b 3 4 5 ...
+	inFile = filepath.Join(inDir, "pike-stanford.pdf")
+	n1, err := api.PageCountFile(inFile)
+	if err != nil {
+		t.Fatalf("%s %s: %v\n", msg, inFile, err)
+	}
+	if err := api.InsertBlankPagesFile(inFile, outFile, []string{"2", "3", "5"}, &model.BlankPages{Recto: true}, nil); err != nil {
+		t.Fatalf("%s %s: %v\n", msg, outFile, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	n2, err := api.PageCountFile(outFile)
+	if err != nil {
+		t.Fatalf("%s %s: %v\n", msg, outFile, err)
+	}
+	if n2 != n1+2 {
+		t.Fatalf("%s %s: pageCount want:%d got:%d\n", msg, outFile, n1+2, n2)
+	}
+}
+
+func TestInsertPagesFrom(t *testing.T) {
+	msg := "TestInsertPagesFrom"
+	srcFile := filepath.Join(inDir, "Walden.pdf")
+	targetFile := filepath.Join(outDir, "insertPagesFrom.pdf")
+	if err := copyFile(t, filepath.Join(inDir, "Acroforms2.pdf"), targetFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	n, err := api.PageCountFile(targetFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	isWalden := func(dim types.Dim) bool {
+		return int(dim.Width) == 595 && int(dim.Height) == 841
+	}
+
+	checkDims := func(want []bool) {
+		t.Helper()
+		if err := api.ValidateFile(targetFile, nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		dims, err := api.PageDimsFile(targetFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if len(dims) != len(want) {
+			t.Fatalf("%s: pageCount want:%d got:%d\n", msg, len(want), len(dims))
+		}
+		for i, dim := range dims {
+			if isWalden(dim) != want[i] {
+				t.Fatalf("%s: unexpected page %d: %s\n", msg, i+1, dim)
+			}
+		}
+	}
+
+	// Insert Walden page 2 following page 1.
+	if err := api.InsertPagesFromFile(targetFile, srcFile, 1, []string{"2"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	want := make([]bool, n+1)
+	want[1] = true
+	checkDims(want)
+
+	// Insert all Walden pages in front of the first page.
+	if err := api.InsertPagesFromFile(targetFile, srcFile, 0, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	checkDims(append([]bool{true, true}, want...))
+
+	if err := api.InsertPagesFromFile(targetFile, srcFile, n+4, nil, nil); err == nil {
+		t.Fatalf("%s: missing error for invalid page number\n", msg)
+	}
+}
+
+func TestPageTemplates(t *testing.T) {
+	msg := "TestPageTemplates"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "WaldenTemplates.pdf")
+
+	if err := api.AddPageTemplateFile(inFile, outFile, "cover", 1, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.AddPageTemplateFile(outFile, "", "cover", 2, nil); err == nil {
+		t.Fatalf("%s: want error for duplicate page template\n", msg)
+	}
+	if err := api.AddPageTemplateFile(outFile, "", "back", 2, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	names, err := api.PageTemplatesFile(outFile, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(names) != 2 || names[0] != "back" || names[1] != "cover" {
+		t.Fatalf("%s: want [back cover], got %v\n", msg, names)
+	}
+
+	// Append two instances of cover and insert back in front of page 1.
+	if err := api.SpawnPageTemplateFile(outFile, "", "cover", -1, 2, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.SpawnPageTemplateFile(outFile, "", "back", 0, 1, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.SpawnPageTemplateFile(outFile, "", "index", 0, 1, nil); err == nil {
+		t.Fatalf("%s: want error for unknown page template\n", msg)
+	}
+
+	n, err := api.PageCountFile(outFile)
+	if err != nil || n != 5 {
+		t.Fatalf("%s: want 5 pages, got %d %v\n", msg, n, err)
+	}
+
+	if err := api.RemovePageTemplatesFile(outFile, "", []string{"back"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if names, err = api.PageTemplatesFile(outFile, nil); err != nil || len(names) != 1 || names[0] != "cover" {
+		t.Fatalf("%s: want [cover], got %v %v\n", msg, names, err)
+	}
+	if err := api.RemovePageTemplatesFile(outFile, "", nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if names, err = api.PageTemplatesFile(outFile, nil); err != nil || len(names) != 0 {
+		t.Fatalf("%s: want no page templates, got %v %v\n", msg, names, err)
+	}
+}
+
+func TestPageAttrInheritance(t *testing.T) {
+	msg := "TestPageAttrInheritance"
+	inFile := filepath.Join(inDir, "WaldenFull.pdf")
+	outFile := filepath.Join(outDir, "WaldenInheritance.pdf")
+
+	dims, err := api.PageDimsFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	rootEntry := func(k string) bool {
+		t.Helper()
+		ctx, err := api.ReadContextFile(outFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		ir, err := ctx.Pages()
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		d, err := ctx.DereferenceDict(*ir)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		_, found := d.Find(k)
+		return found
+	}
+
+	checkDims := func() {
+		t.Helper()
+		dims1, err := api.PageDimsFile(outFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if len(dims1) != len(dims) {
+			t.Fatalf("%s: want %d pages, got %d\n", msg, len(dims), len(dims1))
+		}
+		for i := range dims {
+			if dims[i] != dims1[i] {
+				t.Fatalf("%s: page %d: want %v, got %v\n", msg, i+1, dims[i], dims1[i])
+			}
+		}
+	}
+
+	if err := api.PushDownPageAttrsFile(inFile, outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if rootEntry("MediaBox") || rootEntry("Resources") {
+		t.Fatalf("%s: want no inheritable attributes in page tree root\n", msg)
+	}
+	checkDims()
+
+	// All pages share the same media box.
+	if err := api.PullUpPageAttrsFile(outFile, "", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if !rootEntry("MediaBox") {
+		t.Fatalf("%s: want media box in page tree root\n", msg)
+	}
+	checkDims()
+}
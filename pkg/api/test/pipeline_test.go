@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+func TestPipeline(t *testing.T) {
+	msg := "TestPipeline"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "WaldenPipeline.pdf")
+
+	wm, err := api.TextWatermark("Draft", "scale:.8, rot:0", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	conf := model.NewAESConfiguration("upw", "opw", 256)
+
+	if err := api.PipelineFile(inFile, outFile, conf,
+		api.RotateOp(90, []string{"1"}),
+		api.WatermarkOp(nil, wm),
+		api.OptimizeOp(),
+		api.EncryptOp("upw", "opw"),
+	); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Reading the result requires a password.
+	if err := api.ValidateFile(outFile, model.NewDefaultConfiguration()); err == nil {
+		t.Fatalf("%s: expected error validating encrypted file without password\n", msg)
+	}
+
+	f, err := os.Open(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	ctx, err := api.ReadContext(f, model.NewAESConfiguration("upw", "opw", 256))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateContext(ctx); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ok, err := api.HasWatermarksFile(outFile, model.NewAESConfiguration("upw", "opw", 256))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if !ok {
+		t.Fatalf("%s: missing watermarks\n", msg)
+	}
+
+	// Stamping internalizes the page rotation of page 1.
+	dims, err := ctx.PageDims()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if !dims[0].Landscape() || !dims[1].Portrait() {
+		t.Fatalf("%s: unexpected page dimensions: %v\n", msg, dims)
+	}
+}
+
+func TestPipelineEncryptFirst(t *testing.T) {
+	msg := "TestPipelineEncryptFirst"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "WaldenPipelineEncryptFirst.pdf")
+
+	wm, err := api.TextWatermark("Draft", "scale:.8, rot:0", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	conf := model.NewDefaultConfiguration()
+	optimizeDuplicateContentStreams := conf.OptimizeDuplicateContentStreams
+
+	// Operations following EncryptOp must not drop the encryption.
+	if err := api.PipelineFile(inFile, outFile, conf,
+		api.EncryptOp("upw", "opw"),
+		api.RotateOp(90, []string{"1"}),
+		api.WatermarkOp(nil, wm),
+	); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// The caller's configuration remains untouched.
+	if conf.UserPW != "" || conf.OwnerPW != "" || conf.Cmd == model.ENCRYPT ||
+		conf.OptimizeDuplicateContentStreams != optimizeDuplicateContentStreams {
+		t.Fatalf("%s: configuration modified\n", msg)
+	}
+
+	if err := api.ValidateFile(outFile, model.NewDefaultConfiguration()); err == nil {
+		t.Fatalf("%s: expected error validating encrypted file without password\n", msg)
+	}
+
+	if err := api.ValidateFile(outFile, model.NewAESConfiguration("upw", "", 256)); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+}
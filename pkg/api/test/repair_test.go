@@ -0,0 +1,175 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+)
+
+func hasFix(rep *pdfcpu.RepairReport, typ string) bool {
+	for _, fix := range rep.Fixes {
+		if fix.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRepair(t *testing.T) {
+	msg := "TestRepair"
+
+	bb, err := os.ReadFile(filepath.Join(inDir, "test.pdf"))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Produce a version using a classic xref table.
+	c := *conf
+	c.WriteObjectStream = false
+	c.WriteXRefStream = false
+	buf := &bytes.Buffer{}
+	if err := api.Optimize(bytes.NewReader(bb), buf, &c); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	bbClassic := buf.Bytes()
+
+	// Corrupt the xref table offset of obj#1.
+	corruptOffset := func(bb []byte) []byte {
+		bb = append([]byte{}, bb...)
+		i := bytes.LastIndex(bb, []byte("\nxref"))
+		i += bytes.Index(bb[i:], []byte(" f")) + 3
+		for bb[i] == '\r' || bb[i] == '\n' || bb[i] == ' ' {
+			i++
+		}
+		copy(bb[i:], "0000000001")
+		return bb
+	}
+
+	shift := func(bb []byte) []byte {
+		i := bytes.Index(bb, []byte("1 0 obj"))
+		return append(append(append([]byte{}, bb[:i]...), []byte("%garbage\n%garbage\n")...), bb[i:]...)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		bb      []byte
+		fixType string
+	}{
+		// Wrong xref table offset.
+		{"offset", corruptOffset(bbClassic), pdfcpu.FixOffset},
+		// All xref stream offsets are off, object streams need to be registered.
+		{"shiftedXRefStream", shift(bb), pdfcpu.FixCompressedObject},
+		// Missing xref stream and trailer.
+		{"truncated", bb[:bytes.Index(bb, []byte("7 0 obj"))], pdfcpu.FixXRef},
+	} {
+		inFile := filepath.Join(outDir, "repair_"+tt.name+".pdf")
+		if err := os.WriteFile(inFile, tt.bb, 0644); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.name, err)
+		}
+
+		outFile := filepath.Join(outDir, "repaired_"+tt.name+".pdf")
+		rep, err := api.RepairFile(inFile, outFile, nil)
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.name, err)
+		}
+
+		if !hasFix(rep, tt.fixType) {
+			t.Fatalf("%s %s: missing fix %s: %v\n", msg, tt.name, tt.fixType, rep.Fixes)
+		}
+
+		if err := api.ValidateFile(outFile, conf); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.name, err)
+		}
+	}
+}
+
+func TestObjectProvenance(t *testing.T) {
+	msg := "TestObjectProvenance"
+
+	bb, err := os.ReadFile(filepath.Join(inDir, "test.pdf"))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	checkOffsets := func(bb []byte, ops []model.ObjectProvenance, sources ...string) {
+		t.Helper()
+		found := map[string]bool{}
+		for _, op := range ops {
+			found[op.Source] = true
+			if op.Offset == nil {
+				continue
+			}
+			if !bytes.HasPrefix(bb[*op.Offset:], []byte(fmt.Sprintf("%d %d obj", op.ObjNr, op.Generation))) {
+				t.Fatalf("%s: obj#%d not located at offset %d\n", msg, op.ObjNr, *op.Offset)
+			}
+		}
+		for _, src := range sources {
+			if !found[src] {
+				t.Fatalf("%s: missing objects of source %s: %v\n", msg, src, found)
+			}
+		}
+	}
+
+	// Xref stream and object streams.
+	ops, err := api.ObjectProvenances(bytes.NewReader(bb), nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	checkOffsets(bb, ops, "xrefStream", "objectStream")
+	for _, op := range ops {
+		if op.Source == "objectStream" && (op.ObjectStream == nil || op.ObjectStreamInd == nil) {
+			t.Fatalf("%s: obj#%d: missing object stream location\n", msg, op.ObjNr)
+		}
+	}
+
+	// Classic xref table.
+	c := *conf
+	c.WriteObjectStream = false
+	c.WriteXRefStream = false
+	buf := &bytes.Buffer{}
+	if err := api.Optimize(bytes.NewReader(bb), buf, &c); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	bb = buf.Bytes()
+	if ops, err = api.ObjectProvenances(bytes.NewReader(bb), nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	checkOffsets(bb, ops, "xrefTable")
+
+	// Relocated object.
+	i := bytes.Index(bb, []byte("1 0 obj"))
+	bb = append(append(append([]byte{}, bb[:i]...), []byte("%garbage\n")...), bb[i:]...)
+	ctx, _, err := api.RepairContext(bytes.NewReader(bb), nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	op, err := ctx.ObjectProvenance(1)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if op.Source != "repairScan" || *op.Offset != int64(i+len("%garbage\n")) {
+		t.Fatalf("%s: obj#1: want repairScan at %d, got %s at %d\n", msg, i+len("%garbage\n"), op.Source, *op.Offset)
+	}
+}
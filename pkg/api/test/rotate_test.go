@@ -17,10 +17,14 @@ limitations under the License.
 package test
 
 import (
+	"fmt"
+	"math"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
 func TestRotate(t *testing.T) {
@@ -41,3 +45,169 @@ func TestRotate(t *testing.T) {
 		t.Fatalf("%s: %v\n", msg, err)
 	}
 }
+
+func TestNormalizeRotation(t *testing.T) {
+	msg := "TestNormalizeRotation"
+	fileName := "Acroforms2.pdf"
+	inFile := filepath.Join(inDir, fileName)
+	rotFile := filepath.Join(outDir, "rotated.pdf")
+	outFile := filepath.Join(outDir, "normalized.pdf")
+
+	if err := api.RotateFile(inFile, rotFile, 90, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.RotateFile(rotFile, "", 180, []string{"1"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.NormalizeRotationFile(rotFile, outFile, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// The visual page dimensions are retained.
+	dims1, err := api.PageDimsFile(rotFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	dims2, err := api.PageDimsFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for i := range dims1 {
+		if math.Abs(dims1[i].Width-dims2[i].Width) > .01 || math.Abs(dims1[i].Height-dims2[i].Height) > .01 {
+			t.Fatalf("%s: page %d: want %v, got %v\n", msg, i+1, dims1[i], dims2[i])
+		}
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for i := 1; i <= ctx.PageCount; i++ {
+		_, _, inhPAttrs, err := ctx.PageDict(i, false)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if inhPAttrs.Rotate != 0 {
+			t.Fatalf("%s: page %d: want Rotate 0, got %d\n", msg, i, inhPAttrs.Rotate)
+		}
+	}
+}
+
+func TestNormalizeRotationDests(t *testing.T) {
+	msg := "TestNormalizeRotationDests"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	rotFile := filepath.Join(outDir, "rotatedDests.pdf")
+	outFile := filepath.Join(outDir, "normalizedDests.pdf")
+
+	// Rotate page 1 and add outline items pointing at it.
+	ctx, err := api.ReadContextFile(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	d, pageIndRef, inhPAttrs, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	d.Update("Rotate", types.Integer(90))
+	mb := inhPAttrs.MediaBox
+
+	outlinesIndRef, err := ctx.IndRefForNewObject(types.Dict{"Type": types.Name("Outlines")})
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	dests := []types.Array{
+		{*pageIndRef, types.Name("XYZ"), types.Integer(100), types.Integer(700), nil},
+		{*pageIndRef, types.Name("FitH"), types.Integer(500)},
+		{*pageIndRef, types.Name("FitR"), types.Integer(100), types.Integer(200), types.Integer(300), types.Integer(400)},
+	}
+	var items []*types.IndirectRef
+	for i, dest := range dests {
+		ir, err := ctx.IndRefForNewObject(types.Dict{
+			"Title":  types.StringLiteral(fmt.Sprintf("Item %d", i+1)),
+			"Parent": *outlinesIndRef,
+			"Dest":   dest,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		items = append(items, ir)
+	}
+	for i, ir := range items {
+		d, _ := ctx.DereferenceDict(*ir)
+		if i > 0 {
+			d["Prev"] = *items[i-1]
+		}
+		if i < len(items)-1 {
+			d["Next"] = *items[i+1]
+		}
+	}
+	outlines, _ := ctx.DereferenceDict(*outlinesIndRef)
+	outlines["First"], outlines["Last"], outlines["Count"] = *items[0], *items[len(items)-1], types.Integer(len(items))
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	rootDict["Outlines"] = *outlinesIndRef
+
+	if err := api.WriteContextFile(ctx, rotFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.NormalizeRotationFile(rotFile, outFile, []string{"1"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err = api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	rootDict, err = ctx.Catalog()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	outlines, err = ctx.DereferenceDict(rootDict["Outlines"])
+	if err != nil || outlines == nil {
+		t.Fatalf("%s: missing outlines: %v\n", msg, err)
+	}
+
+	// A point x,y of a page rotated by 90 degrees ends up at y-y0,x1-x.
+	x1, y0 := mb.UR.X, mb.LL.Y
+	want := []string{
+		fmt.Sprintf("XYZ %.2f %.2f <nil>", 700-y0, x1-100),
+		fmt.Sprintf("FitV %.2f", 500-y0),
+		fmt.Sprintf("FitR %.2f %.2f %.2f %.2f", 200-y0, x1-300, 400-y0, x1-100),
+	}
+
+	o := outlines["First"]
+	for i := range want {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil || d == nil {
+			t.Fatalf("%s: missing outline item %d: %v\n", msg, i+1, err)
+		}
+		a, err := ctx.DereferenceArray(d["Dest"])
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		ss := []string{a[1].(types.Name).Value()}
+		for _, o := range a[2:] {
+			if o == nil {
+				ss = append(ss, "<nil>")
+				continue
+			}
+			f, _ := o.(types.Float)
+			if i, ok := o.(types.Integer); ok {
+				f = types.Float(i)
+			}
+			ss = append(ss, fmt.Sprintf("%.2f", f.Value()))
+		}
+		if got := strings.Join(ss, " "); got != want[i] {
+			t.Fatalf("%s: outline item %d: want %s, got %s\n", msg, i+1, want[i], got)
+		}
+		o = d["Next"]
+	}
+}
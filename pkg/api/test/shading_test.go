@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+)
+
+func meshShadings(m map[int]pdfcpu.PageShadings) (all, pathological int) {
+	for _, ps := range m {
+		for _, sh := range ps.Shadings {
+			if sh.ShadingType < 4 {
+				continue
+			}
+			all++
+			if sh.Elements >= pdfcpu.ShadingRasterMinElements {
+				pathological++
+			}
+		}
+	}
+	return all, pathological
+}
+
+func TestShadings(t *testing.T) {
+	msg := "TestShadings"
+	inFile := filepath.Join(inDir, "VectorApple.pdf")
+	outFile := filepath.Join(outDir, "VectorAppleRasterized.pdf")
+
+	m, err := api.ShadingsFile(inFile, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	all, pathological := meshShadings(m)
+	if all == 0 || pathological == 0 {
+		t.Fatalf("%s: missing pathological mesh shadings: %v\n", msg, m)
+	}
+
+	c := model.NewDefaultConfiguration()
+	c.RasterizeShadings = true
+	if err := api.OptimizeFile(inFile, outFile, c); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	m, err = api.ShadingsFile(outFile, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, n := meshShadings(m); n > 0 {
+		t.Fatalf("%s: %d pathological mesh shadings left\n", msg, n)
+	}
+}
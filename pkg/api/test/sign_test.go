@@ -0,0 +1,487 @@
+/*
+Copyright 2023 The pdf Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/create"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/form"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/primitives"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/sign"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// signers caches signing keys and certificate chains by key type.
+var signers = map[string]*sign.Options{}
+
+func newKey(t *testing.T, keyType string) crypto.Signer {
+	t.Helper()
+	var (
+		key crypto.Signer
+		err error
+	)
+	if keyType == "ECDSA" {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatalf("%s: %v\n", keyType, err)
+	}
+	return key
+}
+
+func newCertificate(t *testing.T, cn string, serial int64, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"pdfcpu"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		BasicConstraintsValid: true,
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	bb, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("%s: %v\n", cn, err)
+	}
+	cert, err := x509.ParseCertificate(bb)
+	if err != nil {
+		t.Fatalf("%s: %v\n", cn, err)
+	}
+	return cert
+}
+
+// signOptions returns signing options for a key of keyType (RSA or ECDSA)
+// and a certificate named "pdfcpu <keyType> Signer" issued by a test CA.
+func signOptions(t *testing.T, keyType string) *sign.Options {
+	t.Helper()
+	opts, ok := signers[keyType]
+	if !ok {
+		caKey := newKey(t, keyType)
+		ca := newCertificate(t, "pdfcpu "+keyType+" CA", 1, caKey, nil, nil)
+		key := newKey(t, keyType)
+		cert := newCertificate(t, "pdfcpu "+keyType+" Signer", 2, key, ca, caKey)
+		opts = &sign.Options{Key: key, Certificates: []*x509.Certificate{cert, ca}}
+		signers[keyType] = opts
+	}
+	return &sign.Options{Key: opts.Key, Certificates: opts.Certificates}
+}
+
+// checkSignatures verifies the byte ranges of all signatures in fileName and returns the signature dicts.
+func checkSignatures(t *testing.T, fileName string) []types.Dict {
+	t.Helper()
+
+	bb, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", fileName, err)
+	}
+
+	if err := api.ValidateFile(fileName, nil); err != nil {
+		t.Fatalf("%s: %v\n", fileName, err)
+	}
+
+	ctx, err := api.ReadContextFile(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", fileName, err)
+	}
+
+	var sigs []types.Dict
+
+	for objNr := 1; objNr < *ctx.Size; objNr++ {
+		d, err := ctx.DereferenceDict(*types.NewIndirectRef(objNr, 0))
+		if err != nil || d == nil {
+			continue
+		}
+		if tp := d.Type(); tp == nil || *tp != "Sig" {
+			continue
+		}
+		sigs = append(sigs, d)
+
+		br := d.ArrayEntry("ByteRange")
+		if len(br) != 4 {
+			t.Fatalf("%s: corrupt ByteRange: %s\n", fileName, br)
+		}
+		var r [4]int
+		for i, o := range br {
+			r[i] = int(o.(types.Integer))
+		}
+		if r[0] != 0 || bb[r[1]] != '<' || bb[r[2]-1] != '>' || r[2]+r[3] > len(bb) {
+			t.Fatalf("%s: invalid ByteRange: %v\n", fileName, r)
+		}
+
+		hl := d.HexLiteralEntry("Contents")
+		if hl == nil {
+			t.Fatalf("%s: missing Contents\n", fileName)
+		}
+		cms, err := hl.Bytes()
+		if err != nil {
+			t.Fatalf("%s: %v\n", fileName, err)
+		}
+		var ci struct {
+			ContentType asn1.ObjectIdentifier
+			Content     asn1.RawValue `asn1:"tag:0,explicit"`
+		}
+		if _, err := asn1.Unmarshal(cms, &ci); err != nil {
+			t.Fatalf("%s: corrupt CMS: %v\n", fileName, err)
+		}
+		if !ci.ContentType.Equal(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}) {
+			t.Fatalf("%s: CMS is not SignedData: %s\n", fileName, ci.ContentType)
+		}
+	}
+
+	return sigs
+}
+
+func TestSignOptions(t *testing.T) {
+	msg := "TestSignOptions"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "SignedOptions.pdf")
+
+	rsaOpts, ecdsaOpts := signOptions(t, "RSA"), signOptions(t, "ECDSA")
+
+	for _, tt := range []struct {
+		name string
+		opts *sign.Options
+	}{
+		{"missing key", &sign.Options{Certificates: rsaOpts.Certificates}},
+		{"missing certificate", &sign.Options{Key: rsaOpts.Key}},
+		{"key not matching certificate", &sign.Options{Key: ecdsaOpts.Key, Certificates: rsaOpts.Certificates}},
+	} {
+		if err := api.SignFile(inFile, outFile, tt.opts, nil); err == nil {
+			t.Fatalf("%s: %s: want error\n", msg, tt.name)
+		}
+	}
+}
+
+func TestLoadPKCS12(t *testing.T) {
+	msg := "TestLoadPKCS12"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+
+	for _, tt := range []struct {
+		p12File, cn string
+	}{
+		{"signerRSA.p12", "pdfcpu RSA Signer"},
+		{"signerRSALegacy.p12", "pdfcpu RSA Signer"},
+		{"signerECDSA.p12", "pdfcpu ECDSA Signer"},
+	} {
+		bb, err := os.ReadFile(filepath.Join(resDir, tt.p12File))
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.p12File, err)
+		}
+
+		key, certs, err := sign.LoadPKCS12(bb, "pdfcpu")
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.p12File, err)
+		}
+		if key == nil || len(certs) != 2 {
+			t.Fatalf("%s %s: want key and 2 certificates, got %d\n", msg, tt.p12File, len(certs))
+		}
+		if cn := certs[0].Subject.CommonName; cn != tt.cn {
+			t.Fatalf("%s %s: want signer %q, got %q\n", msg, tt.p12File, tt.cn, cn)
+		}
+
+		if _, _, err := sign.LoadPKCS12(bb, "wrong"); err == nil {
+			t.Fatalf("%s %s: wrong password accepted\n", msg, tt.p12File)
+		}
+
+		// Sign from the PKCS#12 file.
+		opts, err := sign.LoadPKCS12File(filepath.Join(resDir, tt.p12File), "pdfcpu")
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.p12File, err)
+		}
+		outFile := filepath.Join(outDir, "SignedPKCS12"+strings.TrimSuffix(tt.p12File, ".p12")+".pdf")
+		if err := api.SignFile(inFile, outFile, opts, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.p12File, err)
+		}
+		srs := validateSignatures(t, outFile, 1)
+		if !srs[0].Valid() || srs[0].Name != tt.cn || len(srs[0].Certificates) != 2 {
+			t.Fatalf("%s %s: unexpected signature: %+v\n", msg, tt.p12File, srs[0])
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	msg := "TestSign"
+
+	for _, tt := range []struct {
+		inFile, keyType string
+		hash            crypto.Hash
+		rect            *types.Rectangle
+	}{
+		{"Walden.pdf", "RSA", 0, types.NewRectangle(350, 50, 550, 100)},
+		{"go.pdf", "RSA", crypto.SHA512, nil},
+		{"Hybrid-PDF.pdf", "ECDSA", crypto.SHA384, types.NewRectangle(50, 50, 200, 80)},
+	} {
+		inFile := filepath.Join(inDir, tt.inFile)
+		outFile := filepath.Join(outDir, "Signed"+tt.inFile)
+
+		opts := signOptions(t, tt.keyType)
+		opts.Hash = tt.hash
+		opts.Rect = tt.rect
+		opts.Reason = "Approval"
+		opts.Location = "Vienna"
+
+		if err := api.SignFile(inFile, outFile, opts, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.inFile, err)
+		}
+
+		sigs := checkSignatures(t, outFile)
+		if len(sigs) != 1 {
+			t.Fatalf("%s %s: want 1 signature, got %d\n", msg, tt.inFile, len(sigs))
+		}
+		if sf := sigs[0].NameEntry("SubFilter"); sf == nil || *sf != "ETSI.CAdES.detached" {
+			t.Fatalf("%s %s: invalid SubFilter\n", msg, tt.inFile)
+		}
+	}
+}
+
+func TestSignSignatureFields(t *testing.T) {
+	msg := "TestSignSignatureFields"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "SignedSignatureFields.pdf")
+
+	ff := create.NewFormFields().
+		AddTextField(1, &primitives.TextField{ID: "firstName", Position: [2]float64{100, 700}, Width: 200}).
+		AddSignatureField(1, &primitives.SignatureField{ID: "signature1", Position: [2]float64{100, 450}, Width: 200, Height: 50}).
+		AddSignatureField(1, &primitives.SignatureField{ID: "signature2", Position: [2]float64{100, 350}, Width: 200, Height: 50})
+
+	if err := api.AddFormFieldsFile(inFile, outFile, ff, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	fl := form.FieldLock{Action: form.FieldLockInclude, Fields: []string{"firstName"}}
+	if err := api.SetSignatureFieldLockFile(outFile, "", "signature1", fl, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// The second signature gets appended as increment leaving the first one intact.
+	for _, fieldName := range []string{"signature1", "signature2"} {
+		opts := signOptions(t, "RSA")
+		opts.FieldName = fieldName
+		if err := api.SignFile(outFile, "", opts, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, fieldName, err)
+		}
+	}
+
+	sigs := checkSignatures(t, outFile)
+	if len(sigs) != 2 {
+		t.Fatalf("%s: want 2 signatures, got %d\n", msg, len(sigs))
+	}
+
+	var locked bool
+	for _, d := range sigs {
+		if refs := d.ArrayEntry("Reference"); len(refs) > 0 {
+			locked = true
+		}
+	}
+	if !locked {
+		t.Fatalf("%s: missing FieldMDP reference\n", msg)
+	}
+
+	// Signed fields may not be signed again.
+	opts := signOptions(t, "RSA")
+	opts.FieldName = "signature1"
+	if err := api.SignFile(outFile, filepath.Join(outDir, "SignedTwice.pdf"), opts, nil); err == nil {
+		t.Fatalf("%s: signed field signed again\n", msg)
+	}
+
+	// Encrypted files are not supported.
+	encFile := filepath.Join(outDir, "SignEncrypted.pdf")
+	conf := model.NewAESConfiguration("upw", "opw", 256)
+	if err := api.EncryptFile(inFile, encFile, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	conf = model.NewAESConfiguration("upw", "opw", 256)
+	if err := api.SignFile(encFile, filepath.Join(outDir, "SignedEncrypted.pdf"), signOptions(t, "RSA"), conf); err == nil {
+		t.Fatalf("%s: encrypted file signed\n", msg)
+	}
+}
+
+func validateSignatures(t *testing.T, fileName string, want int) []sign.SignatureReport {
+	t.Helper()
+
+	srs, err := api.ValidateSignaturesFile(fileName, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", fileName, err)
+	}
+	if len(srs) != want {
+		t.Fatalf("%s: want %d signatures, got %d\n", fileName, want, len(srs))
+	}
+
+	return srs
+}
+
+// rewriteSignatureDict appends an increment to bb changing the reason of the first signature.
+func rewriteSignatureDict(t *testing.T, bb []byte) []byte {
+	t.Helper()
+	msg := "rewriteSignatureDict"
+
+	ctx, err := api.ReadContext(bytes.NewReader(bb), model.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	acroForm, err := ctx.DereferenceDict(rootDict["AcroForm"])
+	if err != nil || acroForm == nil {
+		t.Fatalf("%s: missing form: %v\n", msg, err)
+	}
+	fields, err := ctx.DereferenceArray(acroForm["Fields"])
+	if err != nil || len(fields) == 0 {
+		t.Fatalf("%s: missing fields: %v\n", msg, err)
+	}
+	d, err := ctx.DereferenceDict(fields[0])
+	if err != nil || d == nil {
+		t.Fatalf("%s: missing field: %v\n", msg, err)
+	}
+	ir := d.IndirectRefEntry("V")
+	if ir == nil {
+		t.Fatalf("%s: missing signature dict\n", msg)
+	}
+	sigDict, err := ctx.DereferenceDict(*ir)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	sigDict["Reason"] = types.StringLiteral("Rewritten")
+	ctx.Write.IncrementWithObjNr(ir.ObjectNumber.Value())
+	ctx.Write.Increment = true
+	ctx.Write.Offset = int64(len(bb))
+
+	buf := bytes.NewBuffer(append([]byte{}, bb...))
+	if err := api.WriteIncrement(ctx, buf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestValidateSignatures(t *testing.T) {
+	msg := "TestValidateSignatures"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "ValidateSignatures.pdf")
+
+	for i, keyType := range []string{"RSA", "ECDSA"} {
+		opts := signOptions(t, keyType)
+		opts.Reason = "Approval"
+		in := inFile
+		if i > 0 {
+			in = outFile
+		}
+		if err := api.SignFile(in, outFile, opts, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, keyType, err)
+		}
+	}
+
+	srs := validateSignatures(t, outFile, 2)
+
+	for i, want := range []struct {
+		signer       string
+		coverage     sign.Coverage
+		modification sign.Modification
+	}{
+		{"pdfcpu RSA Signer", sign.CoveragePartial, sign.ModificationSignatures},
+		{"pdfcpu ECDSA Signer", sign.CoverageWholeDocument, sign.ModificationNone},
+	} {
+		sr := srs[i]
+		if !sr.Valid() || len(sr.Problems) > 0 {
+			t.Fatalf("%s: signature %d invalid: %v\n", msg, i, sr.Problems)
+		}
+		if sr.Coverage != want.coverage || sr.Modification != want.modification {
+			t.Fatalf("%s: signature %d: want %s/%s, got %s/%s\n", msg, i, want.coverage, want.modification, sr.Coverage, sr.Modification)
+		}
+		if len(sr.Certificates) != 2 || !sr.Certificates[1].SelfSigned {
+			t.Fatalf("%s: signature %d: incomplete certificate chain\n", msg, i)
+		}
+		if sr.Name != want.signer || sr.Reason != "Approval" || sr.SigningTime == nil {
+			t.Fatalf("%s: signature %d: unexpected signature dict: %+v\n", msg, i, sr)
+		}
+	}
+
+	// Adding an annotation modifies the document beyond adding signatures.
+	modFile := filepath.Join(outDir, "ValidateSignaturesModified.pdf")
+	if err := copyFile(t, outFile, modFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.AddAnnotationsFile(modFile, "", []string{"1"}, textAnn, nil, true); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for i, sr := range validateSignatures(t, modFile, 2) {
+		if !sr.Valid() || sr.Modification != sign.ModificationOther || len(sr.ModifiedObjects) == 0 {
+			t.Fatalf("%s: signature %d: modification undetected: %+v\n", msg, i, sr)
+		}
+	}
+
+	// Rewriting an existing signature dict modifies the document beyond adding signatures.
+	bb, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	rewrittenFile := filepath.Join(outDir, "ValidateSignaturesRewritten.pdf")
+	if err := os.WriteFile(rewrittenFile, rewriteSignatureDict(t, bb), 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for i, sr := range validateSignatures(t, rewrittenFile, 2) {
+		if sr.Modification != sign.ModificationOther || len(sr.ModifiedObjects) == 0 {
+			t.Fatalf("%s: signature %d: rewritten signature dict undetected: %+v\n", msg, i, sr)
+		}
+	}
+
+	// Tampering with the signed bytes invalidates the digest.
+	i := bytes.LastIndex(bb, []byte("/M(D:"))
+	if i < 0 {
+		t.Fatalf("%s: missing signing time\n", msg)
+	}
+	bb[i+len("/M(D:")]++
+	tamperedFile := filepath.Join(outDir, "ValidateSignaturesTampered.pdf")
+	if err := os.WriteFile(tamperedFile, bb, 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	srs = validateSignatures(t, tamperedFile, 2)
+	if srs[1].DigestValid || srs[1].Valid() {
+		t.Fatalf("%s: tampering undetected\n", msg)
+	}
+	if !srs[0].Valid() {
+		t.Fatalf("%s: first signature invalid\n", msg)
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package test
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -60,6 +61,221 @@ func TestSplit0ByBookmark(t *testing.T) {
 	}
 }
 
+func TestSplitByBookmarkLevel(t *testing.T) {
+	msg := "TestSplitByBookmarkLevel"
+	inFile := filepath.Join(inDir, "TheGoProgrammingLanguageCh1.pdf")
+	outDir := filepath.Join(outDir, "splitBookmarkLevel")
+	if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Cut at every level 2 bookmark.
+	// "1. Tutorial" starts with "1.1. Hello, World" on the same page and is therefore represented by the latter.
+	if err := api.SplitFileByBookmarks(inFile, outDir, "", 2, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, tt := range []struct {
+		fileName  string
+		pageCount int
+	}{
+		{"Preface.pdf", 1},
+		{"The_Origins_of_Go.pdf", 1},
+		{"1.1._Hello,_World.pdf", 3},
+		{"1.8._Loose_Ends.pdf", 4},
+		{"Z.pdf", 1},
+	} {
+		n, err := api.PageCountFile(filepath.Join(outDir, tt.fileName))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if n != tt.pageCount {
+			t.Fatalf("%s: %s: want %d pages, got %d\n", msg, tt.fileName, tt.pageCount, n)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(outDir, "1._Tutorial.pdf")); err == nil {
+		t.Fatalf("%s: unexpected file for 1. Tutorial\n", msg)
+	}
+
+	if err := api.SplitFileByBookmarks(inFile, outDir, "%03d_%bookmark", 2, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "020_1.1. Hello, World.pdf")); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.SplitFileByBookmarks(inFile, outDir, "", 0, nil); err == nil {
+		t.Fatalf("%s: missing error for invalid level\n", msg)
+	}
+}
+
+func TestSplitBySize(t *testing.T) {
+	msg := "TestSplitBySize"
+	inFile := filepath.Join(inDir, "5116.DCT_Filter.pdf")
+	outDir := filepath.Join(outDir, "splitBySize")
+	if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	maxBytes := int64(32 * 1024)
+	if err := api.SplitBySizeFile(inFile, outDir, maxBytes, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ee, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(ee) < 2 {
+		t.Fatalf("%s: want at least 2 parts, got %d\n", msg, len(ee))
+	}
+
+	pageCount := 0
+	for _, e := range ee {
+		fi, err := e.Info()
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if fi.Size() > maxBytes {
+			t.Fatalf("%s: %s: size %d exceeds %d\n", msg, e.Name(), fi.Size(), maxBytes)
+		}
+		n, err := api.PageCountFile(filepath.Join(outDir, e.Name()))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		pageCount += n
+	}
+	if pageCount != 52 {
+		t.Fatalf("%s: want 52 pages, got %d\n", msg, pageCount)
+	}
+
+	// Not even a single page fits.
+	if err := api.SplitBySizeFile(inFile, outDir, 1000, nil); err == nil {
+		t.Fatalf("%s: missing error for size limit\n", msg)
+	}
+}
+
+func TestSplitByText(t *testing.T) {
+	msg := "TestSplitByText"
+	inFile := filepath.Join(inDir, "TheGoProgrammingLanguageCh1.pdf")
+	outDir := filepath.Join(outDir, "splitByText")
+	if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Start a new file with every blank page.
+	if err := api.SplitFileByText(inFile, outDir, "intentionally left blank", "", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, tt := range []struct {
+		fileName  string
+		pageCount int
+	}{
+		{"TheGoProgrammingLanguageCh1_1-2.pdf", 2},
+		{"TheGoProgrammingLanguageCh1_3-6.pdf", 4},
+		{"TheGoProgrammingLanguageCh1_7-18.pdf", 12},
+		{"TheGoProgrammingLanguageCh1_19-44.pdf", 26},
+		{"TheGoProgrammingLanguageCh1_45-59.pdf", 15},
+	} {
+		n, err := api.PageCountFile(filepath.Join(outDir, tt.fileName))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if n != tt.pageCount {
+			t.Fatalf("%s: %s: want %d pages, got %d\n", msg, tt.fileName, tt.pageCount, n)
+		}
+	}
+
+	// Name output files after the first submatch.
+	if err := api.SplitFileByText(inFile, outDir, "intentionally (left) blank", "%02d_%text", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for _, fn := range []string{"01_.pdf", "03_left.pdf", "45_left.pdf"} {
+		if _, err := os.Stat(filepath.Join(outDir, fn)); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	if err := api.SplitFileByText(inFile, outDir, "no such marker", "", nil); err == nil {
+		t.Fatalf("%s: missing error for unmatched pattern\n", msg)
+	}
+
+	if err := api.SplitFileByText(inFile, outDir, "(", "", nil); err == nil {
+		t.Fatalf("%s: missing error for invalid pattern\n", msg)
+	}
+}
+
+func TestSplitWithTemplate(t *testing.T) {
+	msg := "TestSplitWithTemplate"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outDir := filepath.Join(outDir, "splitTemplate")
+	if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Create single page files named after their Bates numbers.
+	if err := api.SplitFileWithTemplate(inFile, outDir, "%basename_%06bates(1000)", 1, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, fn := range []string{"Walden_001000.pdf", "Walden_001001.pdf"} {
+		if _, err := os.Stat(filepath.Join(outDir, fn)); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	// Extract page 2 using a zero padded page number.
+	if err := api.ExtractPagesFileWithTemplate(inFile, outDir, "page_%03d", []string{"2"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "page_002.pdf")); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// A template resolving to the same file name for all spans is rejected.
+	if err := api.SplitFileWithTemplate(inFile, outDir, "%basename", 1, nil); err == nil {
+		t.Fatalf("%s: expected duplicate file name error\n", msg)
+	}
+
+	// Unknown tokens are rejected.
+	if err := api.SplitFileWithTemplate(inFile, outDir, "%basename_%foo", 1, nil); err == nil {
+		t.Fatalf("%s: expected template error\n", msg)
+	}
+}
+
+func TestSplitWithFormFieldTemplate(t *testing.T) {
+	msg := "TestSplitWithFormFieldTemplate"
+	inFile := filepath.Join(samplesDir, "form", "demo", "english.pdf")
+	outDir := filepath.Join(outDir, "splitFormFieldTemplate")
+	if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Only page 2 holds the field lastName2.
+	if err := api.SplitFileWithTemplate(inFile, outDir, "%d_%formfield(lastName2)", 1, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for _, fn := range []string{"1_.pdf", "2_Doe.pdf"} {
+		if _, err := os.Stat(filepath.Join(outDir, fn)); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	if err := api.ExtractPagesFileWithTemplate(inFile, outDir, "%formfield(firstName2) %formfield(lastName2)", []string{"2"}, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "Jackie Doe.pdf")); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// %formfield requires a field id or name.
+	if err := api.SplitFileWithTemplate(inFile, outDir, "%d_%formfield", 1, nil); err == nil {
+		t.Fatalf("%s: expected template error\n", msg)
+	}
+}
+
 func TestSplitLowLevel(t *testing.T) {
 	msg := "TestSplitLowLevel"
 	inFile := filepath.Join(inDir, "TheGoProgrammingLanguageCh1.pdf")
@@ -17,12 +17,20 @@ limitations under the License.
 package test
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/color"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/mjuen/pdfcpu/pkg/api"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/format"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
@@ -583,3 +591,747 @@ func TestRecycleWM(t *testing.T) {
 		t.Fatalf("%s %s: %v\n", msg, outFile, err)
 	}
 }
+
+func TestAddMultiStamps(t *testing.T) {
+	msg := "TestAddMultiStamps"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	logo := filepath.Join(resDir, "logoSmall.png")
+
+	csvFile := filepath.Join(outDir, "stamps.csv")
+	csv := "page,text,file,x,y,rotation,scale,desc\n" +
+		"1,No. 0001,,400,20,0,1 abs,\"font:Courier, points:12\"\n" +
+		"1,,\"" + logo + "\",20,20,0,0.1 rel,\n" +
+		"2,No. 0002,,400,20,,1 abs,\"font:Courier, points:12\"\n"
+	if err := os.WriteFile(csvFile, []byte(csv), 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	jsonFile := filepath.Join(outDir, "stamps.json")
+	json := `{"stamps": [
+		{"page": 1, "text": "x=100 y=700", "x": 100, "y": 700, "desc": "points:10, fillc:#FF0000"},
+		{"page": 2, "text": "Plot A", "x": 200, "y": 300, "rotation": 45, "scale": "1 abs"}
+	]}`
+	if err := os.WriteFile(jsonFile, []byte(json), 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, stampFile := range []string{csvFile, jsonFile} {
+		outFile := filepath.Join(outDir, "multiStamp"+filepath.Ext(stampFile)+".pdf")
+		if err := api.AddMultiStampsFile(inFile, stampFile, outFile, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, stampFile, err)
+		}
+		if err := api.ValidateFile(outFile, nil); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if ok := hasWatermarks(outFile, t); !ok {
+			t.Fatalf("%s: no stamps found: %s\n", msg, outFile)
+		}
+	}
+
+	// Stamps need a page number.
+	if _, err := pdfcpu.ParseMultiStampsCSV(strings.NewReader("text,x,y\nabc,1,1\n")); err == nil {
+		t.Fatalf("%s: missing error for CSV without page column\n", msg)
+	}
+}
+
+func TestAddStencilStamp(t *testing.T) {
+	msg := "TestAddStencilStamp"
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+
+	// A 1-bit image: a black square on white.
+	img := image.NewPaletted(image.Rect(0, 0, 20, 20), color.Palette{color.White, color.Black})
+	for y := 5; y < 15; y++ {
+		for x := 5; x < 15; x++ {
+			img.SetColorIndex(x, y, 1)
+		}
+	}
+
+	wm, err := api.ImageWatermarkForReader(bytes.NewReader(pngBytes(t, img)), "stencil:on, fillcolor:#FF0000", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	f, err := os.Open(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	if err := api.AddWatermarks(f, &buf, []string{"1"}, wm, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	var found bool
+	for _, sd := range imageStreamDicts(t, buf.Bytes()) {
+		if im := sd.BooleanEntry("ImageMask"); im != nil && *im {
+			found = true
+			if bpc := sd.IntEntry("BitsPerComponent"); bpc == nil || *bpc != 1 {
+				t.Fatalf("%s: image mask needs 1 bit per component\n", msg)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("%s: missing image mask\n", msg)
+	}
+
+	// Images using shades of gray are no stencil masks.
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+	gray.SetGray(0, 0, color.Gray{Y: 0x80})
+	wm, err = api.ImageWatermarkForReader(bytes.NewReader(pngBytes(t, gray)), "stencil:on", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.AddWatermarks(f, &buf, []string{"1"}, wm, nil); err == nil {
+		t.Fatalf("%s: stencil for gray image should fail\n", msg)
+	}
+}
+
+func TestAddShapeStamps(t *testing.T) {
+	msg := "TestAddShapeStamps"
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+	outFile := filepath.Join(outDir, "ShapeStamps.pdf")
+
+	for _, tt := range []struct {
+		shape, desc string
+	}{
+		{"line 300 0", "pos:tc, off:0 -40, linew:2, strokec:#0000FF"},
+		{"rect 200 100", "pos:tl, off:20 -60, mode:2, linew:2, fillc:#FFFFCC, strokec:#000000"},
+		{"roundrect 200 100 12", "pos:tr, off:-20 -60, mode:1, linew:3"},
+		{"ellipse 100 50", "pos:c, fillc:#FF0000, op:0.5"},
+	} {
+		wm, err := api.ShapeWatermark(tt.shape, tt.desc, true, false, types.POINTS)
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.shape, err)
+		}
+		if err := api.AddWatermarksFile(inFile, outFile, []string{"1"}, wm, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, tt.shape, err)
+		}
+		inFile = outFile
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Shapes are drawn true to size including their outline.
+	want := map[string]*types.Rectangle{
+		"l S":  types.RectForDim(302, 2),
+		"re B": types.RectForDim(202, 102),
+		"h S":  types.RectForDim(203, 103),
+		"h f":  types.RectForDim(100, 50),
+	}
+
+	for objNr, entry := range ctx.Table {
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok || sd.Dict.Subtype() == nil || *sd.Dict.Subtype() != "Form" || sd.Dict["OC"] == nil {
+			continue
+		}
+		if err := sd.Decode(); err != nil {
+			t.Fatalf("%s: obj#%d: %v\n", msg, objNr, err)
+		}
+		bbox, err := types.RectForArray(sd.ArrayEntry("BBox"))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		for op, r := range want {
+			if strings.HasSuffix(string(sd.Content), op+" Q") && bbox.Equals(*r) {
+				delete(want, op)
+			}
+		}
+	}
+
+	if len(want) > 0 {
+		t.Fatalf("%s: missing shapes: %v\n", msg, want)
+	}
+
+	for _, s := range []string{"", "circle 10", "rect 10", "rect 0 10", "roundrect 10 10 6", "line 0 0"} {
+		if _, err := api.ShapeWatermark(s, "", true, false, types.POINTS); err == nil {
+			t.Fatalf("%s: invalid shape %q should fail\n", msg, s)
+		}
+	}
+}
+
+func TestStampTimestamp(t *testing.T) {
+	msg := "TestStampTimestamp"
+
+	tm := time.Date(2023, time.March, 6, 11, 30, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		layout, locale, timeZone, want string
+	}{
+		{"2006-01-02 15:04", "", "", "2023-03-06 11:30"},
+		{"Monday, 2 January 2006", "en", "", "Monday, 6 March 2023"},
+		{"Monday, 2 January 2006", "de-AT", "", "Montag, 6 März 2023"},
+		{"Mon 2 Jan 2006", "fr", "", "lun. 6 mars 2023"},
+		{"Month: January", "nl", "", "Month: maart"},
+		{"2 January 2006 15:04 MST", "it", "Europe/Rome", "6 marzo 2023 12:30 CET"},
+	} {
+		ts, err := model.NewTimestamp(tt.layout, tt.locale, tt.timeZone)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if got := ts.Format(tm); got != tt.want {
+			t.Fatalf("%s: want %q, got %q\n", msg, tt.want, got)
+		}
+	}
+
+	if _, err := model.NewTimestamp("", "xx", ""); err == nil {
+		t.Fatalf("%s: unsupported locale should fail\n", msg)
+	}
+	if _, err := model.NewTimestamp("", "", "Mars/Olympus_Mons"); err == nil {
+		t.Fatalf("%s: unknown time zone should fail\n", msg)
+	}
+
+	// Commas and colons within the timestamp layout.
+	wm, err := api.TextWatermark("%t", `timestamp:Monday\, 2 January 2006 15:04 MST, locale:es, timezone:UTC, pos:bc`, true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if wm.TimestampFormat != "Monday, 2 January 2006 15:04 MST" || wm.Locale != "es" || wm.TimeZone != "UTC" {
+		t.Fatalf("%s: corrupt timestamp config: %q %q %q\n", msg, wm.TimestampFormat, wm.Locale, wm.TimeZone)
+	}
+
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "StampTimestamp.pdf")
+	if err := api.AddWatermarksFile(inFile, outFile, nil, wm, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	var found bool
+	for _, entry := range ctx.Table {
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok || sd.Dict.Subtype() == nil || *sd.Dict.Subtype() != "Form" || sd.Dict["OC"] == nil {
+			continue
+		}
+		if err := sd.Decode(); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if strings.Contains(string(sd.Content), "UTC") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("%s: missing timestamp\n", msg)
+	}
+}
+
+// pageStampContent returns the concatenated content of all form XObjects of pageNr.
+func pageStampContent(t *testing.T, ctx *model.Context, pageNr int) string {
+	t.Helper()
+
+	d, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+	if err != nil || d == nil {
+		t.Fatalf("page %d: %v\n", pageNr, err)
+	}
+	xObjs := inhPAttrs.Resources.DictEntry("XObject")
+
+	var sb strings.Builder
+	for _, o := range xObjs {
+		sd, _, err := ctx.DereferenceStreamDict(o)
+		if err != nil || sd == nil || sd.Subtype() == nil || *sd.Subtype() != "Form" {
+			continue
+		}
+		if err := sd.Decode(); err != nil {
+			t.Fatalf("page %d: %v\n", pageNr, err)
+		}
+		sb.Write(sd.Content)
+	}
+	return sb.String()
+}
+
+func TestAddWatermarksBatch(t *testing.T) {
+	msg := "TestAddWatermarksBatch"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "WatermarksBatch.pdf")
+
+	stamp1, err := api.TextWatermark("Section A", "pos:tl, sc:.5", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	wm2, err := api.TextWatermark("Section B", "op:.3", false, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	stamp3, err := api.ImageWatermark(filepath.Join(resDir, "logoSmall.png"), "pos:br, sc:.2, op:.7", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	batch := []api.PageWatermark{
+		{Pages: []string{"1"}, Watermark: stamp1},
+		{Pages: []string{"2"}, Watermark: wm2},
+		{Pages: nil, Watermark: stamp3},
+	}
+
+	if err := api.AddWatermarksBatchFile(inFile, outFile, batch, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for pageNr, tt := range map[int]struct{ want, other string }{1: {"Section A", "Section B"}, 2: {"Section B", "Section A"}} {
+		s := pageStampContent(t, ctx, pageNr)
+		if !strings.Contains(s, tt.want) || strings.Contains(s, tt.other) {
+			t.Fatalf("%s: page %d: want stamp %q only\n", msg, pageNr, tt.want)
+		}
+		if !strings.Contains(s, " Do") {
+			t.Fatalf("%s: page %d: missing image stamp\n", msg, pageNr)
+		}
+	}
+
+	// The same watermark may be applied to several page selections.
+	stamp, err := api.TextWatermark("Draft", "", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	batch = []api.PageWatermark{{Pages: []string{"1"}, Watermark: stamp}, {Pages: []string{"2"}, Watermark: stamp}}
+	if err := api.AddWatermarksBatchFile(inFile, outFile, batch, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	batch = []api.PageWatermark{{Pages: []string{"5"}, Watermark: stamp}}
+	if err := api.AddWatermarksBatchFile(inFile, outFile, batch, nil); err == nil {
+		t.Fatalf("%s: invalid page selection should fail\n", msg)
+	}
+}
+
+func TestStampTemplateTokens(t *testing.T) {
+	msg := "TestStampTemplateTokens"
+
+	ts := model.Timestamp{Layout: "2006"}
+	doc := &model.DocFields{FileName: "in.pdf", Info: map[string]string{"Title": "Walden", "Dept": "R&D"}}
+	year := time.Now().Format("2006")
+
+	for _, tt := range []struct {
+		text   string
+		doc    *model.DocFields
+		want   string
+		unique bool
+	}{
+		{"Page %PageNumber of %PageCount", doc, "Page 3 of 7", true},
+		{"Page %p of %P", doc, "Page 3 of 7", true},
+		{"%Filename: %Info(Title) (%Info(Dept))", doc, "in.pdf: Walden (R&D)", true},
+		{"%Info(Missing)|%Filename", nil, "|", true},
+		{"%Now", doc, year, true},
+		{"%Info(Title", doc, "Info(Title", false},
+		{"no tokens", doc, "no tokens", false},
+	} {
+		got, unique := format.TextWithFields(tt.text, ts, 3, 7, tt.doc)
+		if got != tt.want || unique != tt.unique {
+			t.Fatalf("%s: %q: want %q %t, got %q %t\n", msg, tt.text, tt.want, tt.unique, got, unique)
+		}
+	}
+
+	wm, err := api.TextWatermark("%Filename p%PageNumber/%PageCount", "pos:bc, rot:0", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "StampTemplateTokens.pdf")
+	if err := api.AddWatermarksFile(inFile, outFile, nil, wm, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	found := map[string]bool{}
+	for _, entry := range ctx.Table {
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok || sd.Dict.Subtype() == nil || *sd.Dict.Subtype() != "Form" || sd.Dict["OC"] == nil {
+			continue
+		}
+		if err := sd.Decode(); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		for _, s := range []string{"Walden.pdf p1/2", "Walden.pdf p2/2"} {
+			if strings.Contains(string(sd.Content), s) {
+				found[s] = true
+			}
+		}
+	}
+	if len(found) != 2 {
+		t.Fatalf("%s: missing resolved stamp text, found: %v\n", msg, found)
+	}
+}
+
+const testLogoSVG = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="300" height="150" viewBox="0 0 200 100">
+  <style>.brand { fill: #0066cc; } #outline { stroke: navy; stroke-width: 2px; fill: none; }</style>
+  <defs>
+    <linearGradient id="grad"><stop offset="0" stop-color="#ff8800"/><stop offset="1" stop-color="red"/></linearGradient>
+    <path id="tick" d="M0,5 l3,3 7-8"/>
+  </defs>
+  <rect id="outline" x="1" y="1" width="198" height="98" rx="10"/>
+  <g transform="translate(20 20) rotate(15, 10, 10)" opacity="0.5">
+    <circle class="brand" cx="10" cy="10" r="10"/>
+    <ellipse cx="40" cy="10" rx="12" ry="6" fill="url(#grad)"/>
+  </g>
+  <path d="M60 60 Q70 40 80 60 T100 60 C110 40 120 80 130 60 S150 40 160 60 A10 15 30 0 1 180 60 Z" fill="rgb(0, 128, 0)" fill-rule="evenodd"/>
+  <polygon points="20,90 30,70 40,90" style="fill:#c00;stroke:black;stroke-linejoin:round"/>
+  <polyline points="50 90 60 80 70 90" fill="none" stroke="gray"/>
+  <line x1="0" y1="0" x2="200" y2="100" stroke="black" stroke-opacity=".3"/>
+  <use xlink:href="#tick" x="150" y="80" fill="none" stroke="green"/>
+  <text x="100" y="30" font-family="Arial, sans-serif" font-weight="bold" font-size="14" text-anchor="middle">ACME <tspan font-style="italic">(Inc.)</tspan></text>
+</svg>`
+
+func TestAddSVGStamps(t *testing.T) {
+	msg := "TestAddSVGStamps"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "SVGStamps.pdf")
+
+	svgFile := filepath.Join(outDir, "logo.svg")
+	if err := os.WriteFile(svgFile, []byte(testLogoSVG), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.AddSVGWatermarksFile(inFile, outFile, nil, true, svgFile, "sc:1 abs, pos:tr, rot:0", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	wm, err := api.SVGWatermarkForReader(strings.NewReader(testLogoSVG), "sc:.5, op:.8", false, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.AddWatermarksFile(outFile, "", nil, wm, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// The stamp is drawn at its natural size of 300x150 px = 225x112.5 points.
+	var found bool
+	for objNr, entry := range ctx.Table {
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok || sd.Dict.Subtype() == nil || *sd.Dict.Subtype() != "Form" || sd.Dict["OC"] == nil {
+			continue
+		}
+		if err := sd.Decode(); err != nil {
+			t.Fatalf("%s: obj#%d: %v\n", msg, objNr, err)
+		}
+		bbox, err := types.RectForArray(sd.ArrayEntry("BBox"))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if !bbox.Equals(*types.RectForDim(225, 112.5)) {
+			continue
+		}
+		found = true
+		s := string(sd.Content)
+		for _, op := range []string{" re W n ", " f* Q", " B Q", " S Q", " Tj ET", " gs ", "0.000 0.400 0.800 rg", "1.000 0.533 0.000 rg"} {
+			if !strings.Contains(s, op) {
+				t.Fatalf("%s: missing %q in SVG stamp content:\n%s\n", msg, op, s)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("%s: missing SVG stamp\n", msg)
+	}
+
+	for _, svg := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg"><rect width="10" height="10"/></svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><path d="L 10 10"/></svg>`,
+		`<html></html>`,
+	} {
+		wm, err := api.SVGWatermarkForReader(strings.NewReader(svg), "", true, false, types.POINTS)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if err := api.AddWatermarksFile(inFile, outFile, nil, wm, nil); err == nil {
+			t.Fatalf("%s: invalid svg should fail: %s\n", msg, svg)
+		}
+	}
+
+	if _, err := api.SVGWatermark(filepath.Join(inDir, "Walden.pdf"), "", true, false, types.POINTS); err == nil {
+		t.Fatalf("%s: non SVG file should fail\n", msg)
+	}
+}
+
+func TestAddWatermarksFunc(t *testing.T) {
+	msg := "TestAddWatermarksFunc"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "WatermarksFunc.pdf")
+
+	customers := map[int]string{1: "Customer 4711", 2: "Customer 0815"}
+
+	var calls []int
+	f := func(pageNr int, pageDim types.Dim) (*model.Watermark, error) {
+		calls = append(calls, pageNr)
+		if pageDim.Width <= 0 || pageDim.Height <= 0 {
+			return nil, fmt.Errorf("page %d: invalid dimensions %v", pageNr, pageDim)
+		}
+		return api.TextWatermark(customers[pageNr], "pos:bl, sc:.3 abs", true, false, types.POINTS)
+	}
+
+	if err := api.AddWatermarksFuncFile(inFile, outFile, nil, f, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("%s: want 2 calls, got %v\n", msg, calls)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	for pageNr, want := range customers {
+		if s := pageStampContent(t, ctx, pageNr); !strings.Contains(s, want) {
+			t.Fatalf("%s: page %d: missing stamp %q\n", msg, pageNr, want)
+		}
+	}
+
+	// Pages for which f returns no watermark are left untouched.
+	skip := func(pageNr int, pageDim types.Dim) (*model.Watermark, error) {
+		if pageNr == 1 {
+			return nil, nil
+		}
+		return api.TextWatermark("Page 2 only", "", true, false, types.POINTS)
+	}
+	if err := api.AddWatermarksFuncFile(inFile, outFile, nil, skip, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if ctx, err = api.ReadContextFile(outFile); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if s := pageStampContent(t, ctx, 1); strings.Contains(s, "Page 2 only") {
+		t.Fatalf("%s: page 1 should not be stamped\n", msg)
+	}
+	if s := pageStampContent(t, ctx, 2); !strings.Contains(s, "Page 2 only") {
+		t.Fatalf("%s: page 2 missing stamp\n", msg)
+	}
+
+	// Errors returned by f abort the operation.
+	fail := func(pageNr int, pageDim types.Dim) (*model.Watermark, error) {
+		return nil, fmt.Errorf("lookup failed")
+	}
+	if err := api.AddWatermarksFuncFile(inFile, outFile, []string{"2"}, fail, nil); err == nil {
+		t.Fatalf("%s: expected error\n", msg)
+	}
+}
+
+func TestStampBlendMode(t *testing.T) {
+	msg := "TestStampBlendMode"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "StampBlendMode.pdf")
+
+	wm, err := api.TextWatermark("DRAFT", "op:.6, blend:multiply, group:isolated knockout, fillc:#FF0000", true, false, types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if wm.BlendMode != "Multiply" || !wm.Isolated || !wm.Knockout {
+		t.Fatalf("%s: unexpected watermark config: %q %t %t\n", msg, wm.BlendMode, wm.Isolated, wm.Knockout)
+	}
+
+	if err := api.AddWatermarksFile(inFile, outFile, []string{"1"}, wm, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	_, _, inhPAttrs, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	var blend bool
+	for _, o := range inhPAttrs.Resources.DictEntry("ExtGState") {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if bm := d.NameEntry("BM"); bm != nil && *bm == "Multiply" {
+			blend = true
+		}
+	}
+	if !blend {
+		t.Fatalf("%s: missing blend mode\n", msg)
+	}
+
+	var group bool
+	for _, o := range inhPAttrs.Resources.DictEntry("XObject") {
+		sd, _, err := ctx.DereferenceStreamDict(o)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if d := sd.DictEntry("Group"); d != nil {
+			i, k := d.BooleanEntry("I"), d.BooleanEntry("K")
+			group = i != nil && *i && k != nil && *k
+		}
+	}
+	if !group {
+		t.Fatalf("%s: missing transparency group\n", msg)
+	}
+
+	for _, desc := range []string{"blend:Burn", "group:opaque"} {
+		if _, err := api.TextWatermark("DRAFT", desc, true, false, types.POINTS); err == nil {
+			t.Fatalf("%s: expected error for %q\n", msg, desc)
+		}
+	}
+}
+
+func TestRemoveWatermarkByName(t *testing.T) {
+	msg := "TestRemoveWatermarkByName"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "StampByName.pdf")
+
+	if err := api.AddTextWatermarksFile(inFile, outFile, nil, true, "Approved", "name:approval, pos:tl", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.AddTextWatermarksFile(outFile, "", nil, true, "Confidential", "name:class, pos:br", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.RemoveWatermarkFile(outFile, "", []string{"1"}, "approval", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if s := pageStampContent(t, ctx, 1); strings.Contains(s, "Approved") || !strings.Contains(s, "Confidential") {
+		t.Fatalf("%s: page 1: unexpected stamps\n", msg)
+	}
+	if s := pageStampContent(t, ctx, 2); !strings.Contains(s, "Approved") || !strings.Contains(s, "Confidential") {
+		t.Fatalf("%s: page 2: unexpected stamps\n", msg)
+	}
+
+	if err := api.RemoveWatermarkFile(outFile, "", []string{"1"}, "approval", nil); err == nil {
+		t.Fatalf("%s: expected error for removed watermark\n", msg)
+	}
+
+	// Removing all watermarks also covers named ones.
+	if err := api.RemoveWatermarksFile(outFile, "", nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if ok, err := api.HasWatermarksFile(outFile, nil); err != nil || ok {
+		t.Fatalf("%s: watermarks left: %v\n", msg, err)
+	}
+}
+
+func TestStampPageBox(t *testing.T) {
+	msg := "TestStampPageBox"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "StampPageBox.pdf")
+
+	// Asymmetric crop and trim box.
+	pb, err := api.PageBoundaries("crop:[20 30 580 800], trim:[50 40 500 780]", types.POINTS)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.AddBoxesFile(inFile, outFile, []string{"1"}, pb, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, desc := range []string{
+		"pos:bl, off:10 5, pagebox:trim",
+		"pos:tr, off:-10% -5%, pagebox:trim",
+		"pos:bl, pagebox:bleed", // falls back to the crop box.
+		"pos:bl, pagebox:media",
+	} {
+		wm, err := api.ShapeWatermark("rect 50 20", desc, true, false, types.POINTS)
+		if err != nil {
+			t.Fatalf("%s %s: %v\n", msg, desc, err)
+		}
+		if err := api.AddWatermarksFile(outFile, "", []string{"1"}, wm, nil); err != nil {
+			t.Fatalf("%s %s: %v\n", msg, desc, err)
+		}
+	}
+
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	s := pageContent(t, ctx, 1)
+	for _, want := range []string{
+		"60.00000 45.00000 cm",   // trim box lower left + offset
+		"405.00000 723.00000 cm", // trim box upper right - relative offset - shape
+		"20.00000 30.00000 cm",   // crop box lower left
+		"0.00000 0.00000 cm",     // media box lower left
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("%s: missing %q in:\n%s\n", msg, want, s)
+		}
+	}
+
+	if _, err := api.ShapeWatermark("rect 50 20", "pagebox:foo", true, false, types.POINTS); err == nil {
+		t.Fatalf("%s: expected error for invalid page box\n", msg)
+	}
+}
+
+func TestRemoveWatermarksInRegion(t *testing.T) {
+	msg := "TestRemoveWatermarksInRegion"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "RemoveRegion.pdf")
+
+	// Remove the title from page 1 while keeping the year at the bottom of the page.
+	r := types.NewRectangle(0, 400, 600, 842)
+	if err := api.RemoveWatermarksInRegionFile(inFile, outFile, []string{"1"}, r, true, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if err := api.ValidateFile(outFile, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := api.ReadContextFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	s, err := pdfcpu.ExtractPageText(ctx, 1, false)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if strings.Contains(s, "BY") || !strings.Contains(s, "18") {
+		t.Fatalf("%s: unexpected page text: %s\n", msg, s)
+	}
+
+	// Nothing to remove.
+	if err := api.RemoveWatermarksInRegionFile(inFile, outFile, []string{"1"}, types.NewRectangle(0, 0, 10, 10), false, nil); err == nil {
+		t.Fatalf("%s: expected error for empty region\n", msg)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+func checkArticles(t *testing.T, msg, fileName string, want []pdfcpu.Article) {
+	t.Helper()
+
+	if err := api.ValidateFile(fileName, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	aa, err := api.ArticlesFile(fileName, conf)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	if len(aa) != len(want) {
+		t.Fatalf("%s: want %d articles, got %d\n", msg, len(want), len(aa))
+	}
+
+	for i, a := range aa {
+		w := want[i]
+		if a.Title != w.Title || a.Author != w.Author || len(a.Beads) != len(w.Beads) {
+			t.Fatalf("%s: want\n%s\ngot\n%s\n", msg, w, a)
+		}
+		for j, b := range a.Beads {
+			if b.PageNr != w.Beads[j].PageNr || !b.Rect.Equals(*w.Beads[j].Rect) {
+				t.Fatalf("%s: want\n%s\ngot\n%s\n", msg, w, a)
+			}
+		}
+	}
+}
+
+func TestArticles(t *testing.T) {
+	msg := "TestArticles"
+	inFile := filepath.Join(inDir, "Walden.pdf")
+	outFile := filepath.Join(outDir, "WaldenArticles.pdf")
+
+	aa := []pdfcpu.Article{
+		{
+			Title:  "Economy",
+			Author: "Henry David Thoreau",
+			Beads: []pdfcpu.Bead{
+				{PageNr: 1, Rect: types.NewRectangle(50, 400, 290, 750)},
+				{PageNr: 1, Rect: types.NewRectangle(310, 400, 550, 750)},
+				{PageNr: 2, Rect: types.NewRectangle(50, 50, 550, 750)},
+			},
+		},
+		{
+			Title: "Sidebar",
+			Beads: []pdfcpu.Bead{{PageNr: 1, Rect: types.NewRectangle(50, 50, 550, 380)}},
+		},
+	}
+
+	if err := api.AddArticlesFile(inFile, outFile, aa, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	checkArticles(t, msg, outFile, aa)
+
+	// Append to existing article threads.
+	more := []pdfcpu.Article{{Title: "Addendum", Beads: []pdfcpu.Bead{{PageNr: 2, Rect: types.NewRectangle(0, 0, 100, 100)}}}}
+	if err := api.AddArticlesFile(outFile, "", more, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	checkArticles(t, msg, outFile, append(aa, more...))
+
+	invalid := []pdfcpu.Article{{Title: "Invalid", Beads: []pdfcpu.Bead{{PageNr: 3, Rect: types.NewRectangle(0, 0, 100, 100)}}}}
+	if err := api.AddArticlesFile(outFile, "", invalid, nil); err == nil {
+		t.Fatalf("%s: invalid page number should fail\n", msg)
+	}
+
+	if err := api.RemoveArticlesFile(outFile, "", nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	checkArticles(t, msg, outFile, nil)
+
+	if err := api.RemoveArticlesFile(outFile, "", nil); err != api.ErrNoArticles {
+		t.Fatalf("%s: want ErrNoArticles, got %v\n", msg, err)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/api"
+)
+
+func zipEntries(t *testing.T, msg string, buf *bytes.Buffer) []*zip.File {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	return zr.File
+}
+
+func TestSplitToZip(t *testing.T) {
+	msg := "TestSplitToZip"
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+
+	f, err := os.Open(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	n, err := api.PageCount(f, conf)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	var buf bytes.Buffer
+	if err := api.SplitToZip(f, &buf, "Acroforms2.pdf", "", 1, conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	zf := zipEntries(t, msg, &buf)
+	if len(zf) != n {
+		t.Fatalf("%s: want %d entries, got %d\n", msg, n, len(zf))
+	}
+
+	if zf[0].Name != "Acroforms2_1.pdf" {
+		t.Fatalf("%s: unexpected entry name: %s\n", msg, zf[0].Name)
+	}
+
+	rc, err := zf[0].Open()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer rc.Close()
+
+	bb, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err := api.Validate(bytes.NewReader(bb), conf); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+}
+
+func TestExtractImagesToZip(t *testing.T) {
+	msg := "TestExtractImagesToZip"
+	inFile := filepath.Join(inDir, "testImage.pdf")
+
+	f, err := os.Open(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	if err := api.ExtractImagesToZip(f, &buf, "testImage.pdf", nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	zf := zipEntries(t, msg, &buf)
+	if len(zf) == 0 {
+		t.Fatalf("%s: missing images\n", msg)
+	}
+
+	for _, f := range zf {
+		if !strings.HasPrefix(f.Name, "testImage_") {
+			t.Fatalf("%s: unexpected entry name: %s\n", msg, f.Name)
+		}
+	}
+}
+
+func TestExtractAttachmentsToZip(t *testing.T) {
+	msg := "TestExtractAttachmentsToZip"
+
+	fileName := filepath.Join(outDir, "attachmentsZip.pdf")
+	if err := copyFile(t, filepath.Join(inDir, "go.pdf"), fileName); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	attachment := filepath.Join(resDir, "test.wav")
+	if err := api.AddAttachmentsFile(fileName, "", []string{attachment}, false, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	if err := api.ExtractAttachmentsToZip(f, &buf, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	zf := zipEntries(t, msg, &buf)
+	if len(zf) != 1 || zf[0].Name != "test.wav" {
+		t.Fatalf("%s: unexpected zip entries: %v\n", msg, zf)
+	}
+
+	want, err := os.ReadFile(attachment)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	rc, err := zf[0].Open()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s: attachment content mismatch\n", msg)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// ErrNoArticles is returned when removing article threads from a file without any.
+var ErrNoArticles = errors.New("pdfcpu: no article threads available")
+
+// Articles returns the article threads of rs.
+func Articles(rs io.ReadSeeker, conf *model.Configuration) ([]pdfcpu.Article, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: Articles: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTARTICLES
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfcpu.Articles(ctx)
+}
+
+// ArticlesFile returns the article threads of inFile.
+func ArticlesFile(inFile string, conf *model.Configuration) ([]pdfcpu.Article, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Articles(f, conf)
+}
+
+// AddArticles appends article threads aa to rs and writes the result to w.
+func AddArticles(rs io.ReadSeeker, w io.Writer, aa []pdfcpu.Article, conf *model.Configuration) error {
+	if len(aa) == 0 {
+		return errors.New("pdfcpu: AddArticles: missing articles")
+	}
+	return modifyContext(rs, w, model.ADDARTICLES, conf, func(ctx *model.Context) error {
+		return pdfcpu.AddArticles(ctx, aa)
+	})
+}
+
+// AddArticlesFile appends article threads aa to inFile and writes the result to outFile.
+func AddArticlesFile(inFile, outFile string, aa []pdfcpu.Article, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return AddArticles(rs, w, aa, conf)
+	})
+}
+
+// RemoveArticles removes all article threads from rs and writes the result to w.
+func RemoveArticles(rs io.ReadSeeker, w io.Writer, conf *model.Configuration) error {
+	return modifyContext(rs, w, model.REMOVEARTICLES, conf, func(ctx *model.Context) error {
+		ok, err := pdfcpu.RemoveArticles(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNoArticles
+		}
+		return nil
+	})
+}
+
+// RemoveArticlesFile removes all article threads from inFile and writes the result to outFile.
+func RemoveArticlesFile(inFile, outFile string, conf *model.Configuration) error {
+	return processFile(inFile, outFile, func(rs io.ReadSeeker, w io.Writer) error {
+		return RemoveArticles(rs, w, conf)
+	})
+}
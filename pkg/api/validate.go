@@ -60,6 +60,10 @@ func Validate(rs io.ReadSeeker, conf *model.Configuration) error {
 		err = errors.Wrap(err, fmt.Sprintf("validation error (obj#:%d)%s", ctx.CurObj, s))
 	}
 
+	for _, w := range ctx.Warnings {
+		log.CLI.Printf("warning: %s\n", w)
+	}
+
 	dur2 := time.Since(from2).Seconds()
 	dur := time.Since(from1).Seconds()
 
@@ -174,3 +178,33 @@ func DumpObjectFile(inFile string, objNr int, hex bool, conf *model.Configuratio
 
 	return DumpObject(f, objNr, hex, conf)
 }
+
+// ObjectProvenances returns where the objects of rs were read from sorted by object number.
+func ObjectProvenances(rs io.ReadSeeker, conf *model.Configuration) ([]model.ObjectProvenance, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: ObjectProvenances: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.DUMP
+
+	ctx, err := ReadContext(rs, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.ObjectProvenances(), nil
+}
+
+// ObjectProvenancesFile returns where the objects of inFile were read from sorted by object number.
+func ObjectProvenancesFile(inFile string, conf *model.Configuration) ([]model.ObjectProvenance, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ObjectProvenances(f, conf)
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/form"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+var ErrNoXFA = errors.New("pdfcpu: no XFA form available")
+
+// XFA returns the XFA form data of rs or nil if there is none.
+func XFA(rs io.ReadSeeker, conf *model.Configuration) (*form.XFA, error) {
+	if rs == nil {
+		return nil, errors.New("pdfcpu: XFA: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTXFA
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	return form.ReadXFA(ctx)
+}
+
+// XFAFile returns the XFA form data of inFile or nil if there is none.
+func XFAFile(inFile string, conf *model.Configuration) (*form.XFA, error) {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return XFA(f, conf)
+}
+
+// ExtractXFA writes the XDP packets of the XFA form of rs into outDir.
+func ExtractXFA(rs io.ReadSeeker, outDir, fileName string, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: ExtractXFA: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.EXTRACTXFA
+
+	ctx, _, _, err := readAndValidate(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	xfa, err := form.ReadXFA(ctx)
+	if err != nil {
+		return err
+	}
+	if xfa == nil {
+		return ErrNoXFA
+	}
+
+	fileName = strings.TrimSuffix(filepath.Base(fileName), ".pdf")
+
+	for i, p := range xfa.Packets {
+		// Packet names may contain namespace prefixes and are not necessarily unique.
+		name := strings.NewReplacer(":", "_", "/", "_").Replace(p.Name)
+		outFile := filepath.Join(outDir, fmt.Sprintf("%s_XFA_%02d_%s.xml", fileName, i+1, name))
+		logWritingTo(outFile)
+		if err := os.WriteFile(outFile, p.Content, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExtractXFAFile writes the XDP packets of the XFA form of inFile into outDir.
+func ExtractXFAFile(inFile, outDir string, conf *model.Configuration) error {
+	f, err := os.Open(inFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if log.CLIEnabled() {
+		log.CLI.Printf("extracting XFA from %s into %s/ ...\n", inFile, outDir)
+	}
+
+	return ExtractXFA(f, outDir, filepath.Base(inFile), conf)
+}
+
+// RemoveXFA removes the XFA form of rs leaving the AcroForm in place and writes the result to w.
+func RemoveXFA(rs io.ReadSeeker, w io.Writer, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: RemoveXFA: missing rs")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.REMOVEXFA
+
+	ctx, _, _, _, err := ReadValidateAndOptimize(rs, conf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	ok, err := form.RemoveXFA(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoXFA
+	}
+
+	if conf.ValidationMode != model.ValidationNone {
+		if err = ValidateContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// RemoveXFAFile removes the XFA form of inFile leaving the AcroForm in place and writes the result to outFile.
+func RemoveXFAFile(inFile, outFile string, conf *model.Configuration) (err error) {
+	var f1, f2 *os.File
+
+	if f1, err = os.Open(inFile); err != nil {
+		return err
+	}
+
+	tmpFile := inFile + ".tmp"
+	if outFile != "" && inFile != outFile {
+		tmpFile = outFile
+		logWritingTo(outFile)
+	} else {
+		logWritingTo(inFile)
+	}
+	if f2, err = os.Create(tmpFile); err != nil {
+		f1.Close()
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f2.Close()
+			f1.Close()
+			if outFile == "" || inFile == outFile {
+				os.Remove(tmpFile)
+			}
+			return
+		}
+		if err = f2.Close(); err != nil {
+			return
+		}
+		if err = f1.Close(); err != nil {
+			return
+		}
+		if outFile == "" || inFile == outFile {
+			err = os.Rename(tmpFile, inFile)
+		}
+	}()
+
+	return RemoveXFA(f1, f2, conf)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"archive/zip"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+func writeZipEntry(zw *zip.Writer, name string, r io.Reader) error {
+	return writeZipEntryWithHeader(zw, &zip.FileHeader{Name: name, Method: zip.Deflate}, r)
+}
+
+func writeZipEntryWithHeader(zw *zip.Writer, fh *zip.FileHeader, r io.Reader) error {
+	if log.CLIEnabled() {
+		log.CLI.Printf("zipping %s\n", fh.Name)
+	}
+	w, err := zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func pageSpanZipWriter(zw *zip.Writer) pageSpanWriter {
+	return func(ctx *model.Context, from, thru int, fileName string) error {
+		ps, err := pageSpan(ctx, from, thru)
+		if err != nil {
+			return err
+		}
+		return writeZipEntry(zw, fileName, ps.Reader)
+	}
+}
+
+// SplitToZip splits the PDF stream read from rs obeying given split span
+// and writes the resulting PDF files as a zip archive to w.
+// Output file names are generated using tmpl, see SplitWithTemplate.
+// If span == 1 splitting results in single page PDFs.
+// If span == 0 we split along given bookmarks (level 1 only).
+func SplitToZip(rs io.ReadSeeker, w io.Writer, fileName, tmpl string, span int, conf *model.Configuration) error {
+	if rs == nil {
+		return errors.New("pdfcpu: SplitToZip: missing rs")
+	}
+
+	if w == nil {
+		return errors.New("pdfcpu: SplitToZip: missing w")
+	}
+
+	if tmpl != "" {
+		if err := pdfcpu.ValidateFileNameTemplate(tmpl); err != nil {
+			return err
+		}
+	}
+
+	ctx, err := context(rs, conf)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	write := pageSpanZipWriter(zw)
+
+	if span == 0 {
+		err = writePageSpansSplitAlongBookmarks(ctx, 1, fileName, tmpl, write)
+	} else {
+		err = writePageSpans(ctx, span, fileName, tmpl, write)
+	}
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// ExtractImagesToZip extracts embedded image resources from rs for selected pages
+// and writes them as a zip archive to w.
+func ExtractImagesToZip(rs io.ReadSeeker, w io.Writer, fileName string, selectedPages []string, conf *model.Configuration) error {
+	if w == nil {
+		return errors.New("pdfcpu: ExtractImagesToZip: missing w")
+	}
+
+	fileName = strings.TrimSuffix(filepath.Base(fileName), ".pdf")
+	zw := zip.NewWriter(w)
+
+	digest := func(img model.Image, singleImgPerPage bool, maxPageDigits int) error {
+		if img.Reader == nil {
+			return nil
+		}
+		return writeZipEntry(zw, pdfcpu.ImageFileName(img, fileName, maxPageDigits), img)
+	}
+
+	if err := ExtractImages(rs, selectedPages, digest, conf); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// ExtractAttachmentsToZip extracts embedded files from a PDF context read from rs
+// and writes them as a zip archive to w.
+func ExtractAttachmentsToZip(rs io.ReadSeeker, w io.Writer, fileNames []string, conf *model.Configuration) error {
+	if w == nil {
+		return errors.New("pdfcpu: ExtractAttachmentsToZip: missing w")
+	}
+
+	aa, err := ExtractAttachmentsRaw(rs, "", fileNames, conf)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	for _, a := range aa {
+		// Do not allow attachments to escape the archive root.
+		fh := &zip.FileHeader{Name: path.Base(filepath.ToSlash(a.FileName)), Method: zip.Deflate}
+		if a.ModTime != nil {
+			fh.Modified = *a.ModTime
+		}
+		if err := writeZipEntryWithHeader(zw, fh, a); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
@@ -18,7 +18,10 @@ limitations under the License.
 package cli
 
 import (
+	"strings"
+
 	"github.com/mjuen/pdfcpu/pkg/api"
+	"github.com/mjuen/pdfcpu/pkg/font"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 	"github.com/pkg/errors"
 )
@@ -34,7 +37,14 @@ func Validate(cmd *Command) ([]string, error) {
 
 // Optimize inFile and write result to outFile.
 func Optimize(cmd *Command) ([]string, error) {
-	return nil, api.OptimizeFile(*cmd.InFile, *cmd.OutFile, cmd.Conf)
+	if !cmd.BoolVal {
+		return nil, api.OptimizeFile(*cmd.InFile, *cmd.OutFile, cmd.Conf)
+	}
+	r, err := api.OptimizeFileWithReport(*cmd.InFile, *cmd.OutFile, 10, cmd.Conf)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(r.String(), "\n"), "\n"), nil
 }
 
 // Encrypt inFile and write result to outFile.
@@ -69,7 +79,16 @@ func SetPermissions(cmd *Command) ([]string, error) {
 
 // Split inFile into single page PDFs and write result files to outDir.
 func Split(cmd *Command) ([]string, error) {
-	return nil, api.SplitFile(*cmd.InFile, *cmd.OutDir, cmd.IntVal, cmd.Conf)
+	if cmd.BoolVal {
+		return nil, api.SplitBySizeFile(*cmd.InFile, *cmd.OutDir, int64(cmd.IntVal), cmd.Conf)
+	}
+	if len(cmd.StringVals) > 0 {
+		return nil, api.SplitFileByText(*cmd.InFile, *cmd.OutDir, cmd.StringVals[0], cmd.StringVal, cmd.Conf)
+	}
+	if len(cmd.IntVals) > 0 {
+		return nil, api.SplitFileByBookmarks(*cmd.InFile, *cmd.OutDir, cmd.StringVal, cmd.IntVals[0], cmd.Conf)
+	}
+	return nil, api.SplitFileWithTemplate(*cmd.InFile, *cmd.OutDir, cmd.StringVal, cmd.IntVal, cmd.Conf)
 }
 
 // Trim inFile and write result to outFile.
@@ -89,6 +108,12 @@ func AddWatermarks(cmd *Command) ([]string, error) {
 
 // RemoveWatermarks remove watermarks or stamps from selected pages of inFile and writes the result to outFile.
 func RemoveWatermarks(cmd *Command) ([]string, error) {
+	if cmd.Box != nil {
+		return nil, api.RemoveWatermarksInRegionFile(*cmd.InFile, *cmd.OutFile, cmd.PageSelection, cmd.Box.Rect, cmd.BoolVal, cmd.Conf)
+	}
+	if cmd.StringVal != "" {
+		return nil, api.RemoveWatermarkFile(*cmd.InFile, *cmd.OutFile, cmd.PageSelection, cmd.StringVal, cmd.Conf)
+	}
 	return nil, api.RemoveWatermarksFile(*cmd.InFile, *cmd.OutFile, cmd.PageSelection, cmd.Conf)
 }
 
@@ -110,12 +135,10 @@ func ImportImages(cmd *Command) ([]string, error) {
 }
 
 // InsertPages inserts a blank page before or after each selected page.
+// In recto mode blank pages get inserted only where needed for selected pages to start on a recto page.
 func InsertPages(cmd *Command) ([]string, error) {
-	before := true
-	if cmd.Mode == model.INSERTPAGESAFTER {
-		before = false
-	}
-	return nil, api.InsertPagesFile(*cmd.InFile, *cmd.OutFile, cmd.PageSelection, before, cmd.Conf)
+	bp := &model.BlankPages{Before: cmd.Mode == model.INSERTPAGESBEFORE, Recto: cmd.BoolVal}
+	return nil, api.InsertBlankPagesFile(*cmd.InFile, *cmd.OutFile, cmd.PageSelection, bp, cmd.Conf)
 }
 
 // RemovePages removes selected pages.
@@ -123,6 +146,11 @@ func RemovePages(cmd *Command) ([]string, error) {
 	return nil, api.RemovePagesFile(*cmd.InFile, *cmd.OutFile, cmd.PageSelection, cmd.Conf)
 }
 
+// InsertPagesFrom inserts selected pages of a source file into a target file following some page.
+func InsertPagesFrom(cmd *Command) ([]string, error) {
+	return nil, api.InsertPagesFromFile(cmd.InFiles[0], cmd.InFiles[1], cmd.IntVal, cmd.PageSelection, cmd.Conf)
+}
+
 // MergeCreate merges inFiles in the order specified and writes the result to outFile.
 func MergeCreate(cmd *Command) ([]string, error) {
 	return nil, api.MergeCreateFile(cmd.InFiles, *cmd.OutFile, cmd.Conf)
@@ -145,7 +173,7 @@ func ExtractFonts(cmd *Command) ([]string, error) {
 
 // ExtractPages generates single page PDF files from inFile in outDir for selected pages.
 func ExtractPages(cmd *Command) ([]string, error) {
-	return nil, api.ExtractPagesFile(*cmd.InFile, *cmd.OutDir, cmd.PageSelection, cmd.Conf)
+	return nil, api.ExtractPagesFileWithTemplate(*cmd.InFile, *cmd.OutDir, cmd.StringVal, cmd.PageSelection, cmd.Conf)
 }
 
 // ExtractContent dumps "PDF source" files from inFile into outDir for selected pages.
@@ -153,6 +181,11 @@ func ExtractContent(cmd *Command) ([]string, error) {
 	return nil, api.ExtractContentFile(*cmd.InFile, *cmd.OutDir, cmd.PageSelection, cmd.Conf)
 }
 
+// ExtractText dumps the text of selected pages of inFile into outDir.
+func ExtractText(cmd *Command) ([]string, error) {
+	return nil, api.ExtractTextFile(*cmd.InFile, *cmd.OutDir, cmd.PageSelection, cmd.BoolVal, cmd.Conf)
+}
+
 // ExtractMetadata dumps all metadata dict entries for inFile into outDir.
 func ExtractMetadata(cmd *Command) ([]string, error) {
 	return nil, api.ExtractMetadataFile(*cmd.InFile, *cmd.OutDir, cmd.Conf)
@@ -163,6 +196,11 @@ func ListAttachments(cmd *Command) ([]string, error) {
 	return ListAttachmentsFile(*cmd.InFile, cmd.Conf)
 }
 
+// AuditAttachments returns encryption status, size, SHA-256 and declared MIME type of the attachments of inFile.
+func AuditAttachments(cmd *Command) ([]string, error) {
+	return AuditAttachmentsFile(*cmd.InFile, cmd.BoolVal, cmd.Conf)
+}
+
 // AddAttachments embeds inFiles into a PDF context read from inFile and writes the result to outFile.
 func AddAttachments(cmd *Command) ([]string, error) {
 	return nil, api.AddAttachmentsFile(*cmd.InFile, *cmd.OutFile, cmd.InFiles, cmd.Mode == model.ADDATTACHMENTSPORTFOLIO, cmd.Conf)
@@ -183,6 +221,11 @@ func ListInfo(cmd *Command) ([]string, error) {
 	return ListInfoFiles(cmd.InFiles, cmd.PageSelection, cmd.BoolVal, cmd.Conf)
 }
 
+// ListPageStats returns content metrics for selected pages of inFile as JSON.
+func ListPageStats(cmd *Command) ([]string, error) {
+	return ListPageStatsFile(*cmd.InFile, cmd.PageSelection, cmd.Conf)
+}
+
 // CreateCheatSheetsFonts creates single page PDF cheat sheets for user fonts in current dir.
 func CreateCheatSheetsFonts(cmd *Command) ([]string, error) {
 	return nil, api.CreateCheatSheetsUserFonts(cmd.InFiles)
@@ -190,11 +233,21 @@ func CreateCheatSheetsFonts(cmd *Command) ([]string, error) {
 
 // ListFonts gathers information about supported fonts and returns the result as []string.
 func ListFonts(cmd *Command) ([]string, error) {
+	if cmd.InFile != nil {
+		return ListFontInfosFile(*cmd.InFile, cmd.Conf)
+	}
 	return api.ListFonts()
 }
 
 // InstallFonts installs True Type fonts into the pdfcpu pconfig dir.
 func InstallFonts(cmd *Command) ([]string, error) {
+	if cmd.StringVal != "" {
+		coords, err := font.ParseAxisCoordinates(cmd.StringVal)
+		if err != nil {
+			return nil, err
+		}
+		return nil, api.InstallFontInstances(cmd.InFiles, coords)
+	}
 	return nil, api.InstallFonts(cmd.InFiles)
 }
 
@@ -233,6 +286,11 @@ func Collect(cmd *Command) ([]string, error) {
 	return nil, api.CollectFile(*cmd.InFile, *cmd.OutFile, cmd.PageSelection, cmd.Conf)
 }
 
+// ReorderPages creates a predefined sequence of selected pages of inFile and writes result to outFile.
+func ReorderPages(cmd *Command) ([]string, error) {
+	return nil, api.ReorderPagesForModeFile(*cmd.InFile, *cmd.OutFile, cmd.PageSelection, cmd.PageOrder, cmd.IntVal, cmd.Conf)
+}
+
 // ListBoxes returns inFile's page boundaries.
 func ListBoxes(cmd *Command) ([]string, error) {
 	return ListBoxesFile(*cmd.InFile, cmd.PageSelection, cmd.PageBoundaries, cmd.Conf)
@@ -308,6 +366,38 @@ func ResetFormFields(cmd *Command) ([]string, error) {
 	return nil, api.ResetFormFieldsFile(*cmd.InFile, *cmd.OutFile, cmd.StringVals, cmd.Conf)
 }
 
+// FlattenForm burns the form fields of inFile into the page content and removes the form.
+func FlattenForm(cmd *Command) ([]string, error) {
+	return nil, api.FlattenFormFile(*cmd.InFile, *cmd.OutFile, cmd.Conf)
+}
+
+// RenameFormFields renames the form fields of inFile as mapped by inFileJSON.
+func RenameFormFields(cmd *Command) ([]string, error) {
+	return nil, api.RenameFormFieldsJSONFile(*cmd.InFile, *cmd.InFileJSON, *cmd.OutFile, cmd.Conf)
+}
+
+// ListXFA returns a report on the XFA form of inFile.
+func ListXFA(cmd *Command) ([]string, error) {
+	xfa, err := api.XFAFile(*cmd.InFile, cmd.Conf)
+	if err != nil {
+		return nil, err
+	}
+	if xfa == nil {
+		return []string{"no XFA form"}, nil
+	}
+	return []string{xfa.String()}, nil
+}
+
+// ExtractXFA writes the XDP packets of the XFA form of inFile into outDir.
+func ExtractXFA(cmd *Command) ([]string, error) {
+	return nil, api.ExtractXFAFile(*cmd.InFile, *cmd.OutDir, cmd.Conf)
+}
+
+// RemoveXFA removes the XFA form of inFile leaving the AcroForm in place.
+func RemoveXFA(cmd *Command) ([]string, error) {
+	return nil, api.RemoveXFAFile(*cmd.InFile, *cmd.OutFile, cmd.Conf)
+}
+
 // ExportFormFields returns a representation of inFile's form as outFileJSON.
 func ExportFormFields(cmd *Command) ([]string, error) {
 	return nil, api.ExportFormFile(*cmd.InFile, *cmd.OutFileJSON, cmd.Conf)
@@ -19,6 +19,7 @@ package cli
 import (
 	"io"
 
+	"github.com/mjuen/pdfcpu/pkg/api"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 )
@@ -38,6 +39,7 @@ type Command struct {
 	PWNew          *string
 	IntVal         int
 	BoolVal        bool
+	StringVal      string
 	IntVals        []int
 	StringVals     []string
 	StringMap      map[string]string
@@ -51,6 +53,7 @@ type Command struct {
 	PageBoundaries *model.PageBoundaries
 	Resize         *model.Resize
 	Watermark      *model.Watermark
+	PageOrder      api.PageOrderMode
 	Conf           *model.Configuration
 }
 
@@ -64,6 +67,9 @@ var cmdMap = map[model.CommandMode]func(cmd *Command) ([]string, error){
 	model.EXTRACTFONTS:            ExtractFonts,
 	model.EXTRACTPAGES:            ExtractPages,
 	model.EXTRACTCONTENT:          ExtractContent,
+	model.EXTRACTTEXT:             ExtractText,
+	model.LISTPAGESTATS:           ListPageStats,
+	model.AUDITATTACHMENTS:        processAttachments,
 	model.EXTRACTMETADATA:         ExtractMetadata,
 	model.TRIM:                    Trim,
 	model.ADDWATERMARKS:           AddWatermarks,
@@ -83,6 +89,7 @@ var cmdMap = map[model.CommandMode]func(cmd *Command) ([]string, error){
 	model.INSERTPAGESBEFORE:       processPages,
 	model.INSERTPAGESAFTER:        processPages,
 	model.REMOVEPAGES:             processPages,
+	model.INSERTPAGESFROM:         processPages,
 	model.ROTATE:                  Rotate,
 	model.NUP:                     NUp,
 	model.BOOKLET:                 Booklet,
@@ -97,6 +104,7 @@ var cmdMap = map[model.CommandMode]func(cmd *Command) ([]string, error){
 	model.ADDPROPERTIES:           processProperties,
 	model.REMOVEPROPERTIES:        processProperties,
 	model.COLLECT:                 Collect,
+	model.REORDERPAGES:            ReorderPages,
 	model.LISTBOXES:               processPageBoundaries,
 	model.ADDBOXES:                processPageBoundaries,
 	model.REMOVEBOXES:             processPageBoundaries,
@@ -114,6 +122,11 @@ var cmdMap = map[model.CommandMode]func(cmd *Command) ([]string, error){
 	model.EXPORTFORMFIELDS:        processForm,
 	model.FILLFORMFIELDS:          processForm,
 	model.MULTIFILLFORMFIELDS:     processForm,
+	model.FLATTENFORM:             processForm,
+	model.RENAMEFORMFIELDS:        processForm,
+	model.LISTXFA:                 processForm,
+	model.EXTRACTXFA:              processForm,
+	model.REMOVEXFA:               processForm,
 	model.RESIZE:                  Resize,
 	model.POSTER:                  Poster,
 	model.NDOWN:                   NDown,
@@ -163,6 +176,49 @@ func SplitCommand(inFile, dirNameOut string, span int, conf *model.Configuration
 		Conf:   conf}
 }
 
+// SplitByBookmarksCommand creates a new command to split a file along its bookmarks down to given outline level.
+func SplitByBookmarksCommand(inFile, dirNameOut string, level int, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.SPLIT
+	return &Command{
+		Mode:    model.SPLIT,
+		InFile:  &inFile,
+		OutDir:  &dirNameOut,
+		IntVals: []int{level},
+		Conf:    conf}
+}
+
+// SplitByTextCommand creates a new command to split a file before every page whose text matches pattern.
+func SplitByTextCommand(inFile, dirNameOut, pattern string, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.SPLIT
+	return &Command{
+		Mode:       model.SPLIT,
+		InFile:     &inFile,
+		OutDir:     &dirNameOut,
+		StringVals: []string{pattern},
+		Conf:       conf}
+}
+
+// SplitBySizeCommand creates a new command to split a file into parts not exceeding maxBytes each.
+func SplitBySizeCommand(inFile, dirNameOut string, maxBytes int, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.SPLIT
+	return &Command{
+		Mode:    model.SPLIT,
+		InFile:  &inFile,
+		OutDir:  &dirNameOut,
+		IntVal:  maxBytes,
+		BoolVal: true,
+		Conf:    conf}
+}
+
 // MergeCreateCommand creates a new command to merge files.
 // Outfile will be created. An existing outFile will be overwritten.
 func MergeCreateCommand(inFiles []string, outFile string, conf *model.Configuration) *Command {
@@ -249,6 +305,21 @@ func ExtractContentCommand(inFile string, outDir string, pageSelection []string,
 		Conf:          conf}
 }
 
+// ExtractTextCommand creates a new command to extract page text.
+func ExtractTextCommand(inFile string, outDir string, pageSelection []string, heal bool, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.EXTRACTTEXT
+	return &Command{
+		Mode:          model.EXTRACTTEXT,
+		InFile:        &inFile,
+		OutDir:        &outDir,
+		PageSelection: pageSelection,
+		BoolVal:       heal,
+		Conf:          conf}
+}
+
 // ExtractMetadataCommand creates a new command to extract metadata streams.
 func ExtractMetadataCommand(inFile string, outDir string, conf *model.Configuration) *Command {
 	if conf == nil {
@@ -288,6 +359,19 @@ func ListAttachmentsCommand(inFile string, conf *model.Configuration) *Command {
 		Conf:   conf}
 }
 
+// AuditAttachmentsCommand creates a new command to report encryption status, size and hash of attachments.
+func AuditAttachmentsCommand(inFile string, json bool, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.AUDITATTACHMENTS
+	return &Command{
+		Mode:    model.AUDITATTACHMENTS,
+		InFile:  &inFile,
+		BoolVal: json,
+		Conf:    conf}
+}
+
 // AddAttachmentsCommand creates a new command to add attachments.
 func AddAttachmentsCommand(inFile, outFile string, fileNames []string, conf *model.Configuration) *Command {
 	if conf == nil {
@@ -469,6 +553,7 @@ func ImportImagesCommand(imageFiles []string, outFile string, imp *pdfcpu.Import
 }
 
 // InsertPagesCommand creates a new command to insert a blank page before or after selected pages.
+// Mode "recto" inserts a blank page before selected pages only where needed for them to start on a recto page.
 func InsertPagesCommand(inFile, outFile string, pageSelection []string, conf *model.Configuration, mode string) *Command {
 	if conf == nil {
 		conf = model.NewDefaultConfiguration()
@@ -483,6 +568,7 @@ func InsertPagesCommand(inFile, outFile string, pageSelection []string, conf *mo
 		InFile:        &inFile,
 		OutFile:       &outFile,
 		PageSelection: pageSelection,
+		BoolVal:       mode == "recto",
 		Conf:          conf}
 }
 
@@ -500,6 +586,20 @@ func RemovePagesCommand(inFile, outFile string, pageSelection []string, conf *mo
 		Conf:          conf}
 }
 
+// InsertPagesFromCommand creates a new command to insert selected pages of srcFile into inFile following page afterPage.
+func InsertPagesFromCommand(inFile, srcFile string, afterPage int, pageSelection []string, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.INSERTPAGESFROM
+	return &Command{
+		Mode:          model.INSERTPAGESFROM,
+		InFiles:       []string{inFile, srcFile},
+		IntVal:        afterPage,
+		PageSelection: pageSelection,
+		Conf:          conf}
+}
+
 // RotateCommand creates a new command to rotate pages.
 func RotateCommand(inFile, outFile string, rotation int, pageSelection []string, conf *model.Configuration) *Command {
 	if conf == nil {
@@ -559,6 +659,19 @@ func InfoCommand(inFiles []string, pageSelection []string, json bool, conf *mode
 		Conf:          conf}
 }
 
+// PageStatsCommand creates a new command to output page content metrics as JSON.
+func PageStatsCommand(inFile string, pageSelection []string, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTPAGESTATS
+	return &Command{
+		Mode:          model.LISTPAGESTATS,
+		InFile:        &inFile,
+		PageSelection: pageSelection,
+		Conf:          conf}
+}
+
 // ListFontsCommand returns a list of supported fonts.
 func ListFontsCommand(conf *model.Configuration) *Command {
 	if conf == nil {
@@ -570,6 +683,18 @@ func ListFontsCommand(conf *model.Configuration) *Command {
 		Conf: conf}
 }
 
+// ListFontInfosCommand returns a list of fonts embedded in inFile including their embedding permissions.
+func ListFontInfosCommand(inFile string, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.LISTFONTS
+	return &Command{
+		Mode:   model.LISTFONTS,
+		InFile: &inFile,
+		Conf:   conf}
+}
+
 // InstallFontsCommand installs true type fonts for embedding.
 func InstallFontsCommand(fontFiles []string, conf *model.Configuration) *Command {
 	if conf == nil {
@@ -582,6 +707,19 @@ func InstallFontsCommand(fontFiles []string, conf *model.Configuration) *Command
 		Conf:    conf}
 }
 
+// InstallFontInstancesCommand installs static instances of variable true type fonts at axis coordinates (eg. "wght:700, wdth:75") for embedding.
+func InstallFontInstancesCommand(fontFiles []string, axes string, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.INSTALLFONTS
+	return &Command{
+		Mode:      model.INSTALLFONTS,
+		InFiles:   fontFiles,
+		StringVal: axes,
+		Conf:      conf}
+}
+
 // CreateCheatSheetsFontsCommand creates single page PDF cheat sheets in current dir.
 func CreateCheatSheetsFontsCommand(fontFiles []string, conf *model.Configuration) *Command {
 	if conf == nil {
@@ -688,6 +826,22 @@ func CollectCommand(inFile, outFile string, pageSelection []string, conf *model.
 		Conf:          conf}
 }
 
+// ReorderPagesCommand creates a new command to create a predefined sequence of selected pages.
+func ReorderPagesCommand(inFile, outFile string, pageSelection []string, mode api.PageOrderMode, n int, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.REORDERPAGES
+	return &Command{
+		Mode:          model.REORDERPAGES,
+		InFile:        &inFile,
+		OutFile:       &outFile,
+		PageSelection: pageSelection,
+		PageOrder:     mode,
+		IntVal:        n,
+		Conf:          conf}
+}
+
 // ListBoxesCommand creates a new command to list page boundaries for selected pages.
 func ListBoxesCommand(inFile string, pageSelection []string, pb *model.PageBoundaries, conf *model.Configuration) *Command {
 	if conf == nil {
@@ -884,6 +1038,63 @@ func ResetFormCommand(inFile, outFile string, fieldIDs []string, conf *model.Con
 		Conf:       conf}
 }
 
+// FlattenFormCommand creates a new command to flatten a PDF form.
+func FlattenFormCommand(inFile, outFile string, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.FLATTENFORM
+	return &Command{
+		Mode:    model.FLATTENFORM,
+		InFile:  &inFile,
+		OutFile: &outFile,
+		Conf:    conf}
+}
+
+// RenameFormCommand creates a new command to rename PDF form fields as mapped by a JSON file.
+func RenameFormCommand(inFilePDF, inFileJSON, outFilePDF string, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.RENAMEFORMFIELDS
+	return &Command{
+		Mode:       model.RENAMEFORMFIELDS,
+		InFile:     &inFilePDF,
+		InFileJSON: &inFileJSON,
+		OutFile:    &outFilePDF,
+		Conf:       conf}
+}
+
+// XFAFormCommand creates a new command to inspect the XFA form of a PDF or to extract its XDP packets into outDir.
+func XFAFormCommand(inFile, outDir string, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	mode := model.LISTXFA
+	if outDir != "" {
+		mode = model.EXTRACTXFA
+	}
+	conf.Cmd = mode
+	return &Command{
+		Mode:   mode,
+		InFile: &inFile,
+		OutDir: &outDir,
+		Conf:   conf}
+}
+
+// RemoveXFAFormCommand creates a new command to remove the XFA form of a PDF.
+func RemoveXFAFormCommand(inFile, outFile string, conf *model.Configuration) *Command {
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+	conf.Cmd = model.REMOVEXFA
+	return &Command{
+		Mode:    model.REMOVEXFA,
+		InFile:  &inFile,
+		OutFile: &outFile,
+		Conf:    conf}
+}
+
 // ExportFormCommand creates a new command to export a PDF form.
 func ExportFormCommand(inFilePDF, outFileJSON string, conf *model.Configuration) *Command {
 	if conf == nil {
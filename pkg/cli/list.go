@@ -18,6 +18,7 @@ limitations under the License.
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -33,6 +34,52 @@ import (
 	"github.com/pkg/errors"
 )
 
+func attachmentInfosJSON(aii []model.AttachmentInfo) ([]string, error) {
+	if aii == nil {
+		aii = []model.AttachmentInfo{}
+	}
+	bb, err := json.MarshalIndent(aii, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(bb)}, nil
+}
+
+// AuditAttachmentsFile returns encryption status, size, SHA-256 and declared MIME type of the attachments of inFile.
+func AuditAttachmentsFile(inFile string, json bool, conf *model.Configuration) ([]string, error) {
+	aii, err := api.AttachmentInfosFile(inFile, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if json {
+		return attachmentInfosJSON(aii)
+	}
+
+	if len(aii) == 0 {
+		return []string{"no attachments available"}, nil
+	}
+
+	var ss []string
+	for _, ai := range aii {
+		enc := "none"
+		if ai.Encrypted {
+			enc = ai.Encryption
+		}
+		mimeType := ai.MimeType
+		if mimeType == "" {
+			mimeType = "-"
+		}
+		ss = append(ss, ai.FileName)
+		ss = append(ss, fmt.Sprintf("%12s: %d bytes", "size", ai.Size))
+		ss = append(ss, fmt.Sprintf("%12s: %s", "sha256", ai.SHA256))
+		ss = append(ss, fmt.Sprintf("%12s: %s", "mime type", mimeType))
+		ss = append(ss, fmt.Sprintf("%12s: %s", "encryption", enc))
+	}
+
+	return ss, nil
+}
+
 func listAttachments(rs io.ReadSeeker, conf *model.Configuration, withDesc, sorted bool) ([]string, error) {
 	if rs == nil {
 		return nil, errors.New("pdfcpu: listAttachments: missing rs")
@@ -68,6 +115,25 @@ func listAttachments(rs io.ReadSeeker, conf *model.Configuration, withDesc, sort
 	return ss, nil
 }
 
+// ListFontInfosFile returns a list of fonts embedded in inFile including their embedding permissions.
+func ListFontInfosFile(inFile string, conf *model.Configuration) ([]string, error) {
+	ff, err := api.FontInfosFile(inFile, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ff) == 0 {
+		return []string{"no embedded fonts available"}, nil
+	}
+
+	ss := []string{fmt.Sprintf("%-7s %-30s %-12s %-9s %s", "obj", "Fontname", "Subtype", "FontFile", "License")}
+	for _, fi := range ff {
+		ss = append(ss, fi.String())
+	}
+
+	return ss, nil
+}
+
 // ListAttachmentsFile returns a list of embedded file attachments of inFile with optional description.
 func ListAttachmentsFile(inFile string, conf *model.Configuration) ([]string, error) {
 	f, err := os.Open(inFile)
@@ -325,6 +391,15 @@ func listInfoFilesJSON(inFiles []string, selectedPages []string, conf *model.Con
 	return []string{string(bb)}, nil
 }
 
+// ListPageStatsFile returns content metrics for selected pages of inFile as JSON.
+func ListPageStatsFile(inFile string, selectedPages []string, conf *model.Configuration) ([]string, error) {
+	var buf bytes.Buffer
+	if err := api.PageStatsJSONFile(inFile, &buf, selectedPages, conf); err != nil {
+		return nil, err
+	}
+	return []string{buf.String()}, nil
+}
+
 // ListInfoFiles returns formatted information about inFiles.
 func ListInfoFiles(inFiles []string, selectedPages []string, json bool, conf *model.Configuration) ([]string, error) {
 
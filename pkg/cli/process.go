@@ -65,6 +65,9 @@ func processAttachments(cmd *Command) (out []string, err error) {
 
 	case model.EXTRACTATTACHMENTS:
 		out, err = ExtractAttachments(cmd)
+
+	case model.AUDITATTACHMENTS:
+		out, err = AuditAttachments(cmd)
 	}
 
 	return out, err
@@ -144,6 +147,9 @@ func processPages(cmd *Command) (out []string, err error) {
 
 	case model.REMOVEPAGES:
 		return RemovePages(cmd)
+
+	case model.INSERTPAGESFROM:
+		return InsertPagesFrom(cmd)
 	}
 
 	return nil, nil
@@ -204,6 +210,21 @@ func processForm(cmd *Command) (out []string, err error) {
 
 	case model.MULTIFILLFORMFIELDS:
 		return MultiFillFormFields(cmd)
+
+	case model.FLATTENFORM:
+		return FlattenForm(cmd)
+
+	case model.RENAMEFORMFIELDS:
+		return RenameFormFields(cmd)
+
+	case model.LISTXFA:
+		return ListXFA(cmd)
+
+	case model.EXTRACTXFA:
+		return ExtractXFA(cmd)
+
+	case model.REMOVEXFA:
+		return RemoveXFA(cmd)
 	}
 
 	return nil, nil
@@ -44,7 +44,8 @@ const (
 
 type ttf struct {
 	PostscriptName     string            // name: NameID 6
-	Protected          bool              // OS/2: fsType
+	Protected          bool              // OS/2: fsType Restricted License embedding
+	FSType             uint16            // OS/2: fsType
 	UnitsPerEm         int               // head: unitsPerEm
 	Ascent             int               // OS/2: sTypoAscender
 	Descent            int               // OS/2: sTypoDescender
@@ -244,6 +245,7 @@ func (t table) parseWindowsMetricsTable(fd *ttf) error {
 	version := t.uint16(0)
 	fsType := t.uint16(8)
 	fd.Protected = fsType&2 > 0
+	fd.FSType = fsType
 	//fmt.Printf("protected: %t\n", fd.Protected)
 
 	uniCodeRange1 := t.uint32(42)
@@ -648,7 +650,7 @@ func readGob(fileName string, fd *ttf) error {
 	return dec.Decode(fd)
 }
 
-func installTrueTypeRep(fontDir, fontName string, header []byte, tables map[string]*table) error {
+func installTrueTypeRep(fontDir, fontName, psName string, header []byte, tables map[string]*table) error {
 	fd := ttf{}
 	//fmt.Println(fontName)
 	for _, v := range []string{"head", "OS/2", "post", "name", "hhea", "maxp", "hmtx", "cmap"} {
@@ -657,6 +659,11 @@ func installTrueTypeRep(fontDir, fontName string, header []byte, tables map[stri
 		}
 	}
 
+	if psName != "" {
+		// Instance of a variable font.
+		fd.PostscriptName = psName
+	}
+
 	bb, err := createTTF(header, tables)
 	if err != nil {
 		return err
@@ -724,7 +731,7 @@ func InstallTrueTypeCollection(fontDir, fn string) error {
 		if err != nil {
 			return err
 		}
-		if err := installTrueTypeRep(fontDir, fn, header, tables); err != nil {
+		if err := installTrueTypeRep(fontDir, fn, "", header, tables); err != nil {
 			return err
 		}
 	}
@@ -744,7 +751,7 @@ func InstallTrueTypeFont(fontDir, fontName string) error {
 	if err != nil {
 		return err
 	}
-	return installTrueTypeRep(fontDir, fontName, header, tables)
+	return installTrueTypeRep(fontDir, fontName, "", header, tables)
 }
 
 func ttfTables(tableCount int, bb []byte) (map[string]*table, error) {
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package font
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Embedding permissions as of the OS/2 table entry fsType.
+const (
+	FSTypeRestricted   uint16 = 0x0002 // Restricted License embedding, must not be embedded.
+	FSTypePreviewPrint uint16 = 0x0004 // Preview & Print embedding.
+	FSTypeEditable     uint16 = 0x0008 // Editable embedding.
+	FSTypeNoSubsetting uint16 = 0x0100 // must not be subsetted prior to embedding.
+	FSTypeBitmapOnly   uint16 = 0x0200 // only bitmaps contained in the font may be embedded.
+)
+
+// FSTypeEmbeddable returns true if fsType permits embedding the font outlines.
+// If multiple permission bits are set the least restrictive one applies.
+func FSTypeEmbeddable(fsType uint16) bool {
+	if fsType&FSTypeBitmapOnly > 0 {
+		return false
+	}
+	return fsType&(FSTypeRestricted|FSTypePreviewPrint|FSTypeEditable) != FSTypeRestricted
+}
+
+// FSTypeSubsettable returns true if fsType permits subsetting the font.
+func FSTypeSubsettable(fsType uint16) bool {
+	return fsType&FSTypeNoSubsetting == 0
+}
+
+// FSTypeString returns a string rep for the embedding permissions of fsType.
+func FSTypeString(fsType uint16) string {
+	var ss []string
+	switch {
+	case fsType&FSTypeEditable > 0:
+		ss = append(ss, "editable")
+	case fsType&FSTypePreviewPrint > 0:
+		ss = append(ss, "preview&print")
+	case fsType&FSTypeRestricted > 0:
+		ss = append(ss, "restricted")
+	default:
+		ss = append(ss, "installable")
+	}
+	if fsType&FSTypeNoSubsetting > 0 {
+		ss = append(ss, "no subsetting")
+	}
+	if fsType&FSTypeBitmapOnly > 0 {
+		ss = append(ss, "bitmap only")
+	}
+	return strings.Join(ss, ", ")
+}
+
+// License returns a string rep for the embedding permissions of fd.
+func (fd TTFLight) License() string {
+	if fd.FSType == 0 && fd.Protected {
+		// Fonts installed before fsType was recorded.
+		return FSTypeString(FSTypeRestricted)
+	}
+	return FSTypeString(fd.FSType)
+}
+
+// Embeddable returns true if the license of fd permits embedding.
+func (fd TTFLight) Embeddable() bool {
+	if fd.FSType == 0 {
+		// Fonts installed before fsType was recorded.
+		return !fd.Protected
+	}
+	return FSTypeEmbeddable(fd.FSType)
+}
+
+// Subsettable returns true if the license of fd permits subsetting.
+func (fd TTFLight) Subsettable() bool {
+	return FSTypeSubsettable(fd.FSType)
+}
+
+// ParseFSType returns the fsType embedding permissions of the TrueType or OpenType font file bb.
+// ok is false for fonts without OS/2 table.
+func ParseFSType(bb []byte) (fsType uint16, ok bool, err error) {
+	if len(bb) < 12 {
+		return 0, false, errors.New("pdfcpu: corrupt font file")
+	}
+
+	switch string(bb[:4]) {
+	case sfntVersionTrueType, sfntVersionTrueTypeApple, sfntVersionCFF:
+	default:
+		return 0, false, errors.New("pdfcpu: unrecognized font format")
+	}
+
+	c := int(binary.BigEndian.Uint16(bb[4:]))
+	if len(bb) < 12+c*16 {
+		return 0, false, errors.New("pdfcpu: corrupt font file")
+	}
+
+	for j := 0; j < c; j++ {
+		b := bb[12+j*16:]
+		if string(b[:4]) != "OS/2" {
+			continue
+		}
+		off := int(binary.BigEndian.Uint32(b[8:]))
+		if off+10 > len(bb) {
+			return 0, false, errors.New("pdfcpu: corrupt table: OS/2")
+		}
+		return binary.BigEndian.Uint16(bb[off+8:]), true, nil
+	}
+
+	return 0, false, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package font
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSType(t *testing.T) {
+	for _, tt := range []struct {
+		fsType     uint16
+		embeddable bool
+		subset     bool
+		s          string
+	}{
+		{0x0000, true, true, "installable"},
+		{0x0002, false, true, "restricted"},
+		{0x0004, true, true, "preview&print"},
+		{0x0006, true, true, "preview&print"}, // least restrictive wins
+		{0x0008, true, true, "editable"},
+		{0x0108, true, false, "editable, no subsetting"},
+		{0x0204, false, true, "preview&print, bitmap only"},
+	} {
+		if got := FSTypeEmbeddable(tt.fsType); got != tt.embeddable {
+			t.Errorf("fsType %04x: embeddable want %t got %t", tt.fsType, tt.embeddable, got)
+		}
+		if got := FSTypeSubsettable(tt.fsType); got != tt.subset {
+			t.Errorf("fsType %04x: subsettable want %t got %t", tt.fsType, tt.subset, got)
+		}
+		if got := FSTypeString(tt.fsType); got != tt.s {
+			t.Errorf("fsType %04x: want %q got %q", tt.fsType, tt.s, got)
+		}
+	}
+
+	// Fonts installed before fsType was recorded.
+	if (TTFLight{Protected: true}).Embeddable() {
+		t.Errorf("protected font should not be embeddable")
+	}
+}
+
+func TestParseFSType(t *testing.T) {
+	bb, err := os.ReadFile(filepath.Join("..", "testdata", "fonts", "Roboto-Regular.ttf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsType, ok, err := ParseFSType(bb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("missing OS/2 table")
+	}
+	if !FSTypeEmbeddable(fsType) {
+		t.Errorf("Roboto should be embeddable, fsType: %04x", fsType)
+	}
+
+	if _, _, err := ParseFSType([]byte("%PDF-1.7 no font")); err == nil {
+		t.Errorf("expected error for invalid font file")
+	}
+}
@@ -36,7 +36,8 @@ import (
 // TTFLight represents a TrueType font w/o font file.
 type TTFLight struct {
 	PostscriptName     string            // name: NameID 6
-	Protected          bool              // OS/2: fsType
+	Protected          bool              // OS/2: fsType Restricted License embedding
+	FSType             uint16            // OS/2: fsType
 	UnitsPerEm         int               // head: unitsPerEm
 	Ascent             int               // OS/2: sTypoAscender
 	Descent            int               // OS/2: sTypoDescender
@@ -391,13 +392,17 @@ func UserFontNames() []string {
 	return ss
 }
 
-// UserFontNamesVerbose return a list of all installed TrueType fonts including glyph count.
+// UserFontNamesVerbose return a list of all installed TrueType fonts including glyph count and any license restrictions.
 func UserFontNamesVerbose() []string {
 	ss := []string{}
 	UserFontMetricsLock.RLock()
 	defer UserFontMetricsLock.RUnlock()
 	for fName, ttf := range UserFontMetrics {
-		s := fName + " (" + strconv.Itoa(ttf.GlyphCount) + " glyphs)"
+		s := fName + " (" + strconv.Itoa(ttf.GlyphCount) + " glyphs"
+		if l := ttf.License(); l != "installable" {
+			s += ", " + l
+		}
+		s += ")"
 		ss = append(ss, s)
 	}
 	return ss
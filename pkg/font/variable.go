@@ -0,0 +1,1429 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package font
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Tables only meaningful for variable fonts, dropped for static instances.
+var variationTables = []string{"avar", "cvar", "fvar", "gvar", "HVAR", "MVAR", "STAT", "VVAR"}
+
+// Composite glyph flags.
+const (
+	compArgsAreWords     = 0x0001
+	compArgsAreXYValues  = 0x0002
+	compScale            = 0x0008
+	compMoreComponents   = 0x0020
+	compXYScale          = 0x0040
+	compTwoByTwo         = 0x0080
+	compHaveInstructions = 0x0100
+)
+
+// variationAxis represents an axis of a variable font (fvar).
+type variationAxis struct {
+	tag           string
+	min, def, max float64
+}
+
+// namedInstance represents a predefined instance of a variable font (fvar).
+type namedInstance struct {
+	coords   []float64
+	psNameID uint16 // 0 if undefined.
+}
+
+func (t table) fixed(off int) float64 {
+	return float64(int32(t.uint32(off))) / 65536.0
+}
+
+func f2dot14(b []byte) float64 {
+	return float64(int16(binary.BigEndian.Uint16(b))) / 16384.0
+}
+
+func newTable(tag string, bb []byte) *table {
+	data := pad(append([]byte(nil), bb...))
+	return &table{chksum: calcTableChecksum(tag, data), size: uint32(len(bb)), padded: uint32(len(data)), data: data}
+}
+
+// nameForID returns the English name record for nameID.
+func (t table) nameForID(nameID uint16) string {
+	count := int(t.uint16(2))
+	stringOffset := int(t.uint16(4))
+	for i := 0; i < count; i++ {
+		recOff := 6 + i*12
+		if t.uint16(recOff+6) != nameID {
+			continue
+		}
+		pf, enc, lang := t.uint16(recOff), t.uint16(recOff+2), t.uint16(recOff+4)
+		l, o := int(t.uint16(recOff+8)), int(t.uint16(recOff+10))
+		if stringOffset+o+l > len(t.data) {
+			return ""
+		}
+		s := t.data[stringOffset+o : stringOffset+o+l]
+		if pf == 3 && enc == 1 && lang == 0x0409 {
+			return utf16BEToString(s)
+		}
+		if pf == 1 && enc == 0 && lang == 0 {
+			return string(s)
+		}
+	}
+	return ""
+}
+
+func parseVariationAxes(t *table) ([]variationAxis, []namedInstance, error) {
+	if len(t.data) < 16 {
+		return nil, nil, errors.New("pdfcpu: corrupt fvar table")
+	}
+
+	axesOff := int(t.uint16(4))
+	axisCount := int(t.uint16(8))
+	axisSize := int(t.uint16(10))
+	instanceCount := int(t.uint16(12))
+	instanceSize := int(t.uint16(14))
+
+	if axisSize < 20 || instanceSize < axisCount*4+4 || axesOff+axisCount*axisSize+instanceCount*instanceSize > len(t.data) {
+		return nil, nil, errors.New("pdfcpu: corrupt fvar table")
+	}
+
+	axes := make([]variationAxis, axisCount)
+	for i := range axes {
+		off := axesOff + i*axisSize
+		axes[i] = variationAxis{
+			tag: string(t.data[off : off+4]),
+			min: t.fixed(off + 4),
+			def: t.fixed(off + 8),
+			max: t.fixed(off + 12),
+		}
+	}
+
+	instances := make([]namedInstance, instanceCount)
+	off := axesOff + axisCount*axisSize
+	for i := range instances {
+		coords := make([]float64, axisCount)
+		for j := range coords {
+			coords[j] = t.fixed(off + 4 + j*4)
+		}
+		instances[i].coords = coords
+		if instanceSize >= axisCount*4+6 {
+			instances[i].psNameID = t.uint16(off + 4 + axisCount*4)
+		}
+		off += instanceSize
+	}
+
+	return axes, instances, nil
+}
+
+// ParseAxisCoordinates parses variation axis coordinates like "wght:700, wdth:75".
+func ParseAxisCoordinates(s string) (map[string]float64, error) {
+	coords := map[string]float64{}
+	for _, ss := range strings.Split(s, ",") {
+		ss = strings.TrimSpace(ss)
+		if ss == "" {
+			continue
+		}
+		tag, v, ok := strings.Cut(ss, ":")
+		if !ok {
+			return nil, errors.Errorf("pdfcpu: invalid axis coordinate: %s", ss)
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, errors.Errorf("pdfcpu: invalid axis coordinate: %s", ss)
+		}
+		coords[strings.TrimSpace(tag)] = f
+	}
+	if len(coords) == 0 {
+		return nil, errors.New("pdfcpu: missing axis coordinates")
+	}
+	return coords, nil
+}
+
+// piecewiseLinearMap maps v using the avar segment map defined by from and to.
+func piecewiseLinearMap(v float64, from, to []float64) float64 {
+	if len(from) == 0 {
+		return v
+	}
+	if v <= from[0] {
+		return v + to[0] - from[0]
+	}
+	for i := 1; i < len(from); i++ {
+		if v == from[i] {
+			return to[i]
+		}
+		if v < from[i] {
+			return to[i-1] + (v-from[i-1])*(to[i]-to[i-1])/(from[i]-from[i-1])
+		}
+	}
+	return v + to[len(to)-1] - from[len(from)-1]
+}
+
+func applyAxisVariations(t *table, nc []float64) error {
+	if len(t.data) < 8 || int(t.uint16(6)) != len(nc) {
+		return errors.New("pdfcpu: corrupt avar table")
+	}
+	off := 8
+	for i := range nc {
+		if off+2 > len(t.data) {
+			return errors.New("pdfcpu: corrupt avar table")
+		}
+		c := int(t.uint16(off))
+		off += 2
+		if off+c*4 > len(t.data) {
+			return errors.New("pdfcpu: corrupt avar table")
+		}
+		from, to := make([]float64, c), make([]float64, c)
+		for j := 0; j < c; j++ {
+			from[j], to[j] = f2dot14(t.data[off:]), f2dot14(t.data[off+2:])
+			off += 4
+		}
+		nc[i] = piecewiseLinearMap(nc[i], from, to)
+	}
+	return nil
+}
+
+// normalizedCoordinates maps user space axis coordinates to the normalized range [-1,1].
+func normalizedCoordinates(axes []variationAxis, coords map[string]float64, avar *table) ([]float64, error) {
+	for tag := range coords {
+		found := false
+		for _, a := range axes {
+			if a.tag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("pdfcpu: unknown variation axis: %s", tag)
+		}
+	}
+
+	nc := make([]float64, len(axes))
+	for i, a := range axes {
+		v, ok := coords[a.tag]
+		if !ok {
+			continue
+		}
+		v = math.Max(a.min, math.Min(a.max, v))
+		if v < a.def {
+			nc[i] = (v - a.def) / (a.def - a.min)
+		} else if v > a.def {
+			nc[i] = (v - a.def) / (a.max - a.def)
+		}
+	}
+
+	if avar != nil {
+		if err := applyAxisVariations(avar, nc); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, v := range nc {
+		nc[i] = math.Round(v*16384) / 16384
+	}
+
+	return nc, nil
+}
+
+// tupleScalar returns the scalar for a tuple variation or variation region at normalized coordinates nc.
+// start and end are nil for tuples without intermediate region.
+func tupleScalar(nc, peak, start, end []float64) float64 {
+	s := 1.
+	for i, p := range peak {
+		if p == 0 {
+			continue
+		}
+		v := nc[i]
+		if start == nil {
+			if v == 0 || v < math.Min(0, p) || v > math.Max(0, p) {
+				return 0
+			}
+			s *= v / p
+			continue
+		}
+		st, e := start[i], end[i]
+		if st > p || p > e || (st < 0 && e > 0) {
+			continue
+		}
+		if v < st || v > e {
+			return 0
+		}
+		if v < p {
+			s *= (v - st) / (p - st)
+		} else if v > p {
+			s *= (e - v) / (e - p)
+		}
+	}
+	return s
+}
+
+// unpackPointNumbers returns the packed point numbers of b and the number of bytes consumed.
+// A nil slice stands for all points.
+func unpackPointNumbers(b []byte) ([]int, int, error) {
+	errCorrupt := errors.New("pdfcpu: corrupt packed point numbers")
+	if len(b) < 1 {
+		return nil, 0, errCorrupt
+	}
+	n, i := int(b[0]), 1
+	if n&0x80 > 0 {
+		if len(b) < 2 {
+			return nil, 0, errCorrupt
+		}
+		n, i = (n&0x7F)<<8|int(b[1]), 2
+	}
+	if n == 0 {
+		return nil, i, nil
+	}
+
+	pp := make([]int, 0, n)
+	p := 0
+	for len(pp) < n {
+		if i >= len(b) {
+			return nil, 0, errCorrupt
+		}
+		c := b[i]
+		i++
+		for j := 0; j <= int(c&0x7F) && len(pp) < n; j++ {
+			if c&0x80 > 0 {
+				if i+2 > len(b) {
+					return nil, 0, errCorrupt
+				}
+				p += int(binary.BigEndian.Uint16(b[i:]))
+				i += 2
+			} else {
+				if i >= len(b) {
+					return nil, 0, errCorrupt
+				}
+				p += int(b[i])
+				i++
+			}
+			pp = append(pp, p)
+		}
+	}
+
+	return pp, i, nil
+}
+
+// unpackDeltas returns n packed deltas of b and the number of bytes consumed.
+func unpackDeltas(b []byte, n int) ([]float64, int, error) {
+	errCorrupt := errors.New("pdfcpu: corrupt packed deltas")
+	dd := make([]float64, 0, n)
+	i := 0
+	for len(dd) < n {
+		if i >= len(b) {
+			return nil, 0, errCorrupt
+		}
+		c := b[i]
+		i++
+		cnt := int(c&0x3F) + 1
+		size := 1
+		switch c & 0xC0 {
+		case 0x80:
+			size = 0
+		case 0x40:
+			size = 2
+		case 0xC0:
+			size = 4
+		}
+		if i+cnt*size > len(b) {
+			return nil, 0, errCorrupt
+		}
+		for j := 0; j < cnt && len(dd) < n; j++ {
+			var d float64
+			switch size {
+			case 1:
+				d = float64(int8(b[i]))
+			case 2:
+				d = float64(int16(binary.BigEndian.Uint16(b[i:])))
+			case 4:
+				d = float64(int32(binary.BigEndian.Uint32(b[i:])))
+			}
+			i += size
+			dd = append(dd, d)
+		}
+	}
+	return dd, i, nil
+}
+
+func iupCoord(c, c1, c2, d1, d2 float64) float64 {
+	if c1 > c2 {
+		c1, c2, d1, d2 = c2, c1, d2, d1
+	}
+	if c1 == c2 {
+		if d1 == d2 {
+			return d1
+		}
+		return 0
+	}
+	if c <= c1 {
+		return d1
+	}
+	if c >= c2 {
+		return d2
+	}
+	return d1 + (c-c1)*(d2-d1)/(c2-c1)
+}
+
+// interpolateUntouchedPoints infers the deltas of points not referenced by a tuple variation (IUP).
+func interpolateUntouchedPoints(dx, dy []float64, touched []bool, xs, ys []float64, endPts []int) {
+	start := 0
+	for _, end := range endPts {
+		if end >= len(xs) || end < start {
+			return
+		}
+		refs := []int{}
+		for i := start; i <= end; i++ {
+			if touched[i] {
+				refs = append(refs, i)
+			}
+		}
+		if len(refs) == 1 {
+			r := refs[0]
+			for i := start; i <= end; i++ {
+				dx[i], dy[i] = dx[r], dy[r]
+			}
+		}
+		if len(refs) > 1 {
+			for j, r1 := range refs {
+				r2 := refs[(j+1)%len(refs)]
+				for i := r1 + 1; ; i++ {
+					if i > end {
+						i = start
+					}
+					if i == r2 {
+						break
+					}
+					dx[i] = iupCoord(xs[i], xs[r1], xs[r2], dx[r1], dx[r2])
+					dy[i] = iupCoord(ys[i], ys[r1], ys[r2], dy[r1], dy[r2])
+				}
+			}
+		}
+		start = end + 1
+	}
+}
+
+type glyphVariations struct {
+	t            *table
+	axisCount    int
+	sharedTuples [][]float64
+	glyphCount   int
+	longOffsets  bool
+	dataOff      int
+}
+
+func parseGlyphVariations(t *table) (*glyphVariations, error) {
+	errCorrupt := errors.New("pdfcpu: corrupt gvar table")
+	if len(t.data) < 20 {
+		return nil, errCorrupt
+	}
+
+	gv := &glyphVariations{
+		t:           t,
+		axisCount:   int(t.uint16(4)),
+		glyphCount:  int(t.uint16(12)),
+		longOffsets: t.uint16(14)&1 > 0,
+		dataOff:     int(t.uint32(16)),
+	}
+
+	sharedTupleCount := int(t.uint16(6))
+	off := int(t.uint32(8))
+	if off+sharedTupleCount*gv.axisCount*2 > len(t.data) {
+		return nil, errCorrupt
+	}
+	for i := 0; i < sharedTupleCount; i++ {
+		tuple := make([]float64, gv.axisCount)
+		for j := range tuple {
+			tuple[j] = f2dot14(t.data[off:])
+			off += 2
+		}
+		gv.sharedTuples = append(gv.sharedTuples, tuple)
+	}
+
+	n := 2
+	if gv.longOffsets {
+		n = 4
+	}
+	if 20+(gv.glyphCount+1)*n > len(t.data) {
+		return nil, errCorrupt
+	}
+
+	return gv, nil
+}
+
+func (gv glyphVariations) offset(gid int) int {
+	if gv.longOffsets {
+		return int(gv.t.uint32(20 + gid*4))
+	}
+	return 2 * int(gv.t.uint16(20+gid*2))
+}
+
+func (gv glyphVariations) glyphData(gid int) []byte {
+	if gid >= gv.glyphCount {
+		return nil
+	}
+	from, thru := gv.dataOff+gv.offset(gid), gv.dataOff+gv.offset(gid+1)
+	if thru <= from || thru > len(gv.t.data) {
+		return nil
+	}
+	return gv.t.data[from:thru]
+}
+
+func (gv glyphVariations) tupleCoords(b []byte, off int) ([]float64, int, error) {
+	if off+gv.axisCount*2 > len(b) {
+		return nil, 0, errors.New("pdfcpu: corrupt glyph variation data")
+	}
+	tuple := make([]float64, gv.axisCount)
+	for i := range tuple {
+		tuple[i] = f2dot14(b[off:])
+		off += 2
+	}
+	return tuple, off, nil
+}
+
+// deltas returns the accumulated deltas at nc for the points xs, ys of glyph gid.
+// endPts is nil for composite glyphs.
+func (gv glyphVariations) deltas(gid int, nc, xs, ys []float64, endPts []int) ([]float64, []float64, error) {
+	errCorrupt := errors.Errorf("pdfcpu: corrupt glyph variation data for glyph %d", gid)
+
+	n := len(xs)
+	dx, dy := make([]float64, n), make([]float64, n)
+
+	b := gv.glyphData(gid)
+	if len(b) < 4 {
+		return dx, dy, nil
+	}
+
+	tvc := binary.BigEndian.Uint16(b)
+	dataOff := int(binary.BigEndian.Uint16(b[2:]))
+	if dataOff > len(b) {
+		return nil, nil, errCorrupt
+	}
+	data := b[dataOff:]
+
+	var (
+		sharedPts []int
+		err       error
+	)
+	if tvc&0x8000 > 0 {
+		var i int
+		if sharedPts, i, err = unpackPointNumbers(data); err != nil {
+			return nil, nil, err
+		}
+		data = data[i:]
+	}
+
+	off := 4
+	for i := 0; i < int(tvc&0x0FFF); i++ {
+		if off+4 > len(b) {
+			return nil, nil, errCorrupt
+		}
+		size := int(binary.BigEndian.Uint16(b[off:]))
+		idx := binary.BigEndian.Uint16(b[off+2:])
+		off += 4
+
+		var peak, start, end []float64
+		if idx&0x8000 > 0 {
+			if peak, off, err = gv.tupleCoords(b, off); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			j := int(idx & 0x0FFF)
+			if j >= len(gv.sharedTuples) {
+				return nil, nil, errCorrupt
+			}
+			peak = gv.sharedTuples[j]
+		}
+		if idx&0x4000 > 0 {
+			if start, off, err = gv.tupleCoords(b, off); err != nil {
+				return nil, nil, err
+			}
+			if end, off, err = gv.tupleCoords(b, off); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if size > len(data) {
+			return nil, nil, errCorrupt
+		}
+		td := data[:size]
+		data = data[size:]
+
+		s := tupleScalar(nc, peak, start, end)
+		if s == 0 {
+			continue
+		}
+
+		pts := sharedPts
+		if idx&0x2000 > 0 {
+			var j int
+			if pts, j, err = unpackPointNumbers(td); err != nil {
+				return nil, nil, err
+			}
+			td = td[j:]
+		}
+
+		m := n
+		if pts != nil {
+			m = len(pts)
+		}
+
+		x, j, err := unpackDeltas(td, m)
+		if err != nil {
+			return nil, nil, err
+		}
+		y, _, err := unpackDeltas(td[j:], m)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if pts == nil {
+			for k := 0; k < n; k++ {
+				dx[k] += s * x[k]
+				dy[k] += s * y[k]
+			}
+			continue
+		}
+
+		tx, ty, touched := make([]float64, n), make([]float64, n), make([]bool, n)
+		for k, p := range pts {
+			if p < n {
+				tx[p], ty[p], touched[p] = x[k], y[k], true
+			}
+		}
+		if endPts != nil {
+			interpolateUntouchedPoints(tx, ty, touched, xs, ys, endPts)
+		}
+		for k := 0; k < n; k++ {
+			dx[k] += s * tx[k]
+			dy[k] += s * ty[k]
+		}
+	}
+
+	return dx, dy, nil
+}
+
+// advanceWidthDeltas returns the advance width deltas at nc for all glyphs as defined by HVAR.
+func advanceWidthDeltas(t *table, nc []float64, numGlyphs int) ([]float64, error) {
+	errCorrupt := errors.New("pdfcpu: corrupt HVAR table")
+	if len(t.data) < 20 {
+		return nil, errCorrupt
+	}
+
+	ivs := int(t.uint32(4))
+	if ivs+8 > len(t.data) {
+		return nil, errCorrupt
+	}
+
+	// Variation regions
+	rl := ivs + int(t.uint32(ivs+2))
+	if rl+4 > len(t.data) {
+		return nil, errCorrupt
+	}
+	axisCount, regionCount := int(t.uint16(rl)), int(t.uint16(rl+2))
+	if axisCount != len(nc) || rl+4+regionCount*axisCount*6 > len(t.data) {
+		return nil, errCorrupt
+	}
+	scalars := make([]float64, regionCount)
+	for i := range scalars {
+		start, peak, end := make([]float64, axisCount), make([]float64, axisCount), make([]float64, axisCount)
+		for j := 0; j < axisCount; j++ {
+			off := rl + 4 + (i*axisCount+j)*6
+			start[j], peak[j], end[j] = f2dot14(t.data[off:]), f2dot14(t.data[off+2:]), f2dot14(t.data[off+4:])
+		}
+		scalars[i] = tupleScalar(nc, peak, start, end)
+	}
+
+	// Item variation data
+	dataCount := int(t.uint16(ivs + 6))
+	if ivs+8+dataCount*4 > len(t.data) {
+		return nil, errCorrupt
+	}
+	deltaSets := make([][]float64, dataCount)
+	for i := range deltaSets {
+		off := ivs + int(t.uint32(ivs+8+i*4))
+		if off+6 > len(t.data) {
+			return nil, errCorrupt
+		}
+		itemCount, wordDeltaCount, regionIndexCount := int(t.uint16(off)), int(t.uint16(off+2)), int(t.uint16(off+4))
+		longWords := wordDeltaCount&0x8000 > 0
+		wordDeltaCount &= 0x7FFF
+		off += 6
+		regions := make([]int, regionIndexCount)
+		for j := range regions {
+			if off+2 > len(t.data) {
+				return nil, errCorrupt
+			}
+			regions[j] = int(t.uint16(off))
+			if regions[j] >= regionCount {
+				return nil, errCorrupt
+			}
+			off += 2
+		}
+		wordSize, shortSize := 2, 1
+		if longWords {
+			wordSize, shortSize = 4, 2
+		}
+		rowSize := wordDeltaCount*wordSize + (regionIndexCount-wordDeltaCount)*shortSize
+		if off+itemCount*rowSize > len(t.data) {
+			return nil, errCorrupt
+		}
+		deltas := make([]float64, itemCount)
+		for j := range deltas {
+			o := off + j*rowSize
+			for k, r := range regions {
+				var d float64
+				size := shortSize
+				if k < wordDeltaCount {
+					size = wordSize
+				}
+				switch size {
+				case 1:
+					d = float64(int8(t.data[o]))
+				case 2:
+					d = float64(int16(t.uint16(o)))
+				case 4:
+					d = float64(int32(t.uint32(o)))
+				}
+				o += size
+				deltas[j] += scalars[r] * d
+			}
+		}
+		deltaSets[i] = deltas
+	}
+
+	// Advance width mapping
+	var (
+		entrySize, innerBits, mapCount, mapOff int
+	)
+	if m := int(t.uint32(8)); m > 0 {
+		if m+4 > len(t.data) {
+			return nil, errCorrupt
+		}
+		format, entryFormat := t.data[m], t.data[m+1]
+		entrySize, innerBits = int(entryFormat&0x30>>4)+1, int(entryFormat&0x0F)+1
+		if format == 0 {
+			mapCount, mapOff = int(t.uint16(m+2)), m+4
+		} else {
+			if m+6 > len(t.data) {
+				return nil, errCorrupt
+			}
+			mapCount, mapOff = int(t.uint32(m+2)), m+6
+		}
+		if mapCount == 0 || mapOff+mapCount*entrySize > len(t.data) {
+			return nil, errCorrupt
+		}
+	}
+
+	dd := make([]float64, numGlyphs)
+	for gid := range dd {
+		outer, inner := 0, gid
+		if mapCount > 0 {
+			i := gid
+			if i >= mapCount {
+				i = mapCount - 1
+			}
+			v := 0
+			for _, c := range t.data[mapOff+i*entrySize : mapOff+(i+1)*entrySize] {
+				v = v<<8 | int(c)
+			}
+			outer, inner = v>>innerBits, v&(1<<innerBits-1)
+		}
+		if outer < len(deltaSets) && inner < len(deltaSets[outer]) {
+			dd[gid] = deltaSets[outer][inner]
+		}
+	}
+
+	return dd, nil
+}
+
+type glyphComponent struct {
+	flags      uint16
+	gid        uint16
+	arg1, arg2 float64 // x/y offset or point numbers
+	transform  []byte
+}
+
+type glyph struct {
+	contours   int // < 0 for composite glyphs
+	bbox       [4]int
+	endPts     []int
+	flags      []byte
+	xs, ys     []float64
+	instr      []byte
+	components []glyphComponent
+}
+
+func parseGlyph(bb []byte) (*glyph, error) {
+	errCorrupt := errors.New("pdfcpu: corrupt glyph")
+	if len(bb) < 10 {
+		return nil, errCorrupt
+	}
+
+	g := &glyph{contours: int(int16(binary.BigEndian.Uint16(bb)))}
+	for i := range g.bbox {
+		g.bbox[i] = int(int16(binary.BigEndian.Uint16(bb[2+i*2:])))
+	}
+
+	if g.contours < 0 {
+		return g, g.parseComponents(bb)
+	}
+
+	off := 10
+	if off+g.contours*2+2 > len(bb) {
+		return nil, errCorrupt
+	}
+	g.endPts = make([]int, g.contours)
+	for i := range g.endPts {
+		g.endPts[i] = int(binary.BigEndian.Uint16(bb[off:]))
+		off += 2
+	}
+	n := 0
+	if g.contours > 0 {
+		n = g.endPts[g.contours-1] + 1
+	}
+
+	l := int(binary.BigEndian.Uint16(bb[off:]))
+	off += 2
+	if off+l > len(bb) {
+		return nil, errCorrupt
+	}
+	g.instr = bb[off : off+l]
+	off += l
+
+	g.flags = make([]byte, 0, n)
+	for len(g.flags) < n {
+		if off >= len(bb) {
+			return nil, errCorrupt
+		}
+		f := bb[off]
+		off++
+		g.flags = append(g.flags, f)
+		if f&0x08 > 0 {
+			if off >= len(bb) {
+				return nil, errCorrupt
+			}
+			for r := int(bb[off]); r > 0 && len(g.flags) < n; r-- {
+				g.flags = append(g.flags, f)
+			}
+			off++
+		}
+	}
+
+	coords := func(short, same byte) ([]float64, error) {
+		cc := make([]float64, n)
+		v := 0
+		for i, f := range g.flags {
+			switch {
+			case f&short > 0:
+				if off >= len(bb) {
+					return nil, errCorrupt
+				}
+				d := int(bb[off])
+				off++
+				if f&same == 0 {
+					d = -d
+				}
+				v += d
+			case f&same == 0:
+				if off+2 > len(bb) {
+					return nil, errCorrupt
+				}
+				v += int(int16(binary.BigEndian.Uint16(bb[off:])))
+				off += 2
+			}
+			cc[i] = float64(v)
+		}
+		return cc, nil
+	}
+
+	var err error
+	if g.xs, err = coords(0x02, 0x10); err != nil {
+		return nil, err
+	}
+	if g.ys, err = coords(0x04, 0x20); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func (g *glyph) parseComponents(bb []byte) error {
+	errCorrupt := errors.New("pdfcpu: corrupt composite glyph")
+	off := 10
+	for more := true; more; {
+		if off+4 > len(bb) {
+			return errCorrupt
+		}
+		c := glyphComponent{flags: binary.BigEndian.Uint16(bb[off:]), gid: binary.BigEndian.Uint16(bb[off+2:])}
+		off += 4
+		switch {
+		case c.flags&compArgsAreWords > 0 && c.flags&compArgsAreXYValues > 0:
+			if off+4 > len(bb) {
+				return errCorrupt
+			}
+			c.arg1, c.arg2 = float64(int16(binary.BigEndian.Uint16(bb[off:]))), float64(int16(binary.BigEndian.Uint16(bb[off+2:])))
+			off += 4
+		case c.flags&compArgsAreWords > 0:
+			if off+4 > len(bb) {
+				return errCorrupt
+			}
+			c.arg1, c.arg2 = float64(binary.BigEndian.Uint16(bb[off:])), float64(binary.BigEndian.Uint16(bb[off+2:]))
+			off += 4
+		case c.flags&compArgsAreXYValues > 0:
+			if off+2 > len(bb) {
+				return errCorrupt
+			}
+			c.arg1, c.arg2 = float64(int8(bb[off])), float64(int8(bb[off+1]))
+			off += 2
+		default:
+			if off+2 > len(bb) {
+				return errCorrupt
+			}
+			c.arg1, c.arg2 = float64(bb[off]), float64(bb[off+1])
+			off += 2
+		}
+		l := 0
+		switch {
+		case c.flags&compScale > 0:
+			l = 2
+		case c.flags&compXYScale > 0:
+			l = 4
+		case c.flags&compTwoByTwo > 0:
+			l = 8
+		}
+		if off+l > len(bb) {
+			return errCorrupt
+		}
+		c.transform = bb[off : off+l]
+		off += l
+		g.components = append(g.components, c)
+		more = c.flags&compMoreComponents > 0
+	}
+
+	for _, c := range g.components {
+		if c.flags&compHaveInstructions > 0 && off+2 <= len(bb) {
+			l := int(binary.BigEndian.Uint16(bb[off:]))
+			if off+2+l <= len(bb) {
+				g.instr = bb[off+2 : off+2+l]
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// points returns the points subject to glyph variations excluding phantom points.
+func (g *glyph) points() ([]float64, []float64) {
+	if g.contours >= 0 {
+		return append([]float64(nil), g.xs...), append([]float64(nil), g.ys...)
+	}
+	xs, ys := make([]float64, len(g.components)), make([]float64, len(g.components))
+	for i, c := range g.components {
+		xs[i], ys[i] = c.arg1, c.arg2
+	}
+	return xs, ys
+}
+
+func (g *glyph) setPoints(xs, ys []float64) {
+	if g.contours >= 0 {
+		for i := range g.xs {
+			g.xs[i], g.ys[i] = math.Round(xs[i]), math.Round(ys[i])
+		}
+		return
+	}
+	for i, c := range g.components {
+		if c.flags&compArgsAreXYValues > 0 {
+			g.components[i].arg1, g.components[i].arg2 = math.Round(xs[i]), math.Round(ys[i])
+		}
+	}
+}
+
+func (g *glyph) simpleBBox() {
+	if len(g.xs) == 0 {
+		g.bbox = [4]int{}
+		return
+	}
+	xMin, yMin, xMax, yMax := g.xs[0], g.ys[0], g.xs[0], g.ys[0]
+	for i := range g.xs {
+		xMin, xMax = math.Min(xMin, g.xs[i]), math.Max(xMax, g.xs[i])
+		yMin, yMax = math.Min(yMin, g.ys[i]), math.Max(yMax, g.ys[i])
+	}
+	g.bbox = [4]int{int(xMin), int(yMin), int(xMax), int(yMax)}
+}
+
+func (g *glyph) bytes() []byte {
+	var buf bytes.Buffer
+	w := func(v interface{}) { binary.Write(&buf, binary.BigEndian, v) }
+
+	w(int16(g.contours))
+	for _, v := range g.bbox {
+		w(int16(v))
+	}
+
+	if g.contours < 0 {
+		for i, c := range g.components {
+			flags := c.flags | compArgsAreWords
+			if i < len(g.components)-1 {
+				flags |= compMoreComponents
+			} else {
+				flags &^= compMoreComponents
+			}
+			w(flags)
+			w(c.gid)
+			if c.flags&compArgsAreXYValues > 0 {
+				w(int16(c.arg1))
+				w(int16(c.arg2))
+			} else {
+				w(uint16(c.arg1))
+				w(uint16(c.arg2))
+			}
+			buf.Write(c.transform)
+		}
+		for _, c := range g.components {
+			if c.flags&compHaveInstructions > 0 {
+				w(uint16(len(g.instr)))
+				buf.Write(g.instr)
+				break
+			}
+		}
+		return buf.Bytes()
+	}
+
+	for _, v := range g.endPts {
+		w(uint16(v))
+	}
+	w(uint16(len(g.instr)))
+	buf.Write(g.instr)
+
+	var xbuf, ybuf bytes.Buffer
+	px, py := 0, 0
+	for i, f := range g.flags {
+		f &= 0x01 | 0x40
+		dx, dy := int(g.xs[i])-px, int(g.ys[i])-py
+		px, py = int(g.xs[i]), int(g.ys[i])
+		switch {
+		case dx == 0:
+			f |= 0x10
+		case dx >= -255 && dx <= 255:
+			f |= 0x02
+			if dx > 0 {
+				f |= 0x10
+			} else {
+				dx = -dx
+			}
+			xbuf.WriteByte(byte(dx))
+		default:
+			binary.Write(&xbuf, binary.BigEndian, int16(dx))
+		}
+		switch {
+		case dy == 0:
+			f |= 0x20
+		case dy >= -255 && dy <= 255:
+			f |= 0x04
+			if dy > 0 {
+				f |= 0x20
+			} else {
+				dy = -dy
+			}
+			ybuf.WriteByte(byte(dy))
+		default:
+			binary.Write(&ybuf, binary.BigEndian, int16(dy))
+		}
+		buf.WriteByte(f)
+	}
+	buf.Write(xbuf.Bytes())
+	buf.Write(ybuf.Bytes())
+
+	return buf.Bytes()
+}
+
+type glyphInstancer struct {
+	glyphs map[int]*glyph
+	raw    map[int][]byte
+	bboxes map[int]*[4]int
+}
+
+// bbox returns the bounding box of glyph gid, resolving composite glyphs.
+func (gi *glyphInstancer) bbox(gid, depth int) *[4]int {
+	if bb, ok := gi.bboxes[gid]; ok {
+		return bb
+	}
+	g, ok := gi.glyphs[gid]
+	if !ok {
+		return nil
+	}
+	if g.contours >= 0 || depth > 10 {
+		return &g.bbox
+	}
+
+	var bbox *[4]int
+	for _, c := range g.components {
+		if c.flags&compArgsAreXYValues == 0 || len(c.transform) > 0 {
+			// Keep the bounding box of the original glyph.
+			return &g.bbox
+		}
+		cb := gi.bbox(int(c.gid), depth+1)
+		if cb == nil || *cb == [4]int{} {
+			continue
+		}
+		dx, dy := int(c.arg1), int(c.arg2)
+		r := [4]int{cb[0] + dx, cb[1] + dy, cb[2] + dx, cb[3] + dy}
+		if bbox == nil {
+			bbox = &r
+			continue
+		}
+		bbox[0], bbox[1] = minInt(bbox[0], r[0]), minInt(bbox[1], r[1])
+		bbox[2], bbox[3] = maxInt(bbox[2], r[2]), maxInt(bbox[3], r[3])
+	}
+	if bbox == nil {
+		bbox = &[4]int{}
+	}
+	g.bbox = *bbox
+	gi.bboxes[gid] = bbox
+	return bbox
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func horMetrics(hmtx *table, numHMetrics, gid int) (int, int) {
+	if gid < numHMetrics {
+		return int(hmtx.uint16(gid * 4)), int(hmtx.int16(gid*4 + 2))
+	}
+	return int(hmtx.uint16((numHMetrics - 1) * 4)), int(hmtx.int16(numHMetrics*4 + (gid-numHMetrics)*2))
+}
+
+// instantiateGlyphs applies the glyph variations at nc to glyf, loca and hmtx.
+func instantiateGlyphs(tables map[string]*table, nc []float64) error {
+	head, maxp, hhea := tables["head"], tables["maxp"], tables["hhea"]
+	glyf, loca, hmtx := tables["glyf"], tables["loca"], tables["hmtx"]
+	for tag, t := range map[string]*table{"head": head, "maxp": maxp, "hhea": hhea, "glyf": glyf, "loca": loca, "hmtx": hmtx, "gvar": tables["gvar"]} {
+		if t == nil {
+			return errors.Errorf("pdfcpu: missing %q table", tag)
+		}
+	}
+
+	gv, err := parseGlyphVariations(tables["gvar"])
+	if err != nil {
+		return err
+	}
+	if gv.axisCount != len(nc) {
+		return errors.New("pdfcpu: corrupt gvar table")
+	}
+
+	indexToLocFormat := int(head.uint16(50))
+	numGlyphs := int(maxp.uint16(4))
+	numHMetrics := int(hhea.uint16(34))
+	if numHMetrics == 0 || len(loca.data) < (numGlyphs+1)*(2+2*indexToLocFormat) || len(hmtx.data) < numHMetrics*2+numGlyphs*2 {
+		return errors.New("pdfcpu: corrupt font tables")
+	}
+
+	var hvarDeltas []float64
+	if t, ok := tables["HVAR"]; ok {
+		if hvarDeltas, err = advanceWidthDeltas(t, nc, numGlyphs); err != nil {
+			return err
+		}
+	}
+
+	gi := &glyphInstancer{glyphs: map[int]*glyph{}, raw: map[int][]byte{}, bboxes: map[int]*[4]int{}}
+	advs, lsbs := make([]int, numGlyphs), make([]int, numGlyphs)
+
+	for gid := 0; gid < numGlyphs; gid++ {
+		adv, lsb := horMetrics(hmtx, numHMetrics, gid)
+		offFrom, offThru := glyfOffset(loca, gid, indexToLocFormat), glyfOffset(loca, gid+1, indexToLocFormat)
+		if offThru < offFrom || offThru > len(glyf.data) {
+			return errors.Errorf("pdfcpu: illegal glyfOffset for glyph %d", gid)
+		}
+		bb := glyf.data[offFrom:offThru]
+
+		var g *glyph
+		var xs, ys []float64
+		var endPts []int
+		xMin := 0.
+		if len(bb) > 0 {
+			if g, err = parseGlyph(bb); err != nil {
+				return err
+			}
+			xs, ys = g.points()
+			if g.contours >= 0 {
+				endPts = g.endPts
+			}
+			xMin = float64(g.bbox[0])
+		}
+
+		// Append phantom points.
+		pp1 := xMin - float64(lsb)
+		n := len(xs)
+		xs = append(xs, pp1, pp1+float64(adv), 0, 0)
+		ys = append(ys, 0, 0, 0, 0)
+
+		dx, dy, err := gv.deltas(gid, nc, xs, ys, endPts)
+		if err != nil {
+			return err
+		}
+
+		pp1New := math.Round(pp1 + dx[n])
+		if hvarDeltas != nil {
+			adv = int(math.Round(float64(adv) + hvarDeltas[gid]))
+		} else {
+			adv = int(math.Round(pp1+float64(adv)+dx[n+1]) - pp1New)
+		}
+		advs[gid] = maxInt(adv, 0)
+
+		if g == nil {
+			continue
+		}
+
+		for i := 0; i < n; i++ {
+			xs[i] += dx[i]
+			ys[i] += dy[i]
+		}
+		g.setPoints(xs[:n], ys[:n])
+		if g.contours >= 0 {
+			g.simpleBBox()
+		}
+		gi.glyphs[gid] = g
+		lsbs[gid] = int(pp1New) // resolved after bbox calculation
+	}
+
+	var glyfBuf, locaBuf bytes.Buffer
+	xMin, yMin, xMax, yMax := math.MaxInt16, math.MaxInt16, math.MinInt16, math.MinInt16
+	advMax := 0
+
+	for gid := 0; gid < numGlyphs; gid++ {
+		binary.Write(&locaBuf, binary.BigEndian, uint32(glyfBuf.Len()))
+		advMax = maxInt(advMax, advs[gid])
+		g, ok := gi.glyphs[gid]
+		if !ok {
+			lsbs[gid] = 0
+			continue
+		}
+		bbox := gi.bbox(gid, 0)
+		lsbs[gid] = bbox[0] - lsbs[gid]
+		if g.contours != 0 || len(g.components) > 0 {
+			xMin, yMin = minInt(xMin, bbox[0]), minInt(yMin, bbox[1])
+			xMax, yMax = maxInt(xMax, bbox[2]), maxInt(yMax, bbox[3])
+		}
+		glyfBuf.Write(pad(g.bytes()))
+	}
+	binary.Write(&locaBuf, binary.BigEndian, uint32(glyfBuf.Len()))
+
+	var hmtxBuf bytes.Buffer
+	for gid := 0; gid < numGlyphs; gid++ {
+		binary.Write(&hmtxBuf, binary.BigEndian, uint16(advs[gid]))
+		binary.Write(&hmtxBuf, binary.BigEndian, int16(lsbs[gid]))
+	}
+
+	tables["glyf"] = newTable("glyf", glyfBuf.Bytes())
+	tables["loca"] = newTable("loca", locaBuf.Bytes())
+	tables["hmtx"] = newTable("hmtx", hmtxBuf.Bytes())
+
+	// Long loca offsets and updated font bounding box.
+	hd := append([]byte(nil), head.data[:head.size]...)
+	binary.BigEndian.PutUint16(hd[50:], 1)
+	if xMin <= xMax {
+		for i, v := range []int{xMin, yMin, xMax, yMax} {
+			binary.BigEndian.PutUint16(hd[36+i*2:], uint16(int16(v)))
+		}
+	}
+	tables["head"] = newTable("head", hd)
+
+	hh := append([]byte(nil), hhea.data[:hhea.size]...)
+	binary.BigEndian.PutUint16(hh[10:], uint16(advMax))
+	binary.BigEndian.PutUint16(hh[34:], uint16(numGlyphs))
+	tables["hhea"] = newTable("hhea", hh)
+
+	return nil
+}
+
+// widthClass returns the OS/2 usWidthClass for a wdth axis value.
+func widthClass(wdth float64) uint16 {
+	for i, v := range []float64{50, 62.5, 75, 87.5, 100, 112.5, 125, 150} {
+		if wdth <= v {
+			return uint16(i + 1)
+		}
+	}
+	return 9
+}
+
+func updateWindowsMetrics(tables map[string]*table, axes []variationAxis, coords map[string]float64) {
+	t, ok := tables["OS/2"]
+	if !ok || t.size < 8 {
+		return
+	}
+	bb := append([]byte(nil), t.data[:t.size]...)
+	for _, a := range axes {
+		v, ok := coords[a.tag]
+		if !ok {
+			continue
+		}
+		v = math.Max(a.min, math.Min(a.max, v))
+		switch a.tag {
+		case "wght":
+			binary.BigEndian.PutUint16(bb[4:], uint16(math.Max(1, math.Min(1000, math.Round(v)))))
+		case "wdth":
+			binary.BigEndian.PutUint16(bb[6:], widthClass(v))
+		}
+	}
+	tables["OS/2"] = newTable("OS/2", bb)
+}
+
+// instancePostScriptName returns the PostScript name for the instance at coords.
+func instancePostScriptName(tables map[string]*table, axes []variationAxis, instances []namedInstance, coords map[string]float64) (string, error) {
+	name, ok := tables["name"]
+	if !ok {
+		return "", errors.New("pdfcpu: missing \"name\" table")
+	}
+
+	cc := make([]float64, len(axes))
+	for i, a := range axes {
+		cc[i] = a.def
+		if v, ok := coords[a.tag]; ok {
+			cc[i] = math.Max(a.min, math.Min(a.max, v))
+		}
+	}
+
+	for _, inst := range instances {
+		if inst.psNameID == 0 || inst.psNameID == 0xFFFF {
+			continue
+		}
+		match := true
+		for i, v := range inst.coords {
+			if v != cc[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			if s := name.nameForID(inst.psNameID); s != "" {
+				return s, nil
+			}
+		}
+	}
+
+	// See Adobe Technical Note #5902
+	prefix := name.nameForID(25)
+	if prefix == "" {
+		prefix = name.nameForID(6)
+	}
+	if prefix == "" {
+		return "", errors.New("pdfcpu: unable to identify postscript name")
+	}
+
+	ss := []string{prefix}
+	for i, a := range axes {
+		if _, ok := coords[a.tag]; ok {
+			ss = append(ss, strconv.FormatFloat(cc[i], 'f', -1, 64)+strings.TrimSpace(a.tag))
+		}
+	}
+
+	return strings.Join(ss, "_"), nil
+}
+
+// updateTableCount adjusts the offset table of header to tableCount tables.
+func updateTableCount(header []byte, tableCount int) []byte {
+	h := append([]byte(nil), header...)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= tableCount {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+	binary.BigEndian.PutUint16(h[4:], uint16(tableCount))
+	binary.BigEndian.PutUint16(h[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(h[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(h[10:], uint16(tableCount*16-searchRange))
+	return h
+}
+
+// instantiate turns the variable TrueType font represented by header and tables
+// into a static instance at coords and returns its PostScript name.
+func instantiate(header []byte, tables map[string]*table, coords map[string]float64) ([]byte, string, error) {
+	fvar, ok := tables["fvar"]
+	if !ok {
+		return nil, "", errors.New("pdfcpu: not a variable font")
+	}
+	if _, ok := tables["gvar"]; !ok {
+		return nil, "", errors.New("pdfcpu: missing \"gvar\" table")
+	}
+
+	axes, instances, err := parseVariationAxes(fvar)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nc, err := normalizedCoordinates(axes, coords, tables["avar"])
+	if err != nil {
+		return nil, "", err
+	}
+
+	psName, err := instancePostScriptName(tables, axes, instances, coords)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := instantiateGlyphs(tables, nc); err != nil {
+		return nil, "", err
+	}
+
+	updateWindowsMetrics(tables, axes, coords)
+
+	for _, tag := range variationTables {
+		delete(tables, tag)
+	}
+
+	return updateTableCount(header, len(tables)), psName, nil
+}
+
+// InstallTrueTypeFontInstance saves an internal representation of the static instance of variable TrueType font fontName
+// at the given axis coordinates (eg. "wght": 700, "wdth": 75) to the pdfcpu config dir
+// and returns the PostScript name of the instance to be used for referencing this font.
+// Axes not mentioned in coords retain their default value.
+func InstallTrueTypeFontInstance(fontDir, fontName string, coords map[string]float64) (string, error) {
+	f, err := os.Open(fontName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header, tables, err := headerAndTables(fontName, f, 0)
+	if err != nil {
+		return "", err
+	}
+
+	header, psName, err := instantiate(header, tables, coords)
+	if err != nil {
+		return "", errors.Wrapf(err, "pdfcpu: %s", fontName)
+	}
+
+	return psName, installTrueTypeRep(fontDir, fontName, psName, header, tables)
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package font
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func packedWordDeltas(dd []int) []byte {
+	var buf bytes.Buffer
+	for len(dd) > 0 {
+		n := len(dd)
+		if n > 64 {
+			n = 64
+		}
+		buf.WriteByte(0x40 | byte(n-1))
+		for _, d := range dd[:n] {
+			binary.Write(&buf, binary.BigEndian, int16(d))
+		}
+		dd = dd[n:]
+	}
+	return buf.Bytes()
+}
+
+func glyphVariationData(tupleIndex uint16, data []byte) []byte {
+	var buf bytes.Buffer
+	w := func(v interface{}) { binary.Write(&buf, binary.BigEndian, v) }
+	w(uint16(1))  // tupleVariationCount
+	w(uint16(10)) // dataOffset
+	w(uint16(len(data)))
+	w(tupleIndex | 0x8000)
+	w(uint16(0x4000)) // peak: wght 1.0
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// variableTestFont turns Roboto into a variable font with a wght axis (100,400,900).
+// At wght 900 glyph "H" moves 10 units right and gets 20 units wider
+// and the first contour of glyph "I" moves 8 units right.
+func variableTestFont(t *testing.T) ([]byte, map[string]*table, uint16, uint16) {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("..", "testdata", "fonts", "Roboto-Regular.ttf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	header, tables, err := headerAndTables(f.Name(), f, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fd := ttf{}
+	for _, tag := range []string{"head", "maxp", "cmap"} {
+		if err := parse(tables, tag, &fd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gidH, gidI := fd.Chars['H'], fd.Chars['I']
+
+	var buf bytes.Buffer
+	w := func(v interface{}) { binary.Write(&buf, binary.BigEndian, v) }
+
+	// fvar
+	for _, v := range []uint16{1, 0, 16, 2, 1, 20, 0, 8} {
+		w(v)
+	}
+	buf.WriteString("wght")
+	for _, v := range []int32{100 << 16, 400 << 16, 900 << 16} {
+		w(v)
+	}
+	w(uint16(0))
+	w(uint16(256))
+	tables["fvar"] = newTable("fvar", buf.Bytes())
+
+	// gvar
+	glyphData := map[uint16][]byte{}
+
+	g, err := parseGlyph(glyphBytes(t, tables, int(gidH)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dx := make([]int, len(g.xs)+4)
+	for i := range g.xs {
+		dx[i] = 10
+	}
+	dx[len(g.xs)+1] = 20 // pp2
+	dy := make([]int, len(dx))
+	glyphData[gidH] = glyphVariationData(0, append(packedWordDeltas(dx), packedWordDeltas(dy)...))
+
+	// Point 0 only: 1 point, run of 1 byte, point 0, dx 8, dy 0.
+	glyphData[gidI] = glyphVariationData(0x2000, []byte{1, 0, 0, 0, 8, 0x80})
+
+	numGlyphs := int(tables["maxp"].uint16(4))
+	buf.Reset()
+	for _, v := range []uint16{1, 0, 1, 0} {
+		w(v)
+	}
+	w(uint32(20)) // sharedTuplesOffset
+	w(uint16(numGlyphs))
+	w(uint16(1)) // long offsets
+	w(uint32(20 + (numGlyphs+1)*4))
+	var data []byte
+	for gid := 0; gid <= numGlyphs; gid++ {
+		w(uint32(len(data)))
+		if gid < numGlyphs {
+			data = append(data, pad(glyphData[uint16(gid)])...)
+		}
+	}
+	buf.Write(data)
+	tables["gvar"] = newTable("gvar", buf.Bytes())
+
+	return updateTableCount(header, len(tables)), tables, gidH, gidI
+}
+
+func glyphBytes(t *testing.T, tables map[string]*table, gid int) []byte {
+	t.Helper()
+	indexToLocFormat := int(tables["head"].uint16(50))
+	from, thru := glyfOffset(tables["loca"], gid, indexToLocFormat), glyfOffset(tables["loca"], gid+1, indexToLocFormat)
+	return tables["glyf"].data[from:thru]
+}
+
+func advanceWidth(tables map[string]*table, gid int) int {
+	adv, _ := horMetrics(tables["hmtx"], int(tables["hhea"].uint16(34)), gid)
+	return adv
+}
+
+func TestInstantiateVariableFont(t *testing.T) {
+	for _, tt := range []struct {
+		wght   float64
+		psName string
+		dx     float64
+	}{
+		{900, "Roboto-Regular_900wght", 10},
+		{650, "Roboto-Regular_650wght", 5},
+		{400, "Roboto-Regular_400wght", 0},
+		{100, "Roboto-Regular_100wght", 0},
+	} {
+		header, tables, gidH, gidI := variableTestFont(t)
+
+		origH, err := parseGlyph(glyphBytes(t, tables, int(gidH)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		origI, err := parseGlyph(glyphBytes(t, tables, int(gidI)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		origAdvH := advanceWidth(tables, int(gidH))
+
+		header, psName, err := instantiate(header, tables, map[string]float64{"wght": tt.wght})
+		if err != nil {
+			t.Fatalf("wght %.0f: %v\n", tt.wght, err)
+		}
+		if psName != tt.psName {
+			t.Fatalf("wght %.0f: want %s, got %s\n", tt.wght, tt.psName, psName)
+		}
+		for _, tag := range []string{"fvar", "gvar"} {
+			if _, ok := tables[tag]; ok {
+				t.Fatalf("wght %.0f: unexpected table %s\n", tt.wght, tag)
+			}
+		}
+		if n := int(binary.BigEndian.Uint16(header[4:])); n != len(tables) {
+			t.Fatalf("wght %.0f: want %d tables, got %d\n", tt.wght, len(tables), n)
+		}
+
+		h, err := parseGlyph(glyphBytes(t, tables, int(gidH)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range h.xs {
+			if h.xs[i] != origH.xs[i]+tt.dx || h.ys[i] != origH.ys[i] {
+				t.Fatalf("wght %.0f: H point %d: want (%.0f,%.0f), got (%.0f,%.0f)\n",
+					tt.wght, i, origH.xs[i]+tt.dx, origH.ys[i], h.xs[i], h.ys[i])
+			}
+		}
+		if adv := advanceWidth(tables, int(gidH)); adv != origAdvH+int(2*tt.dx) {
+			t.Fatalf("wght %.0f: H advance width: want %d, got %d\n", tt.wght, origAdvH+int(2*tt.dx), adv)
+		}
+
+		// All points of the first contour of "I" get interpolated from point 0.
+		dxI := tt.dx * 0.8
+		i, err := parseGlyph(glyphBytes(t, tables, int(gidI)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j <= origI.endPts[0]; j++ {
+			if i.xs[j] != origI.xs[j]+dxI {
+				t.Fatalf("wght %.0f: I point %d: want x=%.0f, got %.0f\n", tt.wght, j, origI.xs[j]+dxI, i.xs[j])
+			}
+		}
+	}
+}
+
+func TestInstallTrueTypeFontInstance(t *testing.T) {
+	header, tables, _, _ := variableTestFont(t)
+
+	bb, err := createTTF(header, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "RobotoVF.ttf")
+	if err := os.WriteFile(fn, bb, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	psName, err := InstallTrueTypeFontInstance(dir, fn, map[string]float64{"wght": 700})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if psName != "Roboto-Regular_700wght" {
+		t.Fatalf("unexpected PostScript name: %s\n", psName)
+	}
+
+	fd := ttf{}
+	if err := readGob(filepath.Join(dir, psName+".gob"), &fd); err != nil {
+		t.Fatal(err)
+	}
+	if fd.PostscriptName != psName {
+		t.Fatalf("want %s, got %s\n", psName, fd.PostscriptName)
+	}
+
+	if _, err := InstallTrueTypeFontInstance(dir, fn, map[string]float64{"wdth": 75}); err == nil {
+		t.Fatal("missing error for unknown axis")
+	}
+}
@@ -0,0 +1,406 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// ActionTrigger represents a document or page event triggering an action.
+type ActionTrigger int
+
+// Document and page level action triggers.
+const (
+	DocOpen      ActionTrigger = iota // Catalog "OpenAction"
+	DocWillClose                      // Catalog additional action "WC"
+	DocWillSave                       // Catalog additional action "WS"
+	DocDidSave                        // Catalog additional action "DS"
+	DocWillPrint                      // Catalog additional action "WP"
+	DocDidPrint                       // Catalog additional action "DP"
+	PageOpen                          // Page additional action "O"
+	PageClose                         // Page additional action "C"
+)
+
+var actionTriggers = []struct {
+	name string
+	key  string
+}{
+	{"docOpen", "OpenAction"},
+	{"docWillClose", "WC"},
+	{"docWillSave", "WS"},
+	{"docDidSave", "DS"},
+	{"docWillPrint", "WP"},
+	{"docDidPrint", "DP"},
+	{"pageOpen", "O"},
+	{"pageClose", "C"},
+}
+
+func (t ActionTrigger) String() string {
+	if t < DocOpen || t > PageClose {
+		return "unknown"
+	}
+	return actionTriggers[t].name
+}
+
+// MarshalText renders t by name.
+func (t ActionTrigger) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// PageLevel returns true if t is triggered by a page event.
+func (t ActionTrigger) PageLevel() bool {
+	return t == PageOpen || t == PageClose
+}
+
+// ParseActionTrigger returns the action trigger named s.
+func ParseActionTrigger(s string) (ActionTrigger, error) {
+	for i, at := range actionTriggers {
+		if strings.EqualFold(s, at.name) {
+			return ActionTrigger(i), nil
+		}
+	}
+	return 0, errors.Errorf("pdfcpu: unknown action trigger: %s", s)
+}
+
+// Action represents an action triggered by a document or page event.
+type Action struct {
+	Trigger ActionTrigger `json:"trigger"`
+	PageNr  int           `json:"page,omitempty"`  // Page number for page level triggers.
+	Type    string        `json:"type"`            // Action type eg. JavaScript, Named, URI
+	Value   string        `json:"value,omitempty"` // JavaScript code, action name or URI.
+}
+
+// JavaScriptAction returns a JavaScript action dict executing js.
+func JavaScriptAction(js string) (types.Dict, error) {
+	s, err := types.Escape(js)
+	if err != nil {
+		return nil, err
+	}
+	return types.Dict(map[string]types.Object{
+		"Type": types.Name("Action"),
+		"S":    types.Name("JavaScript"),
+		"JS":   types.StringLiteral(*s),
+	}), nil
+}
+
+// NamedAction returns a named action dict eg. for "NextPage" or "Print".
+func NamedAction(name string) types.Dict {
+	return types.Dict(map[string]types.Object{
+		"Type": types.Name("Action"),
+		"S":    types.Name("Named"),
+		"N":    types.Name(name),
+	})
+}
+
+// PrintAction returns an action dict bringing up the print dialog of the viewer.
+func PrintAction() types.Dict {
+	d, _ := JavaScriptAction("this.print({bUI: true, bSilent: false, bShrinkToFit: true});")
+	return d
+}
+
+func actionValue(ctx *model.Context, d types.Dict) (string, error) {
+	s := d.NameEntry("S")
+	if s == nil {
+		return "", nil
+	}
+
+	switch *s {
+
+	case "JavaScript":
+		o, err := ctx.Dereference(d["JS"])
+		if err != nil || o == nil {
+			return "", err
+		}
+		if sd, ok := o.(types.StreamDict); ok {
+			if err := sd.Decode(); err != nil {
+				return "", err
+			}
+			return string(sd.Content), nil
+		}
+		js, err := types.StringOrHexLiteral(o)
+		if err != nil || js == nil {
+			return "", err
+		}
+		return *js, nil
+
+	case "Named":
+		if n := d.NameEntry("N"); n != nil {
+			return *n, nil
+		}
+
+	case "URI":
+		return ctx.DereferenceStringOrHexLiteral(d["URI"], model.V10, nil)
+	}
+
+	return "", nil
+}
+
+func action(ctx *model.Context, trigger ActionTrigger, pageNr int, o types.Object) (*Action, error) {
+	o, err := ctx.Dereference(o)
+	if err != nil || o == nil {
+		return nil, err
+	}
+
+	a := &Action{Trigger: trigger, PageNr: pageNr}
+
+	switch o := o.(type) {
+
+	case types.Dict:
+		if s := o.NameEntry("S"); s != nil {
+			a.Type = *s
+		}
+		if a.Value, err = actionValue(ctx, o); err != nil {
+			return nil, err
+		}
+
+	case types.Array:
+		// An open action may also be a destination.
+		a.Type = "Destination"
+
+	default:
+		return nil, errors.Errorf("pdfcpu: corrupt %s action", trigger)
+	}
+
+	return a, nil
+}
+
+func additionalActions(ctx *model.Context, d types.Dict, create bool) (types.Dict, error) {
+	aa, err := ctx.DereferenceDict(d["AA"])
+	if err != nil {
+		return nil, err
+	}
+	if aa == nil && create {
+		aa = types.NewDict()
+		d["AA"] = aa
+	}
+	return aa, nil
+}
+
+func triggerDict(ctx *model.Context, trigger ActionTrigger, pageNr int) (types.Dict, error) {
+	if !trigger.PageLevel() {
+		return ctx.Catalog()
+	}
+
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errors.Errorf("pdfcpu: invalid page number: %d", pageNr)
+	}
+
+	return d, nil
+}
+
+// SetAction registers the action dict d for trigger replacing any existing action.
+// pageNr is ignored for document level triggers.
+func SetAction(ctx *model.Context, trigger ActionTrigger, pageNr int, d types.Dict) error {
+	if trigger < DocOpen || trigger > PageClose {
+		return errors.Errorf("pdfcpu: unknown action trigger: %d", trigger)
+	}
+
+	if d.NameEntry("S") == nil {
+		return errors.New("pdfcpu: action dict missing \"S\"")
+	}
+
+	d1, err := triggerDict(ctx, trigger, pageNr)
+	if err != nil {
+		return err
+	}
+
+	if trigger == DocOpen {
+		d1["OpenAction"] = d
+		return nil
+	}
+
+	aa, err := additionalActions(ctx, d1, true)
+	if err != nil {
+		return err
+	}
+
+	aa[actionTriggers[trigger].key] = d
+
+	// Additional actions for the document and pages need PDF 1.4.
+	ctx.EnsureVersionForWriting()
+
+	return nil
+}
+
+func documentActions(ctx *model.Context) ([]Action, error) {
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	var aa []Action
+
+	a, err := action(ctx, DocOpen, 0, rootDict["OpenAction"])
+	if err != nil {
+		return nil, err
+	}
+	if a != nil {
+		aa = append(aa, *a)
+	}
+
+	d, err := additionalActions(ctx, rootDict, false)
+	if err != nil || d == nil {
+		return aa, err
+	}
+
+	for t := DocWillClose; t <= DocDidPrint; t++ {
+		a, err := action(ctx, t, 0, d[actionTriggers[t].key])
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			aa = append(aa, *a)
+		}
+	}
+
+	return aa, nil
+}
+
+func pageActions(ctx *model.Context, pageNr int) ([]Action, error) {
+	d, err := triggerDict(ctx, PageOpen, pageNr)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err = additionalActions(ctx, d, false)
+	if err != nil || d == nil {
+		return nil, err
+	}
+
+	var aa []Action
+
+	for _, t := range []ActionTrigger{PageOpen, PageClose} {
+		a, err := action(ctx, t, pageNr, d[actionTriggers[t].key])
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			aa = append(aa, *a)
+		}
+	}
+
+	return aa, nil
+}
+
+// Actions returns all document level actions and the page level actions of selected pages.
+func Actions(ctx *model.Context, selectedPages types.IntSet) ([]Action, error) {
+	aa, err := documentActions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		if selectedPages != nil && !selectedPages[i] {
+			continue
+		}
+		pa, err := pageActions(ctx, i)
+		if err != nil {
+			return nil, errors.Wrapf(err, "pdfcpu: page %d", i)
+		}
+		aa = append(aa, pa...)
+	}
+
+	return aa, nil
+}
+
+func removeAction(ctx *model.Context, d types.Dict, t ActionTrigger) (bool, error) {
+	if t == DocOpen {
+		if _, found := d.Find("OpenAction"); !found {
+			return false, nil
+		}
+		d.Delete("OpenAction")
+		return true, nil
+	}
+
+	aa, err := additionalActions(ctx, d, false)
+	if err != nil || aa == nil {
+		return false, err
+	}
+
+	key := actionTriggers[t].key
+	if _, found := aa.Find(key); !found {
+		return false, nil
+	}
+
+	aa.Delete(key)
+	if len(aa) == 0 {
+		d.Delete("AA")
+	}
+
+	return true, nil
+}
+
+// RemoveActions removes actions for triggers or all actions if triggers is empty.
+// Page level actions are removed from selected pages only.
+// Returns the number of removed actions.
+func RemoveActions(ctx *model.Context, triggers []ActionTrigger, selectedPages types.IntSet) (int, error) {
+	if len(triggers) == 0 {
+		for t := DocOpen; t <= PageClose; t++ {
+			triggers = append(triggers, t)
+		}
+	}
+
+	c := 0
+
+	for _, t := range triggers {
+		if t < DocOpen || t > PageClose {
+			return 0, errors.Errorf("pdfcpu: unknown action trigger: %d", t)
+		}
+
+		if !t.PageLevel() {
+			rootDict, err := ctx.Catalog()
+			if err != nil {
+				return 0, err
+			}
+			ok, err := removeAction(ctx, rootDict, t)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				c++
+			}
+			continue
+		}
+
+		for i := 1; i <= ctx.PageCount; i++ {
+			if selectedPages != nil && !selectedPages[i] {
+				continue
+			}
+			d, err := triggerDict(ctx, t, i)
+			if err != nil {
+				return 0, err
+			}
+			ok, err := removeAction(ctx, d, t)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				c++
+			}
+		}
+	}
+
+	return c, nil
+}
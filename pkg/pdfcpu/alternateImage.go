@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"io"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// AlternateImage represents an entry of the "Alternates" array of an image XObject.
+type AlternateImage struct {
+	ObjNr              int  // Object number of the alternate image.
+	DefaultForPrinting bool // True if this alternate is to be used for printing.
+}
+
+func imageStreamDict(ctx *model.Context, objNr int) (*types.StreamDict, error) {
+	entry, ok := ctx.FindTableEntryLight(objNr)
+	if !ok || entry.Free {
+		return nil, errors.Errorf("pdfcpu: invalid object number: %d", objNr)
+	}
+
+	sd, ok := entry.Object.(types.StreamDict)
+	if !ok || sd.Subtype() == nil || *sd.Subtype() != "Image" {
+		return nil, errors.Errorf("pdfcpu: obj#%d is not an image", objNr)
+	}
+
+	return &sd, nil
+}
+
+// AlternateImages returns the alternates of the image with object number objNr.
+func AlternateImages(ctx *model.Context, objNr int) ([]AlternateImage, error) {
+	sd, err := imageStreamDict(ctx, objNr)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := ctx.DereferenceArray(sd.Dict["Alternates"])
+	if err != nil {
+		return nil, err
+	}
+
+	aa := []AlternateImage{}
+
+	for _, o := range a {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil || d == nil {
+			return nil, errors.Errorf("pdfcpu: obj#%d: corrupt alternate image", objNr)
+		}
+		ir := d.IndirectRefEntry("Image")
+		if ir == nil {
+			return nil, errors.Errorf("pdfcpu: obj#%d: alternate image missing \"Image\"", objNr)
+		}
+		var printing bool
+		if b := d.BooleanEntry("DefaultForPrinting"); b != nil {
+			printing = *b
+		}
+		aa = append(aa, AlternateImage{ObjNr: ir.ObjectNumber.Value(), DefaultForPrinting: printing})
+	}
+
+	return aa, nil
+}
+
+// AddAlternateImage adds the image read from r as an alternate to the image with object number objNr
+// and returns the object number of the alternate image.
+// If defaultForPrinting is true the new alternate replaces any other alternate as default for printing.
+func AddAlternateImage(ctx *model.Context, objNr int, r io.Reader, defaultForPrinting bool) (int, error) {
+	sd, err := imageStreamDict(ctx, objNr)
+	if err != nil {
+		return 0, err
+	}
+
+	a, err := ctx.DereferenceArray(sd.Dict["Alternates"])
+	if err != nil {
+		return 0, err
+	}
+
+	if defaultForPrinting {
+		// At most one alternate may be the default for printing.
+		for _, o := range a {
+			if d, err := ctx.DereferenceDict(o); err == nil && d != nil {
+				d.Delete("DefaultForPrinting")
+			}
+		}
+	}
+
+	sdAlt, _, _, err := model.CreateImageStreamDict(ctx.XRefTable, r, false, false)
+	if err != nil {
+		return 0, err
+	}
+
+	ir, err := ctx.IndRefForNewObject(*sdAlt)
+	if err != nil {
+		return 0, err
+	}
+
+	d := types.Dict(map[string]types.Object{"Image": *ir})
+	if defaultForPrinting {
+		d["DefaultForPrinting"] = types.Boolean(true)
+	}
+
+	sd.Dict["Alternates"] = append(a, d)
+
+	return ir.ObjectNumber.Value(), nil
+}
+
+// RemoveAlternateImages removes the alternates of all images.
+// Returns the number of images modified.
+func RemoveAlternateImages(ctx *model.Context) (int, error) {
+	c := 0
+
+	for objNr, entry := range ctx.Table {
+		if entry == nil || entry.Free {
+			continue
+		}
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok || sd.Subtype() == nil || *sd.Subtype() != "Image" {
+			continue
+		}
+		if _, found := sd.Find("Alternates"); !found {
+			continue
+		}
+		sd.Delete("Alternates")
+		c++
+		if log.OptimizeEnabled() {
+			log.Optimize.Printf("removed alternate images of obj#%d\n", objNr)
+		}
+	}
+
+	return c, nil
+}
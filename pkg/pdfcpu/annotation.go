@@ -932,3 +932,71 @@ func RemoveAnnotations(ctx *model.Context, selectedPages types.IntSet, idsAndTyp
 
 	return removed, nil
 }
+
+func restyleLinks(ctx *model.Context, pageDict types.Dict, ls model.LinkStyle) (int, error) {
+	o, found := pageDict.Find("Annots")
+	if !found {
+		return 0, nil
+	}
+
+	annots, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, o := range annots {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return 0, err
+		}
+		if d == nil {
+			continue
+		}
+		if subtype := d.Subtype(); subtype == nil || *subtype != "Link" {
+			continue
+		}
+		if err := ls.Apply(d); err != nil {
+			return 0, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// RestyleLinks applies border and highlight style ls to all link annotations of selected pages
+// and returns the number of restyled links.
+// Use a zero border width to remove visible link borders.
+func RestyleLinks(ctx *model.Context, selectedPages types.IntSet, ls model.LinkStyle) (int, error) {
+	if err := ls.Validate(); err != nil {
+		return 0, err
+	}
+
+	var n int
+
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+
+		if selectedPages != nil {
+			if _, found := selectedPages[pageNr]; !found {
+				continue
+			}
+		}
+
+		d, _, _, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return 0, err
+		}
+		if d == nil {
+			return 0, errors.Errorf("pdfcpu: unknown page number: %d", pageNr)
+		}
+
+		i, err := restyleLinks(ctx, d, ls)
+		if err != nil {
+			return 0, err
+		}
+		n += i
+	}
+
+	return n, nil
+}
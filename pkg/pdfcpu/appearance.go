@@ -0,0 +1,632 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/color"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// The magic number for approximating a quarter circle using a cubic Bézier curve.
+const kappa = .5523
+
+// markupAppearance holds the parameters shared by all generated markup annotation appearances.
+type markupAppearance struct {
+	rect        *types.Rectangle
+	strokeCol   types.Array // C
+	fillCol     types.Array // IC
+	borderWidth float64
+	dash        []float64
+	blend       bool // Use blend mode Multiply, eg. for highlights.
+	fonts       bool // Use Helvetica as font F0.
+}
+
+// colorOp returns the PDF operator for setting the color represented by a.
+// Gray, RGB and CMYK colors are supported, an empty array means transparent.
+func colorOp(a types.Array, stroke bool) string {
+	ops := map[int]string{1: "g", 3: "rg", 4: "k"}
+	op, ok := ops[len(a)]
+	if !ok {
+		return ""
+	}
+	if stroke {
+		op = strings.ToUpper(op)
+	}
+	ss := make([]string, 0, len(a)+1)
+	for _, o := range a {
+		f, _ := numberValue(o)
+		ss = append(ss, strconv.FormatFloat(f, 'f', 3, 64))
+	}
+	return strings.Join(append(ss, op), " ") + " "
+}
+
+func annotColor(ctx *model.Context, d types.Dict, key string) (types.Array, error) {
+	o, found := d.Find(key)
+	if !found {
+		return nil, nil
+	}
+	return ctx.DereferenceArray(o)
+}
+
+// borderStyle returns the border width and dash pattern of an annotation.
+// The border style dictionary BS takes precedence over the Border array.
+func borderStyle(ctx *model.Context, d types.Dict) (float64, []float64, error) {
+	w, dash := 1., []float64(nil)
+
+	if o, found := d.Find("BS"); found {
+		bs, err := ctx.DereferenceDict(o)
+		if err != nil || bs == nil {
+			return w, nil, err
+		}
+		if o, found := bs.Find("W"); found {
+			if f, ok := numberValue(o); ok {
+				w = f
+			}
+		}
+		if s := bs.NameEntry("S"); s != nil && *s == "D" {
+			if dash, err = numberArray(ctx, bs, "D"); err != nil {
+				return w, nil, err
+			}
+			if dash == nil {
+				dash = []float64{3}
+			}
+		}
+		return w, dash, nil
+	}
+
+	a, err := annotColor(ctx, d, "Border")
+	if err != nil || len(a) < 3 {
+		return w, nil, err
+	}
+	if f, ok := numberValue(a[2]); ok {
+		w = f
+	}
+	if len(a) > 3 {
+		if dash, err = numberArray(ctx, types.Dict{"D": a[3]}, "D"); err != nil {
+			return w, nil, err
+		}
+	}
+
+	return w, dash, nil
+}
+
+// points returns the coordinate pairs of a number array.
+func points(ctx *model.Context, o types.Object) ([]types.Point, error) {
+	a, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return nil, err
+	}
+	pp := []types.Point{}
+	for i := 0; i+1 < len(a); i += 2 {
+		x, ok1 := numberValue(a[i])
+		y, ok2 := numberValue(a[i+1])
+		if ok1 && ok2 {
+			pp = append(pp, types.Point{X: x, Y: y})
+		}
+	}
+	return pp, nil
+}
+
+func writePath(w *bytes.Buffer, pp []types.Point) {
+	for i, p := range pp {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(w, "%.2f %.2f %s ", p.X, p.Y, op)
+	}
+}
+
+// paintOp returns the path painting operator depending on available colors.
+func (ma markupAppearance) paintOp(close bool) string {
+	stroke, fill := colorOp(ma.strokeCol, true) != "" && ma.borderWidth > 0, colorOp(ma.fillCol, false) != ""
+	switch {
+	case stroke && fill:
+		if close {
+			return "b"
+		}
+		return "B"
+	case fill:
+		return "f"
+	case stroke:
+		if close {
+			return "s"
+		}
+		return "S"
+	}
+	return "n"
+}
+
+func (ma markupAppearance) writeGraphicsState(w *bytes.Buffer) {
+	fmt.Fprintf(w, "%.2f w ", ma.borderWidth)
+	if len(ma.dash) > 0 {
+		ss := make([]string, len(ma.dash))
+		for i, f := range ma.dash {
+			ss[i] = strconv.FormatFloat(f, 'f', 2, 64)
+		}
+		fmt.Fprintf(w, "[%s] 0 d ", strings.Join(ss, " "))
+	}
+	w.WriteString(colorOp(ma.strokeCol, true))
+	w.WriteString(colorOp(ma.fillCol, false))
+}
+
+// innerRect returns the rectangle inside r shrunk by RD and half the border width.
+func innerRect(ctx *model.Context, d types.Dict, r *types.Rectangle, bw float64) (*types.Rectangle, error) {
+	rd, err := numberArray(ctx, d, "RD")
+	if err != nil {
+		return nil, err
+	}
+	if len(rd) != 4 {
+		rd = []float64{0, 0, 0, 0}
+	}
+	return types.NewRectangle(r.LL.X+rd[0]+bw/2, r.LL.Y+rd[3]+bw/2, r.UR.X-rd[2]-bw/2, r.UR.Y-rd[1]-bw/2), nil
+}
+
+func writeSquare(ctx *model.Context, w *bytes.Buffer, d types.Dict, ma *markupAppearance) error {
+	r, err := innerRect(ctx, d, ma.rect, ma.borderWidth)
+	if err != nil {
+		return err
+	}
+	ma.writeGraphicsState(w)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f re %s ", r.LL.X, r.LL.Y, r.Width(), r.Height(), ma.paintOp(false))
+	return nil
+}
+
+func writeCircle(ctx *model.Context, w *bytes.Buffer, d types.Dict, ma *markupAppearance) error {
+	r, err := innerRect(ctx, d, ma.rect, ma.borderWidth)
+	if err != nil {
+		return err
+	}
+	rx, ry := r.Width()/2, r.Height()/2
+	x, y := r.LL.X+rx, r.LL.Y+ry
+	ma.writeGraphicsState(w)
+	fmt.Fprintf(w, "%.2f %.2f m ", x+rx, y)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x+rx, y+kappa*ry, x+kappa*rx, y+ry, x, y+ry)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x-kappa*rx, y+ry, x-rx, y+kappa*ry, x-rx, y)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x-rx, y-kappa*ry, x-kappa*rx, y-ry, x, y-ry)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x+kappa*rx, y-ry, x+rx, y-kappa*ry, x+rx, y)
+	fmt.Fprintf(w, "%s ", ma.paintOp(true))
+	return nil
+}
+
+func writePolyLine(ctx *model.Context, w *bytes.Buffer, d types.Dict, ma *markupAppearance, key string, close bool) error {
+	pp, err := points(ctx, d[key])
+	if err != nil || len(pp) < 2 {
+		return err
+	}
+	ma.writeGraphicsState(w)
+	w.WriteString("1 j ")
+	writePath(w, pp)
+	if !close {
+		// Lines and polylines do not get filled, IC applies to line endings only.
+		w.WriteString("S ")
+		return nil
+	}
+	fmt.Fprintf(w, "%s ", ma.paintOp(true))
+	return nil
+}
+
+func writeInk(ctx *model.Context, w *bytes.Buffer, d types.Dict, ma *markupAppearance) error {
+	a, err := ctx.DereferenceArray(d["InkList"])
+	if err != nil || len(a) == 0 {
+		return err
+	}
+	ma.writeGraphicsState(w)
+	w.WriteString("1 J 1 j ")
+	for _, o := range a {
+		pp, err := points(ctx, o)
+		if err != nil {
+			return err
+		}
+		if len(pp) == 1 {
+			pp = append(pp, pp[0])
+		}
+		writePath(w, pp)
+	}
+	w.WriteString("S ")
+	return nil
+}
+
+// quads returns the quadrilaterals of a text markup annotation.
+// The points of each quadrilateral are ordered upper left, upper right, lower left, lower right.
+func quads(ctx *model.Context, d types.Dict, r *types.Rectangle) ([][]types.Point, error) {
+	pp, err := points(ctx, d["QuadPoints"])
+	if err != nil {
+		return nil, err
+	}
+	qq := [][]types.Point{}
+	for i := 0; i+3 < len(pp); i += 4 {
+		qq = append(qq, pp[i:i+4])
+	}
+	if len(qq) == 0 {
+		qq = append(qq, []types.Point{{X: r.LL.X, Y: r.UR.Y}, {X: r.UR.X, Y: r.UR.Y}, {X: r.LL.X, Y: r.LL.Y}, {X: r.UR.X, Y: r.LL.Y}})
+	}
+	return qq, nil
+}
+
+func distance(p, q types.Point) float64 {
+	return math.Hypot(q.X-p.X, q.Y-p.Y)
+}
+
+// between returns the point dividing the line from p to q in the ratio f.
+func between(p, q types.Point, f float64) types.Point {
+	return types.Point{X: p.X + f*(q.X-p.X), Y: p.Y + f*(q.Y-p.Y)}
+}
+
+func writeTextMarkup(ctx *model.Context, w *bytes.Buffer, d types.Dict, ma *markupAppearance, subtype string) error {
+	qq, err := quads(ctx, d, ma.rect)
+	if err != nil {
+		return err
+	}
+
+	if subtype == "Highlight" {
+		ma.blend = true
+		w.WriteString("/GS0 gs ")
+		w.WriteString(colorOp(ma.strokeCol, false))
+		for _, q := range qq {
+			writePath(w, []types.Point{q[0], q[1], q[3], q[2]})
+			w.WriteString("h ")
+		}
+		w.WriteString("f ")
+		return nil
+	}
+
+	w.WriteString(colorOp(ma.strokeCol, true))
+
+	for _, q := range qq {
+		h := distance(q[0], q[2])
+		lw := math.Max(h/14, .5)
+		fmt.Fprintf(w, "%.2f w ", lw)
+
+		switch subtype {
+
+		case "Underline":
+			f := lw / h
+			writePath(w, []types.Point{between(q[2], q[0], f), between(q[3], q[1], f)})
+
+		case "StrikeOut":
+			writePath(w, []types.Point{between(q[2], q[0], .5), between(q[3], q[1], .5)})
+
+		case "Squiggly":
+			l := distance(q[2], q[3])
+			n := int(l / (2 * lw * 2))
+			if n < 1 {
+				n = 1
+			}
+			pp := make([]types.Point, 0, n+1)
+			for i := 0; i <= n; i++ {
+				f := lw / h
+				if i%2 == 1 {
+					f = 3 * lw / h
+				}
+				p1, p2 := between(q[2], q[0], f), between(q[3], q[1], f)
+				pp = append(pp, between(p1, p2, float64(i)/float64(n)))
+			}
+			writePath(w, pp)
+		}
+
+		w.WriteString("S ")
+	}
+
+	return nil
+}
+
+// writeNote renders a generic note icon for text annotations.
+func writeNote(w *bytes.Buffer, ma *markupAppearance) {
+	r := ma.rect
+	if len(ma.strokeCol) == 0 {
+		ma.strokeCol = types.NewNumberArray(1, 1, 0)
+	}
+	fmt.Fprintf(w, "0 G 1 w %s", colorOp(ma.strokeCol, false))
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f re B ", r.LL.X+.5, r.LL.Y+.5, r.Width()-1, r.Height()-1)
+	for i := 1; i <= 3; i++ {
+		y := r.UR.Y - float64(i)*r.Height()/4
+		fmt.Fprintf(w, "%.2f %.2f m %.2f %.2f l S ", r.LL.X+r.Width()/5, y, r.UR.X-r.Width()/5, y)
+	}
+}
+
+// fontSizeAndColorFromDA extracts font size and fill color from a default appearance string.
+func fontSizeAndColorFromDA(s string) (int, color.SimpleColor) {
+	fontSize, col := 12, color.Black
+
+	da := strings.Fields(s)
+	for i, op := range da {
+		switch op {
+		case "Tf":
+			if i >= 1 {
+				if f, err := strconv.ParseFloat(da[i-1], 64); err == nil && f > 0 {
+					fontSize = int(math.Round(f))
+				}
+			}
+		case "g":
+			if i >= 1 {
+				g, _ := strconv.ParseFloat(da[i-1], 32)
+				col = color.SimpleColor{R: float32(g), G: float32(g), B: float32(g)}
+			}
+		case "rg":
+			if i >= 3 {
+				r, _ := strconv.ParseFloat(da[i-3], 32)
+				g, _ := strconv.ParseFloat(da[i-2], 32)
+				b, _ := strconv.ParseFloat(da[i-1], 32)
+				col = color.SimpleColor{R: float32(r), G: float32(g), B: float32(b)}
+			}
+		}
+	}
+
+	return fontSize, col
+}
+
+func writeFreeText(ctx *model.Context, w *bytes.Buffer, d types.Dict, ma *markupAppearance) error {
+	r, err := innerRect(ctx, d, ma.rect, ma.borderWidth)
+	if err != nil {
+		return err
+	}
+
+	// C is the background color of free text annotations.
+	ma.fillCol, ma.strokeCol = ma.strokeCol, types.Array{types.Integer(0)}
+	ma.writeGraphicsState(w)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f re %s ", r.LL.X, r.LL.Y, r.Width(), r.Height(), ma.paintOp(false))
+
+	s := ""
+	if o, found := d.Find("Contents"); found {
+		if s1, err := ctx.DereferenceStringOrHexLiteral(o, model.V10, nil); err == nil {
+			s = s1
+		}
+	}
+	if s == "" {
+		return nil
+	}
+
+	da := ""
+	if s1 := d.StringEntry("DA"); s1 != nil {
+		da = *s1
+	}
+	fontSize, col := fontSizeAndColorFromDA(da)
+
+	ma.fonts = true
+	td := model.TextDescriptor{
+		Text:      s,
+		FontName:  "Helvetica",
+		FontKey:   "F0",
+		FontSize:  fontSize,
+		X:         ma.borderWidth + 2,
+		Y:         r.Height() - ma.borderWidth - 2,
+		Scale:     1.0,
+		ScaleAbs:  true,
+		HAlign:    types.AlignJustify,
+		VAlign:    types.AlignTop,
+		StrokeCol: col,
+		FillCol:   col,
+	}
+
+	w.WriteString("q ")
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f re W n ", r.LL.X, r.LL.Y, r.Width(), r.Height())
+	model.WriteColumn(nil, w, r, nil, td, r.Width()-2*(ma.borderWidth+2))
+	w.WriteString("Q ")
+
+	return nil
+}
+
+// markupAppearanceContent renders the normal appearance of a markup annotation in default user space.
+func markupAppearanceContent(ctx *model.Context, d types.Dict, subtype string, ma *markupAppearance) ([]byte, bool, error) {
+	var (
+		w   bytes.Buffer
+		err error
+	)
+
+	w.WriteString("q ")
+
+	switch subtype {
+	case "Square":
+		err = writeSquare(ctx, &w, d, ma)
+	case "Circle":
+		err = writeCircle(ctx, &w, d, ma)
+	case "Line":
+		err = writePolyLine(ctx, &w, d, ma, "L", false)
+	case "PolyLine":
+		err = writePolyLine(ctx, &w, d, ma, "Vertices", false)
+	case "Polygon":
+		err = writePolyLine(ctx, &w, d, ma, "Vertices", true)
+	case "Ink":
+		err = writeInk(ctx, &w, d, ma)
+	case "Highlight", "Underline", "StrikeOut", "Squiggly":
+		err = writeTextMarkup(ctx, &w, d, ma, subtype)
+	case "Text":
+		writeNote(&w, ma)
+	case "FreeText":
+		err = writeFreeText(ctx, &w, d, ma)
+	default:
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	w.WriteString("Q")
+
+	return w.Bytes(), true, nil
+}
+
+func createMarkupAppearance(ctx *model.Context, d types.Dict, subtype string) (*types.IndirectRef, error) {
+	arr, err := numberArray(ctx, d, "Rect")
+	if err != nil || len(arr) != 4 {
+		return nil, err
+	}
+
+	r := types.NewRectangle(math.Min(arr[0], arr[2]), math.Min(arr[1], arr[3]), math.Max(arr[0], arr[2]), math.Max(arr[1], arr[3]))
+	if r.Width() == 0 || r.Height() == 0 {
+		return nil, nil
+	}
+
+	ma := markupAppearance{rect: r}
+
+	if ma.strokeCol, err = annotColor(ctx, d, "C"); err != nil {
+		return nil, err
+	}
+	if ma.fillCol, err = annotColor(ctx, d, "IC"); err != nil {
+		return nil, err
+	}
+	if ma.borderWidth, ma.dash, err = borderStyle(ctx, d); err != nil {
+		return nil, err
+	}
+	if len(ma.strokeCol) == 0 && subtype != "Text" && subtype != "FreeText" {
+		// Transparent.
+		ma.borderWidth = 0
+	}
+
+	bb, ok, err := markupAppearanceContent(ctx, d, subtype, &ma)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	sd, err := ctx.NewStreamDictForBuf(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	sd.InsertName("Type", "XObject")
+	sd.InsertName("Subtype", "Form")
+	sd.InsertInt("FormType", 1)
+	sd.Insert("BBox", r.Array())
+	sd.Insert("Matrix", types.NewNumberArray(1, 0, 0, 1, 0, 0))
+
+	resDict := types.Dict{}
+	if ma.blend {
+		resDict["ExtGState"] = types.Dict{"GS0": types.Dict{"Type": types.Name("ExtGState"), "BM": types.Name("Multiply")}}
+	}
+	if ma.fonts {
+		resDict["Font"] = types.Dict{
+			"F0": types.Dict{
+				"Type":     types.Name("Font"),
+				"Subtype":  types.Name("Type1"),
+				"BaseFont": types.Name("Helvetica"),
+				"Encoding": types.Name("WinAnsiEncoding"),
+			},
+		}
+	}
+	if len(resDict) > 0 {
+		sd.Insert("Resources", resDict)
+	}
+
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	return ctx.IndRefForNewObject(*sd)
+}
+
+func hasNormalAppearance(ctx *model.Context, d types.Dict) (bool, error) {
+	o, found := d.Find("AP")
+	if !found {
+		return false, nil
+	}
+	ap, err := ctx.DereferenceDict(o)
+	if err != nil || ap == nil {
+		return false, err
+	}
+	_, found = ap.Find("N")
+	return found, nil
+}
+
+func generatePageAnnotationAppearances(ctx *model.Context, pageNr int) (int, error) {
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil || d == nil {
+		return 0, err
+	}
+
+	o, found := d.Find("Annots")
+	if !found {
+		return 0, nil
+	}
+
+	annots, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return 0, err
+	}
+
+	c := 0
+
+	for _, o := range annots {
+
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return 0, err
+		}
+		if d == nil {
+			continue
+		}
+
+		subtype := d.NameEntry("Subtype")
+		if subtype == nil {
+			continue
+		}
+
+		ok, err := hasNormalAppearance(ctx, d)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			continue
+		}
+
+		irN, err := createMarkupAppearance(ctx, d, *subtype)
+		if err != nil {
+			return 0, err
+		}
+		if irN == nil {
+			continue
+		}
+
+		d["AP"] = types.Dict{"N": *irN}
+		c++
+	}
+
+	return c, nil
+}
+
+// GenerateAnnotationAppearances creates appearance streams for markup annotations of selectedPages lacking an appearance dict.
+// Supported are Square, Circle, Line, PolyLine, Polygon, Ink, Highlight, Underline, StrikeOut, Squiggly, Text and FreeText annotations.
+// Returns the number of generated appearances.
+func GenerateAnnotationAppearances(ctx *model.Context, selectedPages types.IntSet) (int, error) {
+	c := 0
+
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		if selectedPages != nil && !selectedPages[pageNr] {
+			continue
+		}
+		n, err := generatePageAnnotationAppearances(ctx, pageNr)
+		if err != nil {
+			return 0, err
+		}
+		c += n
+	}
+
+	if log.InfoEnabled() {
+		log.Info.Printf("generated %d annotation appearance(s)\n", c)
+	}
+
+	return c, nil
+}
@@ -66,24 +66,25 @@ func getPageNumber(pageNumbers []int, n int) int {
 	return pageNumbers[n]
 }
 
-func nup2OutputPageNr(inputPageNr, inputPageCount int, pageNumbers []int) (int, bool) {
+// nup2OutputPageNr returns the booklet position of the page to be rendered at output slot inputPageNr.
+func nup2OutputPageNr(inputPageNr, inputPageCount int) (int, bool) {
 	var p int
 	if inputPageNr%2 == 0 {
 		p = inputPageCount - 1 - inputPageNr/2
 	} else {
 		p = (inputPageNr - 1) / 2
 	}
-	pageNr := getPageNumber(pageNumbers, p)
 
 	// Rotate odd output pages (the back sides) by 180 degrees.
 	var rotate bool
 	if inputPageNr%4 < 2 {
 		rotate = true
 	}
-	return pageNr, rotate
+	return p, rotate
 }
 
-func nup4OutputPageNr(inputPageNr int, inputPageCount int, pageNumbers []int) (int, bool) {
+// nup4OutputPageNr returns the booklet position of the page to be rendered at output slot inputPageNr.
+func nup4OutputPageNr(inputPageNr int, inputPageCount int) (int, bool) {
 	bookletPageNumber := inputPageNr / 4
 	var p int
 	if bookletPageNumber%2 == 0 {
@@ -111,19 +112,19 @@ func nup4OutputPageNr(inputPageNr int, inputPageCount int, pageNumbers []int) (i
 			p = inputPageCount/2 + bookletPageNumber
 		}
 	}
-	pageNr := getPageNumber(pageNumbers, p)
 
 	// Rotate bottom row of each output page by 180 degrees.
 	var rotate bool
 	if inputPageNr%4 >= 2 {
 		rotate = true
 	}
-	return pageNr, rotate
+	return p, rotate
 }
 
 type bookletPage struct {
 	number int
 	rotate bool
+	depth  int // Nesting level of the folded leaf holding this page, 0 = outermost.
 }
 
 func sortSelectedPagesForBooklet(pages types.IntSet, nup *model.NUp) []bookletPage {
@@ -141,27 +142,60 @@ func sortSelectedPagesForBooklet(pages types.IntSet, nup *model.NUp) []bookletPa
 
 	bookletPages := make([]bookletPage, pageCount)
 
-	switch nup.N() {
-	case 2:
-		// (output page, input page) = [(1,n), (2,1), (3, n-1), (4, 2), (5, n-2), (6, 3), ...]
-		for i := 0; i < pageCount; i++ {
-			pageNr, rotate := nup2OutputPageNr(i, pageCount, pageNumbers)
-			bookletPages[i].number = pageNr
-			bookletPages[i].rotate = rotate
-		}
+	outputPageNr := nup2OutputPageNr
+	if nup.N() == 4 {
+		outputPageNr = nup4OutputPageNr
+	}
 
-	case 4:
-		// (output page, input page) = [(1,n), (2,1), (3, n/2+1), (4, n/2-0), (5, 2), (6, n-1), (7, n/2-1), (8, n/2+2) ...]
-		for i := 0; i < pageCount; i++ {
-			pageNr, rotate := nup4OutputPageNr(i, pageCount, pageNumbers)
-			bookletPages[i].number = pageNr
-			bookletPages[i].rotate = rotate
+	// n=2: (output page, input page) = [(1,n), (2,1), (3, n-1), (4, 2), (5, n-2), (6, 3), ...]
+	// n=4: (output page, input page) = [(1,n), (2,1), (3, n/2+1), (4, n/2-0), (5, 2), (6, n-1), (7, n/2-1), (8, n/2+2) ...]
+	for i := 0; i < pageCount; i++ {
+		p, rotate := outputPageNr(i, pageCount)
+		bookletPages[i].number = getPageNumber(pageNumbers, p)
+		bookletPages[i].rotate = rotate
+		// Booklet positions p and pageCount-1-p share a folded leaf.
+		if q := pageCount - 1 - p; q < p {
+			p = q
 		}
+		bookletPages[i].depth = p / 2
 	}
 
 	return bookletPages
 }
 
+// bookletCell returns rDest shifted toward the fold to compensate for creep
+// and away from the fold by nup.BindingShift.
+// leaves is the number of nested folded leaves.
+func bookletCell(nup *model.NUp, rDest *types.Rectangle, depth, leaves int) *types.Rectangle {
+	d := -nup.BindingShift
+	if leaves > 1 {
+		d += nup.Creep * float64(depth) / float64(leaves-1)
+	}
+	if d == 0 {
+		return rDest
+	}
+
+	r := *rDest
+
+	if nup.Grid.Width == 2 {
+		// The fold is vertical.
+		if r.Center().X > nup.PageDim.Width/2 {
+			d = -d
+		}
+		r.LL.X += d
+		r.UR.X += d
+		return &r
+	}
+
+	// The fold is horizontal.
+	if r.Center().Y > nup.PageDim.Height/2 {
+		d = -d
+	}
+	r.LL.Y += d
+	r.UR.Y += d
+	return &r
+}
+
 func bookletPages(
 	ctx *model.Context,
 	selectedPages types.IntSet,
@@ -173,18 +207,21 @@ func bookletPages(
 	formsResDict := types.NewDict()
 	rr := nup.RectsForGrid()
 
-	for i, bp := range sortSelectedPagesForBooklet(selectedPages, nup) {
+	bps := sortSelectedPagesForBooklet(selectedPages, nup)
+	leaves := len(bps) / 4
+
+	for i, bp := range bps {
 
 		if i > 0 && i%len(rr) == 0 {
 			// Wrap complete page.
-			if err := wrapUpPage(ctx, nup, formsResDict, buf, pagesDict, pagesIndRef); err != nil {
+			if err := wrapUpPage(ctx, nup, formsResDict, buf, nil, pagesDict, pagesIndRef); err != nil {
 				return err
 			}
 			buf.Reset()
 			formsResDict = types.NewDict()
 		}
 
-		rDest := rr[i%len(rr)]
+		rDest := bookletCell(nup, rr[i%len(rr)], bp.depth, leaves)
 
 		if bp.number == 0 {
 			// This is an empty page at the end.
@@ -200,7 +237,7 @@ func bookletPages(
 	}
 
 	// Wrap incomplete booklet page.
-	return wrapUpPage(ctx, nup, formsResDict, buf, pagesDict, pagesIndRef)
+	return wrapUpPage(ctx, nup, formsResDict, buf, nil, pagesDict, pagesIndRef)
 }
 
 // BookletFromImages creates a booklet version of the image sequence represented by fileNames.
@@ -212,8 +249,9 @@ func BookletFromImages(ctx *model.Context, fileNames []string, nup *model.NUp, p
 	}
 
 	if nup.PageGrid {
-		nup.PageDim.Width *= nup.Grid.Width
-		nup.PageDim.Height *= nup.Grid.Height
+		dx, dy := nup.GridSpacing()
+		nup.PageDim.Width = nup.PageDim.Width*nup.Grid.Width + dx
+		nup.PageDim.Height = nup.PageDim.Height*nup.Grid.Height + dy
 	}
 
 	xRefTable := ctx.XRefTable
@@ -221,12 +259,15 @@ func BookletFromImages(ctx *model.Context, fileNames []string, nup *model.NUp, p
 	var buf bytes.Buffer
 	rr := nup.RectsForGrid()
 
-	for i, bp := range sortSelectedPagesForBooklet(selectedPages, nup) {
+	bps := sortSelectedPagesForBooklet(selectedPages, nup)
+	leaves := len(bps) / 4
+
+	for i, bp := range bps {
 
 		if i > 0 && i%len(rr) == 0 {
 
 			// Wrap complete page.
-			if err := wrapUpPage(ctx, nup, formsResDict, buf, pagesDict, pagesIndRef); err != nil {
+			if err := wrapUpPage(ctx, nup, formsResDict, buf, nil, pagesDict, pagesIndRef); err != nil {
 				return err
 			}
 
@@ -234,7 +275,7 @@ func BookletFromImages(ctx *model.Context, fileNames []string, nup *model.NUp, p
 			formsResDict = types.NewDict()
 		}
 
-		rDest := rr[i%len(rr)]
+		rDest := bookletCell(nup, rr[i%len(rr)], bp.depth, leaves)
 
 		if bp.number == 0 {
 			// This is an empty page at the end of a booklet.
@@ -249,7 +290,7 @@ func BookletFromImages(ctx *model.Context, fileNames []string, nup *model.NUp, p
 			return err
 		}
 
-		imgIndRef, w, h, err := model.CreateImageResource(xRefTable, f, false, false)
+		imgIndRef, w, h, o, err := createNUpImageResource(xRefTable, f)
 		if err != nil {
 			return err
 		}
@@ -258,7 +299,7 @@ func BookletFromImages(ctx *model.Context, fileNames []string, nup *model.NUp, p
 			return err
 		}
 
-		formIndRef, err := createNUpFormForImage(xRefTable, imgIndRef, w, h, i)
+		formIndRef, err := createNUpFormForImage(xRefTable, imgIndRef, w, h, o, i)
 		if err != nil {
 			return err
 		}
@@ -268,11 +309,11 @@ func BookletFromImages(ctx *model.Context, fileNames []string, nup *model.NUp, p
 
 		// Append to content stream of booklet page i.
 		enforceOrientation := false
-		model.NUpTilePDFBytes(&buf, types.RectForDim(float64(w), float64(h)), rr[i%len(rr)], formResID, nup, bp.rotate, enforceOrientation)
+		model.NUpTilePDFBytes(&buf, types.RectForDim(float64(w), float64(h)), rDest, formResID, nup, bp.rotate, enforceOrientation)
 	}
 
 	// Wrap incomplete booklet page.
-	return wrapUpPage(ctx, nup, formsResDict, buf, pagesDict, pagesIndRef)
+	return wrapUpPage(ctx, nup, formsResDict, buf, nil, pagesDict, pagesIndRef)
 }
 
 // BookletFromPDF creates a booklet version of the PDF represented by xRefTable.
@@ -305,25 +346,46 @@ func BookletFromPDF(ctx *model.Context, selectedPages types.IntSet, nup *model.N
 
 	nup.PageDim = &types.Dim{Width: mb.Width(), Height: mb.Height()}
 
+	if nup.Signature > 0 {
+		// A sheet holds 2*n pages.
+		if nup.Signature%(2*n) != 0 {
+			return fmt.Errorf("pdfcpu: booklet signature size must be a multiple of %d, got %d", 2*n, nup.Signature)
+		}
+		nup.MultiFolio = true
+		nup.FolioSize = nup.Signature / (2 * n)
+	}
+
+	// Each signature gets oriented on its own starting from the configured sheet.
+	pageDim, grid := nup.PageDim, nup.Grid
+	signature := func(pages types.IntSet) error {
+		if nup.AutoOrient {
+			nup.PageDim, nup.Grid = pageDim, grid
+			if err := ctx.NUpAutoOrient(sortSelectedPages(pages), nup); err != nil {
+				return err
+			}
+		}
+		return bookletPages(ctx, pages, nup, pagesDict, pagesIndRef)
+	}
+
 	if nup.MultiFolio {
 		pages := types.IntSet{}
 		for _, i := range sortSelectedPages(selectedPages) {
 			pages[i] = true
-			if len(pages) == 4*nup.FolioSize {
-				if err = bookletPages(ctx, pages, nup, pagesDict, pagesIndRef); err != nil {
+			if len(pages) == 2*n*nup.FolioSize {
+				if err = signature(pages); err != nil {
 					return err
 				}
 				pages = types.IntSet{}
 			}
 		}
 		if len(pages) > 0 {
-			if err = bookletPages(ctx, pages, nup, pagesDict, pagesIndRef); err != nil {
+			if err = signature(pages); err != nil {
 				return err
 			}
 		}
 
 	} else {
-		if err = bookletPages(ctx, selectedPages, nup, pagesDict, pagesIndRef); err != nil {
+		if err = signature(selectedPages); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,299 @@
+/*
+	Copyright 2023 The pdfcpu Authors.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/color"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// levelBookmark is a bookmark along with its outline level starting at 1.
+type levelBookmark struct {
+	level int
+	bm    Bookmark
+}
+
+// bookmarkTree nests a flat sequence of bookmarks according to their levels.
+func bookmarkTree(lbms []levelBookmark) ([]Bookmark, error) {
+	var bms []Bookmark
+
+	// path holds the latest bookmark for each level.
+	var path []*[]Bookmark
+	path = append(path, &bms)
+
+	for i, lbm := range lbms {
+		if lbm.level < 1 || lbm.level > len(path) {
+			return nil, errors.Errorf("pdfcpu: bookmark %d \"%s\": invalid level %d", i+1, lbm.bm.Title, lbm.level)
+		}
+		path = path[:lbm.level]
+		kids := path[lbm.level-1]
+		*kids = append(*kids, lbm.bm)
+		path = append(path, &(*kids)[len(*kids)-1].Kids)
+	}
+
+	return bms, nil
+}
+
+func parseBookmarkStyle(s string, bm *Bookmark) error {
+	for _, s1 := range strings.Fields(strings.ToLower(s)) {
+		switch s1 {
+		case "b", "bold":
+			bm.Bold = true
+		case "i", "italic":
+			bm.Italic = true
+		case "bi", "ib":
+			bm.Bold, bm.Italic = true, true
+		default:
+			return errors.Errorf("pdfcpu: invalid bookmark style: %s, use bold and/or italic", s)
+		}
+	}
+	return nil
+}
+
+func parseBookmarkPage(s string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 1 {
+		return 0, errors.Errorf("pdfcpu: invalid bookmark page: %s", s)
+	}
+	return i, nil
+}
+
+func bookmarkFromCSVRecord(rec []string) (levelBookmark, error) {
+	var lbm levelBookmark
+
+	if len(rec) < 3 || len(rec) > 5 {
+		return lbm, errors.Errorf("pdfcpu: bookmark record needs 3 to 5 fields: level, title, page, style, color: %v", rec)
+	}
+
+	level, err := strconv.Atoi(rec[0])
+	if err != nil {
+		return lbm, errors.Errorf("pdfcpu: invalid bookmark level: %s", rec[0])
+	}
+	lbm.level = level
+
+	lbm.bm.Title = rec[1]
+	if lbm.bm.Title == "" {
+		return lbm, errors.New("pdfcpu: missing bookmark title")
+	}
+
+	if lbm.bm.PageFrom, err = parseBookmarkPage(rec[2]); err != nil {
+		return lbm, err
+	}
+
+	if len(rec) > 3 {
+		if err := parseBookmarkStyle(rec[3], &lbm.bm); err != nil {
+			return lbm, err
+		}
+	}
+
+	if len(rec) > 4 && rec[4] != "" {
+		c, err := color.ParseColor(rec[4])
+		if err != nil {
+			return lbm, err
+		}
+		lbm.bm.Color = &c
+	}
+
+	return lbm, nil
+}
+
+// ParseBookmarksCSV parses bookmarks from CSV records: level, title, page{, style{, color}}
+// eg. 2, "Chapter 1", 5, bold, #FF0000
+// Level starts at 1, style is any combination of bold and italic.
+// An optional header record starting with "level" is ignored.
+func ParseBookmarksCSV(rd io.Reader) ([]Bookmark, error) {
+	r := csv.NewReader(rd)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var lbms []levelBookmark
+
+	for i := 0; ; i++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j := range rec {
+			rec[j] = strings.TrimSpace(rec[j])
+		}
+		if i == 0 && strings.EqualFold(rec[0], "level") {
+			continue
+		}
+		if len(rec) == 1 && rec[0] == "" {
+			continue
+		}
+		lbm, err := bookmarkFromCSVRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		lbms = append(lbms, lbm)
+	}
+
+	return bookmarkTree(lbms)
+}
+
+var (
+	// A title followed by a page number optionally separated by leader dots or a bar.
+	reTitlePage = regexp.MustCompile(`^(.+?)[\s.|]*\s(\d+)$`)
+
+	// List markers: -, *, + and 1.
+	reListMarker = regexp.MustCompile(`^([-*+]|\d+\.)\s+`)
+)
+
+// emphasis strips Markdown emphasis from s and sets the corresponding bookmark style.
+func emphasis(s string, bm *Bookmark) string {
+	for _, e := range []string{"***", "___", "**", "__", "*", "_"} {
+		if len(s) > 2*len(e) && strings.HasPrefix(s, e) && strings.HasSuffix(s, e) {
+			bm.Bold = len(e) > 1
+			bm.Italic = len(e) != 2
+			return strings.TrimSpace(s[len(e) : len(s)-len(e)])
+		}
+	}
+	return s
+}
+
+func bookmarkFromLine(s string) (Bookmark, error) {
+	var bm Bookmark
+
+	ss := reTitlePage.FindStringSubmatch(s)
+	if ss == nil {
+		return bm, errors.Errorf("pdfcpu: missing bookmark page: %s", s)
+	}
+
+	page, err := parseBookmarkPage(ss[2])
+	if err != nil {
+		return bm, err
+	}
+
+	bm.PageFrom = page
+	bm.Title = emphasis(strings.TrimSpace(ss[1]), &bm)
+
+	return bm, nil
+}
+
+// indentation returns the width of the leading white space of s expanding tabs to 4 columns.
+func indentation(s string) int {
+	w := 0
+	for _, r := range s {
+		switch r {
+		case ' ':
+			w++
+		case '\t':
+			w += 4
+		default:
+			return w
+		}
+	}
+	return w
+}
+
+// ParseBookmarksText parses bookmarks from indented text or Markdown, one bookmark per line:
+// a title followed by a page number optionally separated by leader dots or a bar.
+// The level of a Markdown heading is the number of leading #.
+// Otherwise the level is given by the indentation of the line, list markers are ignored.
+// Markdown emphasis sets the bookmark style: *italic*, **bold**, ***bold italic***
+//
+//	# Part 1 .......... 1
+//	## **Chapter 1** .. 2
+//	- Introduction 1
+//	  - Motivation 2
+func ParseBookmarksText(rd io.Reader) ([]Bookmark, error) {
+	var (
+		lbms    []levelBookmark
+		indents []int // indentation per level for indented lines.
+	)
+
+	sc := bufio.NewScanner(rd)
+
+	for lineNr := 1; sc.Scan(); lineNr++ {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		s := strings.TrimSpace(line)
+		if s == "" {
+			continue
+		}
+
+		var level int
+
+		if h := strings.TrimLeft(s, "#"); h != s && strings.HasPrefix(h, " ") {
+			// Markdown heading
+			level = len(s) - len(h)
+			s = strings.TrimSpace(h)
+		} else {
+			w := indentation(line)
+			for len(indents) > 0 && indents[len(indents)-1] > w {
+				indents = indents[:len(indents)-1]
+			}
+			if len(indents) == 0 || indents[len(indents)-1] < w {
+				indents = append(indents, w)
+			}
+			level = len(indents)
+			s = reListMarker.ReplaceAllString(s, "")
+		}
+
+		bm, err := bookmarkFromLine(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNr)
+		}
+
+		lbms = append(lbms, levelBookmark{level: level, bm: bm})
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return bookmarkTree(lbms)
+}
+
+func importBookmarks(ctx *model.Context, bms []Bookmark, replace bool) (bool, error) {
+	if err := AddBookmarks(ctx, bms, replace); err != nil {
+		if err == errExistingBookmarks {
+			return false, nil
+		}
+		return true, err
+	}
+	return true, nil
+}
+
+// ImportBookmarksCSV creates/replaces outlines in ctx as provided by CSV records in rd.
+func ImportBookmarksCSV(ctx *model.Context, rd io.Reader, replace bool) (bool, error) {
+	bms, err := ParseBookmarksCSV(rd)
+	if err != nil {
+		return false, err
+	}
+	return importBookmarks(ctx, bms, replace)
+}
+
+// ImportBookmarksText creates/replaces outlines in ctx as provided by indented text or Markdown in rd.
+func ImportBookmarksText(ctx *model.Context, rd io.Reader, replace bool) (bool, error) {
+	bms, err := ParseBookmarksText(rd)
+	if err != nil {
+		return false, err
+	}
+	return importBookmarks(ctx, bms, replace)
+}
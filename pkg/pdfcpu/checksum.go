@@ -0,0 +1,239 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/filter"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Dict entries not contributing to the checksum of a page.
+var checksumIgnoredKeys = map[string]bool{
+	"CreationDate":   true,
+	"ModDate":        true,
+	"LastModified":   true,
+	"M":              true,
+	"ID":             true,
+	"Metadata":       true,
+	"PieceInfo":      true,
+	"Parent":         true,
+	"StructParent":   true,
+	"StructParents":  true,
+	"Length":         true,
+	"Filter":         true,
+	"DecodeParms":    true,
+	"FFilter":        true,
+	"FDecodeParms":   true,
+	"DL":             true,
+	"StructTreeRoot": true,
+}
+
+type pageHasher struct {
+	ctx     *model.Context
+	h       hash.Hash
+	visited map[int]int // objNr -> position in visiting order.
+}
+
+func (ph *pageHasher) writeString(s string) {
+	ph.h.Write([]byte(s))
+}
+
+func (ph *pageHasher) writeBytes(bb []byte) {
+	ph.writeString(fmt.Sprintf("%d:", len(bb)))
+	ph.h.Write(bb)
+}
+
+func (ph *pageHasher) writeDict(d types.Dict) error {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		if !checksumIgnoredKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	ph.writeString("<<")
+	for _, k := range keys {
+		ph.writeString("/" + k + " ")
+		if err := ph.write(d[k]); err != nil {
+			return err
+		}
+	}
+	ph.writeString(">>")
+
+	return nil
+}
+
+func (ph *pageHasher) writeStreamDict(sd types.StreamDict) error {
+	if err := ph.writeDict(sd.Dict); err != nil {
+		return err
+	}
+
+	// Prefer decoded content in order to be independent of the compression in use.
+	bb := sd.Raw
+	if err := sd.Decode(); err == nil && sd.Content != nil {
+		bb = sd.Content
+	} else if err != nil && err != filter.ErrUnsupportedFilter {
+		return err
+	}
+
+	ph.writeString("stream")
+	ph.writeBytes(bb)
+
+	return nil
+}
+
+func (ph *pageHasher) writeIndRef(ir types.IndirectRef) error {
+	objNr := ir.ObjectNumber.Value()
+
+	// Object numbers do not contribute to the checksum, cycles are taken care of.
+	if i, ok := ph.visited[objNr]; ok {
+		ph.writeString(fmt.Sprintf("@%d", i))
+		return nil
+	}
+	ph.visited[objNr] = len(ph.visited)
+
+	o, err := ph.ctx.Dereference(ir)
+	if err != nil {
+		return err
+	}
+
+	return ph.write(o)
+}
+
+func (ph *pageHasher) write(o types.Object) error {
+	switch o := o.(type) {
+
+	case nil:
+		ph.writeString("null")
+
+	case types.IndirectRef:
+		return ph.writeIndRef(o)
+
+	case types.Dict:
+		return ph.writeDict(o)
+
+	case types.StreamDict:
+		return ph.writeStreamDict(o)
+
+	case types.Array:
+		ph.writeString("[")
+		for _, o1 := range o {
+			if err := ph.write(o1); err != nil {
+				return err
+			}
+			ph.writeString(" ")
+		}
+		ph.writeString("]")
+
+	case types.StringLiteral:
+		bb, err := types.Unescape(o.Value(), false)
+		if err != nil {
+			return err
+		}
+		ph.writeString("s")
+		ph.writeBytes(bb)
+
+	case types.HexLiteral:
+		bb, err := o.Bytes()
+		if err != nil {
+			return err
+		}
+		ph.writeString("s")
+		ph.writeBytes(bb)
+
+	case types.Float:
+		ph.writeString(fmt.Sprintf("%.4f ", o.Value()))
+
+	case types.Integer:
+		// Integer and real representations of a number are equivalent.
+		ph.writeString(fmt.Sprintf("%.4f ", float64(o.Value())))
+
+	default:
+		ph.writeString(o.PDFString() + " ")
+	}
+
+	return nil
+}
+
+// PageChecksum returns a normalized SHA-256 checksum for page pageNr.
+// The checksum covers the decoded page content, the page resources and the page geometry.
+// It does not depend on object numbers, the compression in use or timestamps
+// and may be used to detect changed pages between two versions of a document.
+func PageChecksum(ctx *model.Context, pageNr int) (string, error) {
+	d, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return "", err
+	}
+	if d == nil {
+		return "", errors.Errorf("pdfcpu: invalid page number: %d", pageNr)
+	}
+
+	ph := &pageHasher{ctx: ctx, h: sha256.New(), visited: map[int]int{}}
+
+	bb, err := ctx.PageContent(d)
+	if err != nil && err != model.ErrNoContent {
+		return "", err
+	}
+	ph.writeString("content")
+	ph.writeBytes(bb)
+
+	ph.writeString("resources")
+	if err := ph.write(inhPAttrs.Resources); err != nil {
+		return "", err
+	}
+
+	if inhPAttrs.MediaBox != nil {
+		ph.writeString("mediabox" + inhPAttrs.MediaBox.String())
+	}
+	if inhPAttrs.CropBox != nil {
+		ph.writeString("cropbox" + inhPAttrs.CropBox.String())
+	}
+	ph.writeString(fmt.Sprintf("rotate%d", inhPAttrs.Rotate))
+
+	return hex.EncodeToString(ph.h.Sum(nil)), nil
+}
+
+// PageChecksums returns normalized checksums for selected pages or all pages if selectedPages is empty.
+func PageChecksums(ctx *model.Context, selectedPages types.IntSet) (map[int]string, error) {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	m := map[int]string{}
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		if len(selectedPages) > 0 && !selectedPages[i] {
+			continue
+		}
+		s, err := PageChecksum(ctx, i)
+		if err != nil {
+			return nil, err
+		}
+		m[i] = s
+	}
+
+	return m, nil
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/filter"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// ContentOperation represents an operator along with its operands as found in a content stream.
+type ContentOperation struct {
+	Operands  []types.Object
+	Operator  string
+	ImageDict types.Dict // Inline image parameters for operator BI.
+	ImageData []byte     // Inline image data for operator BI.
+}
+
+func contentWhitespace(c byte) bool {
+	return c == 0x00 || c == 0x09 || c == 0x0A || c == 0x0C || c == 0x0D || c == 0x20
+}
+
+func contentDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// skipContentWhitespace skips whitespace and comments.
+func skipContentWhitespace(s string) string {
+	for len(s) > 0 {
+		if contentWhitespace(s[0]) {
+			s = s[1:]
+			continue
+		}
+		if s[0] != '%' {
+			break
+		}
+		i := strings.IndexAny(s, "\x0A\x0D")
+		if i < 0 {
+			return ""
+		}
+		s = s[i:]
+	}
+	return s
+}
+
+func contentToken(s string) string {
+	for i := 0; i < len(s); i++ {
+		if contentWhitespace(s[i]) || contentDelimiter(s[i]) {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func contentNumber(t string) (types.Object, bool) {
+	if strings.IndexAny(t[:1], "+-.0123456789") < 0 {
+		return nil, false
+	}
+	if !strings.Contains(t, ".") {
+		if i, err := strconv.Atoi(t); err == nil {
+			return types.Integer(i), true
+		}
+	}
+	f, err := strconv.ParseFloat(t, 64)
+	if err != nil {
+		return nil, false
+	}
+	return types.Float(f), true
+}
+
+// inlineImageEnd returns the position of the EI operator terminating inline image data.
+func inlineImageEnd(s string) int {
+	for i := 0; ; {
+		j := strings.Index(s[i:], "EI")
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if i > 0 && contentWhitespace(s[i-1]) && (i+2 == len(s) || contentWhitespace(s[i+2])) {
+			return i
+		}
+		i += 2
+	}
+}
+
+func parseInlineImage(s *string) (*ContentOperation, error) {
+	l := *s
+	d := types.Dict{}
+
+	for {
+		l = skipContentWhitespace(l)
+		if len(l) == 0 {
+			return nil, errors.New("pdfcpu: content stream: inline image: missing ID")
+		}
+		if t := contentToken(l); t == "ID" {
+			l = l[2:]
+			break
+		}
+		k, err := model.ParseObject(&l)
+		if err != nil {
+			return nil, errors.Wrap(err, "pdfcpu: content stream: inline image")
+		}
+		key, ok := k.(types.Name)
+		if !ok {
+			return nil, errors.Errorf("pdfcpu: content stream: inline image: invalid key: %v", k)
+		}
+		l = skipContentWhitespace(l)
+		v, err := model.ParseObject(&l)
+		if err != nil {
+			return nil, errors.Wrap(err, "pdfcpu: content stream: inline image")
+		}
+		d[key.Value()] = v
+	}
+
+	// A single white-space character follows ID.
+	if len(l) > 0 && contentWhitespace(l[0]) {
+		l = l[1:]
+	}
+
+	i := inlineImageEnd(l)
+	if i < 0 {
+		return nil, errors.New("pdfcpu: content stream: inline image: missing EI")
+	}
+
+	// The white-space character preceding EI is not part of the image data.
+	data := []byte(l[:i-1])
+
+	*s = l[i+2:]
+
+	return &ContentOperation{Operator: "BI", ImageDict: d, ImageData: data}, nil
+}
+
+// ParseContentStream parses the decoded content stream bb into a sequence of operations.
+func ParseContentStream(bb []byte) ([]ContentOperation, error) {
+	var (
+		ops      []ContentOperation
+		operands []types.Object
+	)
+
+	s := string(bb)
+
+	for {
+		s = skipContentWhitespace(s)
+		if len(s) == 0 {
+			break
+		}
+
+		switch s[0] {
+
+		case '/', '(', '<', '[':
+			o, err := model.ParseObject(&s)
+			if err != nil {
+				return nil, errors.Wrap(err, "pdfcpu: content stream")
+			}
+			operands = append(operands, o)
+			continue
+
+		case ')', '>', ']', '{', '}':
+			return nil, errors.Errorf("pdfcpu: content stream: unexpected delimiter: %c", s[0])
+		}
+
+		t := contentToken(s)
+		s = s[len(t):]
+
+		if o, ok := contentNumber(t); ok {
+			operands = append(operands, o)
+			continue
+		}
+
+		switch t {
+		case "true", "false":
+			operands = append(operands, types.Boolean(t == "true"))
+			continue
+		case "null":
+			operands = append(operands, nil)
+			continue
+		}
+
+		if t == "BI" {
+			if len(operands) > 0 {
+				return nil, errors.New("pdfcpu: content stream: inline image: unexpected operands")
+			}
+			op, err := parseInlineImage(&s)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, *op)
+			continue
+		}
+
+		ops = append(ops, ContentOperation{Operands: operands, Operator: t})
+		operands = nil
+	}
+
+	if len(operands) > 0 {
+		return nil, errors.Errorf("pdfcpu: content stream: operands without operator: %v", operands)
+	}
+
+	return ops, nil
+}
+
+// ParsePageContent parses the content of page pageNr into a sequence of operations.
+func ParsePageContent(ctx *model.Context, pageNr int) ([]ContentOperation, error) {
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errors.Errorf("pdfcpu: invalid page number: %d", pageNr)
+	}
+
+	bb, err := ctx.PageContent(d)
+	if err == model.ErrNoContent {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseContentStream(bb)
+}
+
+func contentOperandString(o types.Object) string {
+	switch o := o.(type) {
+
+	case nil:
+		return "null"
+
+	case types.Float:
+		return strconv.FormatFloat(o.Value(), 'f', -1, 64)
+
+	case types.Array:
+		ss := make([]string, len(o))
+		for i, o1 := range o {
+			ss[i] = contentOperandString(o1)
+		}
+		return "[" + strings.Join(ss, " ") + "]"
+
+	case types.Dict:
+		return "<<" + contentDictString(o) + ">>"
+	}
+
+	return o.PDFString()
+}
+
+func contentDictString(d types.Dict) string {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ss := make([]string, len(keys))
+	for i, k := range keys {
+		ss[i] = types.Name(k).PDFString() + " " + contentOperandString(d[k])
+	}
+	return strings.Join(ss, " ")
+}
+
+func (op ContentOperation) String() string {
+	if op.Operator == "BI" {
+		return "BI " + contentDictString(op.ImageDict) + " ID " + string(op.ImageData) + "\nEI"
+	}
+
+	var sb strings.Builder
+	for _, o := range op.Operands {
+		sb.WriteString(contentOperandString(o))
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(op.Operator)
+	return sb.String()
+}
+
+// ContentStreamBytes serializes ops into a content stream.
+func ContentStreamBytes(ops []ContentOperation) []byte {
+	var buf bytes.Buffer
+	for _, op := range ops {
+		buf.WriteString(op.String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// setContentStream replaces the content of sd with bb using Flate encoding.
+func setContentStream(sd *types.StreamDict, bb []byte) error {
+	sd.Content = bb
+	sd.FilterPipeline = []types.PDFFilter{{Name: filter.Flate, DecodeParms: nil}}
+	sd.InsertName("Filter", filter.Flate)
+	sd.Delete("DecodeParms")
+	return sd.Encode()
+}
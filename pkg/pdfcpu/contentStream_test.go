@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+func TestParseContentStream(t *testing.T) {
+	s := `q 1 0 0 1 72.5 -.25 cm % comment
+	/CS0 cs 0.5 g /GS0 gs BT /F1 12 Tf (Hello \(World\)) Tj [(a) -120 <00410042>] TJ ET
+	/Span <</ActualText <FEFF0020>>> BDC EMC 0 0 m 10 10 l S
+	BI /W 2 /H 1 /CS /G /BPC 8 ID ab
+EI Q`
+
+	ops, err := ParseContentStream([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var operators []string
+	for _, op := range ops {
+		operators = append(operators, op.Operator)
+	}
+
+	want := []string{"q", "cm", "cs", "g", "gs", "BT", "Tf", "Tj", "TJ", "ET", "BDC", "EMC", "m", "l", "S", "BI", "Q"}
+	if !reflect.DeepEqual(operators, want) {
+		t.Fatalf("want: %v\ngot: %v\n", want, operators)
+	}
+
+	if got := ops[1].Operands; !reflect.DeepEqual(got, []types.Object{
+		types.Integer(1), types.Integer(0), types.Integer(0), types.Integer(1), types.Float(72.5), types.Float(-.25)}) {
+		t.Fatalf("cm operands: %v\n", got)
+	}
+
+	bi := ops[15]
+	if string(bi.ImageData) != "ab" || bi.ImageDict.IntEntry("W") == nil || *bi.ImageDict.IntEntry("W") != 2 {
+		t.Fatalf("inline image: %v %s\n", bi.ImageDict, bi.ImageData)
+	}
+
+	// Serialize and parse again.
+	ops2, err := ParseContentStream(ContentStreamBytes(ops))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ops, ops2) {
+		t.Fatalf("roundtrip mismatch:\n%s\n", ContentStreamBytes(ops2))
+	}
+}
+
+func TestParseContentStreamCorrupt(t *testing.T) {
+	for _, s := range []string{
+		"1 0 0",            // operands without operator
+		"BI /W 1 ID x",     // missing EI
+		"(unterminated Tj", // corrupt string literal
+	} {
+		if _, err := ParseContentStream([]byte(s)); err == nil {
+			t.Fatalf("%s: expected error\n", s)
+		}
+	}
+}
@@ -448,13 +448,8 @@ func cacheResIDs(ctx *model.Context, pdf *primitives.PDF) error {
 	return nil
 }
 
-func parseFromJSON(ctx *model.Context, bb []byte) (*primitives.PDF, error) {
-
-	if !json.Valid(bb) {
-		return nil, errors.Errorf("pdfcpu: invalid JSON encoding detected.")
-	}
-
-	pdf := &primitives.PDF{
+func newPDF(ctx *model.Context) *primitives.PDF {
+	return &primitives.PDF{
 		FieldIDs:      types.StringSet{},
 		Fields:        types.Array{},
 		FormFonts:     map[string]*primitives.FormFont{},
@@ -468,11 +463,9 @@ func parseFromJSON(ctx *model.Context, bb []byte) (*primitives.PDF, error) {
 		RadioBtnAPs:   map[float64]*primitives.AP{},
 		OldFieldIDs:   types.StringSet{},
 	}
+}
 
-	if err := json.Unmarshal(bb, pdf); err != nil {
-		return nil, err
-	}
-
+func prepareForUpdate(ctx *model.Context, pdf *primitives.PDF) error {
 	if pdf.Update() {
 
 		_, found := ctx.RootDict.Find("AcroForm")
@@ -481,17 +474,32 @@ func parseFromJSON(ctx *model.Context, bb []byte) (*primitives.PDF, error) {
 
 		if pdf.HasForm {
 			if err := cacheFormFieldIDs(ctx, pdf); err != nil {
-				return nil, err
+				return err
 			}
 		}
 
 		if err := cacheResIDs(ctx, pdf); err != nil {
-			return nil, err
+			return err
 		}
 
 	}
 
-	if err := pdf.Validate(); err != nil {
+	return pdf.Validate()
+}
+
+func parseFromJSON(ctx *model.Context, bb []byte) (*primitives.PDF, error) {
+
+	if !json.Valid(bb) {
+		return nil, errors.Errorf("pdfcpu: invalid JSON encoding detected.")
+	}
+
+	pdf := newPDF(ctx)
+
+	if err := json.Unmarshal(bb, pdf); err != nil {
+		return nil, err
+	}
+
+	if err := prepareForUpdate(ctx, pdf); err != nil {
 		return nil, err
 	}
 
@@ -737,6 +745,10 @@ func FromJSON(ctx *model.Context, rd io.Reader) error {
 		return err
 	}
 
+	return render(ctx, pdf)
+}
+
+func render(ctx *model.Context, pdf *primitives.PDF) error {
 	pages, fontMap, err := pdf.RenderPages()
 	if err != nil {
 		return err
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package create
+
+import (
+	"strconv"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/primitives"
+	"github.com/pkg/errors"
+)
+
+// FormFields collects form fields to be added to existing pages.
+// Field positions are in user space relative to the lower left page corner.
+// Fields without a font use the named font "input", labels the named font "label".
+// Both default to Helvetica 12 and may be overridden via Fonts.
+type FormFields struct {
+	Fonts map[string]*primitives.FormFont // named fonts
+	pages map[int]*primitives.Content
+}
+
+// NewFormFields returns an empty form field collection.
+func NewFormFields() *FormFields {
+	return &FormFields{
+		Fonts: map[string]*primitives.FormFont{},
+		pages: map[int]*primitives.Content{},
+	}
+}
+
+func (ff *FormFields) content(pageNr int) *primitives.Content {
+	c, ok := ff.pages[pageNr]
+	if !ok {
+		c = &primitives.Content{}
+		ff.pages[pageNr] = c
+	}
+	return c
+}
+
+// AddTextField adds tf to page pageNr.
+func (ff *FormFields) AddTextField(pageNr int, tf *primitives.TextField) *FormFields {
+	c := ff.content(pageNr)
+	c.TextFields = append(c.TextFields, tf)
+	return ff
+}
+
+// AddDateField adds df to page pageNr.
+func (ff *FormFields) AddDateField(pageNr int, df *primitives.DateField) *FormFields {
+	c := ff.content(pageNr)
+	c.DateFields = append(c.DateFields, df)
+	return ff
+}
+
+// AddCheckBox adds cb to page pageNr.
+func (ff *FormFields) AddCheckBox(pageNr int, cb *primitives.CheckBox) *FormFields {
+	c := ff.content(pageNr)
+	c.CheckBoxes = append(c.CheckBoxes, cb)
+	return ff
+}
+
+// AddRadioButtonGroup adds rbg to page pageNr.
+func (ff *FormFields) AddRadioButtonGroup(pageNr int, rbg *primitives.RadioButtonGroup) *FormFields {
+	c := ff.content(pageNr)
+	c.RadioButtonGroups = append(c.RadioButtonGroups, rbg)
+	return ff
+}
+
+// AddComboBox adds cb to page pageNr.
+func (ff *FormFields) AddComboBox(pageNr int, cb *primitives.ComboBox) *FormFields {
+	c := ff.content(pageNr)
+	c.ComboBoxes = append(c.ComboBoxes, cb)
+	return ff
+}
+
+// AddListBox adds lb to page pageNr.
+func (ff *FormFields) AddListBox(pageNr int, lb *primitives.ListBox) *FormFields {
+	c := ff.content(pageNr)
+	c.ListBoxes = append(c.ListBoxes, lb)
+	return ff
+}
+
+// AddSignatureField adds sf to page pageNr.
+func (ff *FormFields) AddSignatureField(pageNr int, sf *primitives.SignatureField) *FormFields {
+	c := ff.content(pageNr)
+	c.SignatureFields = append(c.SignatureFields, sf)
+	return ff
+}
+
+// AddFormFields adds the form fields collected in ff to existing pages of ctx.
+func AddFormFields(ctx *model.Context, ff *FormFields) error {
+	if ff == nil || len(ff.pages) == 0 {
+		return errors.New("pdfcpu: missing form fields")
+	}
+
+	pdf := newPDF(ctx)
+
+	pdf.Fonts = map[string]*primitives.FormFont{
+		"input": {Name: "Helvetica", Size: 12},
+		"label": {Name: "Helvetica", Size: 12},
+	}
+	for k, f := range ff.Fonts {
+		pdf.Fonts[k] = f
+	}
+
+	pdf.Pages = map[string]*primitives.PDFPage{}
+	for pageNr, c := range ff.pages {
+		if pageNr < 1 || pageNr > ctx.PageCount {
+			return errors.Errorf("pdfcpu: invalid page number: %d", pageNr)
+		}
+		pdf.Pages[strconv.Itoa(pageNr)] = &primitives.PDFPage{Content: c}
+	}
+
+	if err := prepareForUpdate(ctx, pdf); err != nil {
+		return err
+	}
+
+	return render(ctx, pdf)
+}
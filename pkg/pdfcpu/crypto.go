@@ -26,6 +26,7 @@ import (
 	"crypto/rand"
 	"crypto/rc4"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -57,6 +58,7 @@ var (
 		model.MERGEAPPEND:             {0, 0},
 		model.EXTRACTIMAGES:           {1, 0},
 		model.EXTRACTFONTS:            {1, 0},
+		model.LISTFONTS:               {0, 0},
 		model.EXTRACTPAGES:            {1, 0},
 		model.EXTRACTCONTENT:          {1, 0},
 		model.EXTRACTMETADATA:         {1, 0},
@@ -106,13 +108,48 @@ var (
 		model.RESETFORMFIELDS:         {0, 1},
 		model.EXPORTFORMFIELDS:        {0, 1},
 		model.FILLFORMFIELDS:          {0, 1},
-	}
-
-	ErrUnknownEncryption = errors.New("pdfcpu: PDF 2.0 encryption not supported")
+		model.FLATTENFORM:             {0, 1},
+		model.ADDFORMFIELDS:           {0, 1},
+		model.RENAMEFORMFIELDS:        {0, 1},
+		model.REGENERATEAPPEARANCES:   {0, 1},
+		model.EXTRACTTEXT:             {1, 0},
+		model.LISTPAGESTATS:           {0, 0},
+		model.AUDITATTACHMENTS:        {0, 0},
+		model.INSERTPAGESFROM:         {0, 1},
+		model.ADDALTERNATEIMAGE:       {0, 1},
+		model.REORDERPAGES:            {1, 0},
+		model.LISTACTIONS:             {0, 0},
+		model.SETACTIONS:              {0, 1},
+		model.REMOVEACTIONS:           {0, 1},
+		model.REMOVEHIDDENLAYERS:      {0, 1},
+		model.INHERITPAGEATTRS:        {0, 1},
+		model.LISTPAGETEMPLATES:       {0, 0},
+		model.ADDPAGETEMPLATES:        {0, 1},
+		model.REMOVEPAGETEMPLATES:     {0, 1},
+		model.SPAWNPAGETEMPLATES:      {0, 1},
+		model.RESTYLELINKS:            {0, 1},
+		model.LISTARTICLES:            {0, 0},
+		model.ADDARTICLES:             {0, 1},
+		model.REMOVEARTICLES:          {0, 1},
+		model.ANALYZEJAVASCRIPT:       {0, 0},
+		model.LISTJAVASCRIPT:          {0, 0},
+		model.REMOVEJAVASCRIPT:        {0, 1},
+		model.REPLACEJAVASCRIPT:       {0, 1},
+		model.LISTXFA:                 {0, 0},
+		model.EXTRACTXFA:              {0, 0},
+		model.REMOVEXFA:               {0, 1},
+		model.SETFORMFIELDACCESS:      {0, 1},
+		model.SETSIGNATUREFIELDLOCK:   {0, 1},
+		model.SIGN:                    {0, 1},
+		model.VALIDATESIGNATURES:      {0, 0},
+	}
+
+	ErrUnknownEncryption = errors.New("pdfcpu: unknown encryption revision")
 )
 
 // NewEncryptDict creates a new EncryptDict using the standard security handler.
-func newEncryptDict(needAES bool, keyLength int, permissions int16) types.Dict {
+// AES-256 encryption for PDF 2.0 files uses revision 6 of the security handler.
+func newEncryptDict(needAES bool, keyLength int, permissions int16, pdf20 bool) types.Dict {
 
 	d := types.NewDict()
 
@@ -120,12 +157,15 @@ func newEncryptDict(needAES bool, keyLength int, permissions int16) types.Dict {
 
 	if keyLength >= 128 {
 		d.Insert("Length", types.Integer(keyLength))
-		i := 4
+		r, v := 4, 4
 		if keyLength == 256 {
-			i = 5
+			r, v = 5, 5
+			if pdf20 {
+				r = 6
+			}
 		}
-		d.Insert("R", types.Integer(i))
-		d.Insert("V", types.Integer(i))
+		d.Insert("R", types.Integer(r))
+		d.Insert("V", types.Integer(v))
 	} else {
 		d.Insert("R", types.Integer(2))
 		d.Insert("V", types.Integer(1))
@@ -223,16 +263,16 @@ func encKey(userpw string, e *model.Enc) (key []byte) {
 }
 
 // validateUserPassword validates the user password aka document open password.
-func validateUserPassword(ctx *model.Context) (ok bool, err error) {
+func validateUserPassword(ctx *model.Context, userpw string) (ok bool, err error) {
 
-	if ctx.E.R == 5 {
-		return validateUserPasswordAES256(ctx)
+	if ctx.E.R >= 5 {
+		return validateUserPasswordAES256(ctx, userpw)
 	}
 
 	// Alg.4/5 p63
 	// 4a/5a create encryption key using Alg.2 p61
 
-	u, key, err := u(ctx)
+	u, key, err := u(ctx, userpw)
 	if err != nil {
 		return false, err
 	}
@@ -289,10 +329,7 @@ func key(ownerpw, userpw string, r, l int) (key []byte) {
 }
 
 // O calculates the owner password digest.
-func o(ctx *model.Context) ([]byte, error) {
-
-	ownerpw := ctx.OwnerPW
-	userpw := ctx.UserPW
+func o(ctx *model.Context, ownerpw, userpw string) ([]byte, error) {
 
 	e := ctx.E
 
@@ -336,13 +373,11 @@ func o(ctx *model.Context) ([]byte, error) {
 }
 
 // U calculates the user password digest.
-func u(ctx *model.Context) (u []byte, key []byte, err error) {
+func u(ctx *model.Context, userpw string) (u []byte, key []byte, err error) {
 
 	// The PW string is generated from OS codepage characters by first converting the string to
 	// PDFDocEncoding. If input is Unicode, first convert to a codepage encoding , and then to
 	// PDFDocEncoding for backward compatibility.
-	userpw := ctx.UserPW
-	//fmt.Printf("U userpw=ctx.UserPW=%s\n", userpw)
 
 	e := ctx.E
 
@@ -406,33 +441,103 @@ func keySalt(bb []byte) []byte {
 	return bb[40:]
 }
 
-func validateOwnerPasswordAES256(ctx *model.Context) (ok bool, err error) {
+// passwordAES256 prepares pw for the AES-256 security handlers (Algorithm 2.A, ISO 32000-2 7.6.4.3.3):
+// the password is processed with SASLprep, encoded as UTF-8 and truncated to 127 bytes.
+func passwordAES256(pw string) ([]byte, error) {
+	s, err := saslPrep(pw)
+	if err != nil {
+		return nil, err
+	}
+	bb := []byte(s)
+	if len(bb) > 127 {
+		bb = bb[:127]
+	}
+	return bb, nil
+}
+
+// hashAES256 computes the hash of pw, salt and u as used for AES-256 encryption.
+// Revision 6 of the standard security handler (PDF 2.0) applies Algorithm 2.B.
+func hashAES256(pw, salt, u []byte, r int) ([]byte, error) {
+	b := make([]byte, 0, len(pw)+len(salt)+len(u))
+	b = append(b, pw...)
+	b = append(b, salt...)
+	b = append(b, u...)
+
+	h := sha256.Sum256(b)
+	k := h[:]
+
+	if r < 6 {
+		return k, nil
+	}
+
+	for i := 1; ; i++ {
+
+		b = make([]byte, 0, len(pw)+len(k)+len(u))
+		b = append(b, pw...)
+		b = append(b, k...)
+		b = append(b, u...)
+		k1 := bytes.Repeat(b, 64)
+
+		cb, err := aes.NewCipher(k[:16])
+		if err != nil {
+			return nil, err
+		}
 
-	if len(ctx.OwnerPW) == 0 {
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(cb, k[16:32]).CryptBlocks(e, k1)
+
+		var sum int
+		for _, c := range e[:16] {
+			sum += int(c)
+		}
+
+		switch sum % 3 {
+		case 0:
+			h := sha256.Sum256(e)
+			k = h[:]
+		case 1:
+			h := sha512.Sum384(e)
+			k = h[:]
+		case 2:
+			h := sha512.Sum512(e)
+			k = h[:]
+		}
+
+		if i >= 64 && int(e[len(e)-1]) <= i-32 {
+			break
+		}
+	}
+
+	return k[:32], nil
+}
+
+func validateOwnerPasswordAES256(ctx *model.Context, ownerpw string) (ok bool, err error) {
+
+	if len(ownerpw) == 0 {
 		return false, nil
 	}
 
-	// TODO Process PW with SASLPrep profile (RFC 4013) of stringprep (RFC 3454).
-	opw := []byte(ctx.OwnerPW)
-	if len(opw) > 127 {
-		opw = opw[:127]
+	opw, err := passwordAES256(ownerpw)
+	if err != nil {
+		return false, err
 	}
-	//fmt.Printf("opw <%s> isValidUTF8String: %t\n", opw, utf8.Valid(opw))
 
 	// Algorithm 3.2a 3.
-	b := append(opw, validationSalt(ctx.E.O)...)
-	b = append(b, ctx.E.U...)
-	s := sha256.Sum256(b)
+	s, err := hashAES256(opw, validationSalt(ctx.E.O), ctx.E.U[:48], ctx.E.R)
+	if err != nil {
+		return false, err
+	}
 
-	if !bytes.HasPrefix(ctx.E.O, s[:]) {
+	if !bytes.HasPrefix(ctx.E.O, s) {
 		return false, nil
 	}
 
-	b = append(opw, keySalt(ctx.E.O)...)
-	b = append(b, ctx.E.U...)
-	key := sha256.Sum256(b)
+	key, err := hashAES256(opw, keySalt(ctx.E.O), ctx.E.U[:48], ctx.E.R)
+	if err != nil {
+		return false, err
+	}
 
-	cb, err := aes.NewCipher(key[:])
+	cb, err := aes.NewCipher(key)
 	if err != nil {
 		return false, err
 	}
@@ -446,25 +551,29 @@ func validateOwnerPasswordAES256(ctx *model.Context) (ok bool, err error) {
 	return true, nil
 }
 
-func validateUserPasswordAES256(ctx *model.Context) (ok bool, err error) {
+func validateUserPasswordAES256(ctx *model.Context, userpw string) (ok bool, err error) {
 
-	// TODO Process PW with SASLPrep profile (RFC 4013) of stringprep (RFC 3454).
-	upw := []byte(ctx.UserPW)
-	if len(upw) > 127 {
-		upw = upw[:127]
+	upw, err := passwordAES256(userpw)
+	if err != nil {
+		return false, err
 	}
-	//fmt.Printf("upw <%s> isValidUTF8String: %t\n", upw, utf8.Valid(upw))
 
 	// Algorithm 3.2a 4,
-	s := sha256.Sum256(append(upw, validationSalt(ctx.E.U)...))
+	s, err := hashAES256(upw, validationSalt(ctx.E.U), nil, ctx.E.R)
+	if err != nil {
+		return false, err
+	}
 
-	if !bytes.HasPrefix(ctx.E.U, s[:]) {
+	if !bytes.HasPrefix(ctx.E.U, s) {
 		return false, nil
 	}
 
-	key := sha256.Sum256(append(upw, keySalt(ctx.E.U)...))
+	key, err := hashAES256(upw, keySalt(ctx.E.U), nil, ctx.E.R)
+	if err != nil {
+		return false, err
+	}
 
-	cb, err := aes.NewCipher(key[:])
+	cb, err := aes.NewCipher(key)
 	if err != nil {
 		return false, err
 	}
@@ -479,21 +588,18 @@ func validateUserPasswordAES256(ctx *model.Context) (ok bool, err error) {
 }
 
 // ValidateOwnerPassword validates the owner password aka change permissions password.
-func validateOwnerPassword(ctx *model.Context) (ok bool, err error) {
+func validateOwnerPassword(ctx *model.Context, ownerpw, userpw string) (ok bool, err error) {
 
 	e := ctx.E
 
-	if e.R == 5 {
-		return validateOwnerPasswordAES256(ctx)
+	if e.R >= 5 {
+		return validateOwnerPasswordAES256(ctx, ownerpw)
 	}
 
 	// The PW string is generated from OS codepage characters by first converting the string to
 	// PDFDocEncoding. If input is Unicode, first convert to a codepage encoding , and then to
 	// PDFDocEncoding for backward compatibility.
 
-	ownerpw := ctx.OwnerPW
-	userpw := ctx.UserPW
-
 	// 7a: Alg.3 p62 a-d
 	key := key(ownerpw, userpw, e.R, e.L)
 
@@ -531,16 +637,7 @@ func validateOwnerPassword(ctx *model.Context) (ok bool, err error) {
 		}
 	}
 
-	// Save user pw
-	upws := ctx.UserPW
-
-	ctx.UserPW = string(upw)
-	ok, err = validateUserPassword(ctx)
-
-	// Restore user pw
-	ctx.UserPW = upws
-
-	return ok, err
+	return validateUserPassword(ctx, string(upw))
 }
 
 // SupportedCFEntry returns true if all entries found are supported.
@@ -604,7 +701,7 @@ func validatePermissions(ctx *model.Context) (bool, error) {
 
 	// Algorithm 3.2a 5.
 
-	if ctx.E.R != 5 {
+	if ctx.E.R < 5 {
 		return true, nil
 	}
 
@@ -627,7 +724,7 @@ func writePermissions(ctx *model.Context, d types.Dict) error {
 
 	// Algorithm 3.10
 
-	if ctx.E.R != 5 {
+	if ctx.E.R < 5 {
 		return nil
 	}
 
@@ -832,11 +929,11 @@ func length(d types.Dict) (int, error) {
 func getR(d types.Dict) (int, error) {
 
 	r := d.IntEntry("R")
-	if r == nil || *r < 2 || *r > 5 {
-		if r != nil && *r > 5 {
+	if r == nil || *r < 2 || *r > 6 {
+		if r != nil && *r > 6 {
 			return 0, ErrUnknownEncryption
 		}
-		return 0, errors.New("pdfcpu: encryption: \"R\" must be 2,3,4,5")
+		return 0, errors.New("pdfcpu: encryption: \"R\" must be 2,3,4,5,6")
 	}
 
 	return *r, nil
@@ -959,7 +1056,13 @@ func supportedEncryption(ctx *model.Context, d types.Dict) (*model.Enc, error) {
 	}
 
 	var oe, ue, perms []byte
-	if r == 5 {
+	if r >= 5 {
+		if len(o) != 48 || len(u) != 48 {
+			return nil, errors.New("pdfcpu: unsupported encryption: invalid \"O\" or \"U\" for AES-256")
+		}
+		if _, found := d.Find("Length"); !found {
+			l = 256
+		}
 		oe, ue, perms, err = validateAES256Parameters(d)
 		if err != nil {
 			return nil, err
@@ -1026,7 +1129,7 @@ func encryptBytes(b []byte, objNr, genNr int, encKey []byte, needAES bool, r int
 
 	if needAES {
 		k := encKey
-		if r != 5 {
+		if r < 5 {
 			k = decryptKey(objNr, genNr, encKey, needAES)
 		}
 		bb, err := encryptAESBytes(b, k)
@@ -1060,7 +1163,7 @@ func decryptBytes(b []byte, objNr, genNr int, encKey []byte, needAES bool, r int
 
 	if needAES {
 		k := encKey
-		if r != 5 {
+		if r < 5 {
 			k = decryptKey(objNr, genNr, encKey, needAES)
 		}
 		bb, err := decryptAESBytes(b, k)
@@ -1253,7 +1356,7 @@ func decryptDeepObject(objIn types.Object, objNr, genNr int, key []byte, needAES
 func encryptStream(buf []byte, objNr, genNr int, encKey []byte, needAES bool, r int) ([]byte, error) {
 
 	k := encKey
-	if r != 5 {
+	if r < 5 {
 		k = decryptKey(objNr, genNr, encKey, needAES)
 	}
 
@@ -1268,7 +1371,7 @@ func encryptStream(buf []byte, objNr, genNr int, encKey []byte, needAES bool, r
 func decryptStream(buf []byte, objNr, genNr int, encKey []byte, needAES bool, r int) ([]byte, error) {
 
 	k := encKey
-	if r != 5 {
+	if r < 5 {
 		k = decryptKey(objNr, genNr, encKey, needAES)
 	}
 
@@ -1426,9 +1529,9 @@ func decryptHexLiteral(hl types.HexLiteral, objNr, genNr int, key []byte, needAE
 	return decryptBytes(bb, objNr, genNr, key, needAES, r)
 }
 
-func calcFileEncKeyFromUE(ctx *model.Context) (k []byte, err error) {
+func calcFileEncKeyFromUE(ctx *model.Context, ownerpw string) (k []byte, err error) {
 
-	upw := []byte(ctx.OwnerPW)
+	upw := []byte(ownerpw)
 	key := sha256.Sum256(append(upw, keySalt(ctx.E.U)...))
 
 	cb, err := aes.NewCipher(key[:])
@@ -1466,7 +1569,7 @@ func calcFileEncKeyFromUE(ctx *model.Context) (k []byte, err error) {
 // 	return k, nil
 // }
 
-func calcFileEncKey(ctx *model.Context, d types.Dict) (err error) {
+func calcFileEncKey(ctx *model.Context, d types.Dict, ownerpw string) (err error) {
 
 	// Calc Random UE (32 bytes)
 	ue := make([]byte, 32)
@@ -1479,12 +1582,12 @@ func calcFileEncKey(ctx *model.Context, d types.Dict) (err error) {
 	d.Update("UE", types.HexLiteral(hex.EncodeToString(ctx.E.UE)))
 
 	// Calc file encryption key.
-	ctx.EncKey, err = calcFileEncKeyFromUE(ctx)
+	ctx.EncKey, err = calcFileEncKeyFromUE(ctx, ownerpw)
 
 	return err
 }
 
-func calcOAndUAES256(ctx *model.Context, d types.Dict) (err error) {
+func calcOAndUAES256(ctx *model.Context, d types.Dict, ownerpw, userpw string) (err error) {
 
 	// 1) Calc U.
 	b := make([]byte, 16)
@@ -1494,9 +1597,15 @@ func calcOAndUAES256(ctx *model.Context, d types.Dict) (err error) {
 	}
 
 	u := append(make([]byte, 32), b...)
-	upw := []byte(ctx.UserPW)
-	h := sha256.Sum256(append(upw, validationSalt(u)...))
-	ctx.E.U = append(h[:], b...)
+	upw, err := passwordAES256(userpw)
+	if err != nil {
+		return err
+	}
+	h, err := hashAES256(upw, validationSalt(u), nil, ctx.E.R)
+	if err != nil {
+		return err
+	}
+	ctx.E.U = append(h, b...)
 	d.Update("U", types.HexLiteral(hex.EncodeToString(ctx.E.U)))
 
 	// 2) Calc O (depends on U).
@@ -1507,20 +1616,27 @@ func calcOAndUAES256(ctx *model.Context, d types.Dict) (err error) {
 	}
 
 	o := append(make([]byte, 32), b...)
-	opw := []byte(ctx.OwnerPW)
-	c := append(opw, validationSalt(o)...)
-	h = sha256.Sum256(append(c, ctx.E.U...))
-	ctx.E.O = append(h[:], b...)
+	opw, err := passwordAES256(ownerpw)
+	if err != nil {
+		return err
+	}
+	h, err = hashAES256(opw, validationSalt(o), ctx.E.U, ctx.E.R)
+	if err != nil {
+		return err
+	}
+	ctx.E.O = append(h, b...)
 	d.Update("O", types.HexLiteral(hex.EncodeToString(ctx.E.O)))
 
-	err = calcFileEncKey(ctx, d)
+	err = calcFileEncKey(ctx, d, ownerpw)
 	if err != nil {
 		return err
 	}
 
 	// Encrypt file encryption key into UE.
-	h = sha256.Sum256(append(upw, keySalt(u)...))
-	cb, err := aes.NewCipher(h[:])
+	if h, err = hashAES256(upw, keySalt(u), nil, ctx.E.R); err != nil {
+		return err
+	}
+	cb, err := aes.NewCipher(h)
 	if err != nil {
 		return err
 	}
@@ -1531,9 +1647,10 @@ func calcOAndUAES256(ctx *model.Context, d types.Dict) (err error) {
 	d.Update("UE", types.HexLiteral(hex.EncodeToString(ctx.E.UE)))
 
 	// Encrypt file encryption key into OE.
-	c = append(opw, keySalt(o)...)
-	h = sha256.Sum256(append(c, ctx.E.U...))
-	cb, err = aes.NewCipher(h[:])
+	if h, err = hashAES256(opw, keySalt(o), ctx.E.U, ctx.E.R); err != nil {
+		return err
+	}
+	cb, err = aes.NewCipher(h)
 	if err != nil {
 		return err
 	}
@@ -1545,18 +1662,18 @@ func calcOAndUAES256(ctx *model.Context, d types.Dict) (err error) {
 	return nil
 }
 
-func calcOAndU(ctx *model.Context, d types.Dict) (err error) {
+func calcOAndU(ctx *model.Context, d types.Dict, ownerpw, userpw string) (err error) {
 
-	if ctx.E.R == 5 {
-		return calcOAndUAES256(ctx, d)
+	if ctx.E.R >= 5 {
+		return calcOAndUAES256(ctx, d, ownerpw, userpw)
 	}
 
-	ctx.E.O, err = o(ctx)
+	ctx.E.O, err = o(ctx, ownerpw, userpw)
 	if err != nil {
 		return err
 	}
 
-	ctx.E.U, ctx.EncKey, err = u(ctx)
+	ctx.E.U, ctx.EncKey, err = u(ctx, userpw)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// maxDedupPasses limits the number of deduplication passes.
+// Deduplicating ICC profiles or soft masks may turn referencing images into duplicates.
+const maxDedupPasses = 3
+
+// dedupCandidates returns the object numbers of font programs, ICC profiles and image XObjects.
+func dedupCandidates(ctx *model.Context) types.IntSet {
+	objNrs := types.IntSet{}
+
+	addRef := func(o types.Object) {
+		if ir, ok := o.(types.IndirectRef); ok {
+			objNrs[ir.ObjectNumber.Value()] = true
+		}
+	}
+
+	var walk func(o types.Object)
+
+	walk = func(o types.Object) {
+		switch o := o.(type) {
+
+		case types.Dict:
+			if o.Type() != nil && *o.Type() == "FontDescriptor" {
+				for _, k := range []string{"FontFile", "FontFile2", "FontFile3"} {
+					addRef(o[k])
+				}
+			}
+			addRef(o["DestOutputProfile"])
+			for _, v := range o {
+				walk(v)
+			}
+
+		case types.StreamDict:
+			walk(o.Dict)
+
+		case types.Array:
+			if len(o) == 2 {
+				if n, ok := o[0].(types.Name); ok && n == "ICCBased" {
+					addRef(o[1])
+				}
+			}
+			for _, v := range o {
+				walk(v)
+			}
+		}
+	}
+
+	for objNr, entry := range ctx.Table {
+		if entry == nil || entry.Free || entry.Object == nil {
+			continue
+		}
+		if sd, ok := entry.Object.(types.StreamDict); ok && sd.Subtype() != nil && *sd.Subtype() == "Image" {
+			objNrs[objNr] = true
+		}
+		walk(entry.Object)
+	}
+
+	return objNrs
+}
+
+// streamHash returns a hash over the stream dict and its encoded content.
+func streamHash(sd types.StreamDict) string {
+	h := sha256.New()
+	h.Write([]byte(sd.Dict.PDFString()))
+	if sd.Raw != nil {
+		h.Write(sd.Raw)
+	} else {
+		h.Write(sd.Content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replaceRefs(o types.Object, lookup map[int]int) types.Object {
+	switch o := o.(type) {
+
+	case types.IndirectRef:
+		if objNr, ok := lookup[o.ObjectNumber.Value()]; ok {
+			return *types.NewIndirectRef(objNr, 0)
+		}
+
+	case types.Dict:
+		for k, v := range o {
+			o[k] = replaceRefs(v, lookup)
+		}
+
+	case types.StreamDict:
+		replaceRefs(o.Dict, lookup)
+
+	case types.Array:
+		for i, v := range o {
+			o[i] = replaceRefs(v, lookup)
+		}
+	}
+
+	return o
+}
+
+func dedupStreamsPass(ctx *model.Context) (int, error) {
+	objNrs := make([]int, 0)
+	for objNr := range dedupCandidates(ctx) {
+		objNrs = append(objNrs, objNr)
+	}
+	// Keep the first occurrence.
+	sort.Ints(objNrs)
+
+	hashes := map[string]int{}
+	lookup := map[int]int{}
+
+	for _, objNr := range objNrs {
+		entry, ok := ctx.FindTableEntryLight(objNr)
+		if !ok || entry.Free || entry.Generation == nil || *entry.Generation != 0 {
+			continue
+		}
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok {
+			continue
+		}
+		h := streamHash(sd)
+		if objNr1, ok := hashes[h]; ok {
+			lookup[objNr] = objNr1
+			continue
+		}
+		hashes[h] = objNr
+	}
+
+	if len(lookup) == 0 {
+		return 0, nil
+	}
+
+	for _, entry := range ctx.Table {
+		if entry == nil || entry.Free || entry.Object == nil {
+			continue
+		}
+		entry.Object = replaceRefs(entry.Object, lookup)
+	}
+
+	for _, v := range ctx.Names {
+		if err := v.Process(nil, func(xRefTable *model.XRefTable, k string, v *types.Object) error {
+			*v = replaceRefs(*v, lookup)
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	for objNr := range lookup {
+		if err := ctx.FreeObject(objNr); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(lookup), nil
+}
+
+// DeduplicateStreams collapses identical font programs, ICC profiles and image XObjects
+// as found in documents merged from files produced by the same generator.
+// Returns the number of removed streams.
+func DeduplicateStreams(ctx *model.Context) (int, error) {
+	// References to duplicates may be anywhere, including objects not yet loaded.
+	if err := ctx.LoadAll(); err != nil {
+		return 0, err
+	}
+
+	c := 0
+
+	for i := 0; i < maxDedupPasses; i++ {
+		n, err := dedupStreamsPass(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			break
+		}
+		c += n
+	}
+
+	if log.OptimizeEnabled() {
+		log.Optimize.Printf("removed %d duplicate stream(s)\n", c)
+	}
+
+	return c, nil
+}
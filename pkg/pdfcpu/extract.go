@@ -255,6 +255,11 @@ func imageStub(
 		interpol = true
 	}
 
+	var alternates int
+	if a, err := ctx.DereferenceArray(sd.Dict["Alternates"]); err == nil {
+		alternates = len(a)
+	}
+
 	i, err := StreamLength(ctx, sd)
 	if err != nil {
 		return nil, err
@@ -278,6 +283,7 @@ func imageStub(
 		Comp:        comp,
 		Bpc:         bpc,
 		Interpol:    interpol,
+		Alternates:  alternates,
 		Size:        i,
 		Filter:      filters,
 		DecodeParms: s,
@@ -0,0 +1,508 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/matrix"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// maxTextFormDepth limits the nesting of form XObjects processed during text extraction.
+const maxTextFormDepth = 8
+
+// textGlyph represents a glyph shown on a page.
+type textGlyph struct {
+	s      string
+	x0, x1 float64 // Start and end of the glyph on the baseline in user space.
+	y      float64 // Baseline in user space.
+	size   float64 // Font size in user space.
+	frag   int     // Identifies the string that showed this glyph.
+}
+
+// textParams represents the text state parameters being part of the graphics state.
+type textParams struct {
+	ctm                  matrix.Matrix
+	font                 *textFont
+	size                 float64
+	tc, tw, th, tl, rise float64
+}
+
+type textExtractor struct {
+	ctx    *model.Context
+	fonts  map[int]*textFont
+	glyphs []textGlyph
+	frag   int
+}
+
+func operandMatrix(oo []types.Object) (matrix.Matrix, bool) {
+	if len(oo) != 6 {
+		return matrix.IdentMatrix, false
+	}
+	var ff [6]float64
+	for i, o := range oo {
+		f, ok := numberValue(o)
+		if !ok {
+			return matrix.IdentMatrix, false
+		}
+		ff[i] = f
+	}
+	return matrix.Matrix{{ff[0], ff[1], 0}, {ff[2], ff[3], 0}, {ff[4], ff[5], 1}}, true
+}
+
+func translationMatrix(tx, ty float64) matrix.Matrix {
+	m := matrix.IdentMatrix
+	m[2][0], m[2][1] = tx, ty
+	return m
+}
+
+func (te *textExtractor) font(resDict types.Dict, id string) (*textFont, error) {
+	fontDict, err := te.ctx.DereferenceDict(resDict["Font"])
+	if err != nil || fontDict == nil {
+		return nil, err
+	}
+
+	o, found := fontDict.Find(id)
+	if !found {
+		return nil, nil
+	}
+
+	ir, isRef := o.(types.IndirectRef)
+	if isRef {
+		if tf, ok := te.fonts[ir.ObjectNumber.Value()]; ok {
+			return tf, nil
+		}
+	}
+
+	d, err := te.ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return nil, err
+	}
+
+	tf, err := newTextFont(te.ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if isRef {
+		te.fonts[ir.ObjectNumber.Value()] = tf
+	}
+
+	return tf, nil
+}
+
+// show records the glyphs for a string shown using tp and advances tm accordingly.
+func (te *textExtractor) show(o types.Object, tp *textParams, tm *matrix.Matrix) error {
+	var (
+		bb  []byte
+		err error
+	)
+
+	switch o := o.(type) {
+	case types.StringLiteral:
+		bb, err = types.Unescape(o.Value(), false)
+	case types.HexLiteral:
+		bb, err = o.Bytes()
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if tp.font == nil {
+		return nil
+	}
+
+	te.frag++
+
+	for _, c := range tp.font.codes(bb) {
+		w := tp.font.width(c.code)*tp.size + tp.tc
+		if c.len == 1 && c.code == 0x20 {
+			w += tp.tw
+		}
+		w *= tp.th
+
+		m := tm.Multiply(tp.ctm)
+		p0 := m.Transform(types.Point{X: 0, Y: tp.rise})
+		*tm = translationMatrix(w, 0).Multiply(*tm)
+		p1 := tm.Multiply(tp.ctm).Transform(types.Point{X: 0, Y: tp.rise})
+
+		te.glyphs = append(te.glyphs, textGlyph{
+			s:    tp.font.text(c.code),
+			x0:   p0.X,
+			x1:   p1.X,
+			y:    p0.Y,
+			size: tp.size * math.Hypot(m[1][0], m[1][1]),
+			frag: te.frag,
+		})
+	}
+
+	return nil
+}
+
+func (te *textExtractor) form(resDict types.Dict, id string, tp textParams, depth int) error {
+	if depth >= maxTextFormDepth {
+		return nil
+	}
+
+	xObjDict, err := te.ctx.DereferenceDict(resDict["XObject"])
+	if err != nil || xObjDict == nil {
+		return err
+	}
+
+	o, found := xObjDict.Find(id)
+	if !found {
+		return nil
+	}
+
+	sd, _, err := te.ctx.DereferenceStreamDict(o)
+	if err != nil || sd == nil {
+		return err
+	}
+
+	if st := sd.Subtype(); st == nil || *st != "Form" {
+		return nil
+	}
+
+	if err := sd.Decode(); err != nil {
+		return err
+	}
+
+	ops, err := ParseContentStream(sd.Content)
+	if err != nil {
+		return err
+	}
+
+	if a, err := te.ctx.DereferenceArray(sd.Dict["Matrix"]); err == nil {
+		if m, ok := operandMatrix(a); ok {
+			tp.ctm = m.Multiply(tp.ctm)
+		}
+	}
+
+	formResDict, err := te.ctx.DereferenceDict(sd.Dict["Resources"])
+	if err != nil {
+		return err
+	}
+	if formResDict == nil {
+		formResDict = resDict
+	}
+
+	return te.process(ops, formResDict, tp, depth+1)
+}
+
+func (te *textExtractor) process(ops []ContentOperation, resDict types.Dict, tp textParams, depth int) error {
+	var (
+		stack   []textParams
+		tm, tlm = matrix.IdentMatrix, matrix.IdentMatrix
+	)
+
+	newLine := func(tx, ty float64) {
+		tlm = translationMatrix(tx, ty).Multiply(tlm)
+		tm = tlm
+	}
+
+	for _, op := range ops {
+		oo := op.Operands
+
+		f := func(i int) float64 {
+			if i >= len(oo) {
+				return 0
+			}
+			v, _ := numberValue(oo[i])
+			return v
+		}
+
+		var err error
+
+		switch op.Operator {
+
+		case "q":
+			stack = append(stack, tp)
+
+		case "Q":
+			if len(stack) > 0 {
+				tp, stack = stack[len(stack)-1], stack[:len(stack)-1]
+			}
+
+		case "cm":
+			if m, ok := operandMatrix(oo); ok {
+				tp.ctm = m.Multiply(tp.ctm)
+			}
+
+		case "BT":
+			tm, tlm = matrix.IdentMatrix, matrix.IdentMatrix
+
+		case "Tf":
+			if len(oo) == 2 {
+				if id, ok := oo[0].(types.Name); ok {
+					tp.font, err = te.font(resDict, id.Value())
+				}
+				tp.size = f(1)
+			}
+
+		case "Tc":
+			tp.tc = f(0)
+
+		case "Tw":
+			tp.tw = f(0)
+
+		case "Tz":
+			tp.th = f(0) / 100
+
+		case "TL":
+			tp.tl = f(0)
+
+		case "Ts":
+			tp.rise = f(0)
+
+		case "Td":
+			newLine(f(0), f(1))
+
+		case "TD":
+			tp.tl = -f(1)
+			newLine(f(0), f(1))
+
+		case "Tm":
+			if m, ok := operandMatrix(oo); ok {
+				tm, tlm = m, m
+			}
+
+		case "T*":
+			newLine(0, -tp.tl)
+
+		case "Tj":
+			if len(oo) == 1 {
+				err = te.show(oo[0], &tp, &tm)
+			}
+
+		case "'":
+			newLine(0, -tp.tl)
+			if len(oo) == 1 {
+				err = te.show(oo[0], &tp, &tm)
+			}
+
+		case "\"":
+			if len(oo) == 3 {
+				tp.tw, tp.tc = f(0), f(1)
+				newLine(0, -tp.tl)
+				err = te.show(oo[2], &tp, &tm)
+			}
+
+		case "TJ":
+			if len(oo) != 1 {
+				break
+			}
+			a, _ := oo[0].(types.Array)
+			for _, o := range a {
+				if adj, ok := numberValue(o); ok {
+					tm = translationMatrix(-adj/1000*tp.size*tp.th, 0).Multiply(tm)
+					continue
+				}
+				if err = te.show(o, &tp, &tm); err != nil {
+					break
+				}
+			}
+
+		case "Do":
+			if len(oo) == 1 {
+				if id, ok := oo[0].(types.Name); ok {
+					err = te.form(resDict, id.Value(), tp, depth)
+				}
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// textLine represents a sequence of glyphs sharing a baseline.
+type textLine struct {
+	glyphs []textGlyph
+	y      float64
+	size   float64
+}
+
+func textLines(gg []textGlyph) []*textLine {
+	var (
+		ll   []*textLine
+		line *textLine
+	)
+
+	for _, g := range gg {
+		if g.s == "" {
+			continue
+		}
+		if line == nil || math.Abs(g.y-line.y) > math.Max(g.size, line.size)/2 {
+			line = &textLine{y: g.y}
+			ll = append(ll, line)
+		}
+		line.glyphs = append(line.glyphs, g)
+		line.size = math.Max(line.size, g.size)
+	}
+
+	return ll
+}
+
+func endsWithSpace(sb *strings.Builder) bool {
+	s := sb.String()
+	return len(s) == 0 || s[len(s)-1] == ' '
+}
+
+// rawText returns the text of l separating all strings by a blank.
+func (l *textLine) rawText() string {
+	var sb strings.Builder
+	for i, g := range l.glyphs {
+		if i > 0 && g.frag != l.glyphs[i-1].frag && !endsWithSpace(&sb) && g.s != " " {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(g.s)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// wordSpacing is the minimum gap between glyphs relative to the font size recognized as word boundary.
+const wordSpacing = 0.15
+
+// text returns the text of l inserting blanks for gaps between glyphs only.
+func (l *textLine) text() string {
+	var sb strings.Builder
+	for i, g := range l.glyphs {
+		if i > 0 {
+			gap := g.x0 - l.glyphs[i-1].x1
+			if gap > wordSpacing*math.Max(g.size, 1) && !endsWithSpace(&sb) && g.s != " " {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(g.s)
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+var ligatures = strings.NewReplacer("ﬀ", "ff", "ﬁ", "fi", "ﬂ", "fl", "ﬃ", "ffi", "ﬄ", "ffl", "ﬅ", "st", "ﬆ", "st")
+
+// joinLines joins s1 and s2 rejoining words hyphenated across the line break.
+func joinLines(s1, s2 string) string {
+	if s1 == "" {
+		return s2
+	}
+
+	if strings.HasSuffix(s1, "­") {
+		return strings.TrimSuffix(s1, "­") + s2
+	}
+
+	r1, _ := utf8.DecodeLastRuneInString(s1)
+	if r1 == '-' || r1 == '‐' {
+		s := strings.TrimSuffix(strings.TrimSuffix(s1, "-"), "‐")
+		r0, _ := utf8.DecodeLastRuneInString(s)
+		r2, _ := utf8.DecodeRuneInString(s2)
+		if unicode.IsLetter(r0) && unicode.IsLower(r2) {
+			return s + s2
+		}
+		return s1 + s2
+	}
+
+	return s1 + " " + s2
+}
+
+// paragraphSpacing is the minimum baseline distance relative to the font size recognized as paragraph boundary.
+const paragraphSpacing = 1.6
+
+// healedText assembles lines into paragraphs.
+func healedText(ll []*textLine) string {
+	var (
+		pp   []string
+		para string
+		prev *textLine
+	)
+
+	for _, l := range ll {
+		s := ligatures.Replace(l.text())
+		if s == "" {
+			continue
+		}
+		if prev != nil {
+			d := prev.y - l.y
+			if d <= 0 || d > paragraphSpacing*math.Max(prev.size, l.size) {
+				pp = append(pp, para)
+				para = ""
+			}
+		}
+		para = joinLines(para, s)
+		prev = l
+	}
+
+	if para != "" {
+		pp = append(pp, para)
+	}
+
+	return strings.Join(pp, "\n\n")
+}
+
+// ExtractPageText returns the text of page pageNr.
+// If heal is true words hyphenated across line breaks get rejoined,
+// strings split by kerning adjustments get merged and lines get assembled into paragraphs.
+func ExtractPageText(ctx *model.Context, pageNr int, heal bool) (string, error) {
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return "", err
+	}
+	if d == nil {
+		return "", errors.Errorf("pdfcpu: invalid page number: %d", pageNr)
+	}
+
+	ops, err := ParsePageContent(ctx, pageNr)
+	if err != nil || len(ops) == 0 {
+		return "", err
+	}
+
+	resDict, err := pageResourceDict(ctx, d)
+	if err != nil {
+		return "", err
+	}
+
+	te := &textExtractor{ctx: ctx, fonts: map[int]*textFont{}}
+
+	if err := te.process(ops, resDict, textParams{ctm: matrix.IdentMatrix, th: 1}, 0); err != nil {
+		return "", err
+	}
+
+	ll := textLines(te.glyphs)
+
+	if heal {
+		return healedText(ll), nil
+	}
+
+	ss := make([]string, 0, len(ll))
+	for _, l := range ll {
+		if s := l.rawText(); s != "" {
+			ss = append(ss, s)
+		}
+	}
+
+	return strings.Join(ss, "\n"), nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/matrix"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+func TestExtractTextHealing(t *testing.T) {
+	s := `BT /F1 10 Tf 12 TL 72 700 Td
+	[(The ex)-10(am)20(ple of hy-)] TJ
+	(phenation keeps self-) '
+	(Contained words.) '
+	0 -30 Td (Next) Tj ( ) Tj [(para)(graph)] TJ ET`
+
+	ops, err := ParseContentStream([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xRefTable, err := CreateXRefTableWithRootDict()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resDict := types.Dict{
+		"Font": types.Dict{
+			"F1": types.Dict{
+				"Type":     types.Name("Font"),
+				"Subtype":  types.Name("Type1"),
+				"BaseFont": types.Name("Helvetica"),
+				"Encoding": types.Name("WinAnsiEncoding"),
+			},
+		},
+	}
+
+	te := &textExtractor{ctx: CreateContext(xRefTable, nil), fonts: map[int]*textFont{}}
+	if err := te.process(ops, resDict, textParams{ctm: matrix.IdentMatrix, th: 1}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	ll := textLines(te.glyphs)
+
+	var raw []string
+	for _, l := range ll {
+		raw = append(raw, l.rawText())
+	}
+	want := []string{"The ex am ple of hy-", "phenation keeps self-", "Contained words.", "Next para graph"}
+	if len(raw) != len(want) {
+		t.Fatalf("want %q, got %q\n", want, raw)
+	}
+	for i := range want {
+		if raw[i] != want[i] {
+			t.Fatalf("want %q, got %q\n", want[i], raw[i])
+		}
+	}
+
+	wantHealed := "The example of hyphenation keeps self-Contained words.\n\nNext paragraph"
+	if got := healedText(ll); got != wantHealed {
+		t.Fatalf("want %q, got %q\n", wantHealed, got)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"unicode/utf16"
 
 	"github.com/mjuen/pdfcpu/pkg/font"
+	"github.com/mjuen/pdfcpu/pkg/log"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 	"github.com/pkg/errors"
@@ -141,7 +142,47 @@ func flateEncodedStreamIndRef(xRefTable *model.XRefTable, data []byte) (*types.I
 	return xRefTable.IndRefForNewObject(*sd)
 }
 
+// checkFontLicense applies the configured font license policy to embedding fontName.
+func checkFontLicense(xRefTable *model.XRefTable, ttf font.TTFLight, fontName string, subFont bool) error {
+	policy := model.FontLicenseWarn
+	if xRefTable.Conf != nil {
+		policy = xRefTable.Conf.FontLicensePolicy
+	}
+	if policy == model.FontLicenseIgnore {
+		return nil
+	}
+
+	var op string
+	switch {
+	case !ttf.Embeddable():
+		op = "embedding"
+	case subFont && !ttf.Subsettable():
+		op = "subsetting"
+	default:
+		return nil
+	}
+
+	s := fmt.Sprintf("license of font %s (%s) forbids %s", fontName, font.FSTypeString(ttf.FSType), op)
+	if policy == model.FontLicenseRefuse {
+		return errors.New("pdfcpu: " + s)
+	}
+
+	for _, w := range xRefTable.Warnings {
+		if w == s {
+			return nil
+		}
+	}
+	xRefTable.Warnings = append(xRefTable.Warnings, s)
+	if log.CLIEnabled() {
+		log.CLI.Printf("warning: %s\n", s)
+	}
+	return nil
+}
+
 func ttfFontFile(xRefTable *model.XRefTable, ttf font.TTFLight, fontName string) (*types.IndirectRef, error) {
+	if err := checkFontLicense(xRefTable, ttf, fontName, false); err != nil {
+		return nil, err
+	}
 	bb, err := font.Read(fontName)
 	if err != nil {
 		return nil, err
@@ -150,6 +191,9 @@ func ttfFontFile(xRefTable *model.XRefTable, ttf font.TTFLight, fontName string)
 }
 
 func ttfSubFontFile(xRefTable *model.XRefTable, ttf font.TTFLight, fontName string, indRef *types.IndirectRef) (*types.IndirectRef, error) {
+	if err := checkFontLicense(xRefTable, ttf, fontName, true); err != nil {
+		return nil, err
+	}
 	bb, err := font.Subset(fontName, xRefTable.UsedGIDs[fontName])
 	if err != nil {
 		return nil, err
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/font"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pkg/errors"
+)
+
+// FontInfo represents an embedded font including the embedding permissions of its font file.
+type FontInfo struct {
+	ObjNr     int
+	Name      string
+	Type      string // font dict Subtype
+	FontFile  string // FontFile, FontFile2 or FontFile3
+	FSType    uint16 // OS/2 fsType
+	HasFSType bool   // false for font files w/o OS/2 table like Type1 or CFF fonts.
+}
+
+// License returns a string rep for the embedding permissions of fi.
+func (fi FontInfo) License() string {
+	if !fi.HasFSType {
+		return "n/a"
+	}
+	return font.FSTypeString(fi.FSType)
+}
+
+func (fi FontInfo) String() string {
+	return fmt.Sprintf("#%-6d %-30s %-12s %-9s %s", fi.ObjNr, fi.Name, fi.Type, fi.FontFile, fi.License())
+}
+
+func fontFileFSType(ctx *model.Context, fi *FontInfo, fontObject model.FontObject) error {
+	d, err := fontDescriptor(ctx.XRefTable, fontObject.FontDict, fi.ObjNr)
+	if err != nil || d == nil {
+		return err
+	}
+
+	for _, k := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		ir := d.IndirectRefEntry(k)
+		if ir == nil {
+			continue
+		}
+
+		fi.FontFile = k
+		if k == "FontFile" {
+			// Type1 fonts have no OS/2 table.
+			return nil
+		}
+
+		sd, _, err := ctx.DereferenceStreamDict(*ir)
+		if err != nil {
+			return err
+		}
+		if sd == nil {
+			return errors.Errorf("pdfcpu: corrupt font file for font obj#%d: %s", fi.ObjNr, fi.Name)
+		}
+
+		if k == "FontFile3" {
+			// Only OpenType font files are sfnt based.
+			if st := sd.Subtype(); st == nil || *st != "OpenType" {
+				return nil
+			}
+		}
+
+		if err := sd.Decode(); err != nil {
+			return err
+		}
+
+		fsType, ok, err := font.ParseFSType(sd.Content)
+		if err != nil {
+			return errors.Wrapf(err, "font obj#%d: %s", fi.ObjNr, fi.Name)
+		}
+		fi.FSType, fi.HasFSType = fsType, ok
+		return nil
+	}
+
+	return nil
+}
+
+// FontInfos returns all embedded fonts including their embedding permissions sorted by object number.
+// Requires an optimized context.
+func FontInfos(ctx *model.Context) ([]FontInfo, error) {
+	m := map[int]*model.FontObject{}
+	for objNr, fo := range ctx.Optimize.FontObjects {
+		m[objNr] = fo
+	}
+	for objNr, fo := range ctx.Optimize.FormFontObjects {
+		m[objNr] = fo
+	}
+
+	objNrs := make([]int, 0, len(m))
+	for objNr, fo := range m {
+		if fo.Embedded() {
+			objNrs = append(objNrs, objNr)
+		}
+	}
+	sort.Ints(objNrs)
+
+	ff := make([]FontInfo, 0, len(objNrs))
+	for _, objNr := range objNrs {
+		fo := m[objNr]
+		fi := FontInfo{ObjNr: objNr, Name: fo.FontName, Type: fo.SubType()}
+		if err := fontFileFSType(ctx, &fi, *fo); err != nil {
+			return nil, err
+		}
+		if fi.FontFile == "" {
+			// eg. Type0 font w/o embedded font file.
+			continue
+		}
+		ff = append(ff, fi)
+	}
+
+	return ff, nil
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package form
+
+import (
+	pdffont "github.com/mjuen/pdfcpu/pkg/pdfcpu/font"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/primitives"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// FieldAccess represents changes to the field flags controlling access to form fields.
+// A nil entry leaves the corresponding flag unchanged.
+type FieldAccess struct {
+	ReadOnly *bool `json:"readOnly,omitempty"`
+	Required *bool `json:"required,omitempty"`
+}
+
+// FieldLockAction specifies the fields locked by a signature field lock dict.
+type FieldLockAction string
+
+const (
+	FieldLockAll     FieldLockAction = "All"     // Lock all fields.
+	FieldLockInclude FieldLockAction = "Include" // Lock the fields listed.
+	FieldLockExclude FieldLockAction = "Exclude" // Lock all fields but the ones listed.
+)
+
+// FieldLock represents the lock dict of a signature field (see 12.7.5.5)
+// declaring the fields that become read-only once the signature field gets signed.
+type FieldLock struct {
+	Action FieldLockAction `json:"action"`
+	Fields []string        `json:"fields,omitempty"` // Field ids or names for Include and Exclude.
+
+	// Optional DocMDP access permissions (1..3) granted after signing, since PDF 2.0.
+	P int `json:"p,omitempty"`
+}
+
+func setFieldFlag(d types.Dict, flag primitives.FieldFlags, on bool) {
+	i := primitives.FieldFlags(0)
+	if ff := d.IntEntry("Ff"); ff != nil {
+		i = primitives.FieldFlags(*ff)
+	}
+	if on {
+		i |= flag
+	} else {
+		i &^= flag
+	}
+	d["Ff"] = types.Integer(i)
+}
+
+func setFormFieldAccess(ctx *model.Context, d types.Dict, fi *fieldInfo, fa FieldAccess, fonts map[string]types.IndirectRef) error {
+	dd := []types.Dict{d}
+	for _, o := range d.ArrayEntry("Kids") {
+		d1, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		dd = append(dd, d1)
+	}
+
+	for _, d1 := range dd {
+		if fa.ReadOnly != nil {
+			setFieldFlag(d1, primitives.FieldReadOnly, *fa.ReadOnly)
+		}
+		if fa.Required != nil {
+			setFieldFlag(d1, primitives.FieldRequired, *fa.Required)
+		}
+	}
+
+	if fa.ReadOnly == nil {
+		return nil
+	}
+
+	// Read-only comboboxes need an appearance stream.
+	if *fa.ReadOnly {
+		return ensureAP(ctx, d, fi, fonts)
+	}
+
+	return deleteAP(d, fi)
+}
+
+func setPageFieldAccess(
+	ctx *model.Context,
+	fieldIDsOrNames []string,
+	fa FieldAccess,
+	fields types.Array,
+	indRefs map[types.IndirectRef]bool,
+	wAnnots model.Annot,
+	fonts map[string]types.IndirectRef,
+	ok *bool) error {
+
+	for _, ir := range *(wAnnots.IndRefs) {
+
+		found, fi, err := isField(ctx.XRefTable, ir, fields)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		if !matchField(fi, fieldIDsOrNames) {
+			continue
+		}
+
+		if fi.indRef != nil {
+			ir = *fi.indRef
+		}
+		if indRefs[ir] {
+			continue
+		}
+		indRefs[ir] = true
+
+		d, err := ctx.DereferenceDict(ir)
+		if err != nil {
+			return err
+		}
+		if len(d) == 0 {
+			continue
+		}
+
+		if err := setFormFieldAccess(ctx, d, fi, fa, fonts); err != nil {
+			return err
+		}
+
+		*ok = true
+	}
+
+	return nil
+}
+
+// SetFormFieldAccess sets or clears the read-only and required flags of all form fields contained in fieldIDsOrNames.
+func SetFormFieldAccess(ctx *model.Context, fieldIDsOrNames []string, fa FieldAccess) (bool, error) {
+	if fa.ReadOnly == nil && fa.Required == nil {
+		return false, errors.New("pdfcpu: missing field access")
+	}
+
+	xRefTable := ctx.XRefTable
+
+	fields, err := fields(xRefTable)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	fonts := map[string]types.IndirectRef{}
+	indRefs := map[types.IndirectRef]bool{}
+
+	for i := 1; i <= xRefTable.PageCount; i++ {
+
+		pgAnnots := xRefTable.PageAnnots[i]
+		if len(pgAnnots) == 0 {
+			continue
+		}
+
+		wAnnots, found := pgAnnots[model.AnnWidget]
+		if !found {
+			continue
+		}
+
+		if err := setPageFieldAccess(ctx, fieldIDsOrNames, fa, fields, indRefs, wAnnots, fonts, &ok); err != nil {
+			return false, err
+		}
+	}
+
+	for fName, indRef := range fonts {
+
+		if len(ctx.UsedGIDs[fName]) == 0 {
+			continue
+		}
+
+		fDict, err := xRefTable.DereferenceDict(indRef)
+		if err != nil {
+			return false, err
+		}
+
+		fr := model.FontResource{}
+		if err := pdffont.IndRefsForUserfontUpdate(xRefTable, fDict, "", &fr); err != nil {
+			return false, pdffont.ErrCorruptFontDict
+		}
+
+		if err := pdffont.UpdateUserfont(xRefTable, fName, fr); err != nil {
+			return false, err
+		}
+	}
+
+	return ok, nil
+}
+
+func (fl FieldLock) validate() error {
+	switch fl.Action {
+	case FieldLockAll:
+		if len(fl.Fields) > 0 {
+			return errors.New("pdfcpu: field lock action All does not take fields")
+		}
+	case FieldLockInclude, FieldLockExclude:
+		if len(fl.Fields) == 0 {
+			return errors.Errorf("pdfcpu: field lock action %s: missing fields", fl.Action)
+		}
+	default:
+		return errors.Errorf("pdfcpu: invalid field lock action: %s", fl.Action)
+	}
+
+	if fl.P < 0 || fl.P > 3 {
+		return errors.Errorf("pdfcpu: invalid field lock permissions: %d", fl.P)
+	}
+
+	return nil
+}
+
+// SetSignatureFieldLock sets the lock dict of signature field sigFieldIDOrName.
+// Once the signature field gets signed, conforming readers make the fields declared by fl read-only.
+func SetSignatureFieldLock(ctx *model.Context, sigFieldIDOrName string, fl FieldLock) error {
+	if err := fl.validate(); err != nil {
+		return err
+	}
+
+	xRefTable := ctx.XRefTable
+
+	fields, err := fields(xRefTable)
+	if err != nil {
+		return err
+	}
+
+	var nn []namedField
+	if err := collectNamedFields(xRefTable, fields, "", "", &nn, 0); err != nil {
+		return err
+	}
+
+	sig := fieldByIDOrName(nn, sigFieldIDOrName)
+	if sig == nil {
+		return errors.Errorf("pdfcpu: unknown form field: %s", sigFieldIDOrName)
+	}
+	if ft := sig.d.NameEntry("FT"); ft == nil || *ft != "Sig" {
+		return errors.Errorf("pdfcpu: %s is not a signature field", sigFieldIDOrName)
+	}
+
+	d := types.Dict(map[string]types.Object{
+		"Type":   types.Name("SigFieldLock"),
+		"Action": types.Name(fl.Action),
+	})
+
+	if len(fl.Fields) > 0 {
+		arr := types.Array{}
+		for _, idOrName := range fl.Fields {
+			nf := fieldByIDOrName(nn, idOrName)
+			if nf == nil {
+				return errors.Errorf("pdfcpu: unknown form field: %s", idOrName)
+			}
+			s, err := types.EscapeUTF16String(nf.name)
+			if err != nil {
+				return err
+			}
+			arr = append(arr, types.StringLiteral(*s))
+		}
+		d["Fields"] = arr
+	}
+
+	if fl.P > 0 {
+		d["P"] = types.Integer(fl.P)
+	}
+
+	indRef, err := xRefTable.IndRefForNewObject(d)
+	if err != nil {
+		return err
+	}
+
+	sig.d["Lock"] = *indRef
+
+	return nil
+}
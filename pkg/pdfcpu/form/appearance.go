@@ -0,0 +1,312 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package form
+
+import (
+	pdffont "github.com/mjuen/pdfcpu/pkg/pdfcpu/font"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/primitives"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+func refreshBtn(ctx *model.Context, d types.Dict) error {
+	ff := d.IntEntry("Ff")
+	if ff != nil && primitives.FieldFlags(*ff)&primitives.FieldPushbutton > 0 {
+		return nil
+	}
+
+	v := types.Name("Off")
+	if n := d.NameEntry("V"); n != nil {
+		v = types.Name(*n)
+	}
+
+	if ff != nil && len(d.ArrayEntry("Kids")) > 0 && primitives.FieldFlags(*ff)&primitives.FieldRadio > 0 {
+		vraw, err := types.DecodeName(v.String())
+		if err != nil {
+			return err
+		}
+		_, _, err = fillRadioButtons(ctx, d, vraw)
+		return err
+	}
+
+	if _, found := d.Find("AS"); found {
+		offName, yesName := primitives.CalcCheckBoxASNames(d)
+		asName := yesName
+		if v == "Off" {
+			asName = offName
+		}
+		d["AS"] = asName
+	}
+
+	return nil
+}
+
+func refreshCh(ctx *model.Context, d types.Dict, fonts map[string]types.IndirectRef) error {
+	ff := d.IntEntry("Ff")
+	if ff == nil {
+		return errors.New("pdfcpu: corrupt form field: missing entry Ff")
+	}
+
+	opts, err := parseOptions(ctx.XRefTable, d)
+	if err != nil {
+		return err
+	}
+	if len(opts) == 0 {
+		return errors.New("pdfcpu: missing Opts")
+	}
+
+	if primitives.FieldFlags(*ff)&primitives.FieldCombo > 0 {
+		v := ""
+		if sl := d.StringLiteralEntry("V"); sl != nil {
+			if v, err = types.StringLiteralToString(*sl); err != nil {
+				return err
+			}
+		}
+		return primitives.EnsureComboBoxAP(ctx, d, v, fonts)
+	}
+
+	var vv []string
+	if primitives.FieldFlags(*ff)&primitives.FieldMultiselect > 0 {
+		if vv, err = parseStringLiteralArray(ctx.XRefTable, d, "V"); err != nil {
+			return err
+		}
+	} else if sl := d.StringLiteralEntry("V"); sl != nil {
+		s, err := types.StringLiteralToString(*sl)
+		if err != nil {
+			return err
+		}
+		vv = []string{s}
+	}
+
+	// V takes precedence over I.
+	ind := types.Array{}
+	for _, v := range vv {
+		for i, o := range opts {
+			if o == v {
+				ind = append(ind, types.Integer(i))
+				break
+			}
+		}
+	}
+	if len(ind) > 0 {
+		d["I"] = ind
+	} else {
+		d.Delete("I")
+	}
+
+	return primitives.EnsureListBoxAP(ctx, d, opts, ind, fonts)
+}
+
+func refreshTx(ctx *model.Context, d types.Dict, fonts map[string]types.IndirectRef) error {
+	df, err := extractDateFormat(ctx.XRefTable, d)
+	if err != nil {
+		return err
+	}
+
+	v := ""
+	if o, found := d.Find("V"); found {
+		sl, _ := o.(types.StringLiteral)
+		if v, err = types.StringLiteralToString(sl); err != nil {
+			return err
+		}
+	}
+
+	if df != nil {
+		return primitives.EnsureDateFieldAP(ctx, d, v, fonts)
+	}
+
+	fieldFmt, err := parseFieldFormat(ctx.XRefTable, d)
+	if err != nil {
+		return err
+	}
+	if fieldFmt != nil && fieldFmt.category != formatDate {
+		_, v = fieldFmt.apply(v)
+	}
+
+	ff := d.IntEntry("Ff")
+	multiLine := ff != nil && uint(primitives.FieldFlags(*ff))&uint(primitives.FieldMultiline) > 0
+
+	return ensureTextFieldAPs(ctx, d, v, multiLine, fonts)
+}
+
+func refreshPageFields(
+	ctx *model.Context,
+	fields types.Array,
+	indRefs map[types.IndirectRef]bool,
+	wAnnots model.Annot,
+	fonts map[string]types.IndirectRef,
+	missingOnly bool,
+	ok *bool) error {
+
+	for _, ir := range *(wAnnots.IndRefs) {
+
+		found, fi, err := isField(ctx.XRefTable, ir, fields)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		if fi.indRef != nil {
+			if indRefs[*fi.indRef] {
+				continue
+			}
+			indRefs[*fi.indRef] = true
+			ir = *fi.indRef
+		}
+
+		d, err := ctx.DereferenceDict(ir)
+		if err != nil {
+			return err
+		}
+		if len(d) == 0 {
+			continue
+		}
+
+		if missingOnly {
+			missing, err := lacksAppearance(ctx, d)
+			if err != nil {
+				return err
+			}
+			if !missing {
+				continue
+			}
+		}
+
+		ft := fi.ft
+		if ft == nil {
+			ft = d.NameEntry("FT")
+			if ft == nil {
+				return errors.Errorf("pdfcpu: corrupt form field %s: missing entry FT\n%s", fi.id, d)
+			}
+		}
+
+		switch *ft {
+		case "Btn":
+			err = refreshBtn(ctx, d)
+
+		case "Ch":
+			err = refreshCh(ctx, d, fonts)
+
+		case "Tx":
+			err = refreshTx(ctx, d, fonts)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		*ok = true
+	}
+
+	return nil
+}
+
+func lacksAppearance(ctx *model.Context, d types.Dict) (bool, error) {
+	widgets, err := fieldWidgets(ctx, d)
+	if err != nil {
+		return false, err
+	}
+	for _, w := range widgets {
+		ap, err := ctx.DereferenceDict(w["AP"])
+		if err != nil {
+			return false, err
+		}
+		if _, found := ap.Find("N"); !found {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RefreshFormFieldAppearances regenerates the appearance streams of all form fields of selectedPages based on their current values.
+// Missing appearance streams get created, checkboxes and radio buttons get their appearance state synced with their value.
+func RefreshFormFieldAppearances(ctx *model.Context, selectedPages types.IntSet) (bool, error) {
+	return refreshFormFieldAppearances(ctx, selectedPages, false)
+}
+
+func refreshFormFieldAppearances(ctx *model.Context, selectedPages types.IntSet, missingOnly bool) (bool, error) {
+
+	xRefTable := ctx.XRefTable
+
+	// Skip documents without form fields.
+	if xRefTable.Form == nil {
+		return false, nil
+	}
+	o, found := xRefTable.Form.Find("Fields")
+	if !found {
+		return false, nil
+	}
+	if a, err := xRefTable.DereferenceArray(o); err != nil || len(a) == 0 {
+		return false, err
+	}
+
+	fields, err := fields(xRefTable)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	fonts := map[string]types.IndirectRef{}
+	indRefs := map[types.IndirectRef]bool{}
+
+	for i := 1; i <= xRefTable.PageCount; i++ {
+
+		if selectedPages != nil && !selectedPages[i] {
+			continue
+		}
+
+		pgAnnots := xRefTable.PageAnnots[i]
+		if len(pgAnnots) == 0 {
+			continue
+		}
+
+		wAnnots, found := pgAnnots[model.AnnWidget]
+		if !found {
+			continue
+		}
+
+		if err := refreshPageFields(ctx, fields, indRefs, wAnnots, fonts, missingOnly, &ok); err != nil {
+			return false, err
+		}
+	}
+
+	for fName, indRef := range fonts {
+
+		if len(ctx.UsedGIDs[fName]) == 0 {
+			continue
+		}
+
+		fDict, err := xRefTable.DereferenceDict(indRef)
+		if err != nil {
+			return false, err
+		}
+
+		fr := model.FontResource{}
+		if err := pdffont.IndRefsForUserfontUpdate(xRefTable, fDict, "", &fr); err != nil {
+			return false, pdffont.ErrCorruptFontDict
+		}
+
+		if err := pdffont.UpdateUserfont(xRefTable, fName, fr); err != nil {
+			return false, err
+		}
+	}
+
+	return ok, nil
+}
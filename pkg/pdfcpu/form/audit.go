@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package form
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// XMPNamespaceFormAudit is the namespace of the form audit trail recorded in XMP metadata.
+const XMPNamespaceFormAudit = "http://pdfcpu.io/ns/formaudit/1.0/"
+
+// FieldChange represents a form field value modified by form filling.
+type FieldChange struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// Audit represents all form field value changes of a form filling run.
+type Audit struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// NewAudit compares field values before and after form filling.
+func NewAudit(before, after []Field, ts time.Time) *Audit {
+	m := map[string]Field{}
+	for _, f := range before {
+		m[f.ID] = f
+	}
+
+	a := &Audit{Timestamp: ts, Changes: []FieldChange{}}
+
+	for _, f := range after {
+		f0, ok := m[f.ID]
+		if ok && f0.V == f.V {
+			continue
+		}
+		a.Changes = append(a.Changes, FieldChange{ID: f.ID, Name: f.Name, Type: f.Typ.string(), Old: f0.V, New: f.V})
+	}
+
+	return a
+}
+
+func (a Audit) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d changed fields\n", a.Timestamp.Format(time.RFC3339), len(a.Changes))
+	for _, c := range a.Changes {
+		fmt.Fprintf(&sb, "%s (%s): %q -> %q\n", c.Name, c.ID, c.Old, c.New)
+	}
+	return sb.String()
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// xmpHistoryItem renders a as a member of the audit history sequence.
+func (a Audit) xmpHistoryItem() string {
+	var sb strings.Builder
+	sb.WriteString("     <rdf:li rdf:parseType=\"Resource\">\n")
+	fmt.Fprintf(&sb, "      <pdfcpuFormAudit:When>%s</pdfcpuFormAudit:When>\n", a.Timestamp.Format(time.RFC3339))
+	sb.WriteString("      <pdfcpuFormAudit:Changes>\n       <rdf:Bag>\n")
+	for _, c := range a.Changes {
+		sb.WriteString("        <rdf:li rdf:parseType=\"Resource\">\n")
+		fmt.Fprintf(&sb, "         <pdfcpuFormAudit:Field>%s</pdfcpuFormAudit:Field>\n", xmlEscape(c.Name))
+		fmt.Fprintf(&sb, "         <pdfcpuFormAudit:ID>%s</pdfcpuFormAudit:ID>\n", xmlEscape(c.ID))
+		fmt.Fprintf(&sb, "         <pdfcpuFormAudit:Old>%s</pdfcpuFormAudit:Old>\n", xmlEscape(c.Old))
+		fmt.Fprintf(&sb, "         <pdfcpuFormAudit:New>%s</pdfcpuFormAudit:New>\n", xmlEscape(c.New))
+		sb.WriteString("        </rdf:li>\n")
+	}
+	sb.WriteString("       </rdf:Bag>\n      </pdfcpuFormAudit:Changes>\n     </rdf:li>\n")
+	return sb.String()
+}
+
+func (a Audit) xmpDescription() string {
+	return "  <rdf:Description rdf:about=\"\" xmlns:pdfcpuFormAudit=\"" + XMPNamespaceFormAudit + "\">\n" +
+		"   <pdfcpuFormAudit:History>\n    <rdf:Seq>\n" +
+		a.xmpHistoryItem() +
+		"    </rdf:Seq>\n   </pdfcpuFormAudit:History>\n  </rdf:Description>\n"
+}
+
+func (a Audit) xmpPacket() string {
+	return "<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n" +
+		"<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n" +
+		" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n" +
+		a.xmpDescription() +
+		" </rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>"
+}
+
+var (
+	reAuditHistoryEnd = regexp.MustCompile(`</rdf:Seq>\s*</pdfcpuFormAudit:History>`)
+	reRDFEnd          = regexp.MustCompile(`</rdf:RDF>`)
+)
+
+// addToXMP adds a to the audit history of the XMP packet bb.
+func (a Audit) addToXMP(bb []byte) ([]byte, bool) {
+	if loc := reAuditHistoryEnd.FindIndex(bb); loc != nil {
+		return append(append(append([]byte{}, bb[:loc[0]]...), a.xmpHistoryItem()...), bb[loc[0]:]...), true
+	}
+	if loc := reRDFEnd.FindIndex(bb); loc != nil {
+		return append(append(append([]byte{}, bb[:loc[0]]...), a.xmpDescription()...), bb[loc[0]:]...), true
+	}
+	return nil, false
+}
+
+// RecordAudit adds a to the form audit history kept in the XMP metadata of ctx.
+// Audits without changes are ignored.
+func RecordAudit(ctx *model.Context, a *Audit) error {
+	if a == nil || len(a.Changes) == 0 {
+		return nil
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+
+	var sd *types.StreamDict
+	if o, found := rootDict.Find("Metadata"); found && o != nil {
+		if sd, _, err = ctx.DereferenceStreamDict(o); err != nil {
+			return err
+		}
+	}
+
+	if sd != nil {
+		if err := sd.Decode(); err != nil {
+			return err
+		}
+		if bb, ok := a.addToXMP(sd.Content); ok {
+			// Metadata should remain readable by non PDF aware tools.
+			sd.Content = bb
+			sd.FilterPipeline = nil
+			sd.Delete("Filter")
+			sd.Delete("DecodeParms")
+			if err := sd.Encode(); err != nil {
+				return err
+			}
+			ir, ok := rootDict["Metadata"].(types.IndirectRef)
+			if !ok {
+				rootDict["Metadata"] = *sd
+				return nil
+			}
+			if entry, found := ctx.FindTableEntryForIndRef(&ir); found {
+				entry.Object = *sd
+			}
+			return nil
+		}
+		// Replace unrecognizable metadata.
+	}
+
+	sd = &types.StreamDict{Dict: types.NewDict(), Content: []byte(a.xmpPacket())}
+	sd.InsertName("Type", "Metadata")
+	sd.InsertName("Subtype", "XML")
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+
+	ir, err := ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+	rootDict["Metadata"] = *ir
+
+	return nil
+}
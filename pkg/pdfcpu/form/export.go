@@ -110,6 +110,16 @@ type ListBox struct {
 	Locked   bool     `json:"locked"`
 }
 
+// PushButton represents a form push button.
+// Filling a push button replaces its icon by the image named Image.
+type PushButton struct {
+	Pages  []int  `json:"pages"`
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Image  string `json:"image"`
+	Locked bool   `json:"locked"`
+}
+
 // Page is a container for page imageboxes.
 type Page struct {
 	ImageBoxes []*primitives.ImageBox `json:"image,omitempty"`
@@ -123,6 +133,7 @@ type Form struct {
 	RadioButtonGroups []*RadioButtonGroup `json:"radiobuttongroup,omitempty"`
 	ComboBoxes        []*ComboBox         `json:"combobox,omitempty"`
 	ListBoxes         []*ListBox          `json:"listbox,omitempty"`
+	PushButtons       []*PushButton       `json:"pushbutton,omitempty"`
 	Pages             map[string]*Page    `json:"pages,omitempty"`
 }
 
@@ -186,6 +197,15 @@ func (f Form) listBoxValuesAndLock(id, name string) ([]string, bool, bool) {
 	return nil, false, false
 }
 
+func (f Form) pushButtonImageAndLock(id, name string) (string, bool, bool) {
+	for _, pb := range f.PushButtons {
+		if pb.ID == id || pb.Name == name {
+			return pb.Image, pb.Locked, true
+		}
+	}
+	return "", false, false
+}
+
 func extractRadioButtonGroupOptions(xRefTable *model.XRefTable, d types.Dict) ([]string, error) {
 
 	var opts []string
@@ -270,11 +290,11 @@ func extractCheckBox(page int, d types.Dict, id, name string, locked bool) (*Che
 	cb := &CheckBox{Pages: []int{page}, ID: id, Name: name, Locked: locked}
 
 	if o, ok := d.Find("DV"); ok {
-		cb.Default = o.(types.Name) == "Yes"
+		cb.Default = checkBoxOn(o)
 	}
 
 	if o, ok := d.Find("V"); ok {
-		cb.Value = o.(types.Name) == "Yes"
+		cb.Value = checkBoxOn(o)
 	}
 
 	return cb, nil
@@ -18,6 +18,7 @@ package form
 
 import (
 	"bytes"
+	"io"
 	"sort"
 	"strconv"
 	"strings"
@@ -36,6 +37,9 @@ const (
 	JSON
 )
 
+// IconLookup returns the image for the push button icon named name by the form data.
+type IconLookup func(name string) (io.Reader, error)
+
 func cacheResIDs(ctx *model.Context, pdf *primitives.PDF) error {
 	// Iterate over all pages of ctx and prepare a resIds []string for inherited "Font" and "XObject" resources.
 	for i := 1; i <= ctx.PageCount; i++ {
@@ -437,6 +441,10 @@ func FillDetails(form *Form, fieldMap map[string]CSVFieldAttributes) func(id, na
 		case FTListBox:
 			return f.listBoxValuesAndLock(id, name)
 
+		case FTPushButton:
+			v, lock, ok := f.pushButtonImageAndLock(id, name)
+			return []string{v}, lock, ok
+
 		case FTDate:
 			v, lock, ok := f.dateFieldValueAndLock(id, name)
 			return []string{v}, lock, ok
@@ -450,41 +458,111 @@ func FillDetails(form *Form, fieldMap map[string]CSVFieldAttributes) func(id, na
 	}
 }
 
-func fillRadioButtons(ctx *model.Context, d types.Dict, vNew string, v types.Name) error {
-
-	for _, o := range d.ArrayEntry("Kids") {
+// fieldWidgets returns the widget annotations of terminal field d.
+func fieldWidgets(ctx *model.Context, d types.Dict) ([]types.Dict, error) {
+	kids := d.ArrayEntry("Kids")
+	if len(kids) == 0 {
+		// Field and widget annotation are merged.
+		return []types.Dict{d}, nil
+	}
 
+	var dd []types.Dict
+	for _, o := range kids {
 		d, err := ctx.DereferenceDict(o)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		dd = append(dd, d)
+	}
+	return dd, nil
+}
 
-		d1 := d.DictEntry("AP")
-		if d1 == nil {
-			return errors.New("pdfcpu: corrupt form field: missing entry AP")
+// setAppearanceState sets the appearance state of widget d and returns true if it changed.
+func setAppearanceState(d types.Dict, as types.Name) bool {
+	if n := d.NameEntry("AS"); n != nil && *n == string(as) {
+		return false
+	}
+	d["AS"] = as
+	return true
+}
+
+// radioButtonOnState returns the on state of radio button widget d.
+func radioButtonOnState(ctx *model.Context, d types.Dict) (string, error) {
+	o, found := d.Find("AP")
+	if !found {
+		return "", errors.New("pdfcpu: corrupt form field: missing entry AP")
+	}
+	d1, err := ctx.DereferenceDict(o)
+	if err != nil {
+		return "", err
+	}
+
+	o, found = d1.Find("N")
+	if !found {
+		return "", errors.New("pdfcpu: corrupt AP field: missing entry N")
+	}
+	d2, err := ctx.DereferenceDict(o)
+	if err != nil {
+		return "", err
+	}
+
+	for k := range d2 {
+		k, err := types.DecodeName(k)
+		if err != nil {
+			return "", err
 		}
+		if k != "Off" {
+			return k, nil
+		}
+	}
+
+	return "", nil
+}
+
+// fillRadioButtons switches on all buttons of radio button group d matching vNew and switches off all others.
+// vNew matches a button either by its on state or by its export value in Opt.
+// fillRadioButtons returns the on state in effect and true if any appearance state changed.
+func fillRadioButtons(ctx *model.Context, d types.Dict, vNew string) (string, bool, error) {
+	if vNew == "Off" {
+		vNew = ""
+	}
+
+	opts, err := parseOptions(ctx.XRefTable, d)
+	if err != nil {
+		return "", false, err
+	}
 
-		d2 := d1.DictEntry("N")
-		if d2 == nil {
-			return errors.New("pdfcpu: corrupt AP field: missing entry N")
+	widgets, err := fieldWidgets(ctx, d)
+	if err != nil {
+		return "", false, err
+	}
+
+	var on string
+	states := make([]string, len(widgets))
+
+	for i, d := range widgets {
+		s, err := radioButtonOnState(ctx, d)
+		if err != nil {
+			return "", false, err
+		}
+		states[i] = s
+		if vNew != "" && on == "" && (s == vNew || i < len(opts) && opts[i] == vNew) {
+			on = s
 		}
+	}
 
-		for k := range d2 {
-			k, err := types.DecodeName(k)
-			if err != nil {
-				return err
-			}
-			if k != "Off" {
-				d["AS"] = types.Name("Off")
-				if k == vNew {
-					d["AS"] = v
-				}
-				break
-			}
+	var changed bool
+	for i, d := range widgets {
+		as := types.Name("Off")
+		if on != "" && states[i] == on {
+			as = types.Name(types.EncodeName(on))
+		}
+		if setAppearanceState(d, as) {
+			changed = true
 		}
 	}
 
-	return nil
+	return on, changed, nil
 }
 
 func fillRadioButtonGroup(
@@ -514,29 +592,26 @@ func fillRadioButtonGroup(
 	}
 
 	vNew := vv[0]
-	vOld := ""
-	if s := d.NameEntry("V"); s != nil {
-		n, err := types.DecodeName(*s)
-		if err != nil {
-			return err
-		}
-		if n != "Off" {
-			vOld = n
-		}
+	on, changed, err := fillRadioButtons(ctx, d, vNew)
+	if err != nil {
+		return err
 	}
-	if vNew == vOld {
-		return nil
+	if changed {
+		*ok = true
 	}
 
-	s := types.EncodeName(vNew)
-	v := types.Name(s)
-	d["V"] = v
-
-	if err := fillRadioButtons(ctx, d, vNew, v); err != nil {
-		return err
+	// Values not matching any button are kept as is.
+	v := types.Name("Off")
+	if on != "" {
+		v = types.Name(types.EncodeName(on))
+	} else if vNew != "" {
+		v = types.Name(types.EncodeName(vNew))
 	}
 
-	*ok = true
+	if n := d.NameEntry("V"); n == nil || *n != string(v) {
+		d["V"] = v
+		*ok = true
+	}
 
 	return nil
 }
@@ -569,53 +644,148 @@ func fillCheckBox(
 
 	s := strings.ToLower(vv[0])
 	vNew := strings.HasPrefix(s, "t")
-	vOld := false
-	if o, found := d.Find("V"); found {
-		vOld = o.(types.Name) == "Yes"
-	}
-	if vNew == vOld {
-		return nil
+
+	widgets, err := fieldWidgets(ctx, d)
+	if err != nil {
+		return err
 	}
 
+	// The value of a checked checkbox is its on state.
 	v := types.Name("Off")
 	if vNew {
 		v = types.Name("Yes")
 	}
-	d["V"] = v
-	if _, found := d.Find("AS"); found {
+
+	for _, d := range widgets {
+		if _, found := d.Find("AS"); !found {
+			continue
+		}
 		offName, yesName := primitives.CalcCheckBoxASNames(d)
 		//fmt.Printf("off:<%s> yes:<%s>\n", offName, yesName)
-		asName := yesName
-		if v == "Off" {
-			asName = offName
+		asName := offName
+		if vNew {
+			asName, v = yesName, yesName
+		}
+		if setAppearanceState(d, asName) {
+			*ok = true
 		}
-		d["AS"] = asName
 	}
-	*ok = true
+
+	if n := d.NameEntry("V"); n == nil || *n != string(v) {
+		d["V"] = v
+		*ok = true
+	}
 
 	return nil
 }
 
-func fillBtn(
+// fillPushButton replaces the icon of push button d by the image named by fillDetails.
+func fillPushButton(
 	ctx *model.Context,
 	d types.Dict,
 	id, name string,
 	locked bool,
 	format DataFormat,
 	fillDetails func(id, name string, fieldType FieldType, format DataFormat) ([]string, bool, bool),
+	icons IconLookup,
 	ok *bool) error {
 
-	ff := d.IntEntry("Ff")
-	if ff != nil && primitives.FieldFlags(*ff)&primitives.FieldPushbutton > 0 {
+	vv, lock, found := fillDetails(id, name, FTPushButton, format)
+	if !found {
 		return nil
 	}
 
-	if len(d.ArrayEntry("Kids")) > 0 && primitives.FieldFlags(*ff)&primitives.FieldRadio > 0 {
-		if err := fillRadioButtonGroup(ctx, d, id, name, locked, format, fillDetails, ok); err != nil {
-			return err
+	if locked {
+		if !lock {
+			unlockFormField(d)
+			*ok = true
 		}
 	} else {
-		if err := fillCheckBox(ctx, d, id, name, locked, format, fillDetails, ok); err != nil {
+		if lock {
+			lockFormField(d)
+			*ok = true
+		}
+	}
+
+	if len(vv) == 0 || vv[0] == "" {
+		return nil
+	}
+
+	if icons == nil {
+		return errors.Errorf("pdfcpu: push button %s: no images available for icon %s", id, vv[0])
+	}
+
+	rd, err := icons(vv[0])
+	if err != nil {
+		return err
+	}
+
+	imgIndRef, w, h, err := model.CreateImageResource(ctx.XRefTable, rd, false, false)
+	if err != nil {
+		return err
+	}
+
+	iconIndRef, err := primitives.NewIconForm(ctx.XRefTable, imgIndRef, w, h)
+	if err != nil {
+		return err
+	}
+
+	widgets, err := fieldWidgets(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range widgets {
+		if err := primitives.EnsurePushButtonIconAP(ctx, d, iconIndRef, w, h); err != nil {
+			return err
+		}
+	}
+
+	*ok = true
+
+	return nil
+}
+
+func fillBtn(
+	ctx *model.Context,
+	d types.Dict,
+	id, name string,
+	locked bool,
+	format DataFormat,
+	fillDetails func(id, name string, fieldType FieldType, format DataFormat) ([]string, bool, bool),
+	icons IconLookup,
+	ok *bool) error {
+
+	var flags primitives.FieldFlags
+	if ff := d.IntEntry("Ff"); ff != nil {
+		flags = primitives.FieldFlags(*ff)
+	}
+
+	if flags&primitives.FieldPushbutton > 0 {
+		return fillPushButton(ctx, d, id, name, locked, format, fillDetails, icons, ok)
+	}
+
+	if len(d.ArrayEntry("Kids")) > 0 && flags&primitives.FieldRadio > 0 {
+		return fillRadioButtonGroup(ctx, d, id, name, locked, format, fillDetails, ok)
+	}
+
+	return fillCheckBox(ctx, d, id, name, locked, format, fillDetails, ok)
+}
+
+// ensureChoiceAPs regenerates the appearance streams of all widgets of choice field d.
+func ensureChoiceAPs(ctx *model.Context, d types.Dict, combo bool, v string, displays []string, ind types.Array, fonts map[string]types.IndirectRef) error {
+	widgets, err := fieldWidgets(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range widgets {
+		if combo {
+			err = primitives.EnsureComboBoxAP(ctx, d, v, fonts)
+		} else {
+			err = primitives.EnsureListBoxAP(ctx, d, displays, ind, fonts)
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -627,7 +797,7 @@ func fillComboBox(
 	ctx *model.Context,
 	d types.Dict,
 	id, name string,
-	opts []string,
+	opts, displays []string,
 	locked bool,
 	format DataFormat,
 	fonts map[string]types.IndirectRef,
@@ -641,6 +811,19 @@ func fillComboBox(
 	}
 
 	vNew := vv[0]
+
+	// Editable comboboxes accept values other than their options.
+	editable := primitives.FieldFlags(*ff)&primitives.FieldEdit > 0
+
+	// Resolve vNew into an export value and its display text.
+	i := optionIndex(opts, displays, vNew)
+	text := vNew
+	if i >= 0 {
+		vNew, text = opts[i], displays[i]
+	} else if !editable {
+		text = ""
+	}
+
 	if locked {
 		if !lock {
 			unlockFormField(d)
@@ -649,7 +832,7 @@ func fillComboBox(
 		}
 	} else if lock {
 		lockFormField(d)
-		if err := primitives.EnsureComboBoxAP(ctx, d, vNew, fonts); err != nil {
+		if err := ensureChoiceAPs(ctx, d, true, text, nil, nil, fonts); err != nil {
 			return err
 		}
 		*ok = true
@@ -672,70 +855,62 @@ func fillComboBox(
 		return err
 	}
 
-	ind := types.Array{}
-	for i, o := range opts {
-		if o == vNew {
-			ind = append(ind, types.Integer(i))
-			break
-		}
-	}
-	if len(ind) > 0 {
-		d["I"] = ind
+	switch {
+	case i >= 0:
+		d["I"] = types.Array{types.Integer(i)}
 		d["V"] = types.StringLiteral(*s)
-	} else {
+	case editable && vNew != "":
+		d.Delete("I")
+		d["V"] = types.StringLiteral(*s)
+	default:
 		d.Delete("I")
 		d.Delete("V")
 	}
+
+	if _, found := d.Find("AP"); found || len(d.ArrayEntry("Kids")) > 0 {
+		if err := ensureChoiceAPs(ctx, d, true, text, nil, nil, fonts); err != nil {
+			return err
+		}
+	}
+
 	*ok = true
 
 	return nil
 }
 
-func updateListBoxValues(multi bool, d types.Dict, opts, vNew []string) (types.Array, error) {
+func updateListBoxValues(multi bool, d types.Dict, opts, displays, vNew []string) (types.Array, error) {
 	ind := types.Array{}
-	if multi {
-		arr := types.Array{}
-		for _, v := range vNew {
-			for i, o := range opts {
-				if o == v {
-					ind = append(ind, types.Integer(i))
-					break
-				}
-			}
-			s, err := types.EscapeUTF16String(v)
-			if err != nil {
-				return nil, err
-			}
-			arr = append(arr, types.StringLiteral(*s))
+	arr := types.Array{}
+
+	for _, v := range vNew {
+		i := optionIndex(opts, displays, v)
+		if i < 0 {
+			continue
 		}
-		if len(vNew) > 0 {
-			d["I"] = ind
-			d["V"] = arr
-		} else {
-			d.Delete("I")
-			d.Delete("V")
+		ind = append(ind, types.Integer(i))
+		s, err := types.EscapeUTF16String(opts[i])
+		if err != nil {
+			return nil, err
 		}
-		return ind, nil
-	}
-
-	v := vNew[0]
-	s, err := types.EscapeUTF16String(v)
-	if err != nil {
-		return nil, err
-	}
-	for i, o := range opts {
-		if o == v {
-			ind = append(ind, types.Integer(i))
+		arr = append(arr, types.StringLiteral(*s))
+		if !multi {
 			break
 		}
 	}
-	if len(ind) > 0 {
-		d["I"] = ind
-		d["V"] = types.StringLiteral(*s)
-	} else {
+
+	if len(ind) == 0 {
 		d.Delete("I")
 		d.Delete("V")
+		return ind, nil
 	}
+
+	d["I"] = ind
+	if multi {
+		d["V"] = arr
+	} else {
+		d["V"] = arr[0]
+	}
+
 	return ind, nil
 }
 
@@ -743,7 +918,7 @@ func fillListBox(
 	ctx *model.Context,
 	d types.Dict,
 	id, name string,
-	opts []string,
+	opts, displays []string,
 	locked bool,
 	format DataFormat,
 	fonts map[string]types.IndirectRef,
@@ -791,12 +966,12 @@ func fillListBox(
 		return nil
 	}
 
-	ind, err := updateListBoxValues(multi, d, opts, vNew)
+	ind, err := updateListBoxValues(multi, d, opts, displays, vNew)
 	if err != nil {
 		return err
 	}
 
-	if err := primitives.EnsureListBoxAP(ctx, d, opts, ind, fonts); err != nil {
+	if err := ensureChoiceAPs(ctx, d, false, "", displays, ind, fonts); err != nil {
 		return err
 	}
 
@@ -820,7 +995,7 @@ func fillCh(
 		return errors.New("pdfcpu: corrupt form field: missing entry Ff")
 	}
 
-	opts, err := parseOptions(ctx.XRefTable, d)
+	opts, displays, err := parseOptionPairs(ctx.XRefTable, d)
 	if err != nil {
 		return err
 	}
@@ -830,10 +1005,10 @@ func fillCh(
 	}
 
 	if primitives.FieldFlags(*ff)&primitives.FieldCombo > 0 {
-		return fillComboBox(ctx, d, id, name, opts, locked, format, fonts, fillDetails, ff, ok)
+		return fillComboBox(ctx, d, id, name, opts, displays, locked, format, fonts, fillDetails, ff, ok)
 	}
 
-	return fillListBox(ctx, d, id, name, opts, locked, format, fonts, fillDetails, ff, ok)
+	return fillListBox(ctx, d, id, name, opts, displays, locked, format, fonts, fillDetails, ff, ok)
 }
 
 func fillDateField(
@@ -842,6 +1017,7 @@ func fillDateField(
 	id, name, vOld string,
 	locked bool,
 	format DataFormat,
+	fieldFmt *fieldFormat,
 	fonts map[string]types.IndirectRef,
 	fillDetails func(id, name string, fieldType FieldType, format DataFormat) ([]string, bool, bool),
 	ff *int,
@@ -864,7 +1040,7 @@ func fillDateField(
 		}
 	}
 
-	vNew := vv[0]
+	vNew, _ := fieldFmt.apply(vv[0])
 	if vNew == vOld {
 		return nil
 	}
@@ -891,6 +1067,7 @@ func fillTextField(
 	id, name, vOld string,
 	locked bool,
 	format DataFormat,
+	fieldFmt *fieldFormat,
 	fonts map[string]types.IndirectRef,
 	fillDetails func(id, name string, fieldType FieldType, format DataFormat) ([]string, bool, bool),
 	ff *int,
@@ -913,7 +1090,8 @@ func fillTextField(
 		}
 	}
 
-	vNew := vv[0]
+	// Formatted fields store the plain value and display the formatted one.
+	vNew, text := fieldFmt.apply(vv[0])
 
 	if vNew == vOld {
 		return nil
@@ -928,26 +1106,7 @@ func fillTextField(
 
 	multiLine := ff != nil && uint(primitives.FieldFlags(*ff))&uint(primitives.FieldMultiline) > 0
 
-	kids := d.ArrayEntry("Kids")
-	if len(kids) > 0 {
-		for _, o := range kids {
-
-			d, err := ctx.DereferenceDict(o)
-			if err != nil {
-				return err
-			}
-
-			if err := primitives.EnsureTextFieldAP(ctx, d, vNew, multiLine, fonts); err != nil {
-				return err
-			}
-
-			*ok = true
-		}
-
-		return nil
-	}
-
-	if err := primitives.EnsureTextFieldAP(ctx, d, vNew, multiLine, fonts); err != nil {
+	if err := ensureTextFieldAPs(ctx, d, text, multiLine, fonts); err != nil {
 		return err
 	}
 
@@ -970,6 +1129,12 @@ func fillTx(
 	if err != nil {
 		return err
 	}
+
+	fieldFmt, err := parseFieldFormat(ctx.XRefTable, d)
+	if err != nil {
+		return err
+	}
+
 	vOld := ""
 	if o, found := d.Find("V"); found {
 		sl, _ := o.(types.StringLiteral)
@@ -981,10 +1146,13 @@ func fillTx(
 	}
 
 	if df != nil {
-		return fillDateField(ctx, d, id, name, vOld, locked, format, fonts, fillDetails, ff, ok)
+		if fieldFmt == nil || fieldFmt.category != formatDate {
+			fieldFmt = &fieldFormat{category: formatDate, pattern: df.Ext}
+		}
+		return fillDateField(ctx, d, id, name, vOld, locked, format, fieldFmt, fonts, fillDetails, ff, ok)
 	}
 
-	return fillTextField(ctx, d, id, name, vOld, locked, format, fonts, fillDetails, ff, ok)
+	return fillTextField(ctx, d, id, name, vOld, locked, format, fieldFmt, fonts, fillDetails, ff, ok)
 }
 
 func fillWidgetAnnots(
@@ -995,6 +1163,7 @@ func fillWidgetAnnots(
 	format DataFormat,
 	fonts map[string]types.IndirectRef,
 	fillDetails func(id, name string, fieldType FieldType, format DataFormat) ([]string, bool, bool),
+	icons IconLookup,
 	ok *bool) error {
 
 	for _, indRef := range *(wAnnots.IndRefs) {
@@ -1041,7 +1210,7 @@ func fillWidgetAnnots(
 
 		switch *ft {
 		case "Btn":
-			err = fillBtn(ctx, d, id, name, locked, format, fillDetails, ok)
+			err = fillBtn(ctx, d, id, name, locked, format, fillDetails, icons, ok)
 
 		case "Ch":
 			err = fillCh(ctx, d, id, name, locked, format, fonts, fillDetails, ff, ok)
@@ -1059,10 +1228,14 @@ func fillWidgetAnnots(
 }
 
 // FillForm populates form fields as provided by fillDetails and also supports virtual image fields.
+// Values of number and date fields get formatted according to the field's format
+// and fields using simple calculations get recomputed in calculation order.
+// Push button icons are taken from icons which may be nil if no push buttons are to be filled.
 func FillForm(
 	ctx *model.Context,
 	fillDetails func(id, name string, fieldType FieldType, format DataFormat) ([]string, bool, bool),
 	imgs map[string]*Page,
+	icons IconLookup,
 	format DataFormat) (bool, []*model.Page, error) {
 
 	xRefTable := ctx.XRefTable
@@ -1087,11 +1260,15 @@ func FillForm(
 			continue
 		}
 
-		if err := fillWidgetAnnots(ctx, fields, indRefs, wAnnots, format, fonts, fillDetails, &ok); err != nil {
+		if err := fillWidgetAnnots(ctx, fields, indRefs, wAnnots, format, fonts, fillDetails, icons, &ok); err != nil {
 			return false, nil, err
 		}
 	}
 
+	if err := calculateFields(ctx, fields, fonts, &ok); err != nil {
+		return false, nil, err
+	}
+
 	for fName, indRef := range fonts {
 		if len(ctx.UsedGIDs[fName]) == 0 {
 			continue
@@ -0,0 +1,304 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package form
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/matrix"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// widgetAppearance returns the normal appearance stream of widget d honoring its appearance state.
+func widgetAppearance(ctx *model.Context, d types.Dict) (*types.IndirectRef, *types.StreamDict, error) {
+	ap, err := ctx.DereferenceDict(d["AP"])
+	if err != nil || ap == nil {
+		return nil, nil, err
+	}
+
+	o, found := ap.Find("N")
+	if !found {
+		return nil, nil, nil
+	}
+
+	if states, err := ctx.DereferenceDict(o); err == nil && states != nil {
+		// Appearance subdictionary, see 12.5.5
+		as := d.NameEntry("AS")
+		if as == nil {
+			return nil, nil, nil
+		}
+		if o, found = states.Find(*as); !found {
+			return nil, nil, nil
+		}
+	}
+
+	indRef, ok := o.(types.IndirectRef)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	sd, _, err := ctx.DereferenceStreamDict(indRef)
+	if err != nil || sd == nil {
+		return nil, nil, err
+	}
+
+	return &indRef, sd, nil
+}
+
+// appearanceMatrix maps the bounding box of form sd transformed by its matrix onto r, see 12.5.5
+func appearanceMatrix(ctx *model.Context, sd *types.StreamDict, r *types.Rectangle) (*matrix.Matrix, error) {
+	a, err := ctx.DereferenceArray(sd.Dict["BBox"])
+	if err != nil || len(a) != 4 {
+		return nil, err
+	}
+	bb, err := types.RectForArray(a)
+	if err != nil {
+		return nil, err
+	}
+
+	m := matrix.IdentMatrix
+	if a := sd.Dict.ArrayEntry("Matrix"); len(a) == 6 {
+		for i, o := range a {
+			f, err := ctx.DereferenceNumber(o)
+			if err != nil {
+				return nil, err
+			}
+			m[i/2][i%2] = f
+		}
+	}
+
+	var llx, lly, urx, ury float64
+	for i, p := range []types.Point{bb.LL, bb.UR, {X: bb.LL.X, Y: bb.UR.Y}, {X: bb.UR.X, Y: bb.LL.Y}} {
+		p = m.Transform(p)
+		if i == 0 || p.X < llx {
+			llx = p.X
+		}
+		if i == 0 || p.Y < lly {
+			lly = p.Y
+		}
+		if i == 0 || p.X > urx {
+			urx = p.X
+		}
+		if i == 0 || p.Y > ury {
+			ury = p.Y
+		}
+	}
+	if urx-llx == 0 || ury-lly == 0 {
+		return nil, nil
+	}
+
+	sx, sy := r.Width()/(urx-llx), r.Height()/(ury-lly)
+
+	return &matrix.Matrix{{sx, 0, 0}, {0, sy, 0}, {r.LL.X - llx*sx, r.LL.Y - lly*sy, 1}}, nil
+}
+
+func addFormToPageResources(ctx *model.Context, d types.Dict, res types.Dict, indRef types.IndirectRef) (string, error) {
+	o, found := res.Find("XObject")
+	if !found {
+		o = types.Dict{}
+		res.Insert("XObject", o)
+	}
+	xo, err := ctx.DereferenceDict(o)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	for i := 0; ; i++ {
+		id = "Fm" + strconv.Itoa(i)
+		if _, found := xo.Find(id); !found {
+			break
+		}
+	}
+	xo.Insert(id, indRef)
+	d.Update("Resources", res)
+
+	return id, nil
+}
+
+// flattenWidget draws the current appearance of widget d onto the page.
+func flattenWidget(ctx *model.Context, pageDict, res, d types.Dict, buf *bytes.Buffer) error {
+	if f := d.IntEntry("F"); f != nil && model.AnnotationFlags(*f)&(model.AnnHidden|model.AnnNoView) > 0 {
+		return nil
+	}
+
+	indRef, sd, err := widgetAppearance(ctx, d)
+	if err != nil || sd == nil {
+		return err
+	}
+
+	a, err := ctx.DereferenceArray(d["Rect"])
+	if err != nil || len(a) != 4 {
+		return err
+	}
+	r, err := types.RectForArray(a)
+	if err != nil {
+		return err
+	}
+
+	m, err := appearanceMatrix(ctx, sd, r)
+	if err != nil || m == nil {
+		return err
+	}
+
+	id, err := addFormToPageResources(ctx, pageDict, res, *indRef)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(buf, "q %.5f 0 0 %.5f %.5f %.5f cm /%s Do Q\n", m[0][0], m[1][1], m[2][0], m[2][1], id)
+
+	return nil
+}
+
+// wrapPageContent isolates the existing page content in a q/Q pair and appends bb.
+func wrapPageContent(ctx *model.Context, d types.Dict, bb []byte) error {
+	var a types.Array
+
+	if o, found := d.Find("Contents"); found {
+		o1, err := ctx.Dereference(o)
+		if err != nil {
+			return err
+		}
+		if arr, ok := o1.(types.Array); ok {
+			a = append(a, arr...)
+		} else {
+			a = append(a, o)
+		}
+	}
+
+	newContent := func(bb []byte) (*types.IndirectRef, error) {
+		sd, _ := ctx.NewStreamDictForBuf(bb)
+		if err := sd.Encode(); err != nil {
+			return nil, err
+		}
+		return ctx.IndRefForNewObject(*sd)
+	}
+
+	q, err := newContent([]byte("q\n"))
+	if err != nil {
+		return err
+	}
+
+	Q, err := newContent(append([]byte("Q\n"), bb...))
+	if err != nil {
+		return err
+	}
+
+	d.Update("Contents", append(append(types.Array{*q}, a...), *Q))
+
+	return nil
+}
+
+func flattenPage(ctx *model.Context, pageNr int, ok *bool) error {
+	d, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+	if err != nil || d == nil {
+		return err
+	}
+
+	o, found := d.Find("Annots")
+	if !found {
+		return nil
+	}
+	annots, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return err
+	}
+
+	res := inhPAttrs.Resources
+	if res == nil {
+		res = types.Dict{}
+	}
+
+	var (
+		buf bytes.Buffer
+		arr types.Array
+	)
+
+	for _, o := range annots {
+		d1, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if d1 == nil || d1.Subtype() == nil || *d1.Subtype() != "Widget" {
+			arr = append(arr, o)
+			continue
+		}
+		if err := flattenWidget(ctx, d, res, d1, &buf); err != nil {
+			return err
+		}
+		*ok = true
+	}
+
+	if len(arr) == len(annots) {
+		return nil
+	}
+
+	if buf.Len() > 0 {
+		if err := wrapPageContent(ctx, d, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if len(arr) == 0 {
+		d.Delete("Annots")
+	} else {
+		d.Update("Annots", arr)
+	}
+
+	if pgAnnots, found := ctx.PageAnnots[pageNr]; found {
+		delete(pgAnnots, model.AnnWidget)
+	}
+
+	return nil
+}
+
+// FlattenForm burns the current values of all form fields into the page content.
+// Missing appearance streams get generated, unless NeedAppearances is set all others are taken as is.
+// The widget annotations and the AcroForm dict are removed.
+func FlattenForm(ctx *model.Context) (bool, error) {
+	xRefTable := ctx.XRefTable
+
+	if xRefTable.Form == nil {
+		return false, nil
+	}
+
+	missingOnly := true
+	if b := xRefTable.Form.BooleanEntry("NeedAppearances"); b != nil && *b {
+		missingOnly = false
+	}
+
+	if _, err := refreshFormFieldAppearances(ctx, nil, missingOnly); err != nil {
+		return false, err
+	}
+
+	var ok bool
+
+	for i := 1; i <= xRefTable.PageCount; i++ {
+		if err := flattenPage(ctx, i, &ok); err != nil {
+			return false, err
+		}
+	}
+
+	ctx.RootDict.Delete("AcroForm")
+	xRefTable.Form = nil
+
+	return ok, nil
+}
@@ -42,6 +42,7 @@ const (
 	FTComboBox
 	FTListBox
 	FTRadioButtonGroup
+	FTPushButton
 )
 
 func (ft FieldType) string() string {
@@ -59,6 +60,8 @@ func (ft FieldType) string() string {
 		s = "ListBox"
 	case FTRadioButtonGroup:
 		s = "RadioBGr."
+	case FTPushButton:
+		s = "PushButton"
 	}
 	return s
 }
@@ -218,6 +221,14 @@ func isField(xRefTable *model.XRefTable, indRef types.IndirectRef, fields types.
 func extractStringSlice(a types.Array) ([]string, error) {
 	var ss []string
 	for _, o := range a {
+		if hl, ok := o.(types.HexLiteral); ok {
+			s, err := types.HexLiteralToString(hl)
+			if err != nil {
+				return nil, err
+			}
+			ss = append(ss, s)
+			continue
+		}
 		sl, _ := o.(types.StringLiteral)
 		s, err := types.StringLiteralToString(sl)
 		if err != nil {
@@ -228,13 +239,68 @@ func extractStringSlice(a types.Array) ([]string, error) {
 	return ss, nil
 }
 
-func parseOptions(xRefTable *model.XRefTable, d types.Dict) ([]string, error) {
+// checkBoxOn returns true if the checkbox value o represents an on state.
+// The on state is named "Yes" unless a checkbox uses an individual name.
+func checkBoxOn(o types.Object) bool {
+	n, ok := o.(types.Name)
+	return ok && n != "" && n != "Off"
+}
+
+// parseOptionPairs returns the export values and display texts of the options of choice field d.
+// An option is either a text string or an array holding an export value and a display text.
+func parseOptionPairs(xRefTable *model.XRefTable, d types.Dict) ([]string, []string, error) {
 	o, _ := d.Find("Opt")
 	a, err := xRefTable.DereferenceArray(o)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	var exports, displays []string
+	for _, o := range a {
+		o, err := xRefTable.Dereference(o)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pair, ok := o.(types.Array); ok {
+			if len(pair) != 2 {
+				return nil, nil, errors.New("pdfcpu: corrupt form field: invalid option")
+			}
+			ss, err := extractStringSlice(pair)
+			if err != nil {
+				return nil, nil, err
+			}
+			exports, displays = append(exports, ss[0]), append(displays, ss[1])
+			continue
+		}
+		ss, err := extractStringSlice(types.Array{o})
+		if err != nil {
+			return nil, nil, err
+		}
+		exports, displays = append(exports, ss[0]), append(displays, ss[0])
+	}
+
+	return exports, displays, nil
+}
+
+// parseOptions returns the export values of the options of choice field d.
+func parseOptions(xRefTable *model.XRefTable, d types.Dict) ([]string, error) {
+	exports, _, err := parseOptionPairs(xRefTable, d)
+	return exports, err
+}
+
+// optionIndex returns the index of the option matching v either by export value or display text.
+func optionIndex(exports, displays []string, v string) int {
+	for i, s := range exports {
+		if s == v {
+			return i
+		}
+	}
+	for i, s := range displays {
+		if s == v {
+			return i
+		}
 	}
-	return extractStringSlice(a)
+	return -1
 }
 
 func parseStringLiteralArray(xRefTable *model.XRefTable, d types.Dict, key string) ([]string, error) {
@@ -367,7 +433,7 @@ func collectBtn(xRefTable *model.XRefTable, d types.Dict, f *Field, fm *FieldMet
 
 	f.Typ = FTCheckBox
 	if o, found := d.Find("V"); found {
-		if o.(types.Name) == "Yes" {
+		if checkBoxOn(o) {
 			v := "Yes"
 			if len(v) > fm.valMax {
 				fm.valMax = len(v)
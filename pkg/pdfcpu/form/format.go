@@ -0,0 +1,562 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package form
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/primitives"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// The format and calculation scripts generated by Acrobat for text fields call into Acrobat's built-in AForm.js.
+// The commonly used functions are emulated here so that filled values get rendered the way Acrobat renders them.
+
+type formatCategory int
+
+const (
+	formatNone formatCategory = iota
+	formatNumber
+	formatPercent
+	formatDate
+)
+
+// fieldFormat represents the format category of a text field as set up via AFNumber_Format, AFPercent_Format or AFDate_Format(Ex).
+type fieldFormat struct {
+	category formatCategory
+	nDec     int    // number of decimals
+	sepStyle int    // 0: 1,234.56 1: 1234.56 2: 1.234,56 3: 1234,56 4: 1'234.56
+	negStyle int    // 0: -1234.56 1: red 2: (1234.56) 3: red (1234.56)
+	currency string // currency symbol
+	prepend  bool   // prepend currency symbol
+	pattern  string // date pattern eg. "mm/dd/yyyy"
+}
+
+var (
+	reNumberFormat  = regexp.MustCompile(`AFNumber_Format\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)\s*,\s*\d+\s*,\s*"((?:[^"\\]|\\.)*)"\s*,\s*(true|false)\s*\)`)
+	rePercentFormat = regexp.MustCompile(`AFPercent_Format\(\s*(\d+)\s*,\s*(\d+)`)
+	reDateFormatEx  = regexp.MustCompile(`AFDate_FormatEx\(\s*"([^"]*)"\s*\)`)
+	reDateFormat    = regexp.MustCompile(`AFDate_Format\(\s*(\d+)\s*\)`)
+	reCalculate     = regexp.MustCompile(`AFSimple_Calculate\(\s*"(SUM|PRD|AVG|MIN|MAX)"\s*,\s*(?:new\s+Array\s*\(([^)]*)\)|"([^"]*)")\s*\)`)
+	reQuoted        = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+)
+
+// Predefined date patterns of AFDate_Format.
+var afDateFormats = []string{
+	"m/d", "m/d/yy", "mm/dd/yy", "mm/yy", "d-mmm", "d-mmm-yy", "dd-mmm-yy", "yy-mm-dd",
+	"mmm-yy", "mmmm-yy", "mmm d, yyyy", "mmmm d, yyyy", "m/d/yy h:MM tt", "m/d/yy HH:MM",
+}
+
+// Date pattern tokens and their Go layout counterparts, longest tokens first.
+var datePatternTokens = []struct{ token, layout string }{
+	{"yyyy", "2006"}, {"yy", "06"},
+	{"mmmm", "January"}, {"mmm", "Jan"}, {"mm", "01"}, {"m", "1"},
+	{"dddd", "Monday"}, {"ddd", "Mon"}, {"dd", "02"}, {"d", "2"},
+	{"HH", "15"}, {"H", "15"}, {"hh", "03"}, {"h", "3"},
+	{"MM", "04"}, {"M", "4"}, {"ss", "05"}, {"s", "5"},
+	{"tt", "PM"}, {"t", "PM"},
+}
+
+// actionScript returns the JavaScript of the additional action key of field d eg. "F" for format or "C" for calculate.
+func actionScript(xRefTable *model.XRefTable, d types.Dict, key string) (string, error) {
+	aa, err := xRefTable.DereferenceDict(d["AA"])
+	if err != nil || len(aa) == 0 {
+		return "", err
+	}
+
+	a, err := xRefTable.DereferenceDict(aa[key])
+	if err != nil || len(a) == 0 {
+		return "", err
+	}
+
+	o, err := xRefTable.Dereference(a["JS"])
+	if err != nil || o == nil {
+		return "", err
+	}
+
+	if sd, ok := o.(types.StreamDict); ok {
+		if err := sd.Decode(); err != nil {
+			return "", err
+		}
+		return string(sd.Content), nil
+	}
+
+	js, err := types.StringOrHexLiteral(o)
+	if err != nil || js == nil {
+		return "", err
+	}
+
+	return *js, nil
+}
+
+func unquoteJS(s string) string {
+	if u, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return u
+	}
+	return s
+}
+
+// parseFieldFormat returns the format of text field d or nil if d is not formatted.
+func parseFieldFormat(xRefTable *model.XRefTable, d types.Dict) (*fieldFormat, error) {
+	js, err := actionScript(xRefTable, d, "F")
+	if err != nil || js == "" {
+		return nil, err
+	}
+
+	if m := reNumberFormat.FindStringSubmatch(js); m != nil {
+		ff := &fieldFormat{category: formatNumber, currency: unquoteJS(m[4]), prepend: m[5] == "true"}
+		ff.nDec, _ = strconv.Atoi(m[1])
+		ff.sepStyle, _ = strconv.Atoi(m[2])
+		ff.negStyle, _ = strconv.Atoi(m[3])
+		return ff, nil
+	}
+
+	if m := rePercentFormat.FindStringSubmatch(js); m != nil {
+		ff := &fieldFormat{category: formatPercent}
+		ff.nDec, _ = strconv.Atoi(m[1])
+		ff.sepStyle, _ = strconv.Atoi(m[2])
+		return ff, nil
+	}
+
+	if m := reDateFormatEx.FindStringSubmatch(js); m != nil {
+		return &fieldFormat{category: formatDate, pattern: m[1]}, nil
+	}
+
+	if m := reDateFormat.FindStringSubmatch(js); m != nil {
+		if i, _ := strconv.Atoi(m[1]); i < len(afDateFormats) {
+			return &fieldFormat{category: formatDate, pattern: afDateFormats[i]}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (ff fieldFormat) separators() (group, decimal string) {
+	switch ff.sepStyle {
+	case 1:
+		return "", "."
+	case 2:
+		return ".", ","
+	case 3:
+		return "", ","
+	case 4:
+		return "'", "."
+	}
+	return ",", "."
+}
+
+// parseNumber parses s as entered into a number field, see AFMakeNumber.
+func (ff fieldFormat) parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if ff.currency != "" {
+		s = strings.ReplaceAll(s, ff.currency, "")
+	}
+
+	percent := strings.HasSuffix(s, "%")
+	s = strings.TrimSuffix(s, "%")
+
+	neg := strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")")
+	if neg {
+		s = s[1 : len(s)-1]
+	}
+
+	s = strings.NewReplacer(" ", "", "'", "").Replace(s)
+
+	if _, dec := ff.separators(); dec == "," && strings.Contains(s, ",") {
+		s = strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, false
+	}
+
+	if neg {
+		f = -f
+	}
+	if percent && ff.category == formatPercent {
+		f /= 100
+	}
+
+	return f, true
+}
+
+// formatNumber renders f the way AFNumber_Format and AFPercent_Format do.
+// Colors for negative numbers are not reproduced, so negStyle 1 keeps the minus sign.
+func (ff fieldFormat) formatNumber(f float64) string {
+	if ff.category == formatPercent {
+		f *= 100
+	}
+
+	s := strconv.FormatFloat(math.Abs(f), 'f', ff.nDec, 64)
+	neg := f < 0 && strings.Trim(s, "0.") != ""
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	group, dec := ff.separators()
+
+	var sb strings.Builder
+	for i, c := range intPart {
+		if i > 0 && group != "" && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(group)
+		}
+		sb.WriteRune(c)
+	}
+	if fracPart != "" {
+		sb.WriteString(dec + fracPart)
+	}
+
+	s = sb.String()
+
+	if ff.category == formatPercent {
+		s += "%"
+	} else if ff.currency != "" {
+		if ff.prepend {
+			s = ff.currency + s
+		} else {
+			s += ff.currency
+		}
+	}
+
+	if neg {
+		if ff.negStyle == 2 || ff.negStyle == 3 {
+			return "(" + s + ")"
+		}
+		return "-" + s
+	}
+
+	return s
+}
+
+// dateLayout returns the Go time layout for an Acrobat date pattern.
+func dateLayout(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); {
+		matched := false
+		for _, t := range datePatternTokens {
+			if strings.HasPrefix(pattern[i:], t.token) {
+				sb.WriteString(t.layout)
+				i += len(t.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sb.WriteByte(pattern[i])
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// parseDate parses s using the field's date pattern and falls back to ISO 8601 and all supported date formats.
+func (ff fieldFormat) parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(dateLayout(ff.pattern), s); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	if df, err := primitives.DateFormatForDate(s); err == nil {
+		if t, err := time.Parse(df.Int, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// apply returns the field value to be stored for input s along with its display text.
+// Number fields store the plain number and display the formatted number.
+// Date fields store and display the date formatted according to the field's date pattern.
+// Input not matching the format category is taken as is.
+func (ff *fieldFormat) apply(s string) (string, string) {
+	if ff == nil || strings.TrimSpace(s) == "" {
+		return s, s
+	}
+
+	switch ff.category {
+
+	case formatNumber, formatPercent:
+		f, ok := ff.parseNumber(s)
+		if !ok {
+			return s, s
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), ff.formatNumber(f)
+
+	case formatDate:
+		t, ok := ff.parseDate(s)
+		if !ok {
+			return s, s
+		}
+		s = t.Format(dateLayout(ff.pattern))
+		return s, s
+	}
+
+	return s, s
+}
+
+// simpleCalculation represents a calculation script set up via AFSimple_Calculate.
+type simpleCalculation struct {
+	op     string // SUM, PRD, AVG, MIN, MAX
+	fields []string
+}
+
+func parseSimpleCalculation(js string) *simpleCalculation {
+	m := reCalculate.FindStringSubmatch(js)
+	if m == nil {
+		return nil
+	}
+
+	calc := &simpleCalculation{op: m[1]}
+
+	if m[2] != "" {
+		for _, q := range reQuoted.FindAllStringSubmatch(m[2], -1) {
+			calc.fields = append(calc.fields, unquoteJS(q[1]))
+		}
+	} else {
+		for _, s := range strings.Split(m[3], ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				calc.fields = append(calc.fields, s)
+			}
+		}
+	}
+
+	return calc
+}
+
+func fieldValue(xRefTable *model.XRefTable, d types.Dict) (string, error) {
+	o, err := xRefTable.Dereference(d["V"])
+	if err != nil || o == nil {
+		return "", err
+	}
+	if n, ok := o.(types.Name); ok {
+		return n.Value(), nil
+	}
+	s, err := types.StringOrHexLiteral(o)
+	if err != nil || s == nil {
+		return "", nil
+	}
+	return *s, nil
+}
+
+// numberValue returns the numeric value of field d, see AFMakeNumber.
+func numberValue(xRefTable *model.XRefTable, d types.Dict) (float64, error) {
+	s, err := fieldValue(xRefTable, d)
+	if err != nil || s == "" {
+		return 0, err
+	}
+
+	fieldFmt, err := parseFieldFormat(xRefTable, d)
+	if err != nil {
+		return 0, err
+	}
+	if fieldFmt != nil && fieldFmt.category != formatDate {
+		f, _ := fieldFmt.parseNumber(s)
+		return f, nil
+	}
+
+	f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+	if err != nil {
+		return 0, nil
+	}
+
+	return f, nil
+}
+
+// terminalFields returns the terminal fields named name, including the descendants of non terminal field name.
+func terminalFields(nn []namedField, name string) []types.Dict {
+	var dd []types.Dict
+	for _, nf := range nn {
+		if nf.name != name && !strings.HasPrefix(nf.name, name+".") {
+			continue
+		}
+		terminal := true
+		for _, nf1 := range nn {
+			if strings.HasPrefix(nf1.name, nf.name+".") {
+				terminal = false
+				break
+			}
+		}
+		if terminal {
+			dd = append(dd, nf.d)
+		}
+	}
+	return dd
+}
+
+func (calc simpleCalculation) compute(xRefTable *model.XRefTable, nn []namedField) (float64, error) {
+	var (
+		v     float64
+		count int
+	)
+
+	if calc.op == "PRD" {
+		v = 1
+	}
+
+	for _, name := range calc.fields {
+		for _, d := range terminalFields(nn, name) {
+			f, err := numberValue(xRefTable, d)
+			if err != nil {
+				return 0, err
+			}
+			switch calc.op {
+			case "SUM", "AVG":
+				v += f
+			case "PRD":
+				v *= f
+			case "MIN":
+				if count == 0 || f < v {
+					v = f
+				}
+			case "MAX":
+				if count == 0 || f > v {
+					v = f
+				}
+			}
+			count++
+		}
+	}
+
+	if calc.op == "AVG" && count > 0 {
+		v /= float64(count)
+	}
+
+	return v, nil
+}
+
+func ensureTextFieldAPs(ctx *model.Context, d types.Dict, text string, multiLine bool, fonts map[string]types.IndirectRef) error {
+	kids := d.ArrayEntry("Kids")
+	if len(kids) == 0 {
+		return primitives.EnsureTextFieldAP(ctx, d, text, multiLine, fonts)
+	}
+
+	for _, o := range kids {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if err := primitives.EnsureTextFieldAP(ctx, d, text, multiLine, fonts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func calculateField(ctx *model.Context, d types.Dict, calc simpleCalculation, nn []namedField, fonts map[string]types.IndirectRef, ok *bool) error {
+	if ft := d.NameEntry("FT"); ft == nil || *ft != "Tx" {
+		return nil
+	}
+
+	f, err := calc.compute(ctx.XRefTable, nn)
+	if err != nil {
+		return err
+	}
+
+	vOld, err := fieldValue(ctx.XRefTable, d)
+	if err != nil {
+		return err
+	}
+
+	fieldFmt, err := parseFieldFormat(ctx.XRefTable, d)
+	if err != nil {
+		return err
+	}
+
+	v := strconv.FormatFloat(f, 'f', -1, 64)
+	text := v
+	if fieldFmt != nil && fieldFmt.category != formatDate {
+		text = fieldFmt.formatNumber(f)
+	}
+
+	if v == vOld {
+		return nil
+	}
+
+	s, err := types.EscapeUTF16String(v)
+	if err != nil {
+		return err
+	}
+	d["V"] = types.StringLiteral(*s)
+
+	ff := d.IntEntry("Ff")
+	multiLine := ff != nil && uint(primitives.FieldFlags(*ff))&uint(primitives.FieldMultiline) > 0
+
+	if err := ensureTextFieldAPs(ctx, d, text, multiLine, fonts); err != nil {
+		return err
+	}
+
+	*ok = true
+
+	return nil
+}
+
+// calculateFields recomputes all fields using AFSimple_Calculate in the form's calculation order (see AcroForm entry CO).
+func calculateFields(ctx *model.Context, fields types.Array, fonts map[string]types.IndirectRef, ok *bool) error {
+	xRefTable := ctx.XRefTable
+
+	co, err := xRefTable.DereferenceArray(xRefTable.Form["CO"])
+	if err != nil || len(co) == 0 {
+		return err
+	}
+
+	var nn []namedField
+	if err := collectNamedFields(xRefTable, fields, "", "", &nn, 0); err != nil {
+		return err
+	}
+
+	for _, o := range co {
+
+		d, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if len(d) == 0 {
+			continue
+		}
+
+		js, err := actionScript(xRefTable, d, "C")
+		if err != nil {
+			return err
+		}
+
+		calc := parseSimpleCalculation(js)
+		if calc == nil {
+			continue
+		}
+
+		if err := calculateField(ctx, d, *calc, nn, fonts, ok); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
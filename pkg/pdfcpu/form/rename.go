@@ -0,0 +1,235 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package form
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// namedField represents a field dict carrying a partial name.
+type namedField struct {
+	d          types.Dict
+	id, name   string // fully qualified
+	parentName string
+}
+
+func collectNamedFields(xRefTable *model.XRefTable, fields types.Array, parentID, parentName string, ff *[]namedField, depth int) error {
+	if depth > 100 {
+		return errors.New("pdfcpu: form field tree too deep")
+	}
+
+	for _, o := range fields {
+
+		d, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if len(d) == 0 {
+			continue
+		}
+
+		id, name := parentID, parentName
+
+		if ir, ok := o.(types.IndirectRef); ok {
+			if id != "" {
+				id += "."
+			}
+			id += ir.ObjectNumber.String()
+		}
+
+		t, err := d.StringOrHexLiteralEntry("T")
+		if err != nil {
+			return err
+		}
+		if t != nil {
+			if name != "" {
+				name += "."
+			}
+			name += *t
+			*ff = append(*ff, namedField{d: d, id: id, name: name, parentName: parentName})
+		}
+
+		kids, err := xRefTable.DereferenceArray(d["Kids"])
+		if err != nil {
+			return err
+		}
+
+		if err := collectNamedFields(xRefTable, kids, id, name, ff, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// partialName returns the partial name for renaming nf to newName.
+func partialName(nf namedField, newName string) (string, error) {
+	if newName == "" {
+		return "", errors.Errorf("pdfcpu: field %s: missing new name", nf.name)
+	}
+	if !strings.Contains(newName, ".") {
+		return newName, nil
+	}
+	if nf.parentName != "" && strings.HasPrefix(newName, nf.parentName+".") {
+		if s := newName[len(nf.parentName)+1:]; s != "" && !strings.Contains(s, ".") {
+			return s, nil
+		}
+	}
+	return "", errors.Errorf("pdfcpu: field %s: new name %s may not move the field within the field hierarchy", nf.name, newName)
+}
+
+func fieldByIDOrName(ff []namedField, idOrName string) *namedField {
+	for i, nf := range ff {
+		if nf.name == idOrName || nf.id == idOrName {
+			return &ff[i]
+		}
+	}
+	return nil
+}
+
+// fieldNameReplacer returns a func replacing quoted fully qualified field names (and descendants thereof) in JavaScript.
+func fieldNameReplacer(renamed map[string]string) func(string) (string, bool) {
+	oldNames := make([]string, 0, len(renamed))
+	for k := range renamed {
+		oldNames = append(oldNames, k)
+	}
+
+	// Longest names first so that the most specific rename wins.
+	sort.Slice(oldNames, func(i, j int) bool { return len(oldNames[i]) > len(oldNames[j]) })
+
+	for i, s := range oldNames {
+		oldNames[i] = regexp.QuoteMeta(s)
+	}
+	alt := strings.Join(oldNames, "|")
+
+	rr := []*regexp.Regexp{
+		regexp.MustCompile(`"(` + alt + `)((?:\.[^"\\\n]*)?)"`),
+		regexp.MustCompile(`'(` + alt + `)((?:\.[^'\\\n]*)?)'`),
+	}
+
+	return func(js string) (string, bool) {
+		js1 := js
+		for _, re := range rr {
+			js1 = re.ReplaceAllStringFunc(js1, func(s string) string {
+				sm := re.FindStringSubmatch(s)
+				q := s[:1]
+				return q + renamed[sm[1]] + sm[2] + q
+			})
+		}
+		return js1, js1 != js
+	}
+}
+
+// RenameFormFields renames the form fields of ctx as mapped by m.
+// Keys are fully qualified field names or field ids.
+// Values are new partial names or fully qualified names within the same parent field.
+// Widget kids inherit the new name and JavaScript referring to a renamed field by its fully qualified name gets updated.
+// Calculation order entries refer to fields by reference and remain valid.
+// Returns the number of renamed fields.
+func RenameFormFields(ctx *model.Context, m map[string]string) (int, error) {
+	if len(m) == 0 {
+		return 0, errors.New("pdfcpu: missing field name map")
+	}
+
+	xRefTable := ctx.XRefTable
+
+	fields, err := fields(xRefTable)
+	if err != nil {
+		return 0, err
+	}
+
+	var ff []namedField
+	if err := collectNamedFields(xRefTable, fields, "", "", &ff, 0); err != nil {
+		return 0, err
+	}
+
+	type rename struct {
+		nf          *namedField
+		partialName string
+		newName     string
+	}
+
+	var rr []rename
+	newNames := map[string]bool{}
+
+	for k, v := range m {
+		nf := fieldByIDOrName(ff, k)
+		if nf == nil {
+			return 0, errors.Errorf("pdfcpu: unknown form field: %s", k)
+		}
+		pn, err := partialName(*nf, v)
+		if err != nil {
+			return 0, err
+		}
+		newName := pn
+		if nf.parentName != "" {
+			newName = nf.parentName + "." + pn
+		}
+		if newName == nf.name {
+			continue
+		}
+		if newNames[newName] {
+			return 0, errors.Errorf("pdfcpu: duplicate form field name: %s", newName)
+		}
+		newNames[newName] = true
+		rr = append(rr, rename{nf: nf, partialName: pn, newName: newName})
+	}
+
+	renamed := map[string]string{}
+	for _, r := range rr {
+		renamed[r.nf.name] = r.newName
+	}
+
+	// New names must not collide with names of fields not renamed away.
+	for _, nf := range ff {
+		if _, ok := renamed[nf.name]; ok {
+			continue
+		}
+		if newNames[nf.name] {
+			return 0, errors.Errorf("pdfcpu: duplicate form field name: %s", nf.name)
+		}
+	}
+
+	for _, r := range rr {
+		s, err := types.EscapeUTF16String(r.partialName)
+		if err != nil {
+			return 0, err
+		}
+		r.nf.d["T"] = types.StringLiteral(*s)
+	}
+
+	if len(renamed) == 0 {
+		return 0, nil
+	}
+
+	f := fieldNameReplacer(renamed)
+
+	if _, err := pdfcpu.ReplaceScripts(ctx, func(s pdfcpu.Script) (string, bool) {
+		return f(s.Source)
+	}); err != nil {
+		return 0, err
+	}
+
+	return len(renamed), nil
+}
@@ -0,0 +1,381 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package form
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/primitives"
+)
+
+// JSONSchemaVersion is the JSON Schema draft used for form schemas.
+const JSONSchemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// Schema represents the subset of JSON Schema needed to describe form data.
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Const       interface{}        `json:"const,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Default     interface{}        `json:"default,omitempty"`
+	Examples    []string           `json:"examples,omitempty"`
+	ReadOnly    bool               `json:"readOnly,omitempty"`
+	MinLength   int                `json:"minLength,omitempty"`
+	MaxLength   int                `json:"maxLength,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	MinItems    int                `json:"minItems,omitempty"`
+	MaxItems    int                `json:"maxItems,omitempty"`
+	UniqueItems bool               `json:"uniqueItems,omitempty"`
+	Contains    *Schema            `json:"contains,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	AnyOf       []*Schema          `json:"anyOf,omitempty"`
+	AllOf       []*Schema          `json:"allOf,omitempty"`
+	Definitions map[string]*Schema `json:"definitions,omitempty"`
+}
+
+type fieldConstraint struct {
+	required bool
+	maxLen   int
+}
+
+func fieldConstraints(xRefTable *model.XRefTable) (map[string]fieldConstraint, error) {
+
+	fields, err := fields(xRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	fcs := map[string]fieldConstraint{}
+
+	for i := 1; i <= xRefTable.PageCount; i++ {
+
+		d, _, _, err := xRefTable.PageDict(i, false)
+		if err != nil {
+			return nil, err
+		}
+
+		o, found := d.Find("Annots")
+		if !found {
+			continue
+		}
+
+		arr, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := fieldsForAnnots(xRefTable, arr, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		for id, fi := range m {
+			d, err := xRefTable.DereferenceDict(*fi.indRef)
+			if err != nil {
+				return nil, err
+			}
+
+			var fc fieldConstraint
+			if ff := d.IntEntry("Ff"); ff != nil {
+				fc.required = uint(primitives.FieldFlags(*ff))&uint(primitives.FieldRequired) > 0
+			}
+			if maxLen := d.IntEntry("MaxLen"); maxLen != nil {
+				fc.maxLen = *maxLen
+			}
+			fcs[id] = fc
+		}
+	}
+
+	return fcs, nil
+}
+
+// datePattern returns a regular expression matching dates in the external date format ext eg. yyyy-mm-dd.
+func datePattern(ext string) string {
+	var sb strings.Builder
+	for len(ext) > 0 {
+		switch {
+		case strings.HasPrefix(ext, "yyyy"):
+			sb.WriteString("[0-9]{4}")
+			ext = ext[4:]
+		case strings.HasPrefix(ext, "mm"), strings.HasPrefix(ext, "dd"):
+			sb.WriteString("[0-9]{2}")
+			ext = ext[2:]
+		case ext[0] == 'm', ext[0] == 'd':
+			sb.WriteString("[0-9]{1,2}")
+			ext = ext[1:]
+		default:
+			sb.WriteString(regexp.QuoteMeta(ext[:1]))
+			ext = ext[1:]
+		}
+	}
+	return sb.String()
+}
+
+func fieldSchema(id, name string, locked bool, value *Schema) *Schema {
+	s := &Schema{
+		Type:  "object",
+		Title: name,
+		Properties: map[string]*Schema{
+			"id":     {Type: "string", Const: id},
+			"locked": {Type: "boolean"},
+			"pages":  {Type: "array", Items: &Schema{Type: "integer"}},
+		},
+		Required: []string{"id"},
+	}
+	if s.Title == "" {
+		s.Title = id
+	}
+	if name != "" {
+		s.Properties["name"] = &Schema{Type: "string", Const: name}
+	}
+	value.ReadOnly = locked
+	if value.Type == "array" {
+		s.Properties["values"] = value
+	} else {
+		s.Properties["value"] = value
+	}
+	return s
+}
+
+// fieldsSchema returns the schema for a list of fields of the same type.
+// Required fields have to be present in the list.
+func fieldsSchema(ss []*Schema, required []bool) *Schema {
+	s := &Schema{Type: "array", Items: &Schema{AnyOf: ss}}
+	for i, fs := range ss {
+		if !required[i] {
+			continue
+		}
+		s.AllOf = append(s.AllOf, &Schema{Contains: &Schema{
+			Properties: map[string]*Schema{"id": fs.Properties["id"]},
+			Required:   []string{"id"},
+		}})
+	}
+	return s
+}
+
+func textFieldsSchema(tfs []*TextField, fcs map[string]fieldConstraint) *Schema {
+	var (
+		ss       []*Schema
+		required []bool
+	)
+	for _, tf := range tfs {
+		fc := fcs[tf.ID]
+		v := &Schema{Type: "string", MaxLength: fc.maxLen}
+		if tf.Default != "" {
+			v.Default = tf.Default
+		}
+		if tf.Multiline {
+			v.Description = "multiline"
+		}
+		if fc.required {
+			v.MinLength = 1
+		}
+		ss = append(ss, fieldSchema(tf.ID, tf.Name, tf.Locked, v))
+		required = append(required, fc.required)
+	}
+	return fieldsSchema(ss, required)
+}
+
+func dateFieldsSchema(dfs []*DateField, fcs map[string]fieldConstraint) *Schema {
+	var (
+		ss       []*Schema
+		required []bool
+	)
+	for _, df := range dfs {
+		fc := fcs[df.ID]
+		pattern := "^(" + datePattern(df.Format) + ")?$"
+		if fc.required {
+			pattern = "^" + datePattern(df.Format) + "$"
+		}
+		v := &Schema{Type: "string", Description: df.Format, Pattern: pattern}
+		if df.Default != "" {
+			v.Default = df.Default
+		}
+		ss = append(ss, fieldSchema(df.ID, df.Name, df.Locked, v))
+		required = append(required, fc.required)
+	}
+	return fieldsSchema(ss, required)
+}
+
+func checkBoxesSchema(cbs []*CheckBox, fcs map[string]fieldConstraint) *Schema {
+	var (
+		ss       []*Schema
+		required []bool
+	)
+	for _, cb := range cbs {
+		v := &Schema{Type: "boolean", Default: cb.Default}
+		ss = append(ss, fieldSchema(cb.ID, cb.Name, cb.Locked, v))
+		required = append(required, fcs[cb.ID].required)
+	}
+	return fieldsSchema(ss, required)
+}
+
+// choice returns the enumeration of options accepting the empty string for optional fields.
+func choice(options []string, required bool) []string {
+	if required {
+		return options
+	}
+	return append([]string{""}, options...)
+}
+
+func radioButtonGroupsSchema(rbgs []*RadioButtonGroup, fcs map[string]fieldConstraint) *Schema {
+	var (
+		ss       []*Schema
+		required []bool
+	)
+	for _, rbg := range rbgs {
+		fc := fcs[rbg.ID]
+		v := &Schema{Type: "string", Enum: choice(rbg.Options, fc.required)}
+		if rbg.Default != "" {
+			v.Default = rbg.Default
+		}
+		ss = append(ss, fieldSchema(rbg.ID, rbg.Name, rbg.Locked, v))
+		required = append(required, fc.required)
+	}
+	return fieldsSchema(ss, required)
+}
+
+func comboBoxesSchema(cbs []*ComboBox, fcs map[string]fieldConstraint) *Schema {
+	var (
+		ss       []*Schema
+		required []bool
+	)
+	for _, cb := range cbs {
+		fc := fcs[cb.ID]
+		v := &Schema{Type: "string"}
+		if cb.Editable {
+			// Editable comboboxes accept any value.
+			v.Examples = cb.Options
+			if fc.required {
+				v.MinLength = 1
+			}
+		} else {
+			v.Enum = choice(cb.Options, fc.required)
+		}
+		if cb.Default != "" {
+			v.Default = cb.Default
+		}
+		ss = append(ss, fieldSchema(cb.ID, cb.Name, cb.Locked, v))
+		required = append(required, fc.required)
+	}
+	return fieldsSchema(ss, required)
+}
+
+func listBoxesSchema(lbs []*ListBox, fcs map[string]fieldConstraint) *Schema {
+	var (
+		ss       []*Schema
+		required []bool
+	)
+	for _, lb := range lbs {
+		fc := fcs[lb.ID]
+		v := &Schema{Type: "array", Items: &Schema{Type: "string", Enum: lb.Options}, UniqueItems: true}
+		if !lb.Multi {
+			v.MaxItems = 1
+		}
+		if fc.required {
+			v.MinItems = 1
+		}
+		if len(lb.Defaults) > 0 {
+			v.Default = lb.Defaults
+		}
+		ss = append(ss, fieldSchema(lb.ID, lb.Name, lb.Locked, v))
+		required = append(required, fc.required)
+	}
+	return fieldsSchema(ss, required)
+}
+
+// ExportFormSchema returns a JSON schema for the form data of xRefTable originating from source.
+// The schema describes the JSON format of ExportFormJSON and FillForm
+// including field types, options, required fields and maximum field lengths.
+func ExportFormSchema(xRefTable *model.XRefTable, source string) (*Schema, bool, error) {
+
+	formGroup, ok, err := ExportForm(xRefTable, source)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	fcs, err := fieldConstraints(xRefTable)
+	if err != nil {
+		return nil, false, err
+	}
+
+	form := formGroup.Forms[0]
+
+	fs := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	if len(form.TextFields) > 0 {
+		fs.Properties["textfield"] = textFieldsSchema(form.TextFields, fcs)
+	}
+	if len(form.DateFields) > 0 {
+		fs.Properties["datefield"] = dateFieldsSchema(form.DateFields, fcs)
+	}
+	if len(form.CheckBoxes) > 0 {
+		fs.Properties["checkbox"] = checkBoxesSchema(form.CheckBoxes, fcs)
+	}
+	if len(form.RadioButtonGroups) > 0 {
+		fs.Properties["radiobuttongroup"] = radioButtonGroupsSchema(form.RadioButtonGroups, fcs)
+	}
+	if len(form.ComboBoxes) > 0 {
+		fs.Properties["combobox"] = comboBoxesSchema(form.ComboBoxes, fcs)
+	}
+	if len(form.ListBoxes) > 0 {
+		fs.Properties["listbox"] = listBoxesSchema(form.ListBoxes, fcs)
+	}
+
+	s := &Schema{
+		Schema:      JSONSchemaVersion,
+		Title:       filepath.Base(source),
+		Description: "pdfcpu form data",
+		Type:        "object",
+		Properties: map[string]*Schema{
+			"header": {Type: "object"},
+			"forms":  {Type: "array", Items: &Schema{Ref: "#/definitions/form"}, MinItems: 1},
+		},
+		Required:    []string{"forms"},
+		Definitions: map[string]*Schema{"form": fs},
+	}
+
+	return s, true, nil
+}
+
+// ExportFormSchemaJSON writes a JSON schema for the form data of xRefTable originating from source to w.
+func ExportFormSchemaJSON(xRefTable *model.XRefTable, source string, w io.Writer) (bool, error) {
+
+	s, ok, err := ExportFormSchema(xRefTable, source)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	bb, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return false, err
+	}
+
+	_, err = w.Write(bb)
+
+	return ok, err
+}
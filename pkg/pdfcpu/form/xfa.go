@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package form
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// XFAPacket represents a packet of an XML Data Package (XDP) eg. template, datasets, config.
+type XFAPacket struct {
+	Name    string `json:"name"`
+	Content []byte `json:"-"`
+}
+
+// XFA represents the XML Forms Architecture (XFA) data of a form.
+type XFA struct {
+	// Dynamic forms get rendered from the template and usually lack meaningful AcroForm fields.
+	Dynamic bool `json:"dynamic"`
+
+	// Number of AcroForm fields serving as static fallback.
+	Fields int `json:"fields"`
+
+	Packets []XFAPacket `json:"packets"`
+}
+
+func (xfa XFA) String() string {
+	var sb strings.Builder
+	kind := "static"
+	if xfa.Dynamic {
+		kind = "dynamic"
+	}
+	fmt.Fprintf(&sb, "XFA form: %s, AcroForm fields: %d\n", kind, xfa.Fields)
+	for _, p := range xfa.Packets {
+		fmt.Fprintf(&sb, "  %s (%d bytes)\n", p.Name, len(p.Content))
+	}
+	return sb.String()
+}
+
+func xfaPacket(xRefTable *model.XRefTable, name string, o types.Object) (*XFAPacket, error) {
+	sd, _, err := xRefTable.DereferenceStreamDict(o)
+	if err != nil || sd == nil {
+		return nil, err
+	}
+	if err := sd.Decode(); err != nil {
+		return nil, err
+	}
+	return &XFAPacket{Name: name, Content: sd.Content}, nil
+}
+
+func xfaPackets(xRefTable *model.XRefTable, o types.Object) ([]XFAPacket, error) {
+	o, err := xRefTable.Dereference(o)
+	if err != nil || o == nil {
+		return nil, err
+	}
+
+	if _, ok := o.(types.StreamDict); ok {
+		// A single stream containing the complete XDP.
+		p, err := xfaPacket(xRefTable, "xdp", o)
+		if err != nil || p == nil {
+			return nil, err
+		}
+		return []XFAPacket{*p}, nil
+	}
+
+	a, ok := o.(types.Array)
+	if !ok {
+		return nil, errors.New("pdfcpu: corrupt XFA entry")
+	}
+
+	var pp []XFAPacket
+
+	for i := 0; i+1 < len(a); i += 2 {
+		s, err := xRefTable.DereferenceStringOrHexLiteral(a[i], model.V10, nil)
+		if err != nil {
+			return nil, err
+		}
+		p, err := xfaPacket(xRefTable, s, a[i+1])
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			pp = append(pp, *p)
+		}
+	}
+
+	return pp, nil
+}
+
+// ReadXFA returns the XFA data of ctx or nil if there is none.
+func ReadXFA(ctx *model.Context) (*XFA, error) {
+	xRefTable := ctx.XRefTable
+
+	if xRefTable.Form == nil {
+		return nil, nil
+	}
+
+	o, found := xRefTable.Form.Find("XFA")
+	if !found || o == nil {
+		return nil, nil
+	}
+
+	pp, err := xfaPackets(xRefTable, o)
+	if err != nil {
+		return nil, err
+	}
+
+	xfa := &XFA{Packets: pp}
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	if b := rootDict.BooleanEntry("NeedsRendering"); b != nil {
+		xfa.Dynamic = *b
+	}
+
+	if _, err := fields(xRefTable); err == nil {
+		fs, _, err := FormFields(ctx)
+		if err != nil {
+			return nil, err
+		}
+		xfa.Fields = len(fs)
+	}
+
+	return xfa, nil
+}
+
+// RemoveXFA removes the XFA data of ctx so that readers fall back to the AcroForm.
+// Returns false if there is no XFA data.
+func RemoveXFA(ctx *model.Context) (bool, error) {
+	xRefTable := ctx.XRefTable
+
+	if xRefTable.Form == nil {
+		return false, nil
+	}
+
+	if _, found := xRefTable.Form.Find("XFA"); !found {
+		return false, nil
+	}
+
+	xRefTable.Form.Delete("XFA")
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return false, err
+	}
+	rootDict.Delete("NeedsRendering")
+
+	// Usage rights signatures cover the XFA form.
+	if d, err := xRefTable.DereferenceDict(rootDict["Perms"]); err == nil && d != nil {
+		d.Delete("UR3")
+		if len(d) == 0 {
+			rootDict.Delete("Perms")
+		}
+	}
+
+	return true, nil
+}
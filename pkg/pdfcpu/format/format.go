@@ -18,17 +18,58 @@ package format
 
 import (
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 )
 
 // Text returns a string with resolved place holders for pageNr, pageCount, timestamp or pdfcpu version.
 func Text(text, timeStampFormat string, pageNr, pageCount int) (string, bool) {
-	// replace  %p with pageNr
-	//			%P with pageCount
-	//			%t with timestamp
-	//			%v with pdfcpu version
+	return TextWithTimestamp(text, model.Timestamp{Layout: timeStampFormat}, pageNr, pageCount)
+}
+
+// TextWithTimestamp returns a string with resolved place holders for pageNr, pageCount, timestamp or pdfcpu version
+// rendering the timestamp according to ts.
+func TextWithTimestamp(text string, ts model.Timestamp, pageNr, pageCount int) (string, bool) {
+	return TextWithFields(text, ts, pageNr, pageCount, nil)
+}
+
+// namedField resolves a named place holder at the start of s and returns its value and length.
+func namedField(s string, ts model.Timestamp, pageNr, pageCount int, doc *model.DocFields) (string, int, bool) {
+	switch {
+	case strings.HasPrefix(s, "PageNumber"):
+		return strconv.Itoa(pageNr), len("PageNumber"), true
+	case strings.HasPrefix(s, "PageCount"):
+		return strconv.Itoa(pageCount), len("PageCount"), true
+	case strings.HasPrefix(s, "Filename"):
+		if doc == nil {
+			return "", len("Filename"), true
+		}
+		return doc.FileName, len("Filename"), true
+	case strings.HasPrefix(s, "Now"):
+		return ts.Now(), len("Now"), true
+	case strings.HasPrefix(s, "Info("):
+		j := strings.IndexByte(s, ')')
+		if j < 0 {
+			return "", 0, false
+		}
+		if doc == nil {
+			return "", j + 1, true
+		}
+		return doc.Info[s[len("Info("):j]], j + 1, true
+	}
+	return "", 0, false
+}
+
+// TextWithFields returns a string with resolved place holders rendering the timestamp according to ts:
+//
+//	%p, %PageNumber  ... pageNr
+//	%P, %PageCount   ... pageCount
+//	%t, %Now         ... timestamp
+//	%v               ... pdfcpu version
+//	%Filename        ... input file name
+//	%Info(key)       ... info dict entry eg. %Info(Title)
+func TextWithFields(text string, ts model.Timestamp, pageNr, pageCount int, doc *model.DocFields) (string, bool) {
 	var (
 		bb         []byte
 		hasPercent bool
@@ -44,6 +85,12 @@ func Text(text, timeStampFormat string, pageNr, pageCount int) (string, bool) {
 		}
 		if hasPercent {
 			hasPercent = false
+			if v, n, ok := namedField(text[i:], ts, pageNr, pageCount, doc); ok {
+				bb = append(bb, v...)
+				unique = true
+				i += n - 1
+				continue
+			}
 			if text[i] == 'p' {
 				bb = append(bb, strconv.Itoa(pageNr)...)
 				unique = true
@@ -55,7 +102,7 @@ func Text(text, timeStampFormat string, pageNr, pageCount int) (string, bool) {
 				continue
 			}
 			if text[i] == 't' {
-				bb = append(bb, time.Now().Format(timeStampFormat)...)
+				bb = append(bb, ts.Now()...)
 				unique = true
 				continue
 			}
@@ -0,0 +1,379 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"math"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedFunction indicates a function that cannot be evaluated (eg. PostScript calculator functions).
+var ErrUnsupportedFunction = errors.New("pdfcpu: unsupported function")
+
+// function is a single input PDF function, see 7.10.
+type function interface {
+	eval(x float64) []float64
+}
+
+func clip(x, min, max float64) float64 {
+	return math.Max(min, math.Min(max, x))
+}
+
+func interpolate(x, xmin, xmax, ymin, ymax float64) float64 {
+	if xmax == xmin {
+		return ymin
+	}
+	return ymin + (x-xmin)*(ymax-ymin)/(xmax-xmin)
+}
+
+func numberArray(ctx *model.Context, d types.Dict, key string) ([]float64, error) {
+	o, found := d.Find(key)
+	if !found {
+		return nil, nil
+	}
+	a, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return nil, err
+	}
+	ff := make([]float64, len(a))
+	for i, o := range a {
+		o, err := ctx.Dereference(o)
+		if err != nil {
+			return nil, err
+		}
+		switch o := o.(type) {
+		case types.Integer:
+			ff[i] = float64(o.Value())
+		case types.Float:
+			ff[i] = o.Value()
+		default:
+			return nil, errors.Errorf("pdfcpu: %s: number expected", key)
+		}
+	}
+	return ff, nil
+}
+
+func numberEntry(ctx *model.Context, d types.Dict, key string, def float64) (float64, error) {
+	o, found := d.Find(key)
+	if !found {
+		return def, nil
+	}
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return 0, err
+	}
+	switch o := o.(type) {
+	case types.Integer:
+		return float64(o.Value()), nil
+	case types.Float:
+		return o.Value(), nil
+	}
+	return 0, errors.Errorf("pdfcpu: %s: number expected", key)
+}
+
+// Type 2: exponential interpolation function.
+type exponentialFunction struct {
+	domain [2]float64
+	c0, c1 []float64
+	n      float64
+}
+
+func (f exponentialFunction) eval(x float64) []float64 {
+	x = clip(x, f.domain[0], f.domain[1])
+	xn := math.Pow(x, f.n)
+	out := make([]float64, len(f.c0))
+	for i := range out {
+		out[i] = f.c0[i] + xn*(f.c1[i]-f.c0[i])
+	}
+	return out
+}
+
+// Type 3: stitching function.
+type stitchingFunction struct {
+	domain    [2]float64
+	functions []function
+	bounds    []float64
+	encode    []float64
+}
+
+func (f stitchingFunction) eval(x float64) []float64 {
+	x = clip(x, f.domain[0], f.domain[1])
+	k := len(f.bounds)
+	for i, b := range f.bounds {
+		if x < b {
+			k = i
+			break
+		}
+	}
+	lo, hi := f.domain[0], f.domain[1]
+	if k > 0 {
+		lo = f.bounds[k-1]
+	}
+	if k < len(f.bounds) {
+		hi = f.bounds[k]
+	}
+	return f.functions[k].eval(interpolate(x, lo, hi, f.encode[2*k], f.encode[2*k+1]))
+}
+
+// Type 0: sampled function with a single input.
+type sampledFunction struct {
+	domain  [2]float64
+	encode  [2]float64
+	decode  []float64
+	size    int
+	outputs int
+	samples []float64 // normalized to 0..1
+}
+
+func (f sampledFunction) eval(x float64) []float64 {
+	x = clip(x, f.domain[0], f.domain[1])
+	e := clip(interpolate(x, f.domain[0], f.domain[1], f.encode[0], f.encode[1]), 0, float64(f.size-1))
+	i0 := int(math.Floor(e))
+	i1 := i0 + 1
+	if i1 >= f.size {
+		i1 = f.size - 1
+	}
+	w := e - float64(i0)
+	out := make([]float64, f.outputs)
+	for j := range out {
+		s := f.samples[i0*f.outputs+j]*(1-w) + f.samples[i1*f.outputs+j]*w
+		out[j] = interpolate(s, 0, 1, f.decode[2*j], f.decode[2*j+1])
+	}
+	return out
+}
+
+// functionArray combines n single output functions.
+type functionArray []function
+
+func (ff functionArray) eval(x float64) []float64 {
+	out := make([]float64, 0, len(ff))
+	for _, f := range ff {
+		out = append(out, f.eval(x)...)
+	}
+	return out
+}
+
+func domain(ctx *model.Context, d types.Dict) ([2]float64, error) {
+	dom, err := numberArray(ctx, d, "Domain")
+	if err != nil {
+		return [2]float64{}, err
+	}
+	if len(dom) < 2 {
+		return [2]float64{}, errors.New("pdfcpu: function: corrupt Domain")
+	}
+	return [2]float64{dom[0], dom[1]}, nil
+}
+
+func newExponentialFunction(ctx *model.Context, d types.Dict) (function, error) {
+	dom, err := domain(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	c0, err := numberArray(ctx, d, "C0")
+	if err != nil {
+		return nil, err
+	}
+	if c0 == nil {
+		c0 = []float64{0}
+	}
+	c1, err := numberArray(ctx, d, "C1")
+	if err != nil {
+		return nil, err
+	}
+	if c1 == nil {
+		c1 = []float64{1}
+	}
+	if len(c0) != len(c1) {
+		return nil, errors.New("pdfcpu: function: C0 and C1 differ in size")
+	}
+	n, err := numberEntry(ctx, d, "N", 1)
+	if err != nil {
+		return nil, err
+	}
+	return exponentialFunction{domain: dom, c0: c0, c1: c1, n: n}, nil
+}
+
+func newStitchingFunction(ctx *model.Context, d types.Dict) (function, error) {
+	dom, err := domain(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	a, err := ctx.DereferenceArray(d["Functions"])
+	if err != nil {
+		return nil, err
+	}
+	if len(a) == 0 {
+		return nil, errors.New("pdfcpu: function: missing Functions")
+	}
+	ff := make([]function, len(a))
+	for i, o := range a {
+		if ff[i], err = newFunction(ctx, o); err != nil {
+			return nil, err
+		}
+	}
+	bounds, err := numberArray(ctx, d, "Bounds")
+	if err != nil {
+		return nil, err
+	}
+	encode, err := numberArray(ctx, d, "Encode")
+	if err != nil {
+		return nil, err
+	}
+	if len(bounds) != len(ff)-1 || len(encode) != 2*len(ff) {
+		return nil, errors.New("pdfcpu: function: corrupt stitching function")
+	}
+	return stitchingFunction{domain: dom, functions: ff, bounds: bounds, encode: encode}, nil
+}
+
+func newSampledFunction(ctx *model.Context, sd *types.StreamDict) (function, error) {
+	dom, err := domain(ctx, sd.Dict)
+	if err != nil {
+		return nil, err
+	}
+	size, err := numberArray(ctx, sd.Dict, "Size")
+	if err != nil {
+		return nil, err
+	}
+	if len(size) != 1 || size[0] < 1 {
+		// Sampled functions with more than one input are not supported.
+		return nil, ErrUnsupportedFunction
+	}
+	rng, err := numberArray(ctx, sd.Dict, "Range")
+	if err != nil {
+		return nil, err
+	}
+	if len(rng) < 2 {
+		return nil, errors.New("pdfcpu: function: corrupt Range")
+	}
+	bps := sd.IntEntry("BitsPerSample")
+	if bps == nil {
+		return nil, errors.New("pdfcpu: function: missing BitsPerSample")
+	}
+
+	f := sampledFunction{domain: dom, size: int(size[0]), outputs: len(rng) / 2, encode: [2]float64{0, size[0] - 1}}
+
+	encode, err := numberArray(ctx, sd.Dict, "Encode")
+	if err != nil {
+		return nil, err
+	}
+	if len(encode) >= 2 {
+		f.encode = [2]float64{encode[0], encode[1]}
+	}
+	if f.decode, err = numberArray(ctx, sd.Dict, "Decode"); err != nil {
+		return nil, err
+	}
+	if len(f.decode) < len(rng) {
+		f.decode = rng
+	}
+
+	if err := sd.Decode(); err != nil {
+		return nil, err
+	}
+	r := bitReader{bb: sd.Content}
+	max := float64(uint64(1)<<*bps - 1)
+	n := f.size * f.outputs
+	f.samples = make([]float64, n)
+	for i := 0; i < n; i++ {
+		v, ok := r.read(*bps)
+		if !ok {
+			return nil, errors.New("pdfcpu: function: insufficient samples")
+		}
+		f.samples[i] = float64(v) / max
+	}
+
+	return f, nil
+}
+
+// newFunction returns the single input function o.
+func newFunction(ctx *model.Context, o types.Object) (function, error) {
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o := o.(type) {
+
+	case types.Array:
+		ff := make(functionArray, len(o))
+		for i, o1 := range o {
+			if ff[i], err = newFunction(ctx, o1); err != nil {
+				return nil, err
+			}
+		}
+		return ff, nil
+
+	case types.Dict:
+		switch ft := o.IntEntry("FunctionType"); {
+		case ft == nil:
+			return nil, errors.New("pdfcpu: function: missing FunctionType")
+		case *ft == 2:
+			return newExponentialFunction(ctx, o)
+		case *ft == 3:
+			return newStitchingFunction(ctx, o)
+		}
+
+	case types.StreamDict:
+		if ft := o.IntEntry("FunctionType"); ft != nil && *ft == 0 {
+			return newSampledFunction(ctx, &o)
+		}
+	}
+
+	return nil, ErrUnsupportedFunction
+}
+
+// functionCount returns the number of non stitching functions making up o.
+func functionCount(ctx *model.Context, o types.Object) (int, error) {
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return 0, err
+	}
+
+	var a types.Array
+
+	switch o := o.(type) {
+
+	case types.Array:
+		a = o
+
+	case types.Dict:
+		if ft := o.IntEntry("FunctionType"); ft == nil || *ft != 3 {
+			return 1, nil
+		}
+		if a, err = ctx.DereferenceArray(o["Functions"]); err != nil {
+			return 0, err
+		}
+
+	case types.StreamDict:
+		return 1, nil
+
+	default:
+		return 0, nil
+	}
+
+	c := 0
+	for _, o := range a {
+		i, err := functionCount(ctx, o)
+		if err != nil {
+			return 0, err
+		}
+		c += i
+	}
+	return c, nil
+}
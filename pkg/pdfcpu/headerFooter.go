@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+var errNoHeaderFooter = errors.New("pdfcpu: no header or footer found")
+
+// hfSlot identifies a header or footer slot.
+type hfSlot struct {
+	header bool
+	pos    types.Anchor
+	text   string
+}
+
+func (s hfSlot) watermark(hf *model.HeaderFooter) (*model.Watermark, error) {
+	wm := model.DefaultWatermarkConfig()
+	wm.OnTop = true
+	wm.Name, wm.Artifact = model.FooterName, model.ArtifactFooter
+	if s.header {
+		wm.Name, wm.Artifact = model.HeaderName, model.ArtifactHeader
+	}
+	wm.Pos = s.pos
+	wm.PageBox = hf.PageBox
+	if hf.FontName != "" {
+		wm.FontName = hf.FontName
+	}
+	wm.FontSize = 10
+	if hf.FontSize > 0 {
+		wm.FontSize = hf.FontSize
+	}
+	wm.Color, wm.FillColor, wm.StrokeColor = hf.FillColor, hf.FillColor, hf.FillColor
+
+	// Render true to size and upright.
+	wm.Scale, wm.ScaleAbs = 1, true
+	wm.Diagonal, wm.Rotation, wm.UserRotOrDiagonal = model.NoDiagonal, 0, true
+
+	return wm, setWatermarkType(model.WMText, s.text, wm)
+}
+
+func hfSlots(header bool, slots model.HFSlots) []hfSlot {
+	top, bot := []types.Anchor{types.TopLeft, types.TopCenter, types.TopRight}, []types.Anchor{types.BottomLeft, types.BottomCenter, types.BottomRight}
+	anchors := bot
+	if header {
+		anchors = top
+	}
+	var ss []hfSlot
+	for i, t := range []string{slots.Left, slots.Center, slots.Right} {
+		if t != "" {
+			ss = append(ss, hfSlot{header: header, pos: anchors[i], text: t})
+		}
+	}
+	return ss
+}
+
+// alignSlot moves wm from its anchor into the page by margin m.
+func alignSlot(wm *model.Watermark, m float64) {
+	wm.Dx, wm.Dy = 0, m
+	switch wm.Pos {
+	case types.TopLeft, types.BottomLeft:
+		wm.Dx = m
+	case types.TopRight, types.BottomRight:
+		wm.Dx = -m
+	}
+	if wm.Artifact == model.ArtifactHeader {
+		wm.Dy = -m
+	}
+}
+
+func removePageHeaderFooter(ctx *model.Context, pageNr int) (bool, error) {
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return false, err
+	}
+	if d == nil {
+		return false, errors.Errorf("pdfcpu: invalid page number: %d", pageNr)
+	}
+
+	// Pages without own content or resources can't have a header or footer.
+	if _, found := d.Find("Resources"); !found {
+		return false, nil
+	}
+	if _, found := d.Find("Contents"); !found {
+		return false, nil
+	}
+
+	var removed bool
+	for _, name := range []string{model.HeaderName, model.FooterName} {
+		ok, err := removePageWatermark(ctx, pageNr, model.WMIDPrefix+name)
+		if err != nil {
+			return false, err
+		}
+		removed = removed || ok
+	}
+	return removed, nil
+}
+
+func selectedPagesOrAll(ctx *model.Context, selectedPages types.IntSet) []int {
+	var pageNrs []int
+	for i := 1; i <= ctx.PageCount; i++ {
+		if len(selectedPages) == 0 || selectedPages[i] {
+			pageNrs = append(pageNrs, i)
+		}
+	}
+	return pageNrs
+}
+
+// AddHeaderFooter adds hf to selected pages replacing any header and footer added before.
+// Slots are positioned relative to the anchor box of each page (defaults to the crop box) and tagged as header or footer pagination artifacts.
+func AddHeaderFooter(ctx *model.Context, selectedPages types.IntSet, hf *model.HeaderFooter) error {
+	if hf == nil {
+		return errors.New("pdfcpu: missing header/footer")
+	}
+
+	if err := hf.Validate(); err != nil {
+		return err
+	}
+
+	if log.DebugEnabled() {
+		log.Debug.Printf("AddHeaderFooter %v\n", *hf)
+	}
+
+	ocg, err := prepareOCPropertiesInRoot(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	extGState, err := createExtGStateForStamp(ctx, 1, "")
+	if err != nil {
+		return err
+	}
+
+	// Slots with identical content share their watermark and resources.
+	wms := map[hfSlot]*model.Watermark{}
+
+	for _, pageNr := range selectedPagesOrAll(ctx, selectedPages) {
+
+		if _, err := removePageHeaderFooter(ctx, pageNr); err != nil {
+			return err
+		}
+
+		d, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return err
+		}
+		r, err := pageBoxForName(ctx, d, inhPAttrs, hf.PageBox)
+		if err != nil {
+			return err
+		}
+		m := hf.MarginFor(r)
+
+		header, footer := hf.Slots(pageNr)
+
+		for _, s := range append(hfSlots(true, header), hfSlots(false, footer)...) {
+			wm, ok := wms[s]
+			if !ok {
+				if wm, err = s.watermark(hf); err != nil {
+					return err
+				}
+				wm.Ocg, wm.ExtGState = ocg, extGState
+				if err := createResourcesForWM(ctx, wm); err != nil {
+					return err
+				}
+				wms[s] = wm
+			}
+			alignSlot(wm, m)
+			if err := addPageWatermark(ctx, pageNr, *wm); err != nil {
+				return err
+			}
+		}
+	}
+
+	ctx.EnsureVersionForWriting()
+	return nil
+}
+
+// RemoveHeaderFooter removes headers and footers added by AddHeaderFooter from selected pages.
+func RemoveHeaderFooter(ctx *model.Context, selectedPages types.IntSet) error {
+	if log.DebugEnabled() {
+		log.Debug.Printf("RemoveHeaderFooter\n")
+	}
+
+	var removed bool
+	for _, pageNr := range selectedPagesOrAll(ctx, selectedPages) {
+		ok, err := removePageHeaderFooter(ctx, pageNr)
+		if err != nil {
+			return err
+		}
+		removed = removed || ok
+	}
+
+	if !removed {
+		return errNoHeaderFooter
+	}
+
+	return nil
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// ICCProfile represents an ICC profile embedded into a PDF file.
+type ICCProfile struct {
+	ObjNr         int      // The object number of the profile stream.
+	N             int      // The number of color components.
+	Size          int      // The size of the profile in bytes.
+	Version       string   // The profile version.
+	Class         string   // The profile/device class, eg. "mntr" or "prtr".
+	ColorSpace    string   // The data color space, eg. "RGB" or "CMYK".
+	Description   string   // The profile description.
+	ICCBased      bool     // true if used by an ICCBased color space.
+	OutputIntents []string // The output condition identifiers of all output intents using this profile.
+}
+
+func (p ICCProfile) String() string {
+	var ss []string
+	if p.ICCBased {
+		ss = append(ss, "ICCBased")
+	}
+	for _, s := range p.OutputIntents {
+		ss = append(ss, "OutputIntent "+s)
+	}
+	return fmt.Sprintf("obj#%d: %s %s v%s %d components, %d bytes, %q, used by: %s",
+		p.ObjNr, p.ColorSpace, p.Class, p.Version, p.N, p.Size, p.Description, strings.Join(ss, ", "))
+}
+
+// iccHeaderSize is the size of an ICC profile header including the tag count.
+const iccHeaderSize = 132
+
+func validICCProfile(bb []byte) bool {
+	return len(bb) >= iccHeaderSize && string(bb[36:40]) == "acsp"
+}
+
+// iccComponents returns the number of color components for an ICC data color space signature.
+func iccComponents(cs string) int {
+	switch cs {
+	case "GRAY":
+		return 1
+	case "CMYK":
+		return 4
+	case "XYZ ", "Lab ", "Luv ", "YCbr", "Yxy ", "RGB ", "HSV ", "HLS ", "CMY ":
+		return 3
+	}
+	// nCLR, n = 2..F
+	if len(cs) == 4 && cs[1:] == "CLR" {
+		var n int
+		if _, err := fmt.Sscanf(cs[:1], "%X", &n); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// description returns the content of the profileDescriptionTag.
+func (p iccProfile) description() string {
+	count := p.tagCount()
+	for i, j := 0, iccHeaderSize; i < count && j+12 <= len(p.b); i, j = i+1, j+12 {
+		if string(p.b[j:j+4]) != "desc" {
+			continue
+		}
+		off := int(binary.BigEndian.Uint32(p.b[j+4:]))
+		size := int(binary.BigEndian.Uint32(p.b[j+8:]))
+		if off < 0 || size < 12 || off+size > len(p.b) {
+			return ""
+		}
+		return iccTextDescription(p.b[off : off+size])
+	}
+	return ""
+}
+
+func iccTextDescription(b []byte) string {
+	switch string(b[:4]) {
+
+	case "desc":
+		// ICC v2 textDescriptionType: ASCII count followed by ASCII text.
+		n := int(binary.BigEndian.Uint32(b[8:]))
+		if n <= 0 || 12+n > len(b) {
+			return ""
+		}
+		return strings.TrimRight(string(b[12:12+n]), "\x00")
+
+	case "mluc":
+		// ICC v4 multiLocalizedUnicodeType: use the first record.
+		if len(b) < 28 || binary.BigEndian.Uint32(b[8:]) == 0 {
+			return ""
+		}
+		n := int(binary.BigEndian.Uint32(b[20:]))
+		off := int(binary.BigEndian.Uint32(b[24:]))
+		if off+n > len(b) {
+			return ""
+		}
+		u := make([]uint16, n/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(b[off+2*i:])
+		}
+		return strings.TrimRight(string(utf16.Decode(u)), "\x00")
+	}
+
+	return ""
+}
+
+func iccProfileStreamDict(ctx *model.Context, objNr int) (*types.StreamDict, error) {
+	sd, _, err := ctx.DereferenceStreamDict(*types.NewIndirectRef(objNr, 0))
+	if err != nil {
+		return nil, err
+	}
+	if sd == nil {
+		return nil, errors.Errorf("pdfcpu: missing ICC profile obj#%d", objNr)
+	}
+	return sd, nil
+}
+
+// ICCProfileData returns the decoded ICC profile for the profile stream objNr.
+func ICCProfileData(ctx *model.Context, objNr int) ([]byte, error) {
+	sd, err := iccProfileStreamDict(ctx, objNr)
+	if err != nil {
+		return nil, err
+	}
+	if err := sd.Decode(); err != nil {
+		return nil, err
+	}
+	return sd.Content, nil
+}
+
+type iccProfileUsage struct {
+	iccBased      bool
+	outputIntents []string
+}
+
+func collectICCProfileUsage(o types.Object, m map[int]*iccProfileUsage) {
+	usage := func(ir types.IndirectRef) *iccProfileUsage {
+		objNr := ir.ObjectNumber.Value()
+		u, ok := m[objNr]
+		if !ok {
+			u = &iccProfileUsage{}
+			m[objNr] = u
+		}
+		return u
+	}
+
+	switch o := o.(type) {
+
+	case types.Array:
+		if len(o) == 2 {
+			if n, ok := o[0].(types.Name); ok && n.Value() == model.ICCBasedCS {
+				if ir, ok := o[1].(types.IndirectRef); ok {
+					usage(ir).iccBased = true
+					return
+				}
+			}
+		}
+		for _, o1 := range o {
+			collectICCProfileUsage(o1, m)
+		}
+
+	case types.Dict:
+		if ir := o.IndirectRefEntry("DestOutputProfile"); ir != nil {
+			id := ""
+			if s, err := o.StringOrHexLiteralEntry("OutputConditionIdentifier"); err == nil && s != nil {
+				id = *s
+			}
+			u := usage(*ir)
+			u.outputIntents = append(u.outputIntents, id)
+		}
+		for _, o1 := range o {
+			collectICCProfileUsage(o1, m)
+		}
+
+	case types.StreamDict:
+		collectICCProfileUsage(o.Dict, m)
+	}
+}
+
+func iccProfileUsages(ctx *model.Context) (map[int]*iccProfileUsage, error) {
+	m := map[int]*iccProfileUsage{}
+
+	for objNr, entry := range ctx.Table {
+		if entry.Free || ctx.Read.IsObjectStreamObject(objNr) || ctx.Read.IsXRefStreamObject(objNr) {
+			continue
+		}
+		o, err := ctx.FindObject(objNr)
+		if err != nil {
+			return nil, err
+		}
+		collectICCProfileUsage(o, m)
+	}
+
+	return m, nil
+}
+
+// ICCProfiles returns all ICC profiles used by ICCBased color spaces or output intents sorted by object number.
+func ICCProfiles(ctx *model.Context) ([]ICCProfile, error) {
+	m, err := iccProfileUsages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pp []ICCProfile
+
+	for objNr, u := range m {
+		sd, err := iccProfileStreamDict(ctx, objNr)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(u.outputIntents)
+		p := ICCProfile{ObjNr: objNr, ICCBased: u.iccBased, OutputIntents: u.outputIntents}
+		if n := sd.IntEntry("N"); n != nil {
+			p.N = *n
+		}
+
+		if err := sd.Decode(); err != nil {
+			return nil, err
+		}
+
+		p.Size = len(sd.Content)
+
+		if validICCProfile(sd.Content) {
+			ip := iccProfile{b: sd.Content}
+			p.Version = ip.version()
+			p.Class = strings.TrimSpace(ip.class())
+			p.ColorSpace = strings.TrimSpace(ip.dataColorSpace())
+			p.Description = ip.description()
+		}
+
+		pp = append(pp, p)
+	}
+
+	sort.Slice(pp, func(i, j int) bool { return pp[i].ObjNr < pp[j].ObjNr })
+
+	return pp, nil
+}
+
+// ReplaceICCProfile replaces the content of the profile stream objNr with the ICC profile bb.
+// The color space of bb has to match the number of color components of the profile to be replaced.
+// All color spaces and output intents referring to objNr are affected.
+func ReplaceICCProfile(ctx *model.Context, objNr int, bb []byte) error {
+	if !validICCProfile(bb) {
+		return errors.New("pdfcpu: invalid ICC profile")
+	}
+
+	entry, ok := ctx.FindTableEntryLight(objNr)
+	if !ok {
+		return errors.Errorf("pdfcpu: missing ICC profile obj#%d", objNr)
+	}
+
+	sd, ok := entry.Object.(types.StreamDict)
+	if !ok {
+		return errors.Errorf("pdfcpu: obj#%d is not an ICC profile", objNr)
+	}
+
+	n := iccComponents(iccProfile{b: bb}.dataColorSpace())
+	if n == 0 {
+		return errors.New("pdfcpu: ICC profile: unsupported color space")
+	}
+	if n0 := sd.IntEntry("N"); n0 != nil && *n0 != n {
+		return errors.Errorf("pdfcpu: obj#%d: ICC profile with %d color components expected, got %d", objNr, *n0, n)
+	}
+
+	sd1, err := ctx.NewStreamDictForBuf(bb)
+	if err != nil {
+		return err
+	}
+
+	// Keep any entries like Alternate or Range.
+	for k, v := range sd.Dict {
+		if k != "Filter" && k != "DecodeParms" && k != "Length" {
+			sd1.Insert(k, v)
+		}
+	}
+	sd1.Update("N", types.Integer(n))
+
+	if err := sd1.Encode(); err != nil {
+		return err
+	}
+
+	entry.Object = *sd1
+
+	return nil
+}
+
+// ReplaceICCProfiles replaces the profiles with the given object numbers with the ICC profile bb.
+// If objNrs is empty all profiles with a matching number of color components get replaced.
+// Returns the object numbers of the replaced profiles.
+func ReplaceICCProfiles(ctx *model.Context, bb []byte, objNrs []int) ([]int, error) {
+	if !validICCProfile(bb) {
+		return nil, errors.New("pdfcpu: invalid ICC profile")
+	}
+
+	if len(objNrs) == 0 {
+		pp, err := ICCProfiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		n := iccComponents(iccProfile{b: bb}.dataColorSpace())
+		for _, p := range pp {
+			if p.N == n {
+				objNrs = append(objNrs, p.ObjNr)
+			}
+		}
+	}
+
+	for _, objNr := range objNrs {
+		if err := ReplaceICCProfile(ctx, objNr, bb); err != nil {
+			return nil, err
+		}
+	}
+
+	return objNrs, nil
+}
@@ -233,24 +233,29 @@ func ListImages(ctx *model.Context, selectedPages types.IntSet) ([]string, error
 	return append([]string{fmt.Sprintf("%d images available(%s)", j, types.ByteSize(size))}, ss...), nil
 }
 
+// ImageFileName returns the file name for an extracted image.
+func ImageFileName(img model.Image, fileName string, maxPageDigits int) string {
+	s := "%s_%" + fmt.Sprintf("0%dd", maxPageDigits)
+	qual := img.Name
+	if img.Thumb {
+		qual = "thumb"
+	}
+	// if singleImgPerPage {
+	// 	if img.thumb {
+	// 		s += "_" + qual
+	// 	}
+	// 	return fmt.Sprintf(s+".%s", fileName, img.pageNr, img.FileType)
+	// }
+	return fmt.Sprintf(s+"_%s.%s", fileName, img.PageNr, qual, img.FileType)
+}
+
 // WriteImageToDisk returns a closure for writing img to disk.
 func WriteImageToDisk(outDir, fileName string) func(model.Image, bool, int) error {
 	return func(img model.Image, singleImgPerPage bool, maxPageDigits int) error {
 		if img.Reader == nil {
 			return nil
 		}
-		s := "%s_%" + fmt.Sprintf("0%dd", maxPageDigits)
-		qual := img.Name
-		if img.Thumb {
-			qual = "thumb"
-		}
-		f := fmt.Sprintf(s+"_%s.%s", fileName, img.PageNr, qual, img.FileType)
-		// if singleImgPerPage {
-		// 	if img.thumb {
-		// 		s += "_" + qual
-		// 	}
-		// 	f = fmt.Sprintf(s+".%s", fileName, img.pageNr, img.FileType)
-		// }
+		f := ImageFileName(img, fileName, maxPageDigits)
 		outFile := filepath.Join(outDir, f)
 		log.CLI.Printf("writing %s\n", outFile)
 		return WriteReader(outFile, img)
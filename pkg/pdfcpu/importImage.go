@@ -380,3 +380,49 @@ func NewPageForImage(xRefTable *model.XRefTable, r io.Reader, parentIndRef *type
 
 	return xRefTable.IndRefForNewObject(pageDict)
 }
+
+// FlushPageImages writes the images of page pageIndRef right away and releases their stream data.
+// This keeps memory bounded when importing large numbers of images, see BeginWrite.
+func FlushPageImages(ctx *model.Context, pageIndRef types.IndirectRef) error {
+	pageDict, err := ctx.DereferenceDict(pageIndRef)
+	if err != nil || pageDict == nil {
+		return err
+	}
+
+	resDict, err := ctx.DereferenceDict(pageDict["Resources"])
+	if err != nil || resDict == nil {
+		return err
+	}
+
+	xObjDict, err := ctx.DereferenceDict(resDict["XObject"])
+	if err != nil || xObjDict == nil {
+		return err
+	}
+
+	for _, o := range xObjDict {
+		ir, ok := o.(types.IndirectRef)
+		if !ok {
+			continue
+		}
+		sd, _, err := ctx.DereferenceStreamDict(ir)
+		if err != nil {
+			return err
+		}
+		if sd == nil || sd.Subtype() == nil || *sd.Subtype() != "Image" {
+			continue
+		}
+		// Soft masks and stencil masks first.
+		for _, k := range []string{"SMask", "Mask"} {
+			if maskIndRef := sd.IndirectRefEntry(k); maskIndRef != nil {
+				if err := FlushStreamDict(ctx, *maskIndRef); err != nil {
+					return err
+				}
+			}
+		}
+		if err := FlushStreamDict(ctx, ir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,518 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// maxFormInlineDepth limits the number of passes for inlining nested form XObjects.
+const maxFormInlineDepth = 8
+
+var resourceCategories = []string{"ColorSpace", "ExtGState", "Font", "Pattern", "Properties", "Shading", "XObject"}
+
+// referenceCounts returns the number of references to each object reachable from the root object.
+func referenceCounts(ctx *model.Context) (map[int]int, error) {
+	m := map[int]int{}
+	visited := map[int]bool{}
+
+	var walk func(o types.Object) error
+
+	walk = func(o types.Object) error {
+		switch o := o.(type) {
+
+		case types.IndirectRef:
+			objNr := o.ObjectNumber.Value()
+			m[objNr]++
+			if visited[objNr] {
+				return nil
+			}
+			visited[objNr] = true
+			o1, err := ctx.Dereference(o)
+			if err != nil {
+				return err
+			}
+			return walk(o1)
+
+		case types.Dict:
+			for _, v := range o {
+				if err := walk(v); err != nil {
+					return err
+				}
+			}
+
+		case types.StreamDict:
+			return walk(o.Dict)
+
+		case types.Array:
+			for _, v := range o {
+				if err := walk(v); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(*ctx.Root); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// contentStream is a content stream along with the resources in effect.
+type contentStream struct {
+	objNr   int
+	resDict types.Dict // nil for forms without resources.
+}
+
+// contentStreams returns all content streams of pages, form XObjects and tiling patterns reachable from the root object.
+func contentStreams(ctx *model.Context, refCounts map[int]int) ([]contentStream, error) {
+	var cc []contentStream
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		d, _, _, err := ctx.PageDict(i, false)
+		if err != nil {
+			return nil, err
+		}
+		resDict, err := pageResourceDict(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		switch o := d["Contents"].(type) {
+		case types.IndirectRef:
+			cc = append(cc, contentStream{o.ObjectNumber.Value(), resDict})
+		case types.Array:
+			for _, o1 := range o {
+				if ir, ok := o1.(types.IndirectRef); ok {
+					cc = append(cc, contentStream{ir.ObjectNumber.Value(), resDict})
+				}
+			}
+		}
+	}
+
+	objNrs := make([]int, 0, len(refCounts))
+	for objNr := range refCounts {
+		objNrs = append(objNrs, objNr)
+	}
+	sort.Ints(objNrs)
+
+	for _, objNr := range objNrs {
+		entry, ok := ctx.FindTableEntryLight(objNr)
+		if !ok || entry.Free {
+			continue
+		}
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok {
+			continue
+		}
+		form := sd.Subtype() != nil && *sd.Subtype() == "Form"
+		tiling := sd.IntEntry("PatternType") != nil && *sd.IntEntry("PatternType") == 1
+		if !form && !tiling {
+			continue
+		}
+		resDict, err := ctx.DereferenceDict(sd.Dict["Resources"])
+		if err != nil {
+			return nil, err
+		}
+		cc = append(cc, contentStream{objNr, resDict})
+	}
+
+	return cc, nil
+}
+
+func (cs contentStream) operations(ctx *model.Context) (*types.StreamDict, []ContentOperation, error) {
+	entry, ok := ctx.FindTableEntryLight(cs.objNr)
+	if !ok {
+		return nil, nil, nil
+	}
+	sd, ok := entry.Object.(types.StreamDict)
+	if !ok {
+		return nil, nil, nil
+	}
+	if err := sd.Decode(); err != nil {
+		return nil, nil, err
+	}
+	ops, err := ParseContentStream(sd.Content)
+	if err != nil {
+		return nil, nil, nil
+	}
+	return &sd, ops, nil
+}
+
+type formInliner struct {
+	ctx       *model.Context
+	refCounts map[int]int
+	usage     map[int]int     // Do operations per form objNr
+	ambiguous map[string]bool // XObject names used by content without resources
+	inlined   map[int]bool    // forms inlined during the current pass
+}
+
+func (fi *formInliner) xObjectRef(resDict types.Dict, name string) *types.IndirectRef {
+	if resDict == nil {
+		return nil
+	}
+	d, err := fi.ctx.DereferenceDict(resDict["XObject"])
+	if err != nil || d == nil {
+		return nil
+	}
+	ir, ok := d[name].(types.IndirectRef)
+	if !ok {
+		return nil
+	}
+	return &ir
+}
+
+func (fi *formInliner) countUsage(cc []contentStream) error {
+	for _, cs := range cc {
+		_, ops, err := cs.operations(fi.ctx)
+		if err != nil {
+			return err
+		}
+		for _, op := range ops {
+			if op.Operator != "Do" || len(op.Operands) != 1 {
+				continue
+			}
+			name, ok := op.Operands[0].(types.Name)
+			if !ok {
+				continue
+			}
+			if cs.resDict == nil {
+				fi.ambiguous[name.Value()] = true
+				continue
+			}
+			if ir := fi.xObjectRef(cs.resDict, name.Value()); ir != nil {
+				fi.usage[ir.ObjectNumber.Value()]++
+			}
+		}
+	}
+	return nil
+}
+
+// inlinable returns the form XObject referenced by name if it can be inlined.
+func (fi *formInliner) inlinable(resDict types.Dict, name string) (*types.StreamDict, int) {
+	if fi.ambiguous[name] {
+		return nil, 0
+	}
+	ir := fi.xObjectRef(resDict, name)
+	if ir == nil {
+		return nil, 0
+	}
+	objNr := ir.ObjectNumber.Value()
+	if fi.refCounts[objNr] != 1 || fi.usage[objNr] != 1 {
+		return nil, 0
+	}
+	sd, _, err := fi.ctx.DereferenceStreamDict(*ir)
+	if err != nil || sd == nil || sd.Subtype() == nil || *sd.Subtype() != "Form" {
+		return nil, 0
+	}
+	// Transparency groups, optional content, reference XObjects and tagged content keep their form.
+	for _, k := range []string{"Group", "OC", "Ref", "StructParent", "StructParents"} {
+		if _, found := sd.Find(k); found {
+			return nil, 0
+		}
+	}
+	return sd, objNr
+}
+
+func sameResource(o1, o2 types.Object) bool {
+	ir1, ok1 := o1.(types.IndirectRef)
+	ir2, ok2 := o2.(types.IndirectRef)
+	if ok1 && ok2 {
+		return ir1.ObjectNumber == ir2.ObjectNumber && ir1.GenerationNumber == ir2.GenerationNumber
+	}
+	if ok1 || ok2 {
+		return false
+	}
+	return o1.String() == o2.String()
+}
+
+// mergeResources merges the resources of a form into resDict and returns the resource names of the form that had to be renamed.
+func mergeResources(ctx *model.Context, resDict, formResDict types.Dict) (map[string]map[string]string, error) {
+	renamed := map[string]map[string]string{}
+
+	for _, cat := range resourceCategories {
+		src, err := ctx.DereferenceDict(formResDict[cat])
+		if err != nil {
+			return nil, err
+		}
+		if len(src) == 0 {
+			continue
+		}
+
+		dest, err := ctx.DereferenceDict(resDict[cat])
+		if err != nil {
+			return nil, err
+		}
+		if dest == nil {
+			dest = types.NewDict()
+			resDict.Insert(cat, dest)
+		}
+
+		names := make([]string, 0, len(src))
+		for k := range src {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		for _, k := range names {
+			v := src[k]
+			v1, found := dest.Find(k)
+			if !found {
+				dest.Insert(k, v)
+				continue
+			}
+			if sameResource(v, v1) {
+				continue
+			}
+			k1 := k
+			for i := 1; ; i++ {
+				k1 = fmt.Sprintf("%s_%d", k, i)
+				_, found1 := dest.Find(k1)
+				_, found2 := src.Find(k1)
+				if !found1 && !found2 {
+					break
+				}
+			}
+			dest.Insert(k1, v)
+			if renamed[cat] == nil {
+				renamed[cat] = map[string]string{}
+			}
+			renamed[cat][k] = k1
+		}
+	}
+
+	return renamed, nil
+}
+
+func renameOperand(oo []types.Object, i int, m map[string]string) {
+	if i < 0 || i >= len(oo) || m == nil {
+		return
+	}
+	if n, ok := oo[i].(types.Name); ok {
+		if s, ok := m[n.Value()]; ok {
+			oo[i] = types.Name(s)
+		}
+	}
+}
+
+// renameResources applies renamed resource names to ops.
+func renameResources(ops []ContentOperation, renamed map[string]map[string]string) {
+	if len(renamed) == 0 {
+		return
+	}
+	for i, op := range ops {
+		oo := op.Operands
+		switch op.Operator {
+		case "Tf":
+			renameOperand(oo, 0, renamed["Font"])
+		case "Do":
+			renameOperand(oo, 0, renamed["XObject"])
+		case "gs":
+			renameOperand(oo, 0, renamed["ExtGState"])
+		case "cs", "CS":
+			renameOperand(oo, 0, renamed["ColorSpace"])
+		case "scn", "SCN":
+			renameOperand(oo, len(oo)-1, renamed["Pattern"])
+		case "sh":
+			renameOperand(oo, 0, renamed["Shading"])
+		case "BDC", "DP":
+			renameOperand(oo, 1, renamed["Properties"])
+		case "BI":
+			m := renamed["ColorSpace"]
+			for _, k := range []string{"CS", "ColorSpace"} {
+				if n, ok := op.ImageDict[k].(types.Name); ok {
+					if s, ok := m[n.Value()]; ok {
+						ops[i].ImageDict[k] = types.Name(s)
+					}
+				}
+			}
+		}
+	}
+}
+
+// inlinedForm returns the content operations replacing the invocation of form sd.
+func (fi *formInliner) inlinedForm(sd *types.StreamDict, resDict types.Dict) ([]ContentOperation, error) {
+	if err := sd.Decode(); err != nil {
+		return nil, err
+	}
+	ops, err := ParseContentStream(sd.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	formResDict, err := fi.ctx.DereferenceDict(sd.Dict["Resources"])
+	if err != nil {
+		return nil, err
+	}
+	if formResDict != nil {
+		renamed, err := mergeResources(fi.ctx, resDict, formResDict)
+		if err != nil {
+			return nil, err
+		}
+		renameResources(ops, renamed)
+	}
+
+	ops1 := []ContentOperation{{Operator: "q"}}
+
+	if m, err := numberArray(fi.ctx, sd.Dict, "Matrix"); err == nil && len(m) == 6 {
+		if m[0] != 1 || m[1] != 0 || m[2] != 0 || m[3] != 1 || m[4] != 0 || m[5] != 0 {
+			oo := make([]types.Object, 6)
+			for i, f := range m {
+				oo[i] = types.Float(f)
+			}
+			ops1 = append(ops1, ContentOperation{Operator: "cm", Operands: oo})
+		}
+	}
+
+	if bb, err := numberArray(fi.ctx, sd.Dict, "BBox"); err == nil && len(bb) == 4 {
+		r := types.NewRectangle(bb[0], bb[1], bb[2], bb[3])
+		ops1 = append(ops1,
+			ContentOperation{Operator: "re", Operands: []types.Object{
+				types.Float(r.LL.X), types.Float(r.LL.Y), types.Float(r.Width()), types.Float(r.Height())}},
+			ContentOperation{Operator: "W"},
+			ContentOperation{Operator: "n"},
+		)
+	}
+
+	ops1 = append(ops1, ops...)
+
+	return append(ops1, ContentOperation{Operator: "Q"}), nil
+}
+
+func (fi *formInliner) inlineForms(cs contentStream) (int, error) {
+	if cs.resDict == nil {
+		return 0, nil
+	}
+
+	sd, ops, err := cs.operations(fi.ctx)
+	if err != nil || sd == nil {
+		return 0, err
+	}
+
+	var (
+		ops1 []ContentOperation
+		c    int
+	)
+
+	for _, op := range ops {
+		if op.Operator != "Do" || len(op.Operands) != 1 {
+			ops1 = append(ops1, op)
+			continue
+		}
+		name, ok := op.Operands[0].(types.Name)
+		if !ok {
+			ops1 = append(ops1, op)
+			continue
+		}
+		form, objNr := fi.inlinable(cs.resDict, name.Value())
+		if form == nil {
+			ops1 = append(ops1, op)
+			continue
+		}
+		formOps, err := fi.inlinedForm(form, cs.resDict)
+		if err != nil {
+			// Keep forms with unparsable content.
+			ops1 = append(ops1, op)
+			continue
+		}
+
+		d, err := fi.ctx.DereferenceDict(cs.resDict["XObject"])
+		if err != nil {
+			return 0, err
+		}
+		d.Delete(name.Value())
+		fi.refCounts[objNr]--
+		fi.usage[objNr]--
+		fi.inlined[objNr] = true
+
+		ops1 = append(ops1, formOps...)
+		c++
+	}
+
+	if c == 0 {
+		return 0, nil
+	}
+
+	if err := setContentStream(sd, ContentStreamBytes(ops1)); err != nil {
+		return 0, err
+	}
+
+	entry, _ := fi.ctx.FindTableEntryLight(cs.objNr)
+	entry.Object = *sd
+
+	return c, nil
+}
+
+// InlineFormXObjects replaces invocations of form XObjects used only once by the form content.
+// Nested form XObjects get inlined up to a depth of 8.
+// Forms representing transparency groups, optional content, reference XObjects or tagged content are kept.
+// Returns the number of inlined forms.
+func InlineFormXObjects(ctx *model.Context) (int, error) {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+
+	for i := 0; i < maxFormInlineDepth; i++ {
+		refCounts, err := referenceCounts(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		cc, err := contentStreams(ctx, refCounts)
+		if err != nil {
+			return 0, err
+		}
+
+		fi := &formInliner{ctx: ctx, refCounts: refCounts, usage: map[int]int{}, ambiguous: map[string]bool{}, inlined: map[int]bool{}}
+		if err := fi.countUsage(cc); err != nil {
+			return 0, err
+		}
+
+		c := 0
+		for _, cs := range cc {
+			if fi.inlined[cs.objNr] {
+				// Orphaned during this pass.
+				continue
+			}
+			n, err := fi.inlineForms(cs)
+			if err != nil {
+				return 0, err
+			}
+			c += n
+		}
+
+		if c == 0 {
+			break
+		}
+		total += c
+	}
+
+	if log.OptimizeEnabled() {
+		log.Optimize.Printf("inlined %d form XObject(s)\n", total)
+	}
+
+	return total, nil
+}
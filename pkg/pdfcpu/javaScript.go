@@ -0,0 +1,613 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// JavaScript locations.
+const (
+	ScriptDocument   = "document"   // Catalog "OpenAction" or additional action
+	ScriptNamed      = "named"      // Document level script of the "JavaScript" name tree
+	ScriptPage       = "page"       // Page additional action
+	ScriptAnnotation = "annotation" // Annotation action or additional action
+	ScriptField      = "field"      // Form field additional action
+)
+
+// maxActionChain limits the number of actions followed via "Next".
+const maxActionChain = 100
+
+// Script represents a JavaScript action found in a PDF.
+type Script struct {
+	Location string `json:"location"`
+	Name     string `json:"name,omitempty"`    // Name tree key or fully qualified field name.
+	Trigger  string `json:"trigger,omitempty"` // Triggering entry eg. "OpenAction", "O", "K" or "A".
+	PageNr   int    `json:"page,omitempty"`
+	ObjNr    int    `json:"objNr,omitempty"` // Object number of an indirect action dict.
+	Source   string `json:"source"`
+	action   types.Dict
+}
+
+type scriptCollector struct {
+	ctx     *model.Context
+	scripts []Script
+	visited map[int]bool // indirect action dicts already processed
+
+	remove  func(s Script) bool  // optional filter for scripts to be removed
+	removed map[int]types.Object // replacements of removed indirect action dicts
+	named   []string             // name tree keys to be removed
+	c       int                  // number of removed scripts
+}
+
+func (sc *scriptCollector) source(o types.Object) (string, error) {
+	o, err := sc.ctx.Dereference(o)
+	if err != nil || o == nil {
+		return "", err
+	}
+	if sd, ok := o.(types.StreamDict); ok {
+		if err := sd.Decode(); err != nil {
+			return "", err
+		}
+		return string(sd.Content), nil
+	}
+	js, err := types.StringOrHexLiteral(o)
+	if err != nil || js == nil {
+		return "", err
+	}
+	return *js, nil
+}
+
+// slotAction returns the action to be used in place of a removed action whose successors are o.
+// An array of successors collapses into its first action taking over the remaining ones via "Next".
+func (sc *scriptCollector) slotAction(o types.Object) (types.Object, error) {
+	o1, err := sc.ctx.Dereference(o)
+	if err != nil || o1 == nil {
+		return nil, err
+	}
+
+	a, ok := o1.(types.Array)
+	if !ok {
+		return o, nil
+	}
+
+	if len(a) == 0 {
+		return nil, nil
+	}
+
+	if len(a) == 1 {
+		return a[0], nil
+	}
+
+	d, err := sc.ctx.DereferenceDict(a[0])
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errors.New("pdfcpu: corrupt action")
+	}
+
+	next := types.Array{}
+	if o, found := d.Find("Next"); found {
+		o1, err := sc.ctx.Dereference(o)
+		if err != nil {
+			return nil, err
+		}
+		if a1, ok := o1.(types.Array); ok {
+			next = append(next, a1...)
+		} else {
+			next = append(next, o)
+		}
+	}
+	d["Next"] = append(next, a[1:]...)
+
+	return a[0], nil
+}
+
+// collectNext processes the successors o of an action and returns their replacement.
+func (sc *scriptCollector) collectNext(o types.Object, s Script, depth int) (types.Object, error) {
+	o1, err := sc.ctx.Dereference(o)
+	if err != nil {
+		return nil, err
+	}
+
+	a, ok := o1.(types.Array)
+	if !ok {
+		return sc.collectAction(o, s, depth+1)
+	}
+
+	c := sc.c
+	a1 := types.Array{}
+
+	for _, o := range a {
+		r, err := sc.collectAction(o, s, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			continue
+		}
+		r1, err := sc.ctx.Dereference(r)
+		if err != nil {
+			return nil, err
+		}
+		if a2, ok := r1.(types.Array); ok {
+			a1 = append(a1, a2...)
+			continue
+		}
+		a1 = append(a1, r)
+	}
+
+	if sc.c == c {
+		return o, nil
+	}
+
+	if len(a1) == 0 {
+		return nil, nil
+	}
+
+	return a1, nil
+}
+
+// collectAction records o if it is a JavaScript action and follows its "Next" chain.
+// Returns the replacement for o which is o itself unless o gets removed.
+func (sc *scriptCollector) collectAction(o types.Object, s Script, depth int) (types.Object, error) {
+	if depth > maxActionChain {
+		return nil, errors.New("pdfcpu: action chain too long")
+	}
+
+	if ir, ok := o.(types.IndirectRef); ok {
+		objNr := ir.ObjectNumber.Value()
+		if sc.visited[objNr] {
+			if r, ok := sc.removed[objNr]; ok {
+				return r, nil
+			}
+			return o, nil
+		}
+		sc.visited[objNr] = true
+		s.ObjNr = objNr
+	} else {
+		s.ObjNr = 0
+	}
+
+	o1, err := sc.ctx.Dereference(o)
+	if err != nil || o1 == nil {
+		return o, err
+	}
+
+	d, ok := o1.(types.Dict)
+	if !ok {
+		// A destination.
+		return o, nil
+	}
+
+	js := false
+	if t := d.NameEntry("S"); t != nil && *t == "JavaScript" {
+		if s.Source, err = sc.source(d["JS"]); err != nil {
+			return nil, err
+		}
+		s.action = d
+		sc.scripts = append(sc.scripts, s)
+		js = true
+	}
+
+	if o, found := d.Find("Next"); found {
+		c := sc.c
+		next, err := sc.collectNext(o, s, depth)
+		if err != nil {
+			return nil, err
+		}
+		if sc.c > c {
+			if next == nil {
+				d.Delete("Next")
+			} else {
+				d["Next"] = next
+			}
+		}
+	}
+
+	if !js || sc.remove == nil || !sc.remove(s) {
+		return o, nil
+	}
+
+	sc.c++
+	r := d["Next"]
+	if s.ObjNr > 0 {
+		sc.removed[s.ObjNr] = r
+	}
+
+	return r, nil
+}
+
+// collectSlot processes the action stored in d under key.
+func (sc *scriptCollector) collectSlot(d types.Dict, key string, s Script) error {
+	c := sc.c
+
+	r, err := sc.collectAction(d[key], s, 0)
+	if err != nil || sc.c == c {
+		return err
+	}
+
+	if r, err = sc.slotAction(r); err != nil {
+		return err
+	}
+
+	if r == nil {
+		d.Delete(key)
+	} else {
+		d[key] = r
+	}
+
+	return nil
+}
+
+// collectAdditionalActions records the JavaScript actions of the additional-actions dict of d.
+func (sc *scriptCollector) collectAdditionalActions(d types.Dict, s Script) error {
+	aa, err := sc.ctx.DereferenceDict(d["AA"])
+	if err != nil || aa == nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(aa))
+	for k := range aa {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s.Trigger = k
+		if err := sc.collectSlot(aa, k, s); err != nil {
+			return err
+		}
+	}
+
+	if len(aa) == 0 {
+		d.Delete("AA")
+	}
+
+	return nil
+}
+
+// collectActions records the JavaScript actions of the action "A" and the additional actions of d.
+func (sc *scriptCollector) collectActions(d types.Dict, s Script) error {
+	if _, found := d.Find("A"); found {
+		s.Trigger = "A"
+		if err := sc.collectSlot(d, "A", s); err != nil {
+			return err
+		}
+	}
+
+	return sc.collectAdditionalActions(d, s)
+}
+
+func (sc *scriptCollector) collectNameTree(o types.Object, depth int) error {
+	if depth > maxActionChain {
+		return errors.New("pdfcpu: JavaScript name tree too deep")
+	}
+
+	d, err := sc.ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return err
+	}
+
+	kids, err := sc.ctx.DereferenceArray(d["Kids"])
+	if err != nil {
+		return err
+	}
+	for _, kid := range kids {
+		if err := sc.collectNameTree(kid, depth+1); err != nil {
+			return err
+		}
+	}
+
+	names, err := sc.ctx.DereferenceArray(d["Names"])
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(names); i += 2 {
+		k, err := sc.ctx.DereferenceStringOrHexLiteral(names[i], model.V10, nil)
+		if err != nil {
+			return err
+		}
+		c := sc.c
+		r, err := sc.collectAction(names[i+1], Script{Location: ScriptNamed, Name: k}, 0)
+		if err != nil {
+			return err
+		}
+		if sc.c == c {
+			continue
+		}
+		if r, err = sc.slotAction(r); err != nil {
+			return err
+		}
+		if r == nil {
+			// Removing keys goes through the cached name tree keeping its limits intact.
+			sc.named = append(sc.named, k)
+			continue
+		}
+		names[i+1] = r
+	}
+
+	return nil
+}
+
+func (sc *scriptCollector) collectDocument() error {
+	rootDict, err := sc.ctx.Catalog()
+	if err != nil {
+		return err
+	}
+
+	if _, found := rootDict.Find("OpenAction"); found {
+		if err := sc.collectSlot(rootDict, "OpenAction", Script{Location: ScriptDocument, Trigger: "OpenAction"}); err != nil {
+			return err
+		}
+	}
+
+	if err := sc.collectAdditionalActions(rootDict, Script{Location: ScriptDocument}); err != nil {
+		return err
+	}
+
+	namesDict, err := sc.ctx.DereferenceDict(rootDict["Names"])
+	if err != nil || namesDict == nil {
+		return err
+	}
+
+	return sc.collectNameTree(namesDict["JavaScript"], 0)
+}
+
+func (sc *scriptCollector) collectPages() error {
+	for i := 1; i <= sc.ctx.PageCount; i++ {
+		d, _, _, err := sc.ctx.PageDict(i, false)
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			continue
+		}
+
+		if err := sc.collectAdditionalActions(d, Script{Location: ScriptPage, PageNr: i}); err != nil {
+			return errors.Wrapf(err, "pdfcpu: page %d", i)
+		}
+
+		annots, err := sc.ctx.DereferenceArray(d["Annots"])
+		if err != nil {
+			return err
+		}
+
+		for _, o := range annots {
+			d, err := sc.ctx.DereferenceDict(o)
+			if err != nil {
+				return err
+			}
+			if d == nil {
+				continue
+			}
+			if st := d.NameEntry("Subtype"); st != nil && *st == "Widget" {
+				// Widget actions are collected along with their fields.
+				continue
+			}
+			if err := sc.collectActions(d, Script{Location: ScriptAnnotation, PageNr: i}); err != nil {
+				return errors.Wrapf(err, "pdfcpu: page %d", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (sc *scriptCollector) collectField(o types.Object, parentName string, pageNrs map[int]int, depth int) error {
+	if depth > maxActionChain {
+		return errors.New("pdfcpu: form field tree too deep")
+	}
+
+	d, err := sc.ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return err
+	}
+
+	name := parentName
+	if o, found := d.Find("T"); found {
+		t, err := sc.ctx.DereferenceStringOrHexLiteral(o, model.V10, nil)
+		if err != nil {
+			return err
+		}
+		if name != "" {
+			name += "."
+		}
+		name += t
+	}
+
+	s := Script{Location: ScriptField, Name: name}
+	if ir := d.IndirectRefEntry("P"); ir != nil {
+		s.PageNr = pageNrs[ir.ObjectNumber.Value()]
+	}
+
+	if err := sc.collectActions(d, s); err != nil {
+		return errors.Wrapf(err, "pdfcpu: field %s", name)
+	}
+
+	kids, err := sc.ctx.DereferenceArray(d["Kids"])
+	if err != nil {
+		return err
+	}
+
+	for _, kid := range kids {
+		if err := sc.collectField(kid, name, pageNrs, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sc *scriptCollector) collectFields() error {
+	rootDict, err := sc.ctx.Catalog()
+	if err != nil {
+		return err
+	}
+
+	d, err := sc.ctx.DereferenceDict(rootDict["AcroForm"])
+	if err != nil || d == nil {
+		return err
+	}
+
+	fields, err := sc.ctx.DereferenceArray(d["Fields"])
+	if err != nil || len(fields) == 0 {
+		return err
+	}
+
+	pageNrs, err := pageNrsByObjNr(sc.ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range fields {
+		if err := sc.collectField(o, "", pageNrs, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sc *scriptCollector) collect() error {
+	if err := sc.collectDocument(); err != nil {
+		return err
+	}
+
+	if err := sc.collectPages(); err != nil {
+		return err
+	}
+
+	return sc.collectFields()
+}
+
+// Scripts returns all document, page, annotation and form field level JavaScript actions of ctx.
+func Scripts(ctx *model.Context) ([]Script, error) {
+	sc := &scriptCollector{ctx: ctx, visited: map[int]bool{}}
+
+	if err := sc.collect(); err != nil {
+		return nil, err
+	}
+
+	return sc.scripts, nil
+}
+
+func (sc *scriptCollector) removeNamed() error {
+	if len(sc.named) == 0 {
+		return nil
+	}
+
+	xRefTable := sc.ctx.XRefTable
+
+	n := xRefTable.Names["JavaScript"]
+	if n == nil {
+		return errors.New("pdfcpu: JavaScript name tree unavailable")
+	}
+
+	for _, k := range sc.named {
+		empty, ok, err := n.Remove(xRefTable, k)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.Errorf("pdfcpu: JavaScript name tree: missing %s", k)
+		}
+		if empty {
+			// Delete name tree root object.
+			if err := xRefTable.RemoveNameTree("JavaScript"); err != nil {
+				return err
+			}
+			delete(xRefTable.Names, "JavaScript")
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// RemoveScripts removes all JavaScript actions of ctx for which f returns true or all JavaScript actions if f is nil.
+// Actions chained to a removed action via "Next" eg. submit form actions are preserved.
+// Returns the number of removed scripts.
+func RemoveScripts(ctx *model.Context, f func(s Script) bool) (int, error) {
+	if f == nil {
+		f = func(Script) bool { return true }
+	}
+
+	sc := &scriptCollector{
+		ctx:     ctx,
+		visited: map[int]bool{},
+		remove:  f,
+		removed: map[int]types.Object{},
+	}
+
+	if err := sc.collect(); err != nil {
+		return 0, err
+	}
+
+	if err := sc.removeNamed(); err != nil {
+		return 0, err
+	}
+
+	return sc.c, nil
+}
+
+// ReplaceScripts replaces the source of all scripts of ctx for which f returns true by the returned source.
+// Returns the number of replaced scripts.
+func ReplaceScripts(ctx *model.Context, f func(s Script) (string, bool)) (int, error) {
+	ss, err := Scripts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c := 0
+
+	for _, s := range ss {
+		js, ok := f(s)
+		if !ok {
+			continue
+		}
+		sl, err := types.Escape(js)
+		if err != nil {
+			return 0, err
+		}
+		s.action["JS"] = types.StringLiteral(*sl)
+		c++
+	}
+
+	return c, nil
+}
+
+func (s Script) String() string {
+	ss := []string{s.Location}
+	if s.PageNr > 0 {
+		ss = append(ss, "page "+strconv.Itoa(s.PageNr))
+	}
+	if s.Name != "" {
+		ss = append(ss, s.Name)
+	}
+	if s.Trigger != "" {
+		ss = append(ss, s.Trigger)
+	}
+	return strings.Join(ss, " ")
+}
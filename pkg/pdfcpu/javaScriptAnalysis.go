@@ -0,0 +1,256 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Risk represents the assessed risk of a script or document.
+type Risk int
+
+// Risk levels in ascending order.
+const (
+	RiskNone Risk = iota
+	RiskLow
+	RiskMedium
+	RiskHigh
+)
+
+var riskNames = []string{"none", "low", "medium", "high"}
+
+func (r Risk) String() string {
+	if r < RiskNone || r > RiskHigh {
+		return "unknown"
+	}
+	return riskNames[r]
+}
+
+// MarshalText renders r by name.
+func (r Risk) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// JavaScript analysis rules.
+const (
+	RuleSuspiciousAPI = "suspiciousAPI" // Call of an API known for abuse.
+	RuleEval          = "eval"          // Dynamic code evaluation.
+	RuleEvalChain     = "evalChain"     // Evaluation of decoded or generated code.
+	RuleDecoding      = "decoding"      // Runtime string decoding eg. unescape, String.fromCharCode.
+	RuleEscapes       = "escapes"       // High density of hex or unicode escapes.
+	RuleLongString    = "longString"    // Unusually long string without whitespace.
+	RuleEntropy       = "entropy"       // High character entropy.
+	RuleConcatenation = "concatenation" // Excessive concatenation of short string literals.
+)
+
+// ScriptFinding represents a suspicious pattern detected in a script.
+type ScriptFinding struct {
+	Rule   string `json:"rule"`
+	Risk   Risk   `json:"risk"`
+	Detail string `json:"detail"`
+}
+
+// ScriptAnalysis represents the static analysis result of a script.
+type ScriptAnalysis struct {
+	Script
+	Risk     Risk            `json:"risk"`
+	Entropy  float64         `json:"entropy"`
+	Findings []ScriptFinding `json:"findings,omitempty"`
+}
+
+// JavaScriptReport represents the static analysis result of all scripts of a PDF.
+type JavaScriptReport struct {
+	Risk    Risk             `json:"risk"`
+	Scripts []ScriptAnalysis `json:"scripts"`
+}
+
+var suspiciousAPIs = []struct {
+	name string
+	re   *regexp.Regexp
+	risk Risk
+}{
+	{"exportDataObject", regexp.MustCompile(`\bexportDataObject\s*\(`), RiskHigh},
+	{"Collab.collectEmailInfo", regexp.MustCompile(`\bcollectEmailInfo\s*\(`), RiskHigh},
+	{"Collab.getIcon", regexp.MustCompile(`\bgetIcon\s*\(`), RiskHigh},
+	{"util.printf", regexp.MustCompile(`\butil\s*\.\s*printf\s*\(`), RiskHigh},
+	{"media.newPlayer", regexp.MustCompile(`\bnewPlayer\s*\(`), RiskHigh},
+	{"spell.customDictionaryOpen", regexp.MustCompile(`\bcustomDictionaryOpen\s*\(`), RiskHigh},
+	{"getAnnots", regexp.MustCompile(`\bgetAnnots\s*\(`), RiskMedium},
+	{"importDataObject", regexp.MustCompile(`\bimportDataObject\s*\(`), RiskMedium},
+	{"launchURL", regexp.MustCompile(`\blaunchURL\s*\(`), RiskMedium},
+	{"getURL", regexp.MustCompile(`\bgetURL\s*\(`), RiskMedium},
+	{"submitForm", regexp.MustCompile(`\bsubmitForm\s*\(`), RiskMedium},
+	{"openDoc", regexp.MustCompile(`\bopenDoc\s*\(`), RiskMedium},
+	{"mailDoc", regexp.MustCompile(`\bmail(Doc|Form|Msg)\s*\(`), RiskMedium},
+	{"SOAP", regexp.MustCompile(`\bSOAP\s*\.`), RiskMedium},
+	{"Net.HTTP", regexp.MustCompile(`\bNet\s*\.\s*HTTP\b`), RiskMedium},
+	{"app.setTimeOut", regexp.MustCompile(`\bset(TimeOut|Interval)\s*\(`), RiskLow},
+}
+
+var (
+	reEval         = regexp.MustCompile(`\beval\s*\(|\bnew\s+Function\s*\(|\bFunction\s*\(`)
+	reEvalChain    = regexp.MustCompile(`\beval\s*\(\s*(unescape|String\s*\.\s*fromCharCode|atob|decodeURIComponent|eval)\b|\bset(TimeOut|Interval)\s*\(\s*["']`)
+	reDecoding     = regexp.MustCompile(`\bunescape\s*\(|\bString\s*\.\s*fromCharCode\s*\(|\batob\s*\(|\bdecodeURIComponent\s*\(`)
+	reUnicodeEsc   = regexp.MustCompile(`%u[0-9a-fA-F]{4}|\\u[0-9a-fA-F]{4}`)
+	reHexEsc       = regexp.MustCompile(`\\x[0-9a-fA-F]{2}|%[0-9a-fA-F]{2}`)
+	reLongString   = regexp.MustCompile(`[^\s]{1000,}`)
+	reShortLiteral = regexp.MustCompile(`["'][^"'\n]{0,3}["']\s*\+`)
+)
+
+// Analysis thresholds.
+const (
+	minEscapes         = 20  // Minimum number of escapes considered suspicious.
+	minEntropyLength   = 256 // Minimum script length for entropy based detection.
+	maxEntropy         = 5.2 // Bits per character considered obfuscated.
+	maxShortLiterals   = 20  // Number of concatenated short literals considered obfuscated.
+	highRiskEscapeRate = 0.2 // Share of script bytes covered by escapes indicating shellcode.
+)
+
+func entropy(s string) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var freq [256]int
+	for i := 0; i < len(s); i++ {
+		freq[s[i]]++
+	}
+	e, n := 0., float64(len(s))
+	for _, c := range freq {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+func (sa *ScriptAnalysis) add(rule string, risk Risk, format string, args ...interface{}) {
+	sa.Findings = append(sa.Findings, ScriptFinding{Rule: rule, Risk: risk, Detail: fmt.Sprintf(format, args...)})
+	if risk > sa.Risk {
+		sa.Risk = risk
+	}
+}
+
+func (sa *ScriptAnalysis) analyzeAPIs(js string) {
+	for _, api := range suspiciousAPIs {
+		if c := len(api.re.FindAllStringIndex(js, -1)); c > 0 {
+			sa.add(RuleSuspiciousAPI, api.risk, "%s called %d time(s)", api.name, c)
+		}
+	}
+}
+
+func (sa *ScriptAnalysis) analyzeEval(js string) {
+	if c := len(reEvalChain.FindAllStringIndex(js, -1)); c > 0 {
+		sa.add(RuleEvalChain, RiskHigh, "%d evaluation(s) of decoded or generated code", c)
+	} else if c := len(reEval.FindAllStringIndex(js, -1)); c > 0 {
+		risk := RiskMedium
+		if c > 1 {
+			risk = RiskHigh
+		}
+		sa.add(RuleEval, risk, "%d dynamic code evaluation(s)", c)
+	}
+}
+
+func (sa *ScriptAnalysis) analyzeObfuscation(js string) {
+	if c := len(reDecoding.FindAllStringIndex(js, -1)); c > 0 {
+		sa.add(RuleDecoding, RiskMedium, "%d runtime string decoding call(s)", c)
+	}
+
+	uu := reUnicodeEsc.FindAllStringIndex(js, -1)
+	xx := reHexEsc.FindAllStringIndex(js, -1)
+	if c := len(uu) + len(xx); c >= minEscapes {
+		risk := RiskMedium
+		if float64(len(uu)*6+len(xx)*3)/float64(len(js)) >= highRiskEscapeRate {
+			// Typical for embedded shellcode.
+			risk = RiskHigh
+		}
+		sa.add(RuleEscapes, risk, "%d unicode and %d hex escapes", len(uu), len(xx))
+	}
+
+	if ss := reLongString.FindAllString(js, -1); len(ss) > 0 {
+		max := 0
+		for _, s := range ss {
+			if len(s) > max {
+				max = len(s)
+			}
+		}
+		sa.add(RuleLongString, RiskMedium, "%d string(s) without whitespace, longest: %d bytes", len(ss), max)
+	}
+
+	if len(js) >= minEntropyLength && sa.Entropy >= maxEntropy {
+		sa.add(RuleEntropy, RiskLow, "entropy %.2f bits/char", sa.Entropy)
+	}
+
+	if c := len(reShortLiteral.FindAllStringIndex(js, -1)); c >= maxShortLiterals {
+		sa.add(RuleConcatenation, RiskLow, "%d concatenated short string literals", c)
+	}
+}
+
+// AnalyzeScript runs a lightweight static analysis on s.
+func AnalyzeScript(s Script) ScriptAnalysis {
+	js := s.Source
+	sa := ScriptAnalysis{Script: s, Entropy: math.Round(entropy(js)*100) / 100}
+
+	sa.analyzeAPIs(js)
+	sa.analyzeEval(js)
+	sa.analyzeObfuscation(js)
+
+	return sa
+}
+
+// AnalyzeJavaScript extracts all scripts of ctx and returns a risk report for security triage.
+func AnalyzeJavaScript(ctx *model.Context) (*JavaScriptReport, error) {
+	ss, err := Scripts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &JavaScriptReport{Scripts: []ScriptAnalysis{}}
+
+	for _, s := range ss {
+		sa := AnalyzeScript(s)
+		if sa.Risk > r.Risk {
+			r.Risk = sa.Risk
+		}
+		r.Scripts = append(r.Scripts, sa)
+	}
+
+	if len(ss) > 0 && r.Risk == RiskNone {
+		// Any JavaScript deserves a look.
+		r.Risk = RiskLow
+	}
+
+	return r, nil
+}
+
+func (r JavaScriptReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d scripts, risk: %s\n", len(r.Scripts), r.Risk)
+	for _, sa := range r.Scripts {
+		fmt.Fprintf(&sb, "%s: risk: %s (%d bytes, entropy %.2f)\n", sa.Script, sa.Risk, len(sa.Source), sa.Entropy)
+		for _, f := range sa.Findings {
+			fmt.Fprintf(&sb, "  %s %s: %s\n", f.Risk, f.Rule, f.Detail)
+		}
+	}
+	return sb.String()
+}
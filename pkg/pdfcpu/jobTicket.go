@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// MIME types for CIP4 job tickets.
+const (
+	JDFMimeType  = "application/vnd.cip4-jdf+xml"
+	XJDFMimeType = "application/vnd.cip4-xjdf+xml"
+)
+
+// DefaultJobTicketFileName is used for job tickets embedded without a file name.
+const DefaultJobTicketFileName = "JobTicket.jdf"
+
+func jobTicketMimeType(fileName string) (string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".jdf":
+		return JDFMimeType, nil
+	case ".xjdf":
+		return XJDFMimeType, nil
+	}
+	return "", errors.Errorf("pdfcpu: job ticket file name needs extension .jdf or .xjdf: %s", fileName)
+}
+
+// AddJobTicket embeds the JDF or XJDF job ticket read from r as associated file of the document
+// with relationship "Supplement" and returns the name of the attachment.
+// The job ticket also shows up as attachment named fileName (default: JobTicket.jdf),
+// the extension .jdf or .xjdf determines the MIME type.
+func AddJobTicket(ctx *model.Context, r io.Reader, fileName string, modTime *time.Time) (string, error) {
+	if fileName == "" {
+		fileName = DefaultJobTicketFileName
+	}
+	fileName = filepath.Base(fileName)
+
+	mimeType, err := jobTicketMimeType(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	xRefTable := ctx.XRefTable
+
+	a := model.Attachment{Reader: r, ID: fileName, FileName: fileName, Desc: "Job ticket", ModTime: modTime}
+
+	d, err := xRefTable.NewFileSpecDictForAttachment(a)
+	if err != nil {
+		return "", err
+	}
+
+	d.InsertName("AFRelationship", "Supplement")
+
+	sd, _, err := xRefTable.DereferenceStreamDict(d.DictEntry("EF")["F"])
+	if err != nil || sd == nil {
+		return "", errors.New("pdfcpu: AddJobTicket: missing embedded file stream")
+	}
+	// Names need the solidus escaped.
+	sd.InsertName("Subtype", strings.ReplaceAll(mimeType, "/", "#2F"))
+
+	ir, err := xRefTable.IndRefForNewObject(d)
+	if err != nil {
+		return "", err
+	}
+
+	if err := xRefTable.LocateNameTree("EmbeddedFiles", true); err != nil {
+		return "", err
+	}
+
+	m := model.NameMap{fileName: []types.Dict{d}}
+
+	if err := xRefTable.Names["EmbeddedFiles"].Add(xRefTable, fileName, *ir, m, []string{"F", "UF"}); err != nil {
+		return "", err
+	}
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return "", err
+	}
+
+	af, err := xRefTable.DereferenceArray(rootDict["AF"])
+	if err != nil {
+		return "", err
+	}
+
+	rootDict["AF"] = append(af, *ir)
+
+	// Associated files need PDF 2.0 or PDF/A-3.
+	xRefTable.EnsureVersionForWriting()
+
+	return fileName, nil
+}
@@ -0,0 +1,513 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// layerRemover deletes content belonging to optional content groups (layers) hidden by default.
+type layerRemover struct {
+	ctx      *model.Context
+	hidden   types.IntSet // Object numbers of hidden OCGs.
+	resDicts []types.Dict // Resource dicts to be cleaned up.
+}
+
+// hiddenOCGs returns the object numbers of all OCGs whose default state is OFF.
+func hiddenOCGs(ctx *model.Context, ocProps types.Dict) (types.IntSet, error) {
+	hidden := types.IntSet{}
+
+	ocgs, err := ctx.DereferenceArray(ocProps["OCGs"])
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := ctx.DereferenceDict(ocProps["D"])
+	if err != nil || d == nil {
+		return hidden, err
+	}
+
+	objNrs := func(key string) (types.IntSet, error) {
+		m := types.IntSet{}
+		a, err := ctx.DereferenceArray(d[key])
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range a {
+			if ir, ok := o.(types.IndirectRef); ok {
+				m[ir.ObjectNumber.Value()] = true
+			}
+		}
+		return m, nil
+	}
+
+	if bs := d.NameEntry("BaseState"); bs != nil && *bs == "OFF" {
+		on, err := objNrs("ON")
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range ocgs {
+			if ir, ok := o.(types.IndirectRef); ok && !on[ir.ObjectNumber.Value()] {
+				hidden[ir.ObjectNumber.Value()] = true
+			}
+		}
+		return hidden, nil
+	}
+
+	return objNrs("OFF")
+}
+
+// hiddenOC returns true if the optional content group or membership dict o is hidden.
+func (lr *layerRemover) hiddenOC(o types.Object) bool {
+	if o == nil {
+		return false
+	}
+
+	if ir, ok := o.(types.IndirectRef); ok && lr.hidden[ir.ObjectNumber.Value()] {
+		return true
+	}
+
+	d, err := lr.ctx.DereferenceDict(o)
+	if err != nil || d == nil || d.Type() == nil || *d.Type() != "OCMD" {
+		return false
+	}
+
+	// Optional content membership dict.
+	// Visibility expressions (VE) are not supported, the visibility policy (P) applies.
+
+	var ocgs types.Array
+	o1, err := lr.ctx.Dereference(d["OCGs"])
+	if err != nil {
+		return false
+	}
+	switch o1 := o1.(type) {
+	case types.Array:
+		ocgs = o1
+	case types.Dict:
+		ocgs = types.Array{d["OCGs"]}
+	}
+
+	var on, off int
+	for _, o := range ocgs {
+		if ir, ok := o.(types.IndirectRef); ok && lr.hidden[ir.ObjectNumber.Value()] {
+			off++
+			continue
+		}
+		on++
+	}
+
+	if on+off == 0 {
+		return false
+	}
+
+	p := "AnyOn"
+	if s := d.NameEntry("P"); s != nil {
+		p = *s
+	}
+
+	var visible bool
+	switch p {
+	case "AllOn":
+		visible = off == 0
+	case "AnyOff":
+		visible = off > 0
+	case "AllOff":
+		visible = on == 0
+	default:
+		visible = on > 0
+	}
+
+	return !visible
+}
+
+func (lr *layerRemover) hiddenXObject(xObjDict types.Dict, id string) bool {
+	sd, _, err := lr.ctx.DereferenceStreamDict(xObjDict[id])
+	if err != nil || sd == nil {
+		return false
+	}
+	return lr.hiddenOC(sd.Dict["OC"])
+}
+
+// filterContent removes hidden marked content sequences and hidden XObject invocations from ops.
+func (lr *layerRemover) filterContent(ops []ContentOperation, resDict types.Dict) []ContentOperation {
+	var propDict, xObjDict types.Dict
+	if resDict != nil {
+		propDict, _ = lr.ctx.DereferenceDict(resDict["Properties"])
+		xObjDict, _ = lr.ctx.DereferenceDict(resDict["XObject"])
+	}
+
+	var out []ContentOperation
+	skip := 0
+
+	for _, op := range ops {
+
+		if skip > 0 {
+			switch op.Operator {
+			case "BMC", "BDC":
+				skip++
+			case "EMC":
+				skip--
+			}
+			continue
+		}
+
+		switch op.Operator {
+
+		case "BDC":
+			if len(op.Operands) == 2 && propDict != nil {
+				tag, ok1 := op.Operands[0].(types.Name)
+				id, ok2 := op.Operands[1].(types.Name)
+				if ok1 && ok2 && tag == "OC" && lr.hiddenOC(propDict[id.Value()]) {
+					skip = 1
+					continue
+				}
+			}
+
+		case "Do":
+			if len(op.Operands) == 1 && xObjDict != nil {
+				if id, ok := op.Operands[0].(types.Name); ok && lr.hiddenXObject(xObjDict, id.Value()) {
+					continue
+				}
+			}
+		}
+
+		out = append(out, op)
+	}
+
+	return out
+}
+
+// filterStream rewrites sd and returns true if any hidden content was removed.
+func (lr *layerRemover) filterStream(sd *types.StreamDict, resDict types.Dict) (bool, error) {
+	if err := sd.Decode(); err != nil {
+		return false, err
+	}
+
+	ops, err := ParseContentStream(sd.Content)
+	if err != nil {
+		return false, err
+	}
+
+	ops1 := lr.filterContent(ops, resDict)
+	if len(ops1) == len(ops) {
+		return false, nil
+	}
+
+	return true, setContentStream(sd, ContentStreamBytes(ops1))
+}
+
+func (lr *layerRemover) processPageAnnots(d types.Dict) error {
+	annots, err := lr.ctx.DereferenceArray(d["Annots"])
+	if err != nil || len(annots) == 0 {
+		return err
+	}
+
+	var a types.Array
+	for _, o := range annots {
+		d1, err := lr.ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		// Widgets are left alone because they are also referenced by the form.
+		if d1 != nil && (d1.Subtype() == nil || *d1.Subtype() != "Widget") && lr.hiddenOC(d1["OC"]) {
+			continue
+		}
+		a = append(a, o)
+	}
+
+	if len(a) == len(annots) {
+		return nil
+	}
+
+	if len(a) == 0 {
+		d.Delete("Annots")
+		return nil
+	}
+
+	d["Annots"] = a
+
+	return nil
+}
+
+func (lr *layerRemover) processPages() error {
+	ctx := lr.ctx
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		d, _, _, err := ctx.PageDict(i, false)
+		if err != nil {
+			return err
+		}
+
+		if err := lr.processPageAnnots(d); err != nil {
+			return err
+		}
+
+		resDict, err := pageResourceDict(ctx, d)
+		if err != nil {
+			return err
+		}
+		if resDict != nil {
+			lr.resDicts = append(lr.resDicts, resDict)
+		}
+
+		// Marked content sequences may span multiple content streams.
+		bb, err := ctx.PageContent(d)
+		if err == model.ErrNoContent {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		ops, err := ParseContentStream(bb)
+		if err != nil {
+			return err
+		}
+
+		ops1 := lr.filterContent(ops, resDict)
+		if len(ops1) == len(ops) {
+			continue
+		}
+
+		sd, err := ctx.NewStreamDictForBuf(nil)
+		if err != nil {
+			return err
+		}
+		if err := setContentStream(sd, ContentStreamBytes(ops1)); err != nil {
+			return err
+		}
+		ir, err := ctx.IndRefForNewObject(*sd)
+		if err != nil {
+			return err
+		}
+		d["Contents"] = *ir
+
+		if log.DebugEnabled() {
+			log.Debug.Printf("RemoveHiddenLayers: removed hidden content from page %d\n", i)
+		}
+	}
+
+	return nil
+}
+
+// processObjects handles form XObjects and tiling patterns.
+func (lr *layerRemover) processObjects() error {
+	ctx := lr.ctx
+
+	for objNr, entry := range ctx.Table {
+		if entry.Free || entry.Compressed && entry.Object == nil {
+			continue
+		}
+		o, err := ctx.FindObject(objNr)
+		if err != nil {
+			return err
+		}
+		sd, ok := o.(types.StreamDict)
+		if !ok {
+			continue
+		}
+		form := sd.Subtype() != nil && *sd.Subtype() == "Form"
+		tiling := sd.IntEntry("PatternType") != nil && *sd.IntEntry("PatternType") == 1
+		if !form && !tiling {
+			continue
+		}
+		resDict, err := ctx.DereferenceDict(sd.Dict["Resources"])
+		if err != nil {
+			return err
+		}
+		if resDict != nil {
+			lr.resDicts = append(lr.resDicts, resDict)
+		}
+		modified, err := lr.filterStream(&sd, resDict)
+		if err != nil {
+			return err
+		}
+		if modified {
+			entry.Object = sd
+		}
+	}
+
+	return nil
+}
+
+// removeResources deletes resources referring to hidden optional content.
+func (lr *layerRemover) removeResources() error {
+	for _, resDict := range lr.resDicts {
+		propDict, err := lr.ctx.DereferenceDict(resDict["Properties"])
+		if err != nil {
+			return err
+		}
+		for k, v := range propDict {
+			if lr.hiddenOC(v) {
+				propDict.Delete(k)
+			}
+		}
+		xObjDict, err := lr.ctx.DereferenceDict(resDict["XObject"])
+		if err != nil {
+			return err
+		}
+		for k := range xObjDict {
+			if lr.hiddenXObject(xObjDict, k) {
+				xObjDict.Delete(k)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (lr *layerRemover) filterOCGs(a types.Array) types.Array {
+	var a1 types.Array
+	for _, o := range a {
+		switch o1 := o.(type) {
+		case types.IndirectRef:
+			if lr.hidden[o1.ObjectNumber.Value()] {
+				continue
+			}
+		case types.Array:
+			// Nested arrays eg. of "Order" or "RBGroups".
+			if o1 = lr.filterOCGs(o1); len(o1) == 0 {
+				continue
+			}
+			o = o1
+		}
+		a1 = append(a1, o)
+	}
+	return a1
+}
+
+func (lr *layerRemover) removeFromConfig(d types.Dict) error {
+	for _, k := range []string{"ON", "OFF", "Order", "RBGroups", "Locked"} {
+		a, err := lr.ctx.DereferenceArray(d[k])
+		if err != nil {
+			return err
+		}
+		if a == nil {
+			continue
+		}
+		if a = lr.filterOCGs(a); len(a) == 0 {
+			d.Delete(k)
+			continue
+		}
+		d[k] = a
+	}
+
+	as, err := lr.ctx.DereferenceArray(d["AS"])
+	if err != nil {
+		return err
+	}
+	for _, o := range as {
+		d1, err := lr.ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if a, err := lr.ctx.DereferenceArray(d1["OCGs"]); err == nil && a != nil {
+			d1["OCGs"] = lr.filterOCGs(a)
+		}
+	}
+
+	return nil
+}
+
+// removeOCGs removes hidden OCGs from the optional content properties.
+func (lr *layerRemover) removeOCGs(rootDict, ocProps types.Dict) error {
+	ocgs, err := lr.ctx.DereferenceArray(ocProps["OCGs"])
+	if err != nil {
+		return err
+	}
+
+	if ocgs = lr.filterOCGs(ocgs); len(ocgs) == 0 {
+		rootDict.Delete("OCProperties")
+		return nil
+	}
+	ocProps["OCGs"] = ocgs
+
+	d, err := lr.ctx.DereferenceDict(ocProps["D"])
+	if err != nil {
+		return err
+	}
+	if d != nil {
+		if err := lr.removeFromConfig(d); err != nil {
+			return err
+		}
+	}
+
+	configs, err := lr.ctx.DereferenceArray(ocProps["Configs"])
+	if err != nil {
+		return err
+	}
+	for _, o := range configs {
+		d, err := lr.ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			if err := lr.removeFromConfig(d); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoveHiddenLayers permanently removes all content belonging to optional content groups
+// whose default state is OFF, including hidden XObjects and annotations.
+// The hidden OCGs are removed from the document so they can't be turned on again.
+// Returns the number of removed OCGs.
+func RemoveHiddenLayers(ctx *model.Context) (int, error) {
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return 0, err
+	}
+
+	ocProps, err := ctx.DereferenceDict(rootDict["OCProperties"])
+	if err != nil || ocProps == nil {
+		return 0, err
+	}
+
+	hidden, err := hiddenOCGs(ctx, ocProps)
+	if err != nil || len(hidden) == 0 {
+		return 0, err
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return 0, err
+	}
+
+	lr := &layerRemover{ctx: ctx, hidden: hidden}
+
+	if err := lr.processPages(); err != nil {
+		return 0, err
+	}
+
+	if err := lr.processObjects(); err != nil {
+		return 0, err
+	}
+
+	if err := lr.removeResources(); err != nil {
+		return 0, err
+	}
+
+	if err := lr.removeOCGs(rootDict, ocProps); err != nil {
+		return 0, err
+	}
+
+	return len(hidden), nil
+}
@@ -17,11 +17,17 @@ limitations under the License.
 package pdfcpu
 
 import (
+	"bytes"
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/color"
+	pdffont "github.com/mjuen/pdfcpu/pkg/pdfcpu/font"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
 )
 
 func EnsureOutlines(ctx *model.Context, fName string, append bool) error {
@@ -348,8 +354,293 @@ func rootDicts(ctxSource, ctxDest *model.Context) (types.Dict, types.Dict, error
 	return rootDictSource, rootDictDest, nil
 }
 
+// fieldOnlyKeys are the entries of a merged field/widget dict belonging to the field.
+var fieldOnlyKeys = []string{"FT", "T", "TU", "TM", "Ff", "V", "DV", "Opt", "TI", "I", "MaxLen", "Lock", "SV"}
+
+func partialFieldName(d types.Dict) (string, error) {
+	s, err := d.StringOrHexLiteralEntry("T")
+	if err != nil || s == nil {
+		return "", err
+	}
+	return *s, nil
+}
+
+// fieldKids returns the kids of a non terminal field or nil for terminal fields.
+func fieldKids(ctx *model.Context, d types.Dict) (types.Array, error) {
+	o, found := d.Find("Kids")
+	if !found {
+		return nil, nil
+	}
+	kids, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range kids {
+		d1, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := d1.Find("T"); ok {
+			return kids, nil
+		}
+	}
+	// Widget annotations only.
+	return nil, nil
+}
+
+// fieldWidgets returns the widget annotations of the terminal field ir.
+func fieldWidgets(ctx *model.Context, ir types.IndirectRef, d types.Dict) (types.Array, error) {
+	o, found := d.Find("Kids")
+	if !found {
+		// Merged field/widget dict.
+		return types.Array{ir}, nil
+	}
+	return ctx.DereferenceArray(o)
+}
+
+// splitField separates the merged field/widget dict ir into a new parent field and its widget ir.
+// Returns the new parent field.
+func splitField(ctx *model.Context, ir types.IndirectRef, d types.Dict) (*types.IndirectRef, types.Dict, error) {
+	dp := types.Dict{}
+	for _, k := range fieldOnlyKeys {
+		if v, ok := d[k]; ok {
+			dp[k] = v
+			delete(d, k)
+		}
+	}
+	if v, ok := d["Parent"]; ok {
+		dp["Parent"] = v
+	}
+
+	// Inheritable variable text attributes.
+	for _, k := range []string{"DA", "Q"} {
+		if v, ok := d[k]; ok {
+			dp[k] = v
+		}
+	}
+
+	dp["Kids"] = types.Array{ir}
+
+	irp, err := ctx.IndRefForNewObject(dp)
+	if err != nil {
+		return nil, nil, err
+	}
+	d["Parent"] = *irp
+
+	return irp, dp, nil
+}
+
+// syncButtonState aligns the appearance state of a check box or radio button widget with the field value.
+func syncButtonState(ctx *model.Context, dField, dWidget types.Dict) error {
+	v := dField.NameEntry("V")
+	if v == nil {
+		return nil
+	}
+	o, found := dWidget.Find("AP")
+	if !found {
+		return nil
+	}
+	ap, err := ctx.DereferenceDict(o)
+	if err != nil || ap == nil {
+		return err
+	}
+	n, err := ctx.DereferenceDict(ap["N"])
+	if err != nil || n == nil {
+		return err
+	}
+	if _, ok := n.Find(*v); ok {
+		dWidget["AS"] = types.Name(*v)
+	} else {
+		dWidget["AS"] = types.Name("Off")
+	}
+	return nil
+}
+
+func mergeTerminalFields(ctx *model.Context, name string, irDest, irSrc types.IndirectRef, dDest, dSrc types.Dict, container types.Array, i int) error {
+	ftDest, ftSrc := dDest.NameEntry("FT"), dSrc.NameEntry("FT")
+	if ftDest == nil || ftSrc == nil || *ftDest != *ftSrc {
+		return errors.Errorf("pdfcpu: merge: form field %s: field types do not match", name)
+	}
+
+	widgets, err := fieldWidgets(ctx, irSrc, dSrc)
+	if err != nil {
+		return err
+	}
+
+	if _, found := dDest.Find("Kids"); !found {
+		// Widgets may only be attached to a field with kids.
+		irp, dp, err := splitField(ctx, irDest, dDest)
+		if err != nil {
+			return err
+		}
+		container[i] = *irp
+		irDest, dDest = *irp, dp
+	}
+
+	kids, err := ctx.DereferenceArray(dDest["Kids"])
+	if err != nil {
+		return err
+	}
+
+	for _, o := range widgets {
+		ir, ok := o.(types.IndirectRef)
+		if !ok {
+			continue
+		}
+		d, err := ctx.DereferenceDict(ir)
+		if err != nil {
+			return err
+		}
+		for _, k := range fieldOnlyKeys {
+			delete(d, k)
+		}
+		d["Parent"] = irDest
+		if *ftDest == "Btn" {
+			if err := syncButtonState(ctx, dDest, d); err != nil {
+				return err
+			}
+		}
+		kids = append(kids, ir)
+	}
+
+	dDest["Kids"] = kids
+
+	return nil
+}
+
+// mergeFields merges the field irSrc into the field container[i] sharing the same fully qualified name.
+func mergeFields(ctx *model.Context, name string, irSrc types.IndirectRef, container types.Array, i int) error {
+	irDest, ok := container[i].(types.IndirectRef)
+	if !ok {
+		return errors.Errorf("pdfcpu: merge: form field %s: corrupt field", name)
+	}
+
+	dDest, err := ctx.DereferenceDict(irDest)
+	if err != nil {
+		return err
+	}
+
+	dSrc, err := ctx.DereferenceDict(irSrc)
+	if err != nil {
+		return err
+	}
+
+	kidsDest, err := fieldKids(ctx, dDest)
+	if err != nil {
+		return err
+	}
+
+	kidsSrc, err := fieldKids(ctx, dSrc)
+	if err != nil {
+		return err
+	}
+
+	if kidsDest == nil && kidsSrc == nil {
+		return mergeTerminalFields(ctx, name, irDest, irSrc, dDest, dSrc, container, i)
+	}
+
+	if kidsDest == nil || kidsSrc == nil {
+		return errors.Errorf("pdfcpu: merge: form field %s: can't merge terminal and non terminal field", name)
+	}
+
+	kids, err := mergeFieldArrays(ctx, name+".", kidsSrc, kidsDest, &irDest)
+	if err != nil {
+		return err
+	}
+	dDest["Kids"] = kids
+
+	return nil
+}
+
+// mergeFieldArrays merges the fields of arrSrc into arrDest according to the configured collision mode.
+func mergeFieldArrays(ctx *model.Context, prefix string, arrSrc, arrDest types.Array, parent *types.IndirectRef) (types.Array, error) {
+	names := map[string]int{}
+	for i, o := range arrDest {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return nil, err
+		}
+		t, err := partialFieldName(d)
+		if err != nil {
+			return nil, err
+		}
+		if t != "" {
+			names[t] = i
+		}
+	}
+
+	for _, o := range arrSrc {
+		ir, ok := o.(types.IndirectRef)
+		if !ok {
+			continue
+		}
+		d, err := ctx.DereferenceDict(ir)
+		if err != nil {
+			return nil, err
+		}
+		if len(d) == 0 {
+			continue
+		}
+
+		t, err := partialFieldName(d)
+		if err != nil {
+			return nil, err
+		}
+
+		i, collision := names[t]
+		if t == "" || !collision {
+			if parent != nil {
+				d["Parent"] = *parent
+			}
+			names[t] = len(arrDest)
+			arrDest = append(arrDest, ir)
+			continue
+		}
+
+		switch ctx.FormFieldCollision {
+
+		case model.FieldCollisionError:
+			return nil, errors.Errorf("pdfcpu: merge: duplicate form field: %s", prefix+t)
+
+		case model.FieldCollisionMerge:
+			if err := mergeFields(ctx, prefix+t, ir, arrDest, i); err != nil {
+				return nil, err
+			}
+
+		default:
+			// Rename by appending the first available suffix.
+			t1 := t
+			for j := 2; collision; j++ {
+				t1 = fmt.Sprintf("%s_%d", t, j)
+				_, collision = names[t1]
+			}
+			s, err := types.EscapeUTF16String(t1)
+			if err != nil {
+				return nil, err
+			}
+			d["T"] = types.StringLiteral(*s)
+			if parent != nil {
+				d["Parent"] = *parent
+			}
+			names[t1] = len(arrDest)
+			arrDest = append(arrDest, ir)
+		}
+	}
+
+	return arrDest, nil
+}
+
 func mergeInFields(ctxDest *model.Context, arrFieldsSrc, arrFieldsDest types.Array, dDest types.Dict) error {
 
+	if ctxDest.FormFieldCollision != model.FieldCollisionGroup {
+		fields, err := mergeFieldArrays(ctxDest, "", arrFieldsSrc, arrFieldsDest, nil)
+		if err != nil {
+			return err
+		}
+		dDest["Fields"] = fields
+		return nil
+	}
+
 	parentDict :=
 		types.Dict(map[string]types.Object{
 			"Kids": arrFieldsSrc,
@@ -833,21 +1124,121 @@ func mergeDuplicateObjNumberIntSets(ctxSource, ctxDest *model.Context) {
 	}
 }
 
+func writeDividerText(w *bytes.Buffer, mediaBox *types.Rectangle, fm model.FontMap, s string, fontSize int, dy float64) {
+	fontName := "Helvetica"
+	td := model.TextDescriptor{
+		FontName:  fontName,
+		FontKey:   fm.EnsureKey(fontName),
+		FontSize:  fontSize,
+		Scale:     1.0,
+		ScaleAbs:  true,
+		StrokeCol: color.Black,
+		FillCol:   color.Black,
+		X:         -1,
+		Y:         -1,
+		Dy:        dy,
+		HAlign:    types.AlignCenter,
+		VAlign:    types.AlignMiddle,
+		Text:      s,
+	}
+	model.WriteMultiLine(nil, w, mediaBox, nil, td)
+}
+
+// appendDividerPage appends a page announcing ctxSource to ctxDest's page tree.
+// The divider page shows fName and the configured divider page text and has the dimensions of ctxSource's first page.
+func appendDividerPage(fName string, ctxSource, ctxDest *model.Context) error {
+	mediaBox := types.RectForFormat("A4")
+	if dims, err := ctxSource.PageDims(); err == nil && len(dims) > 0 {
+		mediaBox = types.RectForDim(dims[0].Width, dims[0].Height)
+	}
+
+	var buf bytes.Buffer
+	fm := model.FontMap{}
+
+	writeDividerText(&buf, mediaBox, fm, fName, 24, 0)
+	if s := ctxDest.DividerPageText; s != "" {
+		writeDividerText(&buf, mediaBox, fm, s, 12, -36)
+	}
+
+	fontRes, err := pdffont.FontResources(ctxDest.XRefTable, fm)
+	if err != nil {
+		return err
+	}
+
+	sd, _ := ctxDest.NewStreamDictForBuf(buf.Bytes())
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+
+	contentsIndRef, err := ctxDest.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+
+	pagesIndRef, err := ctxDest.Pages()
+	if err != nil {
+		return err
+	}
+
+	pageDict := types.Dict(
+		map[string]types.Object{
+			"Type":      types.Name("Page"),
+			"Parent":    *pagesIndRef,
+			"MediaBox":  mediaBox.Array(),
+			"Resources": types.Dict(map[string]types.Object{"Font": fontRes}),
+			"Contents":  *contentsIndRef,
+		},
+	)
+
+	indRef, err := ctxDest.IndRefForNewObject(pageDict)
+	if err != nil {
+		return err
+	}
+
+	pagesDict, err := ctxDest.DereferenceDict(*pagesIndRef)
+	if err != nil {
+		return err
+	}
+
+	if err := model.AppendPageTree(indRef, 1, pagesDict); err != nil {
+		return err
+	}
+
+	ctxDest.PageCount++
+
+	return nil
+}
+
 // MergeXRefTables merges Context ctxSource into ctxDest by appending its page tree.
-func MergeXRefTables(fName string, ctxSource, ctxDest *model.Context) (err error) {
+// If DividerPages is configured, a divider page gets inserted in front of ctxSource's pages.
+func MergeXRefTables(fName string, ctxSource, ctxDest *model.Context) error {
+	_, err := MergeXRefTablesRenamingDests(fName, ctxSource, ctxDest)
+	return err
+}
 
-	// Sweep over ctxSource cross ref table and ensure valid object numbers in ctxDest's space.
-	patchSourceObjectNumbers(ctxSource, ctxDest)
+// MergeXRefTablesRenamingDests merges Context ctxSource into ctxDest like MergeXRefTables
+// and returns the named destinations of ctxSource renamed in order to avoid name clashes as a map of old to new names.
+func MergeXRefTablesRenamingDests(fName string, ctxSource, ctxDest *model.Context) (renames map[string]string, err error) {
 
 	pageCount := ctxDest.PageCount
 
+	if ctxDest.Configuration.DividerPages {
+		// The bookmark for fName points to the divider page.
+		if err := appendDividerPage(fName, ctxSource, ctxDest); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sweep over ctxSource cross ref table and ensure valid object numbers in ctxDest's space.
+	patchSourceObjectNumbers(ctxSource, ctxDest)
+
 	// Append ctxSource pageTree to ctxDest pageTree.
 	if log.DebugEnabled() {
 		log.Debug.Println("appendSourcePageTreeToDestPageTree")
 	}
 	err = appendSourcePageTreeToDestPageTree(ctxSource, ctxDest)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Append ctxSource objects to ctxDest
@@ -857,27 +1248,36 @@ func MergeXRefTables(fName string, ctxSource, ctxDest *model.Context) (err error
 	appendSourceObjectsToDest(ctxSource, ctxDest)
 
 	if err := mergeForms(ctxSource, ctxDest); err != nil {
-		return err
+		return nil, err
+	}
+
+	// Avoid name clashes of named destinations.
+	if renames, err = remapSourceDests(ctxSource, ctxDest); err != nil {
+		return nil, err
 	}
 
 	if err := mergeDests(ctxSource, ctxDest); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := mergeNames(ctxSource, ctxDest); err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := mergeStructTrees(ctxSource, ctxDest); err != nil {
+		return nil, err
 	}
 
 	if ctxDest.Configuration.CreateBookmarks {
 		if err := mergeOutlines(fName, pageCount+1, ctxSource, ctxDest); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	// Mark source's root object as free.
 	err = ctxDest.FreeObject(int(ctxSource.Root.ObjectNumber))
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	// Mark source's info object as free.
@@ -885,7 +1285,7 @@ func MergeXRefTables(fName string, ctxSource, ctxDest *model.Context) (err error
 	if ctxSource.Info != nil {
 		err = ctxDest.FreeObject(int(ctxSource.Info.ObjectNumber))
 		if err != nil {
-			return
+			return nil, err
 		}
 	}
 
@@ -901,5 +1301,251 @@ func MergeXRefTables(fName string, ctxSource, ctxDest *model.Context) (err error
 		log.Info.Printf("Dest XRefTable after merge:\n%s\n", ctxDest)
 	}
 
+	return renames, nil
+}
+
+// MergedFile represents the page span of a source file within a merged document.
+type MergedFile struct {
+	FirstPage   int // 1-based page number of the first page of this file within the merged document.
+	PageCount   int
+	DestRenames map[string]string // Named destinations of this file renamed during merging.
+}
+
+func goToRFileName(ctx *model.Context, o types.Object) (string, error) {
+	o, err := ctx.Dereference(o)
+	if err != nil || o == nil {
+		return "", err
+	}
+
+	var s string
+
+	switch o := o.(type) {
+	case types.StringLiteral, types.HexLiteral:
+		if s, err = model.Text(o); err != nil {
+			return "", err
+		}
+	case types.Dict:
+		// File specification dict
+		for _, k := range []string{"UF", "F"} {
+			if o1, found := o.Find(k); found {
+				if s, err = ctx.DereferenceText(o1); err != nil {
+					return "", err
+				}
+				break
+			}
+		}
+	}
+
+	if s == "" {
+		return "", nil
+	}
+
+	return path.Base(strings.ReplaceAll(s, "\\", "/")), nil
+}
+
+func resolveGoToRAction(ctx *model.Context, d types.Dict, files map[string]MergedFile) error {
+	if o, found := d.Find("Next"); found {
+		// Process action chain.
+		o, err := ctx.Dereference(o)
+		if err != nil {
+			return err
+		}
+		switch o := o.(type) {
+		case types.Dict:
+			if err := resolveGoToRAction(ctx, o, files); err != nil {
+				return err
+			}
+		case types.Array:
+			for _, o1 := range o {
+				d1, err := ctx.DereferenceDict(o1)
+				if err != nil {
+					return err
+				}
+				if d1 != nil {
+					if err := resolveGoToRAction(ctx, d1, files); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	if s := d.NameEntry("S"); s == nil || *s != "GoToR" {
+		return nil
+	}
+
+	o, found := d.Find("F")
+	if !found {
+		return nil
+	}
+
+	fName, err := goToRFileName(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	mf, ok := files[fName]
+	if !ok {
+		// Target file not part of this merge.
+		return nil
+	}
+
+	o, err = ctx.Dereference(d["D"])
+	if err != nil {
+		return err
+	}
+
+	switch dest := o.(type) {
+
+	case types.Array:
+		// For remote destinations the page is specified by its 0-based page number.
+		if len(dest) == 0 {
+			return nil
+		}
+		i, ok := dest[0].(types.Integer)
+		if !ok || i.Value() < 0 || i.Value() >= mf.PageCount {
+			return nil
+		}
+		ir, err := ctx.PageDictIndRef(mf.FirstPage + i.Value())
+		if err != nil {
+			return err
+		}
+		arr := types.Array{*ir}
+		d["D"] = append(arr, dest[1:]...)
+
+	case types.Name:
+		// Named destinations have been merged into the Dests name tree and may have been renamed.
+		if s, ok := mf.DestRenames[dest.Value()]; ok {
+			d["D"] = types.Name(s)
+		}
+
+	case types.StringLiteral, types.HexLiteral:
+		name, err := types.StringOrHexLiteral(dest)
+		if err != nil {
+			return err
+		}
+		if s, ok := mf.DestRenames[*name]; ok {
+			d["D"] = types.NewHexLiteral([]byte(s))
+		}
+
+	default:
+		return nil
+	}
+
+	d["S"] = types.Name("GoTo")
+	d.Delete("F")
+	d.Delete("NewWindow")
+
+	return nil
+}
+
+func resolveGoToRLinksForPage(ctx *model.Context, pageNr int, files map[string]MergedFile) error {
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return err
+	}
+
+	o, found := d.Find("Annots")
+	if !found {
+		return nil
+	}
+
+	annots, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range annots {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			continue
+		}
+		if st := d.Subtype(); st == nil || *st != "Link" {
+			continue
+		}
+		o, found := d.Find("A")
+		if !found {
+			continue
+		}
+		a, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if a != nil {
+			if err := resolveGoToRAction(ctx, a, files); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func resolveGoToRLinksForOutlineItems(ctx *model.Context, item *types.IndirectRef, files map[string]MergedFile) error {
+	var (
+		d   types.Dict
+		err error
+	)
+
+	for ir := item; ir != nil; ir = d.IndirectRefEntry("Next") {
+
+		if d, err = ctx.DereferenceDict(*ir); err != nil {
+			return err
+		}
+
+		if o, found := d.Find("A"); found {
+			a, err := ctx.DereferenceDict(o)
+			if err != nil {
+				return err
+			}
+			if a != nil {
+				if err := resolveGoToRAction(ctx, a, files); err != nil {
+					return err
+				}
+			}
+		}
+
+		if first := d.IndirectRefEntry("First"); first != nil {
+			if err := resolveGoToRLinksForOutlineItems(ctx, first, files); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
+
+// ResolveGoToRLinks converts remote go-to actions (GoToR) targeting any of the merged files
+// into regular go-to actions (GoTo) pointing to the corresponding page of the merged document.
+// files maps the base name of a merged file to its page span within ctx.
+func ResolveGoToRLinks(ctx *model.Context, files map[string]MergedFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		if err := resolveGoToRLinksForPage(ctx, i, files); err != nil {
+			return err
+		}
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+
+	o, found := rootDict.Find("Outlines")
+	if !found {
+		return nil
+	}
+
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return err
+	}
+
+	return resolveGoToRLinksForOutlineItems(ctx, d.IndirectRefEntry("First"), files)
+}
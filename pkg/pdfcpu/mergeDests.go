@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// destNames returns the names of all named destinations of ctx
+// defined either in the Dests name tree or in the Dests dict of the catalog (PDF 1.1).
+func destNames(ctx *model.Context) (map[string]bool, error) {
+	m := map[string]bool{}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	if o, found := rootDict.Find("Dests"); found {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return nil, err
+		}
+		for k := range d {
+			m[k] = true
+		}
+	}
+
+	if err := ctx.LocateNameTree("Dests", false); err != nil {
+		return nil, err
+	}
+
+	if n := ctx.Names["Dests"]; n != nil {
+		err := n.Process(ctx.XRefTable, func(_ *model.XRefTable, k string, _ *types.Object) error {
+			m[k] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func uniqueDestName(k string, taken map[string]bool) string {
+	for i := 2; ; i++ {
+		s := fmt.Sprintf("%s_%d", k, i)
+		if !taken[s] {
+			taken[s] = true
+			return s
+		}
+	}
+}
+
+// renameDestRefs updates all links, actions and outline items referring to renamed named destinations.
+// Remote go-to actions refer to named destinations of other files and are left untouched.
+func renameDestRefs(ctx *model.Context, renames map[string]string) {
+	nm := ctx.NameRefs["Dests"]
+	if nm == nil {
+		return
+	}
+
+	for kOld, kNew := range renames {
+		var remote []types.Dict
+		for _, d := range nm[kOld] {
+			if s := d.NameEntry("S"); s != nil && *s == "GoToR" {
+				remote = append(remote, d)
+				continue
+			}
+			for _, key := range []string{"D", "Dest"} {
+				switch d[key].(type) {
+				case types.Name:
+					d[key] = types.Name(kNew)
+				case types.StringLiteral, types.HexLiteral:
+					d[key] = types.NewHexLiteral([]byte(kNew))
+				}
+			}
+			nm.Add(kNew, d)
+		}
+		if len(remote) > 0 {
+			nm[kOld] = remote
+		} else {
+			delete(nm, kOld)
+		}
+	}
+}
+
+// renameDestsNameTree rebuilds the Dests name tree of ctx using renamed keys.
+func renameDestsNameTree(ctx *model.Context, renames map[string]string) error {
+	n := ctx.Names["Dests"]
+	if n == nil {
+		return nil
+	}
+
+	root := &model.Node{D: n.D}
+	root.D.Delete("Kids")
+	root.D.Delete("Names")
+
+	err := n.Process(ctx.XRefTable, func(_ *model.XRefTable, k string, v *types.Object) error {
+		if s, ok := renames[k]; ok {
+			k = s
+		}
+		return root.Add(nil, k, *v, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx.Names["Dests"] = root
+
+	return nil
+}
+
+// renameDestsDict renames the keys of the Dests dict of ctx's catalog.
+func renameDestsDict(ctx *model.Context, renames map[string]string) error {
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+
+	o, found := rootDict.Find("Dests")
+	if !found {
+		return nil
+	}
+
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return err
+	}
+
+	for kOld, kNew := range renames {
+		if v, ok := d[kOld]; ok {
+			d[kNew] = v
+			delete(d, kOld)
+		}
+	}
+
+	return nil
+}
+
+// remapSourceDests renames all named destinations of ctxSource already defined in ctxDest
+// including any references to them, so that links keep pointing to the pages of ctxSource after merging.
+// The renamed destinations are returned as a map of old to new names.
+func remapSourceDests(ctxSource, ctxDest *model.Context) (map[string]string, error) {
+	namesSrc, err := destNames(ctxSource)
+	if err != nil || len(namesSrc) == 0 {
+		return nil, err
+	}
+
+	namesDest, err := destNames(ctxDest)
+	if err != nil || len(namesDest) == 0 {
+		return nil, err
+	}
+
+	taken := map[string]bool{}
+	for k := range namesSrc {
+		taken[k] = true
+	}
+	for k := range namesDest {
+		taken[k] = true
+	}
+
+	renames := map[string]string{}
+	for k := range namesSrc {
+		if namesDest[k] {
+			renames[k] = uniqueDestName(k, taken)
+		}
+	}
+
+	if len(renames) == 0 {
+		return nil, nil
+	}
+
+	if err := renameDestsDict(ctxSource, renames); err != nil {
+		return nil, err
+	}
+
+	if err := renameDestsNameTree(ctxSource, renames); err != nil {
+		return nil, err
+	}
+
+	renameDestRefs(ctxSource, renames)
+
+	return renames, nil
+}
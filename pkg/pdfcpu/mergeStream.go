@@ -0,0 +1,487 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Reserved object numbers of a stream merge.
+const (
+	streamMergePagesObjNr = iota + 1
+	streamMergeCatalogObjNr
+	streamMergeInfoObjNr
+	streamMergeFirstObjNr
+)
+
+type streamMergeBookmark struct {
+	title     string
+	pageObjNr int
+}
+
+// StreamMerger appends the pages of a sequence of source contexts to a writer.
+// Each source gets written as soon as it is appended and may be released afterwards.
+// Only the object offsets and the page references written so far are kept in memory.
+//
+// Document level structures of the sources like forms, outlines, named destinations
+// or the structure tree are not carried over.
+type StreamMerger struct {
+	w         *model.WriteContext
+	conf      *model.Configuration
+	objNr     int // last object number in use
+	kids      types.Array
+	bookmarks []streamMergeBookmark
+	version   model.Version
+	closed    bool
+}
+
+// NewStreamMerger returns a StreamMerger writing to w and writes the PDF header.
+func NewStreamMerger(w io.Writer, conf *model.Configuration) (*StreamMerger, error) {
+	if w == nil {
+		return nil, errors.New("pdfcpu: NewStreamMerger: missing w")
+	}
+
+	if conf == nil {
+		conf = model.NewDefaultConfiguration()
+	}
+
+	wc := model.NewWriteContext(conf.Eol)
+	wc.Writer = bufio.NewWriter(w)
+
+	sm := &StreamMerger{w: wc, conf: conf, objNr: streamMergeFirstObjNr - 1, version: model.V17}
+
+	if err := writeHeader(wc, model.V17); err != nil {
+		return nil, err
+	}
+
+	return sm, nil
+}
+
+// PageCount returns the number of pages written so far.
+func (sm *StreamMerger) PageCount() int {
+	return len(sm.kids)
+}
+
+func (sm *StreamMerger) nextObjNr() int {
+	sm.objNr++
+	return sm.objNr
+}
+
+func (sm *StreamMerger) writeObject(objNr int, o types.Object) error {
+	w := sm.w
+	w.SetWriteOffset(objNr)
+
+	h, err := writeObjectHeader(w, objNr, 0)
+	if err != nil {
+		return err
+	}
+
+	var written int64
+
+	switch o := o.(type) {
+
+	case nil:
+		i, err := w.WriteString("null")
+		if err != nil {
+			return err
+		}
+		written = int64(i)
+
+	case types.StreamDict:
+		l := int64(len(o.Raw))
+		o.StreamLength = &l
+		o.Update("Length", types.Integer(l))
+		s := o.PDFString()
+		i, err := w.WriteString(s)
+		if err != nil {
+			return err
+		}
+		b, err := writeStream(w, o)
+		if err != nil {
+			return err
+		}
+		written = int64(i) + b
+		w.BinaryTotalSize += l
+
+	default:
+		i, err := w.WriteString(o.PDFString())
+		if err != nil {
+			return err
+		}
+		written = int64(i)
+	}
+
+	t, err := writeObjectTrailer(w)
+	if err != nil {
+		return err
+	}
+
+	w.Offset += int64(h+t) + written
+
+	return nil
+}
+
+// streamMergeSource renumbers the objects of a source context into the object number space of the merge.
+type streamMergeSource struct {
+	sm     *StreamMerger
+	ctx    *model.Context
+	lookup map[int]int // source objNr -> dest objNr
+	queue  []int       // source objNrs waiting to be written
+}
+
+func (src *streamMergeSource) mapIndRef(ir types.IndirectRef) (types.Object, error) {
+	objNr := ir.ObjectNumber.Value()
+
+	if objNr1, ok := src.lookup[objNr]; ok {
+		if objNr1 == 0 {
+			return nil, nil
+		}
+		return *types.NewIndirectRef(objNr1, 0), nil
+	}
+
+	o, err := src.ctx.Dereference(ir)
+	if err != nil {
+		return nil, err
+	}
+
+	if d, ok := o.(types.Dict); ok && d.Type() != nil {
+		// Page tree nodes and the catalog of the source get replaced by their merge counterparts.
+		switch *d.Type() {
+		case "Pages":
+			src.lookup[objNr] = streamMergePagesObjNr
+			return *types.NewIndirectRef(streamMergePagesObjNr, 0), nil
+		case "Catalog":
+			src.lookup[objNr] = streamMergeCatalogObjNr
+			return *types.NewIndirectRef(streamMergeCatalogObjNr, 0), nil
+		case "ObjStm", "XRef":
+			src.lookup[objNr] = 0
+			return nil, nil
+		}
+	}
+
+	objNr1 := src.sm.nextObjNr()
+	src.lookup[objNr] = objNr1
+	src.queue = append(src.queue, objNr)
+
+	return *types.NewIndirectRef(objNr1, 0), nil
+}
+
+// patch returns a copy of o using merge object numbers.
+func (src *streamMergeSource) patch(o types.Object) (types.Object, error) {
+	switch o := o.(type) {
+
+	case types.IndirectRef:
+		return src.mapIndRef(o)
+
+	case types.Dict:
+		d := types.NewDict()
+		for k, v := range o {
+			v1, err := src.patch(v)
+			if err != nil {
+				return nil, err
+			}
+			if v1 != nil {
+				d[k] = v1
+			}
+		}
+		return d, nil
+
+	case types.StreamDict:
+		d, err := src.patch(o.Dict)
+		if err != nil {
+			return nil, err
+		}
+		o.Dict = d.(types.Dict)
+		return o, nil
+
+	case types.Array:
+		a := make(types.Array, len(o))
+		for i, v := range o {
+			v1, err := src.patch(v)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = v1
+		}
+		return a, nil
+	}
+
+	return o, nil
+}
+
+func (src *streamMergeSource) writeQueuedObjects() error {
+	for len(src.queue) > 0 {
+		objNr := src.queue[0]
+		src.queue = src.queue[1:]
+
+		o, err := src.ctx.Dereference(*types.NewIndirectRef(objNr, 0))
+		if err != nil {
+			return err
+		}
+
+		if o, err = src.patch(o); err != nil {
+			return err
+		}
+
+		if err := src.sm.writeObject(src.lookup[objNr], o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamMergePageDict returns a copy of the page dict for pageNr including all inherited page attributes.
+func streamMergePageDict(ctx *model.Context, pageNr int) (types.Dict, *types.IndirectRef, error) {
+	d, ir, inhPAttrs, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if d == nil || ir == nil {
+		return nil, nil, errors.Errorf("pdfcpu: missing page %d", pageNr)
+	}
+
+	d = d.Clone().(types.Dict)
+
+	if _, found := d.Find("Resources"); !found && inhPAttrs.Resources != nil {
+		d.Insert("Resources", inhPAttrs.Resources.Clone())
+	}
+	if _, found := d.Find("MediaBox"); !found && inhPAttrs.MediaBox != nil {
+		d.Insert("MediaBox", inhPAttrs.MediaBox.Array())
+	}
+	if _, found := d.Find("CropBox"); !found && inhPAttrs.CropBox != nil {
+		d.Insert("CropBox", inhPAttrs.CropBox.Array())
+	}
+	if _, found := d.Find("Rotate"); !found && inhPAttrs.Rotate != 0 {
+		d.Insert("Rotate", types.Integer(inhPAttrs.Rotate))
+	}
+
+	return d, ir, nil
+}
+
+// Append writes all pages of ctx including all objects they depend on.
+// If bookmarks get created title is used for the bookmark pointing to the first page of ctx.
+func (sm *StreamMerger) Append(ctx *model.Context, title string) error {
+	if sm.closed {
+		return errors.New("pdfcpu: StreamMerger: already closed")
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	if ctx.Version() > sm.version {
+		sm.version = ctx.Version()
+	}
+
+	src := &streamMergeSource{sm: sm, ctx: ctx, lookup: map[int]int{}}
+
+	dd := make([]types.Dict, ctx.PageCount)
+	objNrs := make([]int, ctx.PageCount)
+
+	// Assign object numbers for all pages first in order to resolve references between pages.
+	for i := 1; i <= ctx.PageCount; i++ {
+		d, ir, err := streamMergePageDict(ctx, i)
+		if err != nil {
+			return err
+		}
+		objNr := sm.nextObjNr()
+		src.lookup[ir.ObjectNumber.Value()] = objNr
+		dd[i-1], objNrs[i-1] = d, objNr
+	}
+
+	for i, d := range dd {
+		d.Delete("Parent")
+		o, err := src.patch(d)
+		if err != nil {
+			return err
+		}
+		d = o.(types.Dict)
+		d.Insert("Parent", *types.NewIndirectRef(streamMergePagesObjNr, 0))
+		if err := sm.writeObject(objNrs[i], d); err != nil {
+			return err
+		}
+		if err := src.writeQueuedObjects(); err != nil {
+			return err
+		}
+		sm.kids = append(sm.kids, *types.NewIndirectRef(objNrs[i], 0))
+	}
+
+	if len(objNrs) > 0 && sm.conf.CreateBookmarks {
+		sm.bookmarks = append(sm.bookmarks, streamMergeBookmark{title: title, pageObjNr: objNrs[0]})
+	}
+
+	if log.InfoEnabled() {
+		log.Info.Printf("StreamMerger: appended %d pages, %d objects written\n", ctx.PageCount, sm.objNr)
+	}
+
+	return sm.w.Flush()
+}
+
+func (sm *StreamMerger) writeOutlines() (*types.IndirectRef, error) {
+	if len(sm.bookmarks) == 0 {
+		return nil, nil
+	}
+
+	root := sm.nextObjNr()
+	first := sm.objNr + 1
+	last := first + len(sm.bookmarks) - 1
+
+	for i, bm := range sm.bookmarks {
+		objNr := first + i
+
+		s, err := types.EscapeUTF16String(bm.title)
+		if err != nil {
+			return nil, err
+		}
+
+		d := types.Dict(map[string]types.Object{
+			"Title":  types.StringLiteral(*s),
+			"Parent": *types.NewIndirectRef(root, 0),
+			"Dest":   types.Array{*types.NewIndirectRef(bm.pageObjNr, 0), types.Name("Fit")},
+		})
+		if objNr > first {
+			d.Insert("Prev", *types.NewIndirectRef(objNr-1, 0))
+		}
+		if objNr < last {
+			d.Insert("Next", *types.NewIndirectRef(objNr+1, 0))
+		}
+
+		if err := sm.writeObject(sm.nextObjNr(), d); err != nil {
+			return nil, err
+		}
+	}
+
+	d := types.Dict(map[string]types.Object{
+		"Type":  types.Name("Outlines"),
+		"First": *types.NewIndirectRef(first, 0),
+		"Last":  *types.NewIndirectRef(last, 0),
+		"Count": types.Integer(len(sm.bookmarks)),
+	})
+	if err := sm.writeObject(root, d); err != nil {
+		return nil, err
+	}
+
+	return types.NewIndirectRef(root, 0), nil
+}
+
+func (sm *StreamMerger) writeXRefTableAndTrailer(id types.HexLiteral) error {
+	w := sm.w
+	offset := w.Offset
+	size := sm.objNr + 1
+
+	if _, err := w.WriteString(fmt.Sprintf("xref%s0 %d%s", w.Eol, size, w.Eol)); err != nil {
+		return err
+	}
+
+	if _, err := w.WriteString(fmt.Sprintf("%010d %05d f%2s", 0, types.FreeHeadGeneration, w.Eol)); err != nil {
+		return err
+	}
+
+	for i := 1; i < size; i++ {
+		if _, err := w.WriteString(fmt.Sprintf("%010d %05d n%2s", w.Table[i], 0, w.Eol)); err != nil {
+			return err
+		}
+	}
+
+	d := types.Dict(map[string]types.Object{
+		"Size": types.Integer(size),
+		"Root": *types.NewIndirectRef(streamMergeCatalogObjNr, 0),
+		"Info": *types.NewIndirectRef(streamMergeInfoObjNr, 0),
+		"ID":   types.Array{id, id},
+	})
+
+	if _, err := w.WriteString(fmt.Sprintf("trailer%s%s%s", w.Eol, d.PDFString(), w.Eol)); err != nil {
+		return err
+	}
+
+	if _, err := w.WriteString(fmt.Sprintf("startxref%s%d%s", w.Eol, offset, w.Eol)); err != nil {
+		return err
+	}
+
+	return writeTrailer(w)
+}
+
+// Close writes the page tree, the catalog, the info dict and the cross reference table.
+func (sm *StreamMerger) Close() error {
+	if sm.closed {
+		return nil
+	}
+	sm.closed = true
+
+	if len(sm.kids) == 0 {
+		return errors.New("pdfcpu: StreamMerger: no pages")
+	}
+
+	d := types.Dict(map[string]types.Object{
+		"Type":  types.Name("Pages"),
+		"Kids":  sm.kids,
+		"Count": types.Integer(len(sm.kids)),
+	})
+	if err := sm.writeObject(streamMergePagesObjNr, d); err != nil {
+		return err
+	}
+
+	outlines, err := sm.writeOutlines()
+	if err != nil {
+		return err
+	}
+
+	d = types.Dict(map[string]types.Object{
+		"Type":  types.Name("Catalog"),
+		"Pages": *types.NewIndirectRef(streamMergePagesObjNr, 0),
+	})
+	if sm.version > model.V17 {
+		d.InsertName("Version", sm.version.String())
+	}
+	if outlines != nil {
+		d.Insert("Outlines", *outlines)
+		d.InsertName("PageMode", "UseOutlines")
+	}
+	if err := sm.writeObject(streamMergeCatalogObjNr, d); err != nil {
+		return err
+	}
+
+	now := types.DateString(time.Now())
+	d = types.NewDict()
+	d.InsertString("Producer", "pdfcpu "+model.VersionStr)
+	d.InsertString("CreationDate", now)
+	d.InsertString("ModDate", now)
+	if err := sm.writeObject(streamMergeInfoObjNr, d); err != nil {
+		return err
+	}
+
+	h := md5.New()
+	h.Write([]byte(now))
+	h.Write([]byte(fmt.Sprintf("%d %d", sm.w.Offset, len(sm.kids))))
+	id := types.HexLiteral(hex.EncodeToString(h.Sum(nil)))
+
+	if err := sm.writeXRefTableAndTrailer(id); err != nil {
+		return err
+	}
+
+	return sm.w.Flush()
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// treeEntries walks the name or number tree o and calls f for each key value pair found in leaf arrays named key.
+func treeEntries(ctx *model.Context, o types.Object, key string, visited types.IntSet, f func(k, v types.Object)) error {
+	if ir, ok := o.(types.IndirectRef); ok {
+		if visited[ir.ObjectNumber.Value()] {
+			return nil
+		}
+		visited[ir.ObjectNumber.Value()] = true
+	}
+
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return err
+	}
+
+	if o, found := d.Find(key); found {
+		a, err := ctx.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(a); i += 2 {
+			f(a[i], a[i+1])
+		}
+	}
+
+	if o, found := d.Find("Kids"); found {
+		a, err := ctx.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+		for _, o := range a {
+			if err := treeEntries(ctx, o, key, visited, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parentTreeEntries returns the entries of a structure parent tree.
+func parentTreeEntries(ctx *model.Context, d types.Dict) (map[int]types.Object, error) {
+	m := map[int]types.Object{}
+
+	o, found := d.Find("ParentTree")
+	if !found {
+		return m, nil
+	}
+
+	err := treeEntries(ctx, o, "Nums", types.IntSet{}, func(k, v types.Object) {
+		if i, ok := k.(types.Integer); ok {
+			m[i.Value()] = v
+		}
+	})
+
+	return m, err
+}
+
+func nextParentTreeKey(d types.Dict, m map[int]types.Object) int {
+	next := 0
+	for k := range m {
+		if k >= next {
+			next = k + 1
+		}
+	}
+	if i := d.IntEntry("ParentTreeNextKey"); i != nil && *i > next {
+		next = *i
+	}
+	return next
+}
+
+// shiftStructParents adds offset to all StructParent and StructParents entries of ctxSource.
+func shiftStructParents(ctxSource *model.Context, offset int) {
+	for _, entry := range ctxSource.Table {
+		if entry == nil || entry.Free {
+			continue
+		}
+		var d types.Dict
+		switch o := entry.Object.(type) {
+		case types.Dict:
+			d = o
+		case types.StreamDict:
+			d = o.Dict
+		default:
+			continue
+		}
+		for _, k := range []string{"StructParent", "StructParents"} {
+			if i := d.IntEntry(k); i != nil {
+				d.Update(k, types.Integer(*i+offset))
+			}
+		}
+	}
+}
+
+// structKids returns the entry K of a structure tree root as array.
+func structKids(ctx *model.Context, d types.Dict) (types.Array, error) {
+	o, found := d.Find("K")
+	if !found {
+		return types.Array{}, nil
+	}
+	o1, err := ctx.Dereference(o)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := o1.(types.Array); ok {
+		return append(types.Array{}, a...), nil
+	}
+	return types.Array{o}, nil
+}
+
+func mergeDictEntries(ctx *model.Context, dSrc, dDest types.Dict, key string) error {
+	o, found := dSrc.Find(key)
+	if !found {
+		return nil
+	}
+	d1, err := ctx.DereferenceDict(o)
+	if err != nil || d1 == nil {
+		return err
+	}
+	d2, err := ctx.DereferenceDict(dDest[key])
+	if err != nil {
+		return err
+	}
+	if d2 == nil {
+		dDest[key] = d1.Clone()
+		return nil
+	}
+	// Note: We ignore duplicate keys
+	for k, v := range d1 {
+		if _, found := d2.Find(k); !found {
+			d2.Insert(k, v)
+		}
+	}
+	return nil
+}
+
+func mergeIDTrees(ctx *model.Context, dSrc, dDest types.Dict) error {
+	oSrc, found := dSrc.Find("IDTree")
+	if !found {
+		return nil
+	}
+	oDest, found := dDest.Find("IDTree")
+	if !found {
+		dDest["IDTree"] = oSrc
+		return nil
+	}
+
+	m := map[string]types.Object{}
+	collect := func(k, v types.Object) {
+		if s, ok := k.(types.StringLiteral); ok {
+			if _, found := m[s.Value()]; !found {
+				m[s.Value()] = v
+			}
+		}
+	}
+
+	visited := types.IntSet{}
+	if err := treeEntries(ctx, oDest, "Names", visited, collect); err != nil {
+		return err
+	}
+	if err := treeEntries(ctx, oSrc, "Names", visited, collect); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	a := types.Array{}
+	for _, k := range keys {
+		a = append(a, types.StringLiteral(k), m[k])
+	}
+
+	ir, err := ctx.IndRefForNewObject(types.Dict(map[string]types.Object{"Names": a}))
+	if err != nil {
+		return err
+	}
+	dDest["IDTree"] = *ir
+
+	return nil
+}
+
+// mergeStructTrees merges the structure tree of ctxSource into the structure tree of ctxDest.
+// Parent tree keys of ctxSource get shifted behind the keys in use by ctxDest.
+// Marked content identifiers are local to their page or content stream and remain valid.
+func mergeStructTrees(ctxSource, ctxDest *model.Context) error {
+	rootDictSource, rootDictDest, err := rootDicts(ctxSource, ctxDest)
+	if err != nil {
+		return err
+	}
+
+	o, found := rootDictSource.Find("StructTreeRoot")
+	if !found {
+		return nil
+	}
+
+	dSrc, err := ctxDest.DereferenceDict(o)
+	if err != nil || dSrc == nil {
+		return err
+	}
+
+	irDest := rootDictDest.IndirectRefEntry("StructTreeRoot")
+	if irDest == nil {
+		d := types.Dict(map[string]types.Object{"Type": types.Name("StructTreeRoot")})
+		if irDest, err = ctxDest.IndRefForNewObject(d); err != nil {
+			return err
+		}
+		rootDictDest["StructTreeRoot"] = *irDest
+	}
+
+	dDest, err := ctxDest.DereferenceDict(*irDest)
+	if err != nil {
+		return err
+	}
+
+	mDest, err := parentTreeEntries(ctxDest, dDest)
+	if err != nil {
+		return err
+	}
+
+	mSrc, err := parentTreeEntries(ctxDest, dSrc)
+	if err != nil {
+		return err
+	}
+
+	offset := nextParentTreeKey(dDest, mDest)
+	shiftStructParents(ctxSource, offset)
+	for k, v := range mSrc {
+		mDest[k+offset] = v
+	}
+
+	keys := make([]int, 0, len(mDest))
+	for k := range mDest {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	nums := types.Array{}
+	for _, k := range keys {
+		nums = append(nums, types.Integer(k), mDest[k])
+	}
+
+	ir, err := ctxDest.IndRefForNewObject(types.Dict(map[string]types.Object{"Nums": nums}))
+	if err != nil {
+		return err
+	}
+	dDest["ParentTree"] = *ir
+	dDest["ParentTreeNextKey"] = types.Integer(nextParentTreeKey(dSrc, mSrc) + offset)
+
+	// Attach the top level structure elements of ctxSource to the structure tree root of ctxDest.
+	kidsSrc, err := structKids(ctxDest, dSrc)
+	if err != nil {
+		return err
+	}
+	for _, o := range kidsSrc {
+		d, err := ctxDest.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			d["P"] = *irDest
+		}
+	}
+	if len(kidsSrc) > 0 {
+		kidsDest, err := structKids(ctxDest, dDest)
+		if err != nil {
+			return err
+		}
+		dDest["K"] = append(kidsDest, kidsSrc...)
+	}
+
+	if err := mergeDictEntries(ctxDest, dSrc, dDest, "RoleMap"); err != nil {
+		return err
+	}
+
+	if err := mergeDictEntries(ctxDest, dSrc, dDest, "ClassMap"); err != nil {
+		return err
+	}
+
+	if err := mergeIDTrees(ctxDest, dSrc, dDest); err != nil {
+		return err
+	}
+
+	if _, found := rootDictDest.Find("MarkInfo"); !found {
+		if o, found := rootDictSource.Find("MarkInfo"); found {
+			rootDictDest["MarkInfo"] = o
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/mjuen/pdfcpu/pkg/font"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/color"
+	pdffont "github.com/mjuen/pdfcpu/pkg/pdfcpu/font"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	tocFontName      = "Helvetica"
+	tocFontSize      = 12
+	tocTitleFontSize = 18
+	tocMargin        = 50.
+	tocHeaderHeight  = 48.
+	tocLineHeight    = 20.
+)
+
+// TOCEntry represents a merged file listed on a table of contents page.
+type TOCEntry struct {
+	Title  string
+	PageNr int // 1-based page number of the first page of this file within the merged document.
+}
+
+// truncateTOCTitle shortens s to fit into width.
+func truncateTOCTitle(s string, width float64) string {
+	if font.TextWidth(s, tocFontName, tocFontSize) <= width {
+		return s
+	}
+	rr := []rune(s)
+	for len(rr) > 0 && font.TextWidth(string(rr)+"...", tocFontName, tocFontSize) > width {
+		rr = rr[:len(rr)-1]
+	}
+	return string(rr) + "..."
+}
+
+func writeTOCText(w *bytes.Buffer, mediaBox *types.Rectangle, fm model.FontMap, s string, fontSize int, x, y float64) {
+	td := model.TextDescriptor{
+		FontName:  tocFontName,
+		FontKey:   fm.EnsureKey(tocFontName),
+		FontSize:  fontSize,
+		Scale:     1.0,
+		ScaleAbs:  true,
+		StrokeCol: color.Black,
+		FillCol:   color.Black,
+		X:         x,
+		Y:         y,
+		HAlign:    types.AlignLeft,
+		VAlign:    types.AlignBaseline,
+		Text:      s,
+	}
+	model.WriteMultiLine(nil, w, mediaBox, nil, td)
+}
+
+// tocLinkAnnotation returns a link annotation for rect jumping to the page referenced by pageIndRef.
+func tocLinkAnnotation(ctx *model.Context, rect *types.Rectangle, pageIndRef, destIndRef types.IndirectRef) (*types.IndirectRef, error) {
+	d := types.Dict(
+		map[string]types.Object{
+			"Type":    types.Name("Annot"),
+			"Subtype": types.Name("Link"),
+			"Rect":    rect.Array(),
+			"P":       pageIndRef,
+			"Border":  types.NewIntegerArray(0, 0, 0),
+			"Dest":    types.Array{destIndRef, types.Name("Fit")},
+		},
+	)
+	return ctx.IndRefForNewObject(d)
+}
+
+// createTOCPage creates a table of contents page listing entries.
+// pageNrOffset accounts for the table of contents pages preceding the merged files.
+func createTOCPage(
+	ctx *model.Context,
+	mediaBox *types.Rectangle,
+	entries []TOCEntry,
+	destIndRefs []types.IndirectRef,
+	pageNrOffset int,
+	header bool,
+	parentIndRef types.IndirectRef) (*types.IndirectRef, error) {
+
+	pageDict := types.Dict(
+		map[string]types.Object{
+			"Type":     types.Name("Page"),
+			"Parent":   parentIndRef,
+			"MediaBox": mediaBox.Array(),
+		},
+	)
+
+	pageIndRef, err := ctx.IndRefForNewObject(pageDict)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fm := model.FontMap{}
+
+	y := mediaBox.Height() - tocMargin
+	if header {
+		writeTOCText(&buf, mediaBox, fm, "Contents", tocTitleFontSize, tocMargin, y-tocTitleFontSize)
+	}
+	y -= tocHeaderHeight
+
+	w := mediaBox.Width() - 2*tocMargin
+	annots := types.Array{}
+
+	for i, e := range entries {
+		s := strconv.Itoa(e.PageNr + pageNrOffset)
+		sw := font.TextWidth(s, tocFontName, tocFontSize)
+		title := truncateTOCTitle(e.Title, w-sw-tocLineHeight)
+		writeTOCText(&buf, mediaBox, fm, title, tocFontSize, tocMargin, y)
+		writeTOCText(&buf, mediaBox, fm, s, tocFontSize, mediaBox.Width()-tocMargin-sw, y)
+
+		r := types.RectForWidthAndHeight(tocMargin, y-tocLineHeight/4, w, tocLineHeight)
+		ir, err := tocLinkAnnotation(ctx, r, *pageIndRef, destIndRefs[i])
+		if err != nil {
+			return nil, err
+		}
+		annots = append(annots, *ir)
+
+		y -= tocLineHeight
+	}
+
+	fontRes, err := pdffont.FontResources(ctx.XRefTable, fm)
+	if err != nil {
+		return nil, err
+	}
+
+	sd, _ := ctx.NewStreamDictForBuf(buf.Bytes())
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	contentsIndRef, err := ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		return nil, err
+	}
+
+	pageDict["Resources"] = types.Dict(map[string]types.Object{"Font": fontRes})
+	pageDict["Contents"] = *contentsIndRef
+	pageDict["Annots"] = annots
+
+	return pageIndRef, nil
+}
+
+// InsertTOCPages inserts table of contents pages in front of the first page of ctx.
+// Each entry gets listed with its page number and a link to its first page.
+func InsertTOCPages(ctx *model.Context, entries []TOCEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	mediaBox := types.RectForFormat("A4")
+	if dims, err := ctx.PageDims(); err == nil && len(dims) > 0 {
+		mediaBox = types.RectForDim(dims[0].Width, dims[0].Height)
+	}
+
+	linesPerPage := int((mediaBox.Height() - 2*tocMargin - tocHeaderHeight) / tocLineHeight)
+	if linesPerPage < 1 {
+		linesPerPage = 1
+	}
+	pageCount := (len(entries) + linesPerPage - 1) / linesPerPage
+
+	// Resolve the link destinations before modifying the page tree.
+	destIndRefs := make([]types.IndirectRef, len(entries))
+	for i, e := range entries {
+		_, ir, _, err := ctx.PageDict(e.PageNr, false)
+		if err != nil {
+			return err
+		}
+		if ir == nil {
+			return errors.Errorf("pdfcpu: InsertTOCPages: invalid page number: %d", e.PageNr)
+		}
+		destIndRefs[i] = *ir
+	}
+
+	pagesIndRef, err := ctx.Pages()
+	if err != nil {
+		return err
+	}
+
+	pagesDict, err := ctx.DereferenceDict(*pagesIndRef)
+	if err != nil {
+		return err
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+
+	// Insert a new page tree root node having the TOC pages and the original page tree as kids.
+	d := types.Dict(
+		map[string]types.Object{
+			"Type":  types.Name("Pages"),
+			"Count": types.Integer(pageCount + ctx.PageCount),
+		},
+	)
+
+	rootIndRef, err := ctx.IndRefForNewObject(d)
+	if err != nil {
+		return err
+	}
+
+	kids := types.Array{}
+
+	for i := 0; i < pageCount; i++ {
+		from, thru := i*linesPerPage, (i+1)*linesPerPage
+		if thru > len(entries) {
+			thru = len(entries)
+		}
+		ir, err := createTOCPage(ctx, mediaBox, entries[from:thru], destIndRefs[from:thru], pageCount, i == 0, *rootIndRef)
+		if err != nil {
+			return err
+		}
+		kids = append(kids, *ir)
+	}
+
+	d["Kids"] = append(kids, *pagesIndRef)
+	pagesDict["Parent"] = *rootIndRef
+	rootDict["Pages"] = *rootIndRef
+
+	ctx.PageCount += pageCount
+
+	return nil
+}
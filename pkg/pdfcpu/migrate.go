@@ -88,12 +88,40 @@ func migrateObject(o types.Object, ctxSource, ctxDest *model.Context, migrated m
 	return o, nil
 }
 
+// migrateButtonParent migrates the parent field of a button widget without its own parent.
+// The source parent remains untouched so ctxSrc may be shared.
+func migrateButtonParent(o types.Object, ctxSrc, ctxDest *model.Context, migrated map[int]int) (types.Object, error) {
+	ir, ok := o.(types.IndirectRef)
+	if !ok {
+		return migrateObject(o, ctxSrc, ctxDest, migrated)
+	}
+	objNr := ir.ObjectNumber.Value()
+	if migrated[objNr] > 0 {
+		ir.ObjectNumber = types.Integer(migrated[objNr])
+		return ir, nil
+	}
+	o1, err := migrateIndRef(&ir, ctxSrc, ctxDest, migrated)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := o1.(types.Dict); ok {
+		d.Delete("Parent")
+	}
+	if _, err := migrateObject(o1, ctxSrc, ctxDest, migrated); err != nil {
+		return nil, err
+	}
+	return ir, nil
+}
+
 func migrateAnnots(o types.Object, pageIndRef types.IndirectRef, ctxSrc, ctxDest *model.Context, migrated map[int]int) (types.Object, error) {
 	arr, err := ctxSrc.DereferenceArray(o)
 	if err != nil {
 		return nil, err
 	}
 
+	// Leave ctxSrc untouched so pages may be extracted repeatedly.
+	arr = arr.Clone().(types.Array)
+
 	for i, v := range arr {
 		o := v.(types.IndirectRef)
 		objNr := o.ObjectNumber.Value()
@@ -123,7 +151,10 @@ func migrateAnnots(o types.Object, pageIndRef types.IndirectRef, ctxSrc, ctxDest
 					d.Delete("Parent")
 					continue
 				}
-				pDict.Delete("Parent")
+				if d[k], err = migrateButtonParent(v, ctxSrc, ctxDest, migrated); err != nil {
+					return nil, err
+				}
+				continue
 			}
 			if d[k], err = migrateObject(v, ctxSrc, ctxDest, migrated); err != nil {
 				return nil, err
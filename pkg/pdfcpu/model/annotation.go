@@ -22,6 +22,7 @@ import (
 
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/color"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
 )
 
 // AnnotationFlags represents the PDF annotation flags.
@@ -70,6 +71,8 @@ const (
 	AnnWatermark
 	Ann3D
 	AnnRedact
+	AnnRichMedia
+	AnnProjection
 )
 
 var AnnotTypes = map[string]AnnotationType{
@@ -99,6 +102,8 @@ var AnnotTypes = map[string]AnnotationType{
 	"Watermark":      AnnWatermark,
 	"3D":             Ann3D,
 	"Redact":         AnnRedact,
+	"RichMedia":      AnnRichMedia,
+	"Projection":     AnnProjection,
 }
 
 // AnnotTypeStrings manages string representations for annotation types.
@@ -129,6 +134,8 @@ var AnnotTypeStrings = map[AnnotationType]string{
 	AnnWatermark:      "Watermark",
 	Ann3D:             "3D",
 	AnnRedact:         "Redact",
+	AnnRichMedia:      "RichMedia",
+	AnnProjection:     "Projection",
 }
 
 // AnnotationRenderer is the interface for PDF annotations.
@@ -435,6 +442,108 @@ func (ann InkAnnotation) RenderDict(pageIndRef types.IndirectRef) types.Dict {
 	return d
 }
 
+// BorderStyle represents the style of an annotation border (see table 168).
+type BorderStyle int
+
+const (
+	BorderSolid BorderStyle = iota
+	BorderDashed
+	BorderBeveled
+	BorderInset
+	BorderUnderline
+)
+
+var borderStyleNames = map[BorderStyle]string{
+	BorderSolid:     "S",
+	BorderDashed:    "D",
+	BorderBeveled:   "B",
+	BorderInset:     "I",
+	BorderUnderline: "U",
+}
+
+// LinkHighlight represents the visual effect applied when a link annotation gets activated (see table 176).
+type LinkHighlight string
+
+const (
+	LinkHighlightNone    LinkHighlight = "N" // No highlighting.
+	LinkHighlightInvert  LinkHighlight = "I" // Invert the contents of the annotation rectangle.
+	LinkHighlightOutline LinkHighlight = "O" // Invert the annotation's border.
+	LinkHighlightPush    LinkHighlight = "P" // Display the annotation as if it were being pushed below the surface of the page.
+)
+
+// LinkStyle represents the border and highlight settings of a link annotation.
+type LinkStyle struct {
+	Width     float64            // border width in points, 0 hides the border.
+	Style     BorderStyle        // border style.
+	Dash      []float64          // dash array for dashed borders, at most 2 elements, defaults to 3.
+	Color     *color.SimpleColor // border color.
+	Highlight LinkHighlight      // highlighting mode, defaults to LinkHighlightInvert.
+}
+
+// Validate checks ls for consistency.
+func (ls LinkStyle) Validate() error {
+	if ls.Width < 0 {
+		return errors.Errorf("pdfcpu: invalid link border width: %.2f", ls.Width)
+	}
+	if _, ok := borderStyleNames[ls.Style]; !ok {
+		return errors.Errorf("pdfcpu: invalid link border style: %d", ls.Style)
+	}
+	if len(ls.Dash) > 2 {
+		return errors.New("pdfcpu: link border dash array has at most 2 elements")
+	}
+	for _, f := range ls.Dash {
+		if f < 0 {
+			return errors.Errorf("pdfcpu: invalid link border dash array: %v", ls.Dash)
+		}
+	}
+	switch ls.Highlight {
+	case "", LinkHighlightNone, LinkHighlightInvert, LinkHighlightOutline, LinkHighlightPush:
+	default:
+		return errors.Errorf("pdfcpu: invalid link highlight mode: %s", ls.Highlight)
+	}
+	return nil
+}
+
+// Apply sets border style, border color and highlighting mode of the link annotation dict d according to ls.
+func (ls LinkStyle) Apply(d types.Dict) error {
+	if err := ls.Validate(); err != nil {
+		return err
+	}
+
+	bs := types.Dict(map[string]types.Object{
+		"Type": types.Name("Border"),
+		"W":    types.Float(ls.Width),
+		"S":    types.Name(borderStyleNames[ls.Style]),
+	})
+
+	// Border is the fallback for PDF 1.0 viewers.
+	border := types.Array{types.Integer(0), types.Integer(0), types.Float(ls.Width)}
+
+	if ls.Style == BorderDashed {
+		dash := ls.Dash
+		if len(dash) == 0 {
+			dash = []float64{3}
+		}
+		bs["D"] = types.NewNumberArray(dash...)
+		border = append(border, types.NewNumberArray(dash...))
+	}
+
+	d["BS"] = bs
+	d["Border"] = border
+
+	d.Delete("C")
+	if ls.Color != nil {
+		d["C"] = ls.Color.Array()
+	}
+
+	d.Delete("H")
+	if ls.Highlight != "" {
+		d["H"] = types.Name(ls.Highlight)
+	}
+
+	return nil
+}
+
 // LinkAnnotation represents a PDF link annotation.
 type LinkAnnotation struct {
 	Annotation
@@ -442,6 +551,7 @@ type LinkAnnotation struct {
 	URI    string           // external link
 	Quad   types.QuadPoints // shall be ignored if any coordinate lies outside the region specified by Rect.
 	Border bool             // render border using borderColor.
+	Style  *LinkStyle       // border and highlight style, takes precedence over Border.
 }
 
 // NewLinkAnnotation returns a new link annotation.
@@ -466,6 +576,22 @@ func NewLinkAnnotation(
 	}
 }
 
+// NewStyledLinkAnnotation returns a new link annotation using border and highlight style ls.
+func NewStyledLinkAnnotation(
+	rect types.Rectangle,
+	quad types.QuadPoints,
+	dest *Destination, // supply dest or uri, dest takes precedence
+	uri string,
+	id string,
+	f AnnotationFlags,
+	ls LinkStyle) LinkAnnotation {
+
+	ann := NewLinkAnnotation(rect, quad, dest, uri, id, f, ls.Color, ls.Width > 0)
+	ann.Style = &ls
+
+	return ann
+}
+
 // ContentString returns a string representation of ann's content.
 func (ann LinkAnnotation) ContentString() string {
 	if len(ann.URI) > 0 {
@@ -489,7 +615,11 @@ func (ann LinkAnnotation) RenderDict(xRefTable *XRefTable, pageIndRef types.Indi
 		"F":       types.Integer(ann.F),
 	})
 
-	if !ann.Border {
+	if ann.Style != nil {
+		if err := ann.Style.Apply(d); err != nil {
+			return nil, err
+		}
+	} else if !ann.Border {
 		d["Border"] = types.NewIntegerArray(0, 0, 0)
 	} else {
 		if ann.C != nil {
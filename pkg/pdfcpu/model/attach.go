@@ -18,6 +18,8 @@ package model
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"sort"
@@ -42,6 +44,20 @@ func (a Attachment) String() string {
 	return fmt.Sprintf("Attachment: id:%s desc:%s modTime:%s", a.ID, a.Desc, a.ModTime)
 }
 
+// AttachmentInfo represents audit relevant properties of an embedded file.
+type AttachmentInfo struct {
+	ID           string     `json:"id"`
+	FileName     string     `json:"fileName"`
+	Desc         string     `json:"desc,omitempty"`
+	MimeType     string     `json:"mimeType,omitempty"`     // Declared MIME type.
+	Size         int        `json:"size"`                   // Size of the decoded payload.
+	DeclaredSize *int       `json:"declaredSize,omitempty"` // Size as declared by the embedded file parameters.
+	SHA256       string     `json:"sha256"`                 // Hex encoded SHA-256 of the decoded payload.
+	Encrypted    bool       `json:"encrypted"`              // True if the payload is stored encrypted.
+	Encryption   string     `json:"encryption,omitempty"`   // RC4, AES-128 or AES-256
+	ModTime      *time.Time `json:"modTime,omitempty"`
+}
+
 func decodeFileSpecStreamDict(sd *types.StreamDict, id string) error {
 	fpl := sd.FilterPipeline
 
@@ -195,6 +211,180 @@ func (ctx *Context) ListAttachments() ([]Attachment, error) {
 	return aa, nil
 }
 
+// embeddedFileEncryption returns the encryption method applied to the embedded file stream sd.
+func (xRefTable *XRefTable) embeddedFileEncryption(sd *types.StreamDict) (string, error) {
+	if xRefTable.Encrypt == nil {
+		return "", nil
+	}
+
+	d, err := xRefTable.DereferenceDict(*xRefTable.Encrypt)
+	if err != nil || d == nil {
+		return "", err
+	}
+
+	if v := d.IntEntry("V"); v == nil || (*v != 4 && *v != 5) {
+		return "RC4", nil
+	}
+
+	// The crypt filter in charge is either set explicitly for this stream or defaults to EFF or StmF.
+	cf := "Identity"
+	if n := d.NameEntry("StmF"); n != nil {
+		cf = *n
+	}
+	if n := d.NameEntry("EFF"); n != nil {
+		cf = *n
+	}
+	for _, f := range sd.FilterPipeline {
+		if f.Name == "Crypt" {
+			cf = "Identity"
+			if f.DecodeParms != nil {
+				if n := f.DecodeParms.NameEntry("Name"); n != nil {
+					cf = *n
+				}
+			}
+		}
+	}
+
+	if cf == "Identity" {
+		return "", nil
+	}
+
+	cfDict := d.DictEntry("CF")
+	if cfDict == nil {
+		return "", errors.Errorf("pdfcpu: missing crypt filter %s", cf)
+	}
+	if cfDict = cfDict.DictEntry(cf); cfDict == nil {
+		return "", errors.Errorf("pdfcpu: missing crypt filter %s", cf)
+	}
+
+	cfm := cfDict.NameEntry("CFM")
+	if cfm == nil {
+		return "", nil
+	}
+
+	switch *cfm {
+	case "V2":
+		return "RC4", nil
+	case "AESV2":
+		return "AES-128", nil
+	case "AESV3":
+		return "AES-256", nil
+	}
+
+	return "", nil
+}
+
+// embeddedFileContent returns the decoded payload of the embedded file stream sd.
+func embeddedFileContent(sd *types.StreamDict) ([]byte, error) {
+	if sd.Content != nil {
+		return sd.Content, nil
+	}
+
+	// Decryption already took place while reading.
+	sd1 := *sd
+	sd1.FilterPipeline = nil
+	for _, f := range sd.FilterPipeline {
+		if f.Name != "Crypt" {
+			sd1.FilterPipeline = append(sd1.FilterPipeline, f)
+		}
+	}
+
+	if err := sd1.Decode(); err != nil {
+		return nil, err
+	}
+
+	return sd1.Content, nil
+}
+
+func (xRefTable *XRefTable) attachmentInfo(id string, o types.Object) (*AttachmentInfo, error) {
+	d, err := xRefTable.DereferenceDict(o)
+	if err != nil {
+		return nil, err
+	}
+
+	ai := &AttachmentInfo{ID: id}
+
+	if o, found := d.Find("Desc"); found {
+		if ai.Desc, err = xRefTable.DereferenceStringOrHexLiteral(o, V10, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if ai.FileName, err = fileSpecStreamFileName(xRefTable, d); err != nil {
+		return nil, err
+	}
+
+	sd, err := fileSpecStreamDict(xRefTable, d)
+	if err != nil {
+		return nil, err
+	}
+	if sd == nil {
+		return nil, errors.Errorf("pdfcpu: missing embedded file stream for %s", id)
+	}
+
+	if st := sd.Subtype(); st != nil {
+		ai.MimeType = *st
+		if s, err := types.DecodeName(*st); err == nil {
+			ai.MimeType = s
+		}
+	}
+
+	if d := sd.DictEntry("Params"); d != nil {
+		ai.DeclaredSize = d.IntEntry("Size")
+		if s := d.StringEntry("ModDate"); s != nil {
+			if dt, ok := types.DateTime(*s, xRefTable.ValidationMode == ValidationRelaxed); ok {
+				ai.ModTime = &dt
+			}
+		}
+	}
+
+	if ai.Encryption, err = xRefTable.embeddedFileEncryption(sd); err != nil {
+		return nil, err
+	}
+	ai.Encrypted = ai.Encryption != ""
+
+	bb, err := embeddedFileContent(sd)
+	if err != nil {
+		return nil, errors.Wrapf(err, "pdfcpu: decoding %s", id)
+	}
+	ai.Size = len(bb)
+	sum := sha256.Sum256(bb)
+	ai.SHA256 = hex.EncodeToString(sum[:])
+
+	return ai, nil
+}
+
+// AttachmentInfos returns the encryption status, size, SHA-256 and declared MIME type of all embedded files.
+// The payloads are processed in memory only.
+func (ctx *Context) AttachmentInfos() ([]AttachmentInfo, error) {
+	xRefTable := ctx.XRefTable
+	if !xRefTable.Valid {
+		if err := xRefTable.LocateNameTree("EmbeddedFiles", false); err != nil {
+			return nil, err
+		}
+	}
+	if xRefTable.Names["EmbeddedFiles"] == nil {
+		return nil, nil
+	}
+
+	var aii []AttachmentInfo
+
+	collect := func(xRefTable *XRefTable, id string, o *types.Object) error {
+		ai, err := xRefTable.attachmentInfo(id, *o)
+		if err != nil {
+			return err
+		}
+		aii = append(aii, *ai)
+		return nil
+	}
+
+	if err := ctx.Names["EmbeddedFiles"].Process(xRefTable, collect); err != nil {
+		return nil, err
+	}
+
+	return aii, nil
+}
+
 // AddAttachment adds a.
 func (ctx *Context) AddAttachment(a Attachment, useCollection bool) error {
 	xRefTable := ctx.XRefTable
@@ -73,8 +73,14 @@ func DrawBookletGuides(nup *NUp, w io.Writer) FontMap {
 
 	switch nup.N() {
 	case 2:
-		// Draw horizontal folding line.
 		fmt.Fprint(w, "[3] 0 d ")
+		if nup.Grid.Width == 2 {
+			// Draw vertical folding line.
+			draw.DrawLineSimple(w, width/2, 0, width/2, height)
+			drawGuideLineLabel(w, width/2-23, 20, "Fold here", mb, fm, 90)
+			break
+		}
+		// Draw horizontal folding line.
 		draw.DrawLineSimple(w, 0, height/2, width, height/2)
 		drawGuideLineLabel(w, 1, height/2+2, "Fold here", mb, fm, 0)
 	case 4:
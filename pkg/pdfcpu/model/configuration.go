@@ -117,6 +117,9 @@ const (
 	EXPORTFORMFIELDS
 	FILLFORMFIELDS
 	MULTIFILLFORMFIELDS
+	FLATTENFORM
+	ADDFORMFIELDS
+	RENAMEFORMFIELDS
 	ENCRYPT
 	DECRYPT
 	CHANGEUPW
@@ -128,8 +131,91 @@ const (
 	POSTER
 	NDOWN
 	CUT
+	REPAIR
+	REGENERATEAPPEARANCES
+	EXTRACTTEXT
+	LISTPAGESTATS
+	AUDITATTACHMENTS
+	INSERTPAGESFROM
+	ADDALTERNATEIMAGE
+	REORDERPAGES
+	LISTACTIONS
+	SETACTIONS
+	REMOVEACTIONS
+	REMOVEHIDDENLAYERS
+	INHERITPAGEATTRS
+	LISTPAGETEMPLATES
+	ADDPAGETEMPLATES
+	REMOVEPAGETEMPLATES
+	SPAWNPAGETEMPLATES
+	RESTYLELINKS
+	LISTARTICLES
+	ADDARTICLES
+	REMOVEARTICLES
+	ANALYZEJAVASCRIPT
+	LISTJAVASCRIPT
+	REMOVEJAVASCRIPT
+	REPLACEJAVASCRIPT
+	LISTXFA
+	EXTRACTXFA
+	REMOVEXFA
+	SETFORMFIELDACCESS
+	SETSIGNATUREFIELDLOCK
+	SIGN
+	VALIDATESIGNATURES
 )
 
+// FieldCollisionMode specifies how merging handles form fields of different files sharing the same name.
+type FieldCollisionMode int
+
+const (
+	// FieldCollisionGroup groups the form fields of each merged file under a new parent field.
+	FieldCollisionGroup FieldCollisionMode = iota
+
+	// FieldCollisionRename renames colliding form fields by appending a suffix eg. "name_2".
+	FieldCollisionRename
+
+	// FieldCollisionMerge merges colliding form fields into one field whose widgets share a single value.
+	FieldCollisionMerge
+
+	// FieldCollisionError aborts merging on colliding form fields.
+	FieldCollisionError
+)
+
+// DuplicateKeyMode specifies how reading handles dict entries whose key is already in use.
+type DuplicateKeyMode int
+
+const (
+	// DuplicateKeyKeepFirst ignores subsequent entries for a key.
+	DuplicateKeyKeepFirst DuplicateKeyMode = iota
+
+	// DuplicateKeyKeepLast lets subsequent entries for a key replace earlier ones.
+	DuplicateKeyKeepLast
+
+	// DuplicateKeyError aborts reading on duplicate keys.
+	DuplicateKeyError
+)
+
+// FontLicensePolicy specifies how embedding a font is handled whose fsType embedding permissions forbid it.
+type FontLicensePolicy int
+
+const (
+	// FontLicenseWarn embeds the font and logs a warning.
+	FontLicenseWarn FontLicensePolicy = iota
+
+	// FontLicenseIgnore embeds the font regardless of its license.
+	FontLicenseIgnore
+
+	// FontLicenseRefuse aborts the operation.
+	FontLicenseRefuse
+)
+
+// PasswordProvider returns the user and owner password to use for opening the encrypted file fileName,
+// which is empty when reading from a stream other than a file.
+// It is invoked for each failed attempt to open the file, attempt starts at 1.
+// Returning ok == false gives up and the read fails with the last password error.
+type PasswordProvider func(fileName string, attempt int) (userPW, ownerPW string, ok bool)
+
 // Configuration of a Context.
 type Configuration struct {
 	// Location of corresponding config.yml
@@ -144,6 +230,10 @@ type Configuration struct {
 	// Enables decoding of all streams (fontfiles, images..) for logging purposes.
 	DecodeAllStreams bool
 
+	// Defers parsing of objects until first access instead of loading all objects on read.
+	// The io.ReadSeeker read from has to remain accessible for the lifetime of the context.
+	LazyLoading bool
+
 	// Validate against ISO-32000: strict or relaxed.
 	ValidationMode int
 
@@ -162,6 +252,17 @@ type Configuration struct {
 	// Switches between xRefSection (<=V1.4) and objectStream/xRefStream (>=V1.5) writing.
 	WriteXRefStream bool
 
+	// Writes hybrid-reference files: a classic xref section readable by pre V1.5 readers
+	// plus a cross reference stream (trailer entry "XRefStm") for objects compressed into object streams.
+	// Page tree objects are not compressed in order to stay accessible to pre V1.5 readers.
+	// Requires WriteXRefStream.
+	WriteHybridXref bool
+
+	// Writes linearized files ("Fast Web View") allowing viewers to display the first page before the file has been loaded completely.
+	// Linearized files use cross reference tables, WriteObjectStream, WriteXRefStream and WriteHybridXref are ignored.
+	// Combines with encryption and optimization within the same write.
+	WriteLinearized bool
+
 	// Turns on stats collection.
 	// TODO Decision - unused.
 	CollectStats bool
@@ -177,6 +278,9 @@ type Configuration struct {
 	OwnerPW    string
 	OwnerPWNew *string
 
+	// Optional source of passwords for encrypted files whenever UserPW and OwnerPW are not sufficient.
+	PasswordProvider PasswordProvider
+
 	// EncryptUsingAES ensures AES encryption.
 	// true: AES encryption
 	// false: RC4 encryption.
@@ -185,6 +289,10 @@ type Configuration struct {
 	// AES:40,128,256 RC4:40,128
 	EncryptKeyLength int
 
+	// Lets WriteObjectStream and WriteXRefStream apply to encrypted files read from a cross reference table.
+	// By default these files keep using a cross reference table.
+	EncryptUsingObjectStreams bool
+
 	// Supplied user access permissions, see Table 22.
 	Permissions int16
 
@@ -197,6 +305,12 @@ type Configuration struct {
 	// Timestamp format.
 	TimestampFormat string
 
+	// Language of month and day names in timestamps eg. "de", defaults to English.
+	TimestampLocale string
+
+	// IANA time zone name for timestamps eg. "Europe/Vienna", defaults to local time.
+	TimestampTimeZone string
+
 	// Date format.
 	DateFormat string
 
@@ -206,8 +320,44 @@ type Configuration struct {
 	// Optimize duplicate content streams across pages.
 	OptimizeDuplicateContentStreams bool
 
+	// Optimize rasterizes mesh shadings made up of an excessive number of triangles or patches.
+	RasterizeShadings bool
+
+	// Optimize simplifies vector paths by reducing coordinate precision, joining collinear segments and removing duplicate paths.
+	SimplifyPaths bool
+
+	// Optimize inlines form XObjects used only once into the content stream invoking them.
+	InlineFormXObjects bool
+
+	// Optimize removes alternate images, eg. high resolution print versions of images.
+	StripAlternateImages bool
+
+	// Optimize collapses identical font programs, ICC profiles and images, eg. across merged files.
+	DeduplicateStreams bool
+
 	// Merge creates bookmarks
 	CreateBookmarks bool
+
+	// Merge handling of form fields sharing the same name.
+	FormFieldCollision FieldCollisionMode
+
+	// Merge inserts a divider page showing the file name in front of each merged file.
+	DividerPages bool
+
+	// Optional text rendered on divider pages below the file name.
+	DividerPageText string
+
+	// Merge inserts table of contents pages listing and linking the merged files, requires CreateBookmarks.
+	TOCPage bool
+
+	// Handling of dict entries whose key is already in use.
+	DuplicateKeys DuplicateKeyMode
+
+	// Handling of user fonts whose license forbids embedding or subsetting.
+	FontLicensePolicy FontLicensePolicy
+
+	// Form filling records previous and new field values into the document's XMP metadata.
+	FormAuditTrail bool
 }
 
 // ConfigPath defines the location of pdfcpu's configuration directory.
@@ -277,12 +427,26 @@ func newDefaultConfiguration() *Configuration {
 		WriteXRefStream:                 true,
 		EncryptUsingAES:                 true,
 		EncryptKeyLength:                256,
+		EncryptUsingObjectStreams:       false,
 		Permissions:                     PermissionsNone,
 		TimestampFormat:                 "2006-01-02 15:04",
+		TimestampLocale:                 "en",
 		DateFormat:                      "2006-01-02",
 		HeaderBufSize:                   100,
 		OptimizeDuplicateContentStreams: false,
+		RasterizeShadings:               false,
+		SimplifyPaths:                   false,
+		InlineFormXObjects:              false,
+		StripAlternateImages:            false,
+		DeduplicateStreams:              false,
 		CreateBookmarks:                 true,
+		FormFieldCollision:              FieldCollisionGroup,
+		DividerPages:                    false,
+		DividerPageText:                 "",
+		TOCPage:                         false,
+		DuplicateKeys:                   DuplicateKeyKeepFirst,
+		FontLicensePolicy:               FontLicenseWarn,
+		FormAuditTrail:                  false,
 	}
 }
 
@@ -348,10 +512,25 @@ func (c Configuration) String() string {
 		"Permissions:       %d\n"+
 		"Unit :             %s\n"+
 		"TimestampFormat:	%s\n"+
+		"TimestampLocale:	%s\n"+
+		"TimestampTimeZone:	%s\n"+
 		"DateFormat:		%s\n"+
 		"HeaderBufSize:		%d\n"+
 		"OptimizeDuplicateContentStreams %t\n"+
-		"CreateBookmarks %t\n",
+		"RasterizeShadings %t\n"+
+		"SimplifyPaths %t\n"+
+		"InlineFormXObjects %t\n"+
+		"StripAlternateImages %t\n"+
+		"DeduplicateStreams %t\n"+
+		"CreateBookmarks %t\n"+
+		"FormFieldCollision %s\n"+
+		"DividerPages %t\n"+
+		"DividerPageText %s\n"+
+		"TOCPage %t\n"+
+		"DuplicateKeys %s\n"+
+		"FontLicensePolicy %s\n"+
+		"FormAuditTrail %t\n"+
+		"EncryptUsingObjectStreams %t\n",
 		path,
 		c.CheckFileNameExt,
 		c.Reader15,
@@ -365,10 +544,25 @@ func (c Configuration) String() string {
 		c.Permissions,
 		c.UnitString(),
 		c.TimestampFormat,
+		c.TimestampLocale,
+		c.TimestampTimeZone,
 		c.DateFormat,
 		c.HeaderBufSize,
 		c.OptimizeDuplicateContentStreams,
+		c.RasterizeShadings,
+		c.SimplifyPaths,
+		c.InlineFormXObjects,
+		c.StripAlternateImages,
+		c.DeduplicateStreams,
 		c.CreateBookmarks,
+		c.FormFieldCollisionString(),
+		c.DividerPages,
+		c.DividerPageText,
+		c.TOCPage,
+		c.DuplicateKeysString(),
+		c.FontLicensePolicyString(),
+		c.FormAuditTrail,
+		c.EncryptUsingObjectStreams,
 	)
 }
 
@@ -386,6 +580,41 @@ func (c *Configuration) EolString() string {
 	return s
 }
 
+// FormFieldCollisionString returns a string rep for the form field collision mode in effect.
+func (c *Configuration) FormFieldCollisionString() string {
+	switch c.FormFieldCollision {
+	case FieldCollisionRename:
+		return "rename"
+	case FieldCollisionMerge:
+		return "merge"
+	case FieldCollisionError:
+		return "error"
+	}
+	return "group"
+}
+
+// DuplicateKeysString returns a string rep for the duplicate key handling in effect.
+func (c *Configuration) DuplicateKeysString() string {
+	switch c.DuplicateKeys {
+	case DuplicateKeyKeepLast:
+		return "keepLast"
+	case DuplicateKeyError:
+		return "error"
+	}
+	return "keepFirst"
+}
+
+// FontLicensePolicyString returns a string rep for the font license policy in effect.
+func (c *Configuration) FontLicensePolicyString() string {
+	switch c.FontLicensePolicy {
+	case FontLicenseIgnore:
+		return "ignore"
+	case FontLicenseRefuse:
+		return "refuse"
+	}
+	return "warn"
+}
+
 // ValidationModeString returns a string rep for the validation mode in effect.
 func (c *Configuration) ValidationModeString() string {
 	if c.ValidationMode == ValidationStrict {
@@ -32,11 +32,12 @@ import (
 type Context struct {
 	*Configuration
 	*XRefTable
-	Read         *ReadContext
-	Optimize     *OptimizationContext
-	Write        *WriteContext
-	WritingPages bool // true, when writing page dicts.
-	Dest         bool // true when writing a destination within a page.
+	Read           *ReadContext
+	Optimize       *OptimizationContext
+	Write          *WriteContext
+	WritingPages   bool // true, when writing page dicts.
+	Dest           bool // true when writing a destination within a page.
+	EncryptOnWrite bool // true, when encryption has been requested by a preceding operation.
 }
 
 // NewContext initializes a new Context.
@@ -59,6 +60,7 @@ func NewContext(rs io.ReadSeeker, conf *Configuration) (*Context, error) {
 		NewWriteContext(conf.Eol),
 		false,
 		false,
+		false,
 	}
 
 	return ctx, nil
@@ -69,6 +71,50 @@ func (ctx *Context) ResetWriteContext() {
 	ctx.Write = NewWriteContext(ctx.Write.Eol)
 }
 
+// ResetOptimizationContext prepares an existing Context for another optimization pass.
+func (ctx *Context) ResetOptimizationContext() {
+	ctx.Optimize = newOptimizationContext()
+	ctx.Optimized = false
+}
+
+// Freeze prepares ctx for serving read-only operations like info, text extraction or page extraction
+// concurrently from multiple goroutines.
+//
+// Freeze resolves everything otherwise loaded and cached on first access:
+// objects deferred by lazy loading, the catalog, the page count and the name trees.
+// Afterwards dereferencing no longer modifies ctx and adding objects fails with ErrFrozen.
+//
+// Freeze itself is not safe for concurrent use and must be called before ctx is shared.
+// Operations modifying existing objects, like stamping or form filling, must not be used on a frozen context.
+func (ctx *Context) Freeze() error {
+	xRefTable := ctx.XRefTable
+	if xRefTable.Frozen {
+		return nil
+	}
+
+	if err := xRefTable.LoadAll(); err != nil {
+		return err
+	}
+
+	if _, err := xRefTable.Catalog(); err != nil {
+		return err
+	}
+
+	if err := xRefTable.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"Dests", "AP", "JavaScript", "Pages", "Templates", "IDS", "URLS", "EmbeddedFiles", "AlternatePresentations", "Renditions"} {
+		if err := xRefTable.LocateNameTree(name, false); err != nil {
+			return err
+		}
+	}
+
+	xRefTable.Frozen = true
+
+	return nil
+}
+
 func (rc *ReadContext) logReadContext(logStr *[]string) {
 	if rc.UsingObjectStreams {
 		*logStr = append(*logStr, "using object streams\n")
@@ -194,6 +240,8 @@ type ReadContext struct {
 	ObjectStreams       types.IntSet  // All object numbers of any object streams found which need to be decoded.
 	UsingXRefStreams    bool          // File is using xref streams.
 	XRefStreams         types.IntSet  // All object numbers of any xref streams found.
+	UserPW              string        // User password used for decryption.
+	OwnerPW             string        // Owner password used for decryption.
 }
 
 func newReadContext(rs io.ReadSeeker) (*ReadContext, error) {
@@ -607,6 +655,8 @@ type WriteContext struct {
 	Increment           bool          // Write context as PDF increment.
 	ObjNrs              []int         // Increment candidate object numbers.
 	OffsetPrevXRef      *int64        // Increment trailer entry "Prev".
+	OffsetXRefStm       *int64        // Hybrid trailer entry "XRefStm".
+	Streamed            bool          // Header and some objects have already been written, see pdfcpu.BeginWrite.
 }
 
 // NewWriteContext returns a new WriteContext.
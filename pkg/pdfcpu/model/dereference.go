@@ -31,12 +31,19 @@ func (xRefTable *XRefTable) indRefToObject(ir *types.IndirectRef) (types.Object,
 	// 7.3.10
 	// An indirect reference to an undefined object shall not be considered an error by a conforming reader;
 	// it shall be treated as a reference to the null object.
-	entry, found := xRefTable.FindTableEntryForIndRef(ir)
+	objNr := ir.ObjectNumber.Value()
+	entry, found := xRefTable.Find(objNr)
 	if !found || entry.Free {
 		return nil, nil
 	}
 
-	xRefTable.CurObj = int(ir.ObjectNumber)
+	if err := xRefTable.load(objNr, entry); err != nil {
+		return nil, err
+	}
+
+	if !xRefTable.Frozen {
+		xRefTable.CurObj = int(ir.ObjectNumber)
+	}
 
 	// return dereferenced object
 	return entry.Object, nil
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/color"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Names identifying headers and footers, see Watermark.Name.
+const (
+	HeaderName = "header"
+	FooterName = "footer"
+)
+
+// HFSlots represents the left, center and right slot of a header or footer.
+// Slot text supports the place holders of text stamps eg. %p for the current page number.
+type HFSlots struct {
+	Left, Center, Right string
+}
+
+// Empty returns true if no slot has content.
+func (s HFSlots) Empty() bool {
+	return s.Left == "" && s.Center == "" && s.Right == ""
+}
+
+// HeaderFooter represents running headers and footers.
+type HeaderFooter struct {
+	Header     HFSlots           // header for all pages or for odd pages if EvenHeader is set.
+	Footer     HFSlots           // footer for all pages or for odd pages if EvenFooter is set.
+	EvenHeader *HFSlots          // optional header for even pages.
+	EvenFooter *HFSlots          // optional footer for even pages.
+	FontName   string            // defaults to Helvetica.
+	FontSize   int               // defaults to 10.
+	FillColor  color.SimpleColor // text color, defaults to black.
+	Margin     float64           // distance of slots from the anchor box edges in display unit, 0 derives the margin from the anchor box.
+	PageBox    string            // anchor box, one of media, crop (default), trim, bleed, art.
+	Unit       types.DisplayUnit // display unit.
+}
+
+// DefaultHeaderFooter returns a header/footer configuration with empty slots.
+func DefaultHeaderFooter() *HeaderFooter {
+	return &HeaderFooter{
+		FontName:  "Helvetica",
+		FontSize:  10,
+		FillColor: color.Black,
+	}
+}
+
+// Validate checks hf for content and sane values.
+func (hf HeaderFooter) Validate() error {
+	if hf.Header.Empty() && hf.Footer.Empty() &&
+		(hf.EvenHeader == nil || hf.EvenHeader.Empty()) &&
+		(hf.EvenFooter == nil || hf.EvenFooter.Empty()) {
+		return errors.New("pdfcpu: missing header/footer content")
+	}
+	if hf.FontSize < 0 {
+		return errors.Errorf("pdfcpu: invalid header/footer font size: %d", hf.FontSize)
+	}
+	if hf.Margin < 0 {
+		return errors.Errorf("pdfcpu: invalid header/footer margin: %.2f", hf.Margin)
+	}
+	switch hf.PageBox {
+	case "", "media", "crop", "trim", "bleed", "art":
+	default:
+		return errors.Errorf("pdfcpu: invalid header/footer page box: %s", hf.PageBox)
+	}
+	return nil
+}
+
+// Slots returns the header and footer slots for pageNr.
+func (hf HeaderFooter) Slots(pageNr int) (header, footer HFSlots) {
+	header, footer = hf.Header, hf.Footer
+	if pageNr%2 == 0 {
+		if hf.EvenHeader != nil {
+			header = *hf.EvenHeader
+		}
+		if hf.EvenFooter != nil {
+			footer = *hf.EvenFooter
+		}
+	}
+	return header, footer
+}
+
+// MarginFor returns the effective margin in points for a page with anchor box r.
+// Unless configured the margin is 5% of the shorter anchor box side.
+func (hf HeaderFooter) MarginFor(r *types.Rectangle) float64 {
+	if hf.Margin > 0 {
+		return types.ToUserSpace(hf.Margin, hf.Unit)
+	}
+	m := r.Width()
+	if r.Height() < m {
+		m = r.Height()
+	}
+	return m * .05
+}
@@ -52,6 +52,7 @@ type Image struct {
 	HasSMask    bool   // "SMask"
 	Thumb       bool   // "Thumbnail"
 	Interpol    bool   // "Interpolate"
+	Alternates  int    // number of "Alternates"
 	Size        int64  // "Length"
 	Filter      string // filter pipeline
 	DecodeParms string
@@ -202,28 +203,6 @@ func writeRGBAImageBuf(img image.Image) []byte {
 	return buf
 }
 
-func writeRGBA64ImageBuf(img image.Image) []byte {
-	w := img.Bounds().Dx()
-	h := img.Bounds().Dy()
-	i := 0
-	buf := make([]byte, w*h*6)
-
-	for y := 0; y < h; y++ {
-		for x := 0; x < w; x++ {
-			c := img.At(x, y).(color.RGBA64)
-			buf[i] = uint8(c.R >> 8)
-			buf[i+1] = uint8(c.R & 0x00FF)
-			buf[i+2] = uint8(c.G >> 8)
-			buf[i+3] = uint8(c.G & 0x00FF)
-			buf[i+4] = uint8(c.B >> 8)
-			buf[i+5] = uint8(c.B & 0x00FF)
-			i += 6
-		}
-	}
-
-	return buf
-}
-
 // func writeYCbCrToRGBAImageBuf(img image.Image) []byte {
 // 	w := img.Bounds().Dx()
 // 	h := img.Bounds().Dy()
@@ -380,6 +359,20 @@ func convertToRGBA(img image.Image) *image.RGBA {
 	return m
 }
 
+func convertToNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	m := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(m, m.Bounds(), img, b.Min, draw.Src)
+	return m
+}
+
+func convertToNRGBA64(img image.Image) *image.NRGBA64 {
+	b := img.Bounds()
+	m := image.NewNRGBA64(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(m, m.Bounds(), img, b.Min, draw.Src)
+	return m
+}
+
 func convertToGray(img image.Image) *image.Gray {
 	b := img.Bounds()
 	m := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
@@ -460,16 +453,18 @@ func createImageBuf(xRefTable *XRefTable, img image.Image, format string) ([]byt
 	case *image.RGBA:
 		// A 32-bit alpha-premultiplied color, having 8 bits for each of red, green, blue and alpha.
 		// An alpha-premultiplied color component C has been scaled by alpha (A), so it has valid values 0 <= C <= A.
+		// Undo the premultiplication and preserve alpha as soft mask.
 		cs = DeviceRGBCS
 		bpc = 8
-		buf = writeRGBAImageBuf(img)
+		buf, sm = writeNRGBAImageBuf(xRefTable, convertToNRGBA(img))
 
 	case *image.RGBA64:
 		// A 64-bit alpha-premultiplied color, having 16 bits for each of red, green, blue and alpha.
 		// An alpha-premultiplied color component C has been scaled by alpha (A), so it has valid values 0 <= C <= A.
+		// Undo the premultiplication and preserve alpha as soft mask.
 		cs = DeviceRGBCS
 		bpc = 16
-		buf = writeRGBA64ImageBuf(img)
+		buf, sm = writeNRGBA64ImageBuf(xRefTable, convertToNRGBA64(img))
 
 	case *image.NRGBA:
 		// Non-alpha-premultiplied 32-bit color.
@@ -513,13 +508,17 @@ func createImageBuf(xRefTable *XRefTable, img image.Image, format string) ([]byt
 		buf = writeRGBAImageBuf(convertToRGBA(img))
 
 	case *image.NYCbCrA:
-		return buf, sm, bpc, cs, errors.New("pdfcpu: unsupported image type: NYCbCrA")
+		// YCbCr color with a separate alpha channel.
+		cs = DeviceRGBCS
+		bpc = 8
+		buf, sm = writeNRGBAImageBuf(xRefTable, convertToNRGBA(img))
 
 	case *image.Paletted:
 		// In-memory image of uint8 indices into a given palette.
+		// Transparent palette entries end up in the soft mask.
 		cs = DeviceRGBCS
 		bpc = 8
-		buf = writeRGBAImageBuf(convertToRGBA(img))
+		buf, sm = writeNRGBAImageBuf(xRefTable, convertToNRGBA(img))
 
 	default:
 		return buf, sm, bpc, cs, errors.Errorf("pdfcpu: unsupported image type: %T", img)
@@ -547,8 +546,25 @@ func createDCTImageObjectForJPEG(xRefTable *XRefTable, c image.Config, bb bytes.
 	}
 
 	sd, err := CreateDCTImageObject(xRefTable, bb.Bytes(), c.Width, c.Height, 8, cs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// Embed the ICC profile of the JPEG to preserve its colors.
+	icc, err := jpegICCProfile(bb.Bytes())
+	if err != nil || icc == nil {
+		return sd, c.Width, c.Height, nil
+	}
+
+	arr, err := iccColorSpace(xRefTable, icc, cs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if arr != nil {
+		sd.Update("ColorSpace", arr)
+	}
 
-	return sd, c.Width, c.Height, err
+	return sd, c.Width, c.Height, nil
 }
 
 // CreateImageStreamDict returns a stream dict for image data represented by r and applies optional filters.
@@ -602,6 +618,71 @@ func CreateImageStreamDict(xRefTable *XRefTable, r io.Reader, gray, sepia bool)
 	return createImageDict(xRefTable, imgBuf, softMask, w, h, bpc, format, cs)
 }
 
+func imageMaskBuf(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	rowLen := (w + 7) / 8
+	buf := make([]byte, rowLen*h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			switch {
+			case c.A == 0x00, c.A == 0xFF && c.R == 0xFF && c.G == 0xFF && c.B == 0xFF:
+				// Masked out: leaves the background untouched.
+				buf[y*rowLen+x/8] |= 0x80 >> (x % 8)
+			case c.A == 0xFF && c.R == 0x00 && c.G == 0x00 && c.B == 0x00:
+				// Painted using the current fill color.
+			default:
+				return nil, errors.New("pdfcpu: image mask needs a 1-bit image (black, white or transparent pixels only)")
+			}
+		}
+	}
+
+	return buf, nil
+}
+
+// CreateImageMaskStreamDict returns a stencil mask stream dict for the 1-bit image represented by r.
+// Black pixels get painted using the current fill color,
+// white and transparent pixels leave the background untouched.
+func CreateImageMaskStreamDict(xRefTable *XRefTable, r io.Reader) (*types.StreamDict, int, int, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	buf, err := imageMaskBuf(img)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	sd, _ := xRefTable.NewStreamDictForBuf(buf)
+	sd.InsertName("Type", "XObject")
+	sd.InsertName("Subtype", "Image")
+	sd.InsertInt("Width", w)
+	sd.InsertInt("Height", h)
+	sd.InsertInt("BitsPerComponent", 1)
+	sd.Insert("ImageMask", types.Boolean(true))
+
+	if err := sd.Encode(); err != nil {
+		return nil, 0, 0, err
+	}
+
+	return sd, w, h, nil
+}
+
+// CreateImageMaskResource creates a new stencil mask XObject for the 1-bit image represented by r.
+func CreateImageMaskResource(xRefTable *XRefTable, r io.Reader) (*types.IndirectRef, int, int, error) {
+	sd, w, h, err := CreateImageMaskStreamDict(xRefTable, r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	indRef, err := xRefTable.IndRefForNewObject(*sd)
+	return indRef, w, h, err
+}
+
 // CreateImageResource creates a new XObject for given image data represented by r and applies optional filters.
 func CreateImageResource(xRefTable *XRefTable, r io.Reader, gray, sepia bool) (*types.IndirectRef, int, int, error) {
 	sd, w, h, err := CreateImageStreamDict(xRefTable, r, gray, sepia)
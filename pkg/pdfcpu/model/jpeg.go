@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+var errNoJPEG = errors.New("pdfcpu: not a JPEG")
+
+const (
+	jpegSOI = 0xD8 // start of image
+	jpegEOI = 0xD9 // end of image
+	jpegSOS = 0xDA // start of scan
+	jpegAPP = 0xE0 // APP0
+)
+
+// jpegAppSegments calls f for the payload of each APPn segment preceding the image data in r
+// until f returns false.
+func jpegAppSegments(r io.Reader, f func(n int, data []byte) bool) error {
+	br := bufio.NewReader(r)
+
+	var b [2]byte
+	if _, err := io.ReadFull(br, b[:]); err != nil || b[0] != 0xFF || b[1] != jpegSOI {
+		return errNoJPEG
+	}
+
+	for {
+		m, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if m != 0xFF {
+			return errors.New("pdfcpu: corrupt JPEG marker")
+		}
+		// Skip fill bytes.
+		for m == 0xFF {
+			if m, err = br.ReadByte(); err != nil {
+				return err
+			}
+		}
+
+		if m == jpegSOS || m == jpegEOI {
+			return nil
+		}
+
+		if m == 0x01 || m >= 0xD0 && m <= 0xD7 {
+			// Standalone markers: TEM, RSTn
+			continue
+		}
+
+		if _, err := io.ReadFull(br, b[:]); err != nil {
+			return err
+		}
+		l := int(binary.BigEndian.Uint16(b[:])) - 2
+		if l < 0 {
+			return errors.New("pdfcpu: corrupt JPEG segment")
+		}
+
+		if m < jpegAPP || m > jpegAPP+0x0F {
+			if _, err := br.Discard(l); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data := make([]byte, l)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return err
+		}
+		if !f(int(m-jpegAPP), data) {
+			return nil
+		}
+	}
+}
+
+// exifOrientation returns the orientation tag of IFD0 of the Exif APP1 payload data.
+func exifOrientation(data []byte) int {
+	const exifHeader = "Exif\x00\x00"
+	if !bytes.HasPrefix(data, []byte(exifHeader)) {
+		return 0
+	}
+	tiff := data[len(exifHeader):]
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var bo binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 0
+	}
+
+	off := int(bo.Uint32(tiff[4:]))
+	if off < 8 || off+2 > len(tiff) {
+		return 0
+	}
+	n := int(bo.Uint16(tiff[off:]))
+
+	for i := 0; i < n; i++ {
+		e := off + 2 + i*12
+		if e+12 > len(tiff) {
+			return 0
+		}
+		// Orientation is a SHORT.
+		if bo.Uint16(tiff[e:]) == 0x0112 && bo.Uint16(tiff[e+2:]) == 3 {
+			return int(bo.Uint16(tiff[e+8:]))
+		}
+	}
+
+	return 0
+}
+
+// JPEGOrientation returns the Exif orientation of the JPEG image represented by r (1..8).
+// 1 means upright and is also returned for images without orientation tag and for non JPEG images.
+func JPEGOrientation(r io.Reader) (int, error) {
+	o := 1
+	err := jpegAppSegments(r, func(n int, data []byte) bool {
+		if n != 1 {
+			return true
+		}
+		if i := exifOrientation(data); i >= 1 && i <= 8 {
+			o = i
+			return false
+		}
+		return true
+	})
+	if err == errNoJPEG {
+		err = nil
+	}
+	return o, err
+}
+
+// jpegICCProfile returns the ICC profile embedded into the APP2 segments of the JPEG image bb.
+func jpegICCProfile(bb []byte) ([]byte, error) {
+	const iccHeader = "ICC_PROFILE\x00"
+
+	var (
+		chunks = map[int][]byte{}
+		count  int
+	)
+
+	err := jpegAppSegments(bytes.NewReader(bb), func(n int, data []byte) bool {
+		if n != 2 || !bytes.HasPrefix(data, []byte(iccHeader)) || len(data) < len(iccHeader)+2 {
+			return true
+		}
+		seq, cnt := int(data[len(iccHeader)]), int(data[len(iccHeader)+1])
+		count = cnt
+		chunks[seq] = data[len(iccHeader)+2:]
+		return true
+	})
+	if err != nil || count == 0 {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for i := 1; i <= count; i++ {
+		c, ok := chunks[i]
+		if !ok {
+			// Incomplete profile.
+			return nil, nil
+		}
+		buf.Write(c)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// iccColorSpace returns an ICCBased color space for the ICC profile bb if bb matches the device color space cs.
+func iccColorSpace(xRefTable *XRefTable, bb []byte, cs string) (types.Array, error) {
+	n := map[string]int{DeviceGrayCS: 1, DeviceRGBCS: 3, DeviceCMYKCS: 4}[cs]
+	sig := map[string]string{DeviceGrayCS: "GRAY", DeviceRGBCS: "RGB ", DeviceCMYKCS: "CMYK"}[cs]
+
+	// Ignore invalid profiles and profiles not matching the image data.
+	if len(bb) < 132 || string(bb[36:40]) != "acsp" || string(bb[16:20]) != sig {
+		return nil, nil
+	}
+
+	sd, err := xRefTable.NewStreamDictForBuf(bb)
+	if err != nil {
+		return nil, err
+	}
+	sd.InsertInt("N", n)
+	sd.InsertName("Alternate", cs)
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	ir, err := xRefTable.IndRefForNewObject(*sd)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.Array{types.Name(ICCBasedCS), *ir}, nil
+}
+
+// ExifOrientationMatrix returns the transformation of the unit square
+// rendering an image with Exif orientation o upright: a b c d e f
+func ExifOrientationMatrix(o int) [6]float64 {
+	switch o {
+	case 2: // mirrored horizontally
+		return [6]float64{-1, 0, 0, 1, 1, 0}
+	case 3: // rotated by 180 degrees
+		return [6]float64{-1, 0, 0, -1, 1, 1}
+	case 4: // mirrored vertically
+		return [6]float64{1, 0, 0, -1, 0, 1}
+	case 5: // transposed
+		return [6]float64{0, -1, -1, 0, 1, 1}
+	case 6: // rotated by 90 degrees clockwise
+		return [6]float64{0, -1, 1, 0, 0, 1}
+	case 7: // transversed
+		return [6]float64{0, 1, 1, 0, 0, 0}
+	case 8: // rotated by 90 degrees counterclockwise
+		return [6]float64{0, 1, -1, 0, 1, 0}
+	}
+	return [6]float64{1, 0, 0, 1, 0, 0}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+const (
+	cropMarkGap    = 2.  // Distance between a crop mark and the bleed area.
+	cropMarkMaxLen = 18. // Maximum length of a crop mark.
+	regMarkMaxRad  = 6.  // Maximum radius of a registration mark.
+)
+
+// Marks are stroked in registration color which prints on all separations.
+const registrationColor = "1 1 1 1 K"
+
+func drawLine(w io.Writer, x1, y1, x2, y2 float64) {
+	fmt.Fprintf(w, "%.2f %.2f m %.2f %.2f l ", x1, y1, x2, y2)
+}
+
+// DrawCropMarks draws crop marks into the margin at the corners of the trim box of each grid cell.
+// Crop marks keep clear of the bleed.
+func DrawCropMarks(nup *NUp, w io.Writer) {
+	off := nup.Bleed + cropMarkGap
+	l := math.Min(nup.Margin-off, cropMarkMaxLen)
+	if l <= 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "q [] 0 d 0.25 w %s ", registrationColor)
+
+	for _, r := range nup.RectsForGrid() {
+		t := r.CroppedCopy(nup.Margin)
+		for _, x := range []float64{t.LL.X, t.UR.X} {
+			dx := 1.
+			if x == t.LL.X {
+				dx = -1
+			}
+			for _, y := range []float64{t.LL.Y, t.UR.Y} {
+				dy := 1.
+				if y == t.LL.Y {
+					dy = -1
+				}
+				drawLine(w, x+dx*off, y, x+dx*(off+l), y)
+				drawLine(w, x, y+dy*off, x, y+dy*(off+l))
+			}
+		}
+	}
+
+	fmt.Fprint(w, "S Q ")
+}
+
+// gaps returns the parts of [0,l] not covered by any of the intervals ii.
+func gaps(ii [][2]float64, l float64) [][2]float64 {
+	sort.Slice(ii, func(i, j int) bool { return ii[i][0] < ii[j][0] })
+	var gg [][2]float64
+	from := 0.
+	for _, i := range ii {
+		if i[0] > from {
+			gg = append(gg, [2]float64{from, i[0]})
+		}
+		if i[1] > from {
+			from = i[1]
+		}
+	}
+	if from < l {
+		gg = append(gg, [2]float64{from, l})
+	}
+	return gg
+}
+
+// DrawTrimGuides draws cut lines along the edges of the trim box of each grid cell.
+// Trim guides run across the whole sheet but keep clear of all cells including their bleed.
+func DrawTrimGuides(nup *NUp, w io.Writer) {
+	b := nup.Bleed
+
+	var tt []*types.Rectangle
+	for _, r := range nup.RectsForGrid() {
+		tt = append(tt, r.CroppedCopy(nup.Margin))
+	}
+
+	fmt.Fprintf(w, "q [] 0 d 0.25 w %s ", registrationColor)
+
+	xx, yy := map[float64]bool{}, map[float64]bool{}
+
+	for _, t := range tt {
+		for _, x := range []float64{t.LL.X, t.UR.X} {
+			if xx[x] {
+				continue
+			}
+			xx[x] = true
+			var ii [][2]float64
+			for _, t1 := range tt {
+				if x >= t1.LL.X-b && x <= t1.UR.X+b {
+					ii = append(ii, [2]float64{t1.LL.Y - b, t1.UR.Y + b})
+				}
+			}
+			for _, g := range gaps(ii, nup.PageDim.Height) {
+				drawLine(w, x, g[0], x, g[1])
+			}
+		}
+		for _, y := range []float64{t.LL.Y, t.UR.Y} {
+			if yy[y] {
+				continue
+			}
+			yy[y] = true
+			var ii [][2]float64
+			for _, t1 := range tt {
+				if y >= t1.LL.Y-b && y <= t1.UR.Y+b {
+					ii = append(ii, [2]float64{t1.LL.X - b, t1.UR.X + b})
+				}
+			}
+			for _, g := range gaps(ii, nup.PageDim.Width) {
+				drawLine(w, g[0], y, g[1], y)
+			}
+		}
+	}
+
+	fmt.Fprint(w, "S Q ")
+}
+
+func drawRegistrationMark(w io.Writer, x, y, r float64) {
+	// Approximate the circle by four Bézier curves.
+	k := 0.5523 * r / 1.5
+	rc := r / 1.5
+	fmt.Fprintf(w, "%.2f %.2f m ", x+rc, y)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x+rc, y+k, x+k, y+rc, x, y+rc)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x-k, y+rc, x-rc, y+k, x-rc, y)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x-rc, y-k, x-k, y-rc, x, y-rc)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x+k, y-rc, x+rc, y-k, x+rc, y)
+	drawLine(w, x-r, y, x+r, y)
+	drawLine(w, x, y-r, x, y+r)
+}
+
+// DrawRegistrationMarks draws registration marks into the margin at the center of each sheet edge.
+func DrawRegistrationMarks(nup *NUp, w io.Writer) {
+	d := nup.Margin / 2
+	r := math.Min(d-1, regMarkMaxRad)
+	if r <= 0 {
+		return
+	}
+
+	mb := types.RectForDim(nup.PageDim.Width, nup.PageDim.Height)
+
+	fmt.Fprintf(w, "q [] 0 d 0.25 w %s ", registrationColor)
+	drawRegistrationMark(w, mb.Center().X, mb.UR.Y-d, r)
+	drawRegistrationMark(w, mb.Center().X, mb.LL.Y+d, r)
+	drawRegistrationMark(w, mb.LL.X+d, mb.Center().Y, r)
+	drawRegistrationMark(w, mb.UR.X-d, mb.Center().Y, r)
+	fmt.Fprint(w, "S Q ")
+}
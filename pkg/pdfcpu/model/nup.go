@@ -48,6 +48,18 @@ func (o orientation) String() string {
 	case DownLeft:
 		return "down left"
 
+	case RightUp:
+		return "right up"
+
+	case UpRight:
+		return "up right"
+
+	case LeftUp:
+		return "left up"
+
+	case UpLeft:
+		return "up left"
+
 	}
 
 	return ""
@@ -59,39 +71,89 @@ const (
 	DownRight
 	LeftDown
 	DownLeft
+	RightUp
+	UpRight
+	LeftUp
+	UpLeft
 )
 
+// columnMajor returns true if o fills columns before rows.
+func (o orientation) columnMajor() bool {
+	return o == DownRight || o == DownLeft || o == UpRight || o == UpLeft
+}
+
+// rightToLeft returns true if o fills rows from right to left.
+func (o orientation) rightToLeft() bool {
+	return o == LeftDown || o == DownLeft || o == LeftUp || o == UpLeft
+}
+
+// bottomUp returns true if o fills columns from bottom to top.
+func (o orientation) bottomUp() bool {
+	return o == RightUp || o == UpRight || o == LeftUp || o == UpLeft
+}
+
 // NUp represents the command details for the command "NUp".
 type NUp struct {
 	PageDim       *types.Dim         // Page dimensions in display unit.
 	PageSize      string             // Paper size eg. A4L, A4P, A4(=default=A4P), see paperSize.go
 	UserDim       bool               // true if one of dimensions or paperSize provided overriding the default.
-	Orient        orientation        // One of rd(=default),dr,ld,dl
+	Orient        orientation        // One of rd(=default),dr,ld,dl,ru,ur,lu,ul
+	Boustrophedon bool               // Reverse the fill direction of every other row or column.
+	NoAutoRotate  bool               // Preserve the orientation of sources instead of rotating them into the orientation of their cell.
+	AutoOrient    bool               // Choose the sheet orientation per sheet or booklet signature from the aspect ratios of its sources.
+	Align         types.Anchor       // Alignment of sources within their cell, default: center.
+	CutAndStack   bool               // Order sources for collating after cutting the sheets and stacking the piles.
+	StepAndRepeat bool               // Repeat each source across all cells of a sheet eg. for labels and business cards.
+	CropMarks     bool               // Draw crop marks into the margin at the corners of each cell's trim box.
+	RegMarks      bool               // Draw registration marks into the margin at the center of each sheet edge.
+	TrimGuides    bool               // Draw cut lines along the trim boxes of all cells across gutters and margins.
+	Bleed         float64            // Extend n-up content beyond its trim box into the margin.
 	Grid          *types.Dim         // Intra page grid dimensions eg (2,2)
 	PageGrid      bool               // Create a m x n grid of pages for PDF inputfiles only (think "extra page n-Up").
 	ImgInputFile  bool               // Process image or PDF input files.
 	Margin        float64            // Cropbox for n-Up content.
+	SheetMTop     float64            // Outer sheet margin above the grid.
+	SheetMRight   float64            // Outer sheet margin right of the grid.
+	SheetMBot     float64            // Outer sheet margin below the grid.
+	SheetMLeft    float64            // Outer sheet margin left of the grid.
+	HSpacing      float64            // Horizontal gutter between adjacent grid cells.
+	VSpacing      float64            // Vertical gutter between adjacent grid cells.
 	Border        bool               // Draw bounding box.
 	BookletGuides bool               // Draw folding and cutting lines.
 	MultiFolio    bool               // Render booklet as sequence of folios.
 	FolioSize     int                // Booklet multifolio folio size: default: 8
+	Signature     int                // Booklet signature size in pages, overrides FolioSize and implies MultiFolio.
+	Creep         float64            // Booklet creep compensation: shift of the innermost pages of a signature toward the fold.
+	BindingShift  float64            // Booklet shift of all pages away from the fold eg. for a binding margin.
+	Uniform       bool               // Render all sources at a common scale preserving their relative sizes.
+	UniformScale  float64            // Common scale factor in effect, see Uniform.
+	PageScales    map[int]float64    // Additional scale factors for individual source pages, see Uniform.
 	InpUnit       types.DisplayUnit  // input display unit.
 	BgColor       *color.SimpleColor // background color
 }
 
+// NUpInput represents a PDF or image file taking part in a mixed source n-up.
+type NUpInput struct {
+	FileName string   // PDF or image file.
+	Pages    []string // Page selection for PDF files, default: all pages.
+	Scale    float64  // Scale factor relative to the other inputs, default: 1.0
+	DPI      int      // Resolution of image files determining their natural size, default: 72
+}
+
 // DefaultNUpConfig returns the default NUp configuration.
 func DefaultNUpConfig() *NUp {
 	return &NUp{
 		PageSize: "A4",
 		Orient:   RightDown,
+		Align:    types.Center,
 		Margin:   3,
 		Border:   true,
 	}
 }
 
 func (nup NUp) String() string {
-	return fmt.Sprintf("N-Up conf: %s %s, orient=%s, grid=%s, pageGrid=%t, isImage=%t\n",
-		nup.PageSize, *nup.PageDim, nup.Orient, *nup.Grid, nup.PageGrid, nup.ImgInputFile)
+	return fmt.Sprintf("N-Up conf: %s %s, orient=%s, boustrophedon=%t, grid=%s, pageGrid=%t, isImage=%t\n",
+		nup.PageSize, *nup.PageDim, nup.Orient, nup.Boustrophedon, *nup.Grid, nup.PageGrid, nup.ImgInputFile)
 }
 
 // N returns the nUp value.
@@ -99,56 +161,51 @@ func (nup NUp) N() int {
 	return int(nup.Grid.Height * nup.Grid.Width)
 }
 
+// GridSpacing returns the horizontal and vertical space taken by sheet margins and gutters.
+func (nup NUp) GridSpacing() (float64, float64) {
+	cols := nup.Grid.Width
+	rows := nup.Grid.Height
+	return nup.SheetMLeft + nup.SheetMRight + (cols-1)*nup.HSpacing,
+		nup.SheetMTop + nup.SheetMBot + (rows-1)*nup.VSpacing
+}
+
 // RectsForGrid calculates dest rectangles for given grid.
 func (nup NUp) RectsForGrid() []*types.Rectangle {
 	cols := int(nup.Grid.Width)
 	rows := int(nup.Grid.Height)
 
-	maxX := float64(nup.PageDim.Width)
-	maxY := float64(nup.PageDim.Height)
+	dx, dy := nup.GridSpacing()
 
-	gw := maxX / float64(cols)
-	gh := maxY / float64(rows)
+	gw := (float64(nup.PageDim.Width) - dx) / float64(cols)
+	gh := (float64(nup.PageDim.Height) - dy) / float64(rows)
 
-	var llx, lly float64
 	rr := []*types.Rectangle{}
 
-	switch nup.Orient {
+	outer, inner := rows, cols
+	if nup.Orient.columnMajor() {
+		outer, inner = cols, rows
+	}
 
-	case RightDown:
-		for i := rows - 1; i >= 0; i-- {
-			for j := 0; j < cols; j++ {
-				llx = float64(j) * gw
-				lly = float64(i) * gh
-				rr = append(rr, types.NewRectangle(llx, lly, llx+gw, lly+gh))
+	for i := 0; i < outer; i++ {
+		for j := 0; j < inner; j++ {
+			k := j
+			if nup.Boustrophedon && i%2 == 1 {
+				k = inner - 1 - j
 			}
-		}
-
-	case DownRight:
-		for i := 0; i < cols; i++ {
-			for j := rows - 1; j >= 0; j-- {
-				llx = float64(i) * gw
-				lly = float64(j) * gh
-				rr = append(rr, types.NewRectangle(llx, lly, llx+gw, lly+gh))
+			// col counts from the left, row from the top.
+			col, row := k, i
+			if nup.Orient.columnMajor() {
+				col, row = i, k
 			}
-		}
-
-	case LeftDown:
-		for i := rows - 1; i >= 0; i-- {
-			for j := cols - 1; j >= 0; j-- {
-				llx = float64(j) * gw
-				lly = float64(i) * gh
-				rr = append(rr, types.NewRectangle(llx, lly, llx+gw, lly+gh))
+			if nup.Orient.rightToLeft() {
+				col = cols - 1 - col
 			}
-		}
-
-	case DownLeft:
-		for i := cols - 1; i >= 0; i-- {
-			for j := rows - 1; j >= 0; j-- {
-				llx = float64(i) * gw
-				lly = float64(j) * gh
-				rr = append(rr, types.NewRectangle(llx, lly, llx+gw, lly+gh))
+			if nup.Orient.bottomUp() {
+				row = rows - 1 - row
 			}
+			llx := nup.SheetMLeft + float64(col)*(gw+nup.HSpacing)
+			lly := nup.SheetMBot + float64(rows-1-row)*(gh+nup.VSpacing)
+			rr = append(rr, types.NewRectangle(llx, lly, llx+gw, lly+gh))
 		}
 	}
 
@@ -202,16 +259,6 @@ func NUpTilePDFBytes(wr io.Writer, rSrc, rDest *types.Rectangle, formResID strin
 		)
 	}
 
-	// Apply margin to rDest which potentially makes it smaller.
-	rDestCr := rDest.CroppedCopy(nup.Margin)
-
-	// Calculate transform matrix.
-
-	// Best fit translation of a source rectangle into a destination rectangle.
-	// For nup we enforce the dest orientation,
-	// whereas in cases where the original orientation needs to be preserved eg. for booklets, we don't.
-	w, h, dx, dy, r := types.BestFitRectIntoRect(rSrc, rDestCr, enforceOrient, false)
-
 	if nup.BgColor != nil {
 		if nup.ImgInputFile {
 			// Fill background.
@@ -223,6 +270,37 @@ func NUpTilePDFBytes(wr io.Writer, rSrc, rDest *types.Rectangle, formResID strin
 		}
 	}
 
+	m := nUpTileMatrix(rSrc, rDest, nup, rotate, enforceOrient)
+
+	// Apply transform matrix and display form.
+	fmt.Fprintf(wr, "q %.5f %.5f %.5f %.5f %.5f %.5f cm /%s Do Q ",
+		m[0][0], m[0][1], m[1][0], m[1][1], m[2][0], m[2][1], formResID)
+}
+
+// nUpTileMatrix returns the transform matrix rendering rSrc into rDest.
+func nUpTileMatrix(rSrc, rDest *types.Rectangle, nup *NUp, rotate, enforceOrient bool) matrix.Matrix {
+
+	// Apply margin to rDest which potentially makes it smaller.
+	rDestCr := rDest.CroppedCopy(nup.Margin)
+
+	// Calculate transform matrix.
+
+	// Best fit translation of a source rectangle into a destination rectangle.
+	// For nup we enforce the dest orientation,
+	// whereas in cases where the original orientation needs to be preserved eg. for booklets, we don't.
+	w, h, _, _, r := types.BestFitRectIntoRect(rSrc, rDestCr, enforceOrient && !nup.NoAutoRotate, false)
+	if nup.UniformScale > 0 {
+		// Same scale for all sources.
+		w, h = rSrc.Width()*nup.UniformScale, rSrc.Height()*nup.UniformScale
+	}
+
+	// Align the source within its cell.
+	bw, bh := w, h
+	if r == 90 {
+		bw, bh = h, w
+	}
+	dx, dy := types.AnchorPosition(nup.Align, rDestCr, bw, bh)
+
 	// Apply additional rotation.
 	if rotate {
 		r += 180
@@ -251,11 +329,7 @@ func NUpTilePDFBytes(wr io.Writer, rSrc, rDest *types.Rectangle, formResID strin
 	dx += rDestCr.LL.X
 	dy += rDestCr.LL.Y
 
-	m := matrix.CalcTransformMatrix(sx, sy, sin, cos, dx, dy)
-
-	// Apply transform matrix and display form.
-	fmt.Fprintf(wr, "q %.5f %.5f %.5f %.5f %.5f %.5f cm /%s Do Q ",
-		m[0][0], m[0][1], m[1][0], m[1][1], m[2][0], m[2][1], formResID)
+	return matrix.CalcTransformMatrix(sx, sy, sin, cos, dx, dy)
 }
 
 func translationForPageRotation(pageRot int, w, h float64) (float64, float64) {
@@ -283,6 +357,113 @@ func ContentBytesForPageRotation(rot int, w, h float64) []byte {
 	return b.Bytes()
 }
 
+// nUpSource returns the region of a page rendered into a grid cell accounting for page rotation and bleed
+// along with the dimensions of the rotated crop box and the effective nup configuration.
+func nUpSource(inhPAttrs *InheritedPageAttrs, nup *NUp) (*types.Rectangle, float64, float64, *NUp) {
+	cropBox := inhPAttrs.MediaBox
+	if inhPAttrs.CropBox != nil {
+		cropBox = inhPAttrs.CropBox
+	}
+	cropBox = types.NewRectangle(cropBox.LL.X, cropBox.LL.Y, cropBox.UR.X, cropBox.UR.Y)
+
+	// Account for existing rotation.
+	if types.IntMemberOf(inhPAttrs.Rotate, []int{+90, -90, +270, -270}) {
+		w := cropBox.Width()
+		cropBox.UR.X = cropBox.LL.X + cropBox.Height()
+		cropBox.UR.Y = cropBox.LL.Y + w
+	}
+	w, h := cropBox.Width(), cropBox.Height()
+
+	if nup.Bleed > 0 {
+		// Extend the page beyond its crop box into the margin.
+		b := nup.Bleed
+		cropBox = types.NewRectangle(cropBox.LL.X-b, cropBox.LL.Y-b, cropBox.UR.X+b, cropBox.UR.Y+b)
+		nup1 := *nup
+		nup1.Margin = math.Max(nup.Margin-b, 0)
+		nup = &nup1
+	}
+
+	return cropBox, w, h, nup
+}
+
+// ForSourcePage returns nup adjusted for the scale factor of source page pageNr.
+func (nup *NUp) ForSourcePage(pageNr int) *NUp {
+	s, ok := nup.PageScales[pageNr]
+	if !ok || nup.UniformScale <= 0 {
+		return nup
+	}
+	nup1 := *nup
+	nup1.UniformScale *= s
+	return &nup1
+}
+
+// NUpUniformScale returns the largest common scale factor fitting all pages of pageNrs into their cells.
+func (ctx *Context) NUpUniformScale(pageNrs []int, nup *NUp) (float64, error) {
+	rDest := nup.RectsForGrid()[0]
+	f := 0.
+
+	for _, pageNr := range pageNrs {
+		_, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return 0, err
+		}
+		if inhPAttrs == nil {
+			return 0, errors.Errorf("pdfcpu: unknown page number: %d\n", pageNr)
+		}
+
+		cropBox, _, _, nup1 := nUpSource(inhPAttrs, nup)
+
+		s := 1.
+		if s1, ok := nup.PageScales[pageNr]; ok {
+			s = s1
+		}
+		rSrc := types.RectForDim(cropBox.Width()*s, cropBox.Height()*s)
+
+		w, _, _, _, _ := types.BestFitRectIntoRect(rSrc, rDest.CroppedCopy(nup1.Margin), !nup.NoAutoRotate, false)
+		if f1 := w / rSrc.Width(); f == 0 || f1 < f {
+			f = f1
+		}
+	}
+
+	return f, nil
+}
+
+// NUpAutoOrient turns the sheet of nup by 90 degrees if most pages of pageNrs need to be rotated into their cells otherwise.
+// Turning the sheet swaps both the sheet dimensions and the grid. On a tie the sheet keeps its orientation.
+func (ctx *Context) NUpAutoOrient(pageNrs []int, nup *NUp) error {
+	cell := nup.RectsForGrid()[0]
+
+	var fit, misfit int
+	for _, pageNr := range pageNrs {
+		if pageNr == 0 {
+			// Blank page.
+			continue
+		}
+		_, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return err
+		}
+		if inhPAttrs == nil {
+			return errors.Errorf("pdfcpu: unknown page number: %d\n", pageNr)
+		}
+
+		cropBox, _, _, _ := nUpSource(inhPAttrs, nup)
+		switch {
+		case cropBox.Portrait() && cell.Portrait(), cropBox.Landscape() && cell.Landscape():
+			fit++
+		case cropBox.Portrait() && cell.Landscape(), cropBox.Landscape() && cell.Portrait():
+			misfit++
+		}
+	}
+
+	if misfit > fit {
+		nup.PageDim = &types.Dim{Width: nup.PageDim.Height, Height: nup.PageDim.Width}
+		nup.Grid = &types.Dim{Width: nup.Grid.Height, Height: nup.Grid.Width}
+	}
+
+	return nil
+}
+
 // NUpTilePDFBytesForPDF applies nup tiles from PDF.
 func (ctx *Context) NUpTilePDFBytesForPDF(
 	pageNr int,
@@ -301,6 +482,16 @@ func (ctx *Context) NUpTilePDFBytesForPDF(
 		return errors.Errorf("pdfcpu: unknown page number: %d\n", pageNr)
 	}
 
+	cropBox, w, h, nup := nUpSource(inhPAttrs, nup)
+
+	formResID := fmt.Sprintf("Fm%d", pageNr)
+
+	// Reuse the form of a page repeated on this sheet.
+	if _, found := formsResDict.Find(formResID); found {
+		NUpTilePDFBytes(buf, cropBox, rDest, formResID, nup, rotate, true)
+		return nil
+	}
+
 	// Retrieve content stream bytes.
 	bb, err := ctx.PageContent(d)
 	if err == ErrNoContent {
@@ -317,19 +508,8 @@ func (ctx *Context) NUpTilePDFBytesForPDF(
 		return err
 	}
 
-	cropBox := inhPAttrs.MediaBox
-	if inhPAttrs.CropBox != nil {
-		cropBox = inhPAttrs.CropBox
-	}
-
-	// Account for existing rotation.
 	if inhPAttrs.Rotate != 0 {
-		if types.IntMemberOf(inhPAttrs.Rotate, []int{+90, -90, +270, -270}) {
-			w := cropBox.Width()
-			cropBox.UR.X = cropBox.LL.X + cropBox.Height()
-			cropBox.UR.Y = cropBox.LL.Y + w
-		}
-		bb = append(ContentBytesForPageRotation(inhPAttrs.Rotate, cropBox.Width(), cropBox.Height()), bb...)
+		bb = append(ContentBytesForPageRotation(inhPAttrs.Rotate, w, h), bb...)
 	}
 
 	formIndRef, err := createNUpFormForPDF(ctx.XRefTable, ir, bb, cropBox)
@@ -337,7 +517,6 @@ func (ctx *Context) NUpTilePDFBytesForPDF(
 		return err
 	}
 
-	formResID := fmt.Sprintf("Fm%d", pageNr)
 	formsResDict.Insert(formResID, *formIndRef)
 
 	// Append to content stream buf of destination page.
@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"math"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/matrix"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+func transformRect(m matrix.Matrix, r *types.Rectangle) *types.Rectangle {
+	p1 := m.Transform(r.LL)
+	p2 := m.Transform(r.UR)
+	p3 := m.Transform(types.Point{X: r.LL.X, Y: r.UR.Y})
+	p4 := m.Transform(types.Point{X: r.UR.X, Y: r.LL.Y})
+	return types.NewRectangle(
+		math.Min(math.Min(p1.X, p2.X), math.Min(p3.X, p4.X)),
+		math.Min(math.Min(p1.Y, p2.Y), math.Min(p3.Y, p4.Y)),
+		math.Max(math.Max(p1.X, p2.X), math.Max(p3.X, p4.X)),
+		math.Max(math.Max(p1.Y, p2.Y), math.Max(p3.Y, p4.Y)))
+}
+
+func transformPoints(xRefTable *XRefTable, m matrix.Matrix, a types.Array) (types.Array, error) {
+	if len(a)%2 != 0 {
+		return nil, errors.New("pdfcpu: odd number of coordinates")
+	}
+
+	a1 := make(types.Array, len(a))
+	for i := 0; i < len(a); i += 2 {
+		x, err := xRefTable.DereferenceNumber(a[i])
+		if err != nil {
+			return nil, err
+		}
+		y, err := xRefTable.DereferenceNumber(a[i+1])
+		if err != nil {
+			return nil, err
+		}
+		p := m.Transform(types.Point{X: x, Y: y})
+		a1[i], a1[i+1] = types.Float(p.X), types.Float(p.Y)
+	}
+
+	return a1, nil
+}
+
+// internalLink returns true if d links to a destination within this document.
+// Such links are dropped since n-up discards the original pages.
+func internalLink(xRefTable *XRefTable, d types.Dict) (bool, error) {
+	if _, found := d.Find("Dest"); found {
+		return true, nil
+	}
+	a, err := xRefTable.DereferenceDict(d["A"])
+	if err != nil || a == nil {
+		return false, err
+	}
+	s := a.NameEntry("S")
+	return s != nil && *s == "GoTo", nil
+}
+
+func (ctx *Context) nUpLink(m matrix.Matrix, d types.Dict) (*types.IndirectRef, error) {
+	internal, err := internalLink(ctx.XRefTable, d)
+	if err != nil || internal {
+		return nil, err
+	}
+
+	a, err := ctx.DereferenceArray(d["Rect"])
+	if err != nil || len(a) != 4 {
+		return nil, err
+	}
+	r, err := rect(ctx.XRefTable, a)
+	if err != nil {
+		return nil, err
+	}
+
+	d1 := d.Clone().(types.Dict)
+	d1.Delete("P")
+	d1["Rect"] = transformRect(m, r).Array()
+
+	if a, ok := d["QuadPoints"].(types.Array); ok {
+		if d1["QuadPoints"], err = transformPoints(ctx.XRefTable, m, a); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctx.IndRefForNewObject(d1)
+}
+
+func (ctx *Context) nUpWidget(m matrix.Matrix, d types.Dict) error {
+	a, err := ctx.DereferenceArray(d["Rect"])
+	if err != nil || len(a) != 4 {
+		return err
+	}
+	r, err := rect(ctx.XRefTable, a)
+	if err != nil {
+		return err
+	}
+
+	d["Rect"] = transformRect(m, r).Array()
+
+	return nil
+}
+
+// NUpAnnotsForPDF transforms the annotations of page pageNr the same way
+// NUpTilePDFBytesForPDF transforms the page content into rDest.
+// Link annotations get copied except for links to destinations within the document.
+// Widgets get moved unless already moved (see widgets) or their cell is rotated,
+// since a widget may only be referenced by a single page.
+// The returned annotations still need to be bound to their new page.
+func (ctx *Context) NUpAnnotsForPDF(pageNr int, rDest *types.Rectangle, nup *NUp, rotate bool, widgets types.IntSet) (types.Array, error) {
+	d, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errors.Errorf("pdfcpu: unknown page number: %d\n", pageNr)
+	}
+
+	annots, err := ctx.DereferenceArray(d["Annots"])
+	if err != nil || len(annots) == 0 {
+		return nil, err
+	}
+
+	cropBox, w, h, nup := nUpSource(inhPAttrs, nup)
+
+	// page space -> rotated page space -> form space -> sheet space
+	m := matrix.IdentMatrix
+	if inhPAttrs.Rotate != 0 {
+		dx, dy := translationForPageRotation(inhPAttrs.Rotate, w, h)
+		m = matrix.CalcRotateAndTranslateTransformMatrix(float64(-inhPAttrs.Rotate), dx, dy)
+	}
+	mForm := matrix.IdentMatrix
+	mForm[2][0], mForm[2][1] = -cropBox.LL.X, -cropBox.LL.Y
+	m = m.Multiply(mForm).Multiply(nUpTileMatrix(cropBox, rDest, nup, rotate, true))
+
+	// Widget appearances can't be rotated by adjusting "Rect".
+	const eps = 1e-6
+	upright := math.Abs(m[0][1]) < eps && math.Abs(m[1][0]) < eps && m[0][0] > 0 && m[1][1] > 0
+
+	var a types.Array
+
+	for _, o := range annots {
+		indRef, ok := o.(types.IndirectRef)
+		if !ok {
+			continue
+		}
+		d, err := ctx.DereferenceDict(indRef)
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			continue
+		}
+
+		subtype := d.NameEntry("Subtype")
+		if subtype == nil {
+			continue
+		}
+
+		switch *subtype {
+
+		case "Link":
+			ir, err := ctx.nUpLink(m, d)
+			if err != nil {
+				return nil, err
+			}
+			if ir != nil {
+				a = append(a, *ir)
+			}
+
+		case "Widget":
+			objNr := indRef.ObjectNumber.Value()
+			if !upright || widgets[objNr] {
+				continue
+			}
+			if err := ctx.nUpWidget(m, d); err != nil {
+				return nil, err
+			}
+			widgets[objNr] = true
+			a = append(a, indRef)
+		}
+	}
+
+	return a, nil
+}
@@ -74,6 +74,14 @@ type FieldAnnotation struct {
 	Kids   types.Array
 }
 
+// BlankPages represents the configuration for inserting blank pages.
+type BlankPages struct {
+	Before   bool       // Insert in front of selected pages instead of following them.
+	Recto    bool       // Insert in front of selected pages only where needed for them to start on a recto (odd) page.
+	PageSize string     // Optional paper size, eg. A4 or LetterL
+	PageDim  *types.Dim // Optional page dimensions, by default blank pages take over MediaBox, CropBox and Rotate of their adjacent page.
+}
+
 // Page represents rendered page content.
 type Page struct {
 	MediaBox   *types.Rectangle
@@ -18,6 +18,7 @@ package model
 
 import (
 	"encoding/hex"
+	"fmt"
 	"strconv"
 	"strings"
 	"unicode"
@@ -28,25 +29,99 @@ import (
 )
 
 var (
-	errArrayCorrupt            = errors.New("pdfcpu: parse: corrupt array")
-	errArrayNotTerminated      = errors.New("pdfcpu: parse: unterminated array")
-	errDictionaryCorrupt       = errors.New("pdfcpu: parse: corrupt dictionary")
-	errDictionaryDuplicateKey  = errors.New("pdfcpu: parse: duplicate key")
-	errDictionaryNotTerminated = errors.New("pdfcpu: parse: unterminated dictionary")
-	errHexLiteralCorrupt       = errors.New("pdfcpu: parse: corrupt hex literal")
-	errHexLiteralNotTerminated = errors.New("pdfcpu: parse: hex literal not terminated")
-	errNameObjectCorrupt       = errors.New("pdfcpu: parse: corrupt name object")
-	errNoArray                 = errors.New("pdfcpu: parse: no array")
-	errNoDictionary            = errors.New("pdfcpu: parse: no dictionary")
-	errStringLiteralCorrupt    = errors.New("pdfcpu: parse: corrupt string literal, possibly unbalanced parenthesis")
-	errBufNotAvailable         = errors.New("pdfcpu: parse: no buffer available")
-	errXrefStreamMissingW      = errors.New("pdfcpu: parse: xref stream dict missing entry W")
-	errXrefStreamCorruptW      = errors.New("pdfcpu: parse: xref stream dict corrupt entry W: expecting array of 3 int")
-	errXrefStreamCorruptIndex  = errors.New("pdfcpu: parse: xref stream dict corrupt entry Index")
-	errObjStreamMissingN       = errors.New("pdfcpu: parse: obj stream dict missing entry W")
-	errObjStreamMissingFirst   = errors.New("pdfcpu: parse: obj stream dict missing entry First")
+	ErrArrayCorrupt            = errors.New("pdfcpu: parse: corrupt array")
+	ErrArrayNotTerminated      = errors.New("pdfcpu: parse: unterminated array")
+	ErrDictionaryCorrupt       = errors.New("pdfcpu: parse: corrupt dictionary")
+	ErrDictionaryDuplicateKey  = errors.New("pdfcpu: parse: duplicate key")
+	ErrDictionaryNotTerminated = errors.New("pdfcpu: parse: unterminated dictionary")
+	ErrHexLiteralCorrupt       = errors.New("pdfcpu: parse: corrupt hex literal")
+	ErrHexLiteralNotTerminated = errors.New("pdfcpu: parse: hex literal not terminated")
+	ErrNameObjectCorrupt       = errors.New("pdfcpu: parse: corrupt name object")
+	ErrNoArray                 = errors.New("pdfcpu: parse: no array")
+	ErrNoDictionary            = errors.New("pdfcpu: parse: no dictionary")
+	ErrStringLiteralCorrupt    = errors.New("pdfcpu: parse: corrupt string literal, possibly unbalanced parenthesis")
+	ErrBufNotAvailable         = errors.New("pdfcpu: parse: no buffer available")
+	ErrXrefStreamMissingW      = errors.New("pdfcpu: parse: xref stream dict missing entry W")
+	ErrXrefStreamCorruptW      = errors.New("pdfcpu: parse: xref stream dict corrupt entry W: expecting array of 3 int")
+	ErrXrefStreamCorruptIndex  = errors.New("pdfcpu: parse: xref stream dict corrupt entry Index")
+	ErrObjStreamMissingN       = errors.New("pdfcpu: parse: obj stream dict missing entry W")
+	ErrObjStreamMissingFirst   = errors.New("pdfcpu: parse: obj stream dict missing entry First")
 )
 
+// ParseError describes a failure to parse a PDF object.
+type ParseError struct {
+	Err    error  // The cause, eg. ErrDictionaryCorrupt.
+	Token  string // The offending token.
+	Offset int64  // The file offset of the offending token or -1 if unknown.
+	ObjNr  int    // The number of the object being parsed or 0 if unknown.
+	GenNr  int    // The generation number of the object being parsed.
+	rest   int    // The length of the parse buffer starting at the offending token.
+}
+
+func (e *ParseError) Error() string {
+	var ss []string
+	if e.ObjNr > 0 {
+		ss = append(ss, fmt.Sprintf("obj#%d gen:%d", e.ObjNr, e.GenNr))
+	}
+	if e.Offset >= 0 {
+		ss = append(ss, fmt.Sprintf("offset:%d", e.Offset))
+	}
+	if e.Token != "" {
+		ss = append(ss, fmt.Sprintf("token:%q", e.Token))
+	}
+	if len(ss) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (%s)", e.Err, strings.Join(ss, " "))
+}
+
+// Unwrap returns the cause of e.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+const maxParseErrorTokenLen = 32
+
+func parseErrorToken(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	i := 1
+	for ; i < len(s) && i < maxParseErrorTokenLen; i++ {
+		if unicode.IsSpace(rune(s[i])) || s[i] == 0x00 {
+			break
+		}
+	}
+	return s[:i]
+}
+
+// newParseError returns a *ParseError for err located at the start of the remaining parse buffer s.
+// An err which already is a *ParseError is returned unchanged.
+func newParseError(err error, s string) error {
+	if _, ok := err.(*ParseError); ok {
+		return err
+	}
+	return &ParseError{Err: err, Token: parseErrorToken(s), Offset: -1, rest: len(s)}
+}
+
+// LocateParseError attributes err to the object objNr with generation genNr
+// assuming err resulted from parsing a buffer of length bufLen located at file offset off.
+// Pass a negative offset if the file offset is unknown.
+// Errors other than *ParseError are returned unchanged.
+func LocateParseError(err error, off int64, bufLen, objNr, genNr int) error {
+	e, ok := err.(*ParseError)
+	if !ok {
+		return err
+	}
+	if off >= 0 && e.Offset < 0 {
+		e.Offset = off + int64(bufLen-e.rest)
+	}
+	if e.ObjNr == 0 {
+		e.ObjNr, e.GenNr = objNr, genNr
+	}
+	return e
+}
+
 func positionToNextWhitespace(s string) (int, string) {
 	for i, c := range s {
 		if unicode.IsSpace(c) || c == 0x00 {
@@ -231,7 +306,7 @@ func delimiter(b byte) bool {
 func ParseObjectAttributes(line *string) (objectNumber *int, generationNumber *int, err error) {
 
 	if line == nil || len(*line) == 0 {
-		return nil, nil, errors.New("pdfcpu: ParseObjectAttributes: buf not available")
+		return nil, nil, newParseError(errors.New("pdfcpu: ParseObjectAttributes: buf not available"), "")
 	}
 
 	if log.ParseEnabled() {
@@ -243,7 +318,7 @@ func ParseObjectAttributes(line *string) (objectNumber *int, generationNumber *i
 
 	i := strings.Index(l, "obj")
 	if i < 0 {
-		return nil, nil, errors.New("pdfcpu: ParseObjectAttributes: can't find \"obj\"")
+		return nil, nil, newParseError(errors.New("pdfcpu: ParseObjectAttributes: can't find \"obj\""), *line)
 	}
 
 	remainder = l[i+len("obj"):]
@@ -253,17 +328,17 @@ func ParseObjectAttributes(line *string) (objectNumber *int, generationNumber *i
 
 	l, _ = trimLeftSpace(l, false)
 	if len(l) == 0 {
-		return nil, nil, errors.New("pdfcpu: ParseObjectAttributes: can't find object number")
+		return nil, nil, newParseError(errors.New("pdfcpu: ParseObjectAttributes: can't find object number"), *line)
 	}
 
 	i, _ = positionToNextWhitespaceOrChar(l, "%")
 	if i <= 0 {
-		return nil, nil, errors.New("pdfcpu: ParseObjectAttributes: can't find end of object number")
+		return nil, nil, newParseError(errors.New("pdfcpu: ParseObjectAttributes: can't find end of object number"), *line)
 	}
 
 	objNr, err := strconv.Atoi(l[:i])
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, newParseError(err, *line)
 	}
 
 	// generation number
@@ -271,17 +346,17 @@ func ParseObjectAttributes(line *string) (objectNumber *int, generationNumber *i
 	l = l[i:]
 	l, _ = trimLeftSpace(l, false)
 	if len(l) == 0 {
-		return nil, nil, errors.New("pdfcpu: ParseObjectAttributes: can't find generation number")
+		return nil, nil, newParseError(errors.New("pdfcpu: ParseObjectAttributes: can't find generation number"), *line)
 	}
 
 	i, _ = positionToNextWhitespaceOrChar(l, "%")
 	if i <= 0 {
-		return nil, nil, errors.New("pdfcpu: ParseObjectAttributes: can't find end of generation number")
+		return nil, nil, newParseError(errors.New("pdfcpu: ParseObjectAttributes: can't find end of generation number"), *line)
 	}
 
 	genNr, err := strconv.Atoi(l[:i])
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, newParseError(err, *line)
 	}
 
 	objectNumber = &objNr
@@ -292,12 +367,12 @@ func ParseObjectAttributes(line *string) (objectNumber *int, generationNumber *i
 	return objectNumber, generationNumber, nil
 }
 
-func parseArray(line *string) (*types.Array, error) {
+func parseArray(line *string, dk *duplicateKeys) (*types.Array, error) {
 	if log.ParseEnabled() {
 		log.Parse.Println("ParseObject: value = Array")
 	}
 	if line == nil || len(*line) == 0 {
-		return nil, errNoArray
+		return nil, newParseError(ErrNoArray, "")
 	}
 
 	l := *line
@@ -307,11 +382,11 @@ func parseArray(line *string) (*types.Array, error) {
 	}
 
 	if !strings.HasPrefix(l, "[") {
-		return nil, errArrayCorrupt
+		return nil, newParseError(ErrArrayCorrupt, l)
 	}
 
 	if len(l) == 1 {
-		return nil, errArrayNotTerminated
+		return nil, newParseError(ErrArrayNotTerminated, l)
 	}
 
 	// position behind '['
@@ -322,14 +397,14 @@ func parseArray(line *string) (*types.Array, error) {
 
 	if len(l) == 0 {
 		// only whitespace after '['
-		return nil, errArrayNotTerminated
+		return nil, newParseError(ErrArrayNotTerminated, l)
 	}
 
 	a := types.Array{}
 
 	for !strings.HasPrefix(l, "]") {
 
-		obj, err := ParseObject(&l)
+		obj, err := parseObject(&l, dk)
 		if err != nil {
 			return nil, err
 		}
@@ -340,13 +415,13 @@ func parseArray(line *string) (*types.Array, error) {
 
 		// we are positioned on the char behind the last parsed array entry.
 		if len(l) == 0 {
-			return nil, errArrayNotTerminated
+			return nil, newParseError(ErrArrayNotTerminated, l)
 		}
 
 		// position to next non whitespace char.
 		l, _ = trimLeftSpace(l, false)
 		if len(l) == 0 {
-			return nil, errArrayNotTerminated
+			return nil, newParseError(ErrArrayNotTerminated, l)
 		}
 	}
 
@@ -384,7 +459,7 @@ func parseStringLiteral(line *string) (types.Object, error) {
 	// Join split lines by '\' eol.
 
 	if line == nil || len(*line) == 0 {
-		return nil, errBufNotAvailable
+		return nil, newParseError(ErrBufNotAvailable, "")
 	}
 
 	if log.ParseEnabled() {
@@ -398,7 +473,7 @@ func parseStringLiteral(line *string) (types.Object, error) {
 	}
 
 	if len(l) < 2 || !strings.HasPrefix(l, "(") {
-		return nil, errStringLiteralCorrupt
+		return nil, newParseError(ErrStringLiteralCorrupt, l)
 	}
 
 	// Calculate prefix with balanced parentheses,
@@ -406,7 +481,7 @@ func parseStringLiteral(line *string) (types.Object, error) {
 	i := balancedParenthesesPrefix(l)
 	if i < 0 {
 		// No balanced parentheses.
-		return nil, errStringLiteralCorrupt
+		return nil, newParseError(ErrStringLiteralCorrupt, l)
 	}
 
 	// remove enclosing '(', ')'
@@ -428,7 +503,7 @@ func parseStringLiteral(line *string) (types.Object, error) {
 
 func parseHexLiteral(line *string) (types.Object, error) {
 	if line == nil || len(*line) == 0 {
-		return nil, errBufNotAvailable
+		return nil, newParseError(ErrBufNotAvailable, "")
 	}
 
 	l := *line
@@ -438,7 +513,7 @@ func parseHexLiteral(line *string) (types.Object, error) {
 	}
 
 	if len(l) < 2 || !strings.HasPrefix(l, "<") {
-		return nil, errHexLiteralCorrupt
+		return nil, newParseError(ErrHexLiteralCorrupt, l)
 	}
 
 	// position behind '<'
@@ -446,12 +521,12 @@ func parseHexLiteral(line *string) (types.Object, error) {
 
 	eov := strings.Index(l, ">") // end of hex literal.
 	if eov < 0 {
-		return nil, errHexLiteralNotTerminated
+		return nil, newParseError(ErrHexLiteralNotTerminated, *line)
 	}
 
 	hexStr, ok := hexString(strings.TrimSpace(l[:eov]))
 	if !ok {
-		return nil, errHexLiteralCorrupt
+		return nil, newParseError(ErrHexLiteralCorrupt, *line)
 	}
 
 	// position behind '>'
@@ -470,7 +545,7 @@ func validateNameHexSequence(s string) error {
 
 		// # detected, next 2 chars have to exist.
 		if len(s) < i+3 {
-			return errNameObjectCorrupt
+			return ErrNameObjectCorrupt
 		}
 
 		s1 := s[i+1 : i+3]
@@ -478,7 +553,7 @@ func validateNameHexSequence(s string) error {
 		// And they have to be hex characters.
 		_, err := hex.DecodeString(s1)
 		if err != nil {
-			return errNameObjectCorrupt
+			return ErrNameObjectCorrupt
 		}
 
 		i += 3
@@ -492,7 +567,7 @@ func parseName(line *string) (*types.Name, error) {
 		log.Parse.Println("ParseObject: value = Name Object")
 	}
 	if line == nil || len(*line) == 0 {
-		return nil, errBufNotAvailable
+		return nil, newParseError(ErrBufNotAvailable, "")
 	}
 
 	l := *line
@@ -501,9 +576,11 @@ func parseName(line *string) (*types.Name, error) {
 		log.Parse.Printf("parseNameObject: %s\n", l)
 	}
 	if len(l) < 2 || !strings.HasPrefix(l, "/") {
-		return nil, errNameObjectCorrupt
+		return nil, newParseError(ErrNameObjectCorrupt, l)
 	}
 
+	s := l
+
 	// position behind '/'
 	l = forwardParseBuf(l, 1)
 
@@ -520,16 +597,45 @@ func parseName(line *string) (*types.Name, error) {
 	// Validate optional #xx sequences
 	err := validateNameHexSequence(l)
 	if err != nil {
-		return nil, err
+		return nil, newParseError(err, s)
 	}
 
 	nameObj := types.Name(l)
 	return &nameObj, nil
 }
 
-func processDictKeys(line *string, relaxed bool) (types.Dict, error) {
+// duplicateKeys represents the handling of dict entries whose key is already in use.
+type duplicateKeys struct {
+	mode DuplicateKeyMode
+	keys []string // The duplicate keys encountered.
+}
+
+func (dk *duplicateKeys) insert(d types.Dict, key string, o types.Object, dups *[]string) error {
+	if _, found := d.Find(key); !found {
+		d[key] = o
+		return nil
+	}
+
+	if dk == nil {
+		return nil
+	}
+
+	*dups = append(*dups, key)
+
+	switch dk.mode {
+	case DuplicateKeyError:
+		return ErrDictionaryDuplicateKey
+	case DuplicateKeyKeepLast:
+		d[key] = o
+	}
+
+	return nil
+}
+
+func processDictKeys(line *string, relaxed bool, dk *duplicateKeys) (types.Dict, error) {
 	l := *line
 	var eol bool
+	var dups []string
 	d := types.NewDict()
 	for !strings.HasPrefix(l, ">>") {
 		key, err := parseName(&l)
@@ -548,7 +654,7 @@ func processDictKeys(line *string, relaxed bool) (types.Dict, error) {
 				log.Parse.Println("ParseDict: only whitespace after key")
 			}
 			// only whitespace after key
-			return nil, errDictionaryNotTerminated
+			return nil, newParseError(ErrDictionaryNotTerminated, l)
 		}
 
 		// Fix for #252:
@@ -558,13 +664,13 @@ func processDictKeys(line *string, relaxed bool) (types.Dict, error) {
 			if log.ParseEnabled() {
 				log.Parse.Printf("ParseDict: dict[%s]=%v\n", key, obj)
 			}
-			if ok := d.Insert(string(*key), obj); !ok {
-				return nil, errDictionaryDuplicateKey
+			if err := dk.insert(d, string(*key), obj, &dups); err != nil {
+				return nil, newParseError(err, l)
 			}
 			continue
 		}
 
-		obj, err := ParseObject(&l)
+		obj, err := parseObject(&l, dk)
 		if err != nil {
 			return nil, err
 		}
@@ -572,34 +678,36 @@ func processDictKeys(line *string, relaxed bool) (types.Dict, error) {
 		// Specifying the null object as the value of a dictionary entry (7.3.7, "Dictionary Objects")
 		// hall be equivalent to omitting the entry entirely.
 		if obj != nil {
-			d.Insert(string(*key), obj)
+			if err := dk.insert(d, string(*key), obj, &dups); err != nil {
+				return nil, newParseError(err, l)
+			}
 			if log.ParseEnabled() {
 				log.Parse.Printf("ParseDict: dict[%s]=%v\n", key, obj)
 			}
-			// if ok := d.Insert(string(*key), obj); !ok {
-			// 	return nil, errDictionaryDuplicateKey
-			// }
 		}
 
 		// We are positioned on the char behind the last parsed dict value.
 		if len(l) == 0 {
-			return nil, errDictionaryNotTerminated
+			return nil, newParseError(ErrDictionaryNotTerminated, l)
 		}
 
 		// Position to next non whitespace char.
 		l, _ = trimLeftSpace(l, false)
 		if len(l) == 0 {
-			return nil, errDictionaryNotTerminated
+			return nil, newParseError(ErrDictionaryNotTerminated, l)
 		}
 
 	}
+	if dk != nil {
+		dk.keys = append(dk.keys, dups...)
+	}
 	*line = l
 	return d, nil
 }
 
-func parseDict(line *string, relaxed bool) (types.Dict, error) {
+func parseDict(line *string, relaxed bool, dk *duplicateKeys) (types.Dict, error) {
 	if line == nil || len(*line) == 0 {
-		return nil, errNoDictionary
+		return nil, newParseError(ErrNoDictionary, "")
 	}
 
 	l := *line
@@ -609,7 +717,7 @@ func parseDict(line *string, relaxed bool) (types.Dict, error) {
 	}
 
 	if len(l) < 4 || !strings.HasPrefix(l, "<<") {
-		return nil, errDictionaryCorrupt
+		return nil, newParseError(ErrDictionaryCorrupt, l)
 	}
 
 	// position behind '<<'
@@ -620,10 +728,10 @@ func parseDict(line *string, relaxed bool) (types.Dict, error) {
 
 	if len(l) == 0 {
 		// only whitespace after '['
-		return nil, errDictionaryNotTerminated
+		return nil, newParseError(ErrDictionaryNotTerminated, l)
 	}
 
-	d, err := processDictKeys(&l, relaxed)
+	d, err := processDictKeys(&l, relaxed, dk)
 	if err != nil {
 		return nil, err
 	}
@@ -751,7 +859,7 @@ func parseFloat(s string) (types.Object, error) {
 
 func parseNumericOrIndRef(line *string) (types.Object, error) {
 	if noBuf(line) {
-		return nil, errBufNotAvailable
+		return nil, newParseError(ErrBufNotAvailable, "")
 	}
 
 	l := *line
@@ -829,9 +937,9 @@ func parseNumericOrIndRef(line *string) (types.Object, error) {
 	return parseIndRef(s, l, l1, line, i, i2, rangeErr)
 }
 
-func parseHexLiteralOrDict(l *string) (val types.Object, err error) {
+func parseHexLiteralOrDict(l *string, dk *duplicateKeys) (val types.Object, err error) {
 	if len(*l) < 2 {
-		return nil, errBufNotAvailable
+		return nil, newParseError(ErrBufNotAvailable, *l)
 	}
 
 	// if next char = '<' parseDict.
@@ -843,8 +951,16 @@ func parseHexLiteralOrDict(l *string) (val types.Object, err error) {
 			d   types.Dict
 			err error
 		)
-		if d, err = parseDict(l, false); err != nil {
-			if d, err = parseDict(l, true); err != nil {
+		var n int
+		if dk != nil {
+			n = len(dk.keys)
+		}
+		if d, err = parseDict(l, false, dk); err != nil {
+			if dk != nil {
+				// Forget about duplicates of the failed attempt.
+				dk.keys = dk.keys[:n]
+			}
+			if d, err = parseDict(l, true, dk); err != nil {
 				return nil, err
 			}
 		}
@@ -891,9 +1007,15 @@ func parseBooleanOrNull(l string) (val types.Object, s string, ok bool) {
 }
 
 // ParseObject parses next Object from string buffer and returns the updated (left clipped) buffer.
+// Errors are returned as *ParseError.
+// Dict entries whose key is already in use are ignored.
 func ParseObject(line *string) (types.Object, error) {
+	return parseObject(line, nil)
+}
+
+func parseObject(line *string, dk *duplicateKeys) (types.Object, error) {
 	if noBuf(line) {
-		return nil, errBufNotAvailable
+		return nil, newParseError(ErrBufNotAvailable, "")
 	}
 
 	l := *line
@@ -906,7 +1028,7 @@ func ParseObject(line *string) (types.Object, error) {
 	l, _ = trimLeftSpace(l, false)
 	if len(l) == 0 {
 		// only whitespace
-		return nil, errBufNotAvailable
+		return nil, newParseError(ErrBufNotAvailable, l)
 	}
 
 	var value types.Object
@@ -915,7 +1037,7 @@ func ParseObject(line *string) (types.Object, error) {
 	switch l[0] {
 
 	case '[': // array
-		a, err := parseArray(&l)
+		a, err := parseArray(&l, dk)
 		if err != nil {
 			return nil, err
 		}
@@ -929,7 +1051,7 @@ func ParseObject(line *string) (types.Object, error) {
 		value = *nameObj
 
 	case '<': // hex literal or dict
-		value, err = parseHexLiteralOrDict(&l)
+		value, err = parseHexLiteralOrDict(&l, dk)
 		if err != nil {
 			return nil, err
 		}
@@ -951,8 +1073,9 @@ func ParseObject(line *string) (types.Object, error) {
 		// int 0 r
 		// int
 		// float
+		l0 := l
 		if value, err = parseNumericOrIndRef(&l); err != nil {
-			return nil, err
+			return nil, newParseError(err, l0)
 		}
 
 	}
@@ -966,6 +1089,38 @@ func ParseObject(line *string) (types.Object, error) {
 	return value, nil
 }
 
+// ParseObjectWithContext parses next Object from string buffer line located at file offset off
+// and returns the updated (left clipped) buffer.
+// Errors are returned as *ParseError attributed to the object objNr with generation genNr.
+// Pass a negative offset if the file offset is unknown.
+func ParseObjectWithContext(line *string, off int64, objNr, genNr int) (types.Object, error) {
+	var bufLen int
+	if line != nil {
+		bufLen = len(*line)
+	}
+	o, err := ParseObject(line)
+	if err != nil {
+		return nil, LocateParseError(err, off, bufLen, objNr, genNr)
+	}
+	return o, nil
+}
+
+// ParseObjectWithDuplicateKeys parses like ParseObjectWithContext
+// but handles dict entries whose key is already in use according to mode.
+// It also returns the duplicate keys encountered.
+func ParseObjectWithDuplicateKeys(line *string, off int64, objNr, genNr int, mode DuplicateKeyMode) (types.Object, []string, error) {
+	var bufLen int
+	if line != nil {
+		bufLen = len(*line)
+	}
+	dk := &duplicateKeys{mode: mode}
+	o, err := parseObject(line, dk)
+	if err != nil {
+		return nil, dk.keys, LocateParseError(err, off, bufLen, objNr, genNr)
+	}
+	return o, dk.keys, nil
+}
+
 func createXRefStreamDict(sd *types.StreamDict, objs []int) (*types.XRefStreamDict, error) {
 	// Read parameter W in order to decode the xref table.
 	// array of integers representing the size of the fields in a single cross-reference entry.
@@ -974,33 +1129,34 @@ func createXRefStreamDict(sd *types.StreamDict, objs []int) (*types.XRefStreamDi
 
 	a := sd.W()
 	if a == nil {
-		return nil, errXrefStreamMissingW
+		return nil, ErrXrefStreamMissingW
 	}
 
 	// validate array with 3 positive integers
 	if len(a) != 3 {
-		return nil, errXrefStreamCorruptW
+		return nil, ErrXrefStreamCorruptW
 	}
 
+	// Field widths beyond 8 bytes do not fit into int64.
 	f := func(ok bool, i int) bool {
-		return !ok || i < 0
+		return !ok || i < 0 || i > 8
 	}
 
 	i1, ok := a[0].(types.Integer)
 	if f(ok, i1.Value()) {
-		return nil, errXrefStreamCorruptW
+		return nil, ErrXrefStreamCorruptW
 	}
 	wIntArr[0] = int(i1)
 
 	i2, ok := a[1].(types.Integer)
 	if f(ok, i2.Value()) {
-		return nil, errXrefStreamCorruptW
+		return nil, ErrXrefStreamCorruptW
 	}
 	wIntArr[1] = int(i2)
 
 	i3, ok := a[2].(types.Integer)
 	if f(ok, i3.Value()) {
-		return nil, errXrefStreamCorruptW
+		return nil, ErrXrefStreamCorruptW
 	}
 	wIntArr[2] = int(i3)
 
@@ -1032,19 +1188,19 @@ func ParseXRefStreamDict(sd *types.StreamDict) (*types.XRefStreamDict, error) {
 		}
 
 		if len(indArr)%2 > 1 {
-			return nil, errXrefStreamCorruptIndex
+			return nil, ErrXrefStreamCorruptIndex
 		}
 
 		for i := 0; i < len(indArr)/2; i++ {
 
 			startObj, ok := indArr[i*2].(types.Integer)
 			if !ok {
-				return nil, errXrefStreamCorruptIndex
+				return nil, ErrXrefStreamCorruptIndex
 			}
 
 			count, ok := indArr[i*2+1].(types.Integer)
 			if !ok {
-				return nil, errXrefStreamCorruptIndex
+				return nil, ErrXrefStreamCorruptIndex
 			}
 
 			for j := 0; j < count.Value(); j++ {
@@ -1077,11 +1233,11 @@ func ParseXRefStreamDict(sd *types.StreamDict) (*types.XRefStreamDict, error) {
 // ObjectStreamDict creates a ObjectStreamDict out of a StreamDict.
 func ObjectStreamDict(sd *types.StreamDict) (*types.ObjectStreamDict, error) {
 	if sd.First() == nil {
-		return nil, errObjStreamMissingFirst
+		return nil, ErrObjStreamMissingFirst
 	}
 
 	if sd.N() == nil {
-		return nil, errObjStreamMissingN
+		return nil, ErrObjStreamMissingN
 	}
 
 	osd := types.ObjectStreamDict{
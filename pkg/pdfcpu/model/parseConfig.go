@@ -42,10 +42,25 @@ type configuration struct {
 	Unit                            string `yaml:"unit"`
 	Units                           string `yaml:"units"` // Be flexible if version < v0.3.8
 	TimestampFormat                 string `yaml:"timestampFormat"`
+	TimestampLocale                 string `yaml:"timestampLocale"`
+	TimestampTimeZone               string `yaml:"timestampTimeZone"`
 	DateFormat                      string `yaml:"dateFormat"`
 	HeaderBufSize                   int    `yaml:"headerBufSize"`
 	OptimizeDuplicateContentStreams bool   `yaml:"optimizeDuplicateContentStreams"`
+	RasterizeShadings               bool   `yaml:"rasterizeShadings"`
+	SimplifyPaths                   bool   `yaml:"simplifyPaths"`
+	InlineFormXObjects              bool   `yaml:"inlineFormXObjects"`
+	StripAlternateImages            bool   `yaml:"stripAlternateImages"`
+	DeduplicateStreams              bool   `yaml:"deduplicateStreams"`
 	CreateBookmarks                 bool   `yaml:"createBookmarks"`
+	FormFieldCollision              string `yaml:"formFieldCollision"`
+	DividerPages                    bool   `yaml:"dividerPages"`
+	DividerPageText                 string `yaml:"dividerPageText"`
+	TOCPage                         bool   `yaml:"tocPage"`
+	DuplicateKeys                   string `yaml:"duplicateKeys"`
+	FontLicensePolicy               string `yaml:"fontLicensePolicy"`
+	FormAuditTrail                  bool   `yaml:"formAuditTrail"`
+	EncryptUsingObjectStreams       bool   `yaml:"encryptUsingObjectStreams"`
 }
 
 func loadedConfig(c configuration, configPath string) *Configuration {
@@ -59,6 +74,7 @@ func loadedConfig(c configuration, configPath string) *Configuration {
 	conf.WriteXRefStream = c.WriteXRefStream
 	conf.EncryptUsingAES = c.EncryptUsingAES
 	conf.EncryptKeyLength = c.EncryptKeyLength
+	conf.EncryptUsingObjectStreams = c.EncryptUsingObjectStreams
 	conf.Permissions = int16(c.Permissions)
 
 	switch c.ValidationMode {
@@ -91,10 +107,50 @@ func loadedConfig(c configuration, configPath string) *Configuration {
 	}
 
 	conf.TimestampFormat = c.TimestampFormat
+	conf.TimestampLocale = c.TimestampLocale
+	conf.TimestampTimeZone = c.TimestampTimeZone
 	conf.DateFormat = c.DateFormat
 	conf.HeaderBufSize = c.HeaderBufSize
 	conf.OptimizeDuplicateContentStreams = c.OptimizeDuplicateContentStreams
+	conf.RasterizeShadings = c.RasterizeShadings
+	conf.SimplifyPaths = c.SimplifyPaths
+	conf.InlineFormXObjects = c.InlineFormXObjects
+	conf.StripAlternateImages = c.StripAlternateImages
+	conf.DeduplicateStreams = c.DeduplicateStreams
 	conf.CreateBookmarks = c.CreateBookmarks
+	conf.DividerPages = c.DividerPages
+	conf.DividerPageText = c.DividerPageText
+	conf.TOCPage = c.TOCPage
+	conf.FormAuditTrail = c.FormAuditTrail
+
+	switch c.FormFieldCollision {
+	case "rename":
+		conf.FormFieldCollision = FieldCollisionRename
+	case "merge":
+		conf.FormFieldCollision = FieldCollisionMerge
+	case "error":
+		conf.FormFieldCollision = FieldCollisionError
+	default:
+		conf.FormFieldCollision = FieldCollisionGroup
+	}
+
+	switch c.DuplicateKeys {
+	case "keepLast":
+		conf.DuplicateKeys = DuplicateKeyKeepLast
+	case "error":
+		conf.DuplicateKeys = DuplicateKeyError
+	default:
+		conf.DuplicateKeys = DuplicateKeyKeepFirst
+	}
+
+	switch c.FontLicensePolicy {
+	case "ignore":
+		conf.FontLicensePolicy = FontLicenseIgnore
+	case "refuse":
+		conf.FontLicensePolicy = FontLicenseRefuse
+	default:
+		conf.FontLicensePolicy = FontLicenseWarn
+	}
 
 	return &conf
 }
@@ -134,6 +190,26 @@ func parseConfigFile(r io.Reader, configPath string) error {
 		return errors.Errorf("encryptKeyLength possible values: 40, 128, 256, got: %s", c.Unit)
 	}
 
+	// formFieldCollision is optional for old config files.
+	if !types.MemberOf(c.FormFieldCollision, []string{"", "group", "rename", "merge", "error"}) {
+		return errors.Errorf("invalid formFieldCollision: %s", c.FormFieldCollision)
+	}
+
+	// duplicateKeys is optional for old config files.
+	if !types.MemberOf(c.DuplicateKeys, []string{"", "keepFirst", "keepLast", "error"}) {
+		return errors.Errorf("invalid duplicateKeys: %s", c.DuplicateKeys)
+	}
+
+	// fontLicensePolicy is optional for old config files.
+	if !types.MemberOf(c.FontLicensePolicy, []string{"", "warn", "ignore", "refuse"}) {
+		return errors.Errorf("invalid fontLicensePolicy: %s", c.FontLicensePolicy)
+	}
+
+	// timestampLocale and timestampTimeZone are optional for old config files.
+	if _, err := NewTimestamp(c.TimestampFormat, c.TimestampLocale, c.TimestampTimeZone); err != nil {
+		return err
+	}
+
 	// TODO Disable on next release.
 	if c.HeaderBufSize == 0 {
 		c.HeaderBufSize = 100
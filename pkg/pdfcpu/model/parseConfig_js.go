@@ -112,6 +112,15 @@ func handleConfEncryptUsingAES(k, v string, c *Configuration) error {
 	return nil
 }
 
+func handleConfEncryptUsingObjectStreams(k, v string, c *Configuration) error {
+	v = strings.ToLower(v)
+	if v != "true" && v != "false" {
+		return errors.Errorf("config key %s is boolean", k)
+	}
+	c.EncryptUsingObjectStreams = v == "true"
+	return nil
+}
+
 func handleConfEncryptKeyLength(v string, c *Configuration) error {
 	i, err := strconv.Atoi(v)
 	if err != nil {
@@ -155,6 +164,22 @@ func handleTimestampFormat(v string, c *Configuration) error {
 	return nil
 }
 
+func handleTimestampLocale(v string, c *Configuration) error {
+	if _, err := NewTimestamp(c.TimestampFormat, v, ""); err != nil {
+		return err
+	}
+	c.TimestampLocale = v
+	return nil
+}
+
+func handleTimestampTimeZone(v string, c *Configuration) error {
+	if _, err := NewTimestamp(c.TimestampFormat, "", v); err != nil {
+		return err
+	}
+	c.TimestampTimeZone = v
+	return nil
+}
+
 func handleDateFormat(v string, c *Configuration) error {
 	c.DateFormat = v
 	return nil
@@ -181,6 +206,51 @@ func handleOptimizeDuplicateContentStreams(k, v string, c *Configuration) error
 	return nil
 }
 
+func handleRasterizeShadings(k, v string, c *Configuration) error {
+	v = strings.ToLower(v)
+	if v != "true" && v != "false" {
+		return errors.Errorf("config key %s is boolean", k)
+	}
+	c.RasterizeShadings = v == "true"
+	return nil
+}
+
+func handleSimplifyPaths(k, v string, c *Configuration) error {
+	v = strings.ToLower(v)
+	if v != "true" && v != "false" {
+		return errors.Errorf("config key %s is boolean", k)
+	}
+	c.SimplifyPaths = v == "true"
+	return nil
+}
+
+func handleInlineFormXObjects(k, v string, c *Configuration) error {
+	v = strings.ToLower(v)
+	if v != "true" && v != "false" {
+		return errors.Errorf("config key %s is boolean", k)
+	}
+	c.InlineFormXObjects = v == "true"
+	return nil
+}
+
+func handleStripAlternateImages(k, v string, c *Configuration) error {
+	v = strings.ToLower(v)
+	if v != "true" && v != "false" {
+		return errors.Errorf("config key %s is boolean", k)
+	}
+	c.StripAlternateImages = v == "true"
+	return nil
+}
+
+func handleDeduplicateStreams(k, v string, c *Configuration) error {
+	v = strings.ToLower(v)
+	if v != "true" && v != "false" {
+		return errors.Errorf("config key %s is boolean", k)
+	}
+	c.DeduplicateStreams = v == "true"
+	return nil
+}
+
 func handleCreateBookmarks(k, v string, c *Configuration) error {
 	v = strings.ToLower(v)
 	if v != "true" && v != "false" {
@@ -190,6 +260,82 @@ func handleCreateBookmarks(k, v string, c *Configuration) error {
 	return nil
 }
 
+func handleFormFieldCollision(k, v string, c *Configuration) error {
+	switch strings.ToLower(v) {
+	case "group":
+		c.FormFieldCollision = FieldCollisionGroup
+	case "rename":
+		c.FormFieldCollision = FieldCollisionRename
+	case "merge":
+		c.FormFieldCollision = FieldCollisionMerge
+	case "error":
+		c.FormFieldCollision = FieldCollisionError
+	default:
+		return errors.Errorf("config key %s possible values: group, rename, merge, error", k)
+	}
+	return nil
+}
+
+func handleDividerPages(k, v string, c *Configuration) error {
+	v = strings.ToLower(v)
+	if v != "true" && v != "false" {
+		return errors.Errorf("config key %s is boolean", k)
+	}
+	c.DividerPages = v == "true"
+	return nil
+}
+
+func handleDividerPageText(v string, c *Configuration) error {
+	c.DividerPageText = v
+	return nil
+}
+
+func handleTOCPage(k, v string, c *Configuration) error {
+	v = strings.ToLower(v)
+	if v != "true" && v != "false" {
+		return errors.Errorf("config key %s is boolean", k)
+	}
+	c.TOCPage = v == "true"
+	return nil
+}
+
+func handleFormAuditTrail(k, v string, c *Configuration) error {
+	v = strings.ToLower(v)
+	if v != "true" && v != "false" {
+		return errors.Errorf("config key %s is boolean", k)
+	}
+	c.FormAuditTrail = v == "true"
+	return nil
+}
+
+func handleDuplicateKeys(k, v string, c *Configuration) error {
+	switch strings.ToLower(v) {
+	case "keepfirst":
+		c.DuplicateKeys = DuplicateKeyKeepFirst
+	case "keeplast":
+		c.DuplicateKeys = DuplicateKeyKeepLast
+	case "error":
+		c.DuplicateKeys = DuplicateKeyError
+	default:
+		return errors.Errorf("config key %s possible values: keepFirst, keepLast, error", k)
+	}
+	return nil
+}
+
+func handleFontLicensePolicy(k, v string, c *Configuration) error {
+	switch strings.ToLower(v) {
+	case "warn":
+		c.FontLicensePolicy = FontLicenseWarn
+	case "ignore":
+		c.FontLicensePolicy = FontLicenseIgnore
+	case "refuse":
+		c.FontLicensePolicy = FontLicenseRefuse
+	default:
+		return errors.Errorf("config key %s possible values: warn, ignore, refuse", k)
+	}
+	return nil
+}
+
 func parseKeysPart1(k, v string, c *Configuration) (bool, error) {
 	switch k {
 
@@ -228,6 +374,9 @@ func parseKeysPart2(k, v string, c *Configuration) error {
 	case "encryptKeyLength":
 		return handleConfEncryptKeyLength(v, c)
 
+	case "encryptUsingObjectStreams":
+		return handleConfEncryptUsingObjectStreams(k, v, c)
+
 	case "permissions":
 		return handleConfPermissions(v, c)
 
@@ -237,6 +386,12 @@ func parseKeysPart2(k, v string, c *Configuration) error {
 	case "timestampFormat":
 		return handleTimestampFormat(v, c)
 
+	case "timestampLocale":
+		return handleTimestampLocale(v, c)
+
+	case "timestampTimeZone":
+		return handleTimestampTimeZone(v, c)
+
 	case "dateFormat":
 		return handleDateFormat(v, c)
 
@@ -246,8 +401,44 @@ func parseKeysPart2(k, v string, c *Configuration) error {
 	case "optimizeDuplicateContentStreams":
 		return handleOptimizeDuplicateContentStreams(k, v, c)
 
+	case "rasterizeShadings":
+		return handleRasterizeShadings(k, v, c)
+
+	case "simplifyPaths":
+		return handleSimplifyPaths(k, v, c)
+
+	case "inlineFormXObjects":
+		return handleInlineFormXObjects(k, v, c)
+
+	case "stripAlternateImages":
+		return handleStripAlternateImages(k, v, c)
+
+	case "deduplicateStreams":
+		return handleDeduplicateStreams(k, v, c)
+
 	case "createBookmarks":
 		return handleCreateBookmarks(k, v, c)
+
+	case "formFieldCollision":
+		return handleFormFieldCollision(k, v, c)
+
+	case "dividerPages":
+		return handleDividerPages(k, v, c)
+
+	case "dividerPageText":
+		return handleDividerPageText(v, c)
+
+	case "tocPage":
+		return handleTOCPage(k, v, c)
+
+	case "duplicateKeys":
+		return handleDuplicateKeys(k, v, c)
+
+	case "fontLicensePolicy":
+		return handleFontLicensePolicy(k, v, c)
+
+	case "formAuditTrail":
+		return handleFormAuditTrail(k, v, c)
 	}
 
 	return nil
@@ -38,14 +38,14 @@ func whitespaceOrEOL(c rune) bool {
 func skipDict(l *string) error {
 	s := *l
 	if !strings.HasPrefix(s, "<<") {
-		return errDictionaryCorrupt
+		return ErrDictionaryCorrupt
 	}
 	s = s[2:]
 	j := 0
 	for {
 		i := strings.IndexAny(s, "<>")
 		if i < 0 {
-			return errDictionaryCorrupt
+			return ErrDictionaryCorrupt
 		}
 		if s[i] == '<' {
 			j++
@@ -61,7 +61,7 @@ func skipDict(l *string) error {
 			// >> ?
 			s = s[i:]
 			if !strings.HasPrefix(s, ">>") {
-				return errDictionaryCorrupt
+				return ErrDictionaryCorrupt
 			}
 			*l = s[2:]
 			break
@@ -81,7 +81,7 @@ func skipStringLiteral(l *string) error {
 		s = s[i+1:]
 	}
 	if i < 0 {
-		return errStringLiteralCorrupt
+		return ErrStringLiteralCorrupt
 	}
 	s = s[i+1:]
 	*l = s
@@ -92,7 +92,7 @@ func skipHexStringLiteral(l *string) error {
 	s := *l
 	i := strings.Index(s, ">")
 	if i < 0 {
-		return errHexLiteralCorrupt
+		return ErrHexLiteralCorrupt
 	}
 	s = s[i+1:]
 	*l = s
@@ -17,6 +17,7 @@ limitations under the License.
 package model
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -112,3 +113,37 @@ func TestParseObject(t *testing.T) {
 
 	doTestParseObjectOK("18446744071963345064 0 R", t)
 }
+
+func doTestParseObjectError(parseString string, off int64, wantErr error, wantOff int64, wantToken string, t *testing.T) {
+	_, err := ParseObjectWithContext(&parseString, off, 7, 1)
+	if err == nil {
+		t.Errorf("parseObject should have returned an error for %s\n", parseString)
+		return
+	}
+
+	var e *ParseError
+	if !errors.As(err, &e) {
+		t.Errorf("parseObject: want *ParseError, got %T: %v\n", err, err)
+		return
+	}
+
+	if !errors.Is(err, wantErr) {
+		t.Errorf("parseObject: want %v, got %v\n", wantErr, e.Err)
+	}
+	if e.Offset != wantOff {
+		t.Errorf("parseObject: want offset %d, got %d\n", wantOff, e.Offset)
+	}
+	if e.Token != wantToken {
+		t.Errorf("parseObject: want token %q, got %q\n", wantToken, e.Token)
+	}
+	if e.ObjNr != 7 || e.GenNr != 1 {
+		t.Errorf("parseObject: want obj#7 gen:1, got obj#%d gen:%d\n", e.ObjNr, e.GenNr)
+	}
+}
+
+func TestParseObjectError(t *testing.T) {
+	doTestParseObjectError("<</Key1 (abc) /Key2 <0z>>>", 100, ErrHexLiteralCorrupt, 120, "<0z>>>", t)
+	doTestParseObjectError("[1 2 (abc)", 0, ErrArrayNotTerminated, 10, "", t)
+	doTestParseObjectError("  [/Na#2me]", 50, ErrNameObjectCorrupt, 53, "/Na#2me]", t)
+	doTestParseObjectError("<</Type /Page /Parent 2 0 R", -1, ErrDictionaryNotTerminated, -1, "", t)
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package model
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
 
 func doTestParseDictOK(parseString string, t *testing.T) {
@@ -157,3 +160,40 @@ func TestParseDict(t *testing.T) {
 	doTestParseDictIndirectRefs(t)
 	doTestParseDictWithComments(t)
 }
+
+func TestParseDictDuplicateKeys(t *testing.T) {
+	s := "<</Key1/Value1/Inner<</Key2 1/Key2 2>>/Key1/Value2>>"
+
+	for _, tt := range []struct {
+		mode  DuplicateKeyMode
+		key1  string
+		key2  int
+		isErr bool
+	}{
+		{DuplicateKeyKeepFirst, "Value1", 1, false},
+		{DuplicateKeyKeepLast, "Value2", 2, false},
+		{DuplicateKeyError, "", 0, true},
+	} {
+		l := s
+		o, keys, err := ParseObjectWithDuplicateKeys(&l, 0, 1, 0, tt.mode)
+		if tt.isErr {
+			if !errors.Is(err, ErrDictionaryDuplicateKey) {
+				t.Fatalf("mode %d: want ErrDictionaryDuplicateKey, got %v\n", tt.mode, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("mode %d: %v\n", tt.mode, err)
+		}
+		if len(keys) != 2 || keys[0] != "Key2" || keys[1] != "Key1" {
+			t.Fatalf("mode %d: want duplicate keys [Key2 Key1], got %v\n", tt.mode, keys)
+		}
+		d := o.(types.Dict)
+		if v := d.NameEntry("Key1"); v == nil || *v != tt.key1 {
+			t.Fatalf("mode %d: want Key1 %s, got %v\n", tt.mode, tt.key1, v)
+		}
+		if v := d.DictEntry("Inner").IntEntry("Key2"); v == nil || *v != tt.key2 {
+			t.Fatalf("mode %d: want Key2 %d, got %v\n", tt.mode, tt.key2, v)
+		}
+	}
+}
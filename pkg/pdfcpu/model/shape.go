@@ -0,0 +1,224 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/draw"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// ShapeKind represents a vector primitive usable as watermark/stamp.
+type ShapeKind int
+
+const (
+	ShapeLine ShapeKind = iota
+	ShapeRect
+	ShapeRoundRect
+	ShapeEllipse
+)
+
+var shapeKinds = map[string]ShapeKind{
+	"line":      ShapeLine,
+	"rect":      ShapeRect,
+	"roundrect": ShapeRoundRect,
+	"ellipse":   ShapeEllipse,
+}
+
+// Shape represents a vector primitive in user space.
+type Shape struct {
+	Kind   ShapeKind
+	Dx, Dy float64 // ShapeLine: the line runs from (0,0) to (Dx,Dy).
+	Width  float64 // ShapeRect, ShapeRoundRect, ShapeEllipse
+	Height float64 // ShapeRect, ShapeRoundRect, ShapeEllipse
+	Radius float64 // ShapeRoundRect: corner radius
+}
+
+func (sh Shape) String() string {
+	switch sh.Kind {
+	case ShapeLine:
+		return fmt.Sprintf("line %.2f %.2f", sh.Dx, sh.Dy)
+	case ShapeRect:
+		return fmt.Sprintf("rect %.2f %.2f", sh.Width, sh.Height)
+	case ShapeRoundRect:
+		return fmt.Sprintf("roundrect %.2f %.2f %.2f", sh.Width, sh.Height, sh.Radius)
+	}
+	return fmt.Sprintf("ellipse %.2f %.2f", sh.Width, sh.Height)
+}
+
+// ParseShape parses a shape definition in display unit u:
+//
+//	line dx dy
+//	rect w h
+//	roundrect w h r
+//	ellipse w h
+func ParseShape(s string, u types.DisplayUnit) (*Shape, error) {
+	ss := strings.Fields(s)
+	if len(ss) == 0 {
+		return nil, errors.New("pdfcpu: missing shape, use one of: line, rect, roundrect, ellipse")
+	}
+
+	kind, ok := shapeKinds[strings.ToLower(ss[0])]
+	if !ok {
+		return nil, errors.Errorf("pdfcpu: unsupported shape: %s, use one of: line, rect, roundrect, ellipse\n", ss[0])
+	}
+
+	want := 2
+	if kind == ShapeRoundRect {
+		want = 3
+	}
+	if len(ss)-1 != want {
+		return nil, errors.Errorf("pdfcpu: %s needs %d values: %s\n", ss[0], want, s)
+	}
+
+	ff := make([]float64, want)
+	for i, s1 := range ss[1:] {
+		f, err := strconv.ParseFloat(s1, 64)
+		if err != nil {
+			return nil, errors.Errorf("pdfcpu: shape values must be float values: %s\n", s)
+		}
+		ff[i] = types.ToUserSpace(f, u)
+	}
+
+	sh := &Shape{Kind: kind}
+
+	if kind == ShapeLine {
+		sh.Dx, sh.Dy = ff[0], ff[1]
+		if sh.Dx == 0 && sh.Dy == 0 {
+			return nil, errors.New("pdfcpu: line must not be empty")
+		}
+		return sh, nil
+	}
+
+	sh.Width, sh.Height = ff[0], ff[1]
+	if sh.Width <= 0 || sh.Height <= 0 {
+		return nil, errors.Errorf("pdfcpu: %s width and height must be > 0: %s\n", ss[0], s)
+	}
+
+	if kind == ShapeRoundRect {
+		sh.Radius = ff[2]
+		if sh.Radius < 0 || 2*sh.Radius > math.Min(sh.Width, sh.Height) {
+			return nil, errors.Errorf("pdfcpu: roundrect radius must be >= 0 and fit into the box: %s\n", s)
+		}
+	}
+
+	return sh, nil
+}
+
+// stroked returns true if the outline of sh gets painted for render mode rm.
+func (sh Shape) stroked(rm draw.RenderMode) bool {
+	return sh.Kind == ShapeLine || rm != draw.RMFill
+}
+
+// BoundingBox returns the bounding box of sh including its outline for given line width and render mode.
+func (sh Shape) BoundingBox(lineWidth float64, rm draw.RenderMode) *types.Rectangle {
+	w, h := sh.Width, sh.Height
+	if sh.Kind == ShapeLine {
+		w, h = math.Abs(sh.Dx), math.Abs(sh.Dy)
+	}
+	if sh.stroked(rm) {
+		w += lineWidth
+		h += lineWidth
+	}
+	return types.RectForDim(w, h)
+}
+
+// Approximation of a quarter circle by a cubic Bézier curve.
+const kappa = 0.5523
+
+func ellipse(w io.Writer, r *types.Rectangle) {
+	cx, cy := r.Center().X, r.Center().Y
+	rx, ry := r.Width()/2, r.Height()/2
+	kx, ky := kappa*rx, kappa*ry
+	fmt.Fprintf(w, "%.2f %.2f m ", cx+rx, cy)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", cx+rx, cy+ky, cx+kx, cy+ry, cx, cy+ry)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", cx-kx, cy+ry, cx-rx, cy+ky, cx-rx, cy)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", cx-rx, cy-ky, cx-kx, cy-ry, cx, cy-ry)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c h ", cx+kx, cy-ry, cx+rx, cy-ky, cx+rx, cy)
+}
+
+func roundRect(w io.Writer, r *types.Rectangle, rad float64) {
+	k := kappa * rad
+	x1, y1, x2, y2 := r.LL.X, r.LL.Y, r.UR.X, r.UR.Y
+	fmt.Fprintf(w, "%.2f %.2f m ", x1+rad, y1)
+	fmt.Fprintf(w, "%.2f %.2f l ", x2-rad, y1)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x2-rad+k, y1, x2, y1+rad-k, x2, y1+rad)
+	fmt.Fprintf(w, "%.2f %.2f l ", x2, y2-rad)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x2, y2-rad+k, x2-rad+k, y2, x2-rad, y2)
+	fmt.Fprintf(w, "%.2f %.2f l ", x1+rad, y2)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x1+rad-k, y2, x1, y2-rad+k, x1, y2-rad)
+	fmt.Fprintf(w, "%.2f %.2f l ", x1, y1+rad)
+	fmt.Fprintf(w, "%.2f %.2f %.2f %.2f %.2f %.2f c h ", x1, y1+rad-k, x1+rad-k, y1, x1+rad, y1)
+}
+
+// ShapeFormContent renders the shape of wm into its bounding box wm.Bb.
+func ShapeFormContent(w io.Writer, wm Watermark) {
+	sh := wm.Shape
+	bb := sh.BoundingBox(wm.LineWidth, wm.RenderMode)
+	sc := wm.Bb.Width() / bb.Width()
+
+	fmt.Fprintf(w, "q %.5f 0 0 %.5f 0 0 cm ", sc, sc)
+
+	var off float64
+	if sh.stroked(wm.RenderMode) {
+		off = wm.LineWidth / 2
+		draw.SetLineWidth(w, wm.LineWidth)
+		draw.SetStrokeColor(w, wm.StrokeColor)
+	}
+	draw.SetFillColor(w, wm.FillColor)
+
+	r := types.RectForWidthAndHeight(off, off, sh.Width, sh.Height)
+
+	switch sh.Kind {
+
+	case ShapeLine:
+		x1, y1, x2, y2 := off, off, off+sh.Dx, off+sh.Dy
+		if sh.Dx < 0 {
+			x1, x2 = x1-sh.Dx, x2-sh.Dx
+		}
+		if sh.Dy < 0 {
+			y1, y2 = y1-sh.Dy, y2-sh.Dy
+		}
+		fmt.Fprintf(w, "%.2f %.2f m %.2f %.2f l S Q", x1, y1, x2, y2)
+		return
+
+	case ShapeRect:
+		fmt.Fprintf(w, "%.2f %.2f %.2f %.2f re ", r.LL.X, r.LL.Y, r.Width(), r.Height())
+
+	case ShapeRoundRect:
+		roundRect(w, r, sh.Radius)
+
+	case ShapeEllipse:
+		ellipse(w, r)
+	}
+
+	op := "f"
+	switch wm.RenderMode {
+	case draw.RMStroke:
+		op = "S"
+	case draw.RMFillAndStroke:
+		op = "B"
+	}
+
+	fmt.Fprintf(w, "%s Q", op)
+}
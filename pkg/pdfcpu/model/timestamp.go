@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// localeNames holds month and day names for a language.
+type localeNames struct {
+	months, monthsShort [12]string
+	days, daysShort     [7]string // starting with Sunday
+}
+
+var locales = map[string]localeNames{
+	"de": {
+		months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		monthsShort: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		daysShort:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"es": {
+		months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		monthsShort: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		daysShort:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"fr": {
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		monthsShort: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		daysShort:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"it": {
+		months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		monthsShort: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:        [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		daysShort:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"nl": {
+		months:      [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		monthsShort: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:        [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		daysShort:   [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"pt": {
+		months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		monthsShort: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		days:        [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		daysShort:   [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	},
+	"sv": {
+		months:      [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		monthsShort: [12]string{"jan", "feb", "mars", "apr", "maj", "juni", "juli", "aug", "sep", "okt", "nov", "dec"},
+		days:        [7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+		daysShort:   [7]string{"sön", "mån", "tis", "ons", "tors", "fre", "lör"},
+	},
+}
+
+// language returns the language part of locale eg. "de" for "de-AT" or "de_CH".
+func language(locale string) string {
+	s := strings.ToLower(locale)
+	if i := strings.IndexAny(s, "-_"); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// SupportedLocales returns the languages supported for month and day names besides English.
+func SupportedLocales() []string {
+	ss := make([]string, 0, len(locales))
+	for k := range locales {
+		ss = append(ss, k)
+	}
+	sort.Strings(ss)
+	return ss
+}
+
+// Timestamp represents a timestamp format.
+type Timestamp struct {
+	Layout   string         // Go reference time layout eg. "2006-01-02 15:04" or "Monday, 2 January 2006"
+	Locale   string         // Language of month and day names eg. "de" or "fr-CA", defaults to English.
+	Location *time.Location // Time zone, defaults to local time.
+}
+
+// NewTimestamp returns a timestamp format for layout, locale and an IANA time zone name like "Europe/Vienna".
+func NewTimestamp(layout, locale, timeZone string) (Timestamp, error) {
+	ts := Timestamp{Layout: layout}
+
+	if locale != "" {
+		if l := language(locale); l != "en" {
+			if _, ok := locales[l]; !ok {
+				return ts, errors.Errorf("pdfcpu: unsupported locale: %s, use one of: en, %s", locale, strings.Join(SupportedLocales(), ", "))
+			}
+		}
+		ts.Locale = locale
+	}
+
+	if timeZone != "" {
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return ts, errors.Errorf("pdfcpu: unknown time zone: %s", timeZone)
+		}
+		ts.Location = loc
+	}
+
+	return ts, nil
+}
+
+// Timestamp returns the timestamp format of c.
+func (c *Configuration) Timestamp() Timestamp {
+	ts, err := NewTimestamp(c.TimestampFormat, c.TimestampLocale, c.TimestampTimeZone)
+	if err != nil {
+		// Fall back to English and local time.
+		return Timestamp{Layout: c.TimestampFormat}
+	}
+	return ts
+}
+
+func startsWithLowerCase(s string) bool {
+	return len(s) > 0 && 'a' <= s[0] && s[0] <= 'z'
+}
+
+// nextName returns the position and length of the next month or day name element in layout.
+func nextName(layout string) (int, string) {
+	for i := 0; i < len(layout); i++ {
+		s := layout[i:]
+		for _, e := range []string{"January", "Monday"} {
+			if strings.HasPrefix(s, e) {
+				return i, e
+			}
+		}
+		// Go treats Jan and Mon as layout elements unless followed by a lower case letter.
+		for _, e := range []string{"Jan", "Mon"} {
+			if strings.HasPrefix(s, e) && !startsWithLowerCase(s[len(e):]) {
+				return i, e
+			}
+		}
+	}
+	return -1, ""
+}
+
+// Format returns a textual representation of t according to ts.
+func (ts Timestamp) Format(t time.Time) string {
+	if ts.Location != nil {
+		t = t.In(ts.Location)
+	}
+
+	names, ok := locales[language(ts.Locale)]
+	if !ok {
+		return t.Format(ts.Layout)
+	}
+
+	var sb strings.Builder
+	layout := ts.Layout
+
+	for {
+		i, e := nextName(layout)
+		if i < 0 {
+			sb.WriteString(t.Format(layout))
+			break
+		}
+		sb.WriteString(t.Format(layout[:i]))
+		switch e {
+		case "January":
+			sb.WriteString(names.months[t.Month()-1])
+		case "Jan":
+			sb.WriteString(names.monthsShort[t.Month()-1])
+		case "Monday":
+			sb.WriteString(names.days[t.Weekday()])
+		case "Mon":
+			sb.WriteString(names.daysShort[t.Weekday()])
+		}
+		layout = layout[i+len(e):]
+	}
+
+	return sb.String()
+}
+
+// Now returns the current time formatted according to ts.
+func (ts Timestamp) Now() string {
+	return ts.Format(time.Now())
+}
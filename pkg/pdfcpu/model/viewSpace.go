@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"math"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// View space is the coordinate system of a page as displayed by a viewer:
+// the origin is the lower left corner of the visible (crop) box after applying the page rotation.
+// Page space is the default user space of a page which is unaffected by the page rotation.
+
+func normalizedRotation(rot int) int {
+	return ((rot % 360) + 360) % 360
+}
+
+// PageToView converts the point (x,y) from page space into view space
+// for a page with crop box cropBox rotated clockwise by rot degrees.
+func PageToView(x, y float64, cropBox *types.Rectangle, rot int) (float64, float64) {
+	u, v := x-cropBox.LL.X, y-cropBox.LL.Y
+	w, h := cropBox.Width(), cropBox.Height()
+
+	switch normalizedRotation(rot) {
+	case 90:
+		return v, w - u
+	case 180:
+		return w - u, h - v
+	case 270:
+		return h - v, u
+	}
+
+	return u, v
+}
+
+// ViewToPage converts the point (x,y) from view space into page space
+// for a page with crop box cropBox rotated clockwise by rot degrees.
+func ViewToPage(x, y float64, cropBox *types.Rectangle, rot int) (float64, float64) {
+	w, h := cropBox.Width(), cropBox.Height()
+
+	var u, v float64
+
+	switch normalizedRotation(rot) {
+	case 90:
+		u, v = w-y, x
+	case 180:
+		u, v = w-x, h-y
+	case 270:
+		u, v = y, h-x
+	default:
+		u, v = x, y
+	}
+
+	return u + cropBox.LL.X, v + cropBox.LL.Y
+}
+
+func convertRect(r *types.Rectangle, f func(x, y float64) (float64, float64)) *types.Rectangle {
+	x1, y1 := f(r.LL.X, r.LL.Y)
+	x2, y2 := f(r.UR.X, r.UR.Y)
+	return types.NewRectangle(math.Min(x1, x2), math.Min(y1, y2), math.Max(x1, x2), math.Max(y1, y2))
+}
+
+// PageRectToView converts r from page space into view space.
+func PageRectToView(r, cropBox *types.Rectangle, rot int) *types.Rectangle {
+	return convertRect(r, func(x, y float64) (float64, float64) { return PageToView(x, y, cropBox, rot) })
+}
+
+// ViewRectToPage converts r from view space into page space.
+func ViewRectToPage(r, cropBox *types.Rectangle, rot int) *types.Rectangle {
+	return convertRect(r, func(x, y float64) (float64, float64) { return ViewToPage(x, y, cropBox, rot) })
+}
+
+// ViewSpaceAnnotation wraps an annotation whose coordinates are given in view space.
+// Use it with any of the annotation adding APIs in order to place annotations on rotated pages
+// the way they are seen in a viewer.
+type ViewSpaceAnnotation struct {
+	AnnotationRenderer
+}
+
+// NewViewSpaceAnnotation returns ar using view space coordinates.
+func NewViewSpaceAnnotation(ar AnnotationRenderer) ViewSpaceAnnotation {
+	return ViewSpaceAnnotation{AnnotationRenderer: ar}
+}
+
+func viewPointsToPage(xRefTable *XRefTable, a types.Array, cropBox *types.Rectangle, rot int) (types.Array, error) {
+	if len(a)%2 != 0 {
+		return nil, errors.New("pdfcpu: odd number of coordinates")
+	}
+
+	a1 := make(types.Array, len(a))
+	for i := 0; i < len(a); i += 2 {
+		x, err := xRefTable.DereferenceNumber(a[i])
+		if err != nil {
+			return nil, err
+		}
+		y, err := xRefTable.DereferenceNumber(a[i+1])
+		if err != nil {
+			return nil, err
+		}
+		x, y = ViewToPage(x, y, cropBox, rot)
+		a1[i], a1[i+1] = types.Float(x), types.Float(y)
+	}
+
+	return a1, nil
+}
+
+// RenderDict renders the wrapped annotation and converts its coordinates into the page space of pageIndRef.
+func (ann ViewSpaceAnnotation) RenderDict(xRefTable *XRefTable, pageIndRef types.IndirectRef) (types.Dict, error) {
+	d, err := ann.AnnotationRenderer.RenderDict(xRefTable, pageIndRef)
+	if err != nil || d == nil {
+		return d, err
+	}
+
+	pageNr, err := xRefTable.PageNumber(pageIndRef.ObjectNumber.Value())
+	if err != nil {
+		return nil, err
+	}
+	if pageNr == 0 {
+		return nil, errors.Errorf("pdfcpu: unknown page obj#%d", pageIndRef.ObjectNumber.Value())
+	}
+
+	pbs, err := xRefTable.PageBoundaries(types.IntSet{pageNr: true})
+	if err != nil {
+		return nil, err
+	}
+	pb := pbs[pageNr-1]
+	cropBox, rot := pb.CropBox(), pb.Rot
+
+	if a, ok := d["Rect"].(types.Array); ok {
+		r, err := rect(xRefTable, a)
+		if err != nil {
+			return nil, err
+		}
+		d["Rect"] = ViewRectToPage(r, cropBox, rot).Array()
+	}
+
+	if a, ok := d["QuadPoints"].(types.Array); ok {
+		if d["QuadPoints"], err = viewPointsToPage(xRefTable, a, cropBox, rot); err != nil {
+			return nil, err
+		}
+	}
+
+	if a, ok := d["InkList"].(types.Array); ok {
+		for i, o := range a {
+			a1, ok := o.(types.Array)
+			if !ok {
+				continue
+			}
+			if a[i], err = viewPointsToPage(xRefTable, a1, cropBox, rot); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return d, nil
+}
@@ -44,6 +44,18 @@ const (
 	WMText = iota
 	WMImage
 	WMPDF
+	WMShape
+	WMSVG
+)
+
+// WMIDPrefix prefixes the ID tagging the content of each watermark, see Watermark.ID.
+const WMIDPrefix = "pdfcpuWM:"
+
+// Subtypes of the pagination artifacts wrapping watermarks.
+const (
+	ArtifactWatermark = "Watermark"
+	ArtifactHeader    = "Header"
+	ArtifactFooter    = "Footer"
 )
 
 type formCache map[types.Rectangle]*types.IndirectRef
@@ -54,20 +66,37 @@ type PdfResources struct {
 	Bb      *types.Rectangle // visible region in user space
 }
 
+// DocFields holds document values for place holders in stamp text.
+type DocFields struct {
+	FileName string            // base name of the input file.
+	Info     map[string]string // info dict entries like Title or Author and custom properties.
+}
+
 // Watermark represents the basic structure and command details for the commands "Stamp" and "Watermark".
 type Watermark struct {
 	// configuration
-	Mode              int                 // WMText, WMImage or WMPDF
+	Mode              int                 // WMText, WMImage, WMPDF, WMShape or WMSVG
+	Name              string              // identifies the watermark for selective removal, defaults to "stamp" or "watermark".
+	Artifact          string              // subtype of the pagination artifact wrapping the watermark, defaults to Watermark.
 	TextString        string              // raw display text.
 	TextLines         []string            // display multiple lines of text.
 	URL               string              // overlay link annotation for stamps.
-	FileName          string              // display pdf page or png image.
-	Image             io.Reader           // reader for image watermark.
+	FileName          string              // display pdf page, png image or svg.
+	Image             io.Reader           // reader for image or SVG watermark.
+	Stencil           bool                // paint a 1-bit image watermark as stencil mask using the fill color.
+	Shape             *Shape              // vector primitive for shape watermarks.
+	LineWidth         float64             // line width for stroking shapes.
+	TimestampFormat   string              // layout for %t overriding the configured timestamp format.
+	Locale            string              // language of month and day names for %t.
+	TimeZone          string              // IANA time zone name for %t.
+	Doc               *DocFields          // document values for %Filename and %Info(key), set while stamping.
 	Page              int                 // the page number of a PDF file. 0 means multistamp/multiwatermark.
 	OnTop             bool                // if true this is a STAMP else this is a WATERMARK.
 	InpUnit           types.DisplayUnit   // input display unit.
 	Pos               types.Anchor        // position anchor, one of tl,tc,tr,l,c,r,bl,bc,br.
 	Dx, Dy            float64             // anchor offset.
+	DxRel, DyRel      float64             // anchor offset relative to the anchor box dimensions.
+	PageBox           string              // anchor box, one of media, crop (default), trim, bleed, art.
 	HAlign            *types.HAlignment   // horizonal alignment for text watermarks.
 	FontName          string              // supported are Adobe base fonts only. (as of now: Helvetica, Times-Roman, Courier)
 	FontSize          int                 // font scaling factor.
@@ -86,6 +115,9 @@ type Watermark struct {
 	Diagonal          int                 // paint along the diagonal.
 	UserRotOrDiagonal bool                // true if one of rotation or diagonal provided overriding the default.
 	Opacity           float64             // opacity of the watermark. 0 <= x <= 1
+	BlendMode         string              // blend mode, eg. Multiply or Darken. Defaults to Normal.
+	Isolated          bool                // paint the watermark as isolated transparency group.
+	Knockout          bool                // paint the watermark as knockout transparency group.
 	RenderMode        draw.RenderMode     // fill=0, stroke=1 fill&stroke=2
 	Scale             float64             // relative scale factor: 0 <= x <= 1, absolute scale factor: 0 <= x
 	ScaleEff          float64             // effective scale factor
@@ -106,6 +138,7 @@ type Watermark struct {
 	Bb      *types.Rectangle   // bounding box of the form representing this watermark.
 	BbTrans types.QuadLiteral  // Transformed bounding box.
 	Vp      *types.Rectangle   // page dimensions.
+	Ab      *types.Rectangle   // anchor box if other than Vp.
 	PageRot int                // page rotation in effect.
 	Form    *types.IndirectRef // Forms are dependent on given page dimensions.
 
@@ -130,6 +163,7 @@ func DefaultWatermarkConfig() *Watermark {
 		Diagonal:    DiagonalLLToUR,
 		Opacity:     1.0,
 		RenderMode:  draw.RMFill,
+		LineWidth:   1,
 		PdfRes:      map[int]PdfResources{},
 		Objs:        types.IntSet{},
 		FCache:      formCache{},
@@ -158,6 +192,16 @@ func (wm Watermark) IsImage() bool {
 	return wm.Mode == WMImage
 }
 
+// IsShape returns true if the watermark content is a vector primitive.
+func (wm Watermark) IsShape() bool {
+	return wm.Mode == WMShape
+}
+
+// IsSVG returns true if the watermark content is SVG.
+func (wm Watermark) IsSVG() bool {
+	return wm.Mode == WMSVG
+}
+
 // Typ returns the nature of wm.
 func (wm Watermark) Typ() string {
 	if wm.IsImage() {
@@ -166,6 +210,12 @@ func (wm Watermark) Typ() string {
 	if wm.IsPDF() {
 		return "pdf"
 	}
+	if wm.IsShape() {
+		return "shape"
+	}
+	if wm.IsSVG() {
+		return "svg"
+	}
 	return "text"
 }
 
@@ -179,6 +229,9 @@ func (wm Watermark) String() string {
 	if len(t) == 0 {
 		t = wm.FileName
 	}
+	if wm.IsShape() {
+		t = wm.Shape.String()
+	}
 
 	sc := "relative"
 	if wm.ScaleAbs {
@@ -231,6 +284,23 @@ func (wm Watermark) OnTopString() string {
 	return s
 }
 
+// ID returns the ID wm gets tagged with: "pdfcpuWM:<name>".
+func (wm Watermark) ID() string {
+	name := wm.Name
+	if name == "" {
+		name = wm.OnTopString()
+	}
+	return WMIDPrefix + name
+}
+
+// ArtifactSubtype returns the subtype of the pagination artifact wrapping wm.
+func (wm Watermark) ArtifactSubtype() string {
+	if wm.Artifact == "" {
+		return ArtifactWatermark
+	}
+	return wm.Artifact
+}
+
 // MultiStamp returns true if wm is a multi stamp.
 func (wm Watermark) MultiStamp() bool {
 	return wm.Page == 0
@@ -240,7 +310,11 @@ func (wm Watermark) MultiStamp() bool {
 func (wm *Watermark) CalcBoundingBox(pageNr int) {
 	bb := types.RectForDim(float64(wm.Width), float64(wm.Height))
 
-	if wm.IsPDF() {
+	if wm.IsShape() {
+		bb = wm.Shape.BoundingBox(wm.LineWidth, wm.RenderMode)
+	}
+
+	if wm.IsPDF() || wm.IsSVG() {
 		wm.bbPDF = wm.PdfRes[wm.Page].Bb
 		if wm.MultiStamp() {
 			i := pageNr
@@ -432,19 +506,27 @@ func (wm *Watermark) CalcTransformMatrix() matrix.Matrix {
 	cos = math.Cos(float64(r) * float64(DegToRad))
 
 	var dx, dy float64
-	if !wm.IsImage() && !wm.IsPDF() {
+	if !wm.IsImage() && !wm.IsPDF() && !wm.IsSVG() {
 		dy = wm.Bb.LL.Y
 	}
 
-	ll := LowerLeftCorner(wm.Vp, wm.Bb.Width(), wm.Bb.Height(), wm.Pos)
+	ab := wm.Vp
+	if wm.Ab != nil {
+		ab = wm.Ab
+	}
+
+	ll := LowerLeftCorner(ab, wm.Bb.Width(), wm.Bb.Height(), wm.Pos)
+
+	offX := wm.Dx + wm.DxRel*ab.Width()
+	offY := wm.Dy + wm.DyRel*ab.Height()
 
 	if wm.Pos != types.Center && (r == 90 || r == -90) {
 		dx, dy = wm.alignWithPageBoundaries()
-		dx = ll.X + dx + wm.Dx
-		dy = ll.Y + dy + wm.Dy
+		dx = ll.X + dx + offX
+		dy = ll.Y + dy + offY
 	} else {
-		dx = ll.X + wm.Bb.Width()/2 + wm.Dx + sin*(wm.Bb.Height()/2+dy) - cos*wm.Bb.Width()/2
-		dy = ll.Y + wm.Bb.Height()/2 + wm.Dy - cos*(wm.Bb.Height()/2+dy) - sin*wm.Bb.Width()/2
+		dx = ll.X + wm.Bb.Width()/2 + offX + sin*(wm.Bb.Height()/2+dy) - cos*wm.Bb.Width()/2
+		dy = ll.Y + wm.Bb.Height()/2 + offY - cos*(wm.Bb.Height()/2+dy) - sin*wm.Bb.Width()/2
 	}
 
 	return matrix.CalcTransformMatrix(1, 1, sin, cos, dx, dy)
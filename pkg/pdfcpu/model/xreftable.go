@@ -53,6 +53,96 @@ type XRefTableEntry struct {
 	ObjectStream    *int
 	ObjectStreamInd *int
 	Valid           bool
+	Source          ObjectSource // Where offset and generation of this entry were taken from while reading.
+}
+
+// ObjectSource identifies where the location of an object was taken from while reading.
+type ObjectSource int
+
+const (
+	// SourceNone applies to objects created in memory.
+	SourceNone ObjectSource = iota
+
+	// SourceXRefTable applies to objects declared by an xref table section.
+	SourceXRefTable
+
+	// SourceXRefStream applies to uncompressed objects declared by an xref stream.
+	SourceXRefStream
+
+	// SourceObjectStream applies to compressed objects declared by an xref stream.
+	SourceObjectStream
+
+	// SourceRepairScan applies to objects located by scanning the file.
+	SourceRepairScan
+)
+
+func (src ObjectSource) String() string {
+	switch src {
+	case SourceXRefTable:
+		return "xrefTable"
+	case SourceXRefStream:
+		return "xrefStream"
+	case SourceObjectStream:
+		return "objectStream"
+	case SourceRepairScan:
+		return "repairScan"
+	}
+	return "none"
+}
+
+// ObjectProvenance describes where an object was read from.
+type ObjectProvenance struct {
+	ObjNr           int    `json:"objNr"`
+	Generation      int    `json:"generation"`
+	Free            bool   `json:"free,omitempty"`
+	Source          string `json:"source"`
+	Offset          *int64 `json:"offset,omitempty"`          // File offset of an uncompressed object.
+	ObjectStream    *int   `json:"objectStream,omitempty"`    // Object number of the object stream holding a compressed object.
+	ObjectStreamInd *int   `json:"objectStreamInd,omitempty"` // Index of a compressed object within its object stream.
+}
+
+// ObjectProvenance returns where object objNr was read from.
+func (xRefTable *XRefTable) ObjectProvenance(objNr int) (*ObjectProvenance, error) {
+	entry, found := xRefTable.Find(objNr)
+	if !found {
+		return nil, errors.Errorf("pdfcpu: ObjectProvenance: invalid object number: %d", objNr)
+	}
+
+	op := &ObjectProvenance{ObjNr: objNr, Free: entry.Free, Source: entry.Source.String()}
+
+	if entry.Generation != nil {
+		op.Generation = *entry.Generation
+	}
+
+	// Decompressed objects keep their object stream reference.
+	if entry.ObjectStream != nil {
+		op.ObjectStream = entry.ObjectStream
+		op.ObjectStreamInd = entry.ObjectStreamInd
+	} else if !entry.Free {
+		op.Offset = entry.Offset
+	}
+
+	return op, nil
+}
+
+// ObjectProvenances returns where all objects were read from sorted by object number.
+func (xRefTable *XRefTable) ObjectProvenances() []ObjectProvenance {
+	var keys []int
+	for k := range xRefTable.Table {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	var ops []ObjectProvenance
+	for _, objNr := range keys {
+		op, err := xRefTable.ObjectProvenance(objNr)
+		if err != nil {
+			continue
+		}
+		ops = append(ops, *op)
+	}
+
+	return ops
 }
 
 // NewXRefTableEntryGen0 returns a cross reference table entry for an object with generation 0.
@@ -154,6 +244,7 @@ type XRefTable struct {
 	ValidateLinks  bool                      // check for broken links in LinkAnnotations/URIDicts.
 	Valid          bool                      // true means successful validated against ISO 32000.
 	URIs           map[int]map[string]string // URIs for link checking
+	Warnings       []string                  // Tolerated irregularities like duplicate dict keys.
 
 	Optimized      bool
 	Watermarked    bool
@@ -164,6 +255,12 @@ type XRefTable struct {
 
 	// Fonts
 	UsedGIDs map[string]map[uint16]bool
+
+	// Lazy loading
+	Loader func(objNr int, entry *XRefTableEntry) error // Loads objects on first access, see Configuration.LazyLoading.
+
+	// Concurrent read-only access
+	Frozen bool // true, after Context.Freeze, rejects new objects and skips bookkeeping on dereference.
 }
 
 // NewXRefTable creates a new XRefTable.
@@ -235,7 +332,11 @@ func (xRefTable *XRefTable) IsMerging() bool {
 // EnsureVersionForWriting sets the version to the highest supported PDF Version 1.7.
 // This is necessary to allow validation after adding features not supported
 // by the original version of a document as during watermarking.
+// PDF 2.0 documents keep their version.
 func (xRefTable *XRefTable) EnsureVersionForWriting() {
+	if xRefTable.HeaderVersion != nil && xRefTable.Version() == V20 {
+		return
+	}
 	v := V17
 	xRefTable.RootVersion = &v
 }
@@ -278,12 +379,45 @@ func (xRefTable *XRefTable) Find(objNr int) (*XRefTableEntry, bool) {
 	return e, true
 }
 
+// load parses the object of entry from the underlying source unless already loaded.
+func (xRefTable *XRefTable) load(objNr int, entry *XRefTableEntry) error {
+	if xRefTable.Loader == nil || entry.Free || entry.Object != nil {
+		return nil
+	}
+	return xRefTable.Loader(objNr, entry)
+}
+
+// LoadAll loads all objects deferred by lazy loading.
+// Operations relying on a complete view of all objects, eg. when rewriting references, need to call this first.
+func (xRefTable *XRefTable) LoadAll() error {
+	if xRefTable.Loader == nil {
+		return nil
+	}
+
+	objNrs := make([]int, 0, len(xRefTable.Table))
+	for objNr := range xRefTable.Table {
+		objNrs = append(objNrs, objNr)
+	}
+	sort.Ints(objNrs)
+	for _, objNr := range objNrs {
+		if err := xRefTable.load(objNr, xRefTable.Table[objNr]); err != nil {
+			return err
+		}
+	}
+	xRefTable.Loader = nil
+
+	return nil
+}
+
 // FindObject returns the object of the XRefTableEntry for a specific object number.
 func (xRefTable *XRefTable) FindObject(objNr int) (types.Object, error) {
 	entry, ok := xRefTable.Find(objNr)
 	if !ok {
 		return nil, errors.Errorf("FindObject: obj#%d not registered in xRefTable", objNr)
 	}
+	if err := xRefTable.load(objNr, entry); err != nil {
+		return nil, err
+	}
 	return entry.Object, nil
 }
 
@@ -312,7 +446,7 @@ func (xRefTable *XRefTable) NextForFree(objNr int) (int, error) {
 
 // FindTableEntryLight returns the XRefTable entry for given object number.
 func (xRefTable *XRefTable) FindTableEntryLight(objNr int) (*XRefTableEntry, bool) {
-	return xRefTable.Find(objNr)
+	return xRefTable.findLoaded(objNr)
 }
 
 // FindTableEntry returns the XRefTable entry for given object and generation numbers.
@@ -320,7 +454,20 @@ func (xRefTable *XRefTable) FindTableEntry(objNr int, genNr int) (*XRefTableEntr
 	if log.TraceEnabled() {
 		log.Trace.Printf("FindTableEntry: obj#:%d gen:%d \n", objNr, genNr)
 	}
-	return xRefTable.Find(objNr)
+	return xRefTable.findLoaded(objNr)
+}
+
+// findLoaded returns the XRefTable entry for given object number with its object loaded.
+// Load errors are surfaced by FindObject and Dereference.
+func (xRefTable *XRefTable) findLoaded(objNr int) (*XRefTableEntry, bool) {
+	entry, found := xRefTable.Find(objNr)
+	if !found {
+		return nil, false
+	}
+	if err := xRefTable.load(objNr, entry); err != nil && log.ReadEnabled() {
+		log.Read.Printf("findLoaded: obj#%d: %v\n", objNr, err)
+	}
+	return entry, true
 }
 
 // FindTableEntryForIndRef returns the XRefTable entry for given indirect reference.
@@ -338,6 +485,9 @@ func (xRefTable *XRefTable) IncrementRefCount(indRef *types.IndirectRef) {
 	}
 }
 
+// ErrFrozen is returned when adding objects to a context prepared for concurrent read-only access.
+var ErrFrozen = errors.New("pdfcpu: context is frozen for read-only access")
+
 // InsertNew adds given xRefTableEntry at next new objNumber into the cross reference table.
 // Only to be called once an xRefTable has been generated completely and all trailer dicts have been processed.
 // xRefTable.Size is the size entry of the first trailer dict processed.
@@ -360,6 +510,10 @@ func (xRefTable *XRefTable) InsertAndUseRecycled(xRefTableEntry XRefTableEntry)
 	// This is because pdfcpu does not reuse objects
 	// in an incremental fashion like laid out in the PDF spec.
 
+	if xRefTable.Frozen {
+		return 0, ErrFrozen
+	}
+
 	if log.WriteEnabled() {
 		log.Write.Println("InsertAndUseRecycled: begin")
 	}
@@ -408,6 +562,9 @@ func (xRefTable *XRefTable) InsertAndUseRecycled(xRefTableEntry XRefTableEntry)
 
 // InsertObject inserts an object into the xRefTable.
 func (xRefTable *XRefTable) InsertObject(obj types.Object) (objNr int, err error) {
+	if xRefTable.Frozen {
+		return 0, ErrFrozen
+	}
 	xRefTableEntry := NewXRefTableEntryGen0(obj)
 	xRefTableEntry.RefCount = 1
 	return xRefTable.InsertNew(*xRefTableEntry), nil
@@ -479,6 +636,11 @@ func (xRefTable *XRefTable) locateObjForIndRef(ir types.IndirectRef) (types.Obje
 		return nil, errors.Errorf("pdfcpu: locateObjForIndRef: no xref entry found for obj #%d\n", objNr)
 	}
 
+	// Ref counts are incomplete if lazy loading is in effect.
+	if xRefTable.Loader != nil {
+		return nil, nil
+	}
+
 	// Check for multiple indRefs.
 	if entry.RefCount > 1 {
 		entry.RefCount--
@@ -503,7 +665,7 @@ func (xRefTable *XRefTable) FreeObject(objNr int) error {
 		return err
 	}
 
-	entry, found := xRefTable.FindTableEntryLight(objNr)
+	entry, found := xRefTable.Find(objNr)
 	if !found {
 		return errors.Errorf("FreeObject: no entry for obj #%d\n", objNr)
 	}
@@ -928,7 +1090,7 @@ func (xRefTable *XRefTable) DereferenceStreamDict(o types.Object) (*types.Stream
 		return nil, false, nil
 	}
 	ev := entry.Valid
-	if !entry.Valid {
+	if !entry.Valid && !xRefTable.Frozen {
 		entry.Valid = true
 	}
 	sd, ok := entry.Object.(types.StreamDict)
@@ -2036,7 +2198,8 @@ func (xRefTable *XRefTable) EnsurePageCount() error {
 	return nil
 }
 
-func (xRefTable *XRefTable) resolvePageBoundary(d types.Dict, boxName string) (*types.Rectangle, error) {
+// ResolvePageBoundary returns the page boundary boxName of page dict d or nil if not present.
+func (xRefTable *XRefTable) ResolvePageBoundary(d types.Dict, boxName string) (*types.Rectangle, error) {
 	obj, found := d.Find(boxName)
 	if !found {
 		return nil, nil
@@ -2052,7 +2215,7 @@ func (xRefTable *XRefTable) collectPageBoundariesForPage(d types.Dict, pb []Page
 	if inhMediaBox != nil {
 		pb[p].Media = &Box{Rect: inhMediaBox, Inherited: true}
 	}
-	r, err := xRefTable.resolvePageBoundary(d, "MediaBox")
+	r, err := xRefTable.ResolvePageBoundary(d, "MediaBox")
 	if err != nil {
 		return err
 	}
@@ -2067,7 +2230,7 @@ func (xRefTable *XRefTable) collectPageBoundariesForPage(d types.Dict, pb []Page
 	if inhCropBox != nil {
 		pb[p].Crop = &Box{Rect: inhCropBox, Inherited: true}
 	}
-	r, err = xRefTable.resolvePageBoundary(d, "CropBox")
+	r, err = xRefTable.ResolvePageBoundary(d, "CropBox")
 	if err != nil {
 		return err
 	}
@@ -2075,7 +2238,7 @@ func (xRefTable *XRefTable) collectPageBoundariesForPage(d types.Dict, pb []Page
 		pb[p].Crop = &Box{Rect: r, Inherited: false}
 	}
 
-	r, err = xRefTable.resolvePageBoundary(d, "TrimBox")
+	r, err = xRefTable.ResolvePageBoundary(d, "TrimBox")
 	if err != nil {
 		return err
 	}
@@ -2083,7 +2246,7 @@ func (xRefTable *XRefTable) collectPageBoundariesForPage(d types.Dict, pb []Page
 		pb[p].Trim = &Box{Rect: r}
 	}
 
-	r, err = xRefTable.resolvePageBoundary(d, "BleedBox")
+	r, err = xRefTable.ResolvePageBoundary(d, "BleedBox")
 	if err != nil {
 		return err
 	}
@@ -2091,7 +2254,7 @@ func (xRefTable *XRefTable) collectPageBoundariesForPage(d types.Dict, pb []Page
 		pb[p].Bleed = &Box{Rect: r}
 	}
 
-	r, err = xRefTable.resolvePageBoundary(d, "ArtBox")
+	r, err = xRefTable.ResolvePageBoundary(d, "ArtBox")
 	if err != nil {
 		return err
 	}
@@ -2307,65 +2470,63 @@ func (xRefTable *XRefTable) PageDims() ([]types.Dim, error) {
 	return dims, nil
 }
 
-func (xRefTable *XRefTable) emptyPage(parentIndRef *types.IndirectRef, mediaBox *types.Rectangle) (*types.IndirectRef, error) {
-	sd, _ := xRefTable.NewStreamDictForBuf(nil)
-
-	if err := sd.Encode(); err != nil {
-		return nil, err
-	}
-
-	contentsIndRef, err := xRefTable.IndRefForNewObject(*sd)
-	if err != nil {
-		return nil, err
-	}
-
-	pageDict := types.Dict(
+// blankPageDict returns a page dict for a blank page adjacent to a page with attributes pAttrs.
+// Unless bp.PageDim is set the blank page takes over MediaBox, CropBox and Rotate.
+func blankPageDict(parentIndRef types.IndirectRef, pAttrs *InheritedPageAttrs, bp BlankPages) (types.Dict, error) {
+	d := types.Dict(
 		map[string]types.Object{
 			"Type":      types.Name("Page"),
-			"Parent":    *parentIndRef,
+			"Parent":    parentIndRef,
 			"Resources": types.NewDict(),
-			"MediaBox":  mediaBox.Array(),
-			"Contents":  *contentsIndRef,
 		},
 	)
 
-	return xRefTable.IndRefForNewObject(pageDict)
-}
-
-func (xRefTable *XRefTable) pageMediaBox(d *types.Dict) (*types.Rectangle, error) {
-	o, found := d.Find("MediaBox")
-	if !found {
-		return nil, errors.Errorf("pdfcpu: pageMediaBox: missing mediaBox")
+	if bp.PageDim != nil {
+		mediaBox := types.RectForDim(bp.PageDim.Width, bp.PageDim.Height)
+		d["MediaBox"] = mediaBox.Array()
+		if pAttrs.CropBox != nil {
+			// Don't inherit a crop box.
+			d["CropBox"] = mediaBox.Array()
+		}
+		// Don't inherit a rotation.
+		d["Rotate"] = types.Integer(0)
+		return d, nil
 	}
 
-	a, err := xRefTable.DereferenceArray(o)
-	if err != nil {
-		return nil, err
+	if pAttrs.MediaBox == nil {
+		return nil, errors.New("pdfcpu: InsertBlankPages: missing mediaBox")
 	}
+	d["MediaBox"] = pAttrs.MediaBox.Array()
+	if pAttrs.CropBox != nil {
+		d["CropBox"] = pAttrs.CropBox.Array()
+	}
+	d["Rotate"] = types.Integer(pAttrs.Rotate)
 
-	return rect(xRefTable, a)
+	return d, nil
 }
 
-func (xRefTable *XRefTable) insertEmptyPage(root *types.IndirectRef, pAttrs *InheritedPageAttrs, pageNodeDict types.Dict) (indRef *types.IndirectRef, err error) {
-	mediaBox := pAttrs.MediaBox
-	if mediaBox == nil {
-		mediaBox, err = xRefTable.pageMediaBox(&pageNodeDict)
-		if err != nil {
-			return nil, err
-		}
+func (xRefTable *XRefTable) insertBlankPage(root *types.IndirectRef, pAttrs InheritedPageAttrs, pageDict types.Dict, bp BlankPages) (*types.IndirectRef, error) {
+	// Apply the attributes of the adjacent page.
+	if err := xRefTable.checkInheritedPageAttrs(pageDict, &pAttrs, false); err != nil {
+		return nil, err
 	}
 
-	return xRefTable.emptyPage(root, mediaBox)
+	d, err := blankPageDict(*root, &pAttrs, bp)
+	if err != nil {
+		return nil, err
+	}
+
+	return xRefTable.IndRefForNewObject(d)
 }
 
-func (xRefTable *XRefTable) insertBlankPagesIntoPageTree(root *types.IndirectRef, pAttrs *InheritedPageAttrs, p *int, selectedPages types.IntSet, before bool) (int, error) {
+func (xRefTable *XRefTable) insertBlankPagesIntoPageTree(root *types.IndirectRef, pAttrs InheritedPageAttrs, p, inserted *int, selectedPages types.IntSet, bp BlankPages) (int, error) {
 	d, err := xRefTable.DereferenceDict(*root)
 	if err != nil {
 		return 0, err
 	}
 
 	consolidateRes := false
-	if err = xRefTable.checkInheritedPageAttrs(d, pAttrs, consolidateRes); err != nil {
+	if err = xRefTable.checkInheritedPageAttrs(d, &pAttrs, consolidateRes); err != nil {
 		return 0, err
 	}
 
@@ -2398,7 +2559,7 @@ func (xRefTable *XRefTable) insertBlankPagesIntoPageTree(root *types.IndirectRef
 
 		case "Pages":
 			// Recurse over sub pagetree.
-			j, err := xRefTable.insertBlankPagesIntoPageTree(&ir, pAttrs, p, selectedPages, before)
+			j, err := xRefTable.insertBlankPagesIntoPageTree(&ir, pAttrs, p, inserted, selectedPages, bp)
 			if err != nil {
 				return 0, err
 			}
@@ -2407,24 +2568,26 @@ func (xRefTable *XRefTable) insertBlankPagesIntoPageTree(root *types.IndirectRef
 
 		case "Page":
 			*p++
-			if !before {
+
+			// In recto mode insert only if the page would start on a verso page.
+			if !selectedPages[*p] || (bp.Recto && (*p+*inserted)%2 == 1) {
 				a = append(a, ir)
 				i++
+				continue
 			}
-			if selectedPages[*p] {
-				// Insert empty page.
-				indRef, err := xRefTable.insertEmptyPage(root, pAttrs, pageNodeDict)
-				if err != nil {
-					return 0, err
-				}
 
-				a = append(a, *indRef)
-				i++
+			indRef, err := xRefTable.insertBlankPage(root, pAttrs, pageNodeDict, bp)
+			if err != nil {
+				return 0, err
 			}
-			if before {
-				a = append(a, ir)
-				i++
+
+			if bp.Before || bp.Recto {
+				a = append(a, *indRef, ir)
+			} else {
+				a = append(a, ir, *indRef)
 			}
+			i += 2
+			*inserted++
 		}
 
 	}
@@ -2435,19 +2598,23 @@ func (xRefTable *XRefTable) insertBlankPagesIntoPageTree(root *types.IndirectRef
 	return i, nil
 }
 
-// InsertBlankPages inserts a blank page before or after each selected page.
-func (xRefTable *XRefTable) InsertBlankPages(pages types.IntSet, before bool) error {
+// InsertBlankPages inserts blank pages for selected pages as configured by bp.
+// Unless bp.PageDim is set a blank page takes over MediaBox, CropBox and Rotate of its adjacent page.
+func (xRefTable *XRefTable) InsertBlankPages(pages types.IntSet, bp BlankPages) error {
 	root, err := xRefTable.Pages()
 	if err != nil {
 		return err
 	}
 
-	var inhPAttrs InheritedPageAttrs
-	p := 0
+	p, inserted := 0, 0
+
+	if _, err = xRefTable.insertBlankPagesIntoPageTree(root, InheritedPageAttrs{}, &p, &inserted, pages, bp); err != nil {
+		return err
+	}
 
-	_, err = xRefTable.insertBlankPagesIntoPageTree(root, &inhPAttrs, &p, pages, before)
+	xRefTable.PageCount += inserted
 
-	return err
+	return nil
 }
 
 // StreamDictIndRef creates a new stream dict for bb.
@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// MultiStamp represents a single stamp to be applied in a multi stamp run.
+//
+// Either Text or File (an image or a PDF file) is required.
+// X and Y position the stamp relative to the lower left corner of the page using the configured display unit.
+// Desc takes any additional stamp parameters, eg. "font:Courier, points:12".
+type MultiStamp struct {
+	PageNr   int      `json:"page"`
+	Text     string   `json:"text,omitempty"`
+	File     string   `json:"file,omitempty"`
+	X        *float64 `json:"x,omitempty"`
+	Y        *float64 `json:"y,omitempty"`
+	Rotation *float64 `json:"rotation,omitempty"`
+	Scale    string   `json:"scale,omitempty"` // eg. "0.5 rel" or "2 abs"
+	Desc     string   `json:"desc,omitempty"`
+}
+
+// MultiStamps represents the JSON structure of a multi stamp file.
+type MultiStamps struct {
+	Stamps []MultiStamp `json:"stamps"`
+}
+
+// Watermark returns the stamp configuration for ms.
+func (ms MultiStamp) Watermark(u types.DisplayUnit) (*model.Watermark, error) {
+	if ms.PageNr < 1 {
+		return nil, errors.Errorf("pdfcpu: invalid page number: %d", ms.PageNr)
+	}
+
+	var (
+		wm  *model.Watermark
+		err error
+	)
+
+	switch {
+	case ms.Text != "" && ms.File != "":
+		return nil, errors.New("pdfcpu: please specify text or file")
+	case ms.Text != "":
+		wm, err = ParseTextWatermarkDetails(ms.Text, ms.Desc, true, u)
+	case strings.HasSuffix(strings.ToLower(strings.Split(ms.File, ":")[0]), ".pdf"):
+		wm, err = ParsePDFWatermarkDetails(ms.File, ms.Desc, true, u)
+	case ms.File != "":
+		wm, err = ParseImageWatermarkDetails(ms.File, ms.Desc, true, u)
+	default:
+		return nil, errors.New("pdfcpu: missing text or file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ms.X != nil || ms.Y != nil {
+		wm.Pos = types.BottomLeft
+		if ms.X != nil {
+			wm.Dx = types.ToUserSpace(*ms.X, u)
+		}
+		if ms.Y != nil {
+			wm.Dy = types.ToUserSpace(*ms.Y, u)
+		}
+	}
+
+	if ms.Rotation != nil {
+		if err := parseRotation(strconv.FormatFloat(*ms.Rotation, 'f', -1, 64), wm); err != nil {
+			return nil, err
+		}
+	}
+
+	if ms.Scale != "" {
+		if err := parseScaleFactorWM(ms.Scale, wm); err != nil {
+			return nil, err
+		}
+	}
+
+	return wm, nil
+}
+
+func parseOptionalFloat(s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func multiStampForCSVRecord(header map[string]int, rec []string) (MultiStamp, error) {
+	var ms MultiStamp
+
+	val := func(col string) string {
+		if i, ok := header[col]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	i, err := strconv.Atoi(val("page"))
+	if err != nil {
+		return ms, errors.Errorf("invalid page number: %s", val("page"))
+	}
+	ms.PageNr = i
+
+	ms.Text, ms.File, ms.Scale, ms.Desc = val("text"), val("file"), val("scale"), val("desc")
+
+	if ms.X, err = parseOptionalFloat(val("x")); err != nil {
+		return ms, errors.Errorf("invalid x: %s", val("x"))
+	}
+	if ms.Y, err = parseOptionalFloat(val("y")); err != nil {
+		return ms, errors.Errorf("invalid y: %s", val("y"))
+	}
+	if ms.Rotation, err = parseOptionalFloat(val("rotation")); err != nil {
+		return ms, errors.Errorf("invalid rotation: %s", val("rotation"))
+	}
+
+	return ms, nil
+}
+
+// ParseMultiStampsCSV parses stamps from CSV data.
+// The first row holds the column names: page, text, file, x, y, rotation, scale and desc where only page is mandatory.
+//
+//	page,text,file,x,y,rotation,scale,desc
+//	1,No. 0001,,500,20,0,1 abs,"font:Courier, points:10"
+//	2,,logo.png,20,20,45,0.2 rel,
+func ParseMultiStampsCSV(r io.Reader) ([]MultiStamp, error) {
+	recs, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(recs) < 2 {
+		return nil, errors.New("pdfcpu: invalid multistamp CSV: missing header or data")
+	}
+
+	header := map[string]int{}
+	for i, s := range recs[0] {
+		header[strings.ToLower(strings.TrimSpace(s))] = i
+	}
+	if _, ok := header["page"]; !ok {
+		return nil, errors.New("pdfcpu: invalid multistamp CSV: missing column \"page\"")
+	}
+
+	stamps := make([]MultiStamp, 0, len(recs)-1)
+
+	for i, rec := range recs[1:] {
+		ms, err := multiStampForCSVRecord(header, rec)
+		if err != nil {
+			return nil, errors.Errorf("pdfcpu: multistamp CSV line %d: %v", i+2, err)
+		}
+		stamps = append(stamps, ms)
+	}
+
+	return stamps, nil
+}
+
+// ParseMultiStampsJSON parses stamps from JSON data.
+//
+//	{"stamps": [{"page": 1, "text": "No. 0001", "x": 500, "y": 20, "desc": "font:Courier, points:10"}]}
+func ParseMultiStampsJSON(r io.Reader) ([]MultiStamp, error) {
+	var mss MultiStamps
+	if err := json.NewDecoder(r).Decode(&mss); err != nil {
+		return nil, err
+	}
+	if len(mss.Stamps) == 0 {
+		return nil, errors.New("pdfcpu: invalid multistamp JSON: missing stamps")
+	}
+	return mss.Stamps, nil
+}
+
+// MultiStampWatermarks returns the stamp configurations for stamps by page number.
+func MultiStampWatermarks(stamps []MultiStamp, u types.DisplayUnit) (map[int][]*model.Watermark, error) {
+	m := map[int][]*model.Watermark{}
+
+	for i, ms := range stamps {
+		wm, err := ms.Watermark(u)
+		if err != nil {
+			return nil, errors.Errorf("pdfcpu: stamp %d: %v", i+1, err)
+		}
+		m[ms.PageNr] = append(m[ms.PageNr], wm)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A file name template is made up of literal text and tokens of the form %[0][width]name[(arg)]:
+//
+//	%basename      the input file name without extension
+//	%d             the first page of the output file, eg. %04d => 0007
+//	%page          the page span of the output file, eg. 7 or 7-9
+//	%bookmark      the bookmark title
+//	%bates(start)  the Bates number of the first page counting from start (default: 1), eg. %06bates(1000)
+//	%text          the matched text
+//	%formfield(id) the value of the form field with given id or name located on the pages of the output file
+//	%%             a literal %
+//
+// The extension ".pdf" is appended unless already present.
+var reFileNameToken = regexp.MustCompile(`^%(0?)(\d*)(basename|bookmark|bates|page|text|formfield|d)(?:\(([^)]*)\))?`)
+
+// FileNameVars represents the values available for resolving a file name template.
+type FileNameVars struct {
+	BaseName string // The input file name without extension.
+	From     int    // The first page of the output file.
+	Thru     int    // The last page of the output file.
+	Bookmark string // The title of the bookmark the output file represents.
+	Text     string // The text that triggered the output file.
+
+	// FormFields holds form field values by id and name for the fields located on the pages of the output file.
+	FormFields map[string]string
+}
+
+func sanitizeFileNamePart(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func fileNameNumber(n int, zero, width string) string {
+	return fmt.Sprintf("%"+zero+width+"d", n)
+}
+
+func fileNameToken(m []string, vars FileNameVars) (string, error) {
+	zero, width, name, arg := m[1], m[2], m[3], m[4]
+
+	if arg != "" && name != "bates" && name != "formfield" {
+		return "", errors.Errorf("pdfcpu: file name template: %%%s takes no argument", name)
+	}
+
+	switch name {
+
+	case "basename":
+		return sanitizeFileNamePart(vars.BaseName), nil
+
+	case "d":
+		return fileNameNumber(vars.From, zero, width), nil
+
+	case "page":
+		s := fileNameNumber(vars.From, zero, width)
+		if vars.Thru > vars.From {
+			s += "-" + fileNameNumber(vars.Thru, zero, width)
+		}
+		return s, nil
+
+	case "bookmark":
+		return sanitizeFileNamePart(vars.Bookmark), nil
+
+	case "bates":
+		start := 1
+		if arg != "" {
+			i, err := strconv.Atoi(arg)
+			if err != nil || i < 0 {
+				return "", errors.Errorf("pdfcpu: file name template: invalid Bates start number: %s", arg)
+			}
+			start = i
+		}
+		return fileNameNumber(start+vars.From-1, zero, width), nil
+
+	case "text":
+		return sanitizeFileNamePart(vars.Text), nil
+
+	case "formfield":
+		if arg == "" {
+			return "", errors.New("pdfcpu: file name template: %formfield requires a field id or name, eg. %formfield(InvoiceNo)")
+		}
+		return sanitizeFileNamePart(vars.FormFields[arg]), nil
+	}
+
+	return "", errors.Errorf("pdfcpu: file name template: unknown token: %s", m[0])
+}
+
+// ValidateFileNameTemplate checks tmpl for syntax errors.
+func ValidateFileNameTemplate(tmpl string) error {
+	_, err := ResolveFileNameTemplate(tmpl, FileNameVars{From: 1, Thru: 1})
+	return err
+}
+
+// UsesFormFields returns true if tmpl refers to form field values.
+func UsesFormFields(tmpl string) bool {
+	return strings.Contains(tmpl, "%formfield")
+}
+
+// ResolveFileNameTemplate returns the file name for tmpl using vars.
+func ResolveFileNameTemplate(tmpl string, vars FileNameVars) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return "", errors.New("pdfcpu: file name template: missing template")
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(tmpl); {
+
+		if tmpl[i] != '%' {
+			sb.WriteByte(tmpl[i])
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(tmpl[i:], "%%") {
+			sb.WriteByte('%')
+			i += 2
+			continue
+		}
+
+		m := reFileNameToken.FindStringSubmatch(tmpl[i:])
+		if m == nil {
+			return "", errors.Errorf("pdfcpu: file name template: invalid token at: %s", tmpl[i:])
+		}
+
+		s, err := fileNameToken(m, vars)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(s)
+		i += len(m[0])
+	}
+
+	fn := sb.String()
+	if !strings.HasSuffix(strings.ToLower(fn), ".pdf") {
+		fn += ".pdf"
+	}
+
+	return fn, nil
+}
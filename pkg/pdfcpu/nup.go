@@ -61,13 +61,30 @@ var nupParamMap = nUpParamMap{
 	"formsize":        parsePageFormatNUp,
 	"papersize":       parsePageFormatNUp,
 	"orientation":     parseOrientation,
+	"fillorder":       parseFillOrder,
+	"snake":           parseSnake,
+	"rotation":        parseCellRotation,
+	"autoorient":      parseAutoOrient,
+	"alignment":       parseCellAlignment,
+	"cutandstack":     parseCutAndStack,
+	"repeat":          parseStepAndRepeat,
+	"uniform":         parseUniform,
+	"cropmarks":       parseCropMarks,
+	"regmarks":        parseRegistrationMarks,
+	"trimguides":      parseTrimGuides,
+	"bleed":           parseBleed,
 	"border":          parseElementBorder,
 	"margin":          parseElementMargin,
+	"sheetmargin":     parseSheetMargin,
+	"spacing":         parseGridSpacing,
 	"backgroundcolor": parseSheetBackgroundColor,
 	"bgcolor":         parseSheetBackgroundColor,
 	"guides":          parseBookletGuides,
 	"multifolio":      parseBookletMultifolio,
 	"foliosize":       parseBookletFolioSize,
+	"signature":       parseBookletSignature,
+	"creep":           parseBookletCreep,
+	"bindingshift":    parseBookletBindingShift,
 }
 
 // Handle applies parameter completion and if successful
@@ -121,6 +138,14 @@ func parseOrientation(s string, nup *model.NUp) error {
 		nup.Orient = model.LeftDown
 	case "dl":
 		nup.Orient = model.DownLeft
+	case "ru":
+		nup.Orient = model.RightUp
+	case "ur":
+		nup.Orient = model.UpRight
+	case "lu":
+		nup.Orient = model.LeftUp
+	case "ul":
+		nup.Orient = model.UpLeft
 	default:
 		return errors.Errorf("pdfcpu: unknown nUp orientation: %s", s)
 	}
@@ -128,6 +153,173 @@ func parseOrientation(s string, nup *model.NUp) error {
 	return nil
 }
 
+func parseFillOrder(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "rowmajor":
+		nup.Orient = model.RightDown
+	case "columnmajor", "colmajor":
+		nup.Orient = model.DownRight
+	case "righttoleft", "rtl":
+		nup.Orient = model.LeftDown
+	case "bottomup":
+		nup.Orient = model.RightUp
+	case "boustrophedon":
+		nup.Orient = model.RightDown
+		nup.Boustrophedon = true
+	default:
+		return errors.Errorf("pdfcpu: unknown nUp fill order: %s", s)
+	}
+
+	return nil
+}
+
+func parseSnake(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "on", "true", "t":
+		nup.Boustrophedon = true
+	case "off", "false", "f":
+		nup.Boustrophedon = false
+	default:
+		return errors.New("pdfcpu: nUp snake, please provide one of: on/off true/false t/f")
+	}
+
+	return nil
+}
+
+func parseCellRotation(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "auto", "on", "true", "t":
+		nup.NoAutoRotate = false
+	case "off", "false", "f":
+		nup.NoAutoRotate = true
+	default:
+		return errors.New("pdfcpu: nUp rotation, please provide one of: auto/off")
+	}
+
+	return nil
+}
+
+func parseAutoOrient(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "on", "true", "t":
+		if nup.PageGrid {
+			return errors.New("pdfcpu: autoorient not supported for grid")
+		}
+		nup.AutoOrient = true
+	case "off", "false", "f":
+		nup.AutoOrient = false
+	default:
+		return errors.New("pdfcpu: nUp autoorient, please provide one of: on/off true/false t/f")
+	}
+
+	return nil
+}
+
+func parseCellAlignment(s string, nup *model.NUp) error {
+	a, err := types.ParsePositionAnchor(strings.ToLower(s))
+	if err != nil {
+		return err
+	}
+	if a == types.Full {
+		return errors.Errorf("pdfcpu: unsupported nUp alignment: %s", s)
+	}
+	nup.Align = a
+	return nil
+}
+
+func parseCutAndStack(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "on", "true", "t":
+		nup.CutAndStack = true
+	case "off", "false", "f":
+		nup.CutAndStack = false
+	default:
+		return errors.New("pdfcpu: nUp cutandstack, please provide one of: on/off true/false t/f")
+	}
+
+	return nil
+}
+
+func parseStepAndRepeat(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "on", "true", "t":
+		nup.StepAndRepeat = true
+	case "off", "false", "f":
+		nup.StepAndRepeat = false
+	default:
+		return errors.New("pdfcpu: nUp repeat, please provide one of: on/off true/false t/f")
+	}
+
+	return nil
+}
+
+func parseUniform(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "on", "true", "t":
+		nup.Uniform = true
+	case "off", "false", "f":
+		nup.Uniform = false
+	default:
+		return errors.New("pdfcpu: nUp uniform, please provide one of: on/off true/false t/f")
+	}
+
+	return nil
+}
+
+func parseCropMarks(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "on", "true", "t":
+		nup.CropMarks = true
+	case "off", "false", "f":
+		nup.CropMarks = false
+	default:
+		return errors.New("pdfcpu: nUp cropmarks, please provide one of: on/off true/false t/f")
+	}
+
+	return nil
+}
+
+func parseRegistrationMarks(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "on", "true", "t":
+		nup.RegMarks = true
+	case "off", "false", "f":
+		nup.RegMarks = false
+	default:
+		return errors.New("pdfcpu: nUp regmarks, please provide one of: on/off true/false t/f")
+	}
+
+	return nil
+}
+
+func parseTrimGuides(s string, nup *model.NUp) error {
+	switch strings.ToLower(s) {
+	case "on", "true", "t":
+		nup.TrimGuides = true
+	case "off", "false", "f":
+		nup.TrimGuides = false
+	default:
+		return errors.New("pdfcpu: nUp trimguides, please provide one of: on/off true/false t/f")
+	}
+
+	return nil
+}
+
+func parseBleed(s string, nup *model.NUp) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+
+	if f < 0 {
+		return errors.New("pdfcpu: nUp bleed, Please provide a positive value")
+	}
+
+	nup.Bleed = types.ToUserSpace(f, nup.InpUnit)
+
+	return nil
+}
+
 func parseElementBorder(s string, nup *model.NUp) error {
 	switch strings.ToLower(s) {
 	case "on", "true", "t":
@@ -177,6 +369,46 @@ func parseBookletFolioSize(s string, nup *model.NUp) error {
 	return nil
 }
 
+func parseBookletSignature(s string, nup *model.NUp) error {
+	i, err := strconv.Atoi(s)
+	if err != nil || i <= 0 || i%4 != 0 {
+		return errors.Errorf("pdfcpu: illegal signature size: must be a positive multiple of 4, %s\n", s)
+	}
+
+	nup.Signature = i
+	return nil
+}
+
+func parseBookletCreep(s string, nup *model.NUp) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+
+	if f < 0 {
+		return errors.New("pdfcpu: booklet creep, Please provide a positive value")
+	}
+
+	nup.Creep = types.ToUserSpace(f, nup.InpUnit)
+
+	return nil
+}
+
+func parseBookletBindingShift(s string, nup *model.NUp) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+
+	if f < 0 {
+		return errors.New("pdfcpu: booklet binding shift, Please provide a positive value")
+	}
+
+	nup.BindingShift = types.ToUserSpace(f, nup.InpUnit)
+
+	return nil
+}
+
 func parseElementMargin(s string, nup *model.NUp) error {
 	f, err := strconv.ParseFloat(s, 64)
 	if err != nil {
@@ -192,6 +424,75 @@ func parseElementMargin(s string, nup *model.NUp) error {
 	return nil
 }
 
+func parseNUpFloats(s, param string, nup *model.NUp) ([]float64, error) {
+	ff := []float64{}
+	for _, s1 := range strings.Fields(s) {
+		f, err := strconv.ParseFloat(s1, 64)
+		if err != nil {
+			return nil, err
+		}
+		if f < 0 {
+			return nil, errors.Errorf("pdfcpu: nUp %s, Please provide positive values", param)
+		}
+		ff = append(ff, types.ToUserSpace(f, nup.InpUnit))
+	}
+	return ff, nil
+}
+
+func parseSheetMargin(s string, nup *model.NUp) error {
+	// i       ... all four sheet margins
+	// i j     ... top/bottom, left/right
+	// i j k   ... top, left/right, bottom
+	// i j k l ... top, right, bottom, left
+	m, err := parseNUpFloats(s, "sheetmargin", nup)
+	if err != nil {
+		return err
+	}
+
+	switch len(m) {
+	case 1:
+		nup.SheetMTop, nup.SheetMRight, nup.SheetMBot, nup.SheetMLeft = m[0], m[0], m[0], m[0]
+	case 2:
+		nup.SheetMTop, nup.SheetMRight, nup.SheetMBot, nup.SheetMLeft = m[0], m[1], m[0], m[1]
+	case 3:
+		nup.SheetMTop, nup.SheetMRight, nup.SheetMBot, nup.SheetMLeft = m[0], m[1], m[2], m[1]
+	case 4:
+		nup.SheetMTop, nup.SheetMRight, nup.SheetMBot, nup.SheetMLeft = m[0], m[1], m[2], m[3]
+	default:
+		return errors.Errorf("pdfcpu: nUp sheetmargin: need 1,2,3 or 4 values, %s\n", s)
+	}
+
+	return nil
+}
+
+func parseGridSpacing(s string, nup *model.NUp) error {
+	// i   ... gutter between rows and columns
+	// i j ... gutter between rows, gutter between columns
+	sp, err := parseNUpFloats(s, "spacing", nup)
+	if err != nil {
+		return err
+	}
+
+	switch len(sp) {
+	case 1:
+		nup.VSpacing, nup.HSpacing = sp[0], sp[0]
+	case 2:
+		nup.VSpacing, nup.HSpacing = sp[0], sp[1]
+	default:
+		return errors.Errorf("pdfcpu: nUp spacing: need 1 or 2 values, %s\n", s)
+	}
+
+	return nil
+}
+
+func validateGridSpacing(nup *model.NUp) error {
+	dx, dy := nup.GridSpacing()
+	if dx >= nup.PageDim.Width || dy >= nup.PageDim.Height {
+		return errors.New("pdfcpu: nUp sheetmargin and spacing exceed the sheet size")
+	}
+	return nil
+}
+
 func parseSheetBackgroundColor(s string, nup *model.NUp) error {
 	c, err := color.ParseColor(s)
 	if err != nil {
@@ -320,12 +621,43 @@ func nUpImagePDFBytes(w io.Writer, imgWidth, imgHeight int, nup *model.NUp, form
 	}
 }
 
-func createNUpFormForImage(xRefTable *model.XRefTable, imgIndRef *types.IndirectRef, w, h, i int) (*types.IndirectRef, error) {
+// createNUpImageResource creates an image resource for f and returns its display dimensions
+// along with the Exif orientation of JPEG images.
+func createNUpImageResource(xRefTable *model.XRefTable, f io.ReadSeeker) (*types.IndirectRef, int, int, int, error) {
+	o, err := model.JPEGOrientation(f)
+	if err != nil {
+		o = 1
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	imgIndRef, w, h, err := model.CreateImageResource(xRefTable, f, false, false)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	// Orientations 5-8 render the image rotated by 90 degrees.
+	if o >= 5 {
+		w, h = h, w
+	}
+
+	return imgIndRef, w, h, o, nil
+}
+
+func createNUpFormForImage(xRefTable *model.XRefTable, imgIndRef *types.IndirectRef, w, h, orientation, i int) (*types.IndirectRef, error) {
 	imgResID := fmt.Sprintf("Im%d", i)
 	bb := types.RectForDim(float64(w), float64(h))
 
 	var b bytes.Buffer
-	fmt.Fprintf(&b, "/%s Do ", imgResID)
+	if orientation > 1 {
+		// Render the image upright.
+		m := model.ExifOrientationMatrix(orientation)
+		fmt.Fprintf(&b, "q %.0f %.0f %.0f %.0f %.0f %.0f cm /%s Do Q ", m[0], m[1], m[2], m[3], m[4], m[5], imgResID)
+	} else {
+		fmt.Fprintf(&b, "/%s Do ", imgResID)
+	}
 
 	d := types.Dict(
 		map[string]types.Object{
@@ -371,14 +703,14 @@ func NewNUpPageForImage(xRefTable *model.XRefTable, fileName string, parentIndRe
 	defer f.Close()
 
 	// create image dict.
-	imgIndRef, w, h, err := model.CreateImageResource(xRefTable, f, false, false)
+	imgIndRef, w, h, o, err := createNUpImageResource(xRefTable, f)
 	if err != nil {
 		return nil, err
 	}
 
 	resID := 0
 
-	formIndRef, err := createNUpFormForImage(xRefTable, imgIndRef, w, h, resID)
+	formIndRef, err := createNUpFormForImage(xRefTable, imgIndRef, w, h, o, resID)
 	if err != nil {
 		return nil, err
 	}
@@ -424,8 +756,72 @@ func NewNUpPageForImage(xRefTable *model.XRefTable, fileName string, parentIndRe
 	return xRefTable.IndRefForNewObject(pageDict)
 }
 
+// NewPageForImageAtResolution creates a new page dict in xRefTable rendering the image r at its natural size for dpi.
+func NewPageForImageAtResolution(xRefTable *model.XRefTable, r io.ReadSeeker, parentIndRef *types.IndirectRef, dpi int) (*types.IndirectRef, error) {
+	if dpi <= 0 {
+		dpi = 72
+	}
+
+	imgIndRef, w, h, o, err := createNUpImageResource(xRefTable, r)
+	if err != nil {
+		return nil, err
+	}
+
+	resID := 0
+
+	formIndRef, err := createNUpFormForImage(xRefTable, imgIndRef, w, h, o, resID)
+	if err != nil {
+		return nil, err
+	}
+
+	formResID := fmt.Sprintf("Fm%d", resID)
+
+	resourceDict := types.Dict(
+		map[string]types.Object{
+			"XObject": types.Dict(map[string]types.Object{formResID: *formIndRef}),
+		},
+	)
+
+	resIndRef, err := xRefTable.IndRefForNewObject(resourceDict)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1 inch = 72 points.
+	pw := float64(w) * 72 / float64(dpi)
+	ph := float64(h) * 72 / float64(dpi)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "q %.2f 0 0 %.2f 0 0 cm /%s Do Q", pw, ph, formResID)
+	sd, _ := xRefTable.NewStreamDictForBuf(buf.Bytes())
+	if err = sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	contentsIndRef, err := xRefTable.IndRefForNewObject(*sd)
+	if err != nil {
+		return nil, err
+	}
+
+	pageDict := types.Dict(
+		map[string]types.Object{
+			"Type":      types.Name("Page"),
+			"Parent":    *parentIndRef,
+			"MediaBox":  types.RectForDim(pw, ph).Array(),
+			"Resources": *resIndRef,
+			"Contents":  *contentsIndRef,
+		},
+	)
+
+	return xRefTable.IndRefForNewObject(pageDict)
+}
+
 // NUpFromOneImage creates one page with instances of one image.
 func NUpFromOneImage(ctx *model.Context, fileName string, nup *model.NUp, pagesDict types.Dict, pagesIndRef *types.IndirectRef) error {
+	if err := validateGridSpacing(nup); err != nil {
+		return err
+	}
+
 	indRef, err := NewNUpPageForImage(ctx.XRefTable, fileName, pagesIndRef, nup)
 	if err != nil {
 		return err
@@ -440,7 +836,7 @@ func NUpFromOneImage(ctx *model.Context, fileName string, nup *model.NUp, pagesD
 	return nil
 }
 
-func wrapUpPage(ctx *model.Context, nup *model.NUp, d types.Dict, buf bytes.Buffer, pagesDict types.Dict, pagesIndRef *types.IndirectRef) error {
+func wrapUpPage(ctx *model.Context, nup *model.NUp, d types.Dict, buf bytes.Buffer, annots types.Array, pagesDict types.Dict, pagesIndRef *types.IndirectRef) error {
 	xRefTable := ctx.XRefTable
 
 	var fm model.FontMap
@@ -449,6 +845,18 @@ func wrapUpPage(ctx *model.Context, nup *model.NUp, d types.Dict, buf bytes.Buff
 		fm = model.DrawBookletGuides(nup, &buf)
 	}
 
+	if nup.CropMarks {
+		model.DrawCropMarks(nup, &buf)
+	}
+
+	if nup.RegMarks {
+		model.DrawRegistrationMarks(nup, &buf)
+	}
+
+	if nup.TrimGuides {
+		model.DrawTrimGuides(nup, &buf)
+	}
+
 	resourceDict := types.Dict(
 		map[string]types.Object{
 			"XObject": d,
@@ -492,11 +900,24 @@ func wrapUpPage(ctx *model.Context, nup *model.NUp, d types.Dict, buf bytes.Buff
 		},
 	)
 
+	if len(annots) > 0 {
+		pageDict["Annots"] = annots
+	}
+
 	indRef, err := xRefTable.IndRefForNewObject(pageDict)
 	if err != nil {
 		return err
 	}
 
+	// Bind annotations to their new page.
+	for _, o := range annots {
+		d, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		d["P"] = *indRef
+	}
+
 	if err = model.AppendPageTree(indRef, 1, pagesDict); err != nil {
 		return err
 	}
@@ -514,6 +935,25 @@ func nupPageNumber(i int, sortedPageNumbers []int) int {
 	return pageNumber
 }
 
+// cutAndStackOrder returns the source index for each of the cells of sheetCount sheets with n cells each
+// or -1 for an empty cell.
+// Cell i of sheet s takes source i*sheetCount+s so that after cutting
+// the piles of each cell position stacked in cell order collate.
+func cutAndStackOrder(count, n int) []int {
+	sheetCount := (count + n - 1) / n
+	order := make([]int, sheetCount*n)
+	for s := 0; s < sheetCount; s++ {
+		for i := 0; i < n; i++ {
+			j := i*sheetCount + s
+			if j >= count {
+				j = -1
+			}
+			order[s*n+i] = j
+		}
+	}
+	return order
+}
+
 func sortSelectedPages(pages types.IntSet) []int {
 	var pageNumbers []int
 	for k, v := range pages {
@@ -532,11 +972,50 @@ func nupPages(
 	pagesDict types.Dict,
 	pagesIndRef *types.IndirectRef) error {
 
-	var buf bytes.Buffer
+	var (
+		buf    bytes.Buffer
+		annots types.Array
+	)
 	formsResDict := types.NewDict()
 	rr := nup.RectsForGrid()
+	widgets := types.IntSet{}
 
 	sortedPageNumbers := sortSelectedPages(selectedPages)
+	if nup.StepAndRepeat {
+		// Fill each sheet with copies of a single page.
+		pageNumbers := make([]int, 0, len(sortedPageNumbers)*nup.N())
+		for _, pageNr := range sortedPageNumbers {
+			for i := 0; i < nup.N(); i++ {
+				pageNumbers = append(pageNumbers, pageNr)
+			}
+		}
+		sortedPageNumbers = pageNumbers
+	} else if nup.CutAndStack {
+		pageNumbers := make([]int, 0, len(sortedPageNumbers))
+		for _, j := range cutAndStackOrder(len(sortedPageNumbers), nup.N()) {
+			pageNr := 0
+			if j >= 0 {
+				pageNr = sortedPageNumbers[j]
+			}
+			pageNumbers = append(pageNumbers, pageNr)
+		}
+		sortedPageNumbers = pageNumbers
+	}
+	if nup.AutoOrient && nup.Uniform {
+		// A common scale requires all sheets to share their orientation.
+		if err := ctx.NUpAutoOrient(sortedPageNumbers, nup); err != nil {
+			return err
+		}
+		rr = nup.RectsForGrid()
+	}
+	if nup.Uniform {
+		f, err := ctx.NUpUniformScale(sortSelectedPages(selectedPages), nup)
+		if err != nil {
+			return err
+		}
+		nup.UniformScale = f
+	}
+
 	pageCount := len(sortedPageNumbers)
 	// pageCount must be a multiple of n.
 	// If not, we will insert blank pages at the end.
@@ -548,11 +1027,23 @@ func nupPages(
 
 		if i > 0 && i%len(rr) == 0 {
 			// Wrap complete page.
-			if err := wrapUpPage(ctx, nup, formsResDict, buf, pagesDict, pagesIndRef); err != nil {
+			if err := wrapUpPage(ctx, nup, formsResDict, buf, annots, pagesDict, pagesIndRef); err != nil {
 				return err
 			}
 			buf.Reset()
 			formsResDict = types.NewDict()
+			annots = nil
+		}
+
+		if i%len(rr) == 0 && nup.AutoOrient && !nup.Uniform {
+			j := i + len(rr)
+			if j > len(sortedPageNumbers) {
+				j = len(sortedPageNumbers)
+			}
+			if err := ctx.NUpAutoOrient(sortedPageNumbers[i:j], nup); err != nil {
+				return err
+			}
+			rr = nup.RectsForGrid()
 		}
 
 		rDest := rr[i%len(rr)]
@@ -566,20 +1057,34 @@ func nupPages(
 			continue
 		}
 
-		if err := ctx.NUpTilePDFBytesForPDF(pageNr, formsResDict, &buf, rDest, nup, false); err != nil {
+		nup1 := nup.ForSourcePage(pageNr)
+
+		if err := ctx.NUpTilePDFBytesForPDF(pageNr, formsResDict, &buf, rDest, nup1, false); err != nil {
 			return err
 		}
+
+		// Preserve links and form fields.
+		a, err := ctx.NUpAnnotsForPDF(pageNr, rDest, nup1, false, widgets)
+		if err != nil {
+			return err
+		}
+		annots = append(annots, a...)
 	}
 
 	// Wrap incomplete nUp page.
-	return wrapUpPage(ctx, nup, formsResDict, buf, pagesDict, pagesIndRef)
+	return wrapUpPage(ctx, nup, formsResDict, buf, annots, pagesDict, pagesIndRef)
 }
 
 // NUpFromMultipleImages creates pages in NUp-style rendering each image once.
 func NUpFromMultipleImages(ctx *model.Context, fileNames []string, nup *model.NUp, pagesDict types.Dict, pagesIndRef *types.IndirectRef) error {
 	if nup.PageGrid {
-		nup.PageDim.Width *= nup.Grid.Width
-		nup.PageDim.Height *= nup.Grid.Height
+		dx, dy := nup.GridSpacing()
+		nup.PageDim.Width = nup.PageDim.Width*nup.Grid.Width + dx
+		nup.PageDim.Height = nup.PageDim.Height*nup.Grid.Height + dy
+	}
+
+	if err := validateGridSpacing(nup); err != nil {
+		return err
 	}
 
 	xRefTable := ctx.XRefTable
@@ -587,6 +1092,18 @@ func NUpFromMultipleImages(ctx *model.Context, fileNames []string, nup *model.NU
 	var buf bytes.Buffer
 	rr := nup.RectsForGrid()
 
+	if nup.CutAndStack {
+		ff := make([]string, 0, len(fileNames))
+		for _, j := range cutAndStackOrder(len(fileNames), nup.N()) {
+			var fileName string
+			if j >= 0 {
+				fileName = fileNames[j]
+			}
+			ff = append(ff, fileName)
+		}
+		fileNames = ff
+	}
+
 	// fileCount must be a multiple of n.
 	// If not, we will insert blank pages at the end.
 	fileCount := len(fileNames)
@@ -598,7 +1115,7 @@ func NUpFromMultipleImages(ctx *model.Context, fileNames []string, nup *model.NU
 
 		if i > 0 && i%len(rr) == 0 {
 			// Wrap complete nUp page.
-			if err := wrapUpPage(ctx, nup, formsResDict, buf, pagesDict, pagesIndRef); err != nil {
+			if err := wrapUpPage(ctx, nup, formsResDict, buf, nil, pagesDict, pagesIndRef); err != nil {
 				return err
 			}
 			buf.Reset()
@@ -625,7 +1142,7 @@ func NUpFromMultipleImages(ctx *model.Context, fileNames []string, nup *model.NU
 			return err
 		}
 
-		imgIndRef, w, h, err := model.CreateImageResource(xRefTable, f, false, false)
+		imgIndRef, w, h, o, err := createNUpImageResource(xRefTable, f)
 		if err != nil {
 			return err
 		}
@@ -634,7 +1151,7 @@ func NUpFromMultipleImages(ctx *model.Context, fileNames []string, nup *model.NU
 			return err
 		}
 
-		formIndRef, err := createNUpFormForImage(xRefTable, imgIndRef, w, h, i)
+		formIndRef, err := createNUpFormForImage(xRefTable, imgIndRef, w, h, o, i)
 		if err != nil {
 			return err
 		}
@@ -647,11 +1164,15 @@ func NUpFromMultipleImages(ctx *model.Context, fileNames []string, nup *model.NU
 	}
 
 	// Wrap incomplete nUp page.
-	return wrapUpPage(ctx, nup, formsResDict, buf, pagesDict, pagesIndRef)
+	return wrapUpPage(ctx, nup, formsResDict, buf, nil, pagesDict, pagesIndRef)
 }
 
 // NUpFromPDF creates an n-up version of the PDF represented by xRefTable.
 func NUpFromPDF(ctx *model.Context, selectedPages types.IntSet, nup *model.NUp) error {
+	if nup.Bleed > nup.Margin {
+		return errors.New("pdfcpu: nUp bleed must not exceed margin")
+	}
+
 	var mb *types.Rectangle
 	if nup.PageDim == nil {
 		// No page dimensions specified, use cropBox of page 1 as mediaBox(=cropBox).
@@ -684,8 +1205,9 @@ func NUpFromPDF(ctx *model.Context, selectedPages types.IntSet, nup *model.NUp)
 	}
 
 	if nup.PageGrid {
-		mb.UR.X = mb.LL.X + float64(nup.Grid.Width)*mb.Width()
-		mb.UR.Y = mb.LL.Y + float64(nup.Grid.Height)*mb.Height()
+		dx, dy := nup.GridSpacing()
+		mb.UR.X = mb.LL.X + float64(nup.Grid.Width)*mb.Width() + dx
+		mb.UR.Y = mb.LL.Y + float64(nup.Grid.Height)*mb.Height() + dy
 	}
 
 	pagesDict := types.Dict(
@@ -703,6 +1225,10 @@ func NUpFromPDF(ctx *model.Context, selectedPages types.IntSet, nup *model.NUp)
 
 	nup.PageDim = &types.Dim{Width: mb.Width(), Height: mb.Height()}
 
+	if err := validateGridSpacing(nup); err != nil {
+		return err
+	}
+
 	if err = nupPages(ctx, selectedPages, nup, pagesDict, pagesIndRef); err != nil {
 		return err
 	}
@@ -1433,6 +1433,41 @@ func OptimizeXRefTable(ctx *model.Context) error {
 		return err
 	}
 
+	// Replace pathological mesh shadings by images.
+	if ctx.RasterizeShadings {
+		if _, err := RasterizeShadings(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Inline form XObjects that are not reused.
+	if ctx.InlineFormXObjects {
+		if _, err := InlineFormXObjects(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Simplify excessive vector paths.
+	if ctx.SimplifyPaths {
+		if _, err := SimplifyPaths(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Remove alternate images.
+	if ctx.StripAlternateImages {
+		if _, err := RemoveAlternateImages(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Get rid of identical font programs, ICC profiles and images eg. across merged files.
+	if ctx.DeduplicateStreams {
+		if _, err := DeduplicateStreams(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Get rid of duplicate embedded fonts and images.
 	if err := optimizeFontAndImages(ctx); err != nil {
 		return err
@@ -126,3 +126,172 @@ func AddPages(ctxSrc, ctxDest *model.Context, pageNrs []int, usePgCache bool) er
 
 	return nil
 }
+
+// insertPageTreeNodeInFrontOf moves the last kid of the page tree root of ctx holding count pages in front of page ir.
+func insertPageTreeNodeInFrontOf(ctx *model.Context, ir types.IndirectRef, count int) error {
+	rootIndRef, err := ctx.Pages()
+	if err != nil {
+		return err
+	}
+
+	rootDict, err := ctx.DereferenceDict(*rootIndRef)
+	if err != nil {
+		return err
+	}
+
+	kids := rootDict.ArrayEntry("Kids")
+	if len(kids) == 0 {
+		return errors.New("pdfcpu: insertPageTreeNode: corrupt page tree")
+	}
+
+	nodeIndRef, ok := kids[len(kids)-1].(types.IndirectRef)
+	if !ok {
+		return errors.New("pdfcpu: insertPageTreeNode: corrupt page tree")
+	}
+
+	nodeDict, err := ctx.DereferenceDict(nodeIndRef)
+	if err != nil {
+		return err
+	}
+
+	// Detach node from the page tree root.
+	rootDict.Update("Kids", append(types.Array{}, kids[:len(kids)-1]...))
+	if err := rootDict.IncrementBy("Count", -count); err != nil {
+		return err
+	}
+
+	pageDict, err := ctx.DereferenceDict(ir)
+	if err != nil {
+		return err
+	}
+
+	parentIndRef := pageDict.IndirectRefEntry("Parent")
+	if parentIndRef == nil {
+		return errors.New("pdfcpu: insertPageTreeNode: corrupt page dict")
+	}
+
+	parentDict, err := ctx.DereferenceDict(*parentIndRef)
+	if err != nil {
+		return err
+	}
+
+	kids = types.Array{}
+	for _, o := range parentDict.ArrayEntry("Kids") {
+		if kid, ok := o.(types.IndirectRef); ok && kid.ObjectNumber == ir.ObjectNumber {
+			kids = append(kids, nodeIndRef)
+		}
+		kids = append(kids, o)
+	}
+	parentDict.Update("Kids", kids)
+	nodeDict["Parent"] = *parentIndRef
+
+	// Adjust the page count of all ancestors.
+	rotated := false
+	for ir := parentIndRef; ir != nil; {
+		d, err := ctx.DereferenceDict(*ir)
+		if err != nil {
+			return err
+		}
+		if err := d.IncrementBy("Count", count); err != nil {
+			return err
+		}
+		if _, found := d.Find("Rotate"); found {
+			rotated = true
+		}
+		ir = d.IndirectRefEntry("Parent")
+	}
+
+	// The inserted pages must not inherit the rotation of their new ancestors.
+	if _, found := nodeDict.Find("Rotate"); rotated && !found {
+		nodeDict["Rotate"] = types.Integer(0)
+	}
+
+	return nil
+}
+
+// InsertPagesFrom inserts all pages of ctxSrc into ctxDest following page afterPage.
+// If afterPage is 0 the pages are inserted in front of the first page.
+// Like with MergeXRefTables ctxSrc gets consumed along the way.
+func InsertPagesFrom(ctxSrc, ctxDest *model.Context, afterPage int) error {
+	if afterPage < 0 || afterPage > ctxDest.PageCount {
+		return errors.Errorf("pdfcpu: InsertPagesFrom: invalid page number: %d", afterPage)
+	}
+
+	var err error
+
+	// Resolve the page in front of which we insert before modifying the page tree.
+	var ir *types.IndirectRef
+	if afterPage < ctxDest.PageCount {
+		if _, ir, _, err = ctxDest.PageDict(afterPage+1, false); err != nil {
+			return err
+		}
+	}
+
+	conf := ctxDest.Configuration
+	createBookmarks, dividerPages := conf.CreateBookmarks, conf.DividerPages
+	conf.CreateBookmarks, conf.DividerPages = false, false
+	defer func() {
+		conf.CreateBookmarks, conf.DividerPages = createBookmarks, dividerPages
+	}()
+
+	pageCount := ctxSrc.PageCount
+
+	// Append the pages.
+	if err := MergeXRefTables("", ctxSrc, ctxDest); err != nil {
+		return err
+	}
+
+	if ir == nil {
+		return nil
+	}
+
+	return insertPageTreeNodeInFrontOf(ctxDest, *ir, pageCount)
+}
+
+// insertPageDict inserts the page dict d into the page tree of ctx before or after the page pageDict.
+func insertPageDict(ctx *model.Context, d, pageDict types.Dict, pageIndRef *types.IndirectRef, before bool) error {
+	parentIndRef := pageDict.IndirectRefEntry("Parent")
+	if parentIndRef == nil {
+		return errors.New("pdfcpu: corrupt page dict")
+	}
+
+	parentDict, err := ctx.DereferenceDict(*parentIndRef)
+	if err != nil {
+		return err
+	}
+
+	d["Parent"] = *parentIndRef
+
+	indRef, err := ctx.IndRefForNewObject(d)
+	if err != nil {
+		return err
+	}
+
+	kids := types.Array{}
+	for _, o := range parentDict.ArrayEntry("Kids") {
+		kid, ok := o.(types.IndirectRef)
+		isPage := ok && kid.ObjectNumber == pageIndRef.ObjectNumber
+		if isPage && before {
+			kids = append(kids, *indRef)
+		}
+		kids = append(kids, o)
+		if isPage && !before {
+			kids = append(kids, *indRef)
+		}
+	}
+	parentDict.Update("Kids", kids)
+
+	// Adjust the page count of all ancestors.
+	for ir := parentIndRef; ir != nil; {
+		d, err := ctx.DereferenceDict(*ir)
+		if err != nil {
+			return err
+		}
+		if err := d.IncrementBy("Count", 1); err != nil {
+			return err
+		}
+		ir = d.IndirectRefEntry("Parent")
+	}
+
+	return nil
+}
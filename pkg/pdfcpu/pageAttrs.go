@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Page attributes which may be inherited from intermediate page tree nodes (see 7.7.3.4).
+var inheritablePageAttrs = []string{"Resources", "MediaBox", "CropBox", "Rotate"}
+
+func pushDownPageAttrs(ctx *model.Context, ir types.IndirectRef, inh types.Dict) error {
+	d, err := ctx.DereferenceDict(ir)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return errors.New("pdfcpu: corrupt page tree")
+	}
+
+	if t := d.Type(); t == nil || *t != "Pages" {
+		// Leaf node: Explicit page attributes override inherited ones.
+		for k, v := range inh {
+			if _, found := d.Find(k); !found {
+				d[k] = v.Clone()
+			}
+		}
+		return nil
+	}
+
+	inh1 := types.Dict{}
+	for k, v := range inh {
+		inh1[k] = v
+	}
+	for _, k := range inheritablePageAttrs {
+		if v, found := d.Find(k); found {
+			inh1[k] = v
+			d.Delete(k)
+		}
+	}
+
+	for _, o := range d.ArrayEntry("Kids") {
+		kid, ok := o.(types.IndirectRef)
+		if !ok {
+			return errors.New("pdfcpu: corrupt page tree")
+		}
+		if err := pushDownPageAttrs(ctx, kid, inh1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PushDownPageAttrs stores all inheritable page attributes (Resources, MediaBox, CropBox, Rotate)
+// with each page and removes them from the intermediate nodes of the page tree.
+// This way each page dict is self contained.
+func PushDownPageAttrs(ctx *model.Context) error {
+	root, err := ctx.Pages()
+	if err != nil {
+		return err
+	}
+	return pushDownPageAttrs(ctx, *root, types.Dict{})
+}
+
+// sharedPageAttr returns the value for k shared by all kids.
+func sharedPageAttr(xRefTable *model.XRefTable, kids []types.Dict, k string) (types.Object, error) {
+	var v types.Object
+	for _, d := range kids {
+		v1, found := d.Find(k)
+		if !found {
+			return nil, nil
+		}
+		if v == nil {
+			v = v1
+			continue
+		}
+		ok, err := model.EqualObjects(v, v1, xRefTable)
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func pullUpPageAttrs(ctx *model.Context, ir types.IndirectRef) (types.Dict, error) {
+	d, err := ctx.DereferenceDict(ir)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errors.New("pdfcpu: corrupt page tree")
+	}
+
+	if t := d.Type(); t == nil || *t != "Pages" {
+		return d, nil
+	}
+
+	kids := []types.Dict{}
+	for _, o := range d.ArrayEntry("Kids") {
+		kid, ok := o.(types.IndirectRef)
+		if !ok {
+			return nil, errors.New("pdfcpu: corrupt page tree")
+		}
+		d1, err := pullUpPageAttrs(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		kids = append(kids, d1)
+	}
+
+	if len(kids) == 0 {
+		return d, nil
+	}
+
+	for _, k := range inheritablePageAttrs {
+		v, err := sharedPageAttr(ctx.XRefTable, kids, k)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		// All kids override any value of d, so it is safe to replace it.
+		d[k] = v
+		for _, d1 := range kids {
+			d1.Delete(k)
+		}
+	}
+
+	return d, nil
+}
+
+// PullUpPageAttrs moves inheritable page attributes (Resources, MediaBox, CropBox, Rotate)
+// shared by all kids of an intermediate page tree node up into this node.
+// This reduces redundancy, especially for documents with many pages of the same size.
+func PullUpPageAttrs(ctx *model.Context) error {
+	root, err := ctx.Pages()
+	if err != nil {
+		return err
+	}
+	_, err = pullUpPageAttrs(ctx, *root)
+	return err
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/matrix"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// maxStatsFormDepth limits the nesting of form XObjects taken into account for page statistics.
+const maxStatsFormDepth = 8
+
+// PageStats represents content metrics of a page useful for estimating the cost of rendering it.
+// The content of form XObjects counts for each invocation.
+type PageStats struct {
+	PageNr         int            `json:"page"`
+	ContentBytes   int            `json:"contentBytes"`   // Size of the decoded content including forms.
+	Operators      int            `json:"operators"`      // Number of content stream operators.
+	OperatorCounts map[string]int `json:"operatorCounts"` // Number of occurrences by operator.
+	PathSegments   int            `json:"pathSegments"`   // Number of path construction segments, a rectangle counts 4.
+	PaintedPaths   int            `json:"paintedPaths"`   // Number of path painting operations.
+	TextRuns       int            `json:"textRuns"`       // Number of text showing operations.
+	Images         int            `json:"images"`         // Number of painted images including inline images.
+	ImagePixels    int64          `json:"imagePixels"`    // Number of image samples.
+	ImageArea      float64        `json:"imageArea"`      // Painted image area in square points.
+	Forms          int            `json:"forms"`          // Number of painted form XObjects.
+	Shadings       int            `json:"shadings"`       // Number of painted shadings.
+}
+
+type pageStatsCollector struct {
+	ctx *model.Context
+	ps  *PageStats
+}
+
+// unitSquareArea returns the area of the unit square transformed by m.
+func unitSquareArea(m matrix.Matrix) float64 {
+	return math.Abs(m[0][0]*m[1][1] - m[0][1]*m[1][0])
+}
+
+func (sc *pageStatsCollector) image(sd *types.StreamDict, ctm matrix.Matrix) {
+	sc.ps.Images++
+	sc.ps.ImageArea += unitSquareArea(ctm)
+	w, h := sd.IntEntry("Width"), sd.IntEntry("Height")
+	if w != nil && h != nil {
+		sc.ps.ImagePixels += int64(*w) * int64(*h)
+	}
+}
+
+func (sc *pageStatsCollector) inlineImage(op ContentOperation, ctm matrix.Matrix) {
+	sc.ps.Images++
+	sc.ps.ImageArea += unitSquareArea(ctm)
+	var wh [2]int
+	for i, keys := range [][]string{{"W", "Width"}, {"H", "Height"}} {
+		for _, k := range keys {
+			if v, ok := numberValue(op.ImageDict[k]); ok {
+				wh[i] = int(v)
+			}
+		}
+	}
+	sc.ps.ImagePixels += int64(wh[0]) * int64(wh[1])
+}
+
+func (sc *pageStatsCollector) xObject(resDict types.Dict, id string, ctm matrix.Matrix, depth int) error {
+	xObjDict, err := sc.ctx.DereferenceDict(resDict["XObject"])
+	if err != nil || xObjDict == nil {
+		return err
+	}
+
+	o, found := xObjDict.Find(id)
+	if !found {
+		return nil
+	}
+
+	sd, _, err := sc.ctx.DereferenceStreamDict(o)
+	if err != nil || sd == nil {
+		return err
+	}
+
+	st := sd.Subtype()
+	if st == nil {
+		return nil
+	}
+
+	switch *st {
+
+	case "Image":
+		sc.image(sd, ctm)
+
+	case "Form":
+		sc.ps.Forms++
+		if depth >= maxStatsFormDepth {
+			return nil
+		}
+		if err := sd.Decode(); err != nil {
+			return err
+		}
+		ops, err := ParseContentStream(sd.Content)
+		if err != nil {
+			return err
+		}
+		sc.ps.ContentBytes += len(sd.Content)
+		if a, err := sc.ctx.DereferenceArray(sd.Dict["Matrix"]); err == nil {
+			if m, ok := operandMatrix(a); ok {
+				ctm = m.Multiply(ctm)
+			}
+		}
+		formResDict, err := sc.ctx.DereferenceDict(sd.Dict["Resources"])
+		if err != nil {
+			return err
+		}
+		if formResDict == nil {
+			formResDict = resDict
+		}
+		return sc.process(ops, formResDict, ctm, depth+1)
+	}
+
+	return nil
+}
+
+func (sc *pageStatsCollector) process(ops []ContentOperation, resDict types.Dict, ctm matrix.Matrix, depth int) error {
+	var stack []matrix.Matrix
+
+	for _, op := range ops {
+		sc.ps.Operators++
+		sc.ps.OperatorCounts[op.Operator]++
+
+		switch op.Operator {
+
+		case "q":
+			stack = append(stack, ctm)
+
+		case "Q":
+			if len(stack) > 0 {
+				ctm, stack = stack[len(stack)-1], stack[:len(stack)-1]
+			}
+
+		case "cm":
+			if m, ok := operandMatrix(op.Operands); ok {
+				ctm = m.Multiply(ctm)
+			}
+
+		case "m", "l", "c", "v", "y", "h":
+			sc.ps.PathSegments++
+
+		case "re":
+			sc.ps.PathSegments += 4
+
+		case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*":
+			sc.ps.PaintedPaths++
+
+		case "Tj", "TJ", "'", "\"":
+			sc.ps.TextRuns++
+
+		case "sh":
+			sc.ps.Shadings++
+
+		case "BI":
+			sc.inlineImage(op, ctm)
+
+		case "Do":
+			if len(op.Operands) == 1 {
+				if id, ok := op.Operands[0].(types.Name); ok {
+					if err := sc.xObject(resDict, id.Value(), ctm, depth); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// PageContentStats returns content metrics for page pageNr.
+func PageContentStats(ctx *model.Context, pageNr int) (*PageStats, error) {
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errors.Errorf("pdfcpu: invalid page number: %d", pageNr)
+	}
+
+	ps := &PageStats{PageNr: pageNr, OperatorCounts: map[string]int{}}
+
+	bb, err := ctx.PageContent(d)
+	if err == model.ErrNoContent {
+		return ps, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ps.ContentBytes = len(bb)
+
+	ops, err := ParseContentStream(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	resDict, err := pageResourceDict(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &pageStatsCollector{ctx: ctx, ps: ps}
+	if err := sc.process(ops, resDict, matrix.IdentMatrix, 0); err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+// PageContentStatsForPages returns content metrics for all selected pages.
+func PageContentStatsForPages(ctx *model.Context, selectedPages types.IntSet) ([]PageStats, error) {
+	var pss []PageStats
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		if selectedPages != nil && !selectedPages[i] {
+			continue
+		}
+		ps, err := PageContentStats(ctx, i)
+		if err != nil {
+			return nil, errors.Wrapf(err, "pdfcpu: page %d", i)
+		}
+		pss = append(pss, *ps)
+	}
+
+	return pss, nil
+}
+
+// PageStatsReport represents the JSON structure of a page content statistics report.
+type PageStatsReport struct {
+	Header Header      `json:"header"`
+	Pages  []PageStats `json:"pages"`
+}
+
+// PageContentStatsJSON writes content metrics for all selected pages of ctx as JSON to w.
+func PageContentStatsJSON(ctx *model.Context, source string, selectedPages types.IntSet, w io.Writer) error {
+	pss, err := PageContentStatsForPages(ctx, selectedPages)
+	if err != nil {
+		return err
+	}
+
+	r := PageStatsReport{Header: header(ctx.XRefTable, source), Pages: pss}
+
+	bb, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bb)
+
+	return err
+}
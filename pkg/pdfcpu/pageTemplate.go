@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Named page templates live in the Templates name tree of the document's name dictionary (see 12.7.6).
+// A template is a page object of type Template which is not part of the page tree.
+
+func locateTemplates(ctx *model.Context, ensure bool) (*model.Node, error) {
+	xRefTable := ctx.XRefTable
+	if !xRefTable.Valid || ensure {
+		if err := xRefTable.LocateNameTree("Templates", ensure); err != nil {
+			return nil, err
+		}
+	}
+	return xRefTable.Names["Templates"], nil
+}
+
+// ListPageTemplates returns the sorted names of all page templates of ctx.
+func ListPageTemplates(ctx *model.Context) ([]string, error) {
+	root, err := locateTemplates(ctx, false)
+	if err != nil || root == nil {
+		return nil, err
+	}
+
+	ss := []string{}
+	if err := root.Process(ctx.XRefTable, func(xRefTable *model.XRefTable, k string, v *types.Object) error {
+		ss = append(ss, k)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(ss)
+
+	return ss, nil
+}
+
+// templateDict returns a template for page pageNr carrying all inherited page attributes.
+// Page tree related entries as well as annotations which are bound to a single page are dropped.
+func templateDict(ctx *model.Context, pageNr int) (types.Dict, error) {
+	d, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errors.Errorf("pdfcpu: unknown page number: %d", pageNr)
+	}
+
+	d1 := d.Clone().(types.Dict)
+	for _, k := range []string{"Parent", "Annots", "B", "StructParents", "PieceInfo"} {
+		d1.Delete(k)
+	}
+	d1["Type"] = types.Name("Template")
+
+	if _, found := d1.Find("Resources"); !found && inhPAttrs.Resources != nil {
+		d1["Resources"] = inhPAttrs.Resources.Clone()
+	}
+	if _, found := d1.Find("MediaBox"); !found && inhPAttrs.MediaBox != nil {
+		d1["MediaBox"] = inhPAttrs.MediaBox.Array()
+	}
+	if _, found := d1.Find("CropBox"); !found && inhPAttrs.CropBox != nil {
+		d1["CropBox"] = inhPAttrs.CropBox.Array()
+	}
+	if _, found := d1.Find("Rotate"); !found && inhPAttrs.Rotate != 0 {
+		d1["Rotate"] = types.Integer(inhPAttrs.Rotate)
+	}
+
+	return d1, nil
+}
+
+// AddPageTemplate adds a page template named name based on page pageNr of ctx.
+func AddPageTemplate(ctx *model.Context, name string, pageNr int) error {
+	if name == "" {
+		return errors.New("pdfcpu: missing page template name")
+	}
+
+	root, err := locateTemplates(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	if _, found := root.Value(name); found {
+		return errors.Errorf("pdfcpu: page template \"%s\" already exists", name)
+	}
+
+	d, err := templateDict(ctx, pageNr)
+	if err != nil {
+		return err
+	}
+
+	ir, err := ctx.IndRefForNewObject(d)
+	if err != nil {
+		return err
+	}
+
+	if err := root.Add(ctx.XRefTable, name, *ir, nil, nil); err != nil {
+		return err
+	}
+
+	// Sync up the name tree dicts with the internal name tree cache.
+	return ctx.BindNameTrees()
+}
+
+// RemovePageTemplates removes the page templates named names from ctx or all page templates if names is empty.
+func RemovePageTemplates(ctx *model.Context, names []string) (bool, error) {
+	root, err := locateTemplates(ctx, false)
+	if err != nil {
+		return false, err
+	}
+	if root == nil {
+		return false, errors.New("pdfcpu: no page templates available")
+	}
+
+	xRefTable := ctx.XRefTable
+
+	removeAll := func() (bool, error) {
+		delete(xRefTable.Names, "Templates")
+		return true, xRefTable.RemoveNameTree("Templates")
+	}
+
+	if len(names) == 0 {
+		return removeAll()
+	}
+
+	var removed bool
+	for _, name := range names {
+		empty, ok, err := root.Remove(xRefTable, name)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, errors.Errorf("pdfcpu: page template \"%s\" not found", name)
+		}
+		removed = true
+		if empty {
+			return removeAll()
+		}
+	}
+
+	return removed, ctx.BindNameTrees()
+}
+
+// SpawnPageTemplate instantiates the page template named name as new page after page afterPage of ctx.
+// Use afterPage = 0 to insert the new page in front of the first page.
+func SpawnPageTemplate(ctx *model.Context, name string, afterPage int) error {
+	root, err := locateTemplates(ctx, false)
+	if err != nil {
+		return err
+	}
+	if root == nil {
+		return errors.New("pdfcpu: no page templates available")
+	}
+
+	o, found := root.Value(name)
+	if !found {
+		return errors.Errorf("pdfcpu: page template \"%s\" not found", name)
+	}
+
+	d, err := ctx.DereferenceDict(o)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return errors.Errorf("pdfcpu: corrupt page template \"%s\"", name)
+	}
+
+	if afterPage < 0 || afterPage > ctx.PageCount {
+		return errors.Errorf("pdfcpu: invalid page number: %d", afterPage)
+	}
+
+	pageNr, before := afterPage, false
+	if afterPage == 0 {
+		pageNr, before = 1, true
+	}
+
+	pageDict, pageIndRef, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return err
+	}
+	if pageDict == nil {
+		return errors.Errorf("pdfcpu: unknown page number: %d", pageNr)
+	}
+
+	d1 := d.Clone().(types.Dict)
+	d1["Type"] = types.Name("Page")
+
+	if err := insertPageDict(ctx, d1, pageDict, pageIndRef, before); err != nil {
+		return err
+	}
+
+	ctx.PageCount++
+
+	return nil
+}
@@ -125,7 +125,7 @@ func (hb *HorizontalBand) renderAnchoredTextBox(
 	fontLang := font.Lang
 	fontSize := font.Size
 	col := font.col
-	t, _ := format.Text(s, pdf.TimestampFormat, pageNr, pdf.pageCount())
+	t, _ := format.TextWithTimestamp(s, pdf.timestamp, pageNr, pdf.pageCount())
 
 	id, err := pdf.idForFontName(fontName, fontLang, p.Fm, fonts, pageNr)
 	if err != nil {
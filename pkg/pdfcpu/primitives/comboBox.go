@@ -56,6 +56,7 @@ type ComboBox struct {
 	RTL             bool
 	Tab             int
 	Locked          bool
+	Required        bool
 	Debug           bool
 	Hide            bool
 }
@@ -433,6 +434,9 @@ func (cb *ComboBox) prepareFF() FieldFlags {
 		// Note: unsupported in Mac Preview
 		ff += FieldReadOnly
 	}
+	if cb.Required {
+		ff += FieldRequired
+	}
 	return ff
 }
 
@@ -58,6 +58,7 @@ type Content struct {
 	RadioButtonGroups []*RadioButtonGroup    `json:"radiobuttongroup"` // input radiobutton groups with optional label
 	ComboBoxes        []*ComboBox            `json:"combobox"`
 	ListBoxes         []*ListBox             `json:"listbox"`
+	SignatureFields   []*SignatureField      `json:"signaturefield"`
 	FieldGroups       []*FieldGroup          `json:"fieldgroup"` // rectangular container holding form elements
 	FieldGroupPool    map[string]*FieldGroup `json:"fieldgroups"`
 }
@@ -186,6 +187,9 @@ func (c *Content) validateFormPrimitives(s string) error {
 	if len(c.ListBoxes) > 0 {
 		return errors.Errorf("pdfcpu: \"listbox\" %s", s)
 	}
+	if len(c.SignatureFields) > 0 {
+		return errors.Errorf("pdfcpu: \"signaturefield\" %s", s)
+	}
 	return nil
 }
 
@@ -416,6 +420,20 @@ func (c *Content) validateListBoxes() error {
 	return nil
 }
 
+func (c *Content) validateSignatureFields() error {
+	pdf := c.page.pdf
+	if len(c.SignatureFields) > 0 {
+		for _, sf := range c.SignatureFields {
+			sf.pdf = pdf
+			sf.content = c
+			if err := sf.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Content) validate() error {
 
 	if err := c.validateBackgroundColor(); err != nil {
@@ -470,7 +488,11 @@ func (c *Content) validate() error {
 		return err
 	}
 
-	return c.validateListBoxes()
+	if err := c.validateListBoxes(); err != nil {
+		return err
+	}
+
+	return c.validateSignatureFields()
 }
 
 func (c *Content) namedFont(id string) *FormFont {
@@ -1201,6 +1223,18 @@ func (c *Content) renderListBoxes(p *model.Page, pageNr int, fonts model.FontMap
 	return nil
 }
 
+func (c *Content) renderSignatureFields(p *model.Page) error {
+	for _, sf := range c.SignatureFields {
+		if sf.Hide {
+			continue
+		}
+		if err := sf.render(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *Content) renderFieldGroups(p *model.Page, pageNr int, fonts model.FontMap) error {
 	for _, fg := range c.FieldGroups {
 		if fg.Hide {
@@ -1284,6 +1318,10 @@ func (c *Content) renderFormPrimitives(p *model.Page, pageNr int, fonts model.Fo
 		return err
 	}
 
+	if err := c.renderSignatureFields(p); err != nil {
+		return err
+	}
+
 	return c.renderFieldGroups(p, pageNr, fonts)
 }
 
@@ -57,6 +57,7 @@ type DateField struct {
 	HorAlign        types.HAlignment   `json:"-"`
 	Tab             int
 	Locked          bool
+	Required        bool
 	Debug           bool
 	Hide            bool
 }
@@ -564,6 +565,9 @@ func (df *DateField) prepareFF() FieldFlags {
 	if df.Locked {
 		ff += FieldReadOnly
 	}
+	if df.Required {
+		ff += FieldRequired
+	}
 	return ff
 }
 
@@ -736,7 +740,7 @@ func (df *DateField) prepLabel(p *model.Page, pageNr int, fonts model.FontMap) e
 
 	t := "Default"
 	if l.Value != "" {
-		t, _ = format.Text(l.Value, pdf.TimestampFormat, pageNr, pdf.pageCount())
+		t, _ = format.TextWithTimestamp(l.Value, pdf.timestamp, pageNr, pdf.pageCount())
 	}
 
 	w := float64(l.Width)
@@ -61,6 +61,7 @@ type ListBox struct {
 	RTL             bool
 	Tab             int
 	Locked          bool
+	Required        bool
 	Debug           bool
 	Hide            bool
 }
@@ -571,6 +572,9 @@ func (lb *ListBox) prepareFF() FieldFlags {
 	if lb.Locked {
 		ff += FieldReadOnly
 	}
+	if lb.Required {
+		ff += FieldRequired
+	}
 	return ff
 }
 
@@ -105,6 +105,8 @@ type PDF struct {
 	DirNames        map[string]string          `json:"dirs"`
 	FileNames       map[string]string          `json:"files"`
 	TimestampFormat string                     `json:"timestamp"`
+	Locale          string                     `json:"locale"`   // language of month and day names in timestamps
+	TimeZone        string                     `json:"timezone"` // IANA time zone name for timestamps
 	DateFormat      string                     `json:"dateFormat"`
 	Conf            *model.Configuration       `json:"-"`
 	XRefTable       *model.XRefTable           `json:"-"`
@@ -116,6 +118,7 @@ type PDF struct {
 	HasForm         bool                       `json:"-"`
 	OldFieldIDs     types.StringSet            `json:"-"`
 	httpClient      *http.Client
+	timestamp       model.Timestamp
 }
 
 func (pdf *PDF) Update() bool {
@@ -444,6 +447,20 @@ func (pdf *PDF) Validate() error {
 		pdf.TimestampFormat = pdf.Conf.TimestampFormat
 	}
 
+	if pdf.Locale == "" {
+		pdf.Locale = pdf.Conf.TimestampLocale
+	}
+
+	if pdf.TimeZone == "" {
+		pdf.TimeZone = pdf.Conf.TimestampTimeZone
+	}
+
+	ts, err := model.NewTimestamp(pdf.TimestampFormat, pdf.Locale, pdf.TimeZone)
+	if err != nil {
+		return err
+	}
+	pdf.timestamp = ts
+
 	if pdf.DateFormat == "" {
 		pdf.DateFormat = pdf.Conf.DateFormat
 	}
@@ -0,0 +1,114 @@
+/*
+	Copyright 2022 The pdfcpu Authors.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package primitives
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// NewIconForm creates a push button icon rendering the image imgIndRef at its natural size w x h.
+func NewIconForm(xRefTable *model.XRefTable, imgIndRef *types.IndirectRef, w, h int) (*types.IndirectRef, error) {
+	bb := []byte(fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", w, h))
+
+	sd, err := xRefTable.NewStreamDictForBuf(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	sd.InsertName("Type", "XObject")
+	sd.InsertName("Subtype", "Form")
+	sd.InsertInt("FormType", 1)
+	sd.Insert("BBox", types.NewNumberArray(0, 0, float64(w), float64(h)))
+	sd.Insert("Matrix", types.NewNumberArray(1, 0, 0, 1, 0, 0))
+	sd.Insert("Resources", types.Dict(
+		map[string]types.Object{
+			"XObject": types.Dict(map[string]types.Object{"Im0": *imgIndRef}),
+		},
+	))
+
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	return xRefTable.IndRefForNewObject(*sd)
+}
+
+// EnsurePushButtonIconAP makes iconIndRef the normal icon of push button widget d
+// and renders a normal appearance fitting the icon of size w x h into the widget centered and proportionally scaled.
+// Any rollover or down appearance gets dropped in favour of the new icon.
+func EnsurePushButtonIconAP(ctx *model.Context, d types.Dict, iconIndRef *types.IndirectRef, w, h int) error {
+	mk := types.Dict{}
+	if o, found := d.Find("MK"); found {
+		d1, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if d1 != nil {
+			mk = d1.Clone().(types.Dict)
+		}
+	}
+	mk["I"] = *iconIndRef
+	mk.Delete("RI")
+	mk.Delete("IX")
+	if _, found := mk.Find("TP"); !found {
+		// Icon only, no caption.
+		mk["TP"] = types.Integer(1)
+	}
+	d["MK"] = mk
+
+	r, err := types.RectForArray(d.ArrayEntry("Rect"))
+	if err != nil {
+		return err
+	}
+	bb := types.RectForDim(r.Width(), r.Height())
+
+	s := math.Min(bb.Width()/float64(w), bb.Height()/float64(h))
+	dx, dy := (bb.Width()-s*float64(w))/2, (bb.Height()-s*float64(h))/2
+
+	sd, err := ctx.NewStreamDictForBuf([]byte(fmt.Sprintf("q %.2f 0 0 %.2f %.2f %.2f cm /Ic0 Do Q", s, s, dx, dy)))
+	if err != nil {
+		return err
+	}
+
+	sd.InsertName("Type", "XObject")
+	sd.InsertName("Subtype", "Form")
+	sd.InsertInt("FormType", 1)
+	sd.Insert("BBox", types.NewNumberArray(0, 0, bb.Width(), bb.Height()))
+	sd.Insert("Matrix", types.NewNumberArray(1, 0, 0, 1, 0, 0))
+	sd.Insert("Resources", types.Dict(
+		map[string]types.Object{
+			"XObject": types.Dict(map[string]types.Object{"Ic0": *iconIndRef}),
+		},
+	))
+
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+
+	irN, err := ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+
+	d["AP"] = types.Dict(map[string]types.Object{"N": *irN})
+
+	return nil
+}
@@ -0,0 +1,337 @@
+/*
+	Copyright 2023 The pdfcpu Authors.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+		http://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package primitives
+
+import (
+	"bytes"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/color"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// SignatureField represents an unsigned form signature field.
+type SignatureField struct {
+	pdf             *PDF
+	content         *Content
+	ID              string
+	Tip             string
+	Position        [2]float64 `json:"pos"` // x,y
+	x, y            float64
+	Width           float64
+	Height          float64
+	Dx, Dy          float64
+	BoundingBox     *types.Rectangle `json:"-"`
+	Margin          *Margin          // applied to content box
+	Border          *Border
+	BackgroundColor string             `json:"bgCol"`
+	BgCol           *color.SimpleColor `json:"-"`
+	Tab             int
+	Locked          bool
+	Required        bool
+	Debug           bool
+	Hide            bool
+}
+
+func (sf *SignatureField) validateID() error {
+	if sf.ID == "" {
+		return errors.New("pdfcpu: missing field id")
+	}
+	if sf.pdf.DuplicateField(sf.ID) {
+		return errors.Errorf("pdfcpu: duplicate form field: %s", sf.ID)
+	}
+	sf.pdf.FieldIDs[sf.ID] = true
+	return nil
+}
+
+func (sf *SignatureField) validatePosition() error {
+	if sf.Position[0] < 0 || sf.Position[1] < 0 {
+		return errors.Errorf("pdfcpu: field: %s pos value < 0", sf.ID)
+	}
+	sf.x, sf.y = sf.Position[0], sf.Position[1]
+	return nil
+}
+
+func (sf *SignatureField) validateDimensions() error {
+	if sf.Width <= 0 {
+		return errors.Errorf("pdfcpu: field: %s width <= 0", sf.ID)
+	}
+	if sf.Height <= 0 {
+		return errors.Errorf("pdfcpu: field: %s height <= 0", sf.ID)
+	}
+	return nil
+}
+
+func (sf *SignatureField) validateMargin() error {
+	if sf.Margin != nil {
+		if err := sf.Margin.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sf *SignatureField) validateBorder() error {
+	if sf.Border != nil {
+		sf.Border.pdf = sf.pdf
+		if err := sf.Border.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sf *SignatureField) validateBackgroundColor() error {
+	if sf.BackgroundColor != "" {
+		sc, err := sf.pdf.parseColor(sf.BackgroundColor)
+		if err != nil {
+			return err
+		}
+		sf.BgCol = sc
+	}
+	return nil
+}
+
+func (sf *SignatureField) validateTab() error {
+	if sf.Tab < 0 {
+		return errors.Errorf("pdfcpu: field: %s negative tab value", sf.ID)
+	}
+	if sf.Tab == 0 {
+		return nil
+	}
+	page := sf.content.page
+	if page.Tabs == nil {
+		page.Tabs = types.IntSet{}
+	} else {
+		if page.Tabs[sf.Tab] {
+			return errors.Errorf("pdfcpu: field: %s duplicate tab value %d", sf.ID, sf.Tab)
+		}
+	}
+	page.Tabs[sf.Tab] = true
+	return nil
+}
+
+func (sf *SignatureField) validate() error {
+
+	if err := sf.validateID(); err != nil {
+		return err
+	}
+
+	if err := sf.validatePosition(); err != nil {
+		return err
+	}
+
+	if err := sf.validateDimensions(); err != nil {
+		return err
+	}
+
+	if err := sf.validateMargin(); err != nil {
+		return err
+	}
+
+	if err := sf.validateBorder(); err != nil {
+		return err
+	}
+
+	if err := sf.validateBackgroundColor(); err != nil {
+		return err
+	}
+
+	return sf.validateTab()
+}
+
+func (sf *SignatureField) calcMargin() (float64, float64, float64, float64, error) {
+	mTop, mRight, mBottom, mLeft := 0., 0., 0., 0.
+	if sf.Margin != nil {
+		m := sf.Margin
+		if m.Name != "" && m.Name[0] == '$' {
+			// use named margin
+			mName := m.Name[1:]
+			m0 := sf.content.namedMargin(mName)
+			if m0 == nil {
+				return mTop, mRight, mBottom, mLeft, errors.Errorf("pdfcpu: unknown named margin %s", mName)
+			}
+			m.mergeIn(m0)
+		}
+		if m.Width > 0 {
+			mTop = m.Width
+			mRight = m.Width
+			mBottom = m.Width
+			mLeft = m.Width
+		} else {
+			mTop = m.Top
+			mRight = m.Right
+			mBottom = m.Bottom
+			mLeft = m.Left
+		}
+	}
+	return mTop, mRight, mBottom, mLeft, nil
+}
+
+func (sf *SignatureField) calcBorder() (boWidth float64, boCol *color.SimpleColor) {
+	if sf.Border == nil {
+		return 0, nil
+	}
+	return sf.Border.calc()
+}
+
+// irN renders the appearance of the empty signature field.
+func (sf *SignatureField) irN() (*types.IndirectRef, error) {
+	w, h := sf.BoundingBox.Width(), sf.BoundingBox.Height()
+	boWidth, boCol := sf.calcBorder()
+
+	buf := new(bytes.Buffer)
+	tf := TextField{}
+	tf.renderBackground(buf, sf.BgCol, boCol, boWidth, w, h)
+
+	sd, err := sf.pdf.XRefTable.NewStreamDictForBuf(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	sd.InsertName("Type", "XObject")
+	sd.InsertName("Subtype", "Form")
+	sd.InsertInt("FormType", 1)
+	sd.Insert("BBox", types.NewNumberArray(0, 0, w, h))
+	sd.Insert("Matrix", types.NewNumberArray(1, 0, 0, 1, 0, 0))
+
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	return sf.pdf.XRefTable.IndRefForNewObject(*sd)
+}
+
+func (sf *SignatureField) prepareFF() FieldFlags {
+	ff := FieldFlags(0)
+	if sf.Locked {
+		ff += FieldReadOnly
+	}
+	if sf.Required {
+		ff += FieldRequired
+	}
+	return ff
+}
+
+func (sf *SignatureField) handleBorderAndMK(d types.Dict) {
+	bgCol := sf.BgCol
+	if bgCol == nil {
+		bgCol = sf.content.page.bgCol
+		if bgCol == nil {
+			bgCol = sf.pdf.bgCol
+		}
+	}
+	sf.BgCol = bgCol
+
+	boWidth, boCol := sf.calcBorder()
+
+	if bgCol != nil || boCol != nil {
+		appCharDict := types.Dict{}
+		if bgCol != nil {
+			appCharDict["BG"] = bgCol.Array()
+		}
+		if boCol != nil && sf.Border.Width > 0 {
+			appCharDict["BC"] = boCol.Array()
+		}
+		d["MK"] = appCharDict
+	}
+
+	if boWidth > 0 {
+		d["Border"] = types.NewNumberArray(0, 0, boWidth)
+	}
+}
+
+func (sf *SignatureField) prepareDict() (types.Dict, error) {
+	id, err := types.EscapeUTF16String(sf.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	d := types.Dict(
+		map[string]types.Object{
+			"Type":    types.Name("Annot"),
+			"Subtype": types.Name("Widget"),
+			"FT":      types.Name("Sig"),
+			"Rect":    sf.BoundingBox.Array(),
+			"F":       types.Integer(model.AnnPrint),
+			"T":       types.StringLiteral(*id),
+		},
+	)
+
+	if ff := sf.prepareFF(); ff > 0 {
+		d["Ff"] = types.Integer(ff)
+	}
+
+	if sf.Tip != "" {
+		tu, err := types.EscapeUTF16String(sf.Tip)
+		if err != nil {
+			return nil, err
+		}
+		d["TU"] = types.StringLiteral(*tu)
+	}
+
+	sf.handleBorderAndMK(d)
+
+	irN, err := sf.irN()
+	if err != nil {
+		return nil, err
+	}
+
+	d["AP"] = types.Dict(map[string]types.Object{"N": *irN})
+
+	return d, nil
+}
+
+func (sf *SignatureField) prepForRender() error {
+
+	mTop, mRight, mBottom, mLeft, err := sf.calcMargin()
+	if err != nil {
+		return err
+	}
+
+	x, y := sf.content.calcPosition(sf.x, sf.y, sf.Dx, sf.Dy, mTop, mRight, mBottom, mLeft)
+
+	sf.BoundingBox = types.RectForWidthAndHeight(x, y, sf.Width, sf.Height)
+
+	return nil
+}
+
+func (sf *SignatureField) render(p *model.Page) error {
+
+	if err := sf.prepForRender(); err != nil {
+		return err
+	}
+
+	d, err := sf.prepareDict()
+	if err != nil {
+		return err
+	}
+
+	ann := model.FieldAnnotation{Dict: d}
+	if sf.Tab > 0 {
+		p.AnnotTabs[sf.Tab] = ann
+	} else {
+		p.Annots = append(p.Annots, ann)
+	}
+
+	if sf.Debug || sf.pdf.Debug {
+		sf.pdf.highlightPos(p.Buf, sf.BoundingBox.LL.X, sf.BoundingBox.LL.Y, sf.content.Box())
+	}
+
+	return nil
+}
@@ -784,7 +784,7 @@ func (t *Table) renderValues(p *model.Page, pageNr int, fonts model.FontMap, col
 				}
 			}
 
-			colTd.Text, _ = format.Text(s, pdf.TimestampFormat, pageNr, pdf.pageCount())
+			colTd.Text, _ = format.TextWithTimestamp(s, pdf.timestamp, pageNr, pdf.pageCount())
 
 			row := i
 			if t.Header != nil {
@@ -857,7 +857,7 @@ func (t *Table) renderHeader(p *model.Page, pageNr int, fonts model.FontMap, col
 
 		colTd := td
 		th.calcColumnPadding(&colTd, i)
-		colTd.Text, _ = format.Text(s, pdf.TimestampFormat, pageNr, pdf.pageCount())
+		colTd.Text, _ = format.TextWithTimestamp(s, pdf.timestamp, pageNr, pdf.pageCount())
 
 		x, y := ll(0, i)
 		r := types.RectForWidthAndHeight(x, y, colWidths[i], float64(t.LineHeight))
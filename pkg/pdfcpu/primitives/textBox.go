@@ -295,7 +295,7 @@ func (tb *TextBox) prepareTextDescriptor(p *model.Page, pageNr int, fonts model.
 	fontSize := f.Size
 	col := f.col
 
-	t, _ := format.Text(tb.Value, pdf.TimestampFormat, pageNr, pdf.pageCount())
+	t, _ := format.TextWithTimestamp(tb.Value, pdf.timestamp, pageNr, pdf.pageCount())
 
 	id, err := tb.pdf.idForFontName(fontName, fontLang, p.Fm, fonts, pageNr)
 	if err != nil {
@@ -58,6 +58,7 @@ type TextField struct {
 	RTL             bool
 	Tab             int
 	Locked          bool
+	Required        bool
 	Debug           bool
 	Hide            bool
 }
@@ -548,6 +549,10 @@ func (tf *TextField) prepareFF() FieldFlags {
 		ff += FieldReadOnly
 	}
 
+	if tf.Required {
+		ff += FieldRequired
+	}
+
 	return ff
 }
 
@@ -694,7 +699,7 @@ func (tf *TextField) prepLabel(p *model.Page, pageNr int, fonts model.FontMap) e
 
 	t := "Default"
 	if l.Value != "" {
-		t, _ = format.Text(l.Value, pdf.TimestampFormat, pageNr, pdf.pageCount())
+		t, _ = format.TextWithTimestamp(l.Value, pdf.timestamp, pageNr, pdf.pageCount())
 	}
 
 	w := float64(l.Width)
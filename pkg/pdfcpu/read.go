@@ -19,11 +19,14 @@ package pdfcpu
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
+	"math"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/mjuen/pdfcpu/pkg/filter"
 	"github.com/mjuen/pdfcpu/pkg/log"
@@ -37,8 +40,9 @@ const (
 )
 
 var (
-	ErrWrongPassword       = errors.New("pdfcpu: please provide the correct password")
-	zero             int64 = 0
+	ErrWrongPassword        = errors.New("pdfcpu: please provide the correct password")
+	ErrMissingOwnerPW       = errors.New("pdfcpu: please provide the owner password with -opw")
+	zero              int64 = 0
 )
 
 // ReadFile reads in a PDF file and builds an internal structure holding its cross reference table aka the Context.
@@ -71,6 +75,10 @@ func Read(rs io.ReadSeeker, conf *model.Configuration) (*model.Context, error) {
 		return nil, err
 	}
 
+	if f, ok := rs.(*os.File); ok {
+		ctx.Read.FileName = f.Name()
+	}
+
 	if log.InfoEnabled() {
 		if ctx.Reader15 {
 			log.Info.Println("PDF Version 1.5 conforming reader")
@@ -278,7 +286,7 @@ func offsetLastXRefSection(ctx *model.Context, skip int64) (*int64, error) {
 }
 
 func createXRefTableEntry(entryType string, objNr int, offset int64, generation int) (model.XRefTableEntry, bool) {
-	entry := model.XRefTableEntry{Offset: &offset, Generation: &generation}
+	entry := model.XRefTableEntry{Offset: &offset, Generation: &generation, Source: model.SourceXRefTable}
 
 	if entryType == "n" {
 
@@ -410,13 +418,28 @@ func parseXRefTableSubSection(xRefTable *model.XRefTable, s *bufio.Scanner, fiel
 	return nil
 }
 
+// Parse an object applying the duplicate key handling in effect.
+func parseObjectWithContext(ctx *model.Context, line *string, off int64, objNr, genNr int) (types.Object, error) {
+	o, keys, err := model.ParseObjectWithDuplicateKeys(line, off, objNr, genNr, ctx.Configuration.DuplicateKeys)
+	for _, k := range keys {
+		ctx.Warnings = append(ctx.Warnings, fmt.Sprintf("obj#%d: duplicate dict key: %s", objNr, k))
+	}
+	return o, err
+}
+
 // Parse compressed object.
-func compressedObject(s string) (types.Object, error) {
+func compressedObject(ctx *model.Context, s string, objNr int) (types.Object, error) {
+	return nextCompressedObject(ctx, &s, objNr)
+}
+
+// Parse the next compressed object of s and advance s accordingly.
+func nextCompressedObject(ctx *model.Context, s *string, objNr int) (types.Object, error) {
 	if log.ReadEnabled() {
 		log.Read.Println("compressedObject: begin")
 	}
 
-	o, err := model.ParseObject(&s)
+	// Compressed objects have no file offset and generation 0.
+	o, err := parseObjectWithContext(ctx, s, -1, objNr, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -442,13 +465,12 @@ func compressedObject(s string) (types.Object, error) {
 	return nil, errors.New("pdfcpu: compressedObject: stream objects are not to be stored in an object stream")
 }
 
-// Parse all objects of an object stream and save them into objectStreamDict.ObjArray.
-func parseObjectStream(osd *types.ObjectStreamDict) error {
-	if log.ReadEnabled() {
-		log.Read.Printf("parseObjectStream begin: decoding %d objects.\n", osd.ObjCount)
-	}
-
+// Parse all objects of an object stream located by the offsets of its prolog.
+func parseObjectStreamByOffsets(ctx *model.Context, osd *types.ObjectStreamDict) (types.Array, error) {
 	decodedContent := osd.Content
+	if osd.FirstObjOffset < 0 || osd.FirstObjOffset > len(decodedContent) {
+		return nil, errors.Errorf("pdfcpu: parseObjectStream: invalid First: %d", osd.FirstObjOffset)
+	}
 	prolog := decodedContent[:osd.FirstObjOffset]
 
 	// The separator used in the prolog shall be white space
@@ -457,7 +479,7 @@ func parseObjectStream(osd *types.ObjectStreamDict) error {
 
 	objs := strings.Fields(string(prolog))
 	if len(objs)%2 > 0 {
-		return errors.New("pdfcpu: parseObjectStream: corrupt object stream dict")
+		return nil, errors.New("pdfcpu: parseObjectStream: corrupt object stream dict")
 	}
 
 	// e.g., 10 0 11 25 = 2 Objects: #10 @ offset 0, #11 @ offset 25
@@ -470,19 +492,24 @@ func parseObjectStream(osd *types.ObjectStreamDict) error {
 
 		offset, err := strconv.Atoi(objs[i+1])
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		offset += osd.FirstObjOffset
 
+		if offset < offsetOld || offset > len(decodedContent) {
+			return nil, errors.Errorf("pdfcpu: parseObjectStream: invalid offset for obj#%s", objs[i])
+		}
+
 		if i > 0 {
 			dstr := string(decodedContent[offsetOld:offset])
 			if log.ReadEnabled() {
 				log.Read.Printf("parseObjectStream: objString = %s\n", dstr)
 			}
-			o, err := compressedObject(dstr)
+			objNr, _ := strconv.Atoi(objs[i-2])
+			o, err := compressedObject(ctx, dstr, objNr)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			if log.ReadEnabled() {
@@ -496,9 +523,10 @@ func parseObjectStream(osd *types.ObjectStreamDict) error {
 			if log.ReadEnabled() {
 				log.Read.Printf("parseObjectStream: objString = %s\n", dstr)
 			}
-			o, err := compressedObject(dstr)
+			objNr, _ := strconv.Atoi(objs[i])
+			o, err := compressedObject(ctx, dstr, objNr)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			if log.ReadEnabled() {
@@ -510,6 +538,73 @@ func parseObjectStream(osd *types.ObjectStreamDict) error {
 		offsetOld = offset
 	}
 
+	if len(objArray) < osd.ObjCount {
+		return nil, errors.Errorf("pdfcpu: parseObjectStream: found %d objects, want %d", len(objArray), osd.ObjCount)
+	}
+
+	return objArray, nil
+}
+
+// Parse all objects of an object stream sequentially ignoring First and the offsets of the prolog.
+// The prolog is expected to consist of N pairs of integers.
+// Objects following the first unparsable object are lost and replaced by null.
+func rescanObjectStream(ctx *model.Context, osd *types.ObjectStreamDict) (types.Array, error) {
+	if osd.ObjCount <= 0 {
+		return nil, errors.New("pdfcpu: parseObjectStream: corrupt object stream dict")
+	}
+
+	s := string(bytes.ReplaceAll(osd.Content, []byte{0x00}, []byte{0x20}))
+
+	// Skip the prolog.
+	objNrs := make([]int, osd.ObjCount)
+	for i := 0; i < 2*osd.ObjCount; i++ {
+		s = strings.TrimLeft(s, " \t\r\n\f")
+		j := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if j <= 0 {
+			return nil, errors.New("pdfcpu: parseObjectStream: corrupt object stream prolog")
+		}
+		if i%2 == 0 {
+			objNrs[i/2], _ = strconv.Atoi(s[:j])
+		}
+		s = s[j:]
+	}
+
+	objArray := make(types.Array, osd.ObjCount)
+
+	for i := range objArray {
+		o, err := nextCompressedObject(ctx, &s, objNrs[i])
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			if log.ReadEnabled() {
+				log.Read.Printf("parseObjectStream: unable to recover obj#%d and following: %v\n", objNrs[i], err)
+			}
+			break
+		}
+		objArray[i] = o
+	}
+
+	return objArray, nil
+}
+
+// Parse all objects of an object stream and save them into objectStreamDict.ObjArray.
+// If First or the offsets of the prolog are inconsistent the content is rescanned for as many objects as possible.
+func parseObjectStream(ctx *model.Context, osd *types.ObjectStreamDict) error {
+	if log.ReadEnabled() {
+		log.Read.Printf("parseObjectStream begin: decoding %d objects.\n", osd.ObjCount)
+	}
+
+	objArray, err := parseObjectStreamByOffsets(ctx, osd)
+	if err != nil {
+		if log.ReadEnabled() {
+			log.Read.Printf("parseObjectStream: %v, rescanning\n", err)
+		}
+		if objArray, err = rescanObjectStream(ctx, osd); err != nil {
+			return err
+		}
+	}
+
 	osd.ObjArray = objArray
 
 	if log.ReadEnabled() {
@@ -519,6 +614,15 @@ func parseObjectStream(osd *types.ObjectStreamDict) error {
 	return nil
 }
 
+// bufToInt64 interprets the content of buf as a big-endian int64.
+func bufToInt64(buf []byte) (i int64) {
+	for _, b := range buf {
+		i <<= 8
+		i |= int64(b)
+	}
+	return
+}
+
 func createXRefTableEntryFromXRefStream(entry byte, objNr int, c2, c3 int64, objStreams types.IntSet) model.XRefTableEntry {
 	var xRefTableEntry model.XRefTableEntry
 
@@ -536,7 +640,8 @@ func createXRefTableEntryFromXRefStream(entry byte, objNr int, c2, c3 int64, obj
 				Free:       true,
 				Compressed: false,
 				Offset:     &c2,
-				Generation: &g}
+				Generation: &g,
+				Source:     model.SourceXRefStream}
 
 	case 0x01:
 		// in use object
@@ -550,7 +655,8 @@ func createXRefTableEntryFromXRefStream(entry byte, objNr int, c2, c3 int64, obj
 				Free:       false,
 				Compressed: false,
 				Offset:     &c2,
-				Generation: &g}
+				Generation: &g,
+				Source:     model.SourceXRefStream}
 
 	case 0x02:
 		// compressed object
@@ -566,7 +672,8 @@ func createXRefTableEntryFromXRefStream(entry byte, objNr int, c2, c3 int64, obj
 				Free:            false,
 				Compressed:      true,
 				ObjectStream:    &objNumberRef,
-				ObjectStreamInd: &objIndex}
+				ObjectStreamInd: &objIndex,
+				Source:          model.SourceObjectStream}
 
 		objStreams[objNumberRef] = true
 	}
@@ -594,7 +701,7 @@ func extractXRefTableEntriesFromXRefStream(buf []byte, xsd *types.XRefStreamDict
 		log.Read.Printf("extractXRefTableEntriesFromXRefStream: begin xrefEntryLen = %d\n", xrefEntryLen)
 	}
 
-	if len(buf)%xrefEntryLen > 0 {
+	if xrefEntryLen == 0 || len(buf)%xrefEntryLen > 0 {
 		return errors.New("pdfcpu: extractXRefTableEntriesFromXRefStream: corrupt xrefstream")
 	}
 
@@ -611,23 +718,31 @@ func extractXRefTableEntriesFromXRefStream(buf []byte, xsd *types.XRefStreamDict
 
 	j := 0
 
-	// bufToInt64 interprets the content of buf as an int64.
-	bufToInt64 := func(buf []byte) (i int64) {
-		for _, b := range buf {
-			i <<= 8
-			i |= int64(b)
-		}
-		return
-	}
-
 	for i := 0; i < len(buf) && j < len(xsd.Objects); i += xrefEntryLen {
 
 		objNr := xsd.Objects[j]
+
+		// A missing type field defaults to type 1.
+		c1 := int64(1)
+		if i1 > 0 {
+			c1 = bufToInt64(buf[i : i+i1])
+		}
+
 		i2Start := i + i1
 		c2 := bufToInt64(buf[i2Start : i2Start+i2])
 		c3 := bufToInt64(buf[i2Start+i2 : i2Start+i2+i3])
 
-		entry := createXRefTableEntryFromXRefStream(buf[i], objNr, c2, c3, ctx.Read.ObjectStreams)
+		if c2 < 0 || c3 < 0 || (c1 == 2 && c2 > math.MaxInt32) {
+			return errors.Errorf("pdfcpu: extractXRefTableEntriesFromXRefStream: corrupt entry for obj#%d", objNr)
+		}
+
+		// Entries of unknown type are references to the null object.
+		if c1 > 2 {
+			j++
+			continue
+		}
+
+		entry := createXRefTableEntryFromXRefStream(byte(c1), objNr, c2, c3, ctx.Read.ObjectStreams)
 
 		if ctx.XRefTable.Exists(objNr) {
 			if log.ReadEnabled() {
@@ -701,7 +816,8 @@ func processXRefStream(ctx *model.Context, xsd *types.XRefStreamDict, objNr, gen
 			Free:       false,
 			Offset:     offset,
 			Generation: genNr,
-			Object:     *xsd}
+			Object:     *xsd,
+			Source:     model.SourceXRefStream}
 
 	if log.ReadEnabled() {
 		log.Read.Printf("processXRefStream: Insert new xRefTable entry for Object %d\n", *objNr)
@@ -746,7 +862,7 @@ func parseXRefStream(ctx *model.Context, rd io.Reader, offset *int64) (prevOffse
 
 	objNr, genNr, err := model.ParseObjectAttributes(&l)
 	if err != nil {
-		return nil, err
+		return nil, model.LocateParseError(err, *offset, streamInd, 0, 0)
 	}
 
 	// parse this object
@@ -755,7 +871,7 @@ func parseXRefStream(ctx *model.Context, rd io.Reader, offset *int64) (prevOffse
 		log.Read.Printf("parseXRefStream: dereferencing object %d\n", *objNr)
 	}
 
-	o, err := model.ParseObject(&l)
+	o, err := parseObjectWithContext(ctx, &l, *offset+int64(streamInd-len(l)), *objNr, *genNr)
 	if err != nil {
 		return nil, errors.Wrapf(err, "parseXRefStream: no object")
 	}
@@ -1430,7 +1546,8 @@ func bypassXrefSection(ctx *model.Context) error {
 			ctx.Table[*objNr] = &model.XRefTableEntry{
 				Free:       false,
 				Offset:     &of,
-				Generation: generation}
+				Generation: generation,
+				Source:     model.SourceRepairScan}
 			bb = nil
 			withinObj = false
 		}
@@ -1985,9 +2102,10 @@ func object(ctx *model.Context, offset int64, objNr, genNr int) (o types.Object,
 	}
 
 	// Parse object number and object generation.
+	bufLen := len(l)
 	var objectNr, generationNr *int
 	if objectNr, generationNr, err = model.ParseObjectAttributes(&l); err != nil {
-		return nil, 0, 0, 0, err
+		return nil, 0, 0, 0, model.LocateParseError(err, offset, bufLen, objNr, genNr)
 	}
 
 	if objNr != *objectNr || genNr != *generationNr {
@@ -1998,15 +2116,20 @@ func object(ctx *model.Context, offset int64, objNr, genNr int) (o types.Object,
 		}
 	}
 
-	l = strings.TrimSpace(l)
-	if len(l) == 0 {
+	// File offset of the object body.
+	off := offset + int64(bufLen-len(l))
+
+	l1 := strings.TrimSpace(l)
+	if len(l1) == 0 {
 		// 7.3.9
 		// Specifying the null object as the value of a dictionary entry (7.3.7, "Dictionary Objects")
 		// shall be equivalent to omitting the entry entirely.
 		return nil, endInd, streamInd, streamOffset, err
 	}
+	off += int64(len(l) - len(strings.TrimLeftFunc(l, unicode.IsSpace)))
+	l = l1
 
-	o, err = model.ParseObject(&l)
+	o, err = parseObjectWithContext(ctx, &l, off, objNr, genNr)
 
 	return o, endInd, streamInd, streamOffset, err
 }
@@ -2172,7 +2295,7 @@ func readStreamContentBlindly(rd io.Reader) (buf []byte, err error) {
 }
 
 // Reads and returns a file buffer with length = stream length using provided reader positioned at offset.
-func readStreamContent(rd io.Reader, streamLength int) ([]byte, error) {
+func readStreamContent(rd io.Reader, streamLength int64) ([]byte, error) {
 	if log.ReadEnabled() {
 		log.Read.Printf("readStreamContent: begin streamLength:%d\n", streamLength)
 	}
@@ -2182,9 +2305,13 @@ func readStreamContent(rd io.Reader, streamLength int) ([]byte, error) {
 		return readStreamContentBlindly(rd)
 	}
 
+	if streamLength < 0 || uint64(streamLength) > math.MaxInt {
+		return nil, errors.Errorf("pdfcpu: readStreamContent: invalid stream length: %d", streamLength)
+	}
+
 	buf := make([]byte, streamLength)
 
-	for totalCount := 0; totalCount < streamLength; {
+	for totalCount := 0; totalCount < len(buf); {
 		count, err := fillBuffer(rd, buf[totalCount:])
 		if err != nil {
 			if err != io.EOF {
@@ -2254,8 +2381,15 @@ func loadEncodedStreamContent(ctx *model.Context, sd *types.StreamDict) error {
 		log.Read.Printf("loadEncodedStreamContent: seeked to offset:%d\n", newOffset)
 	}
 
+	// A stream cannot extend beyond the end of the file.
+	// For a corrupt length read until "endstream" instead of allocating a buffer of this size.
+	streamLength := *sd.StreamLength
+	if fileSize := ctx.Read.FileSize; fileSize > 0 && streamLength > fileSize-newOffset {
+		streamLength = 0
+	}
+
 	// Read content bytes.
-	rawContent, err := readStreamContent(rd, int(*sd.StreamLength))
+	rawContent, err := readStreamContent(rd, streamLength)
 	if err != nil {
 		return err
 	}
@@ -2339,20 +2473,20 @@ func decompressXRefTableEntry(xRefTable *model.XRefTable, objNr int, entry *mode
 		log.Read.Printf("decompressXRefTableEntry: compressed object %d at %d[%d]\n", objNr, *entry.ObjectStream, *entry.ObjectStreamInd)
 	}
 
-	// Resolve xRefTable entry of referenced object stream.
-	objectStreamXRefTableEntry, ok := xRefTable.Find(*entry.ObjectStream)
-	if !ok {
-		return errors.Errorf("decompressXRefTableEntry: problem dereferencing object stream %d, no xref table entry", *entry.ObjectStream)
+	// Resolve referenced object stream.
+	o, err := xRefTable.FindObject(*entry.ObjectStream)
+	if err != nil {
+		return errors.Wrapf(err, "decompressXRefTableEntry: problem dereferencing object stream %d", *entry.ObjectStream)
 	}
 
 	// Object of this entry has to be a ObjectStreamDict.
-	sd, ok := objectStreamXRefTableEntry.Object.(types.ObjectStreamDict)
+	sd, ok := o.(types.ObjectStreamDict)
 	if !ok {
 		return errors.Errorf("decompressXRefTableEntry: problem dereferencing object stream %d, no object stream", *entry.ObjectStream)
 	}
 
 	// Get indexed object from ObjectStreamDict.
-	o, err := sd.IndexedObject(*entry.ObjectStreamInd)
+	o, err = sd.IndexedObject(*entry.ObjectStreamInd)
 	if err != nil {
 		return errors.Wrapf(err, "decompressXRefTableEntry: problem dereferencing object stream %d", *entry.ObjectStream)
 	}
@@ -2409,7 +2543,7 @@ func logStream(o types.Object) {
 
 }
 
-func decodeObjectStreamObjects(sd *types.StreamDict, objNr int) (*types.ObjectStreamDict, error) {
+func decodeObjectStreamObjects(ctx *model.Context, sd *types.StreamDict, objNr int) (*types.ObjectStreamDict, error) {
 	osd, err := model.ObjectStreamDict(sd)
 	if err != nil {
 		return nil, errors.Wrapf(err, "decodeObjectStreamObjects: problem dereferencing object stream %d", objNr)
@@ -2420,7 +2554,7 @@ func decodeObjectStreamObjects(sd *types.StreamDict, objNr int) (*types.ObjectSt
 	}
 
 	// Parse all objects of this object stream and save them to ObjectStreamDict.ObjArray.
-	if err = parseObjectStream(osd); err != nil {
+	if err = parseObjectStream(ctx, osd); err != nil {
 		return nil, errors.Wrapf(err, "decodeObjectStreamObjects: problem decoding object stream %d\n", objNr)
 	}
 
@@ -2482,7 +2616,7 @@ func decodeObjectStream(ctx *model.Context, objNr int) error {
 
 	ctx.Read.UsingObjectStreams = true
 
-	osd, err := decodeObjectStreamObjects(&sd, objNr)
+	osd, err := decodeObjectStreamObjects(ctx, &sd, objNr)
 	if err != nil {
 		return err
 	}
@@ -2694,6 +2828,34 @@ func dereferenceObject(ctx *model.Context, objNr int) error {
 	return nil
 }
 
+// loadObject parses the object of entry on first access.
+// This is the loader in effect for lazy loading.
+func loadObject(ctx *model.Context, objNr int, entry *model.XRefTableEntry) error {
+	if log.ReadEnabled() {
+		log.Read.Printf("loadObject: loading object %d\n", objNr)
+	}
+
+	if ctx.Read.IsObjectStreamObject(objNr) {
+		return decodeObjectStream(ctx, objNr)
+	}
+
+	if entry.Compressed {
+		return decompressXRefTableEntry(ctx.XRefTable, objNr, entry)
+	}
+
+	if entry.Offset == nil || *entry.Offset == 0 {
+		return nil
+	}
+
+	if err := dereferenceAndLoad(ctx, objNr, entry); err != nil {
+		return err
+	}
+
+	logStream(entry.Object)
+
+	return nil
+}
+
 func processDictRefCounts(xRefTable *model.XRefTable, d types.Dict) {
 	for _, e := range d {
 		switch o1 := e.(type) {
@@ -2825,14 +2987,26 @@ func dereferenceXRefTable(ctx *model.Context, conf *model.Configuration) error {
 	}
 	//fmt.Println("pw authenticated")
 
-	// Prepare decompressed objects.
-	if err := decodeObjectStreams(ctx); err != nil {
-		return err
-	}
+	if ctx.LazyLoading {
+
+		// Defer parsing of objects and object streams until first access.
+		ctx.Read.UsingObjectStreams = len(ctx.Read.ObjectStreams) > 0
+		ctx.Loader = func(objNr int, entry *model.XRefTableEntry) error {
+			return loadObject(ctx, objNr, entry)
+		}
+
+	} else {
+
+		// Prepare decompressed objects.
+		if err := decodeObjectStreams(ctx); err != nil {
+			return err
+		}
+
+		// For each xRefTableEntry assign a Object either by parsing from file or pointing to a decompressed object.
+		if err := dereferenceObjects(ctx); err != nil {
+			return err
+		}
 
-	// For each xRefTableEntry assign a Object either by parsing from file or pointing to a decompressed object.
-	if err := dereferenceObjects(ctx); err != nil {
-		return err
 	}
 
 	// Identify an optional Version entry in the root object/catalog.
@@ -2888,7 +3062,7 @@ func handlePermissions(ctx *model.Context) error {
 	return nil
 }
 
-func setupEncryptionKey(ctx *model.Context, d types.Dict) (err error) {
+func setupEncryptionKey(ctx *model.Context, d types.Dict, userpw, ownerpw string) (err error) {
 	if ctx.E, err = supportedEncryption(ctx, d); err != nil {
 		return err
 	}
@@ -2899,17 +3073,17 @@ func setupEncryptionKey(ctx *model.Context, d types.Dict) (err error) {
 
 	var ok bool
 
-	//fmt.Printf("opw: <%s> upw: <%s> \n", ctx.OwnerPW, ctx.UserPW)
+	//fmt.Printf("opw: <%s> upw: <%s> \n", ownerpw, userpw)
 
 	// Validate the owner password aka. permissions/master password.
-	if ok, err = validateOwnerPassword(ctx); err != nil {
+	if ok, err = validateOwnerPassword(ctx, ownerpw, userpw); err != nil {
 		return err
 	}
 
 	// If the owner password does not match we generally move on if the user password is correct
 	// unless we need to insist on a correct owner password due to the specific command in progress.
 	if !ok && needsOwnerAndUserPassword(ctx.Cmd) {
-		return errors.New("pdfcpu: please provide the owner password with -opw")
+		return ErrMissingOwnerPW
 	}
 
 	// Generally the owner password, which is also regarded as the master password or set permissions password
@@ -2926,7 +3100,7 @@ func setupEncryptionKey(ctx *model.Context, d types.Dict) (err error) {
 	}
 
 	// Validate the user password aka. document open password.
-	if ok, err = validateUserPassword(ctx); err != nil {
+	if ok, err = validateUserPassword(ctx, userpw); err != nil {
 		return err
 	}
 	if !ok {
@@ -2966,5 +3140,23 @@ func checkForEncryption(ctx *model.Context) error {
 	}
 
 	// We need to decrypt this file in order to read it.
-	return setupEncryptionKey(ctx, d)
+	// Passwords supplied by a PasswordProvider stay with this read context and do not leak into the configuration.
+	upw, opw := ctx.UserPW, ctx.OwnerPW
+	err = setupEncryptionKey(ctx, d, upw, opw)
+
+	for attempt := 1; ctx.PasswordProvider != nil && (err == ErrWrongPassword || err == ErrMissingOwnerPW); attempt++ {
+		var ok bool
+		if upw, opw, ok = ctx.PasswordProvider(ctx.Read.FileName, attempt); !ok {
+			return err
+		}
+		err = setupEncryptionKey(ctx, d, upw, opw)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ctx.Read.UserPW, ctx.Read.OwnerPW = upw, opw
+
+	return nil
 }
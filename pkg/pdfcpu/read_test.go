@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+func objectStreamDict(content string, n, first int) *types.ObjectStreamDict {
+	return &types.ObjectStreamDict{
+		StreamDict:     types.StreamDict{Dict: types.NewDict(), Content: []byte(content)},
+		ObjCount:       n,
+		FirstObjOffset: first,
+	}
+}
+
+func TestParseObjectStream(t *testing.T) {
+	ctx := &model.Context{Configuration: model.NewDefaultConfiguration(), XRefTable: &model.XRefTable{}}
+	body := "<</Type/Font>> [1 2 0 R] (x)"
+
+	for _, tt := range []struct {
+		msg     string
+		content string
+		n       int
+		first   int
+		want    types.Array
+	}{
+		{"consistent", "10 0 11 15 12 25 " + body, 3, 18,
+			types.Array{types.Dict{"Type": types.Name("Font")}, types.Array{types.Integer(1), *types.NewIndirectRef(2, 0)}, types.StringLiteral("x")}},
+		{"First beyond content", "10 0 11 15 12 25 " + body, 3, 999,
+			types.Array{types.Dict{"Type": types.Name("Font")}, types.Array{types.Integer(1), *types.NewIndirectRef(2, 0)}, types.StringLiteral("x")}},
+		{"offsets out of order", "10 0 11 25 12 15 " + body, 3, 18,
+			types.Array{types.Dict{"Type": types.Name("Font")}, types.Array{types.Integer(1), *types.NewIndirectRef(2, 0)}, types.StringLiteral("x")}},
+		{"First within prolog", "10 0 11 15 12 25 " + body, 3, 5,
+			types.Array{types.Dict{"Type": types.Name("Font")}, types.Array{types.Integer(1), *types.NewIndirectRef(2, 0)}, types.StringLiteral("x")}},
+		{"corrupt tail", "10 0 11 15 12 25 <</Type/Font>> [1 2 0 R (x", 3, 999,
+			types.Array{types.Dict{"Type": types.Name("Font")}, nil, nil}},
+	} {
+		osd := objectStreamDict(tt.content, tt.n, tt.first)
+		if err := parseObjectStream(ctx, osd); err != nil {
+			t.Fatalf("%s: %v\n", tt.msg, err)
+		}
+		if !reflect.DeepEqual(osd.ObjArray, tt.want) {
+			t.Fatalf("%s: want: %v\ngot: %v\n", tt.msg, tt.want, osd.ObjArray)
+		}
+	}
+
+	// Nothing to recover.
+	if err := parseObjectStream(ctx, objectStreamDict("10 0 11 x", 2, 999)); err == nil {
+		t.Fatal("corrupt prolog: expected error")
+	}
+}
+
+func TestExtractXRefStreamEntriesWithLargeOffsets(t *testing.T) {
+	newContext := func() *model.Context {
+		return &model.Context{
+			Configuration: model.NewDefaultConfiguration(),
+			XRefTable:     &model.XRefTable{Table: map[int]*model.XRefTableEntry{}},
+			Read:          &model.ReadContext{ObjectStreams: types.IntSet{}},
+		}
+	}
+
+	off := int64(5<<30 + 17)
+
+	// W [0 5 1]: The type field defaults to 1.
+	ctx := newContext()
+	buf := append(int64ToBuf(off, 5), 0)
+	buf = append(buf, append(int64ToBuf(off+100, 5), 0)...)
+	xsd := &types.XRefStreamDict{W: [3]int{0, 5, 1}, Objects: []int{1, 2}}
+	if err := extractXRefTableEntriesFromXRefStream(buf, xsd, ctx); err != nil {
+		t.Fatal(err)
+	}
+	for objNr, want := range map[int]int64{1: off, 2: off + 100} {
+		e := ctx.Table[objNr]
+		if e == nil || e.Free || e.Compressed || *e.Offset != want {
+			t.Fatalf("obj#%d: want offset %d, got %+v", objNr, want, e)
+		}
+	}
+
+	// W [1 8 2]: Entries of unknown type are skipped.
+	ctx = newContext()
+	buf = append([]byte{1}, append(int64ToBuf(off, 8), 0, 0)...)
+	buf = append(buf, append([]byte{7}, make([]byte, 10)...)...)
+	xsd = &types.XRefStreamDict{W: [3]int{1, 8, 2}, Objects: []int{1, 2}}
+	if err := extractXRefTableEntriesFromXRefStream(buf, xsd, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if e := ctx.Table[1]; e == nil || *e.Offset != off {
+		t.Fatalf("obj#1: want offset %d, got %+v", off, e)
+	}
+	if _, found := ctx.Table[2]; found {
+		t.Fatal("obj#2: unexpected entry")
+	}
+
+	// An offset overflowing int64 is corrupt.
+	ctx = newContext()
+	buf = append([]byte{1}, append(bytes.Repeat([]byte{0xFF}, 8), 0, 0)...)
+	xsd = &types.XRefStreamDict{W: [3]int{1, 8, 2}, Objects: []int{1}}
+	if err := extractXRefTableEntriesFromXRefStream(buf, xsd, ctx); err == nil {
+		t.Fatal("overflowing offset: expected error")
+	}
+
+	// All field widths zero.
+	xsd = &types.XRefStreamDict{Objects: []int{1}}
+	if err := extractXRefTableEntriesFromXRefStream(nil, xsd, newContext()); err == nil {
+		t.Fatal("zero field widths: expected error")
+	}
+}
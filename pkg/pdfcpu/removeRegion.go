@@ -0,0 +1,440 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"math"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/matrix"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// bounds represents the bounding box of content in user space.
+type bounds struct {
+	llx, lly, urx, ury float64
+	empty              bool
+}
+
+func newBounds() bounds {
+	return bounds{llx: math.MaxFloat64, lly: math.MaxFloat64, urx: -math.MaxFloat64, ury: -math.MaxFloat64, empty: true}
+}
+
+func (b *bounds) add(p types.Point) {
+	b.llx, b.lly = math.Min(b.llx, p.X), math.Min(b.lly, p.Y)
+	b.urx, b.ury = math.Max(b.urx, p.X), math.Max(b.ury, p.Y)
+	b.empty = false
+}
+
+// addRect adds the rectangle r transformed by m.
+func (b *bounds) addRect(r *types.Rectangle, m matrix.Matrix) {
+	for _, p := range []types.Point{r.LL, r.UR, {X: r.LL.X, Y: r.UR.Y}, {X: r.UR.X, Y: r.LL.Y}} {
+		b.add(m.Transform(p))
+	}
+}
+
+func (b bounds) intersects(r *types.Rectangle) bool {
+	return !b.empty && b.llx <= r.UR.X && r.LL.X <= b.urx && b.lly <= r.UR.Y && r.LL.Y <= b.ury
+}
+
+// regionRemover removes content intersecting a region of a page.
+type regionRemover struct {
+	te *textExtractor
+	r  *types.Rectangle
+	c  int // number of removed painting operations
+}
+
+func pathPoints(op ContentOperation) []types.Point {
+	var ff []float64
+	for _, o := range op.Operands {
+		f, ok := numberValue(o)
+		if !ok {
+			return nil
+		}
+		ff = append(ff, f)
+	}
+
+	if op.Operator == "re" {
+		if len(ff) != 4 {
+			return nil
+		}
+		x, y, w, h := ff[0], ff[1], ff[2], ff[3]
+		return []types.Point{{X: x, Y: y}, {X: x + w, Y: y}, {X: x, Y: y + h}, {X: x + w, Y: y + h}}
+	}
+
+	var pp []types.Point
+	for i := 0; i+1 < len(ff); i += 2 {
+		pp = append(pp, types.Point{X: ff[i], Y: ff[i+1]})
+	}
+	return pp
+}
+
+// xObjectBounds returns the bounds of XObject id painted using ctm.
+func (rr *regionRemover) xObjectBounds(resDict types.Dict, id string, ctm matrix.Matrix) (bounds, error) {
+	b := newBounds()
+
+	xObjDict, err := rr.te.ctx.DereferenceDict(resDict["XObject"])
+	if err != nil || xObjDict == nil {
+		return b, err
+	}
+
+	sd, _, err := rr.te.ctx.DereferenceStreamDict(xObjDict[id])
+	if err != nil || sd == nil {
+		return b, err
+	}
+
+	if st := sd.Subtype(); st == nil || *st != "Form" {
+		// Images occupy the unit square.
+		b.addRect(types.RectForDim(1, 1), ctm)
+		return b, nil
+	}
+
+	a, err := rr.te.ctx.DereferenceArray(sd.Dict["BBox"])
+	if err != nil {
+		return b, err
+	}
+	bbox, err := types.RectForArray(a)
+	if err != nil {
+		return b, err
+	}
+
+	if a, err := rr.te.ctx.DereferenceArray(sd.Dict["Matrix"]); err == nil {
+		if m, ok := operandMatrix(a); ok {
+			ctm = m.Multiply(ctm)
+		}
+	}
+
+	b.addRect(bbox, ctm)
+
+	return b, nil
+}
+
+// showBounds returns the bounds of the glyphs shown by o and the resulting horizontal displacement in text space.
+func (rr *regionRemover) showBounds(o types.Object, tp *textParams, tm *matrix.Matrix) (bounds, float64, error) {
+	b := newBounds()
+	tm0 := *tm
+
+	rr.te.glyphs = rr.te.glyphs[:0]
+
+	if a, ok := o.(types.Array); ok {
+		for _, o := range a {
+			if adj, ok := numberValue(o); ok {
+				*tm = translationMatrix(-adj/1000*tp.size*tp.th, 0).Multiply(*tm)
+				continue
+			}
+			if err := rr.te.show(o, tp, tm); err != nil {
+				return b, 0, err
+			}
+		}
+	} else if err := rr.te.show(o, tp, tm); err != nil {
+		return b, 0, err
+	}
+
+	for _, g := range rr.te.glyphs {
+		// Approximate the glyph box by font size including descenders.
+		b.add(types.Point{X: g.x0, Y: g.y - g.size/4})
+		b.add(types.Point{X: g.x1, Y: g.y + g.size})
+	}
+
+	// Project the displacement onto the text space x axis.
+	dx, dy := tm[2][0]-tm0[2][0], tm[2][1]-tm0[2][1]
+	ax, ay := tm0[0][0], tm0[0][1]
+	w := 0.
+	if n := ax*ax + ay*ay; n > 0 {
+		w = (dx*ax + dy*ay) / n
+	}
+
+	return b, w, nil
+}
+
+// textDisplacement returns an operation moving the current text position by w like the removed text would have.
+func textDisplacement(w float64, tp textParams) []ContentOperation {
+	if w == 0 || tp.size == 0 || tp.th == 0 {
+		return nil
+	}
+	adj := -w * 1000 / (tp.size * tp.th)
+	return []ContentOperation{{Operands: []types.Object{types.Array{types.Float(adj)}}, Operator: "TJ"}}
+}
+
+// filterContent returns ops without all painting operations intersecting the region.
+// Clipping paths and shadings are retained.
+func (rr *regionRemover) filterContent(ops []ContentOperation, resDict types.Dict) ([]ContentOperation, error) {
+	var (
+		out     []ContentOperation
+		path    []ContentOperation
+		pathBox = newBounds()
+		clip    bool
+		stack   []textParams
+		tp      = textParams{ctm: matrix.IdentMatrix, th: 1}
+		tm, tlm = matrix.IdentMatrix, matrix.IdentMatrix
+	)
+
+	newLine := func(tx, ty float64) {
+		tlm = translationMatrix(tx, ty).Multiply(tlm)
+		tm = tlm
+	}
+
+	f := func(oo []types.Object, i int) float64 {
+		if i >= len(oo) {
+			return 0
+		}
+		v, _ := numberValue(oo[i])
+		return v
+	}
+
+	for _, op := range ops {
+		oo := op.Operands
+
+		var err error
+
+		switch op.Operator {
+
+		case "q":
+			stack = append(stack, tp)
+
+		case "Q":
+			if len(stack) > 0 {
+				tp, stack = stack[len(stack)-1], stack[:len(stack)-1]
+			}
+
+		case "cm":
+			if m, ok := operandMatrix(oo); ok {
+				tp.ctm = m.Multiply(tp.ctm)
+			}
+
+		case "m", "l", "c", "v", "y", "re", "h":
+			for _, p := range pathPoints(op) {
+				pathBox.add(tp.ctm.Transform(p))
+			}
+			path = append(path, op)
+			continue
+
+		case "W", "W*":
+			clip = true
+			path = append(path, op)
+			continue
+
+		case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n":
+			path = append(path, op)
+			if !clip && op.Operator != "n" && pathBox.intersects(rr.r) {
+				rr.c++
+			} else {
+				out = append(out, path...)
+			}
+			path, pathBox, clip = nil, newBounds(), false
+			continue
+
+		case "BI":
+			b := newBounds()
+			b.addRect(types.RectForDim(1, 1), tp.ctm)
+			if b.intersects(rr.r) {
+				rr.c++
+				continue
+			}
+
+		case "Do":
+			if len(oo) == 1 {
+				if id, ok := oo[0].(types.Name); ok {
+					b, err := rr.xObjectBounds(resDict, id.Value(), tp.ctm)
+					if err != nil {
+						return nil, err
+					}
+					if b.intersects(rr.r) {
+						rr.c++
+						continue
+					}
+				}
+			}
+
+		case "BT":
+			tm, tlm = matrix.IdentMatrix, matrix.IdentMatrix
+
+		case "Tf":
+			if len(oo) == 2 {
+				if id, ok := oo[0].(types.Name); ok {
+					tp.font, err = rr.te.font(resDict, id.Value())
+				}
+				tp.size = f(oo, 1)
+			}
+
+		case "Tc":
+			tp.tc = f(oo, 0)
+
+		case "Tw":
+			tp.tw = f(oo, 0)
+
+		case "Tz":
+			tp.th = f(oo, 0) / 100
+
+		case "TL":
+			tp.tl = f(oo, 0)
+
+		case "Ts":
+			tp.rise = f(oo, 0)
+
+		case "Td":
+			newLine(f(oo, 0), f(oo, 1))
+
+		case "TD":
+			tp.tl = -f(oo, 1)
+			newLine(f(oo, 0), f(oo, 1))
+
+		case "Tm":
+			if m, ok := operandMatrix(oo); ok {
+				tm, tlm = m, m
+			}
+
+		case "T*":
+			newLine(0, -tp.tl)
+
+		case "Tj", "TJ", "'", "\"":
+			var pre []ContentOperation
+			switch op.Operator {
+			case "'":
+				newLine(0, -tp.tl)
+				pre = []ContentOperation{{Operator: "T*"}}
+			case "\"":
+				if len(oo) == 3 {
+					tp.tw, tp.tc = f(oo, 0), f(oo, 1)
+					pre = []ContentOperation{{Operands: oo[:1], Operator: "Tw"}, {Operands: oo[1:2], Operator: "Tc"}, {Operator: "T*"}}
+				}
+				newLine(0, -tp.tl)
+			}
+			if len(oo) == 0 {
+				break
+			}
+			b, w, err := rr.showBounds(oo[len(oo)-1], &tp, &tm)
+			if err != nil {
+				return nil, err
+			}
+			if b.intersects(rr.r) {
+				// Keep the text position in sync for any remaining text of this text object.
+				rr.c++
+				out = append(out, pre...)
+				out = append(out, textDisplacement(w, tp)...)
+				continue
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(path) > 0 {
+			// Path construction interrupted by a non painting operator.
+			out = append(out, path...)
+			path, pathBox, clip = nil, newBounds(), false
+		}
+
+		out = append(out, op)
+	}
+
+	return append(out, path...), nil
+}
+
+func whiteFillOps(r *types.Rectangle) []ContentOperation {
+	return []ContentOperation{
+		{Operator: "q"},
+		{Operands: []types.Object{types.Float(1)}, Operator: "g"},
+		{Operands: []types.Object{types.Float(r.LL.X), types.Float(r.LL.Y), types.Float(r.Width()), types.Float(r.Height())}, Operator: "re"},
+		{Operator: "f"},
+		{Operator: "Q"},
+	}
+}
+
+func (rr *regionRemover) processPage(ctx *model.Context, pageNr int, fill bool) error {
+	d, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return errors.Errorf("pdfcpu: invalid page number: %d", pageNr)
+	}
+
+	resDict, err := pageResourceDict(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	bb, err := ctx.PageContent(d)
+	if err != nil && err != model.ErrNoContent {
+		return err
+	}
+
+	ops, err := ParseContentStream(bb)
+	if err != nil {
+		return err
+	}
+
+	c := rr.c
+
+	ops1, err := rr.filterContent(ops, resDict)
+	if err != nil {
+		return err
+	}
+
+	if rr.c == c && !fill {
+		return nil
+	}
+
+	if fill {
+		// Wrap the page content into q/Q restoring the initial graphics state for the white fill.
+		ops1 = append(append([]ContentOperation{{Operator: "q"}}, ops1...), ContentOperation{Operator: "Q"})
+		ops1 = append(ops1, whiteFillOps(rr.r)...)
+	}
+
+	sd, err := ctx.NewStreamDictForBuf(nil)
+	if err != nil {
+		return err
+	}
+	if err := setContentStream(sd, ContentStreamBytes(ops1)); err != nil {
+		return err
+	}
+	ir, err := ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+	d["Contents"] = *ir
+
+	return nil
+}
+
+// RemoveRegion removes all content intersecting r from selected pages, eg. stamps or headers of unknown origin.
+// r is given in user space. If fill is true r gets painted white afterwards.
+// Returns the number of removed painting operations.
+func RemoveRegion(ctx *model.Context, selectedPages types.IntSet, r *types.Rectangle, fill bool) (int, error) {
+	if r == nil || r.Width() <= 0 || r.Height() <= 0 {
+		return 0, errors.New("pdfcpu: invalid region")
+	}
+
+	rr := &regionRemover{
+		te: &textExtractor{ctx: ctx, fonts: map[int]*textFont{}},
+		r:  r,
+	}
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		if selectedPages != nil && !selectedPages[i] {
+			continue
+		}
+		if err := rr.processPage(ctx, i, fill); err != nil {
+			return 0, errors.Wrapf(err, "pdfcpu: page %d", i)
+		}
+	}
+
+	return rr.c, nil
+}
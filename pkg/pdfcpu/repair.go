@@ -0,0 +1,572 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Repair fix types.
+const (
+	FixHeader           = "header"
+	FixXRef             = "xref"
+	FixMissingEntry     = "missingEntry"
+	FixOffset           = "offset"
+	FixGeneration       = "generation"
+	FixDanglingEntry    = "danglingEntry"
+	FixCompressedObject = "compressedObject"
+	FixObjectStream     = "objectStream"
+	FixTrailer          = "trailer"
+	FixRoot             = "root"
+	FixSize             = "size"
+	FixReference        = "reference"
+)
+
+var (
+	reObjMarker = regexp.MustCompile(`(\d+)[\x00\t\n\f\r ]+(\d+)[\x00\t\n\f\r ]+obj\b`)
+	reObjStm    = regexp.MustCompile(`/Type[\x00\t\n\f\r ]*/ObjStm\b`)
+	reXRefStm   = regexp.MustCompile(`/Type[\x00\t\n\f\r ]*/XRef\b`)
+)
+
+// RepairFix represents a single fix applied during repair.
+type RepairFix struct {
+	Type   string `json:"type"`
+	ObjNr  int    `json:"objNr,omitempty"`
+	GenNr  int    `json:"genNr,omitempty"`
+	Offset int64  `json:"offset,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// RepairReport represents the diagnosis of a repaired PDF file.
+type RepairReport struct {
+	FileSize     int64       `json:"fileSize"`
+	XRefRebuilt  bool        `json:"xrefRebuilt"`
+	ObjectsFound int         `json:"objectsFound"`
+	Fixes        []RepairFix `json:"fixes"`
+}
+
+func (r *RepairReport) add(typ string, objNr, genNr int, offset int64, format string, args ...interface{}) {
+	detail := fmt.Sprintf(format, args...)
+	if log.ReadEnabled() {
+		log.Read.Printf("repair: %s obj#%d: %s\n", typ, objNr, detail)
+	}
+	r.Fixes = append(r.Fixes, RepairFix{Type: typ, ObjNr: objNr, GenNr: genNr, Offset: offset, Detail: detail})
+}
+
+// objMarker represents an "N G obj" marker found in the raw byte stream.
+type objMarker struct {
+	objNr, genNr int
+	offset       int64
+	objStm       bool
+	xRefStm      bool
+}
+
+func isRepairWhiteSpace(b byte) bool {
+	return b == 0x00 || b == 0x09 || b == 0x0A || b == 0x0C || b == 0x0D || b == 0x20
+}
+
+// scanObjectMarkers scans buf for "N G obj" markers while skipping any stream data.
+// For objects defined more than once the last definition wins as with incremental updates.
+func scanObjectMarkers(buf []byte) map[int]objMarker {
+	m := map[int]objMarker{}
+
+	var skipUntil int
+
+	for _, loc := range reObjMarker.FindAllSubmatchIndex(buf, -1) {
+
+		if loc[0] < skipUntil {
+			// Marker within stream data.
+			continue
+		}
+
+		if loc[0] > 0 && !isRepairWhiteSpace(buf[loc[0]-1]) && !bytes.HasSuffix(buf[:loc[0]], []byte("endobj")) {
+			continue
+		}
+
+		objNr, err := strconv.Atoi(string(buf[loc[2]:loc[3]]))
+		if err != nil {
+			continue
+		}
+
+		genNr, err := strconv.Atoi(string(buf[loc[4]:loc[5]]))
+		if err != nil {
+			continue
+		}
+
+		om := objMarker{objNr: objNr, genNr: genNr, offset: int64(loc[0])}
+
+		rest := buf[loc[1]:]
+		endInd := bytes.Index(rest, []byte("endobj"))
+		streamInd := bytes.Index(rest, []byte("stream"))
+
+		if streamInd >= 0 && (endInd < 0 || streamInd < endInd) {
+			d := rest[:streamInd]
+			om.objStm = reObjStm.Match(d)
+			om.xRefStm = reXRefStm.Match(d)
+			if i := bytes.Index(rest[streamInd:], []byte("endstream")); i >= 0 {
+				skipUntil = loc[1] + streamInd + i
+			}
+		}
+
+		m[objNr] = om
+	}
+
+	return m
+}
+
+// objMarkerAt returns true if there is an object marker for objNr at offset.
+func objMarkerAt(buf []byte, offset int64, objNr int) (*int, bool) {
+	if offset < 0 || offset >= int64(len(buf)) {
+		return nil, false
+	}
+
+	i := offset
+	for i < int64(len(buf)) && isRepairWhiteSpace(buf[i]) {
+		i++
+	}
+
+	end := i + 32
+	if end > int64(len(buf)) {
+		end = int64(len(buf))
+	}
+
+	loc := reObjMarker.FindSubmatchIndex(buf[i:end])
+	if loc == nil || loc[0] != 0 {
+		return nil, false
+	}
+
+	nr, _ := strconv.Atoi(string(buf[i+int64(loc[2]) : i+int64(loc[3])]))
+	if nr != objNr {
+		return nil, false
+	}
+
+	g, _ := strconv.Atoi(string(buf[i+int64(loc[4]) : i+int64(loc[5])]))
+
+	return &g, true
+}
+
+func repairHeader(ctx *model.Context, rep *RepairReport) {
+	hv, eolCount, err := headerVersion(ctx.Read.RS, ctx.HeaderBufSize)
+	if err == nil {
+		ctx.HeaderVersion = hv
+		ctx.Read.EolCount = eolCount
+		return
+	}
+
+	v := model.V17
+	ctx.HeaderVersion = &v
+	ctx.Read.EolCount = 1
+	rep.add(FixHeader, 0, 0, 0, "assuming version %s: %v", v, err)
+}
+
+func resetXRefTable(ctx *model.Context) {
+	ctx.Table = map[int]*model.XRefTableEntry{}
+	ctx.Size = nil
+	ctx.Read.ObjectStreams = types.IntSet{}
+	ctx.Read.XRefStreams = types.IntSet{}
+	ctx.Read.UsingObjectStreams = false
+	ctx.Read.UsingXRefStreams = false
+}
+
+func reconcileEntry(buf []byte, om objMarker, entry *model.XRefTableEntry, rep *RepairReport) {
+	if entry.Compressed || entry.Free {
+		return
+	}
+
+	if entry.Offset != nil {
+		if g, ok := objMarkerAt(buf, *entry.Offset, om.objNr); ok {
+			// The xref entry points to a valid definition of this object.
+			if entry.Generation == nil || *entry.Generation != *g {
+				rep.add(FixGeneration, om.objNr, *g, *entry.Offset, "generation %d in xref, %d in object header", genNr(entry), *g)
+				entry.Generation = g
+				entry.Source = model.SourceRepairScan
+			}
+			return
+		}
+	}
+
+	off := int64(0)
+	if entry.Offset != nil {
+		off = *entry.Offset
+	}
+	rep.add(FixOffset, om.objNr, om.genNr, om.offset, "offset %d in xref, object found at %d", off, om.offset)
+
+	o, g := om.offset, om.genNr
+	entry.Offset = &o
+	if entry.Generation != nil && *entry.Generation != g {
+		rep.add(FixGeneration, om.objNr, g, om.offset, "generation %d in xref, %d in object header", *entry.Generation, g)
+	}
+	entry.Generation = &g
+	entry.Object = nil
+	entry.Source = model.SourceRepairScan
+}
+
+func genNr(entry *model.XRefTableEntry) int {
+	if entry.Generation == nil {
+		return 0
+	}
+	return *entry.Generation
+}
+
+func reconcileXRefTable(ctx *model.Context, buf []byte, markers map[int]objMarker, rep *RepairReport) {
+	for objNr, om := range markers {
+
+		entry, found := ctx.Table[objNr]
+
+		if om.xRefStm {
+			// Xref streams are rebuilt on writing.
+			if found {
+				ctx.Read.XRefStreams[objNr] = true
+			}
+			continue
+		}
+
+		if !found {
+			if !rep.XRefRebuilt {
+				rep.add(FixMissingEntry, objNr, om.genNr, om.offset, "object missing in xref")
+			}
+			off, g := om.offset, om.genNr
+			ctx.Table[objNr] = &model.XRefTableEntry{Offset: &off, Generation: &g, Source: model.SourceRepairScan}
+		} else {
+			reconcileEntry(buf, om, entry, rep)
+		}
+
+		if om.objStm && !ctx.Read.ObjectStreams[objNr] {
+			if found {
+				rep.add(FixObjectStream, objNr, om.genNr, om.offset, "unregistered object stream")
+			}
+			ctx.Read.ObjectStreams[objNr] = true
+		}
+	}
+
+	// Remove entries pointing to nowhere.
+	for objNr, entry := range ctx.Table {
+		if objNr == 0 || entry.Free || entry.Compressed || entry.Object != nil {
+			continue
+		}
+		if _, ok := markers[objNr]; ok {
+			continue
+		}
+		if entry.Offset != nil {
+			if _, ok := objMarkerAt(buf, *entry.Offset, objNr); ok {
+				continue
+			}
+		}
+		rep.add(FixDanglingEntry, objNr, genNr(entry), 0, "no object definition found, treating as null")
+		delete(ctx.Table, objNr)
+	}
+}
+
+func objectStreamObjNrs(osd types.ObjectStreamDict) []int {
+	prolog := bytes.ReplaceAll(osd.Content[:osd.FirstObjOffset], []byte{0x00}, []byte{0x20})
+	ss := strings.Fields(string(prolog))
+	objNrs := []int{}
+	for i := 0; i+1 < len(ss); i += 2 {
+		objNr, err := strconv.Atoi(ss[i])
+		if err != nil {
+			break
+		}
+		objNrs = append(objNrs, objNr)
+	}
+	return objNrs
+}
+
+func repairObjectStreams(ctx *model.Context, rep *RepairReport) {
+	var keys []int
+	for k := range ctx.Read.ObjectStreams {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	for _, objNr := range keys {
+
+		if err := decodeObjectStream(ctx, objNr); err != nil {
+			rep.add(FixObjectStream, objNr, 0, 0, "dropping undecodable object stream: %v", err)
+			delete(ctx.Read.ObjectStreams, objNr)
+			delete(ctx.Table, objNr)
+			continue
+		}
+
+		osd, ok := ctx.Table[objNr].Object.(types.ObjectStreamDict)
+		if !ok {
+			continue
+		}
+
+		for i, nr := range objectStreamObjNrs(osd) {
+			if i >= len(osd.ObjArray) {
+				break
+			}
+			if entry, found := ctx.Table[nr]; found && (!entry.Free || entry.Compressed) {
+				continue
+			}
+			objStm, ind := objNr, i
+			g := 0
+			ctx.Table[nr] = &model.XRefTableEntry{Compressed: true, ObjectStream: &objStm, ObjectStreamInd: &ind, Generation: &g, Source: model.SourceRepairScan}
+			rep.add(FixCompressedObject, nr, 0, 0, "recovered from object stream %d[%d]", objNr, i)
+		}
+	}
+}
+
+func catalogObjNr(ctx *model.Context) (int, bool) {
+	var keys []int
+	for k := range ctx.Table {
+		keys = append(keys, k)
+	}
+	// Prefer the most recent catalog.
+	sort.Sort(sort.Reverse(sort.IntSlice(keys)))
+
+	for _, objNr := range keys {
+		entry := ctx.Table[objNr]
+		if entry.Free {
+			continue
+		}
+		d, ok := entry.Object.(types.Dict)
+		if !ok {
+			continue
+		}
+		if t := d.Type(); t != nil && *t == "Catalog" {
+			return objNr, true
+		}
+	}
+
+	return 0, false
+}
+
+func repairTrailer(ctx *model.Context, buf []byte, markers map[int]objMarker, rep *RepairReport) error {
+	if ctx.Root != nil {
+		return nil
+	}
+
+	// Try the last trailer dict.
+	if i := bytes.LastIndex(buf, []byte("trailer")); i >= 0 {
+		if j := bytes.Index(buf[i:], []byte("<<")); j >= 0 {
+			s := string(buf[i+j:])
+			if o, err := model.ParseObject(&s); err == nil {
+				if d, ok := o.(types.Dict); ok {
+					if err := parseTrailer(ctx.XRefTable, d); err == nil && ctx.Root != nil {
+						rep.add(FixTrailer, 0, 0, int64(i), "recovered trailer")
+						return nil
+					}
+				}
+			}
+		}
+	}
+
+	// Try the most recent xref stream dict.
+	var xRefStms []objMarker
+	for _, om := range markers {
+		if om.xRefStm {
+			xRefStms = append(xRefStms, om)
+		}
+	}
+	sort.Slice(xRefStms, func(i, j int) bool { return xRefStms[i].offset > xRefStms[j].offset })
+	for _, om := range xRefStms {
+		o, err := ParseObject(ctx, om.offset, om.objNr, om.genNr)
+		if err != nil {
+			continue
+		}
+		sd, ok := o.(types.StreamDict)
+		if !ok {
+			continue
+		}
+		if err := parseTrailer(ctx.XRefTable, sd.Dict); err == nil && ctx.Root != nil {
+			rep.add(FixTrailer, om.objNr, om.genNr, om.offset, "recovered trailer from xref stream")
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func repairRoot(ctx *model.Context, rep *RepairReport) error {
+	if ctx.Root != nil {
+		if entry, found := ctx.Find(ctx.Root.ObjectNumber.Value()); found && !entry.Free {
+			if d, ok := entry.Object.(types.Dict); ok {
+				if t := d.Type(); t == nil || *t == "Catalog" {
+					return nil
+				}
+			}
+		}
+	}
+
+	objNr, ok := catalogObjNr(ctx)
+	if !ok {
+		return errors.New("pdfcpu: repair: unable to locate catalog")
+	}
+
+	rep.add(FixRoot, objNr, 0, 0, "using catalog obj#%d as root", objNr)
+	ctx.Root = types.NewIndirectRef(objNr, genNr(ctx.Table[objNr]))
+	return nil
+}
+
+func repairSize(ctx *model.Context, rep *RepairReport) {
+	maxObjNr := 0
+	for k := range ctx.Table {
+		if k > maxObjNr {
+			maxObjNr = k
+		}
+	}
+	if ctx.Size == nil || *ctx.Size < maxObjNr+1 {
+		size := maxObjNr + 1
+		if ctx.Size != nil {
+			rep.add(FixSize, 0, 0, 0, "trailer size %d, adjusted to %d", *ctx.Size, size)
+		}
+		ctx.Size = &size
+	}
+}
+
+func repairReferencesInObject(ctx *model.Context, o types.Object, fixed types.IntSet, rep *RepairReport) {
+	switch o := o.(type) {
+	case types.Dict:
+		for k, v := range o {
+			if ir, ok := v.(types.IndirectRef); ok {
+				o[k] = repairReference(ctx, ir, fixed, rep)
+				continue
+			}
+			repairReferencesInObject(ctx, v, fixed, rep)
+		}
+	case types.StreamDict:
+		repairReferencesInObject(ctx, o.Dict, fixed, rep)
+	case types.ObjectStreamDict:
+		repairReferencesInObject(ctx, o.Dict, fixed, rep)
+	case types.Array:
+		for i, v := range o {
+			if ir, ok := v.(types.IndirectRef); ok {
+				o[i] = repairReference(ctx, ir, fixed, rep)
+				continue
+			}
+			repairReferencesInObject(ctx, v, fixed, rep)
+		}
+	}
+}
+
+func repairReference(ctx *model.Context, ir types.IndirectRef, fixed types.IntSet, rep *RepairReport) types.IndirectRef {
+	objNr := ir.ObjectNumber.Value()
+	entry, found := ctx.Find(objNr)
+	if !found || entry.Free || entry.Generation == nil || *entry.Generation == ir.GenerationNumber.Value() {
+		return ir
+	}
+	if !fixed[objNr] {
+		rep.add(FixReference, objNr, *entry.Generation, 0, "references using generation %d", ir.GenerationNumber.Value())
+		fixed[objNr] = true
+	}
+	return *types.NewIndirectRef(objNr, *entry.Generation)
+}
+
+// repairReferences reconciles the generation numbers of all indirect references with the xref table.
+func repairReferences(ctx *model.Context, rep *RepairReport) {
+	fixed := types.IntSet{}
+	for _, entry := range ctx.Table {
+		if entry.Free || entry.Object == nil {
+			continue
+		}
+		repairReferencesInObject(ctx, entry.Object, fixed, rep)
+	}
+}
+
+func readAll(rs io.ReadSeeker) ([]byte, error) {
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(rs)
+}
+
+// Repair reads a potentially corrupt PDF from rs and returns its context
+// along with a report of all fixes applied.
+//
+// Repair scans the raw byte stream for object definitions ("N G obj"), rebuilds the xref table if it can't be read,
+// reconciles offsets and generation numbers of existing xref entries,
+// recovers objects of object streams and locates trailer and catalog.
+func Repair(rs io.ReadSeeker, conf *model.Configuration) (*model.Context, *RepairReport, error) {
+	if log.ReadEnabled() {
+		log.Read.Println("Repair: begin")
+	}
+
+	ctx, err := model.NewContext(rs, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rep := &RepairReport{FileSize: ctx.Read.FileSize}
+
+	if err := readXRefTable(ctx); err != nil {
+		rep.XRefRebuilt = true
+		rep.add(FixXRef, 0, 0, 0, "rebuilding xref table: %v", err)
+		resetXRefTable(ctx)
+	}
+
+	repairHeader(ctx, rep)
+
+	buf, err := readAll(rs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	markers := scanObjectMarkers(buf)
+	rep.ObjectsFound = len(markers)
+
+	reconcileXRefTable(ctx, buf, markers, rep)
+
+	if err := repairTrailer(ctx, buf, markers, rep); err != nil {
+		return nil, nil, err
+	}
+
+	repairSize(ctx, rep)
+
+	if err := ctx.EnsureValidFreeList(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkForEncryption(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	repairObjectStreams(ctx, rep)
+
+	repairSize(ctx, rep)
+
+	if err := dereferenceObjects(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	if err := repairRoot(ctx, rep); err != nil {
+		return nil, nil, err
+	}
+
+	repairReferences(ctx, rep)
+
+	if err := identifyRootVersion(ctx.XRefTable); err != nil {
+		return nil, nil, err
+	}
+
+	if log.ReadEnabled() {
+		log.Read.Println("Repair: end")
+	}
+
+	return ctx, rep, nil
+}
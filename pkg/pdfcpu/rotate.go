@@ -17,7 +17,11 @@ limitations under the License.
 package pdfcpu
 
 import (
+	"fmt"
+	"math"
+
 	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/matrix"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
 	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
 )
@@ -52,3 +56,289 @@ func RotatePages(ctx *model.Context, selectedPages types.IntSet, rotation int) e
 
 	return nil
 }
+
+// rotationMatrix returns the transformation from the user space of a page with mediaBox rotated clockwise by rot degrees
+// into the user space of an equivalent unrotated page whose media box has its origin at 0,0.
+func rotationMatrix(rot int, mediaBox *types.Rectangle) matrix.Matrix {
+	x0, y0, x1, y1 := mediaBox.LL.X, mediaBox.LL.Y, mediaBox.UR.X, mediaBox.UR.Y
+	switch rot {
+	case 90:
+		return matrix.Matrix{{0, -1, 0}, {1, 0, 0}, {-y0, x1, 1}}
+	case 180:
+		return matrix.Matrix{{-1, 0, 0}, {0, -1, 0}, {x1, y1, 1}}
+	case 270:
+		return matrix.Matrix{{0, 1, 0}, {-1, 0, 0}, {y1, -x0, 1}}
+	}
+	return matrix.Matrix{{1, 0, 0}, {0, 1, 0}, {-x0, -y0, 1}}
+}
+
+func transformRect(m matrix.Matrix, r *types.Rectangle) *types.Rectangle {
+	p1 := m.Transform(r.LL)
+	p2 := m.Transform(r.UR)
+	return types.NewRectangle(math.Min(p1.X, p2.X), math.Min(p1.Y, p2.Y), math.Max(p1.X, p2.X), math.Max(p1.Y, p2.Y))
+}
+
+func transformRectEntry(ctx *model.Context, d types.Dict, key string, m matrix.Matrix) error {
+	a, err := numberArray(ctx, d, key)
+	if err != nil || len(a) != 4 {
+		return err
+	}
+	d.Update(key, transformRect(m, types.NewRectangle(a[0], a[1], a[2], a[3])).Array())
+	return nil
+}
+
+// transformPoints applies m to an array of coordinate pairs.
+func transformPoints(ctx *model.Context, o types.Object, m matrix.Matrix) (types.Array, error) {
+	a, err := ctx.DereferenceArray(o)
+	if err != nil || a == nil {
+		return nil, err
+	}
+	a1 := make(types.Array, len(a))
+	copy(a1, a)
+	for i := 0; i+1 < len(a); i += 2 {
+		x, ok1 := numberValue(a[i])
+		y, ok2 := numberValue(a[i+1])
+		if !ok1 || !ok2 {
+			continue
+		}
+		p := m.Transform(types.Point{X: x, Y: y})
+		a1[i], a1[i+1] = types.Float(p.X), types.Float(p.Y)
+	}
+	return a1, nil
+}
+
+// rotateAppearances applies the linear part of m to the appearance streams of an annotation.
+// Each appearance stream gets transformed once only.
+func rotateAppearances(ctx *model.Context, d types.Dict, m matrix.Matrix, done types.IntSet) error {
+	apDict, err := ctx.DereferenceDict(d["AP"])
+	if err != nil || apDict == nil {
+		return err
+	}
+
+	r := matrix.Matrix{{m[0][0], m[0][1], 0}, {m[1][0], m[1][1], 0}, {0, 0, 1}}
+
+	var streams []types.IndirectRef
+	for _, k := range []string{"N", "R", "D"} {
+		switch o := apDict[k].(type) {
+		case types.IndirectRef:
+			streams = append(streams, o)
+		case types.Dict:
+			for _, o1 := range o {
+				if ir, ok := o1.(types.IndirectRef); ok {
+					streams = append(streams, ir)
+				}
+			}
+		}
+	}
+
+	for _, ir := range streams {
+		objNr := ir.ObjectNumber.Value()
+		if done[objNr] {
+			continue
+		}
+		done[objNr] = true
+
+		sd, _, err := ctx.DereferenceStreamDict(ir)
+		if err != nil {
+			return err
+		}
+		if sd == nil {
+			continue
+		}
+
+		m1 := matrix.IdentMatrix
+		if a, err := numberArray(ctx, sd.Dict, "Matrix"); err == nil && len(a) == 6 {
+			m1 = matrix.Matrix{{a[0], a[1], 0}, {a[2], a[3], 0}, {a[4], a[5], 1}}
+		}
+		m1 = m1.Multiply(r)
+
+		sd.Update("Matrix", types.NewNumberArray(m1[0][0], m1[0][1], m1[1][0], m1[1][1], m1[2][0], m1[2][1]))
+	}
+
+	return nil
+}
+
+func normalizeAnnotationRotation(ctx *model.Context, d types.Dict, m matrix.Matrix, done types.IntSet) error {
+	f := d.IntEntry("F")
+	if f != nil && model.AnnotationFlags(*f)&model.AnnNoRotate > 0 {
+		// The upper left corner of unrotated annotations stays in place.
+		a, err := numberArray(ctx, d, "Rect")
+		if err != nil || len(a) != 4 {
+			return err
+		}
+		r := types.NewRectangle(a[0], a[1], a[2], a[3])
+		p := m.Transform(types.Point{X: r.LL.X, Y: r.UR.Y})
+		d.Update("Rect", types.NewRectangle(p.X, p.Y-r.Height(), p.X+r.Width(), p.Y).Array())
+		return nil
+	}
+
+	if err := transformRectEntry(ctx, d, "Rect", m); err != nil {
+		return err
+	}
+
+	for _, k := range []string{"QuadPoints", "Vertices", "L", "CL"} {
+		if o, found := d.Find(k); found {
+			a, err := transformPoints(ctx, o, m)
+			if err != nil {
+				return err
+			}
+			d.Update(k, a)
+		}
+	}
+
+	if o, found := d.Find("InkList"); found {
+		a, err := ctx.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+		a1 := make(types.Array, len(a))
+		for i, o1 := range a {
+			if a1[i], err = transformPoints(ctx, o1, m); err != nil {
+				return err
+			}
+		}
+		d.Update("InkList", a1)
+	}
+
+	return rotateAppearances(ctx, d, m, done)
+}
+
+func wrapPageContent(ctx *model.Context, d types.Dict, m matrix.Matrix) error {
+	o, found := d.Find("Contents")
+	if !found {
+		return nil
+	}
+
+	var a types.Array
+
+	switch o := o.(type) {
+	case types.IndirectRef:
+		o1, err := ctx.Dereference(o)
+		if err != nil {
+			return err
+		}
+		if a1, ok := o1.(types.Array); ok {
+			a = append(a, a1...)
+		} else {
+			a = types.Array{o}
+		}
+	case types.Array:
+		a = append(a, o...)
+	default:
+		return nil
+	}
+
+	bb := []byte(fmt.Sprintf("q %.5f %.5f %.5f %.5f %.5f %.5f cm\n", m[0][0], m[0][1], m[1][0], m[1][1], m[2][0], m[2][1]))
+	var irs []types.IndirectRef
+	for _, b := range [][]byte{bb, []byte("\nQ")} {
+		sd, _ := ctx.NewStreamDictForBuf(b)
+		if err := sd.Encode(); err != nil {
+			return err
+		}
+		ir, err := ctx.IndRefForNewObject(*sd)
+		if err != nil {
+			return err
+		}
+		irs = append(irs, *ir)
+	}
+
+	a = append(types.Array{irs[0]}, a...)
+	d.Update("Contents", append(a, irs[1]))
+
+	return nil
+}
+
+func normalizePageRotation(ctx *model.Context, pageNr int, done types.IntSet, pages map[int]matrix.Matrix) (bool, error) {
+	d, pageIndRef, inhPAttrs, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return false, err
+	}
+
+	rot := inhPAttrs.Rotate % 360
+	if rot < 0 {
+		rot += 360
+	}
+	if rot == 0 {
+		if _, found := d.Find("Rotate"); found {
+			d.Update("Rotate", types.Integer(0))
+		}
+		return false, nil
+	}
+
+	mediaBox := inhPAttrs.MediaBox
+	m := rotationMatrix(rot, mediaBox)
+
+	if err := wrapPageContent(ctx, d, m); err != nil {
+		return false, err
+	}
+
+	d.Update("MediaBox", transformRect(m, mediaBox).Array())
+	if inhPAttrs.CropBox != nil {
+		d.Update("CropBox", transformRect(m, inhPAttrs.CropBox).Array())
+	}
+	for _, k := range []string{"BleedBox", "TrimBox", "ArtBox"} {
+		if err := transformRectEntry(ctx, d, k, m); err != nil {
+			return false, err
+		}
+	}
+
+	annots, err := ctx.DereferenceArray(d["Annots"])
+	if err != nil {
+		return false, err
+	}
+	for _, o := range annots {
+		d1, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return false, err
+		}
+		if d1 == nil {
+			continue
+		}
+		if err := normalizeAnnotationRotation(ctx, d1, m, done); err != nil {
+			return false, err
+		}
+	}
+
+	d.Update("Rotate", types.Integer(0))
+
+	if pageIndRef != nil {
+		pages[pageIndRef.ObjectNumber.Value()] = m
+	}
+
+	return true, nil
+}
+
+// NormalizeRotation bakes the rotation of selected pages into their content, page boundaries, annotations
+// and any destinations targeting them so that these pages end up with Rotate 0 while retaining their visual appearance.
+// Returns the number of pages modified.
+func NormalizeRotation(ctx *model.Context, selectedPages types.IntSet) (int, error) {
+	if len(selectedPages) == 0 {
+		selectedPages = types.IntSet{}
+		for i := 1; i <= ctx.PageCount; i++ {
+			selectedPages[i] = true
+		}
+	}
+
+	done := types.IntSet{}
+	pages := map[int]matrix.Matrix{}
+	c := 0
+
+	for k, v := range selectedPages {
+		if !v {
+			continue
+		}
+		ok, err := normalizePageRotation(ctx, k, done, pages)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			c++
+		}
+	}
+
+	if err := transformDests(ctx, pages); err != nil {
+		return 0, err
+	}
+
+	return c, nil
+}
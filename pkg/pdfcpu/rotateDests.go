@@ -0,0 +1,340 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/matrix"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// swappedFit maps destination types with a single coordinate to their counterpart for the other axis.
+var swappedFit = map[types.Name]types.Name{"FitH": "FitV", "FitBH": "FitBV", "FitV": "FitH", "FitBV": "FitBH"}
+
+// destTransformer applies the matrices of normalized pages to explicit destinations targeting these pages.
+type destTransformer struct {
+	ctx     *model.Context
+	pages   map[int]matrix.Matrix // matrix by page object number
+	done    map[*types.Object]bool
+	visited types.IntSet
+}
+
+// transformX returns the image of an x coordinate under m
+// and true if the rotation of m turns it into a y coordinate.
+func transformX(m matrix.Matrix, x float64) (float64, bool) {
+	if m[0][0] == 0 {
+		return m[0][1]*x + m[2][1], true
+	}
+	return m[0][0]*x + m[2][0], false
+}
+
+// transformY returns the image of an y coordinate under m
+// and true if the rotation of m turns it into an x coordinate.
+func transformY(m matrix.Matrix, y float64) (float64, bool) {
+	if m[1][1] == 0 {
+		return m[1][0]*y + m[2][0], true
+	}
+	return m[1][1]*y + m[2][1], false
+}
+
+func transformDestXYZ(a types.Array, m matrix.Matrix) {
+	var left, top types.Object
+	if x, ok := numberValue(a[2]); ok {
+		v, swapped := transformX(m, x)
+		if swapped {
+			top = types.Float(v)
+		} else {
+			left = types.Float(v)
+		}
+	}
+	if len(a) > 3 {
+		if y, ok := numberValue(a[3]); ok {
+			v, swapped := transformY(m, y)
+			if swapped {
+				left = types.Float(v)
+			} else {
+				top = types.Float(v)
+			}
+		}
+		a[3] = top
+	}
+	a[2] = left
+}
+
+// transformDestArray applies m to the coordinates of an explicit destination.
+// The single coordinate of FitH, FitV, FitBH and FitBV destinations
+// ends up on the other axis for pages rotated by 90 or 270 degrees which swaps the destination type.
+func transformDestArray(a types.Array, m matrix.Matrix) {
+	typ, ok := a[1].(types.Name)
+	if !ok || len(a) < 3 {
+		return
+	}
+
+	switch typ {
+
+	case "XYZ":
+		transformDestXYZ(a, m)
+
+	case "FitH", "FitBH":
+		y, ok := numberValue(a[2])
+		if !ok {
+			return
+		}
+		v, swapped := transformY(m, y)
+		a[2] = types.Float(v)
+		if swapped {
+			a[1] = swappedFit[typ]
+		}
+
+	case "FitV", "FitBV":
+		x, ok := numberValue(a[2])
+		if !ok {
+			return
+		}
+		v, swapped := transformX(m, x)
+		a[2] = types.Float(v)
+		if swapped {
+			a[1] = swappedFit[typ]
+		}
+
+	case "FitR":
+		if len(a) < 6 {
+			return
+		}
+		var ff [4]float64
+		for i := range ff {
+			f, ok := numberValue(a[2+i])
+			if !ok {
+				return
+			}
+			ff[i] = f
+		}
+		r := transformRect(m, types.NewRectangle(ff[0], ff[1], ff[2], ff[3]))
+		a[2], a[3], a[4], a[5] = types.Float(r.LL.X), types.Float(r.LL.Y), types.Float(r.UR.X), types.Float(r.UR.Y)
+	}
+}
+
+// transformDest transforms an explicit destination or a destination dict with an explicit destination.
+// Named destinations are taken care of where they are defined.
+func (dt *destTransformer) transformDest(o types.Object) error {
+	o, err := dt.ctx.Dereference(o)
+	if err != nil || o == nil {
+		return err
+	}
+
+	if d, ok := o.(types.Dict); ok {
+		if o, err = dt.ctx.Dereference(d["D"]); err != nil {
+			return err
+		}
+	}
+
+	a, ok := o.(types.Array)
+	if !ok || len(a) < 2 {
+		return nil
+	}
+
+	ir, ok := a[0].(types.IndirectRef)
+	if !ok {
+		return nil
+	}
+	m, ok := dt.pages[ir.ObjectNumber.Value()]
+	if !ok {
+		return nil
+	}
+
+	// Destination arrays may be shared.
+	if dt.done[&a[0]] {
+		return nil
+	}
+	dt.done[&a[0]] = true
+
+	transformDestArray(a, m)
+
+	return nil
+}
+
+// transformAction transforms the destination of a GoTo action including any subsequent actions.
+func (dt *destTransformer) transformAction(o types.Object) error {
+	if ir, ok := o.(types.IndirectRef); ok {
+		if dt.visited[ir.ObjectNumber.Value()] {
+			return nil
+		}
+		dt.visited[ir.ObjectNumber.Value()] = true
+	}
+
+	o, err := dt.ctx.Dereference(o)
+	if err != nil || o == nil {
+		return err
+	}
+
+	switch o := o.(type) {
+
+	case types.Array:
+		for _, o1 := range o {
+			if err := dt.transformAction(o1); err != nil {
+				return err
+			}
+		}
+
+	case types.Dict:
+		if s := o.NameEntry("S"); s != nil && *s == "GoTo" {
+			if err := dt.transformDest(o["D"]); err != nil {
+				return err
+			}
+		}
+		if next, found := o.Find("Next"); found {
+			return dt.transformAction(next)
+		}
+	}
+
+	return nil
+}
+
+func (dt *destTransformer) transformDestOrAction(d types.Dict) error {
+	if o, found := d.Find("Dest"); found {
+		if err := dt.transformDest(o); err != nil {
+			return err
+		}
+	}
+	if o, found := d.Find("A"); found {
+		return dt.transformAction(o)
+	}
+	return nil
+}
+
+func (dt *destTransformer) transformOutlineItems(o types.Object) error {
+	for o != nil {
+		ir, ok := o.(types.IndirectRef)
+		if !ok || dt.visited[ir.ObjectNumber.Value()] {
+			return nil
+		}
+		dt.visited[ir.ObjectNumber.Value()] = true
+
+		d, err := dt.ctx.DereferenceDict(ir)
+		if err != nil || d == nil {
+			return err
+		}
+
+		if err := dt.transformDestOrAction(d); err != nil {
+			return err
+		}
+
+		if err := dt.transformOutlineItems(d["First"]); err != nil {
+			return err
+		}
+
+		o = d["Next"]
+	}
+	return nil
+}
+
+func (dt *destTransformer) transformLinks() error {
+	for i := 1; i <= dt.ctx.PageCount; i++ {
+		d, _, _, err := dt.ctx.PageDict(i, false)
+		if err != nil {
+			return err
+		}
+		annots, err := dt.ctx.DereferenceArray(d["Annots"])
+		if err != nil {
+			return err
+		}
+		for _, o := range annots {
+			d1, err := dt.ctx.DereferenceDict(o)
+			if err != nil {
+				return err
+			}
+			if d1 == nil {
+				continue
+			}
+			if s := d1.Subtype(); s == nil || *s != "Link" {
+				continue
+			}
+			if err := dt.transformDestOrAction(d1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (dt *destTransformer) transformNamedDests(rootDict types.Dict) error {
+	d, err := dt.ctx.DereferenceDict(rootDict["Dests"])
+	if err != nil {
+		return err
+	}
+	for _, o := range d {
+		if err := dt.transformDest(o); err != nil {
+			return err
+		}
+	}
+
+	if err := dt.ctx.LocateNameTree("Dests", false); err != nil {
+		return err
+	}
+	n := dt.ctx.Names["Dests"]
+	if n == nil {
+		return nil
+	}
+	return n.Process(dt.ctx.XRefTable, func(_ *model.XRefTable, _ string, v *types.Object) error {
+		return dt.transformDest(*v)
+	})
+}
+
+// transformDests applies the matrices of normalized pages to all explicit destinations
+// of outline items, link annotations, named destinations and the document open action targeting these pages.
+func transformDests(ctx *model.Context, pages map[int]matrix.Matrix) error {
+	if len(pages) == 0 {
+		return nil
+	}
+
+	dt := &destTransformer{ctx: ctx, pages: pages, done: map[*types.Object]bool{}, visited: types.IntSet{}}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+
+	if err := dt.transformNamedDests(rootDict); err != nil {
+		return err
+	}
+
+	if o, found := rootDict.Find("OpenAction"); found {
+		o1, err := ctx.Dereference(o)
+		if err != nil {
+			return err
+		}
+		if a, ok := o1.(types.Array); ok {
+			if err := dt.transformDest(a); err != nil {
+				return err
+			}
+		} else if err := dt.transformAction(o); err != nil {
+			return err
+		}
+	}
+
+	outlines, err := ctx.DereferenceDict(rootDict["Outlines"])
+	if err != nil {
+		return err
+	}
+	if outlines != nil {
+		if err := dt.transformOutlineItems(outlines["First"]); err != nil {
+			return err
+		}
+	}
+
+	return dt.transformLinks()
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/bidi"
+	"golang.org/x/text/unicode/norm"
+)
+
+// RFC 3454 B.1: Commonly mapped to nothing.
+var mappedToNothing = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00AD, Hi: 0x00AD, Stride: 1},
+		{Lo: 0x034F, Hi: 0x034F, Stride: 1},
+		{Lo: 0x1806, Hi: 0x1806, Stride: 1},
+		{Lo: 0x180B, Hi: 0x180D, Stride: 1},
+		{Lo: 0x200B, Hi: 0x200D, Stride: 1},
+		{Lo: 0x2060, Hi: 0x2060, Stride: 1},
+		{Lo: 0xFE00, Hi: 0xFE0F, Stride: 1},
+		{Lo: 0xFEFF, Hi: 0xFEFF, Stride: 1},
+	},
+}
+
+// RFC 3454 C.1.2: Non-ASCII space characters.
+var nonASCIISpace = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00A0, Hi: 0x00A0, Stride: 1},
+		{Lo: 0x1680, Hi: 0x1680, Stride: 1},
+		{Lo: 0x2000, Hi: 0x200B, Stride: 1},
+		{Lo: 0x202F, Hi: 0x202F, Stride: 1},
+		{Lo: 0x205F, Hi: 0x205F, Stride: 1},
+		{Lo: 0x3000, Hi: 0x3000, Stride: 1},
+	},
+}
+
+// RFC 3454 C.2 - C.9 as referenced by RFC 4013 2.3.
+var prohibitedOutput = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0000, Hi: 0x001F, Stride: 1}, // C.2.1
+		{Lo: 0x007F, Hi: 0x009F, Stride: 1}, // C.2.1, C.2.2
+		{Lo: 0x0340, Hi: 0x0341, Stride: 1}, // C.8
+		{Lo: 0x06DD, Hi: 0x06DD, Stride: 1}, // C.2.2
+		{Lo: 0x070F, Hi: 0x070F, Stride: 1}, // C.2.2
+		{Lo: 0x180E, Hi: 0x180E, Stride: 1}, // C.2.2
+		{Lo: 0x200C, Hi: 0x200F, Stride: 1}, // C.2.2, C.8
+		{Lo: 0x2028, Hi: 0x202E, Stride: 1}, // C.2.2, C.8
+		{Lo: 0x2060, Hi: 0x2063, Stride: 1}, // C.2.2
+		{Lo: 0x206A, Hi: 0x206F, Stride: 1}, // C.2.2, C.8
+		{Lo: 0x2FF0, Hi: 0x2FFB, Stride: 1}, // C.7
+		{Lo: 0xD800, Hi: 0xDFFF, Stride: 1}, // C.5
+		{Lo: 0xE000, Hi: 0xF8FF, Stride: 1}, // C.3
+		{Lo: 0xFDD0, Hi: 0xFDEF, Stride: 1}, // C.4
+		{Lo: 0xFEFF, Hi: 0xFEFF, Stride: 1}, // C.2.2
+		{Lo: 0xFFF9, Hi: 0xFFFF, Stride: 1}, // C.2.2, C.4, C.6
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1D173, Hi: 0x1D17A, Stride: 1}, // C.2.2
+		{Lo: 0x1FFFE, Hi: 0x1FFFF, Stride: 1}, // C.4
+		{Lo: 0x2FFFE, Hi: 0x2FFFF, Stride: 1},
+		{Lo: 0x3FFFE, Hi: 0x3FFFF, Stride: 1},
+		{Lo: 0x4FFFE, Hi: 0x4FFFF, Stride: 1},
+		{Lo: 0x5FFFE, Hi: 0x5FFFF, Stride: 1},
+		{Lo: 0x6FFFE, Hi: 0x6FFFF, Stride: 1},
+		{Lo: 0x7FFFE, Hi: 0x7FFFF, Stride: 1},
+		{Lo: 0x8FFFE, Hi: 0x8FFFF, Stride: 1},
+		{Lo: 0x9FFFE, Hi: 0x9FFFF, Stride: 1},
+		{Lo: 0xAFFFE, Hi: 0xAFFFF, Stride: 1},
+		{Lo: 0xBFFFE, Hi: 0xBFFFF, Stride: 1},
+		{Lo: 0xCFFFE, Hi: 0xCFFFF, Stride: 1},
+		{Lo: 0xDFFFE, Hi: 0xDFFFF, Stride: 1},
+		{Lo: 0xE0001, Hi: 0xE0001, Stride: 1},  // C.9
+		{Lo: 0xE0020, Hi: 0xE007F, Stride: 1},  // C.9
+		{Lo: 0xEFFFE, Hi: 0xEFFFF, Stride: 1},  // C.4
+		{Lo: 0xF0000, Hi: 0x10FFFF, Stride: 1}, // C.3, C.4
+	},
+}
+
+// saslPrep applies the SASLprep profile (RFC 4013) of stringprep (RFC 3454) to s.
+// Unassigned code points are allowed as for stringprep queries.
+func saslPrep(s string) (string, error) {
+
+	// 1) Map
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.Is(mappedToNothing, r) {
+			continue
+		}
+		if unicode.Is(nonASCIISpace, r) {
+			r = ' '
+		}
+		sb.WriteRune(r)
+	}
+
+	// 2) Normalize
+	s = norm.NFKC.String(sb.String())
+
+	// 3) Prohibit
+	var randAL, l bool
+	for _, r := range s {
+		if unicode.Is(prohibitedOutput, r) || unicode.Is(nonASCIISpace, r) {
+			return "", errors.Errorf("pdfcpu: saslPrep: prohibited character %U", r)
+		}
+		p, _ := bidi.LookupRune(r)
+		switch p.Class() {
+		case bidi.R, bidi.AL:
+			randAL = true
+		case bidi.L:
+			l = true
+		}
+	}
+
+	// 4) Check bidi
+	if randAL {
+		rr := []rune(s)
+		first, _ := bidi.LookupRune(rr[0])
+		last, _ := bidi.LookupRune(rr[len(rr)-1])
+		isRandAL := func(c bidi.Class) bool { return c == bidi.R || c == bidi.AL }
+		if l || !isRandAL(first.Class()) || !isRandAL(last.Class()) {
+			return "", errors.New("pdfcpu: saslPrep: invalid bidirectional string")
+		}
+	}
+
+	return s, nil
+}
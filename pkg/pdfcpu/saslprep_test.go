@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSASLPrep(t *testing.T) {
+	// Examples taken from RFC 4013 section 3.
+	for _, tt := range []struct {
+		in, want string
+		err      bool
+	}{
+		{in: "I\u00ADX", want: "IX"},
+		{in: "user", want: "user"},
+		{in: "USER", want: "USER"},
+		{in: "\u00AA", want: "a"},
+		{in: "\u2168", want: "IX"},
+		{in: "\u0007", err: true},
+		{in: "\u0627\u0031", err: true},
+		{in: "\u0627\u0031\u0628", want: "\u0627\u0031\u0628"},
+		{in: "a\u00A0b", want: "a b"},
+	} {
+		got, err := saslPrep(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("saslPrep(%q): want error", tt.in)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("saslPrep(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestPasswordAES256(t *testing.T) {
+	pw := strings.Repeat("ö", 100)
+	bb, err := passwordAES256(pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bb) != 127 || string(bb) != pw[:127] {
+		t.Fatalf("passwordAES256: want first 127 bytes, got %d bytes", len(bb))
+	}
+}
@@ -0,0 +1,730 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+var shadingTypes = map[int]string{
+	1: "Function-based",
+	2: "Axial",
+	3: "Radial",
+	4: "Free-form Gouraud",
+	5: "Lattice-form Gouraud",
+	6: "Coons patch",
+	7: "Tensor-product patch",
+}
+
+// Shading describes a shading used by a page either directly or by a shading pattern.
+type Shading struct {
+	ObjNr       int    // The object number of the shading, 0 for direct objects.
+	ID          string // The resource name of the shading or shading pattern.
+	ShadingType int
+	ColorSpace  string // The color space family.
+	Pattern     bool   // true for shadings used by shading patterns.
+
+	// The number of triangles (type 4,5) or patches (type 6,7) of a mesh shading
+	// or the number of functions making up the color function of any other shading.
+	Elements int
+}
+
+// TypeString returns a string rep for the shading type.
+func (sh Shading) TypeString() string {
+	if s, ok := shadingTypes[sh.ShadingType]; ok {
+		return s
+	}
+	return "Unknown"
+}
+
+func (sh Shading) String() string {
+	s := "Shading"
+	if sh.Pattern {
+		s = "Pattern"
+	}
+	return fmt.Sprintf("%s %s obj#%d: %s %s, %d elements", s, sh.ID, sh.ObjNr, sh.TypeString(), sh.ColorSpace, sh.Elements)
+}
+
+// TilingPattern describes a tiling pattern used by a page.
+type TilingPattern struct {
+	ObjNr      int    // The object number of the pattern.
+	ID         string // The resource name of the pattern.
+	PaintType  int    // 1: colored, 2: uncolored
+	TilingType int    // 1: constant spacing, 2: no distortion, 3: constant spacing and faster tiling
+	BBox       *types.Rectangle
+	XStep      float64
+	YStep      float64
+}
+
+func (tp TilingPattern) String() string {
+	paint := "colored"
+	if tp.PaintType == 2 {
+		paint = "uncolored"
+	}
+	return fmt.Sprintf("Pattern %s obj#%d: Tiling %s, tilingType:%d, bbox:%s, step:%.2f/%.2f",
+		tp.ID, tp.ObjNr, paint, tp.TilingType, tp.BBox, tp.XStep, tp.YStep)
+}
+
+// PageShadings lists the shadings and tiling patterns used by a page.
+type PageShadings struct {
+	Shadings []Shading
+	Patterns []TilingPattern
+}
+
+// colorSpaceInfo returns the family and the number of color components of color space o.
+func colorSpaceInfo(ctx *model.Context, o types.Object) (string, int, error) {
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch o := o.(type) {
+
+	case types.Name:
+		switch o.Value() {
+		case model.DeviceGrayCS:
+			return o.Value(), 1, nil
+		case model.DeviceRGBCS:
+			return o.Value(), 3, nil
+		case model.DeviceCMYKCS:
+			return o.Value(), 4, nil
+		}
+		return o.Value(), 0, nil
+
+	case types.Array:
+		if len(o) == 0 {
+			break
+		}
+		n, ok := o[0].(types.Name)
+		if !ok {
+			break
+		}
+		switch n.Value() {
+		case model.CalGrayCS, model.SeparationCS, model.IndexedCS:
+			return n.Value(), 1, nil
+		case model.CalRGBCS, model.LabCS:
+			return n.Value(), 3, nil
+		case model.ICCBasedCS:
+			if len(o) > 1 {
+				sd, _, err := ctx.DereferenceStreamDict(o[1])
+				if err != nil {
+					return "", 0, err
+				}
+				if sd != nil {
+					if n := sd.IntEntry("N"); n != nil {
+						return model.ICCBasedCS, *n, nil
+					}
+				}
+			}
+		case model.DeviceNCS:
+			if len(o) > 1 {
+				a, err := ctx.DereferenceArray(o[1])
+				if err != nil {
+					return "", 0, err
+				}
+				return model.DeviceNCS, len(a), nil
+			}
+		}
+		return n.Value(), 0, nil
+	}
+
+	return "", 0, errors.New("pdfcpu: corrupt color space")
+}
+
+// shadingDict returns the dict of the shading o and for mesh shadings its stream dict.
+func shadingDict(ctx *model.Context, o types.Object) (types.Dict, *types.StreamDict, error) {
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch o := o.(type) {
+	case types.Dict:
+		return o, nil, nil
+	case types.StreamDict:
+		return o.Dict, &o, nil
+	}
+	return nil, nil, errors.New("pdfcpu: corrupt shading")
+}
+
+func shadingInfo(ctx *model.Context, o types.Object) (*Shading, error) {
+	d, sd, err := shadingDict(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	sh := &Shading{}
+	if ir, ok := o.(types.IndirectRef); ok {
+		sh.ObjNr = ir.ObjectNumber.Value()
+	}
+	if st := d.IntEntry("ShadingType"); st != nil {
+		sh.ShadingType = *st
+	}
+
+	cs, n, err := colorSpaceInfo(ctx, d["ColorSpace"])
+	if err != nil {
+		return nil, err
+	}
+	sh.ColorSpace = cs
+
+	if sh.ShadingType < 4 {
+		if o, found := d.Find("Function"); found {
+			if sh.Elements, err = functionCount(ctx, o); err != nil {
+				return nil, err
+			}
+		}
+		return sh, nil
+	}
+
+	if sd == nil {
+		return nil, errors.Errorf("pdfcpu: shading type %d: missing stream", sh.ShadingType)
+	}
+
+	if _, found := d.Find("Function"); found {
+		n = 1
+	}
+	m, err := decodeMesh(ctx, sd, sh.ShadingType, n)
+	if err != nil {
+		return nil, err
+	}
+	sh.Elements = m.elements()
+
+	return sh, nil
+}
+
+func tilingPattern(ctx *model.Context, id string, ir *types.IndirectRef, sd *types.StreamDict) (*TilingPattern, error) {
+	tp := &TilingPattern{ID: id}
+	if ir != nil {
+		tp.ObjNr = ir.ObjectNumber.Value()
+	}
+	if i := sd.IntEntry("PaintType"); i != nil {
+		tp.PaintType = *i
+	}
+	if i := sd.IntEntry("TilingType"); i != nil {
+		tp.TilingType = *i
+	}
+	if bb, err := numberArray(ctx, sd.Dict, "BBox"); err == nil && len(bb) == 4 {
+		tp.BBox = types.NewRectangle(bb[0], bb[1], bb[2], bb[3])
+	}
+	var err error
+	if tp.XStep, err = numberEntry(ctx, sd.Dict, "XStep", 0); err != nil {
+		return nil, err
+	}
+	if tp.YStep, err = numberEntry(ctx, sd.Dict, "YStep", 0); err != nil {
+		return nil, err
+	}
+	return tp, nil
+}
+
+func collectPatterns(ctx *model.Context, d types.Dict, ps *PageShadings, visited map[int]bool) error {
+	for id, o := range d {
+		ir, isRef := o.(types.IndirectRef)
+		if isRef {
+			if visited[ir.ObjectNumber.Value()] {
+				continue
+			}
+			visited[ir.ObjectNumber.Value()] = true
+		}
+
+		o, err := ctx.Dereference(o)
+		if err != nil {
+			return err
+		}
+
+		switch o := o.(type) {
+
+		case types.StreamDict:
+			// Tiling pattern
+			var irp *types.IndirectRef
+			if isRef {
+				irp = &ir
+			}
+			tp, err := tilingPattern(ctx, id, irp, &o)
+			if err != nil {
+				return err
+			}
+			ps.Patterns = append(ps.Patterns, *tp)
+			if err := collectShadings(ctx, o.Dict["Resources"], ps, visited); err != nil {
+				return err
+			}
+
+		case types.Dict:
+			// Shading pattern
+			o1, found := o.Find("Shading")
+			if !found {
+				continue
+			}
+			sh, err := shadingInfo(ctx, o1)
+			if err != nil {
+				return err
+			}
+			sh.ID, sh.Pattern = id, true
+			ps.Shadings = append(ps.Shadings, *sh)
+		}
+	}
+
+	return nil
+}
+
+func collectShadings(ctx *model.Context, o types.Object, ps *PageShadings, visited map[int]bool) error {
+	resDict, err := ctx.DereferenceDict(o)
+	if err != nil || resDict == nil {
+		return err
+	}
+
+	d, err := ctx.DereferenceDict(resDict["Shading"])
+	if err != nil {
+		return err
+	}
+	for id, o := range d {
+		if ir, ok := o.(types.IndirectRef); ok {
+			if visited[ir.ObjectNumber.Value()] {
+				continue
+			}
+			visited[ir.ObjectNumber.Value()] = true
+		}
+		sh, err := shadingInfo(ctx, o)
+		if err != nil {
+			return err
+		}
+		sh.ID = id
+		ps.Shadings = append(ps.Shadings, *sh)
+	}
+
+	if d, err = ctx.DereferenceDict(resDict["Pattern"]); err != nil {
+		return err
+	}
+	if err := collectPatterns(ctx, d, ps, visited); err != nil {
+		return err
+	}
+
+	// Form XObjects
+	if d, err = ctx.DereferenceDict(resDict["XObject"]); err != nil {
+		return err
+	}
+	for _, o := range d {
+		ir, ok := o.(types.IndirectRef)
+		if !ok || visited[ir.ObjectNumber.Value()] {
+			continue
+		}
+		visited[ir.ObjectNumber.Value()] = true
+		sd, _, err := ctx.DereferenceStreamDict(ir)
+		if err != nil {
+			return err
+		}
+		if sd == nil || sd.Subtype() == nil || *sd.Subtype() != "Form" {
+			continue
+		}
+		if err := collectShadings(ctx, sd.Dict["Resources"], ps, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PageShadingsForPage returns the shadings and tiling patterns used by page pageNr including nested form XObjects.
+func PageShadingsForPage(ctx *model.Context, pageNr int) (*PageShadings, error) {
+	_, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, err
+	}
+	if inhPAttrs == nil {
+		return nil, errors.Errorf("pdfcpu: invalid page number: %d", pageNr)
+	}
+
+	ps := &PageShadings{}
+	if err := collectShadings(ctx, inhPAttrs.Resources, ps, map[int]bool{}); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ps.Shadings, func(i, j int) bool { return ps.Shadings[i].ID < ps.Shadings[j].ID })
+	sort.Slice(ps.Patterns, func(i, j int) bool { return ps.Patterns[i].ID < ps.Patterns[j].ID })
+
+	return ps, nil
+}
+
+// Shadings returns the shadings and tiling patterns used by selected pages keyed by page number.
+// Pages without any shadings or patterns are omitted.
+func Shadings(ctx *model.Context, selectedPages types.IntSet) (map[int]PageShadings, error) {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	m := map[int]PageShadings{}
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		if len(selectedPages) > 0 && !selectedPages[i] {
+			continue
+		}
+		ps, err := PageShadingsForPage(ctx, i)
+		if err != nil {
+			return nil, err
+		}
+		if len(ps.Shadings)+len(ps.Patterns) > 0 {
+			m[i] = *ps
+		}
+	}
+
+	return m, nil
+}
+
+// ListShadings returns a formatted list of the shadings and tiling patterns used by selected pages.
+func ListShadings(ctx *model.Context, selectedPages types.IntSet) ([]string, error) {
+	m, err := Shadings(ctx, selectedPages)
+	if err != nil {
+		return nil, err
+	}
+
+	pageNrs := make([]int, 0, len(m))
+	for i := range m {
+		pageNrs = append(pageNrs, i)
+	}
+	sort.Ints(pageNrs)
+
+	var ss []string
+	for _, i := range pageNrs {
+		ss = append(ss, fmt.Sprintf("page %d:", i))
+		for _, sh := range m[i].Shadings {
+			ss = append(ss, "  "+sh.String())
+		}
+		for _, tp := range m[i].Patterns {
+			ss = append(ss, "  "+tp.String())
+		}
+	}
+
+	return ss, nil
+}
+
+// Mesh shadings
+
+type bitReader struct {
+	bb  []byte
+	pos int // bit position
+}
+
+func (r *bitReader) read(n int) (uint64, bool) {
+	if n <= 0 || r.pos+n > 8*len(r.bb) {
+		return 0, false
+	}
+	var v uint64
+	for i := 0; i < n; i++ {
+		b := r.bb[r.pos>>3] >> (7 - uint(r.pos&7)) & 1
+		v = v<<1 | uint64(b)
+		r.pos++
+	}
+	return v, true
+}
+
+func (r *bitReader) align() {
+	r.pos = (r.pos + 7) &^ 7
+}
+
+type meshPoint struct {
+	x, y float64
+}
+
+type meshVertex struct {
+	meshPoint
+	c []float64
+}
+
+type meshTriangle [3]meshVertex
+
+// meshPatch represents a Coons patch.
+// The 12 boundary control points are ordered counter clockwise starting at the first corner.
+// Tensor-product patches are approximated by their boundary.
+type meshPatch struct {
+	p [12]meshPoint
+	c [4][]float64
+}
+
+type mesh struct {
+	triangles []meshTriangle
+	patches   []meshPatch
+}
+
+func (m mesh) elements() int {
+	return len(m.triangles) + len(m.patches)
+}
+
+func (m mesh) bbox() *types.Rectangle {
+	x0, y0, x1, y1 := math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64
+	add := func(p meshPoint) {
+		x0, y0 = math.Min(x0, p.x), math.Min(y0, p.y)
+		x1, y1 = math.Max(x1, p.x), math.Max(y1, p.y)
+	}
+	for _, t := range m.triangles {
+		for _, v := range t {
+			add(v.meshPoint)
+		}
+	}
+	for _, p := range m.patches {
+		for _, pt := range p.p {
+			add(pt)
+		}
+	}
+	if x0 > x1 {
+		return nil
+	}
+	return types.NewRectangle(x0, y0, x1, y1)
+}
+
+type meshReader struct {
+	bitReader
+	bpCoord, bpComp, bpFlag int
+	decode                  []float64
+	n                       int // number of color components
+}
+
+func (r *meshReader) value(bits int, min, max float64) (float64, bool) {
+	v, ok := r.read(bits)
+	if !ok {
+		return 0, false
+	}
+	return min + float64(v)*(max-min)/float64(uint64(1)<<bits-1), true
+}
+
+func (r *meshReader) flag() (int, bool) {
+	v, ok := r.read(r.bpFlag)
+	return int(v), ok
+}
+
+func (r *meshReader) point() (meshPoint, bool) {
+	x, ok := r.value(r.bpCoord, r.decode[0], r.decode[1])
+	if !ok {
+		return meshPoint{}, false
+	}
+	y, ok := r.value(r.bpCoord, r.decode[2], r.decode[3])
+	return meshPoint{x, y}, ok
+}
+
+func (r *meshReader) color() ([]float64, bool) {
+	c := make([]float64, r.n)
+	for i := range c {
+		v, ok := r.value(r.bpComp, r.decode[4+2*i], r.decode[5+2*i])
+		if !ok {
+			return nil, false
+		}
+		c[i] = v
+	}
+	return c, true
+}
+
+func (r *meshReader) vertex() (meshVertex, bool) {
+	p, ok := r.point()
+	if !ok {
+		return meshVertex{}, false
+	}
+	c, ok := r.color()
+	return meshVertex{p, c}, ok
+}
+
+func newMeshReader(ctx *model.Context, sd *types.StreamDict, shadingType, n int) (*meshReader, error) {
+	if err := sd.Decode(); err != nil {
+		return nil, err
+	}
+
+	r := &meshReader{bitReader: bitReader{bb: sd.Content}, n: n}
+
+	for _, e := range []struct {
+		key string
+		v   *int
+	}{
+		{"BitsPerCoordinate", &r.bpCoord},
+		{"BitsPerComponent", &r.bpComp},
+		{"BitsPerFlag", &r.bpFlag},
+	} {
+		if e.key == "BitsPerFlag" && shadingType == 5 {
+			continue
+		}
+		i := sd.IntEntry(e.key)
+		if i == nil || *i < 1 || *i > 32 {
+			return nil, errors.Errorf("pdfcpu: shading type %d: corrupt %s", shadingType, e.key)
+		}
+		*e.v = *i
+	}
+
+	var err error
+	if r.decode, err = numberArray(ctx, sd.Dict, "Decode"); err != nil {
+		return nil, err
+	}
+	if len(r.decode) < 4+2*n {
+		return nil, errors.Errorf("pdfcpu: shading type %d: corrupt Decode", shadingType)
+	}
+
+	return r, nil
+}
+
+func decodeFreeFormMesh(r *meshReader) []meshTriangle {
+	var (
+		tt      []meshTriangle
+		a, b, c meshVertex
+	)
+	for {
+		f, ok := r.flag()
+		if !ok {
+			break
+		}
+		v, ok := r.vertex()
+		if !ok {
+			break
+		}
+		r.align()
+
+		switch f {
+		case 0:
+			a = v
+			if _, ok = r.flag(); ok {
+				b, ok = r.vertex()
+				r.align()
+			}
+			if ok {
+				_, ok = r.flag()
+			}
+			if ok {
+				c, ok = r.vertex()
+				r.align()
+			}
+			if !ok {
+				return tt
+			}
+		case 1:
+			if len(tt) == 0 {
+				return tt
+			}
+			a, b, c = b, c, v
+		default:
+			if len(tt) == 0 {
+				return tt
+			}
+			b, c = c, v
+		}
+
+		tt = append(tt, meshTriangle{a, b, c})
+	}
+	return tt
+}
+
+func decodeLatticeFormMesh(r *meshReader, verticesPerRow int) []meshTriangle {
+	var (
+		tt  []meshTriangle
+		row []meshVertex
+	)
+	for {
+		v, ok := r.vertex()
+		if !ok {
+			break
+		}
+		row = append(row, v)
+		if len(row) < 2*verticesPerRow {
+			continue
+		}
+		for i := 0; i < verticesPerRow-1; i++ {
+			v00, v01 := row[i], row[i+1]
+			v10, v11 := row[verticesPerRow+i], row[verticesPerRow+i+1]
+			tt = append(tt, meshTriangle{v00, v01, v10}, meshTriangle{v01, v11, v10})
+		}
+		row = row[verticesPerRow:]
+	}
+	return tt
+}
+
+func decodePatchMesh(r *meshReader, tensor bool) []meshPatch {
+	var pp []meshPatch
+
+	for {
+		f, ok := r.flag()
+		if !ok || f > 3 || (f > 0 && len(pp) == 0) {
+			break
+		}
+
+		var p meshPatch
+		i, j := 0, 0
+
+		if f > 0 {
+			// Reuse an edge of the previous patch.
+			prev := pp[len(pp)-1]
+			for k := 0; k < 4; k++ {
+				p.p[k] = prev.p[(3*f+k)%12]
+			}
+			p.c[0], p.c[1] = prev.c[f], prev.c[(f+1)%4]
+			i, j = 4, 2
+		}
+
+		for ; i < 12 && ok; i++ {
+			p.p[i], ok = r.point()
+		}
+		if tensor {
+			// Skip the internal control points.
+			for k := 0; k < 4 && ok; k++ {
+				_, ok = r.point()
+			}
+		}
+		for ; j < 4 && ok; j++ {
+			p.c[j], ok = r.color()
+		}
+		if !ok {
+			break
+		}
+		r.align()
+
+		pp = append(pp, p)
+	}
+
+	return pp
+}
+
+// decodeMesh decodes the mesh of a shading of type 4 to 7 with n color components per vertex.
+func decodeMesh(ctx *model.Context, sd *types.StreamDict, shadingType, n int) (*mesh, error) {
+	if n == 0 {
+		return nil, errors.Errorf("pdfcpu: shading type %d: unsupported color space", shadingType)
+	}
+
+	r, err := newMeshReader(ctx, sd, shadingType, n)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &mesh{}
+
+	switch shadingType {
+
+	case 4:
+		m.triangles = decodeFreeFormMesh(r)
+
+	case 5:
+		vpr := sd.IntEntry("VerticesPerRow")
+		if vpr == nil || *vpr < 2 {
+			return nil, errors.New("pdfcpu: shading type 5: corrupt VerticesPerRow")
+		}
+		m.triangles = decodeLatticeFormMesh(r, *vpr)
+
+	case 6, 7:
+		m.patches = decodePatchMesh(r, shadingType == 7)
+
+	default:
+		return nil, errors.Errorf("pdfcpu: shading type %d: no mesh", shadingType)
+	}
+
+	return m, nil
+}
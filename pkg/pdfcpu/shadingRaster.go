@@ -0,0 +1,705 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+const (
+	// ShadingRasterMinElements is the number of triangles or patches making a mesh shading pathological.
+	ShadingRasterMinElements = 1000
+
+	shadingRasterScale   = 150. / 72 // 150 dpi
+	shadingRasterMaxSize = 2048      // Max image width and height in pixels.
+)
+
+// raster is a simple scanline buffer for rendering Gouraud shaded triangles.
+type raster struct {
+	w, h   int
+	x0, y1 float64 // upper left corner in shading space
+	s      float64 // scale factor shading space -> pixels
+	n      int     // number of color components per pixel
+	pix    []float64
+	mask   []byte
+	f      function
+}
+
+func newRaster(bb *types.Rectangle, n int, f function) *raster {
+	s := shadingRasterScale
+	if m := math.Max(bb.Width(), bb.Height()) * s; m > shadingRasterMaxSize {
+		s *= shadingRasterMaxSize / m
+	}
+	w := int(math.Max(1, math.Ceil(bb.Width()*s)))
+	h := int(math.Max(1, math.Ceil(bb.Height()*s)))
+	return &raster{
+		w: w, h: h,
+		x0: bb.LL.X, y1: bb.UR.Y,
+		s: s, n: n,
+		pix:  make([]float64, w*h*n),
+		mask: make([]byte, w*h),
+		f:    f,
+	}
+}
+
+func (r *raster) pixel(p meshPoint) (float64, float64) {
+	return (p.x - r.x0) * r.s, (r.y1 - p.y) * r.s
+}
+
+func (r *raster) fillTriangle(t meshTriangle) {
+	var px, py [3]float64
+	for i, v := range t {
+		px[i], py[i] = r.pixel(v.meshPoint)
+	}
+
+	det := (py[1]-py[2])*(px[0]-px[2]) + (px[2]-px[1])*(py[0]-py[2])
+	if det == 0 {
+		return
+	}
+
+	i0 := int(math.Max(0, math.Floor(math.Min(px[0], math.Min(px[1], px[2])))))
+	i1 := int(math.Min(float64(r.w-1), math.Ceil(math.Max(px[0], math.Max(px[1], px[2])))))
+	j0 := int(math.Max(0, math.Floor(math.Min(py[0], math.Min(py[1], py[2])))))
+	j1 := int(math.Min(float64(r.h-1), math.Ceil(math.Max(py[0], math.Max(py[1], py[2])))))
+
+	const eps = -1e-9
+	c := make([]float64, len(t[0].c))
+
+	for j := j0; j <= j1; j++ {
+		y := float64(j) + .5
+		for i := i0; i <= i1; i++ {
+			x := float64(i) + .5
+			l0 := ((py[1]-py[2])*(x-px[2]) + (px[2]-px[1])*(y-py[2])) / det
+			l1 := ((py[2]-py[0])*(x-px[2]) + (px[0]-px[2])*(y-py[2])) / det
+			l2 := 1 - l0 - l1
+			if l0 < eps || l1 < eps || l2 < eps {
+				continue
+			}
+			for k := range c {
+				c[k] = l0*t[0].c[k] + l1*t[1].c[k] + l2*t[2].c[k]
+			}
+			out := c
+			if r.f != nil {
+				out = r.f.eval(c[0])
+			}
+			off := (j*r.w + i) * r.n
+			for k := 0; k < r.n && k < len(out); k++ {
+				r.pix[off+k] = out[k]
+			}
+			r.mask[j*r.w+i] = 0xFF
+		}
+	}
+}
+
+func bezier(p0, p1, p2, p3 meshPoint, t float64) meshPoint {
+	a, b, c, d := (1-t)*(1-t)*(1-t), 3*t*(1-t)*(1-t), 3*t*t*(1-t), t*t*t
+	return meshPoint{
+		a*p0.x + b*p1.x + c*p2.x + d*p3.x,
+		a*p0.y + b*p1.y + c*p2.y + d*p3.y,
+	}
+}
+
+// coons returns the point at u,v of the Coons surface defined by the boundary of p.
+func (p meshPatch) coons(u, v float64) meshPoint {
+	pp := p.p
+	p00, p03, p33, p30 := pp[0], pp[3], pp[6], pp[9]
+	c1 := bezier(p00, pp[11], pp[10], p30, u)
+	c2 := bezier(p03, pp[4], pp[5], p33, u)
+	d1 := bezier(p00, pp[1], pp[2], p03, v)
+	d2 := bezier(p30, pp[8], pp[7], p33, v)
+	x := (1-v)*c1.x + v*c2.x + (1-u)*d1.x + u*d2.x -
+		((1-v)*((1-u)*p00.x+u*p30.x) + v*((1-u)*p03.x+u*p33.x))
+	y := (1-v)*c1.y + v*c2.y + (1-u)*d1.y + u*d2.y -
+		((1-v)*((1-u)*p00.y+u*p30.y) + v*((1-u)*p03.y+u*p33.y))
+	return meshPoint{x, y}
+}
+
+func (p meshPatch) color(u, v float64) []float64 {
+	c := make([]float64, len(p.c[0]))
+	for i := range c {
+		c[i] = (1-u)*(1-v)*p.c[0][i] + (1-u)*v*p.c[1][i] + u*v*p.c[2][i] + u*(1-v)*p.c[3][i]
+	}
+	return c
+}
+
+func (r *raster) fillPatch(p meshPatch) {
+	// Choose the subdivision depending on the size of the patch in pixels.
+	x0, y0, x1, y1 := math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64
+	for _, pt := range p.p {
+		x, y := r.pixel(pt)
+		x0, y0, x1, y1 = math.Min(x0, x), math.Min(y0, y), math.Max(x1, x), math.Max(y1, y)
+	}
+	k := int(math.Min(16, math.Max(1, math.Ceil(math.Max(x1-x0, y1-y0)/4))))
+
+	vertex := func(i, j int) meshVertex {
+		u, v := float64(i)/float64(k), float64(j)/float64(k)
+		return meshVertex{p.coons(u, v), p.color(u, v)}
+	}
+
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			v00, v01, v10, v11 := vertex(i, j), vertex(i, j+1), vertex(i+1, j), vertex(i+1, j+1)
+			r.fillTriangle(meshTriangle{v00, v10, v11})
+			r.fillTriangle(meshTriangle{v00, v11, v01})
+		}
+	}
+}
+
+// closeGaps fills single pixel gaps caused by cracks between adjacent patches.
+func (r *raster) closeGaps() {
+	for j := 1; j < r.h-1; j++ {
+		for i := 1; i < r.w-1; i++ {
+			p := j*r.w + i
+			if r.mask[p] != 0 {
+				continue
+			}
+			nb := []int{p - 1, p + 1, p - r.w, p + r.w}
+			c := 0
+			for _, q := range nb {
+				if r.mask[q] != 0 {
+					c++
+				}
+			}
+			if c < 3 {
+				continue
+			}
+			for _, q := range nb {
+				if r.mask[q] != 0 {
+					copy(r.pix[p*r.n:(p+1)*r.n], r.pix[q*r.n:(q+1)*r.n])
+					break
+				}
+			}
+			r.mask[p] = 0xFF
+		}
+	}
+}
+
+func (r *raster) imageBytes(indexed bool) ([]byte, []byte) {
+	bb := make([]byte, len(r.pix))
+	for i, v := range r.pix {
+		if indexed {
+			bb[i] = byte(clip(math.Round(v), 0, 255))
+			continue
+		}
+		bb[i] = byte(math.Round(clip(v, 0, 1) * 255))
+	}
+	return bb, r.mask
+}
+
+func createShadingImage(ctx *model.Context, cs types.Object, r *raster, indexed bool) (*types.IndirectRef, error) {
+	buf, mask := r.imageBytes(indexed)
+
+	sm, err := ctx.NewStreamDictForBuf(mask)
+	if err != nil {
+		return nil, err
+	}
+	sm.InsertName("Type", "XObject")
+	sm.InsertName("Subtype", "Image")
+	sm.InsertInt("Width", r.w)
+	sm.InsertInt("Height", r.h)
+	sm.InsertInt("BitsPerComponent", 8)
+	sm.InsertName("ColorSpace", model.DeviceGrayCS)
+	if err := sm.Encode(); err != nil {
+		return nil, err
+	}
+	smIndRef, err := ctx.IndRefForNewObject(*sm)
+	if err != nil {
+		return nil, err
+	}
+
+	sd, err := ctx.NewStreamDictForBuf(buf)
+	if err != nil {
+		return nil, err
+	}
+	sd.InsertName("Type", "XObject")
+	sd.InsertName("Subtype", "Image")
+	sd.InsertInt("Width", r.w)
+	sd.InsertInt("Height", r.h)
+	sd.InsertInt("BitsPerComponent", 8)
+	sd.Insert("ColorSpace", cs)
+	sd.Insert("SMask", *smIndRef)
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	return ctx.IndRefForNewObject(*sd)
+}
+
+type rasterizedShading struct {
+	img  types.IndirectRef
+	bbox *types.Rectangle
+}
+
+// rasterizeShading renders the mesh shading sd into an image.
+// Returns nil for shadings that are not pathological or cannot be rendered.
+func rasterizeShading(ctx *model.Context, sd *types.StreamDict) (*rasterizedShading, error) {
+	st := sd.IntEntry("ShadingType")
+	if st == nil || *st < 4 || *st > 7 {
+		return nil, nil
+	}
+
+	csName, n, err := colorSpaceInfo(ctx, sd.Dict["ColorSpace"])
+	if err != nil || n == 0 || csName == model.LabCS {
+		return nil, err
+	}
+
+	var f function
+	nComp := n
+	if o, found := sd.Find("Function"); found {
+		if f, err = newFunction(ctx, o); err != nil {
+			if err == ErrUnsupportedFunction {
+				err = nil
+			}
+			return nil, err
+		}
+		nComp = 1
+	}
+
+	m, err := decodeMesh(ctx, sd, *st, nComp)
+	if err != nil {
+		return nil, err
+	}
+	if m.elements() < ShadingRasterMinElements {
+		return nil, nil
+	}
+
+	bb := m.bbox()
+	if bb == nil || bb.Width() <= 0 || bb.Height() <= 0 {
+		return nil, nil
+	}
+
+	r := newRaster(bb, n, f)
+	for _, t := range m.triangles {
+		r.fillTriangle(t)
+	}
+	for _, p := range m.patches {
+		r.fillPatch(p)
+	}
+	r.closeGaps()
+
+	ir, err := createShadingImage(ctx, sd.Dict["ColorSpace"], r, csName == model.IndexedCS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rasterizedShading{img: *ir, bbox: bb}, nil
+}
+
+func imageDrawOps(name string, bb *types.Rectangle) []ContentOperation {
+	return []ContentOperation{
+		{Operator: "q"},
+		{Operator: "cm", Operands: []types.Object{
+			types.Float(bb.Width()), types.Integer(0), types.Integer(0), types.Float(bb.Height()),
+			types.Float(bb.LL.X), types.Float(bb.LL.Y)}},
+		{Operator: "Do", Operands: []types.Object{types.Name(name)}},
+		{Operator: "Q"},
+	}
+}
+
+// tilingPatternForShading returns a single tile pattern painting the rasterized shading rs.
+func tilingPatternForShading(ctx *model.Context, d types.Dict, rs *rasterizedShading) (*types.StreamDict, error) {
+	sd, err := ctx.NewStreamDictForBuf(ContentStreamBytes(imageDrawOps("Im0", rs.bbox)))
+	if err != nil {
+		return nil, err
+	}
+	sd.InsertName("Type", "Pattern")
+	sd.InsertInt("PatternType", 1)
+	sd.InsertInt("PaintType", 1)
+	sd.InsertInt("TilingType", 1)
+	sd.Insert("BBox", rs.bbox.Array())
+
+	// Make sure there is only one tile.
+	sd.Insert("XStep", types.Integer(32767))
+	sd.Insert("YStep", types.Integer(32767))
+
+	if o, found := d.Find("Matrix"); found {
+		sd.Insert("Matrix", o)
+	}
+	if o, found := d.Find("ExtGState"); found {
+		sd.Insert("ExtGState", o)
+	}
+	sd.Insert("Resources", types.Dict(map[string]types.Object{
+		"XObject": types.Dict(map[string]types.Object{"Im0": rs.img}),
+	}))
+
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	return sd, nil
+}
+
+func shadingPatternObjNr(ctx *model.Context, o types.Object) (types.Dict, int) {
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return nil, 0
+	}
+	if pt := d.IntEntry("PatternType"); pt == nil || *pt != 2 {
+		return nil, 0
+	}
+	ir := d.IndirectRefEntry("Shading")
+	if ir == nil {
+		return nil, 0
+	}
+	return d, ir.ObjectNumber.Value()
+}
+
+type shadingRasterizer struct {
+	ctx        *model.Context
+	shadings   map[int]*rasterizedShading
+	parseError bool
+	resDicts   []types.Dict
+}
+
+func xObjectName(d types.Dict) string {
+	for i := 0; ; i++ {
+		s := fmt.Sprintf("ImSh%d", i)
+		if _, found := d.Find(s); !found {
+			return s
+		}
+	}
+}
+
+// rewriteContent replaces all sh operators painting a rasterized shading by the corresponding image.
+func (sr *shadingRasterizer) rewriteContent(sd *types.StreamDict, resDict types.Dict) (bool, error) {
+	shDict, err := sr.ctx.DereferenceDict(resDict["Shading"])
+	if err != nil || shDict == nil {
+		return false, err
+	}
+
+	if err := sd.Decode(); err != nil {
+		return false, err
+	}
+	if !bytes.Contains(sd.Content, []byte("sh")) {
+		return false, nil
+	}
+
+	ops, err := ParseContentStream(sd.Content)
+	if err != nil {
+		sr.parseError = true
+		return false, nil
+	}
+
+	var (
+		ops1     []ContentOperation
+		xObjDict types.Dict
+		names    = map[int]string{}
+		modified bool
+	)
+
+	for _, op := range ops {
+		if op.Operator != "sh" || len(op.Operands) != 1 {
+			ops1 = append(ops1, op)
+			continue
+		}
+		id, ok := op.Operands[0].(types.Name)
+		if !ok {
+			ops1 = append(ops1, op)
+			continue
+		}
+		ir, ok := shDict[id.Value()].(types.IndirectRef)
+		if !ok || sr.shadings[ir.ObjectNumber.Value()] == nil {
+			ops1 = append(ops1, op)
+			continue
+		}
+		objNr := ir.ObjectNumber.Value()
+		rs := sr.shadings[objNr]
+
+		if xObjDict == nil {
+			if xObjDict, err = sr.ctx.DereferenceDict(resDict["XObject"]); err != nil {
+				return false, err
+			}
+			if xObjDict == nil {
+				xObjDict = types.NewDict()
+				resDict.Insert("XObject", xObjDict)
+			}
+		}
+
+		name, ok := names[objNr]
+		if !ok {
+			name = xObjectName(xObjDict)
+			xObjDict.Insert(name, rs.img)
+			names[objNr] = name
+		}
+
+		ops1 = append(ops1, imageDrawOps(name, rs.bbox)...)
+		modified = true
+	}
+
+	if !modified {
+		return false, nil
+	}
+
+	if err := setContentStream(sd, ContentStreamBytes(ops1)); err != nil {
+		return false, err
+	}
+
+	sr.resDicts = append(sr.resDicts, resDict)
+
+	return true, nil
+}
+
+// replacePatterns replaces direct shading patterns using a rasterized shading.
+func (sr *shadingRasterizer) replacePatterns(resDict types.Dict) error {
+	d, err := sr.ctx.DereferenceDict(resDict["Pattern"])
+	if err != nil || d == nil {
+		return err
+	}
+	for k, o := range d {
+		if _, ok := o.(types.IndirectRef); ok {
+			continue
+		}
+		d1, objNr := shadingPatternObjNr(sr.ctx, o)
+		if rs := sr.shadings[objNr]; rs != nil {
+			sd, err := tilingPatternForShading(sr.ctx, d1, rs)
+			if err != nil {
+				return err
+			}
+			ir, err := sr.ctx.IndRefForNewObject(*sd)
+			if err != nil {
+				return err
+			}
+			d.Update(k, *ir)
+		}
+	}
+	return nil
+}
+
+func (sr *shadingRasterizer) processStream(ir types.IndirectRef, resDict types.Dict) error {
+	entry, ok := sr.ctx.FindTableEntryForIndRef(&ir)
+	if !ok {
+		return nil
+	}
+	sd, ok := entry.Object.(types.StreamDict)
+	if !ok {
+		return nil
+	}
+	modified, err := sr.rewriteContent(&sd, resDict)
+	if err != nil {
+		return err
+	}
+	if modified {
+		entry.Object = sd
+	}
+	return nil
+}
+
+func pageResourceDict(ctx *model.Context, d types.Dict) (types.Dict, error) {
+	for d != nil {
+		if o, found := d.Find("Resources"); found {
+			return ctx.DereferenceDict(o)
+		}
+		var err error
+		if d, err = ctx.DereferenceDict(d["Parent"]); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (sr *shadingRasterizer) processPages() error {
+	ctx := sr.ctx
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		d, _, _, err := ctx.PageDict(i, false)
+		if err != nil {
+			return err
+		}
+		resDict, err := pageResourceDict(ctx, d)
+		if err != nil {
+			return err
+		}
+		if resDict == nil {
+			continue
+		}
+		if err := sr.replacePatterns(resDict); err != nil {
+			return err
+		}
+
+		o, err := ctx.Dereference(d["Contents"])
+		if err != nil {
+			return err
+		}
+		var irs []types.IndirectRef
+		switch o := o.(type) {
+		case types.StreamDict:
+			if ir, ok := d["Contents"].(types.IndirectRef); ok {
+				irs = append(irs, ir)
+			}
+		case types.Array:
+			for _, o1 := range o {
+				if ir, ok := o1.(types.IndirectRef); ok {
+					irs = append(irs, ir)
+				}
+			}
+		}
+		for _, ir := range irs {
+			if err := sr.processStream(ir, resDict); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// processObjects handles form XObjects, tiling patterns and shading patterns.
+func (sr *shadingRasterizer) processObjects() error {
+	ctx := sr.ctx
+
+	for objNr, entry := range ctx.Table {
+		if entry.Free || entry.Compressed && entry.Object == nil {
+			continue
+		}
+		o, err := ctx.FindObject(objNr)
+		if err != nil {
+			return err
+		}
+
+		switch o := o.(type) {
+
+		case types.Dict:
+			d, shObjNr := shadingPatternObjNr(ctx, o)
+			if rs := sr.shadings[shObjNr]; rs != nil {
+				sd, err := tilingPatternForShading(ctx, d, rs)
+				if err != nil {
+					return err
+				}
+				entry.Object = *sd
+			}
+
+		case types.StreamDict:
+			form := o.Subtype() != nil && *o.Subtype() == "Form"
+			tiling := o.IntEntry("PatternType") != nil && *o.IntEntry("PatternType") == 1
+			if !form && !tiling {
+				continue
+			}
+			resDict, err := ctx.DereferenceDict(o.Dict["Resources"])
+			if err != nil {
+				return err
+			}
+			if resDict == nil {
+				continue
+			}
+			if err := sr.replacePatterns(resDict); err != nil {
+				return err
+			}
+			modified, err := sr.rewriteContent(&o, resDict)
+			if err != nil {
+				return err
+			}
+			if modified {
+				entry.Object = o
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeShadingResources removes resource entries of shadings no longer used.
+func (sr *shadingRasterizer) removeShadingResources() error {
+	if sr.parseError {
+		// Some content could not be parsed and may still refer to a rasterized shading.
+		return nil
+	}
+	for _, resDict := range sr.resDicts {
+		d, err := sr.ctx.DereferenceDict(resDict["Shading"])
+		if err != nil || d == nil {
+			return err
+		}
+		for k, o := range d {
+			if ir, ok := o.(types.IndirectRef); ok && sr.shadings[ir.ObjectNumber.Value()] != nil {
+				d.Delete(k)
+			}
+		}
+		if len(d) == 0 {
+			resDict.Delete("Shading")
+		}
+	}
+	return nil
+}
+
+// RasterizeShadings replaces mesh shadings consisting of at least ShadingRasterMinElements triangles or patches
+// by images rendered at 150 dpi. Such shadings are typically emitted by CAD exporters and slow down rendering considerably.
+// Shadings using PostScript calculator functions or the Lab color space are left untouched.
+// Returns the object numbers of the rasterized shadings.
+func RasterizeShadings(ctx *model.Context) ([]int, error) {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	sr := &shadingRasterizer{ctx: ctx, shadings: map[int]*rasterizedShading{}}
+
+	var objNrs []int
+
+	for objNr, entry := range ctx.Table {
+		if entry.Free || entry.Compressed && entry.Object == nil {
+			continue
+		}
+		o, err := ctx.FindObject(objNr)
+		if err != nil {
+			return nil, err
+		}
+		sd, ok := o.(types.StreamDict)
+		if !ok || sd.IntEntry("ShadingType") == nil {
+			continue
+		}
+		rs, err := rasterizeShading(ctx, &sd)
+		if err != nil {
+			if log.DebugEnabled() {
+				log.Debug.Printf("RasterizeShadings: skipping obj#%d: %v\n", objNr, err)
+			}
+			continue
+		}
+		if rs != nil {
+			sr.shadings[objNr] = rs
+			objNrs = append(objNrs, objNr)
+		}
+	}
+
+	if len(objNrs) == 0 {
+		return nil, nil
+	}
+
+	sort.Ints(objNrs)
+
+	if err := sr.processPages(); err != nil {
+		return nil, err
+	}
+
+	if err := sr.processObjects(); err != nil {
+		return nil, err
+	}
+
+	if err := sr.removeShadingResources(); err != nil {
+		return nil, err
+	}
+
+	if log.OptimizeEnabled() {
+		log.Optimize.Printf("rasterized %d shading(s): %v\n", len(objNrs), objNrs)
+	}
+
+	return objNrs, nil
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// CMS (RFC 5652) detached SignedData as required by PAdES baseline B-B (ETSI EN 319 142-1).
+
+var (
+	oidData              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAttrContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningCertV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidSHA1              = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256            = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384            = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512            = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidRSAEncryption     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	asn1Null             = asn1.RawValue{Tag: asn1.TagNull, FullBytes: []byte{0x05, 0x00}}
+	signedDataVersion    = 1
+	signerInfoVersion    = 1
+)
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue // SET OF AttributeValue
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerialNumber
+	DigestAlgorithm    algorithmIdentifier
+	SignedAttrs        asn1.RawValue // [0] IMPLICIT SET OF Attribute
+	SignatureAlgorithm algorithmIdentifier
+	Signature          []byte
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue // SET OF DigestAlgorithmIdentifier
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue // [0] IMPLICIT CertificateSet
+	SignerInfos      asn1.RawValue // SET OF SignerInfo
+}
+
+type signedDataContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue // [0] EXPLICIT SignedData
+}
+
+type essCertIDv2 struct {
+	CertHash     []byte
+	IssuerSerial issuerSerial
+}
+
+type issuerSerial struct {
+	Issuer       asn1.RawValue // GeneralNames
+	SerialNumber *big.Int
+}
+
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+func digestAlgorithmOID(h crypto.Hash) (asn1.ObjectIdentifier, error) {
+	switch h {
+	case crypto.SHA256:
+		return oidSHA256, nil
+	case crypto.SHA384:
+		return oidSHA384, nil
+	case crypto.SHA512:
+		return oidSHA512, nil
+	}
+	return nil, errors.Errorf("pdfcpu: unsupported digest algorithm: %s", h)
+}
+
+func signatureAlgorithm(pub crypto.PublicKey, h crypto.Hash) (algorithmIdentifier, error) {
+	switch pub.(type) {
+
+	case *rsa.PublicKey:
+		return algorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1Null}, nil
+
+	case *ecdsa.PublicKey:
+		switch h {
+		case crypto.SHA256:
+			return algorithmIdentifier{Algorithm: oidECDSAWithSHA256}, nil
+		case crypto.SHA384:
+			return algorithmIdentifier{Algorithm: oidECDSAWithSHA384}, nil
+		case crypto.SHA512:
+			return algorithmIdentifier{Algorithm: oidECDSAWithSHA512}, nil
+		}
+	}
+
+	return algorithmIdentifier{}, errors.Errorf("pdfcpu: unsupported signing key: %T", pub)
+}
+
+// derSet returns the DER encoding of a SET OF containing the DER encoded elements bb.
+func derSet(bb [][]byte) ([]byte, error) {
+	// DER requires the elements of a SET OF to be sorted by their encodings.
+	sort.Slice(bb, func(i, j int) bool { return bytes.Compare(bb[i], bb[j]) < 0 })
+	return asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: bytes.Join(bb, nil)})
+}
+
+func newAttribute(oid asn1.ObjectIdentifier, val interface{}) ([]byte, error) {
+	bb, err := asn1.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := derSet([][]byte{bb})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(attribute{Type: oid, Values: asn1.RawValue{FullBytes: set}})
+}
+
+func signingCertificateV2Attr(cert *x509.Certificate) ([]byte, error) {
+	h := sha256.Sum256(cert.Raw)
+
+	// GeneralNames containing a single directoryName [4].
+	dn, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true, Bytes: cert.RawIssuer})
+	if err != nil {
+		return nil, err
+	}
+	gn, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: dn})
+	if err != nil {
+		return nil, err
+	}
+
+	scv2 := signingCertificateV2{
+		Certs: []essCertIDv2{{
+			// hashAlgorithm defaults to SHA-256 and is omitted.
+			CertHash:     h[:],
+			IssuerSerial: issuerSerial{Issuer: asn1.RawValue{FullBytes: gn}, SerialNumber: cert.SerialNumber},
+		}},
+	}
+
+	return newAttribute(oidAttrSigningCertV2, scv2)
+}
+
+func signedAttributes(digest []byte, cert *x509.Certificate) ([][]byte, error) {
+	ct, err := newAttribute(oidAttrContentType, oidData)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := newAttribute(oidAttrMessageDigest, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	// PAdES forbids the signing-time attribute, the signing time goes into the signature dict.
+	sc, err := signingCertificateV2Attr(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	return [][]byte{ct, md, sc}, nil
+}
+
+// createCMS returns a DER encoded detached CMS SignedData for the content digest using signer and certs[0].
+func createCMS(digest []byte, h crypto.Hash, signer crypto.Signer, certs []*x509.Certificate) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("pdfcpu: missing signing certificate")
+	}
+	cert := certs[0]
+
+	digestOID, err := digestAlgorithmOID(h)
+	if err != nil {
+		return nil, err
+	}
+
+	sigAlg, err := signatureAlgorithm(signer.Public(), h)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs, err := signedAttributes(digest, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature covers the DER encoding of the signed attributes using the universal SET tag.
+	set, err := derSet(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	hh := h.New()
+	hh.Write(set)
+
+	sig, err := signer.Sign(rand.Reader, hh.Sum(nil), h)
+	if err != nil {
+		return nil, errors.Wrap(err, "pdfcpu: signing")
+	}
+
+	// Within SignerInfo the signed attributes are [0] IMPLICIT.
+	signedAttrs := asn1.RawValue{FullBytes: append([]byte{0xA0}, set[1:]...)}
+
+	digestAlg := algorithmIdentifier{Algorithm: digestOID}
+
+	si, err := asn1.Marshal(signerInfo{
+		Version:            signerInfoVersion,
+		SID:                issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber},
+		DigestAlgorithm:    digestAlg,
+		SignedAttrs:        signedAttrs,
+		SignatureAlgorithm: sigAlg,
+		Signature:          sig,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	da, err := asn1.Marshal(digestAlg)
+	if err != nil {
+		return nil, err
+	}
+
+	digestAlgs, err := derSet([][]byte{da})
+	if err != nil {
+		return nil, err
+	}
+
+	signerInfos, err := derSet([][]byte{si})
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []byte
+	for _, c := range certs {
+		raw = append(raw, c.Raw...)
+	}
+
+	certSet, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw})
+	if err != nil {
+		return nil, err
+	}
+
+	sd, err := asn1.Marshal(signedData{
+		Version:          signedDataVersion,
+		DigestAlgorithms: asn1.RawValue{FullBytes: digestAlgs},
+		EncapContentInfo: encapContentInfo{EContentType: oidData},
+		Certificates:     asn1.RawValue{FullBytes: certSet},
+		SignerInfos:      asn1.RawValue{FullBytes: signerInfos},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(signedDataContentInfo{ContentType: oidSignedData, Content: asn1.RawValue{FullBytes: content}})
+}
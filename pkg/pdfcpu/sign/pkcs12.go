@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sign
+
+import (
+	"crypto"
+	"crypto/x509"
+	"os"
+
+	"github.com/pkg/errors"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// LoadPKCS12 decodes the private key and certificates of a PKCS#12 (.p12, .pfx) file.
+// The returned chain starts with the certificate matching the private key.
+func LoadPKCS12(data []byte, password string) (crypto.Signer, []*x509.Certificate, error) {
+	k, cert, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "pdfcpu: PKCS#12")
+	}
+
+	key, ok := k.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.Errorf("pdfcpu: PKCS#12: unsupported private key: %T", k)
+	}
+
+	return key, append([]*x509.Certificate{cert}, caCerts...), nil
+}
+
+// LoadPKCS12File returns signing options for the private key and certificates of a PKCS#12 file.
+func LoadPKCS12File(fileName, password string) (*Options, error) {
+	bb, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	key, certs, err := LoadPKCS12(bb, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Options{Key: key, Certificates: certs}, nil
+}
@@ -0,0 +1,686 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sign provides PAdES digital signature creation and validation.
+package sign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/font"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Placeholder values get patched once the final layout of the increment is known.
+	byteRangePlaceholder = 9999999999
+
+	// Bytes reserved for the CMS signature in addition to the certificate chain.
+	cmsOverhead = 4096
+
+	// Print and Locked annotation flags.
+	sigWidgetFlags = 4 | 128
+)
+
+// Options configures the creation of a signature.
+type Options struct {
+	Key          crypto.Signer       // The signing key.
+	Certificates []*x509.Certificate // The signing certificate optionally followed by the chain.
+	Hash         crypto.Hash         // Digest algorithm: SHA256 (default), SHA384 or SHA512.
+
+	// Signature field to be signed. Created unless there is an unsigned signature field with this name.
+	FieldName string
+	PageNr    int              // Page of a new signature field, defaults to 1.
+	Rect      *types.Rectangle // Location of a new signature field, nil results in an invisible signature.
+
+	Name        string    // Name of the signer, defaults to the common name of the signing certificate.
+	Reason      string    // Reason for signing.
+	Location    string    // Location of signing.
+	ContactInfo string    // Contact info of the signer.
+	SigningTime time.Time // Defaults to now.
+}
+
+func (opts *Options) validate() error {
+	if opts.Key == nil {
+		return errors.New("pdfcpu: missing signing key")
+	}
+
+	if len(opts.Certificates) == 0 {
+		return errors.New("pdfcpu: missing signing certificate")
+	}
+
+	type publicKey interface {
+		Equal(crypto.PublicKey) bool
+	}
+
+	pk, ok := opts.Key.Public().(publicKey)
+	if !ok || !pk.Equal(opts.Certificates[0].PublicKey) {
+		return errors.New("pdfcpu: signing key does not match signing certificate")
+	}
+
+	if opts.Hash == 0 {
+		opts.Hash = crypto.SHA256
+	}
+	if _, err := digestAlgorithmOID(opts.Hash); err != nil {
+		return err
+	}
+	if _, err := signatureAlgorithm(opts.Key.Public(), opts.Hash); err != nil {
+		return err
+	}
+
+	if opts.PageNr == 0 {
+		opts.PageNr = 1
+	}
+
+	if opts.Name == "" {
+		opts.Name = opts.Certificates[0].Subject.CommonName
+	}
+
+	if opts.SigningTime.IsZero() {
+		opts.SigningTime = time.Now()
+	}
+
+	return nil
+}
+
+func objNr(ir types.IndirectRef) int {
+	return ir.ObjectNumber.Value()
+}
+
+// appendToArrayEntry appends ir to the array d[key] and marks the modified object for incremental writing.
+// dObjNr is the object number of the indirect object containing d.
+func appendToArrayEntry(ctx *model.Context, d types.Dict, dObjNr int, key string, ir types.IndirectRef) error {
+	o, found := d.Find(key)
+	if !found {
+		d[key] = types.Array{ir}
+		ctx.Write.IncrementWithObjNr(dObjNr)
+		return nil
+	}
+
+	arrIndRef, ok := o.(types.IndirectRef)
+	if !ok {
+		arr, ok := o.(types.Array)
+		if !ok {
+			return errors.Errorf("pdfcpu: corrupt %s entry", key)
+		}
+		d[key] = append(arr, ir)
+		ctx.Write.IncrementWithObjNr(dObjNr)
+		return nil
+	}
+
+	arr, err := ctx.DereferenceArray(arrIndRef)
+	if err != nil {
+		return err
+	}
+
+	entry, ok := ctx.FindTableEntryForIndRef(&arrIndRef)
+	if !ok {
+		return errors.Errorf("pdfcpu: corrupt %s entry", key)
+	}
+	entry.Object = append(arr, ir)
+	ctx.Write.IncrementWithObjNr(objNr(arrIndRef))
+
+	return nil
+}
+
+// acroForm returns the form dict of ctx (created if missing) and the object number of the object containing it.
+func acroForm(ctx *model.Context) (types.Dict, int, error) {
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return nil, 0, err
+	}
+	rootObjNr := objNr(*ctx.Root)
+
+	o, found := rootDict.Find("AcroForm")
+	if !found {
+		ir, err := ctx.IndRefForNewObject(types.Dict{"Fields": types.Array{}})
+		if err != nil {
+			return nil, 0, err
+		}
+		rootDict["AcroForm"] = *ir
+		ctx.Write.IncrementWithObjNr(rootObjNr)
+		d, err := ctx.DereferenceDict(*ir)
+		return d, objNr(*ir), err
+	}
+
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return nil, 0, errors.New("pdfcpu: corrupt AcroForm")
+	}
+
+	if ir, ok := o.(types.IndirectRef); ok {
+		return d, objNr(ir), nil
+	}
+
+	return d, rootObjNr, nil
+}
+
+func fieldName(ctx *model.Context, d types.Dict) (string, error) {
+	o, found := d.Find("T")
+	if !found {
+		return "", nil
+	}
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return "", err
+	}
+	s, err := types.StringOrHexLiteral(o)
+	if err != nil || s == nil {
+		return "", err
+	}
+	return *s, nil
+}
+
+// findField returns the field with fully qualified name fqName.
+func findField(ctx *model.Context, fields types.Array, prefix, fqName string, depth int) (types.Dict, *types.IndirectRef, error) {
+	if depth > 32 {
+		return nil, nil, errors.New("pdfcpu: corrupt field hierarchy")
+	}
+
+	for _, o := range fields {
+		ir, ok := o.(types.IndirectRef)
+		if !ok {
+			continue
+		}
+
+		d, err := ctx.DereferenceDict(ir)
+		if err != nil {
+			return nil, nil, err
+		}
+		if d == nil {
+			continue
+		}
+
+		t, err := fieldName(ctx, d)
+		if err != nil {
+			return nil, nil, err
+		}
+		if t == "" {
+			// Widget annotation of a field.
+			continue
+		}
+
+		name := t
+		if prefix != "" {
+			name = prefix + "." + t
+		}
+
+		if name == fqName {
+			return d, &ir, nil
+		}
+
+		if !strings.HasPrefix(fqName, name+".") {
+			continue
+		}
+
+		o, found := d.Find("Kids")
+		if !found {
+			continue
+		}
+
+		kids, err := ctx.DereferenceArray(o)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return findField(ctx, kids, name, fqName, depth+1)
+	}
+
+	return nil, nil, nil
+}
+
+func signatureFieldWidget(ctx *model.Context, d types.Dict, ir types.IndirectRef) (types.Dict, int, error) {
+	if _, found := d.Find("Rect"); found {
+		return d, objNr(ir), nil
+	}
+
+	kids, err := ctx.DereferenceArray(d["Kids"])
+	if err != nil || len(kids) == 0 {
+		return nil, 0, errors.New("pdfcpu: signature field without widget")
+	}
+
+	kidIndRef, ok := kids[0].(types.IndirectRef)
+	if !ok {
+		return nil, 0, errors.New("pdfcpu: corrupt signature field widget")
+	}
+
+	w, err := ctx.DereferenceDict(kidIndRef)
+	if err != nil || w == nil {
+		return nil, 0, errors.New("pdfcpu: corrupt signature field widget")
+	}
+
+	return w, objNr(kidIndRef), nil
+}
+
+func nextSignatureFieldName(ctx *model.Context, fields types.Array) (string, error) {
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("Signature%d", i)
+		d, _, err := findField(ctx, fields, "", name, 0)
+		if err != nil {
+			return "", err
+		}
+		if d == nil {
+			return name, nil
+		}
+	}
+}
+
+func createSignatureField(ctx *model.Context, form types.Dict, formObjNr int, fields types.Array, opts *Options) (types.Dict, int, error) {
+	if opts.PageNr < 1 || opts.PageNr > ctx.PageCount {
+		return nil, 0, errors.Errorf("pdfcpu: invalid page number: %d", opts.PageNr)
+	}
+
+	pageIndRef, err := ctx.PageDictIndRef(opts.PageNr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pageDict, err := ctx.DereferenceDict(*pageIndRef)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	name := opts.FieldName
+	if name == "" {
+		if name, err = nextSignatureFieldName(ctx, fields); err != nil {
+			return nil, 0, err
+		}
+	}
+	s, err := types.EscapeUTF16String(name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r := opts.Rect
+	if r == nil {
+		r = types.NewRectangle(0, 0, 0, 0)
+	}
+
+	// Merged signature field and widget annotation.
+	d := types.Dict(map[string]types.Object{
+		"FT":      types.Name("Sig"),
+		"T":       types.StringLiteral(*s),
+		"Type":    types.Name("Annot"),
+		"Subtype": types.Name("Widget"),
+		"F":       types.Integer(sigWidgetFlags),
+		"Rect":    r.Array(),
+		"P":       *pageIndRef,
+	})
+
+	ir, err := ctx.IndRefForNewObject(d)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx.Write.IncrementWithObjNr(objNr(*ir))
+
+	if err := appendToArrayEntry(ctx, pageDict, objNr(*pageIndRef), "Annots", *ir); err != nil {
+		return nil, 0, err
+	}
+
+	if err := appendToArrayEntry(ctx, form, formObjNr, "Fields", *ir); err != nil {
+		return nil, 0, err
+	}
+
+	return d, objNr(*ir), nil
+}
+
+func signatureField(ctx *model.Context, opts *Options) (types.Dict, int, types.Dict, int, error) {
+	form, formObjNr, err := acroForm(ctx)
+	if err != nil {
+		return nil, 0, nil, 0, err
+	}
+
+	form["SigFlags"] = types.Integer(3) // SignaturesExist | AppendOnly
+	ctx.Write.IncrementWithObjNr(formObjNr)
+
+	var fields types.Array
+	if o, found := form.Find("Fields"); found {
+		if fields, err = ctx.DereferenceArray(o); err != nil {
+			return nil, 0, nil, 0, err
+		}
+	}
+
+	if opts.FieldName != "" {
+		d, ir, err := findField(ctx, fields, "", opts.FieldName, 0)
+		if err != nil {
+			return nil, 0, nil, 0, err
+		}
+		if d != nil {
+			if ft := d.NameEntry("FT"); ft == nil || *ft != "Sig" {
+				return nil, 0, nil, 0, errors.Errorf("pdfcpu: %s is not a signature field", opts.FieldName)
+			}
+			if _, found := d.Find("V"); found {
+				return nil, 0, nil, 0, errors.Errorf("pdfcpu: signature field %s is already signed", opts.FieldName)
+			}
+			w, wObjNr, err := signatureFieldWidget(ctx, d, *ir)
+			if err != nil {
+				return nil, 0, nil, 0, err
+			}
+			return d, objNr(*ir), w, wObjNr, nil
+		}
+	}
+
+	d, dObjNr, err := createSignatureField(ctx, form, formObjNr, fields, opts)
+	if err != nil {
+		return nil, 0, nil, 0, err
+	}
+
+	return d, dObjNr, d, dObjNr, nil
+}
+
+func appearanceLines(opts *Options) []string {
+	ss := []string{
+		"Digitally signed by " + opts.Name,
+		"Date: " + opts.SigningTime.Format("2006.01.02 15:04:05 -07:00"),
+	}
+	if opts.Reason != "" {
+		ss = append(ss, "Reason: "+opts.Reason)
+	}
+	if opts.Location != "" {
+		ss = append(ss, "Location: "+opts.Location)
+	}
+	return ss
+}
+
+func appearanceContent(r *types.Rectangle, ss []string) ([]byte, error) {
+	const (
+		fontName = "Helvetica"
+		margin   = 2.
+	)
+
+	w, h := r.Width()-2*margin, r.Height()-2*margin
+
+	// Fit font size to both width and height of the widget.
+	fontSize := int(h / (1.2 * float64(len(ss))))
+	if fontSize > 10 {
+		fontSize = 10
+	}
+	for _, s := range ss {
+		for fontSize > 1 && font.TextWidth(s, fontName, fontSize) > w {
+			fontSize--
+		}
+	}
+	if fontSize < 1 {
+		fontSize = 1
+	}
+
+	lh := 1.2 * float64(fontSize)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "BT /F0 %d Tf 0 g %.2f %.2f Td %.2f TL\n", fontSize, margin, r.Height()-margin-float64(fontSize), lh)
+
+	for i, s := range ss {
+		s1, err := types.Escape(model.DecodeUTF8ToByte(s))
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("T* ")
+		}
+		fmt.Fprintf(&buf, "(%s) Tj\n", *s1)
+	}
+
+	buf.WriteString("ET\n")
+
+	return buf.Bytes(), nil
+}
+
+func createAppearance(ctx *model.Context, r *types.Rectangle, opts *Options) (*types.IndirectRef, error) {
+	bb, err := appearanceContent(r, appearanceLines(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	sd, err := ctx.NewStreamDictForBuf(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	sd.InsertName("Type", "XObject")
+	sd.InsertName("Subtype", "Form")
+	sd.InsertInt("FormType", 1)
+	sd.Insert("BBox", types.NewRectangle(0, 0, r.Width(), r.Height()).Array())
+	sd.Insert("Matrix", types.NewNumberArray(1, 0, 0, 1, 0, 0))
+	sd.Insert("Resources", types.Dict{
+		"Font": types.Dict{
+			"F0": types.Dict{
+				"Type":     types.Name("Font"),
+				"Subtype":  types.Name("Type1"),
+				"BaseFont": types.Name("Helvetica"),
+				"Encoding": types.Name("WinAnsiEncoding"),
+			},
+		},
+	})
+
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	return ctx.IndRefForNewObject(*sd)
+}
+
+func updateWidgetAppearance(ctx *model.Context, w types.Dict, wObjNr int, opts *Options) error {
+	a := w.ArrayEntry("Rect")
+	if a == nil {
+		return nil
+	}
+
+	r, err := types.RectForArray(a)
+	if err != nil || r.Width() <= 0 || r.Height() <= 0 {
+		// Invisible signature.
+		return nil
+	}
+
+	ir, err := createAppearance(ctx, r, opts)
+	if err != nil {
+		return err
+	}
+	ctx.Write.IncrementWithObjNr(objNr(*ir))
+
+	w["AP"] = types.Dict{"N": *ir}
+	ctx.Write.IncrementWithObjNr(wObjNr)
+
+	return nil
+}
+
+// fieldMDPReference returns the signature reference dict for the lock dict of a signature field (see 12.8.2.4).
+func fieldMDPReference(ctx *model.Context, d types.Dict) (types.Dict, error) {
+	o, found := d.Find("Lock")
+	if !found {
+		return nil, nil
+	}
+
+	lock, err := ctx.DereferenceDict(o)
+	if err != nil || lock == nil {
+		return nil, err
+	}
+
+	params := types.Dict{"Type": types.Name("TransformParams"), "V": types.Name("1.2")}
+	for _, k := range []string{"Action", "Fields"} {
+		if o, found := lock.Find(k); found {
+			params[k] = o
+		}
+	}
+
+	return types.Dict{
+		"Type":            types.Name("SigRef"),
+		"TransformMethod": types.Name("FieldMDP"),
+		"TransformParams": params,
+		"Data":            *ctx.Root,
+	}, nil
+}
+
+func contentsPlaceholderSize(opts *Options) int {
+	n := cmsOverhead
+	for _, c := range opts.Certificates {
+		n += len(c.Raw)
+	}
+	return n
+}
+
+func encodeTextString(s string) (types.StringLiteral, error) {
+	s1, err := types.EscapeUTF16String(s)
+	if err != nil {
+		return "", err
+	}
+	return types.StringLiteral(*s1), nil
+}
+
+func signatureDict(ctx *model.Context, d types.Dict, opts *Options) (types.Dict, error) {
+	sigDict := types.Dict(map[string]types.Object{
+		"Type":      types.Name("Sig"),
+		"Filter":    types.Name("Adobe.PPKLite"),
+		"SubFilter": types.Name("ETSI.CAdES.detached"),
+		"ByteRange": types.NewIntegerArray(0, byteRangePlaceholder, byteRangePlaceholder, byteRangePlaceholder),
+		"Contents":  types.NewHexLiteral(make([]byte, contentsPlaceholderSize(opts))),
+		"M":         types.StringLiteral(types.DateString(opts.SigningTime)),
+	})
+
+	for k, v := range map[string]string{
+		"Name":        opts.Name,
+		"Reason":      opts.Reason,
+		"Location":    opts.Location,
+		"ContactInfo": opts.ContactInfo,
+	} {
+		if v == "" {
+			continue
+		}
+		sl, err := encodeTextString(v)
+		if err != nil {
+			return nil, err
+		}
+		sigDict[k] = sl
+	}
+
+	ref, err := fieldMDPReference(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	if ref != nil {
+		sigDict["Reference"] = types.Array{ref}
+	}
+
+	return sigDict, nil
+}
+
+// Prepare adds a signature field and a signature dict with placeholders for the byte range and the signature to ctx.
+// All modified objects get marked for writing an increment which needs to be passed to Sign.
+func Prepare(ctx *model.Context, opts *Options) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	if ctx.Encrypt != nil {
+		return errors.New("pdfcpu: signing encrypted files is not supported")
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	d, dObjNr, w, wObjNr, err := signatureField(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := updateWidgetAppearance(ctx, w, wObjNr, opts); err != nil {
+		return err
+	}
+
+	sigDict, err := signatureDict(ctx, d, opts)
+	if err != nil {
+		return err
+	}
+
+	ir, err := ctx.IndRefForNewObject(sigDict)
+	if err != nil {
+		return err
+	}
+	ctx.Write.IncrementWithObjNr(objNr(*ir))
+
+	d["V"] = *ir
+	ctx.Write.IncrementWithObjNr(dObjNr)
+
+	return nil
+}
+
+// placeholder returns the position of the value following key within the signature dict of the increment starting at offset.
+func placeholder(bb []byte, offset int, key, value string) (int, error) {
+	i := bytes.LastIndex(bb[offset:], []byte("/"+key+value))
+	if i < 0 {
+		return 0, errors.Errorf("pdfcpu: missing signature %s placeholder", key)
+	}
+	return offset + i + len(key) + 1, nil
+}
+
+// Sign computes the signature for bb which consists of the signed file followed by the increment written for a context prepared by Prepare.
+// The byte range and the signature get patched into the increment starting at offset.
+func Sign(bb []byte, offset int, opts *Options) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	placeholderRange := types.NewIntegerArray(0, byteRangePlaceholder, byteRangePlaceholder, byteRangePlaceholder).PDFString()
+
+	i, err := placeholder(bb, offset, "ByteRange", placeholderRange)
+	if err != nil {
+		return err
+	}
+
+	j, err := placeholder(bb, offset, "Contents", "<")
+	if err != nil {
+		return err
+	}
+
+	k := bytes.IndexByte(bb[j:], '>')
+	if k < 0 {
+		return errors.New("pdfcpu: corrupt signature Contents placeholder")
+	}
+
+	// The byte range covers everything but the hex string of Contents including its delimiters.
+	start, end := j, j+k+1
+
+	br := fmt.Sprintf("[0 %d %d %d", start, end, len(bb)-end)
+	if len(br) > len(placeholderRange)-1 {
+		return errors.New("pdfcpu: signature byte range overflow")
+	}
+	copy(bb[i:], br+strings.Repeat(" ", len(placeholderRange)-1-len(br))+"]")
+
+	h := opts.Hash.New()
+	h.Write(bb[:start])
+	h.Write(bb[end:])
+
+	cms, err := createCMS(h.Sum(nil), opts.Hash, opts.Key, opts.Certificates)
+	if err != nil {
+		return err
+	}
+
+	s := hex.EncodeToString(cms)
+	if len(s) > k-1 {
+		return errors.Errorf("pdfcpu: signature size %d exceeds reserved space of %d bytes", len(cms), (k-1)/2)
+	}
+	copy(bb[start+1:], s)
+
+	return nil
+}
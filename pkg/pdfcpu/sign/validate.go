@@ -0,0 +1,736 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Coverage specifies the part of a document covered by a signature.
+type Coverage string
+
+const (
+	CoverageWholeDocument Coverage = "WholeDocument" // The signature covers the complete file.
+	CoveragePartial       Coverage = "Partial"       // The file has been updated after signing.
+)
+
+// Modification classifies the updates applied to a document after signing.
+type Modification string
+
+const (
+	ModificationNone       Modification = "None"            // Unmodified since signing.
+	ModificationSignatures Modification = "SignaturesAdded" // Only signatures, signature fields and their appearances were added.
+	ModificationOther      Modification = "Modified"        // Modified beyond adding signatures.
+)
+
+// Certificate represents a certificate of a signer's certificate chain.
+type Certificate struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	SelfSigned   bool      `json:"selfSigned"`
+	SHA256       string    `json:"sha256"` // Fingerprint.
+}
+
+// SignatureReport represents the validation result of a signature.
+type SignatureReport struct {
+	FieldName   string     `json:"fieldName"`
+	Filter      string     `json:"filter,omitempty"`
+	SubFilter   string     `json:"subFilter,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+	Location    string     `json:"location,omitempty"`
+	ContactInfo string     `json:"contactInfo,omitempty"`
+	SigningTime *time.Time `json:"signingTime,omitempty"`
+	ByteRange   []int64    `json:"byteRange,omitempty"`
+
+	Coverage        Coverage     `json:"coverage,omitempty"`
+	Modification    Modification `json:"modification,omitempty"`
+	ModifiedObjects []int        `json:"modifiedObjects,omitempty"` // Objects modified beyond adding signatures.
+
+	DigestAlgorithm string `json:"digestAlgorithm,omitempty"`
+	DigestValid     bool   `json:"digestValid"`    // The signed bytes match the digest embedded in the signature.
+	SignatureValid  bool   `json:"signatureValid"` // The signature verifies against the signer certificate.
+
+	// Signer certificate followed by the issuer certificates embedded in the signature.
+	Certificates []Certificate `json:"certificates,omitempty"`
+
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Valid returns true if the signed bytes are intact and the signature verifies against the signer certificate.
+// Trust in the signer certificate is not checked.
+func (sr SignatureReport) Valid() bool {
+	return sr.DigestValid && sr.SignatureValid
+}
+
+func (sr *SignatureReport) problem(err error) {
+	sr.Problems = append(sr.Problems, err.Error())
+}
+
+type signedField struct {
+	name    string
+	sigDict types.Dict
+}
+
+func collectSignatureFields(ctx *model.Context, fields types.Array, prefix, ft string, depth int, sff *[]signedField) error {
+	if depth > 32 {
+		return errors.New("pdfcpu: corrupt field hierarchy")
+	}
+
+	for _, o := range fields {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			continue
+		}
+
+		t, err := fieldName(ctx, d)
+		if err != nil {
+			return err
+		}
+		if t == "" {
+			// Widget annotation of a field.
+			continue
+		}
+
+		name := t
+		if prefix != "" {
+			name = prefix + "." + t
+		}
+
+		ft1 := ft
+		if n := d.NameEntry("FT"); n != nil {
+			ft1 = *n
+		}
+
+		if o, found := d.Find("Kids"); found {
+			kids, err := ctx.DereferenceArray(o)
+			if err != nil {
+				return err
+			}
+			if err := collectSignatureFields(ctx, kids, name, ft1, depth+1, sff); err != nil {
+				return err
+			}
+		}
+
+		if ft1 != "Sig" {
+			continue
+		}
+
+		o, found := d.Find("V")
+		if !found {
+			// Unsigned.
+			continue
+		}
+
+		sigDict, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+		if sigDict != nil {
+			*sff = append(*sff, signedField{name: name, sigDict: sigDict})
+		}
+	}
+
+	return nil
+}
+
+func signatureFields(ctx *model.Context) ([]signedField, error) {
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	o, found := rootDict.Find("AcroForm")
+	if !found {
+		return nil, nil
+	}
+
+	form, err := ctx.DereferenceDict(o)
+	if err != nil || form == nil {
+		return nil, err
+	}
+
+	o, found = form.Find("Fields")
+	if !found {
+		return nil, nil
+	}
+
+	fields, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return nil, err
+	}
+
+	var sff []signedField
+	if err := collectSignatureFields(ctx, fields, "", "", 0, &sff); err != nil {
+		return nil, err
+	}
+
+	return sff, nil
+}
+
+func textEntry(ctx *model.Context, d types.Dict, key string) string {
+	o, found := d.Find(key)
+	if !found {
+		return ""
+	}
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return ""
+	}
+	s, err := types.StringOrHexLiteral(o)
+	if err != nil || s == nil {
+		return ""
+	}
+	return *s
+}
+
+func byteRange(ctx *model.Context, d types.Dict, size int64) ([]int64, error) {
+	o, found := d.Find("ByteRange")
+	if !found {
+		return nil, errors.New("missing ByteRange")
+	}
+
+	a, err := ctx.DereferenceArray(o)
+	if err != nil || len(a) != 4 {
+		return nil, errors.New("corrupt ByteRange")
+	}
+
+	br := make([]int64, 4)
+	for i, o := range a {
+		n, ok := o.(types.Integer)
+		if !ok || n < 0 {
+			return nil, errors.New("corrupt ByteRange")
+		}
+		br[i] = int64(n)
+	}
+
+	// Check each element on its own to avoid overflows.
+	if br[0] != 0 || br[1] <= 0 || br[1] >= br[2] || br[2] > size || br[3] > size-br[2] {
+		return nil, errors.Errorf("invalid ByteRange: %v", br)
+	}
+
+	return br, nil
+}
+
+func contents(ctx *model.Context, d types.Dict) ([]byte, error) {
+	o, found := d.Find("Contents")
+	if !found {
+		return nil, errors.New("missing Contents")
+	}
+
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o := o.(type) {
+	case types.HexLiteral:
+		return o.Bytes()
+	case types.StringLiteral:
+		return types.Unescape(o.Value(), false)
+	}
+
+	return nil, errors.New("corrupt Contents")
+}
+
+func certificates(chain []*x509.Certificate) []Certificate {
+	cc := make([]Certificate, len(chain))
+	for i, c := range chain {
+		fp := sha256.Sum256(c.Raw)
+		cc[i] = Certificate{
+			Subject:      c.Subject.String(),
+			Issuer:       c.Issuer.String(),
+			SerialNumber: strings.ToUpper(c.SerialNumber.Text(16)),
+			NotBefore:    c.NotBefore,
+			NotAfter:     c.NotAfter,
+			SelfSigned:   bytes.Equal(c.RawIssuer, c.RawSubject),
+			SHA256:       hex.EncodeToString(fp[:]),
+		}
+	}
+	return cc
+}
+
+// writeOffset returns the offset of the last write of an object.
+func writeOffset(ctx *model.Context, entry *model.XRefTableEntry) (int64, bool) {
+	if entry.Compressed {
+		if entry.ObjectStream == nil {
+			return 0, false
+		}
+		if entry = ctx.Table[*entry.ObjectStream]; entry == nil {
+			return 0, false
+		}
+	}
+	if entry.Offset == nil {
+		return 0, false
+	}
+	return *entry.Offset, true
+}
+
+func equalExcept(d1, d2 types.Dict, keys ...string) bool {
+	d1, d2 = d1.Clone().(types.Dict), d2.Clone().(types.Dict)
+	for _, k := range keys {
+		d1.Delete(k)
+		d2.Delete(k)
+	}
+	return d1.PDFString() == d2.PDFString()
+}
+
+// appended returns true if o is either the same indirect reference as o1 or an array starting with the elements of array o1.
+func appended(o, o1 types.Object) bool {
+	if o1 == nil {
+		return true
+	}
+	if ir1, ok := o1.(types.IndirectRef); ok {
+		ir, ok := o.(types.IndirectRef)
+		return ok && ir.PDFString() == ir1.PDFString()
+	}
+	a, ok := o.(types.Array)
+	if !ok {
+		return false
+	}
+	a1, ok := o1.(types.Array)
+	if !ok || len(a1) > len(a) {
+		return false
+	}
+	for i := range a1 {
+		if a[i].PDFString() != a1[i].PDFString() {
+			return false
+		}
+	}
+	return true
+}
+
+// formUpdate returns true if form dict d differs from d1 by added fields and signature flags only.
+func formUpdate(d, d1 types.Dict) bool {
+	return equalExcept(d, d1, "Fields", "SigFlags") && appended(d["Fields"], d1["Fields"])
+}
+
+// rootUpdate returns true if root dict d differs from d1 by an added form, form fields or DSS only.
+func rootUpdate(d, d1 types.Dict) bool {
+	if !equalExcept(d, d1, "AcroForm", "DSS") {
+		return false
+	}
+	o1, found := d1.Find("AcroForm")
+	if !found {
+		return true
+	}
+	if f1, ok := o1.(types.Dict); ok {
+		f, ok := d["AcroForm"].(types.Dict)
+		return ok && formUpdate(f, f1)
+	}
+	return d["AcroForm"].PDFString() == o1.PDFString()
+}
+
+// signatureFieldDict returns the terminal signature field dict for a signature field or widget.
+func signatureFieldDict(ctx *model.Context, d types.Dict) types.Dict {
+	for d1, i := d, 0; d1 != nil && i < 32; i++ {
+		if ft := d1.NameEntry("FT"); ft != nil {
+			if *ft == "Sig" {
+				return d1
+			}
+			return nil
+		}
+		o, found := d1.Find("Parent")
+		if !found {
+			break
+		}
+		d1, _ = ctx.DereferenceDict(o)
+	}
+	return nil
+}
+
+func isSignatureField(ctx *model.Context, d types.Dict) bool {
+	if ft := d.NameEntry("FT"); ft != nil && *ft == "Sig" {
+		return true
+	}
+	st := d.Subtype()
+	return st != nil && *st == "Widget" && signatureFieldDict(ctx, d) != nil
+}
+
+// appendableArrays returns the object numbers of the arrays for form fields and page annotations.
+func appendableArrays(ctx *model.Context) types.IntSet {
+	objNrs := types.IntSet{}
+
+	if rootDict, err := ctx.Catalog(); err == nil {
+		if form, err := ctx.DereferenceDict(rootDict["AcroForm"]); err == nil && form != nil {
+			if ir, ok := form["Fields"].(types.IndirectRef); ok {
+				objNrs[objNr(ir)] = true
+			}
+		}
+	}
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		d, _, _, err := ctx.PageDict(i, false)
+		if err != nil || d == nil {
+			continue
+		}
+		if ir, ok := d["Annots"].(types.IndirectRef); ok {
+			objNrs[objNr(ir)] = true
+		}
+	}
+
+	return objNrs
+}
+
+// newSignatureObject returns true if o got added to ctx for a signature.
+func newSignatureObject(ctx *model.Context, o types.Object, nr, fObjNr int) bool {
+	switch o := o.(type) {
+
+	case types.Dict:
+		if t := o.Type(); t != nil && (*t == "Sig" || *t == "DocTimeStamp") {
+			return true
+		}
+		// A new form, signature field or widget.
+		return nr == fObjNr || isSignatureField(ctx, o)
+
+	case types.Array:
+		return true
+
+	case types.XRefStreamDict, types.ObjectStreamDict:
+		return true
+
+	case types.StreamDict:
+		// Appearance streams.
+		st := o.Subtype()
+		return st != nil && *st == "Form"
+
+	}
+
+	return false
+}
+
+// signatureUpdate returns true if obj# nr of ctx has been written after signing for adding a signature.
+// Objects already present in rev need to be unchanged except for the entries needed to add a signature field.
+func signatureUpdate(ctx, rev *model.Context, nr, fObjNr int, arrs types.IntSet) bool {
+	ir := *types.NewIndirectRef(nr, 0)
+
+	o, err := ctx.Dereference(ir)
+	if err != nil {
+		return false
+	}
+
+	switch o.(type) {
+	case types.XRefStreamDict, types.ObjectStreamDict:
+		return true
+	}
+
+	if entry, found := rev.Find(nr); !found || entry.Free {
+		return newSignatureObject(ctx, o, nr, fObjNr)
+	}
+
+	o1, err := rev.Dereference(ir)
+	if err != nil || o1 == nil {
+		return false
+	}
+
+	switch o := o.(type) {
+
+	case types.Dict:
+		d1, ok := o1.(types.Dict)
+		if !ok {
+			return false
+		}
+		switch {
+		case nr == fObjNr:
+			return formUpdate(o, d1)
+		case nr == objNr(*ctx.Root):
+			return rootUpdate(o, d1)
+		}
+		if t := o.Type(); t != nil && *t == "Page" {
+			return equalExcept(o, d1, "Annots") && appended(o["Annots"], d1["Annots"])
+		}
+		if f1 := signatureFieldDict(rev, d1); f1 != nil {
+			// Signing an unsigned signature field.
+			if _, found := f1.Find("V"); !found {
+				return equalExcept(o, d1, "V", "AP")
+			}
+		}
+
+	case types.Array:
+		// Appending to Fields or Annots.
+		if arrs[nr] {
+			return appended(o, o1)
+		}
+
+	case types.StreamDict:
+		sd1, ok := o1.(types.StreamDict)
+		if !ok || !bytes.Equal(o.Raw, sd1.Raw) {
+			return false
+		}
+
+	}
+
+	return o.PDFString() == o1.PDFString()
+}
+
+func formObjNr(ctx *model.Context) int {
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return 0
+	}
+	if ir, ok := rootDict["AcroForm"].(types.IndirectRef); ok {
+		return objNr(ir)
+	}
+	return 0
+}
+
+// modifiedObjects returns the objects written after the revision ending at end which do not serve adding a signature.
+func modifiedObjects(ctx, rev *model.Context, end int64) []int {
+	fObjNr, arrs := formObjNr(ctx), appendableArrays(ctx)
+
+	var objNrs []int
+
+	for nr, entry := range ctx.Table {
+		if nr == 0 || entry == nil || entry.Free {
+			continue
+		}
+		off, ok := writeOffset(ctx, entry)
+		if !ok || off < end {
+			continue
+		}
+		if !signatureUpdate(ctx, rev, nr, fObjNr, arrs) {
+			objNrs = append(objNrs, nr)
+		}
+	}
+
+	sort.Ints(objNrs)
+
+	return objNrs
+}
+
+func signedBytes(bb []byte, br []int64) []byte {
+	signed := make([]byte, 0, br[1]+br[3])
+	signed = append(signed, bb[br[0]:br[0]+br[1]]...)
+	return append(signed, bb[br[2]:br[2]+br[3]]...)
+}
+
+func (sr *SignatureReport) verifyCMS(cms []byte, signed []byte) error {
+	sd, err := parseCMS(cms)
+	if err != nil {
+		return errors.Wrap(err, "corrupt signature")
+	}
+
+	si := sd.signerInfos[0]
+
+	h, err := hashForOID(si.digestAlg)
+	if err != nil {
+		return err
+	}
+	sr.DigestAlgorithm = h.String()
+
+	cert := signerCertificate(si.sid, sd.certs)
+	if cert == nil {
+		return errors.New("missing signer certificate")
+	}
+	sr.Certificates = certificates(certificateChain(cert, sd.certs))
+
+	content := signed
+	digestValid := true
+
+	if sd.eContent != nil {
+		// adbe.pkcs7.sha1 signs the SHA-1 digest of the signed bytes.
+		d := crypto.SHA1.New()
+		d.Write(signed)
+		digestValid = bytes.Equal(d.Sum(nil), sd.eContent)
+		content = sd.eContent
+	}
+
+	msg := content
+
+	if si.signedAttrs != nil {
+		sav, err := parseSignedAttributes(si.signedAttrs)
+		if err != nil {
+			return errors.Wrap(err, "corrupt signed attributes")
+		}
+		if sav.signingTime != nil && sr.SigningTime == nil {
+			sr.SigningTime = sav.signingTime
+		}
+		d := h.New()
+		d.Write(content)
+		sr.DigestValid = digestValid && bytes.Equal(d.Sum(nil), sav.messageDigest)
+
+		// The signature covers the signed attributes using the universal SET tag.
+		msg = append([]byte{0x31}, si.signedAttrs[1:]...)
+	}
+
+	alg, err := x509SignatureAlgorithm(si.sigAlg, h)
+	if err != nil {
+		return err
+	}
+
+	if err := cert.CheckSignature(alg, msg, si.signature); err != nil {
+		return errors.Wrap(err, "signature verification failed")
+	}
+	sr.SignatureValid = true
+
+	if si.signedAttrs == nil {
+		// Without signed attributes the signature directly covers the content digest.
+		sr.DigestValid = digestValid
+	}
+
+	return nil
+}
+
+type revisions struct {
+	ctx  *model.Context
+	bb   []byte
+	revs map[int64]*model.Context
+}
+
+// revision returns the context of the document revision ending at end.
+func (r *revisions) revision(end int64) (*model.Context, error) {
+	if rev, ok := r.revs[end]; ok {
+		return rev, nil
+	}
+
+	conf := *r.ctx.Configuration
+	rev, err := pdfcpu.Read(bytes.NewReader(r.bb[:end]), &conf)
+	if err != nil {
+		return nil, err
+	}
+
+	r.revs[end] = rev
+
+	return rev, nil
+}
+
+func (sr *SignatureReport) checkModification(r *revisions, end int64) {
+	// Ignore trailing whitespace.
+	size := int64(len(bytes.TrimRight(r.bb, "\x00\t\n\f\r ")))
+
+	if end >= size {
+		sr.Coverage = CoverageWholeDocument
+		sr.Modification = ModificationNone
+		return
+	}
+
+	sr.Coverage = CoveragePartial
+
+	rev, err := r.revision(end)
+	if err != nil {
+		sr.Modification = ModificationOther
+		sr.problem(errors.Wrap(err, "unable to read signed revision"))
+		return
+	}
+
+	if sr.ModifiedObjects = modifiedObjects(r.ctx, rev, end); len(sr.ModifiedObjects) > 0 {
+		sr.Modification = ModificationOther
+		return
+	}
+
+	sr.Modification = ModificationSignatures
+}
+
+func validateSignature(r *revisions, sf signedField) SignatureReport {
+	ctx, d := r.ctx, sf.sigDict
+
+	sr := SignatureReport{
+		FieldName:   sf.name,
+		Name:        textEntry(ctx, d, "Name"),
+		Reason:      textEntry(ctx, d, "Reason"),
+		Location:    textEntry(ctx, d, "Location"),
+		ContactInfo: textEntry(ctx, d, "ContactInfo"),
+	}
+
+	if n := d.NameEntry("Filter"); n != nil {
+		sr.Filter = *n
+	}
+	if n := d.NameEntry("SubFilter"); n != nil {
+		sr.SubFilter = *n
+	}
+
+	if s := textEntry(ctx, d, "M"); s != "" {
+		if t, ok := types.DateTime(s, true); ok {
+			sr.SigningTime = &t
+		}
+	}
+
+	br, err := byteRange(ctx, d, int64(len(r.bb)))
+	if err != nil {
+		sr.problem(err)
+		return sr
+	}
+	sr.ByteRange = br
+
+	if r.bb[br[1]] != '<' || r.bb[br[2]-1] != '>' {
+		sr.problem(errors.New("ByteRange does not exclude exactly the signature value"))
+	}
+
+	sr.checkModification(r, br[2]+br[3])
+
+	switch sr.SubFilter {
+	case "adbe.x509.rsa_sha1", "ETSI.RFC3161":
+		sr.problem(errors.Errorf("unsupported SubFilter: %s", sr.SubFilter))
+		return sr
+	}
+
+	cms, err := contents(ctx, d)
+	if err != nil {
+		sr.problem(err)
+		return sr
+	}
+
+	if err := sr.verifyCMS(cms, signedBytes(r.bb, br)); err != nil {
+		sr.problem(err)
+	}
+
+	return sr
+}
+
+// ValidateSignatures returns a report for each signature of ctx read from bb.
+func ValidateSignatures(ctx *model.Context, bb []byte) ([]SignatureReport, error) {
+	sff, err := signatureFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &revisions{ctx: ctx, bb: bb, revs: map[int64]*model.Context{}}
+
+	srs := make([]SignatureReport, len(sff))
+	for i, sf := range sff {
+		srs[i] = validateSignature(r, sf)
+	}
+
+	// Sort by signing order.
+	sort.SliceStable(srs, func(i, j int) bool {
+		bi, bj := srs[i].ByteRange, srs[j].ByteRange
+		if len(bi) < 4 || len(bj) < 4 {
+			return len(bi) > len(bj)
+		}
+		return bi[2]+bi[3] < bj[2]+bj[3]
+	})
+
+	return srs, nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sign
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+func TestByteRange(t *testing.T) {
+	const size = 100
+
+	ctx := &model.Context{XRefTable: &model.XRefTable{Table: map[int]*model.XRefTableEntry{}}}
+
+	for _, tt := range []struct {
+		br  []int
+		err bool
+	}{
+		{br: []int{0, 10, 20, 80}},
+		{br: []int{0, 10, 20, 0}},
+		{br: []int{0, 10, 20, 81}, err: true},
+		{br: []int{1, 10, 20, 3}, err: true},
+		{br: []int{0, 0, 20, 3}, err: true},
+		{br: []int{0, 20, 20, 3}, err: true},
+		{br: []int{0, 10, 101, 0}, err: true},
+		{br: []int{0, -5, 10, 3}, err: true},
+		{br: []int{0, 10, 20, -1}, err: true},
+		{br: []int{0, 10, -20, 3}, err: true},
+		{br: []int{0, 10, 1 << 62, 1 << 62}, err: true},
+		{br: []int{0, 10, 20, math.MaxInt64}, err: true},
+	} {
+		d := types.Dict{"ByteRange": types.NewIntegerArray(tt.br...)}
+		_, err := byteRange(ctx, d, size)
+		if tt.err != (err != nil) {
+			t.Errorf("byteRange(%v): want error %t, got %v", tt.br, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CMS SignedData parsing and verification.
+// Optional context specific elements get parsed by hand since encoding/asn1 matches an optional RawValue against anything.
+
+var (
+	oidSHA224            = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 4}
+	oidAttrSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA1WithRSA       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidSHA256WithRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidRSASSAPSS         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidECPublicKey       = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA1     = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}
+	oidEd25519           = asn1.ObjectIdentifier{1, 3, 101, 112}
+	errUnsupportedSigAlg = errors.New("unsupported signature algorithm")
+)
+
+type parsedSignerInfo struct {
+	sid         asn1.RawValue
+	digestAlg   asn1.ObjectIdentifier
+	signedAttrs []byte // DER encoding including the [0] IMPLICIT tag, nil if missing.
+	sigAlg      asn1.ObjectIdentifier
+	signature   []byte
+}
+
+type parsedSignedData struct {
+	eContent    []byte // Encapsulated content, nil for detached signatures.
+	certs       []*x509.Certificate
+	signerInfos []parsedSignerInfo
+}
+
+// elements returns the DER encoded elements of the constructed value bb.
+func elements(bb []byte) ([]asn1.RawValue, error) {
+	var rv asn1.RawValue
+	if _, err := asn1.Unmarshal(bb, &rv); err != nil {
+		return nil, err
+	}
+	if !rv.IsCompound {
+		return nil, errors.New("constructed value expected")
+	}
+
+	var rr []asn1.RawValue
+	for rest := rv.Bytes; len(rest) > 0; {
+		var el asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &el); err != nil {
+			return nil, err
+		}
+		rr = append(rr, el)
+	}
+
+	return rr, nil
+}
+
+func isUniversal(rv asn1.RawValue, tag int) bool {
+	return rv.Class == asn1.ClassUniversal && rv.Tag == tag
+}
+
+func isContextSpecific(rv asn1.RawValue, tag int) bool {
+	return rv.Class == asn1.ClassContextSpecific && rv.Tag == tag
+}
+
+func algorithmOID(rv asn1.RawValue) (asn1.ObjectIdentifier, error) {
+	var ai algorithmIdentifier
+	if _, err := asn1.Unmarshal(rv.FullBytes, &ai); err != nil {
+		return nil, err
+	}
+	return ai.Algorithm, nil
+}
+
+func parseSignerInfo(rv asn1.RawValue) (*parsedSignerInfo, error) {
+	ee, err := elements(rv.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// version, sid, digestAlgorithm, [0] signedAttrs, signatureAlgorithm, signature, [1] unsignedAttrs
+	if len(ee) < 5 {
+		return nil, errors.New("corrupt SignerInfo")
+	}
+
+	si := &parsedSignerInfo{sid: ee[1]}
+
+	if si.digestAlg, err = algorithmOID(ee[2]); err != nil {
+		return nil, err
+	}
+
+	i := 3
+	if isContextSpecific(ee[i], 0) {
+		si.signedAttrs = ee[i].FullBytes
+		i++
+	}
+
+	if len(ee) < i+2 {
+		return nil, errors.New("corrupt SignerInfo")
+	}
+
+	if si.sigAlg, err = algorithmOID(ee[i]); err != nil {
+		return nil, err
+	}
+
+	if !isUniversal(ee[i+1], asn1.TagOctetString) {
+		return nil, errors.New("corrupt SignerInfo signature")
+	}
+	si.signature = ee[i+1].Bytes
+
+	return si, nil
+}
+
+func parseEncapContentInfo(rv asn1.RawValue) ([]byte, error) {
+	ee, err := elements(rv.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(ee) < 2 {
+		return nil, nil
+	}
+
+	var content []byte
+	if _, err := asn1.Unmarshal(ee[1].Bytes, &content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// parseCMS parses a DER encoded CMS SignedData ignoring trailing padding.
+func parseCMS(der []byte) (*parsedSignedData, error) {
+	var ci signedDataContentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, err
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, errors.Errorf("unsupported content type: %s", ci.ContentType)
+	}
+
+	ee, err := elements(ci.Content.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// version, digestAlgorithms, encapContentInfo, [0] certificates, [1] crls, signerInfos
+	if len(ee) < 4 {
+		return nil, errors.New("corrupt SignedData")
+	}
+
+	sd := &parsedSignedData{}
+
+	if sd.eContent, err = parseEncapContentInfo(ee[2]); err != nil {
+		return nil, err
+	}
+
+	for _, rv := range ee[3:] {
+		switch {
+
+		case isContextSpecific(rv, 0):
+			certs, err := elements(rv.FullBytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range certs {
+				if !isUniversal(c, asn1.TagSequence) {
+					// Skip other certificate formats.
+					continue
+				}
+				cert, err := x509.ParseCertificate(c.FullBytes)
+				if err != nil {
+					return nil, err
+				}
+				sd.certs = append(sd.certs, cert)
+			}
+
+		case isUniversal(rv, asn1.TagSet):
+			sis, err := elements(rv.FullBytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, rv1 := range sis {
+				si, err := parseSignerInfo(rv1)
+				if err != nil {
+					return nil, err
+				}
+				sd.signerInfos = append(sd.signerInfos, *si)
+			}
+		}
+	}
+
+	if len(sd.signerInfos) == 0 {
+		return nil, errors.New("missing SignerInfo")
+	}
+
+	return sd, nil
+}
+
+// signerCertificate returns the certificate identified by sid.
+func signerCertificate(sid asn1.RawValue, certs []*x509.Certificate) *x509.Certificate {
+	if isContextSpecific(sid, 0) {
+		// SubjectKeyIdentifier
+		for _, c := range certs {
+			if bytes.Equal(c.SubjectKeyId, sid.Bytes) {
+				return c
+			}
+		}
+		return nil
+	}
+
+	var ias struct {
+		Issuer       asn1.RawValue
+		SerialNumber *big.Int
+	}
+	if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+		return nil
+	}
+
+	for _, c := range certs {
+		if bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) && c.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// certificateChain returns the chain of cert as far as it can be built from certs.
+func certificateChain(cert *x509.Certificate, certs []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{cert}
+
+	for c := cert; len(chain) <= len(certs); {
+		if bytes.Equal(c.RawIssuer, c.RawSubject) {
+			break
+		}
+		var issuer *x509.Certificate
+		for _, c1 := range certs {
+			if bytes.Equal(c1.RawSubject, c.RawIssuer) && c.CheckSignatureFrom(c1) == nil {
+				issuer = c1
+				break
+			}
+		}
+		if issuer == nil {
+			break
+		}
+		chain = append(chain, issuer)
+		c = issuer
+	}
+
+	return chain
+}
+
+func hashForOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA1):
+		return crypto.SHA1, nil
+	case oid.Equal(oidSHA224):
+		return crypto.SHA224, nil
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, errors.Errorf("unsupported digest algorithm: %s", oid)
+}
+
+// x509SignatureAlgorithm maps the signature algorithm of a SignerInfo to x509.SignatureAlgorithm.
+// Some signers use the key algorithm as signature algorithm relying on the digest algorithm.
+func x509SignatureAlgorithm(sigAlg asn1.ObjectIdentifier, h crypto.Hash) (x509.SignatureAlgorithm, error) {
+	switch {
+	case sigAlg.Equal(oidSHA1WithRSA):
+		return x509.SHA1WithRSA, nil
+	case sigAlg.Equal(oidSHA256WithRSA):
+		return x509.SHA256WithRSA, nil
+	case sigAlg.Equal(oidSHA384WithRSA):
+		return x509.SHA384WithRSA, nil
+	case sigAlg.Equal(oidSHA512WithRSA):
+		return x509.SHA512WithRSA, nil
+	case sigAlg.Equal(oidECDSAWithSHA1):
+		return x509.ECDSAWithSHA1, nil
+	case sigAlg.Equal(oidECDSAWithSHA256):
+		return x509.ECDSAWithSHA256, nil
+	case sigAlg.Equal(oidECDSAWithSHA384):
+		return x509.ECDSAWithSHA384, nil
+	case sigAlg.Equal(oidECDSAWithSHA512):
+		return x509.ECDSAWithSHA512, nil
+	case sigAlg.Equal(oidEd25519):
+		return x509.PureEd25519, nil
+	}
+
+	var algs map[crypto.Hash]x509.SignatureAlgorithm
+
+	switch {
+	case sigAlg.Equal(oidRSAEncryption):
+		algs = map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA1: x509.SHA1WithRSA, crypto.SHA256: x509.SHA256WithRSA, crypto.SHA384: x509.SHA384WithRSA, crypto.SHA512: x509.SHA512WithRSA,
+		}
+	case sigAlg.Equal(oidRSASSAPSS):
+		algs = map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA256: x509.SHA256WithRSAPSS, crypto.SHA384: x509.SHA384WithRSAPSS, crypto.SHA512: x509.SHA512WithRSAPSS,
+		}
+	case sigAlg.Equal(oidECPublicKey):
+		algs = map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA1: x509.ECDSAWithSHA1, crypto.SHA256: x509.ECDSAWithSHA256, crypto.SHA384: x509.ECDSAWithSHA384, crypto.SHA512: x509.ECDSAWithSHA512,
+		}
+	}
+
+	if alg, ok := algs[h]; ok {
+		return alg, nil
+	}
+
+	return x509.UnknownSignatureAlgorithm, errors.Wrapf(errUnsupportedSigAlg, "%s with %s", sigAlg, h)
+}
+
+type signedAttributeValues struct {
+	messageDigest []byte
+	signingTime   *time.Time
+}
+
+func parseSignedAttributes(bb []byte) (*signedAttributeValues, error) {
+	ee, err := elements(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	sav := &signedAttributeValues{}
+
+	for _, rv := range ee {
+		var attr attribute
+		if _, err := asn1.Unmarshal(rv.FullBytes, &attr); err != nil {
+			return nil, err
+		}
+		vv, err := elements(attr.Values.FullBytes)
+		if err != nil || len(vv) == 0 {
+			return nil, errors.Errorf("corrupt attribute: %s", attr.Type)
+		}
+
+		switch {
+
+		case attr.Type.Equal(oidAttrMessageDigest):
+			if _, err := asn1.Unmarshal(vv[0].FullBytes, &sav.messageDigest); err != nil {
+				return nil, err
+			}
+
+		case attr.Type.Equal(oidAttrSigningTime):
+			var t time.Time
+			if _, err := asn1.Unmarshal(vv[0].FullBytes, &t); err == nil {
+				sav.signingTime = &t
+			}
+		}
+	}
+
+	if sav.messageDigest == nil {
+		return nil, errors.New("missing messageDigest attribute")
+	}
+
+	return sav, nil
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"math"
+
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// pathPrecision is the number of decimal places kept for path coordinates.
+const pathPrecision = 3
+
+var (
+	pathConstructionOps = map[string]bool{"m": true, "l": true, "c": true, "v": true, "y": true, "h": true, "re": true}
+	pathPaintingOps     = map[string]bool{"S": true, "s": true, "f": true, "F": true, "f*": true, "B": true, "B*": true, "b": true, "b*": true, "n": true}
+)
+
+func roundCoordinate(o types.Object) types.Object {
+	f, ok := o.(types.Float)
+	if !ok {
+		return o
+	}
+	p := math.Pow10(pathPrecision)
+	v := math.Round(f.Value()*p) / p
+	if v == math.Trunc(v) && math.Abs(v) < math.MaxInt32 {
+		return types.Integer(int(v))
+	}
+	return types.Float(v)
+}
+
+func operandPoint(op ContentOperation) (meshPoint, bool) {
+	if len(op.Operands) < 2 {
+		return meshPoint{}, false
+	}
+	x, ok1 := numberValue(op.Operands[len(op.Operands)-2])
+	y, ok2 := numberValue(op.Operands[len(op.Operands)-1])
+	return meshPoint{x, y}, ok1 && ok2
+}
+
+func numberValue(o types.Object) (float64, bool) {
+	switch o := o.(type) {
+	case types.Integer:
+		return float64(o.Value()), true
+	case types.Float:
+		return o.Value(), true
+	}
+	return 0, false
+}
+
+// collinear returns true if p1 lies on the straight line from p0 to p2 without reversing direction.
+func collinear(p0, p1, p2 meshPoint) bool {
+	dx1, dy1 := p1.x-p0.x, p1.y-p0.y
+	dx2, dy2 := p2.x-p1.x, p2.y-p1.y
+	if dx1*dx2+dy1*dy2 < 0 {
+		return false
+	}
+	l := math.Hypot(p2.x-p0.x, p2.y-p0.y)
+	if l == 0 {
+		return true
+	}
+	// Distance of p1 from the line p0 p2.
+	return math.Abs(dx1*dy2-dy1*dx2)/l <= .5/math.Pow10(pathPrecision)
+}
+
+// simplifyPath joins collinear line segments of a path object.
+func simplifyPath(ops []ContentOperation) []ContentOperation {
+	var (
+		out []ContentOperation
+		cur meshPoint // current point
+		ok  bool      // true if cur is known
+	)
+
+	for i, op := range ops {
+		if op.Operator == "l" && ok && i+1 < len(ops) && ops[i+1].Operator == "l" {
+			p1, ok1 := operandPoint(op)
+			p2, ok2 := operandPoint(ops[i+1])
+			if ok1 && ok2 && collinear(cur, p1, p2) {
+				// Drop p1, cur remains the start point of the joined segment.
+				continue
+			}
+		}
+
+		switch op.Operator {
+		case "m", "l", "c", "v", "y":
+			cur, ok = operandPoint(op)
+		case "re":
+			cur, ok = meshPoint{}, false
+			if len(op.Operands) == 4 {
+				cur.x, ok = numberValue(op.Operands[0])
+				var ok1 bool
+				cur.y, ok1 = numberValue(op.Operands[1])
+				ok = ok && ok1
+			}
+		case "h":
+			ok = false
+		}
+
+		out = append(out, op)
+	}
+
+	return out
+}
+
+func samePath(ops1, ops2 []ContentOperation) bool {
+	if len(ops1) != len(ops2) {
+		return false
+	}
+	for i := range ops1 {
+		if ops1[i].String() != ops2[i].String() {
+			return false
+		}
+	}
+	return true
+}
+
+// SimplifyPathOperations reduces the precision of path coordinates, joins collinear line segments
+// and removes immediately repeated path objects.
+// Repeated paths are kept if the content changes the graphics state via gs,
+// because painting a translucent path twice is not a no-op.
+func SimplifyPathOperations(ops []ContentOperation) []ContentOperation {
+	dedup := true
+	for _, op := range ops {
+		if op.Operator == "gs" {
+			dedup = false
+			break
+		}
+	}
+
+	var out, path, prevPath []ContentOperation
+
+	for _, op := range ops {
+
+		if pathConstructionOps[op.Operator] || (len(path) > 0 && (op.Operator == "W" || op.Operator == "W*")) {
+			oo := make([]types.Object, len(op.Operands))
+			for i, o := range op.Operands {
+				oo[i] = roundCoordinate(o)
+			}
+			op.Operands = oo
+			path = append(path, op)
+			continue
+		}
+
+		if pathPaintingOps[op.Operator] && len(path) > 0 {
+			path = append(simplifyPath(path), op)
+			if !dedup || !samePath(path, prevPath) {
+				out = append(out, path...)
+			}
+			prevPath, path = path, nil
+			continue
+		}
+
+		out = append(out, path...)
+		out = append(out, op)
+		prevPath, path = nil, nil
+	}
+
+	return append(out, path...)
+}
+
+func simplifyContentStream(sd *types.StreamDict) (bool, error) {
+	if err := sd.Decode(); err != nil {
+		return false, err
+	}
+
+	ops, err := ParseContentStream(sd.Content)
+	if err != nil {
+		// Leave unparsable content alone.
+		return false, nil
+	}
+
+	bb := ContentStreamBytes(SimplifyPathOperations(ops))
+	if len(bb) >= len(sd.Content) {
+		return false, nil
+	}
+
+	return true, setContentStream(sd, bb)
+}
+
+func contentStreamObjNrs(ctx *model.Context) (types.IntSet, error) {
+	objNrs := types.IntSet{}
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		d, _, _, err := ctx.PageDict(i, false)
+		if err != nil {
+			return nil, err
+		}
+		switch o := d["Contents"].(type) {
+		case types.IndirectRef:
+			objNrs[o.ObjectNumber.Value()] = true
+		case types.Array:
+			for _, o1 := range o {
+				if ir, ok := o1.(types.IndirectRef); ok {
+					objNrs[ir.ObjectNumber.Value()] = true
+				}
+			}
+		}
+	}
+
+	for objNr, entry := range ctx.Table {
+		if entry.Free {
+			continue
+		}
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok {
+			continue
+		}
+		form := sd.Subtype() != nil && *sd.Subtype() == "Form"
+		tiling := sd.IntEntry("PatternType") != nil && *sd.IntEntry("PatternType") == 1
+		if form || tiling {
+			objNrs[objNr] = true
+		}
+	}
+
+	return objNrs, nil
+}
+
+// SimplifyPaths simplifies vector paths of all content streams, form XObjects and tiling patterns.
+// See SimplifyPathOperations.
+// Returns the number of content streams modified.
+func SimplifyPaths(ctx *model.Context) (int, error) {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return 0, err
+	}
+
+	objNrs, err := contentStreamObjNrs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c := 0
+
+	for objNr := range objNrs {
+		entry, ok := ctx.FindTableEntryLight(objNr)
+		if !ok {
+			continue
+		}
+		sd, ok := entry.Object.(types.StreamDict)
+		if !ok {
+			continue
+		}
+		modified, err := simplifyContentStream(&sd)
+		if err != nil {
+			if log.DebugEnabled() {
+				log.Debug.Printf("SimplifyPaths: skipping obj#%d: %v\n", objNr, err)
+			}
+			continue
+		}
+		if modified {
+			entry.Object = sd
+			c++
+		}
+	}
+
+	if log.OptimizeEnabled() {
+		log.Optimize.Printf("simplified paths of %d content stream(s)\n", c)
+	}
+
+	return c, nil
+}
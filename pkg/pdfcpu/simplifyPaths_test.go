@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSimplifyPathOperations(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		// Precision reduction and collinear segments.
+		{"0.12345 0 m 10.0004 0 l 20 0 l 30 0.0001 l 30 10 l S",
+			"0.123 0 m 30 0 l 30 10 l S"},
+		// Direction changes are kept.
+		{"0 0 m 10 0 l 5 0 l S", "0 0 m 10 0 l 5 0 l S"},
+		// Repeated paths.
+		{"1 0 0 RG 0 0 m 10 10 l S 0 0 m 10 10 l S 0 0 10 10 re f", "1 0 0 RG\n0 0 m 10 10 l S 0 0 10 10 re f"},
+		// Repeated paths separated by a state change.
+		{"0 0 m 10 10 l S 2 w 0 0 m 10 10 l S", "0 0 m 10 10 l S 2 w 0 0 m 10 10 l S"},
+		// Repeated paths with transparency involved.
+		{"/GS0 gs 0 0 m 10 10 l S 0 0 m 10 10 l S", "/GS0 gs 0 0 m 10 10 l S 0 0 m 10 10 l S"},
+		// Clipping paths.
+		{"0 0 m 10 0 l 20 0 l 20 20 l h W n", "0 0 m 20 0 l 20 20 l h W n"},
+	} {
+		ops, err := ParseContentStream([]byte(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Join(strings.Fields(string(ContentStreamBytes(SimplifyPathOperations(ops)))), " ")
+		want := strings.Join(strings.Fields(tt.want), " ")
+		if got != want {
+			t.Fatalf("%s:\nwant: %s\ngot:  %s\n", tt.in, want, got)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Size report categories.
+const (
+	SizeImages    = "images"
+	SizeFonts     = "fonts"
+	SizeContent   = "content"
+	SizeMetadata  = "metadata"
+	SizeStructure = "structure"
+)
+
+// SizeCategories lists all size report categories.
+var SizeCategories = []string{SizeImages, SizeFonts, SizeContent, SizeMetadata, SizeStructure}
+
+// ObjectSize represents the number of bytes an object occupies in a PDF file.
+type ObjectSize struct {
+	ObjNr    int
+	Category string
+	Desc     string
+	Size     int64
+}
+
+// SizeReport attributes the size of a PDF file to object categories.
+type SizeReport struct {
+	FileSize   int64
+	Categories map[string]int64
+	Largest    []ObjectSize // largest objects in descending order of size.
+}
+
+// OptimizeReport compares the size attribution of a PDF file before and after optimization.
+type OptimizeReport struct {
+	Before, After *SizeReport
+}
+
+type sizeClass struct {
+	cat, desc string
+}
+
+// sizeClassifier assigns objects to size categories.
+// Objects are classified by their own type or by the category of a referring object.
+type sizeClassifier struct {
+	ctx     *model.Context
+	classes map[int]sizeClass
+	refs    map[int]sizeClass
+}
+
+func (c *sizeClassifier) refer(o types.Object, cat, desc string) {
+	switch o := o.(type) {
+	case types.IndirectRef:
+		objNr := o.ObjectNumber.Value()
+		if _, ok := c.refs[objNr]; !ok {
+			c.refs[objNr] = sizeClass{cat, desc}
+		}
+	case types.Array:
+		for _, o1 := range o {
+			c.refer(o1, cat, desc)
+		}
+	}
+}
+
+func nameOrUnknown(d types.Dict, key string) string {
+	if s := d.NameEntry(key); s != nil {
+		return *s
+	}
+	return "?"
+}
+
+func (c *sizeClassifier) classifyDict(objNr int, d types.Dict) {
+	t := d.Type()
+	if t == nil {
+		return
+	}
+
+	switch *t {
+
+	case "Font":
+		name := nameOrUnknown(d, "BaseFont")
+		c.classes[objNr] = sizeClass{SizeFonts, "font " + name}
+		for _, k := range []string{"FontDescriptor", "ToUnicode", "DescendantFonts", "Widths", "Encoding", "CIDToGIDMap"} {
+			c.refer(d[k], SizeFonts, "font "+name)
+		}
+
+	case "FontDescriptor":
+		name := nameOrUnknown(d, "FontName")
+		c.classes[objNr] = sizeClass{SizeFonts, "font descriptor " + name}
+		for _, k := range []string{"FontFile", "FontFile2", "FontFile3", "CIDSet"} {
+			c.refer(d[k], SizeFonts, "font file "+name)
+		}
+
+	case "Metadata":
+		c.classes[objNr] = sizeClass{SizeMetadata, "XMP metadata"}
+	}
+}
+
+func (c *sizeClassifier) classifyStreamDict(objNr int, sd types.StreamDict) {
+	if st := sd.Subtype(); st != nil {
+		switch *st {
+
+		case "Image":
+			desc := "image"
+			if w, h := sd.IntEntry("Width"), sd.IntEntry("Height"); w != nil && h != nil {
+				desc = fmt.Sprintf("image %dx%d", *w, *h)
+			}
+			if len(sd.FilterPipeline) > 0 {
+				desc += " " + sd.FilterPipeline[len(sd.FilterPipeline)-1].Name
+			}
+			c.classes[objNr] = sizeClass{SizeImages, desc}
+			c.refer(sd.Dict["SMask"], SizeImages, "soft mask of obj#"+fmt.Sprint(objNr))
+			return
+
+		case "Form":
+			c.classes[objNr] = sizeClass{SizeContent, "form XObject"}
+			return
+		}
+	}
+
+	c.classifyDict(objNr, sd.Dict)
+}
+
+func (c *sizeClassifier) classify() error {
+	xRefTable := c.ctx.XRefTable
+
+	for objNr, entry := range xRefTable.Table {
+		if entry == nil || entry.Free {
+			continue
+		}
+		switch o := entry.Object.(type) {
+		case types.Dict:
+			c.classifyDict(objNr, o)
+		case types.StreamDict:
+			c.classifyStreamDict(objNr, o)
+		case types.ObjectStreamDict:
+			c.classes[objNr] = sizeClass{SizeStructure, "object stream"}
+		case types.XRefStreamDict:
+			c.classes[objNr] = sizeClass{SizeStructure, "xref stream"}
+		}
+	}
+
+	for pageNr := 1; pageNr <= c.ctx.PageCount; pageNr++ {
+		d, _, _, err := c.ctx.PageDict(pageNr, false)
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			c.refer(d["Contents"], SizeContent, fmt.Sprintf("content page %d", pageNr))
+		}
+	}
+
+	if xRefTable.Info != nil {
+		c.refer(*xRefTable.Info, SizeMetadata, "info dict")
+	}
+
+	return nil
+}
+
+func (c *sizeClassifier) class(objNr int) sizeClass {
+	if sc, ok := c.classes[objNr]; ok {
+		return sc
+	}
+	if sc, ok := c.refs[objNr]; ok {
+		return sc
+	}
+
+	sc := sizeClass{SizeStructure, "object"}
+	entry, _ := c.ctx.FindTableEntryLight(objNr)
+	if entry == nil {
+		return sc
+	}
+	if d, ok := entry.Object.(types.Dict); ok {
+		if t := d.Type(); t != nil {
+			sc.desc = *t
+		}
+	}
+	if sd, ok := entry.Object.(types.StreamDict); ok {
+		sc.desc = "stream"
+		if t := sd.Type(); t != nil {
+			sc.desc = *t
+		}
+	}
+	return sc
+}
+
+// objectSize returns the approximate number of bytes o occupies in a file.
+func objectSize(objNr int, o types.Object) int64 {
+	// objNr genNr obj ... endobj
+	n := int64(len(fmt.Sprintf("%d 0 obj\nendobj\n", objNr)))
+
+	var sd *types.StreamDict
+	switch o := o.(type) {
+	case types.StreamDict:
+		sd = &o
+	case types.ObjectStreamDict:
+		sd = &o.StreamDict
+	case types.XRefStreamDict:
+		sd = &o.StreamDict
+	}
+
+	if sd == nil {
+		if o != nil {
+			n += int64(len(o.PDFString()))
+		}
+		return n
+	}
+
+	n += int64(len(sd.Dict.PDFString())) + int64(len("\nstream\n\nendstream"))
+	if sd.StreamLength != nil {
+		n += *sd.StreamLength
+	} else {
+		n += int64(len(sd.Raw))
+	}
+
+	return n
+}
+
+// CreateSizeReport attributes the size of the PDF file read into ctx to images, fonts, content streams,
+// metadata and structure and lists the n largest objects.
+// The size of an object stream is distributed among the categories of the objects it contains.
+// File overhead like the cross reference table is accounted for as structure.
+func CreateSizeReport(ctx *model.Context, n int) (*SizeReport, error) {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	c := &sizeClassifier{ctx: ctx, classes: map[int]sizeClass{}, refs: map[int]sizeClass{}}
+	if err := c.classify(); err != nil {
+		return nil, err
+	}
+
+	r := &SizeReport{FileSize: ctx.Read.FileSize, Categories: map[string]int64{}}
+	for _, cat := range SizeCategories {
+		r.Categories[cat] = 0
+	}
+
+	oo := []ObjectSize{}
+	var total int64
+
+	// Uncompressed sizes of objects stored in object streams, by object stream.
+	members := map[int]map[string]int64{}
+
+	for objNr, entry := range ctx.Table {
+		if entry == nil || entry.Free || objNr == 0 {
+			continue
+		}
+		sc := c.class(objNr)
+		if entry.ObjectStream != nil {
+			m, ok := members[*entry.ObjectStream]
+			if !ok {
+				m = map[string]int64{}
+				members[*entry.ObjectStream] = m
+			}
+			if entry.Object != nil {
+				m[sc.cat] += int64(len(entry.Object.PDFString()))
+			}
+			continue
+		}
+		size := objectSize(objNr, entry.Object)
+		total += size
+		oo = append(oo, ObjectSize{ObjNr: objNr, Category: sc.cat, Desc: sc.desc, Size: size})
+	}
+
+	for _, o := range oo {
+		m, ok := members[o.ObjNr]
+		if !ok {
+			r.Categories[o.Category] += o.Size
+			continue
+		}
+		// Distribute the size of an object stream among the categories of its objects.
+		var sum int64
+		for _, n := range m {
+			sum += n
+		}
+		if sum == 0 {
+			r.Categories[o.Category] += o.Size
+			continue
+		}
+		rest := o.Size
+		for _, cat := range SizeCategories {
+			n := o.Size * m[cat] / sum
+			r.Categories[cat] += n
+			rest -= n
+		}
+		r.Categories[SizeStructure] += rest
+	}
+
+	if overhead := r.FileSize - total; overhead > 0 {
+		r.Categories[SizeStructure] += overhead
+	}
+
+	sort.Slice(oo, func(i, j int) bool {
+		if oo[i].Size != oo[j].Size {
+			return oo[i].Size > oo[j].Size
+		}
+		return oo[i].ObjNr < oo[j].ObjNr
+	})
+
+	if n >= 0 && n < len(oo) {
+		oo = oo[:n]
+	}
+	r.Largest = oo
+
+	return r, nil
+}
+
+func percentOf(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
+
+func (r SizeReport) largestString(title string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s:\n", title)
+	for _, o := range r.Largest {
+		fmt.Fprintf(&sb, "  obj#%-6d %-9s %10s  %s\n", o.ObjNr, o.Category, types.ByteSize(o.Size), o.Desc)
+	}
+	return sb.String()
+}
+
+// String returns a tabular representation of r.
+func (r SizeReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "file size: %s\n", types.ByteSize(r.FileSize))
+	for _, cat := range SizeCategories {
+		n := r.Categories[cat]
+		fmt.Fprintf(&sb, "%-10s %10s %5.1f%%\n", cat, types.ByteSize(n), percentOf(n, r.FileSize))
+	}
+	sb.WriteString(r.largestString("largest objects"))
+	return sb.String()
+}
+
+// String returns a tabular representation of r comparing sizes before and after optimization.
+func (r OptimizeReport) String() string {
+	var sb strings.Builder
+
+	b, a := r.Before, r.After
+
+	fmt.Fprintf(&sb, "file size: %s -> %s (%+.1f%%)\n\n",
+		types.ByteSize(b.FileSize), types.ByteSize(a.FileSize), percentOf(a.FileSize-b.FileSize, b.FileSize))
+
+	fmt.Fprintf(&sb, "%-10s %10s %10s %8s\n", "category", "before", "after", "share")
+	for _, cat := range SizeCategories {
+		fmt.Fprintf(&sb, "%-10s %10s %10s %7.1f%%\n",
+			cat, types.ByteSize(b.Categories[cat]), types.ByteSize(a.Categories[cat]), percentOf(a.Categories[cat], a.FileSize))
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(b.largestString("largest objects before"))
+	sb.WriteString("\n")
+	sb.WriteString(a.largestString("largest objects after"))
+
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// snapshotRect returns region r relative to the lower left corner of cropBox clipped to cropBox.
+func snapshotRect(cropBox, r *types.Rectangle) (*types.Rectangle, error) {
+	llx := math.Max(cropBox.LL.X+r.LL.X, cropBox.LL.X)
+	lly := math.Max(cropBox.LL.Y+r.LL.Y, cropBox.LL.Y)
+	urx := math.Min(cropBox.LL.X+r.UR.X, cropBox.UR.X)
+	ury := math.Min(cropBox.LL.Y+r.UR.Y, cropBox.UR.Y)
+
+	if urx <= llx || ury <= lly {
+		return nil, errors.Errorf("pdfcpu: snapshot region %s outside of page", r)
+	}
+
+	return types.NewRectangle(llx, lly, urx, ury), nil
+}
+
+// SnapshotPage extracts the regions rr of page i of ctxSrc into a new context, one page for each region.
+// Regions are relative to the lower left corner of the page as displayed.
+// Page content gets clipped to the region and only resources used by the page get migrated.
+// Annotations are dropped.
+func SnapshotPage(ctxSrc *model.Context, i int, rr []*types.Rectangle) (*model.Context, error) {
+	if len(rr) == 0 {
+		return nil, errors.New("pdfcpu: snapshot: missing region")
+	}
+
+	ctxDest, err := CreateContextWithXRefTable(nil, types.PaperSize["A4"])
+	if err != nil {
+		return nil, err
+	}
+
+	pagesIndRef, err := ctxDest.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	pagesDict, err := ctxDest.DereferenceDict(*pagesIndRef)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restrict resources to the ones used by the page content.
+	consolidateRes := true
+	d, _, inhPAttrs, err := ctxSrc.PageDict(i, consolidateRes)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errors.Errorf("pdfcpu: unknown page number: %d\n", i)
+	}
+
+	d = d.Clone().(types.Dict)
+	d.Delete("Annots")
+	d.Delete("Rotate")
+
+	cropBox := inhPAttrs.MediaBox
+	if inhPAttrs.CropBox != nil {
+		cropBox = inhPAttrs.CropBox
+	}
+	cropBox = cropBox.Clone()
+
+	rotate := inhPAttrs.Rotate
+
+	if types.IntMemberOf(rotate, []int{+90, -90, +270, -270}) {
+		w := cropBox.Width()
+		cropBox.UR.X = cropBox.LL.X + cropBox.Height()
+		cropBox.UR.Y = cropBox.LL.Y + w
+	}
+
+	migrated := map[int]int{}
+
+	for _, r := range rr {
+		cb, err := snapshotRect(cropBox, r)
+		if err != nil {
+			return nil, err
+		}
+
+		d1 := d.Clone().(types.Dict)
+
+		clip := fmt.Sprintf("q %.2f %.2f %.2f %.2f re W n ", cb.LL.X, cb.LL.Y, cb.Width(), cb.Height())
+		if err := internPageRot(ctxSrc, rotate, cropBox, d1, []byte(clip)); err != nil {
+			return nil, err
+		}
+
+		d1["Resources"] = inhPAttrs.Resources.Clone()
+		d1["Parent"] = *pagesIndRef
+		d1["MediaBox"] = cb.Array()
+		d1["CropBox"] = cb.Array()
+
+		pageIndRef, err := ctxDest.IndRefForNewObject(d1)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := migratePageDict(d1, *pageIndRef, ctxSrc, ctxDest, migrated); err != nil {
+			return nil, err
+		}
+
+		if err := model.AppendPageTree(pageIndRef, 1, pagesDict); err != nil {
+			return nil, err
+		}
+
+		ctxDest.PageCount++
+	}
+
+	return ctxDest, nil
+}
@@ -49,8 +49,8 @@ var (
 
 type watermarkParamMap map[string]func(string, *model.Watermark) error
 
-func textDescriptor(wm model.Watermark, timestampFormat string, pageNr, pageCount int) (model.TextDescriptor, bool) {
-	t, unique := format.Text(wm.TextString, timestampFormat, pageNr, pageCount)
+func textDescriptor(wm model.Watermark, ts model.Timestamp, pageNr, pageCount int) (model.TextDescriptor, bool) {
+	t, unique := format.TextWithFields(wm.TextString, ts, pageNr, pageCount, wm.Doc)
 	td := model.TextDescriptor{
 		Text:           t,
 		FontName:       wm.FontName,
@@ -96,25 +96,58 @@ var wmParamMap = watermarkParamMap{
 	"aligntext":       parseTextHorAlignment,
 	"backgroundcolor": parseBackgroundColor,
 	"bgcolor":         parseBackgroundColor,
+	"blendmode":       parseBlendMode,
 	"border":          parseBorder,
 	"color":           parseFillColor,
 	"diagonal":        parseDiagonal,
 	"fillcolor":       parseFillColor,
 	"fontname":        parseFontName,
+	"group":           parseTransparencyGroup,
+	"linewidth":       parseLineWidth,
+	"locale":          parseLocale,
 	"margins":         parseMargins,
 	"mode":            parseRenderMode,
+	"name":            parseWMName,
 	"offset":          parsePositionOffsetWM,
 	"opacity":         parseOpacity,
+	"pagebox":         parsePageBox,
 	"points":          parseFontSize,
 	"position":        parsePositionAnchorWM,
 	"rendermode":      parseRenderMode,
 	"rtl":             parseRightToLeft,
 	"rotation":        parseRotation,
 	"scalefactor":     parseScaleFactorWM,
+	"stencil":         parseStencil,
 	"strokecolor":     parseStrokeColor,
+	"timestamp":       parseTimestampFormat,
+	"timezone":        parseTimeZone,
 	"url":             parseURL,
 }
 
+func parseTimestampFormat(s string, wm *model.Watermark) error {
+	if s == "" {
+		return errors.New("pdfcpu: missing timestamp format, eg. \"2 January 2006 15:04\"")
+	}
+	wm.TimestampFormat = s
+	return nil
+}
+
+func parseLocale(s string, wm *model.Watermark) error {
+	if _, err := model.NewTimestamp("", s, ""); err != nil {
+		return err
+	}
+	wm.Locale = s
+	return nil
+}
+
+func parseTimeZone(s string, wm *model.Watermark) error {
+	if _, err := model.NewTimestamp("", "", s); err != nil {
+		return err
+	}
+	wm.TimeZone = s
+	return nil
+}
+
 func parseTextHorAlignment(s string, wm *model.Watermark) error {
 	var a types.HAlignment
 	switch s {
@@ -147,25 +180,46 @@ func parsePositionAnchorWM(s string, wm *model.Watermark) error {
 	return nil
 }
 
-func parsePositionOffsetWM(s string, wm *model.Watermark) error {
+func parsePageBox(s string, wm *model.Watermark) error {
+	for _, k := range []string{"media", "crop", "trim", "bleed", "art"} {
+		if s != "" && strings.HasPrefix(k, strings.ToLower(s)) {
+			wm.PageBox = k
+			return nil
+		}
+	}
+	return errors.Errorf("pdfcpu: unknown page box: %s, please use one of: media, crop, trim, bleed, art", s)
+}
+
+// parseOffset parses an absolute offset or an offset relative to the anchor box like 10%.
+func parseOffset(s string, u types.DisplayUnit) (abs, rel float64, err error) {
+	if strings.HasSuffix(s, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		return 0, f / 100, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return types.ToUserSpace(f, u), 0, nil
+}
+
+func parsePositionOffsetWM(s string, wm *model.Watermark) (err error) {
 	d := strings.Split(s, " ")
 	if len(d) != 2 {
 		return errors.Errorf("pdfcpu: illegal position offset string: need 2 numeric values, %s\n", s)
 	}
 
-	f, err := strconv.ParseFloat(d[0], 64)
-	if err != nil {
+	if wm.Dx, wm.DxRel, err = parseOffset(d[0], wm.InpUnit); err != nil {
 		return err
 	}
-	wm.Dx = types.ToUserSpace(f, wm.InpUnit)
 
-	f, err = strconv.ParseFloat(d[1], 64)
-	if err != nil {
-		return err
-	}
-	wm.Dy = types.ToUserSpace(f, wm.InpUnit)
+	wm.Dy, wm.DyRel, err = parseOffset(d[1], wm.InpUnit)
 
-	return nil
+	return err
 }
 
 func parseScaleFactorWM(s string, wm *model.Watermark) (err error) {
@@ -181,6 +235,15 @@ func parseFontName(s string, wm *model.Watermark) error {
 	return nil
 }
 
+func parseWMName(s string, wm *model.Watermark) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return errors.New("pdfcpu: missing watermark name")
+	}
+	wm.Name = s
+	return nil
+}
+
 func parseURL(s string, wm *model.Watermark) error {
 	if !wm.OnTop {
 		return errors.Errorf("pdfcpu: \"url\" supported for stamps only.\n")
@@ -260,6 +323,65 @@ func parseRightToLeft(s string, wm *model.Watermark) error {
 	return nil
 }
 
+func parseStencil(s string, wm *model.Watermark) error {
+	switch strings.ToLower(s) {
+	case "on", "true", "t":
+		wm.Stencil = true
+	case "off", "false", "f":
+		wm.Stencil = false
+	default:
+		return errors.New("pdfcpu: stencil, please provide one of: on/off true/false t/f")
+	}
+
+	return nil
+}
+
+// blendModes are the separable and non-separable blend modes as of PDF 1.4.
+var blendModes = []string{
+	"Normal", "Multiply", "Screen", "Overlay", "Darken", "Lighten", "ColorDodge", "ColorBurn",
+	"HardLight", "SoftLight", "Difference", "Exclusion", "Hue", "Saturation", "Color", "Luminosity",
+}
+
+func parseBlendMode(s string, wm *model.Watermark) error {
+	for _, bm := range blendModes {
+		if strings.EqualFold(s, bm) {
+			wm.BlendMode = bm
+			return nil
+		}
+	}
+	return errors.Errorf("pdfcpu: unsupported blend mode: %s, please provide one of: %s", s, strings.Join(blendModes, ", "))
+}
+
+func parseTransparencyGroup(s string, wm *model.Watermark) error {
+	wm.Isolated, wm.Knockout = false, false
+	for _, v := range strings.Fields(strings.ToLower(s)) {
+		switch v {
+		case "isolated", "i":
+			wm.Isolated = true
+		case "knockout", "k":
+			wm.Knockout = true
+		case "none":
+		default:
+			return errors.New("pdfcpu: transparency group, please provide any of: isolated knockout, or none")
+		}
+	}
+
+	return nil
+}
+
+func parseLineWidth(s string, wm *model.Watermark) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return errors.Errorf("pdfcpu: line width must be a float value: %s\n", s)
+	}
+	if f <= 0 {
+		return errors.Errorf("pdfcpu: illegal line width: must be > 0, %s\n", s)
+	}
+
+	wm.LineWidth = types.ToUserSpace(f, wm.InpUnit)
+	return nil
+}
+
 func parseStrokeColor(s string, wm *model.Watermark) error {
 	c, err := color.ParseColor(s)
 	if err != nil {
@@ -452,18 +574,39 @@ func parseBorder(s string, wm *model.Watermark) error {
 	return err
 }
 
+// splitWatermarkParams splits a watermark description into its parameters.
+// Commas within parameter values need to be escaped: timestamp:Monday\, 2 January 2006
+func splitWatermarkParams(s string) []string {
+	ss := strings.Split(s, ",")
+	for i := 0; i < len(ss)-1; i++ {
+		if strings.HasSuffix(ss[i], "\\") {
+			ss[i] = ss[i][:len(ss[i])-1] + "," + ss[i+1]
+			ss = append(ss[:i+1], ss[i+2:]...)
+			i--
+		}
+	}
+	return ss
+}
+
 func parseWatermarkDetails(mode int, modeParm, s string, onTop bool, u types.DisplayUnit) (*model.Watermark, error) {
 	wm := model.DefaultWatermarkConfig()
 	wm.OnTop = onTop
 	wm.InpUnit = u
 
-	ss := strings.Split(s, ",")
+	if mode == model.WMShape {
+		// Shapes are drawn true to size and upright unless configured otherwise.
+		wm.Scale, wm.ScaleAbs = 1, true
+		wm.Diagonal = model.NoDiagonal
+	}
+
+	ss := splitWatermarkParams(s)
 	if len(ss) > 0 && len(ss[0]) == 0 {
 		return wm, setWatermarkType(mode, modeParm, wm)
 	}
 
 	for _, s := range ss {
-		ss1 := strings.Split(s, ":")
+		// Values may contain colons eg. timestamp:15:04
+		ss1 := strings.SplitN(s, ":", 2)
 		if len(ss1) != 2 {
 			return nil, parseWatermarkError(onTop)
 		}
@@ -494,6 +637,16 @@ func ParsePDFWatermarkDetails(fileName, desc string, onTop bool, u types.Display
 	return parseWatermarkDetails(model.WMPDF, fileName, desc, onTop, u)
 }
 
+// ParseSVGWatermarkDetails parses an SVG Watermark/Stamp command string into an internal structure.
+func ParseSVGWatermarkDetails(fileName, desc string, onTop bool, u types.DisplayUnit) (*model.Watermark, error) {
+	return parseWatermarkDetails(model.WMSVG, fileName, desc, onTop, u)
+}
+
+// ParseShapeWatermarkDetails parses a shape Watermark/Stamp command string into an internal structure.
+func ParseShapeWatermarkDetails(shape, desc string, onTop bool, u types.DisplayUnit) (*model.Watermark, error) {
+	return parseWatermarkDetails(model.WMShape, shape, desc, onTop, u)
+}
+
 func onTopString(onTop bool) string {
 	e := "watermark"
 	if onTop {
@@ -588,6 +741,25 @@ func setPDFWatermark(s string, wm *model.Watermark) error {
 	return nil
 }
 
+func setSVGWatermark(s string, wm *model.Watermark) error {
+	// An SVG watermark gets converted into a single page PDF watermark.
+	wm.Page = 1
+	if len(s) == 0 {
+		// The caller is expected to supply wm.Image
+		return nil
+	}
+	if strings.ToLower(filepath.Ext(s)) != ".svg" {
+		return errors.Errorf("%s is not an SVG file", s)
+	}
+	wm.FileName = s
+	bb, err := os.ReadFile(wm.FileName)
+	if err != nil {
+		return err
+	}
+	wm.Image = bytes.NewReader(bb)
+	return nil
+}
+
 func setWatermarkType(mode int, s string, wm *model.Watermark) (err error) {
 	wm.Mode = mode
 	switch wm.Mode {
@@ -599,6 +771,12 @@ func setWatermarkType(mode int, s string, wm *model.Watermark) (err error) {
 
 	case model.WMPDF:
 		err = setPDFWatermark(s, wm)
+
+	case model.WMShape:
+		wm.Shape, err = model.ParseShape(s, wm.InpUnit)
+
+	case model.WMSVG:
+		err = setSVGWatermark(s, wm)
 	}
 	return err
 }
@@ -676,7 +854,31 @@ func createPDFResForWM(ctx *model.Context, wm *model.Watermark) error {
 	return nil
 }
 
+func createSVGResForWM(ctx *model.Context, wm *model.Watermark) error {
+	if wm.Image == nil {
+		return errors.New("pdfcpu: missing SVG watermark source")
+	}
+
+	content, d, w, h, err := ConvertSVG(ctx.XRefTable, wm.Image)
+	if err != nil {
+		return err
+	}
+
+	ir, err := ctx.IndRefForNewObject(d)
+	if err != nil {
+		return err
+	}
+
+	wm.PdfRes[wm.Page] = model.PdfResources{Content: content, ResDict: ir, Bb: types.RectForDim(w, h)}
+
+	return nil
+}
+
 func createImageResForWM(ctx *model.Context, wm *model.Watermark) (err error) {
+	if wm.Stencil {
+		wm.Img, wm.Width, wm.Height, err = model.CreateImageMaskResource(ctx.XRefTable, wm.Image)
+		return err
+	}
 	wm.Img, wm.Width, wm.Height, err = model.CreateImageResource(ctx.XRefTable, wm.Image, false, false)
 	return err
 }
@@ -684,20 +886,55 @@ func createImageResForWM(ctx *model.Context, wm *model.Watermark) (err error) {
 func createFontResForWM(ctx *model.Context, wm *model.Watermark) (err error) {
 	// TODO Reuse font dict.
 	if font.IsUserFont(wm.FontName) {
-		td, _ := setupTextDescriptor(*wm, "", 123456789, 0)
+		td, _ := setupTextDescriptor(*wm, model.Timestamp{}, 123456789, 0)
 		model.WriteMultiLine(ctx.XRefTable, new(bytes.Buffer), types.RectForFormat("A4"), nil, td)
 	}
 	wm.Font, err = pdffont.EnsureFontDict(ctx.XRefTable, wm.FontName, "", "", true, false, nil)
 	return err
 }
 
+// docFields returns the values for document place holders in stamp text like %Filename or %Info(Title).
+func docFields(ctx *model.Context) *model.DocFields {
+	doc := &model.DocFields{Info: map[string]string{}}
+	if ctx.Read != nil && ctx.Read.FileName != "" {
+		doc.FileName = filepath.Base(ctx.Read.FileName)
+	}
+	for k, v := range ctx.Properties {
+		doc.Info[k] = v
+	}
+	for k, v := range map[string]string{
+		"Title":        ctx.Title,
+		"Subject":      ctx.Subject,
+		"Keywords":     ctx.Keywords,
+		"Author":       ctx.Author,
+		"Creator":      ctx.Creator,
+		"Producer":     ctx.Producer,
+		"CreationDate": ctx.CreationDate,
+		"ModDate":      ctx.ModDate,
+	} {
+		if v != "" {
+			doc.Info[k] = v
+		}
+	}
+	return doc
+}
+
 func createResourcesForWM(ctx *model.Context, wm *model.Watermark) error {
+	if wm.IsText() && wm.Doc == nil {
+		wm.Doc = docFields(ctx)
+	}
 	if wm.IsPDF() {
 		return createPDFResForWM(ctx, wm)
 	}
 	if wm.IsImage() {
 		return createImageResForWM(ctx, wm)
 	}
+	if wm.IsSVG() {
+		return createSVGResForWM(ctx, wm)
+	}
+	if wm.IsShape() {
+		return nil
+	}
 	return createFontResForWM(ctx, wm)
 }
 
@@ -804,7 +1041,7 @@ func prepareOCPropertiesInRoot(ctx *model.Context, onTop bool) (*types.IndirectR
 }
 
 func createFormResDict(ctx *model.Context, pageNr int, wm *model.Watermark) (*types.IndirectRef, error) {
-	if wm.IsPDF() {
+	if wm.IsPDF() || wm.IsSVG() {
 		i := wm.Page
 		if wm.MultiStamp() {
 			maxStampPageNr := len(wm.PdfRes)
@@ -826,6 +1063,10 @@ func createFormResDict(ctx *model.Context, pageNr int, wm *model.Watermark) (*ty
 		return ctx.IndRefForNewObject(d)
 	}
 
+	if wm.IsShape() {
+		return nil, nil
+	}
+
 	d := types.Dict(
 		map[string]types.Object{
 			"Font":    types.Dict(map[string]types.Object{"F1": *wm.Font}),
@@ -874,20 +1115,25 @@ func pdfFormContent(w io.Writer, pageNr int, wm model.Watermark) error {
 }
 
 func imageFormContent(w io.Writer, wm model.Watermark) {
+	if wm.Stencil {
+		draw.SetFillColor(w, wm.FillColor)
+	}
 	fmt.Fprintf(w, "q %f 0 0 %f 0 0 cm /Im0 Do Q", wm.Bb.Width(), wm.Bb.Height()) // TODO dont need Q
 }
 
 func formContent(w io.Writer, pageNr int, wm model.Watermark) error {
 	switch true {
-	case wm.IsPDF():
+	case wm.IsPDF(), wm.IsSVG():
 		return pdfFormContent(w, pageNr, wm)
 	case wm.IsImage():
 		imageFormContent(w, wm)
+	case wm.IsShape():
+		model.ShapeFormContent(w, wm)
 	}
 	return nil
 }
 
-func setupTextDescriptor(wm model.Watermark, timestampFormat string, pageNr, pageCount int) (model.TextDescriptor, bool) {
+func setupTextDescriptor(wm model.Watermark, ts model.Timestamp, pageNr, pageCount int) (model.TextDescriptor, bool) {
 	// Set horizontal alignment.
 	var hAlign types.HAlignment
 	if wm.HAlign == nil {
@@ -900,7 +1146,7 @@ func setupTextDescriptor(wm model.Watermark, timestampFormat string, pageNr, pag
 
 	// Set effective position and vertical alignment.
 	x, y, _, vAlign := model.AnchorPosAndAlign(types.BottomLeft, wm.Vp)
-	td, unique := textDescriptor(wm, timestampFormat, pageNr, pageCount)
+	td, unique := textDescriptor(wm, ts, pageNr, pageCount)
 	td.X, td.Y, td.HAlign, td.VAlign, td.FontKey = x, y, hAlign, vAlign, "F1"
 
 	// Set right to left rendering.
@@ -925,7 +1171,7 @@ func setupTextDescriptor(wm model.Watermark, timestampFormat string, pageNr, pag
 func drawBoundingBox(b *bytes.Buffer, wm model.Watermark, bb *types.Rectangle) {
 	urx := bb.UR.X
 	ury := bb.UR.Y
-	if wm.IsPDF() {
+	if wm.IsPDF() || wm.IsSVG() {
 		sc := wm.Scale
 		if !wm.ScaleAbs {
 			sc = bb.Width() / float64(wm.Width)
@@ -941,22 +1187,41 @@ func drawBoundingBox(b *bytes.Buffer, wm model.Watermark, bb *types.Rectangle) {
 	)
 }
 
-func calcFormBoundingBox(xRefTable *model.XRefTable, w io.Writer, timestampFormat string, pageNr, pageCount int, wm *model.Watermark) bool {
+func calcFormBoundingBox(xRefTable *model.XRefTable, w io.Writer, ts model.Timestamp, pageNr, pageCount int, wm *model.Watermark) bool {
 	var unique bool
-	if wm.IsImage() || wm.IsPDF() {
+	if wm.IsImage() || wm.IsPDF() || wm.IsShape() || wm.IsSVG() {
 		wm.CalcBoundingBox(pageNr)
 	} else {
 		var td model.TextDescriptor
-		td, unique = setupTextDescriptor(*wm, timestampFormat, pageNr, pageCount)
+		td, unique = setupTextDescriptor(*wm, ts, pageNr, pageCount)
 		// Render td into b and return the bounding box.
 		wm.Bb = model.WriteMultiLine(xRefTable, w, types.RectForDim(wm.Vp.Width(), wm.Vp.Height()), nil, td)
 	}
 	return unique
 }
 
+// watermarkTimestamp returns the timestamp format for wm falling back to the configured one.
+func watermarkTimestamp(conf *model.Configuration, wm *model.Watermark) model.Timestamp {
+	layout, locale, timeZone := conf.TimestampFormat, conf.TimestampLocale, conf.TimestampTimeZone
+	if wm.TimestampFormat != "" {
+		layout = wm.TimestampFormat
+	}
+	if wm.Locale != "" {
+		locale = wm.Locale
+	}
+	if wm.TimeZone != "" {
+		timeZone = wm.TimeZone
+	}
+	ts, err := model.NewTimestamp(layout, locale, timeZone)
+	if err != nil {
+		return conf.Timestamp()
+	}
+	return ts
+}
+
 func createForm(ctx *model.Context, pageNr, pageCount int, wm *model.Watermark, withBB bool) error {
 	var b bytes.Buffer
-	unique := calcFormBoundingBox(ctx.XRefTable, &b, ctx.Configuration.TimestampFormat, pageNr, pageCount, wm)
+	unique := calcFormBoundingBox(ctx.XRefTable, &b, watermarkTimestamp(ctx.Configuration, wm), pageNr, pageCount, wm)
 
 	// The forms bounding box is dependent on the page dimensions.
 	bb := wm.Bb
@@ -970,7 +1235,7 @@ func createForm(ctx *model.Context, pageNr, pageCount int, wm *model.Watermark,
 		}
 	}
 
-	if wm.IsImage() || wm.IsPDF() {
+	if wm.IsImage() || wm.IsPDF() || wm.IsShape() || wm.IsSVG() {
 		if err := formContent(&b, pageNr, *wm); err != nil {
 			return err
 		}
@@ -1007,6 +1272,17 @@ func createForm(ctx *model.Context, pageNr, pageCount int, wm *model.Watermark,
 		sd.Insert("Resources", *ir)
 	}
 
+	if wm.Isolated || wm.Knockout {
+		sd.Insert("Group", types.Dict(
+			map[string]types.Object{
+				"Type": types.Name("Group"),
+				"S":    types.Name("Transparency"),
+				"I":    types.Boolean(wm.Isolated),
+				"K":    types.Boolean(wm.Knockout),
+			},
+		))
+	}
+
 	sd.InsertName("Filter", filter.Flate)
 
 	if err = sd.Encode(); err != nil {
@@ -1028,7 +1304,7 @@ func createForm(ctx *model.Context, pageNr, pageCount int, wm *model.Watermark,
 	return nil
 }
 
-func createExtGStateForStamp(ctx *model.Context, opacity float64) (*types.IndirectRef, error) {
+func createExtGStateForStamp(ctx *model.Context, opacity float64, blendMode string) (*types.IndirectRef, error) {
 	d := types.Dict(
 		map[string]types.Object{
 			"Type": types.Name("ExtGState"),
@@ -1037,6 +1313,10 @@ func createExtGStateForStamp(ctx *model.Context, opacity float64) (*types.Indire
 		},
 	)
 
+	if blendMode != "" && blendMode != "Normal" {
+		d.InsertName("BM", blendMode)
+	}
+
 	return ctx.IndRefForNewObject(d)
 }
 
@@ -1092,9 +1372,10 @@ func wmContent(wm model.Watermark, gsID, xoID string) []byte {
 	p3 := m.Transform(types.Point{X: wm.Bb.UR.X, Y: wm.Bb.UR.Y})
 	p4 := m.Transform(types.Point{X: wm.Bb.LL.X, Y: wm.Bb.UR.Y})
 	wm.BbTrans = types.QuadLiteral{P1: p1, P2: p2, P3: p3, P4: p4}
-	insertOCG := " /Artifact <</Subtype /Watermark /Type /Pagination >>BDC q %.5f %.5f %.5f %.5f %.5f %.5f cm /%s gs /%s Do Q EMC "
+	id, _ := types.Escape(wm.ID())
+	insertOCG := " " + artifactPrefix(wm.ArtifactSubtype()) + "/ID (%s) >>BDC q %.5f %.5f %.5f %.5f %.5f %.5f cm /%s gs /%s Do Q EMC "
 	var b bytes.Buffer
-	fmt.Fprintf(&b, insertOCG, m[0][0], m[0][1], m[1][0], m[1][1], m[2][0], m[2][1], gsID, xoID)
+	fmt.Fprintf(&b, insertOCG, *id, m[0][0], m[0][1], m[1][0], m[1][1], m[2][0], m[2][1], gsID, xoID)
 	return b.Bytes()
 }
 
@@ -1273,6 +1554,57 @@ func viewPort(a *model.InheritedPageAttrs) *types.Rectangle {
 	return visibleRegion
 }
 
+// pageBoxForName returns the page boundary pageBox of page dict d falling back to the crop box.
+func pageBoxForName(ctx *model.Context, d types.Dict, a *model.InheritedPageAttrs, pageBox string) (*types.Rectangle, error) {
+	var boxName string
+
+	switch pageBox {
+	case "media":
+		return a.MediaBox, nil
+	case "trim":
+		boxName = "TrimBox"
+	case "bleed":
+		boxName = "BleedBox"
+	case "art":
+		boxName = "ArtBox"
+	default:
+		return viewPort(a), nil
+	}
+
+	r, err := ctx.ResolvePageBoundary(d, boxName)
+	if err != nil || r != nil {
+		return r, err
+	}
+
+	return viewPort(a), nil
+}
+
+// anchorBoxInsets returns the left, bottom, right and top insets of pageBox relative to the crop box
+// rotated along with the page.
+func anchorBoxInsets(ctx *model.Context, d types.Dict, a *model.InheritedPageAttrs, pageBox string) (*[4]float64, error) {
+	if pageBox == "" || pageBox == "crop" {
+		return nil, nil
+	}
+
+	cropBox := viewPort(a)
+
+	r, err := pageBoxForName(ctx, d, a, pageBox)
+	if err != nil {
+		return nil, err
+	}
+
+	in := [4]float64{r.LL.X - cropBox.LL.X, r.LL.Y - cropBox.LL.Y, cropBox.UR.X - r.UR.X, cropBox.UR.Y - r.UR.Y}
+
+	// Note: PDF rotation is clockwise!
+	k := (a.Rotate/90%4 + 4) % 4
+	var v [4]float64
+	for i := range v {
+		v[i] = in[(i+k)%4]
+	}
+
+	return &v, nil
+}
+
 func handleLink(ctx *model.Context, pageIndRef *types.IndirectRef, d types.Dict, pageNr int, wm model.Watermark) error {
 	if !wm.OnTop || wm.URL == "" {
 		return nil
@@ -1283,7 +1615,7 @@ func handleLink(ctx *model.Context, pageIndRef *types.IndirectRef, d types.Dict,
 		types.QuadPoints{wm.BbTrans},
 		nil,
 		wm.URL,
-		"pdfcpu",
+		wm.ID(),
 		model.AnnNoZoom+model.AnnNoRotate,
 		nil,
 		false)
@@ -1306,7 +1638,12 @@ func addPageWatermark(ctx *model.Context, pageNr int, wm model.Watermark) error
 		if log.DebugEnabled() {
 			log.Debug.Println("Updating")
 		}
-		if _, err := removePageWatermark(ctx, pageNr); err != nil {
+		// Unnamed watermarks replace all watermarks.
+		var id string
+		if wm.Name != "" {
+			id = wm.ID()
+		}
+		if _, err := removePageWatermark(ctx, pageNr, id); err != nil {
 			return err
 		}
 	}
@@ -1317,6 +1654,11 @@ func addPageWatermark(ctx *model.Context, pageNr int, wm model.Watermark) error
 		return err
 	}
 
+	ins, err := anchorBoxInsets(ctx, d, inhPAttrs, wm.PageBox)
+	if err != nil {
+		return err
+	}
+
 	// Internalize page rotation into content stream.
 	wm.PageRot = inhPAttrs.Rotate
 
@@ -1334,6 +1676,11 @@ func addPageWatermark(ctx *model.Context, pageNr int, wm model.Watermark) error
 		d.Delete("Rotate")
 	}
 
+	wm.Ab = nil
+	if ins != nil {
+		wm.Ab = types.NewRectangle(wm.Vp.LL.X+ins[0], wm.Vp.LL.Y+ins[1], wm.Vp.UR.X-ins[2], wm.Vp.UR.Y-ins[3])
+	}
+
 	if err = createForm(ctx, pageNr, ctx.PageCount, &wm, stampWithBBox); err != nil {
 		return err
 	}
@@ -1381,6 +1728,10 @@ func createWMResources(
 	wm.OnTop = onTop
 	wm.Opacity = opacity
 
+	if wm.IsText() && wm.Doc == nil {
+		wm.Doc = docFields(ctx)
+	}
+
 	if wm.IsImage() {
 		return createImageResForWM(ctx, wm)
 	}
@@ -1389,10 +1740,18 @@ func createWMResources(
 		return createPDFResForWM(ctx, wm)
 	}
 
+	if wm.IsSVG() {
+		return createSVGResForWM(ctx, wm)
+	}
+
+	if wm.IsShape() {
+		return nil
+	}
+
 	// Text watermark
 
 	if font.IsUserFont(wm.FontName) {
-		td, _ := setupTextDescriptor(*wm, "", 123456789, 0)
+		td, _ := setupTextDescriptor(*wm, model.Timestamp{}, 123456789, 0)
 		model.WriteMultiLine(ctx.XRefTable, new(bytes.Buffer), types.RectForFormat("A4"), nil, td)
 	}
 
@@ -1445,12 +1804,14 @@ func createResourcesForWMSliceMap(
 // AddWatermarksMap adds watermarks in m to corresponding pages.
 func AddWatermarksMap(ctx *model.Context, m map[int]*model.Watermark) error {
 	var (
-		onTop   bool
-		opacity float64
+		onTop     bool
+		opacity   float64
+		blendMode string
 	)
 	for _, wm := range m {
 		onTop = wm.OnTop
 		opacity = wm.Opacity
+		blendMode = wm.BlendMode
 		break
 	}
 
@@ -1459,7 +1820,7 @@ func AddWatermarksMap(ctx *model.Context, m map[int]*model.Watermark) error {
 		return err
 	}
 
-	extGStateIndRef, err := createExtGStateForStamp(ctx, opacity)
+	extGStateIndRef, err := createExtGStateForStamp(ctx, opacity, blendMode)
 	if err != nil {
 		return err
 	}
@@ -1499,12 +1860,14 @@ func AddWatermarksMap(ctx *model.Context, m map[int]*model.Watermark) error {
 // AddWatermarksSliceMap adds watermarks in m to corresponding pages.
 func AddWatermarksSliceMap(ctx *model.Context, m map[int][]*model.Watermark) error {
 	var (
-		onTop   bool
-		opacity float64
+		onTop     bool
+		opacity   float64
+		blendMode string
 	)
 	for _, wms := range m {
 		onTop = wms[0].OnTop
 		opacity = wms[0].Opacity
+		blendMode = wms[0].BlendMode
 		break
 	}
 
@@ -1513,7 +1876,7 @@ func AddWatermarksSliceMap(ctx *model.Context, m map[int][]*model.Watermark) err
 		return err
 	}
 
-	extGStateIndRef, err := createExtGStateForStamp(ctx, opacity)
+	extGStateIndRef, err := createExtGStateForStamp(ctx, opacity, blendMode)
 	if err != nil {
 		return err
 	}
@@ -1567,7 +1930,7 @@ func AddWatermarks(ctx *model.Context, selectedPages types.IntSet, wm *model.Wat
 		return err
 	}
 
-	if wm.ExtGState, err = createExtGStateForStamp(ctx, wm.Opacity); err != nil {
+	if wm.ExtGState, err = createExtGStateForStamp(ctx, wm.Opacity, wm.BlendMode); err != nil {
 		return err
 	}
 
@@ -1627,25 +1990,72 @@ func removeForms(ctx *model.Context, d types.Dict, ids []string, i int) error {
 	return removeResDictEntry(ctx, d, "XObject", ids, i)
 }
 
-func removeArtifacts(sd *types.StreamDict, i int) (ok bool, extGStates []string, forms []string, err error) {
+// artifactPrefix begins the marked content property list of watermarks, headers and footers.
+// Watermarks added by older versions lack an ID.
+func artifactPrefix(subtype string) string {
+	return "/Artifact <</Subtype /" + subtype + " /Type /Pagination "
+}
+
+var wmArtifacts = []string{
+	artifactPrefix(model.ArtifactWatermark),
+	artifactPrefix(model.ArtifactHeader),
+	artifactPrefix(model.ArtifactFooter),
+}
+
+// indexArtifact returns the index of the first watermark artifact in s or -1.
+func indexArtifact(s string) int {
+	i := -1
+	for _, prefix := range wmArtifacts {
+		if j := strings.Index(s, prefix); j >= 0 && (i < 0 || j < i) {
+			i = j
+		}
+	}
+	return i
+}
+
+// artifactID returns the ID of a watermark artifact's property list props.
+func artifactID(props string) string {
+	i := strings.Index(props, "/ID (")
+	if i < 0 {
+		return ""
+	}
+	t := props[i+5:]
+	for j := 0; j < len(t); j++ {
+		if t[j] == '\\' {
+			j++
+			continue
+		}
+		if t[j] == ')' {
+			bb, err := types.Unescape(t[:j], false)
+			if err != nil {
+				return ""
+			}
+			return string(bb)
+		}
+	}
+	return ""
+}
+
+// removeArtifacts removes all watermarks tagged with id or all watermarks for id == "".
+func removeArtifacts(sd *types.StreamDict, i int, id string) (ok bool, ids, extGStates, forms []string, err error) {
 	err = sd.Decode()
 	if err == filter.ErrUnsupportedFilter {
 		if log.InfoEnabled() {
 			log.Info.Printf("unsupported filter: unable to patch content with watermark for page %d\n", i)
 		}
-		return false, nil, nil, nil
+		return false, nil, nil, nil, nil
 	}
 	if err != nil {
-		return false, nil, nil, err
+		return false, nil, nil, nil, err
 	}
 
 	var patched bool
 
 	// Watermarks may begin or end the content stream.
 
-	for {
-		s := string(sd.Content)
-		beg := strings.Index(s, "/Artifact <</Subtype /Watermark /Type /Pagination >>BDC")
+	for off := 0; ; {
+		s := string(sd.Content[off:])
+		beg := indexArtifact(s)
 		if beg < 0 {
 			break
 		}
@@ -1658,6 +2068,19 @@ func removeArtifacts(sd *types.StreamDict, i int) (ok bool, extGStates []string,
 		// Check for usage of resources.
 		t := s[beg : beg+end]
 
+		props := t
+		if k := strings.Index(t, ">>BDC"); k > 0 {
+			props = t[:k]
+		}
+		wmID := artifactID(props)
+		if id != "" && wmID != id {
+			off += beg + end + 3
+			continue
+		}
+		if wmID != "" {
+			ids = append(ids, wmID)
+		}
+
 		i := strings.Index(t, "/GS")
 		if i > 0 {
 			j := i + 3
@@ -1677,7 +2100,7 @@ func removeArtifacts(sd *types.StreamDict, i int) (ok bool, extGStates []string,
 		}
 
 		// TODO Remove whitespace until 0x0a
-		sd.Content = append(sd.Content[:beg], sd.Content[beg+end+3:]...)
+		sd.Content = append(sd.Content[:off+beg], sd.Content[off+beg+end+3:]...)
 		patched = true
 	}
 
@@ -1685,28 +2108,28 @@ func removeArtifacts(sd *types.StreamDict, i int) (ok bool, extGStates []string,
 		err = sd.Encode()
 	}
 
-	return patched, extGStates, forms, err
+	return patched, ids, extGStates, forms, err
 }
 
-func removeArtifactsFromPage(ctx *model.Context, sd *types.StreamDict, resDict types.Dict, i int) (bool, error) {
+func removeArtifactsFromPage(ctx *model.Context, sd *types.StreamDict, resDict types.Dict, i int, id string) (bool, []string, error) {
 	// Remove watermark artifacts and locate id's
 	// of used extGStates and forms.
-	ok, extGStates, forms, err := removeArtifacts(sd, i)
+	ok, ids, extGStates, forms, err := removeArtifacts(sd, i, id)
 	if err != nil {
-		return false, err
+		return false, nil, err
 	}
 	if !ok {
-		return false, nil
+		return false, nil, nil
 	}
 
 	// Remove obsolete extGStates from page resource dict.
 	err = removeExtGStates(ctx, resDict, extGStates, i)
 	if err != nil {
-		return false, err
+		return false, nil, err
 	}
 
 	// Remove obsolete forms from page resource dict.
-	return true, removeForms(ctx, resDict, forms, i)
+	return true, ids, removeForms(ctx, resDict, forms, i)
 }
 
 func locatePageContentAndResourceDict(ctx *model.Context, pageNr int) (types.Object, *types.IndirectRef, types.Dict, error) {
@@ -1734,15 +2157,17 @@ func locatePageContentAndResourceDict(ctx *model.Context, pageNr int) (types.Obj
 	return o, pageDictIndRef, resDict, nil
 }
 
-func removeArtifacts1(ctx *model.Context, o types.Object, entry *model.XRefTableEntry, resDict types.Dict, pageNr int) (bool, error) {
+func removeArtifacts1(ctx *model.Context, o types.Object, entry *model.XRefTableEntry, resDict types.Dict, pageNr int, id string) (bool, []string, error) {
 	found := false
+	var ids []string
 	switch o := o.(type) {
 
 	case types.StreamDict:
-		ok, err := removeArtifactsFromPage(ctx, &o, resDict, pageNr)
+		ok, ii, err := removeArtifactsFromPage(ctx, &o, resDict, pageNr, id)
 		if err != nil {
-			return false, err
+			return false, nil, err
 		}
+		ids = append(ids, ii...)
 		if !found && ok {
 			found = true
 		}
@@ -1757,10 +2182,11 @@ func removeArtifacts1(ctx *model.Context, o types.Object, entry *model.XRefTable
 		entry, _ := ctx.FindTableEntry(objNr, genNr)
 		sd, _ := (entry.Object).(types.StreamDict)
 
-		ok, err := removeArtifactsFromPage(ctx, &sd, resDict, pageNr)
+		ok, ii, err := removeArtifactsFromPage(ctx, &sd, resDict, pageNr, id)
 		if err != nil {
-			return false, err
+			return false, nil, err
 		}
+		ids = append(ids, ii...)
 		if !found && ok {
 			found = true
 			entry.Object = sd
@@ -1775,10 +2201,11 @@ func removeArtifacts1(ctx *model.Context, o types.Object, entry *model.XRefTable
 			entry, _ := ctx.FindTableEntry(objNr, genNr)
 			sd, _ := (entry.Object).(types.StreamDict)
 
-			ok, err = removeArtifactsFromPage(ctx, &sd, resDict, pageNr)
+			ok, ii, err = removeArtifactsFromPage(ctx, &sd, resDict, pageNr, id)
 			if err != nil {
-				return false, err
+				return false, nil, err
 			}
+			ids = append(ids, ii...)
 			if !found && ok {
 				found = true
 				entry.Object = sd
@@ -1786,10 +2213,11 @@ func removeArtifacts1(ctx *model.Context, o types.Object, entry *model.XRefTable
 		}
 
 	}
-	return found, nil
+	return found, ids, nil
 }
 
-func removePageWatermark(ctx *model.Context, pageNr int) (bool, error) {
+// removePageWatermark removes the watermarks tagged with id or all watermarks for id == "" from page pageNr.
+func removePageWatermark(ctx *model.Context, pageNr int, id string) (bool, error) {
 	o, pageDictIndRef, resDict, err := locatePageContentAndResourceDict(ctx, pageNr)
 	if err != nil {
 		return false, err
@@ -1805,7 +2233,7 @@ func removePageWatermark(ctx *model.Context, pageNr int) (bool, error) {
 		o = entry.Object
 	}
 
-	found, err := removeArtifacts1(ctx, o, entry, resDict, pageNr)
+	found, ids, err := removeArtifacts1(ctx, o, entry, resDict, pageNr, id)
 	if err != nil {
 		return false, err
 	}
@@ -1823,14 +2251,18 @@ func removePageWatermark(ctx *model.Context, pageNr int) (bool, error) {
 
 	*/
 
-	if found {
+	if found && (id == "" || len(ids) > 0) {
 		// Remove any associated link annotations.
 		d, err := ctx.DereferenceDict(*pageDictIndRef)
 		if err != nil {
 			return false, err
 		}
+		if id == "" {
+			// Link annotations of untagged watermarks.
+			ids = append(ids, "pdfcpu")
+		}
 		objNr := pageDictIndRef.ObjectNumber.Value()
-		if _, err = RemoveAnnotationsFromPageDict(ctx, nil, []string{"pdfcpu"}, nil, d, objNr, pageNr, false); err != nil {
+		if _, err = RemoveAnnotationsFromPageDict(ctx, nil, ids, nil, d, objNr, pageNr, false); err != nil {
 			return false, err
 		}
 	}
@@ -1893,7 +2325,7 @@ func detectStampOCG(ctx *model.Context, arr types.Array) error {
 	return errNoWatermark
 }
 
-func removePageWatermarks(ctx *model.Context, selectedPages types.IntSet) error {
+func removePageWatermarks(ctx *model.Context, selectedPages types.IntSet, id string) error {
 	var removed bool
 
 	for k, v := range selectedPages {
@@ -1902,7 +2334,7 @@ func removePageWatermarks(ctx *model.Context, selectedPages types.IntSet) error
 			continue
 		}
 
-		ok, err := removePageWatermark(ctx, k)
+		ok, err := removePageWatermark(ctx, k, id)
 		if err != nil {
 			return err
 		}
@@ -1934,7 +2366,29 @@ func RemoveWatermarks(ctx *model.Context, selectedPages types.IntSet) error {
 		return err
 	}
 
-	return removePageWatermarks(ctx, selectedPages)
+	return removePageWatermarks(ctx, selectedPages, "")
+}
+
+// RemoveWatermark removes the watermark identified by name for all pages selected.
+func RemoveWatermark(ctx *model.Context, selectedPages types.IntSet, name string) error {
+	if log.DebugEnabled() {
+		log.Debug.Printf("RemoveWatermark %s\n", name)
+	}
+
+	if name == "" {
+		return errors.New("pdfcpu: missing watermark name")
+	}
+
+	arr, err := locateOCGs(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := detectStampOCG(ctx, arr); err != nil {
+		return err
+	}
+
+	return removePageWatermarks(ctx, selectedPages, model.WMIDPrefix+name)
 }
 
 func detectArtifacts(sd *types.StreamDict) (bool, error) {
@@ -1942,7 +2396,7 @@ func detectArtifacts(sd *types.StreamDict) (bool, error) {
 		return false, err
 	}
 	// Watermarks may begin or end the content stream.
-	i := strings.Index(string(sd.Content), "/Artifact <</Subtype /Watermark /Type /Pagination >>BDC")
+	i := indexArtifact(string(sd.Content))
 	return i >= 0, nil
 }
 
@@ -0,0 +1,1439 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mjuen/pdfcpu/pkg/font"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/color"
+	pdffont "github.com/mjuen/pdfcpu/pkg/pdfcpu/font"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// This is a converter for the static subset of SVG typically used for logos:
+// paths, basic shapes, text, solid fills and strokes, opacity, transforms and simple CSS rules.
+// Gradients are approximated by their first stop color.
+// Not supported: filters, masks, clip paths, patterns and embedded images.
+
+// User units (px) per point.
+const svgPxPerPt = 96. / 72.
+
+const svgMaxUseDepth = 8
+
+type svgNode struct {
+	name  string // empty for character data
+	attrs map[string]string
+	text  string
+	kids  []*svgNode
+}
+
+func (n *svgNode) attr(k string) string {
+	return strings.TrimSpace(n.attrs[k])
+}
+
+// svgStyle represents the inherited presentation attributes in effect for an element.
+type svgStyle struct {
+	color                  color.SimpleColor
+	fill, stroke           *color.SimpleColor // nil means none.
+	fillOpacity, strokeOp  float64
+	opacity                float64
+	strokeWidth            float64
+	evenOdd                bool
+	lineCap, lineJoin      int
+	fontSize               float64
+	fontFamily, textAnchor string
+	bold, italic           bool
+}
+
+func defaultSVGStyle() svgStyle {
+	black := color.Black
+	return svgStyle{
+		color:       color.Black,
+		fill:        &black,
+		fillOpacity: 1,
+		strokeOp:    1,
+		opacity:     1,
+		strokeWidth: 1,
+		fontSize:    16,
+		fontFamily:  "sans-serif",
+		textAnchor:  "start",
+	}
+}
+
+type svgRule struct {
+	tag, class, id string
+	decls          map[string]string
+}
+
+type svgConverter struct {
+	xRefTable *model.XRefTable
+	vbW, vbH  float64
+	ids       map[string]*svgNode
+	gradients map[string]color.SimpleColor
+	rules     []svgRule
+	fonts     types.Dict
+	fontNames map[string]string
+	gStates   types.Dict
+	gsNames   map[[2]float64]string
+	useDepth  int
+}
+
+// ConvertSVG converts the SVG document read from r into a PDF content stream
+// and a corresponding resource dict rendering the SVG into a box of width w and height h in points.
+func ConvertSVG(xRefTable *model.XRefTable, r io.Reader) (content []byte, resDict types.Dict, w, h float64, err error) {
+	root, err := parseSVG(r)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+
+	c := &svgConverter{
+		xRefTable: xRefTable,
+		ids:       map[string]*svgNode{},
+		gradients: map[string]color.SimpleColor{},
+		fonts:     types.Dict{},
+		fontNames: map[string]string{},
+		gStates:   types.Dict{},
+		gsNames:   map[[2]float64]string{},
+	}
+
+	c.collectDefs(root)
+
+	w, h, err = c.viewPort(root)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "q 0 0 %.3f %.3f re W n ", w, h)
+	c.rootTransform(&b, root, w, h)
+
+	st := c.style(root, defaultSVGStyle())
+	for _, kid := range root.kids {
+		if err := c.render(&b, kid, st); err != nil {
+			return nil, nil, 0, 0, err
+		}
+	}
+	b.WriteString("Q")
+
+	resDict = types.Dict(
+		map[string]types.Object{
+			"ProcSet": types.NewNameArray("PDF", "Text"),
+		},
+	)
+	if len(c.fonts) > 0 {
+		resDict["Font"] = c.fonts
+	}
+	if len(c.gStates) > 0 {
+		resDict["ExtGState"] = c.gStates
+	}
+
+	return b.Bytes(), resDict, w, h, nil
+}
+
+func parseSVG(r io.Reader) (*svgNode, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+
+	var (
+		root  *svgNode
+		stack []*svgNode
+	)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "pdfcpu: svg")
+		}
+
+		switch t := tok.(type) {
+
+		case xml.StartElement:
+			n := &svgNode{name: t.Name.Local, attrs: map[string]string{}}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.kids = append(parent.kids, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.kids = append(parent.kids, &svgNode{text: string(t)})
+			}
+		}
+	}
+
+	if root == nil || root.name != "svg" {
+		return nil, errors.New("pdfcpu: svg: missing svg root element")
+	}
+
+	return root, nil
+}
+
+func (c *svgConverter) collectDefs(n *svgNode) {
+	if n.name == "" {
+		return
+	}
+
+	if id := n.attr("id"); id != "" {
+		c.ids[id] = n
+	}
+
+	switch n.name {
+
+	case "style":
+		var sb strings.Builder
+		for _, kid := range n.kids {
+			sb.WriteString(kid.text)
+		}
+		c.rules = append(c.rules, parseSVGRules(sb.String())...)
+
+	case "linearGradient", "radialGradient":
+		if id := n.attr("id"); id != "" {
+			for _, kid := range n.kids {
+				if kid.name != "stop" {
+					continue
+				}
+				s := kid.attr("stop-color")
+				if v, ok := parseSVGDecls(kid.attr("style"))["stop-color"]; ok {
+					s = v
+				}
+				if col, ok := parseSVGColor(s, color.Black); ok && col != nil {
+					c.gradients[id] = *col
+				}
+				break
+			}
+		}
+	}
+
+	for _, kid := range n.kids {
+		c.collectDefs(kid)
+	}
+}
+
+// parseSVGRules parses simple CSS rules with selectors of the form tag, .class, #id or tag.class.
+func parseSVGRules(s string) []svgRule {
+	rules := []svgRule{}
+
+	for {
+		i := strings.Index(s, "/*")
+		if i < 0 {
+			break
+		}
+		j := strings.Index(s[i+2:], "*/")
+		if j < 0 {
+			s = s[:i]
+			break
+		}
+		s = s[:i] + s[i+2+j+2:]
+	}
+
+	for {
+		i := strings.Index(s, "{")
+		j := strings.Index(s, "}")
+		if i < 0 || j < i {
+			break
+		}
+		decls := parseSVGDecls(s[i+1 : j])
+		for _, sel := range strings.Split(s[:i], ",") {
+			sel = strings.TrimSpace(sel)
+			if sel == "" || strings.ContainsAny(sel, " >+~:[*") {
+				// Only simple selectors are supported.
+				continue
+			}
+			r := svgRule{decls: decls}
+			switch {
+			case sel[0] == '#':
+				r.id = sel[1:]
+			case strings.Contains(sel, "."):
+				k := strings.Index(sel, ".")
+				r.tag, r.class = sel[:k], sel[k+1:]
+			default:
+				r.tag = sel
+			}
+			rules = append(rules, r)
+		}
+		s = s[j+1:]
+	}
+
+	return rules
+}
+
+func parseSVGDecls(s string) map[string]string {
+	m := map[string]string{}
+	for _, decl := range strings.Split(s, ";") {
+		ss := strings.SplitN(decl, ":", 2)
+		if len(ss) != 2 {
+			continue
+		}
+		v := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(ss[1]), "!important"))
+		m[strings.TrimSpace(ss[0])] = v
+	}
+	return m
+}
+
+func (c *svgConverter) viewPort(root *svgNode) (float64, float64, error) {
+	var vb []float64
+	if s := root.attr("viewBox"); s != "" {
+		ff, err := parseSVGNumbers(s)
+		if err != nil || len(ff) != 4 || ff[2] <= 0 || ff[3] <= 0 {
+			return 0, 0, errors.Errorf("pdfcpu: svg: invalid viewBox: %s", s)
+		}
+		vb = ff
+	}
+
+	w, okW := parseSVGLength(root.attr("width"), 0)
+	h, okH := parseSVGLength(root.attr("height"), 0)
+
+	if vb == nil {
+		if !okW || !okH || w <= 0 || h <= 0 {
+			return 0, 0, errors.New("pdfcpu: svg: missing width/height or viewBox")
+		}
+		vb = []float64{0, 0, w, h}
+	}
+
+	switch {
+	case okW && okH:
+	case okW:
+		h = w * vb[3] / vb[2]
+	case okH:
+		w = h * vb[2] / vb[3]
+	default:
+		w, h = vb[2], vb[3]
+	}
+
+	if w <= 0 || h <= 0 {
+		return 0, 0, errors.New("pdfcpu: svg: invalid width/height")
+	}
+
+	c.vbW, c.vbH = vb[2], vb[3]
+	root.attrs["viewBox"] = fmt.Sprintf("%f %f %f %f", vb[0], vb[1], vb[2], vb[3])
+
+	return w / svgPxPerPt, h / svgPxPerPt, nil
+}
+
+// rootTransform maps the viewBox of root onto the PDF box of width w and height h flipping the y axis.
+func (c *svgConverter) rootTransform(b io.Writer, root *svgNode, w, h float64) {
+	vb, _ := parseSVGNumbers(root.attr("viewBox"))
+
+	sx, sy := w/vb[2], h/vb[3]
+	var dx, dy float64
+
+	if par := strings.Fields(root.attr("preserveAspectRatio")); len(par) == 0 || par[0] != "none" {
+		// Default: xMidYMid meet
+		s := math.Min(sx, sy)
+		dx, dy = (w-vb[2]*s)/2, (h-vb[3]*s)/2
+		sx, sy = s, s
+	}
+
+	fmt.Fprintf(b, "%.5f 0 0 %.5f %.3f %.3f cm ", sx, -sy, dx-vb[0]*sx, h-dy+vb[1]*sy)
+}
+
+func (c *svgConverter) style(n *svgNode, parent svgStyle) svgStyle {
+	st := parent
+	st.opacity = 1
+
+	decls := map[string]string{}
+	for k, v := range n.attrs {
+		decls[k] = v
+	}
+	for _, r := range c.rules {
+		if r.matches(n) {
+			for k, v := range r.decls {
+				decls[k] = v
+			}
+		}
+	}
+	for k, v := range parseSVGDecls(n.attr("style")) {
+		decls[k] = v
+	}
+
+	// color needs to be resolved before currentColor may be referenced.
+	if v, ok := decls["color"]; ok {
+		if col, ok := parseSVGColor(v, parent.color); ok && col != nil {
+			st.color = *col
+		}
+	}
+
+	for k, v := range decls {
+		v = strings.TrimSpace(v)
+		if v == "inherit" {
+			continue
+		}
+		switch k {
+		case "fill":
+			st.fill = c.paint(v, st)
+		case "stroke":
+			st.stroke = c.paint(v, st)
+		case "fill-opacity":
+			st.fillOpacity = parseSVGOpacity(v)
+		case "stroke-opacity":
+			st.strokeOp = parseSVGOpacity(v)
+		case "opacity":
+			st.opacity = parseSVGOpacity(v)
+		case "stroke-width":
+			if f, ok := parseSVGLength(v, c.diag()); ok {
+				st.strokeWidth = f
+			}
+		case "fill-rule":
+			st.evenOdd = v == "evenodd"
+		case "stroke-linecap":
+			st.lineCap = map[string]int{"butt": 0, "round": 1, "square": 2}[v]
+		case "stroke-linejoin":
+			st.lineJoin = map[string]int{"miter": 0, "round": 1, "bevel": 2}[v]
+		case "font-size":
+			if f, ok := parseSVGLength(v, parent.fontSize); ok {
+				st.fontSize = f
+			}
+		case "font-family":
+			st.fontFamily = v
+		case "font-weight":
+			i, err := strconv.Atoi(v)
+			st.bold = v == "bold" || v == "bolder" || (err == nil && i >= 600)
+		case "font-style":
+			st.italic = v == "italic" || v == "oblique"
+		case "text-anchor":
+			st.textAnchor = v
+		}
+	}
+
+	// Group opacity is approximated by applying it to all descendants.
+	st.opacity *= parent.opacity
+
+	return st
+}
+
+func (r svgRule) matches(n *svgNode) bool {
+	if r.id != "" {
+		return n.attr("id") == r.id
+	}
+	if r.tag != "" && r.tag != n.name {
+		return false
+	}
+	if r.class == "" {
+		return true
+	}
+	for _, class := range strings.Fields(n.attr("class")) {
+		if class == r.class {
+			return true
+		}
+	}
+	return false
+}
+
+// paint resolves an SVG paint specification.
+// Paint servers are approximated by the first stop color of a gradient or the fallback color.
+func (c *svgConverter) paint(s string, st svgStyle) *color.SimpleColor {
+	if !strings.HasPrefix(s, "url(") {
+		col, _ := parseSVGColor(s, st.color)
+		return col
+	}
+
+	i := strings.Index(s, ")")
+	if i < 0 {
+		return nil
+	}
+
+	id := strings.Trim(strings.TrimSpace(s[4:i]), "'\"")
+	id = strings.TrimPrefix(id, "#")
+
+	var fallback *color.SimpleColor
+	if rest := strings.TrimSpace(s[i+1:]); rest != "" {
+		fallback, _ = parseSVGColor(rest, st.color)
+	}
+
+	if col, ok := c.gradients[id]; ok {
+		return &col
+	}
+
+	return fallback
+}
+
+func (c *svgConverter) diag() float64 {
+	return math.Sqrt(c.vbW*c.vbW+c.vbH*c.vbH) / math.Sqrt2
+}
+
+func (c *svgConverter) render(b *bytes.Buffer, n *svgNode, parent svgStyle) error {
+	if n.name == "" {
+		return nil
+	}
+
+	st := c.style(n, parent)
+
+	if v := parseSVGDecls(n.attr("style"))["display"]; v == "none" || n.attr("display") == "none" {
+		return nil
+	}
+
+	switch n.name {
+
+	case "defs", "style", "title", "desc", "metadata", "symbol", "clipPath", "mask",
+		"linearGradient", "radialGradient", "pattern", "marker", "filter", "image", "script", "foreignObject":
+		return nil
+
+	case "g", "a", "switch":
+		return c.renderGroup(b, n, st, "")
+
+	case "svg":
+		// Nested viewports are treated as groups.
+		x, _ := parseSVGLength(n.attr("x"), c.vbW)
+		y, _ := parseSVGLength(n.attr("y"), c.vbH)
+		return c.renderGroup(b, n, st, fmt.Sprintf("1 0 0 1 %.3f %.3f cm ", x, y))
+
+	case "use":
+		return c.renderUse(b, n, st)
+
+	case "text":
+		return c.renderText(b, n, st)
+	}
+
+	var path bytes.Buffer
+	fillable := true
+
+	switch n.name {
+
+	case "path":
+		if err := svgPath(&path, n.attr("d")); err != nil {
+			return err
+		}
+
+	case "rect":
+		c.rect(&path, n)
+
+	case "circle":
+		r, _ := parseSVGLength(n.attr("r"), c.diag())
+		cx, _ := parseSVGLength(n.attr("cx"), c.vbW)
+		cy, _ := parseSVGLength(n.attr("cy"), c.vbH)
+		svgEllipse(&path, cx, cy, r, r)
+
+	case "ellipse":
+		rx, _ := parseSVGLength(n.attr("rx"), c.vbW)
+		ry, _ := parseSVGLength(n.attr("ry"), c.vbH)
+		cx, _ := parseSVGLength(n.attr("cx"), c.vbW)
+		cy, _ := parseSVGLength(n.attr("cy"), c.vbH)
+		svgEllipse(&path, cx, cy, rx, ry)
+
+	case "line":
+		x1, _ := parseSVGLength(n.attr("x1"), c.vbW)
+		y1, _ := parseSVGLength(n.attr("y1"), c.vbH)
+		x2, _ := parseSVGLength(n.attr("x2"), c.vbW)
+		y2, _ := parseSVGLength(n.attr("y2"), c.vbH)
+		fmt.Fprintf(&path, "%.3f %.3f m %.3f %.3f l ", x1, y1, x2, y2)
+		fillable = false
+
+	case "polyline", "polygon":
+		ff, err := parseSVGNumbers(n.attr("points"))
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(ff); i += 2 {
+			op := "l"
+			if i == 0 {
+				op = "m"
+			}
+			fmt.Fprintf(&path, "%.3f %.3f %s ", ff[i], ff[i+1], op)
+		}
+		if n.name == "polygon" && len(ff) > 2 {
+			path.WriteString("h ")
+		}
+
+	default:
+		// Unsupported elements are ignored.
+		return nil
+	}
+
+	if path.Len() == 0 {
+		return nil
+	}
+
+	return c.paintPath(b, n, st, path.Bytes(), fillable)
+}
+
+func (c *svgConverter) renderGroup(b *bytes.Buffer, n *svgNode, st svgStyle, cm string) error {
+	b.WriteString("q ")
+	b.WriteString(cm)
+	if err := svgTransform(b, n.attr("transform")); err != nil {
+		return err
+	}
+	for _, kid := range n.kids {
+		if err := c.render(b, kid, st); err != nil {
+			return err
+		}
+	}
+	b.WriteString("Q ")
+	return nil
+}
+
+func (c *svgConverter) renderUse(b *bytes.Buffer, n *svgNode, st svgStyle) error {
+	href := n.attr("href")
+	if !strings.HasPrefix(href, "#") {
+		return nil
+	}
+	ref, ok := c.ids[href[1:]]
+	if !ok || c.useDepth >= svgMaxUseDepth {
+		return nil
+	}
+
+	x, _ := parseSVGLength(n.attr("x"), c.vbW)
+	y, _ := parseSVGLength(n.attr("y"), c.vbH)
+
+	b.WriteString("q ")
+	if err := svgTransform(b, n.attr("transform")); err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "1 0 0 1 %.3f %.3f cm ", x, y)
+
+	c.useDepth++
+	defer func() { c.useDepth-- }()
+
+	var err error
+	if ref.name == "symbol" {
+		err = c.renderGroup(b, ref, c.style(ref, st), "")
+	} else {
+		err = c.render(b, ref, st)
+	}
+	if err != nil {
+		return err
+	}
+
+	b.WriteString("Q ")
+	return nil
+}
+
+func (c *svgConverter) rect(w io.Writer, n *svgNode) {
+	x, _ := parseSVGLength(n.attr("x"), c.vbW)
+	y, _ := parseSVGLength(n.attr("y"), c.vbH)
+	width, _ := parseSVGLength(n.attr("width"), c.vbW)
+	height, _ := parseSVGLength(n.attr("height"), c.vbH)
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	rx, okX := parseSVGLength(n.attr("rx"), c.vbW)
+	ry, okY := parseSVGLength(n.attr("ry"), c.vbH)
+	if okX && !okY {
+		ry = rx
+	}
+	if okY && !okX {
+		rx = ry
+	}
+	rx = math.Min(math.Max(rx, 0), width/2)
+	ry = math.Min(math.Max(ry, 0), height/2)
+
+	if rx == 0 || ry == 0 {
+		fmt.Fprintf(w, "%.3f %.3f %.3f %.3f re ", x, y, width, height)
+		return
+	}
+
+	kx, ky := rx*svgKappa, ry*svgKappa
+	x1, y1 := x+width, y+height
+	fmt.Fprintf(w, "%.3f %.3f m ", x+rx, y)
+	fmt.Fprintf(w, "%.3f %.3f l ", x1-rx, y)
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ", x1-rx+kx, y, x1, y+ry-ky, x1, y+ry)
+	fmt.Fprintf(w, "%.3f %.3f l ", x1, y1-ry)
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ", x1, y1-ry+ky, x1-rx+kx, y1, x1-rx, y1)
+	fmt.Fprintf(w, "%.3f %.3f l ", x+rx, y1)
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ", x+rx-kx, y1, x, y1-ry+ky, x, y1-ry)
+	fmt.Fprintf(w, "%.3f %.3f l ", x, y+ry)
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c h ", x, y+ry-ky, x+rx-kx, y, x+rx, y)
+}
+
+// Control point distance for approximating a quarter circle by a cubic Bézier curve.
+const svgKappa = 0.5522847498
+
+func svgEllipse(w io.Writer, cx, cy, rx, ry float64) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	kx, ky := rx*svgKappa, ry*svgKappa
+	fmt.Fprintf(w, "%.3f %.3f m ", cx+rx, cy)
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ", cx+rx, cy+ky, cx+kx, cy+ry, cx, cy+ry)
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ", cx-kx, cy+ry, cx-rx, cy+ky, cx-rx, cy)
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ", cx-rx, cy-ky, cx-kx, cy-ry, cx, cy-ry)
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c h ", cx+kx, cy-ry, cx+rx, cy-ky, cx+rx, cy)
+}
+
+// graphicsState returns the name of an ExtGState resource for the given fill and stroke opacity.
+func (c *svgConverter) graphicsState(fillOp, strokeOp float64) string {
+	k := [2]float64{fillOp, strokeOp}
+	if name, ok := c.gsNames[k]; ok {
+		return name
+	}
+	name := fmt.Sprintf("GS%d", len(c.gsNames))
+	c.gsNames[k] = name
+	c.gStates[name] = types.Dict(
+		map[string]types.Object{
+			"Type": types.Name("ExtGState"),
+			"ca":   types.Float(fillOp),
+			"CA":   types.Float(strokeOp),
+		},
+	)
+	return name
+}
+
+func (c *svgConverter) setPaintState(b *bytes.Buffer, st svgStyle, fill, stroke bool) {
+	fillOp, strokeOp := st.fillOpacity*st.opacity, st.strokeOp*st.opacity
+	if (fill && fillOp < 1) || (stroke && strokeOp < 1) {
+		fmt.Fprintf(b, "/%s gs ", c.graphicsState(fillOp, strokeOp))
+	}
+	if fill {
+		fmt.Fprintf(b, "%.3f %.3f %.3f rg ", st.fill.R, st.fill.G, st.fill.B)
+	}
+	if stroke {
+		fmt.Fprintf(b, "%.3f %.3f %.3f RG %.3f w %d J %d j ", st.stroke.R, st.stroke.G, st.stroke.B, st.strokeWidth, st.lineCap, st.lineJoin)
+	}
+}
+
+func (c *svgConverter) paintPath(b *bytes.Buffer, n *svgNode, st svgStyle, path []byte, fillable bool) error {
+	fill := fillable && st.fill != nil
+	stroke := st.stroke != nil && st.strokeWidth > 0
+	if !fill && !stroke {
+		return nil
+	}
+
+	b.WriteString("q ")
+	if err := svgTransform(b, n.attr("transform")); err != nil {
+		return err
+	}
+
+	c.setPaintState(b, st, fill, stroke)
+	b.Write(path)
+
+	op := "S"
+	if fill {
+		op = "f"
+		if stroke {
+			op = "B"
+		}
+		if st.evenOdd {
+			op += "*"
+		}
+	}
+
+	b.WriteString(op)
+	b.WriteString(" Q ")
+
+	return nil
+}
+
+// svgFontName maps a CSS font family list onto one of the PDF core fonts.
+func svgFontName(family string, bold, italic bool) string {
+	fonts := map[string][4]string{
+		"Helvetica": {"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique"},
+		"Times":     {"Times-Roman", "Times-Bold", "Times-Italic", "Times-BoldItalic"},
+		"Courier":   {"Courier", "Courier-Bold", "Courier-Oblique", "Courier-BoldOblique"},
+	}
+
+	base := "Helvetica"
+
+	for _, f := range strings.Split(family, ",") {
+		f = strings.ToLower(strings.Trim(strings.TrimSpace(f), "'\""))
+		if f == "serif" || strings.Contains(f, "times") || strings.Contains(f, "georgia") {
+			base = "Times"
+			break
+		}
+		if f == "monospace" || strings.Contains(f, "courier") || strings.Contains(f, "mono") {
+			base = "Courier"
+			break
+		}
+		if f == "sans-serif" || strings.Contains(f, "helvetica") || strings.Contains(f, "arial") {
+			break
+		}
+	}
+
+	i := 0
+	if bold {
+		i++
+	}
+	if italic {
+		i += 2
+	}
+
+	return fonts[base][i]
+}
+
+func (c *svgConverter) fontResource(fontName string) (string, error) {
+	if id, ok := c.fontNames[fontName]; ok {
+		return id, nil
+	}
+	ir, err := pdffont.EnsureFontDict(c.xRefTable, fontName, "", "", false, false, nil)
+	if err != nil {
+		return "", err
+	}
+	id := fmt.Sprintf("F%d", len(c.fontNames))
+	c.fontNames[fontName] = id
+	c.fonts[id] = *ir
+	return id, nil
+}
+
+type svgTextRun struct {
+	st     svgStyle
+	x, y   *float64
+	dx, dy float64
+	text   string
+}
+
+func (c *svgConverter) textRuns(n *svgNode, st svgStyle, runs []svgTextRun) []svgTextRun {
+	first := true
+	for _, kid := range n.kids {
+		if kid.name == "" {
+			s := strings.Join(strings.Fields(kid.text), " ")
+			if s == "" {
+				continue
+			}
+			r := svgTextRun{st: st, text: s}
+			if first {
+				r.x, r.y, r.dx, r.dy = c.textPos(n)
+				first = false
+			}
+			runs = append(runs, r)
+			continue
+		}
+		if kid.name != "tspan" {
+			continue
+		}
+		st1 := c.style(kid, st)
+		i := len(runs)
+		runs = c.textRuns(kid, st1, runs)
+		if first && len(runs) > i {
+			first = false
+			if runs[i].x == nil && runs[i].y == nil {
+				runs[i].x, runs[i].y, runs[i].dx, runs[i].dy = c.textPos(n)
+			}
+		}
+	}
+	return runs
+}
+
+func (c *svgConverter) textPos(n *svgNode) (*float64, *float64, float64, float64) {
+	coord := func(k string) *float64 {
+		ff, err := parseSVGNumbers(strings.TrimRight(strings.TrimSpace(n.attr(k)), "px"))
+		if err != nil || len(ff) == 0 {
+			return nil
+		}
+		return &ff[0]
+	}
+	var dx, dy float64
+	if f := coord("dx"); f != nil {
+		dx = *f
+	}
+	if f := coord("dy"); f != nil {
+		dy = *f
+	}
+	return coord("x"), coord("y"), dx, dy
+}
+
+func svgTextBytes(s string) string {
+	bb := []byte{}
+	for _, r := range s {
+		b := byte(0x20)
+		if r <= 0xff {
+			b = byte(r)
+		}
+		bb = append(bb, b)
+	}
+	return string(bb)
+}
+
+func (c *svgConverter) renderText(b *bytes.Buffer, n *svgNode, st svgStyle) error {
+	runs := c.textRuns(n, st, nil)
+	if len(runs) == 0 {
+		return nil
+	}
+
+	b.WriteString("q ")
+	if err := svgTransform(b, n.attr("transform")); err != nil {
+		return err
+	}
+
+	var x, y float64
+	for _, r := range runs {
+		if r.x != nil {
+			x = *r.x
+		}
+		if r.y != nil {
+			y = *r.y
+		}
+		x += r.dx
+		y += r.dy
+
+		fill := r.st.fill != nil
+		stroke := r.st.stroke != nil && r.st.strokeWidth > 0
+		fontName := svgFontName(r.st.fontFamily, r.st.bold, r.st.italic)
+		s := svgTextBytes(r.text)
+		w := font.TextWidth(s, fontName, 1000) * r.st.fontSize / 1000
+
+		x0 := x
+		switch r.st.textAnchor {
+		case "middle":
+			x0 -= w / 2
+		case "end":
+			x0 -= w
+		}
+		x = x0 + w
+
+		if !fill && !stroke {
+			continue
+		}
+
+		id, err := c.fontResource(fontName)
+		if err != nil {
+			return err
+		}
+
+		esc, err := types.Escape(s)
+		if err != nil {
+			return err
+		}
+
+		mode := 0
+		if stroke {
+			mode = 1
+			if fill {
+				mode = 2
+			}
+		}
+
+		b.WriteString("q ")
+		c.setPaintState(b, r.st, fill, stroke)
+		fmt.Fprintf(b, "BT /%s %.3f Tf %d Tr 1 0 0 -1 %.3f %.3f Tm (%s) Tj ET Q ", id, r.st.fontSize, mode, x0, y, *esc)
+	}
+
+	b.WriteString("Q ")
+	return nil
+}
+
+func parseSVGOpacity(s string) float64 {
+	pct := strings.HasSuffix(s, "%")
+	f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 1
+	}
+	if pct {
+		f /= 100
+	}
+	return math.Max(0, math.Min(1, f))
+}
+
+// parseSVGLength returns the length s in user units. Percentages are relative to ref.
+func parseSVGLength(s string, ref float64) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+
+	units := []struct {
+		suffix string
+		f      float64
+	}{
+		{"%", ref / 100},
+		{"px", 1},
+		{"pt", svgPxPerPt},
+		{"pc", 12 * svgPxPerPt},
+		{"mm", 96 / 25.4},
+		{"cm", 96 / 2.54},
+		{"in", 96},
+		{"em", 16},
+	}
+
+	f := 1.
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s, f = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.f
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return v * f, true
+}
+
+var svgNamedColors = map[string]uint32{
+	"black":   0x000000,
+	"white":   0xFFFFFF,
+	"red":     0xFF0000,
+	"lime":    0x00FF00,
+	"green":   0x008000,
+	"blue":    0x0000FF,
+	"yellow":  0xFFFF00,
+	"cyan":    0x00FFFF,
+	"aqua":    0x00FFFF,
+	"magenta": 0xFF00FF,
+	"fuchsia": 0xFF00FF,
+	"gray":    0x808080,
+	"grey":    0x808080,
+	"silver":  0xC0C0C0,
+	"maroon":  0x800000,
+	"olive":   0x808000,
+	"navy":    0x000080,
+	"purple":  0x800080,
+	"teal":    0x008080,
+	"orange":  0xFFA500,
+	"brown":   0xA52A2A,
+	"pink":    0xFFC0CB,
+	"gold":    0xFFD700,
+	"indigo":  0x4B0082,
+	"violet":  0xEE82EE,
+}
+
+// parseSVGColor parses an SVG color specification. A nil color represents none.
+func parseSVGColor(s string, current color.SimpleColor) (*color.SimpleColor, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	switch s {
+	case "", "none", "transparent":
+		return nil, true
+	case "currentcolor":
+		return &current, true
+	}
+
+	if strings.HasPrefix(s, "#") {
+		hex := s[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) == 8 {
+			hex = hex[:6]
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return nil, false
+		}
+		col := color.NewSimpleColor(uint32(v))
+		return &col, true
+	}
+
+	if strings.HasPrefix(s, "rgb(") || strings.HasPrefix(s, "rgba(") {
+		i, j := strings.Index(s, "("), strings.LastIndex(s, ")")
+		if j < i {
+			return nil, false
+		}
+		ss := strings.FieldsFunc(s[i+1:j], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(ss) < 3 {
+			return nil, false
+		}
+		var rgb [3]float32
+		for k := 0; k < 3; k++ {
+			pct := strings.HasSuffix(ss[k], "%")
+			f, err := strconv.ParseFloat(strings.TrimSuffix(ss[k], "%"), 64)
+			if err != nil {
+				return nil, false
+			}
+			if pct {
+				f = f * 255 / 100
+			}
+			rgb[k] = float32(math.Max(0, math.Min(255, f)) / 255)
+		}
+		return &color.SimpleColor{R: rgb[0], G: rgb[1], B: rgb[2]}, true
+	}
+
+	if v, ok := svgNamedColors[s]; ok {
+		col := color.NewSimpleColor(v)
+		return &col, true
+	}
+
+	return nil, false
+}
+
+// svgScanner tokenizes numbers, flags and commands of SVG attributes like path data, points or transforms.
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (sc *svgScanner) skipSep() {
+	for sc.i < len(sc.s) && strings.IndexByte(" \t\r\n,", sc.s[sc.i]) >= 0 {
+		sc.i++
+	}
+}
+
+func (sc *svgScanner) done() bool {
+	sc.skipSep()
+	return sc.i >= len(sc.s)
+}
+
+func (sc *svgScanner) number() (float64, error) {
+	sc.skipSep()
+	start := sc.i
+	if sc.i < len(sc.s) && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+		sc.i++
+	}
+	digits := func() int {
+		j := sc.i
+		for sc.i < len(sc.s) && sc.s[sc.i] >= '0' && sc.s[sc.i] <= '9' {
+			sc.i++
+		}
+		return sc.i - j
+	}
+	n := digits()
+	if sc.i < len(sc.s) && sc.s[sc.i] == '.' {
+		sc.i++
+		n += digits()
+	}
+	if n == 0 {
+		return 0, errors.Errorf("pdfcpu: svg: number expected at: %q", sc.s[start:])
+	}
+	if sc.i < len(sc.s) && (sc.s[sc.i] == 'e' || sc.s[sc.i] == 'E') {
+		j := sc.i
+		sc.i++
+		if sc.i < len(sc.s) && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+			sc.i++
+		}
+		if digits() == 0 {
+			sc.i = j
+		}
+	}
+	return strconv.ParseFloat(sc.s[start:sc.i], 64)
+}
+
+func (sc *svgScanner) numbers(ff ...*float64) error {
+	for _, f := range ff {
+		v, err := sc.number()
+		if err != nil {
+			return err
+		}
+		*f = v
+	}
+	return nil
+}
+
+// flag scans an arc flag which may not be separated from the following number.
+func (sc *svgScanner) flag() (bool, error) {
+	sc.skipSep()
+	if sc.i < len(sc.s) && (sc.s[sc.i] == '0' || sc.s[sc.i] == '1') {
+		sc.i++
+		return sc.s[sc.i-1] == '1', nil
+	}
+	return false, errors.Errorf("pdfcpu: svg: arc flag expected at: %q", sc.s[sc.i:])
+}
+
+func parseSVGNumbers(s string) ([]float64, error) {
+	sc := &svgScanner{s: s}
+	ff := []float64{}
+	for !sc.done() {
+		f, err := sc.number()
+		if err != nil {
+			return nil, err
+		}
+		ff = append(ff, f)
+	}
+	return ff, nil
+}
+
+// svgPath converts SVG path data into PDF path construction operators.
+func svgPath(w io.Writer, d string) error {
+	sc := &svgScanner{s: d}
+
+	var (
+		x, y, x0, y0 float64 // current point, start of subpath
+		cx, cy       float64 // last control point
+		cmd, prev    byte
+	)
+
+	for !sc.done() {
+		if c := sc.s[sc.i]; strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0 {
+			cmd = c
+			sc.i++
+		} else if cmd == 0 || cmd == 'Z' || cmd == 'z' {
+			return errors.Errorf("pdfcpu: svg: invalid path data: %s", d)
+		}
+		if prev == 0 && cmd != 'M' && cmd != 'm' {
+			// Path data has to begin with a moveto.
+			return errors.Errorf("pdfcpu: svg: invalid path data: %s", d)
+		}
+
+		var ox, oy float64
+		if cmd >= 'a' {
+			ox, oy = x, y
+		}
+
+		switch cmd {
+
+		case 'M', 'm':
+			if err := sc.numbers(&x, &y); err != nil {
+				return err
+			}
+			x, y = ox+x, oy+y
+			x0, y0 = x, y
+			fmt.Fprintf(w, "%.3f %.3f m ", x, y)
+			// Subsequent pairs are implicit lineto commands.
+			cmd -= 'M' - 'L'
+
+		case 'L', 'l':
+			if err := sc.numbers(&x, &y); err != nil {
+				return err
+			}
+			x, y = ox+x, oy+y
+			fmt.Fprintf(w, "%.3f %.3f l ", x, y)
+
+		case 'H', 'h':
+			if err := sc.numbers(&x); err != nil {
+				return err
+			}
+			x = ox + x
+			fmt.Fprintf(w, "%.3f %.3f l ", x, y)
+
+		case 'V', 'v':
+			if err := sc.numbers(&y); err != nil {
+				return err
+			}
+			y = oy + y
+			fmt.Fprintf(w, "%.3f %.3f l ", x, y)
+
+		case 'C', 'c':
+			var x1, y1, x2, y2 float64
+			if err := sc.numbers(&x1, &y1, &x2, &y2, &x, &y); err != nil {
+				return err
+			}
+			x1, y1, x2, y2, x, y = ox+x1, oy+y1, ox+x2, oy+y2, ox+x, oy+y
+			fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ", x1, y1, x2, y2, x, y)
+			cx, cy = x2, y2
+
+		case 'S', 's':
+			x1, y1 := x, y
+			if strings.IndexByte("CcSs", prev) >= 0 {
+				x1, y1 = 2*x-cx, 2*y-cy
+			}
+			var x2, y2 float64
+			if err := sc.numbers(&x2, &y2, &x, &y); err != nil {
+				return err
+			}
+			x2, y2, x, y = ox+x2, oy+y2, ox+x, oy+y
+			fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ", x1, y1, x2, y2, x, y)
+			cx, cy = x2, y2
+
+		case 'Q', 'q':
+			var qx, qy, x1, y1 float64
+			if err := sc.numbers(&qx, &qy, &x1, &y1); err != nil {
+				return err
+			}
+			qx, qy = ox+qx, oy+qy
+			svgQuad(w, x, y, qx, qy, ox+x1, oy+y1)
+			x, y, cx, cy = ox+x1, oy+y1, qx, qy
+
+		case 'T', 't':
+			qx, qy := x, y
+			if strings.IndexByte("QqTt", prev) >= 0 {
+				qx, qy = 2*x-cx, 2*y-cy
+			}
+			var x1, y1 float64
+			if err := sc.numbers(&x1, &y1); err != nil {
+				return err
+			}
+			svgQuad(w, x, y, qx, qy, ox+x1, oy+y1)
+			x, y, cx, cy = ox+x1, oy+y1, qx, qy
+
+		case 'A', 'a':
+			var rx, ry, phi, x1, y1 float64
+			if err := sc.numbers(&rx, &ry, &phi); err != nil {
+				return err
+			}
+			large, err := sc.flag()
+			if err != nil {
+				return err
+			}
+			sweep, err := sc.flag()
+			if err != nil {
+				return err
+			}
+			if err := sc.numbers(&x1, &y1); err != nil {
+				return err
+			}
+			svgArc(w, x, y, rx, ry, phi, large, sweep, ox+x1, oy+y1)
+			x, y = ox+x1, oy+y1
+
+		case 'Z', 'z':
+			fmt.Fprint(w, "h ")
+			x, y = x0, y0
+		}
+
+		prev = cmd
+	}
+
+	return nil
+}
+
+// svgQuad writes the quadratic Bézier curve from (x0,y0) to (x1,y1) with control point (qx,qy) as cubic curve.
+func svgQuad(w io.Writer, x0, y0, qx, qy, x1, y1 float64) {
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ",
+		x0+2*(qx-x0)/3, y0+2*(qy-y0)/3,
+		x1+2*(qx-x1)/3, y1+2*(qy-y1)/3,
+		x1, y1)
+}
+
+// svgArc writes an elliptical arc from (x1,y1) to (x2,y2) as a sequence of cubic Bézier curves.
+// See the SVG implementation notes on the conversion from endpoint to center parameterization.
+func svgArc(w io.Writer, x1, y1, rx, ry, phi float64, large, sweep bool, x2, y2 float64) {
+	if x1 == x2 && y1 == y2 {
+		return
+	}
+
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		fmt.Fprintf(w, "%.3f %.3f l ", x2, y2)
+		return
+	}
+
+	sinPhi, cosPhi := math.Sincos(phi * math.Pi / 180)
+
+	dx, dy := (x1-x2)/2, (y1-y2)/2
+	x1p := cosPhi*dx + sinPhi*dy
+	y1p := -sinPhi*dx + cosPhi*dy
+
+	// Scale up radii if necessary.
+	if l := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp, cyp := coef*rx*y1p/ry, -coef*ry*x1p/rx
+
+	cx := cosPhi*cxp - sinPhi*cyp + (x1+x2)/2
+	cy := sinPhi*cxp + cosPhi*cyp + (y1+y2)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+
+	theta := angle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	delta := angle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	d := delta / float64(n)
+	t := 4. / 3 * math.Tan(d/4)
+
+	point := func(a float64) (float64, float64, float64, float64) {
+		sin, cos := math.Sincos(a)
+		// Point and derivative on the ellipse.
+		x := cx + rx*cos*cosPhi - ry*sin*sinPhi
+		y := cy + rx*cos*sinPhi + ry*sin*cosPhi
+		dx := -rx*sin*cosPhi - ry*cos*sinPhi
+		dy := -rx*sin*sinPhi + ry*cos*cosPhi
+		return x, y, dx, dy
+	}
+
+	a := theta
+	px, py, pdx, pdy := point(a)
+	for i := 0; i < n; i++ {
+		a += d
+		qx, qy, qdx, qdy := point(a)
+		if i == n-1 {
+			qx, qy = x2, y2
+		}
+		fmt.Fprintf(w, "%.3f %.3f %.3f %.3f %.3f %.3f c ", px+t*pdx, py+t*pdy, qx-t*qdx, qy-t*qdy, qx, qy)
+		px, py, pdx, pdy = qx, qy, qdx, qdy
+	}
+}
+
+// svgTransform writes an SVG transform list as a sequence of PDF cm operators.
+func svgTransform(w io.Writer, s string) error {
+	s = strings.TrimSpace(s)
+
+	for s != "" {
+		i := strings.Index(s, "(")
+		j := strings.Index(s, ")")
+		if i < 0 || j < i {
+			return errors.Errorf("pdfcpu: svg: invalid transform: %s", s)
+		}
+
+		name := strings.TrimSpace(strings.Trim(s[:i], " ,"))
+		ff, err := parseSVGNumbers(s[i+1 : j])
+		if err != nil {
+			return err
+		}
+		s = strings.TrimSpace(strings.TrimLeft(s[j+1:], " ,"))
+
+		arg := func(k int, def float64) float64 {
+			if k < len(ff) {
+				return ff[k]
+			}
+			return def
+		}
+
+		var m [6]float64
+
+		switch name {
+
+		case "matrix":
+			if len(ff) != 6 {
+				return errors.Errorf("pdfcpu: svg: invalid matrix: %v", ff)
+			}
+			copy(m[:], ff)
+
+		case "translate":
+			m = [6]float64{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+
+		case "scale":
+			sx := arg(0, 1)
+			m = [6]float64{sx, 0, 0, arg(1, sx), 0, 0}
+
+		case "rotate":
+			sin, cos := math.Sincos(arg(0, 0) * math.Pi / 180)
+			cx, cy := arg(1, 0), arg(2, 0)
+			m = [6]float64{cos, sin, -sin, cos, cx - cos*cx + sin*cy, cy - sin*cx - cos*cy}
+
+		case "skewX":
+			m = [6]float64{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+
+		case "skewY":
+			m = [6]float64{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+
+		default:
+			return errors.Errorf("pdfcpu: svg: unsupported transform: %s", name)
+		}
+
+		fmt.Fprintf(w, "%.5f %.5f %.5f %.5f %.3f %.3f cm ", m[0], m[1], m[2], m[3], m[4], m[5])
+	}
+
+	return nil
+}
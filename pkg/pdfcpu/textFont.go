@@ -0,0 +1,553 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/mjuen/pdfcpu/internal/corefont/metrics"
+	"github.com/mjuen/pdfcpu/pkg/font"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// codespaceRange represents a codespace range of a CMap.
+type codespaceRange struct {
+	lo, hi []byte
+}
+
+func (cr codespaceRange) contains(bb []byte) bool {
+	if len(bb) != len(cr.lo) {
+		return false
+	}
+	for i, b := range bb {
+		if b < cr.lo[i] || b > cr.hi[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// textFont maps character codes of a font to Unicode text and glyph widths.
+type textFont struct {
+	type0     bool
+	type3     bool
+	ucs2      bool               // Type0 font using a predefined UCS2 CMap.
+	codespace []codespaceRange   // Type0 fonts only.
+	toUnicode map[uint32]string  // from ToUnicode CMap.
+	encoding  map[uint32]string  // simple fonts only.
+	widths    map[uint32]float64 // in glyph space units.
+	missWidth float64            // in glyph space units.
+	scale     float64            // glyph space to text space.
+	coreFont  string             // standard font without widths.
+}
+
+// textCode represents a single character code shown by a text-showing operator.
+type textCode struct {
+	code uint32
+	len  int
+}
+
+// codes splits the bytes of a shown string into character codes.
+func (tf *textFont) codes(bb []byte) []textCode {
+	var cc []textCode
+
+	for i := 0; i < len(bb); {
+		n := 1
+		if tf.type0 {
+			n = 2
+			for _, cr := range tf.codespace {
+				if i+len(cr.lo) <= len(bb) && cr.contains(bb[i:i+len(cr.lo)]) {
+					n = len(cr.lo)
+					break
+				}
+			}
+			if i+n > len(bb) {
+				n = len(bb) - i
+			}
+		}
+		var c uint32
+		for _, b := range bb[i : i+n] {
+			c = c<<8 | uint32(b)
+		}
+		cc = append(cc, textCode{code: c, len: n})
+		i += n
+	}
+
+	return cc
+}
+
+// text returns the Unicode text for code.
+func (tf *textFont) text(code uint32) string {
+	if s, ok := tf.toUnicode[code]; ok {
+		return s
+	}
+	if !tf.type0 {
+		if s, ok := tf.encoding[code]; ok {
+			return s
+		}
+		return string(charmap.Windows1252.DecodeByte(byte(code)))
+	}
+	if tf.ucs2 {
+		return string(rune(code))
+	}
+	return string(utf8.RuneError)
+}
+
+// width returns the horizontal displacement for code in text space units.
+func (tf *textFont) width(code uint32) float64 {
+	if w, ok := tf.widths[code]; ok {
+		return w * tf.scale
+	}
+	if tf.coreFont != "" {
+		return float64(metrics.CoreFontCharWidth(tf.coreFont, int(code))) * tf.scale
+	}
+	return tf.missWidth * tf.scale
+}
+
+var (
+	glyphNameRunes     map[string]rune
+	glyphNameRunesOnce sync.Once
+)
+
+func initGlyphNameRunes() {
+	glyphNameRunes = map[string]rune{
+		"ff": 'ﬀ', "fi": 'ﬁ', "fl": 'ﬂ', "ffi": 'ﬃ', "ffl": 'ﬄ',
+		"quoteleft": '‘', "quoteright": '’', "minus": '−', "fraction": '⁄',
+		"dotlessi": 'ı', "Lslash": 'Ł', "lslash": 'ł', "nbspace": ' ', "sfthyphen": '­',
+	}
+	for c, name := range metrics.WinAnsiGlyphMap {
+		if _, ok := glyphNameRunes[name]; !ok && c < 256 {
+			glyphNameRunes[name] = charmap.Windows1252.DecodeByte(byte(c))
+		}
+	}
+}
+
+// glyphNameText returns the Unicode text for a glyph name as used in encoding differences.
+func glyphNameText(name string) string {
+	glyphNameRunesOnce.Do(initGlyphNameRunes)
+
+	// Strip any suffix, eg. "a.sc"
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i]
+	}
+
+	if r, ok := glyphNameRunes[name]; ok {
+		return string(r)
+	}
+
+	// "uniXXXX" may hold a sequence of code points.
+	if s := strings.TrimPrefix(name, "uni"); len(s) != len(name) && len(s) > 0 && len(s)%4 == 0 {
+		var sb strings.Builder
+		for ; len(s) > 0; s = s[4:] {
+			i, err := strconv.ParseUint(s[:4], 16, 16)
+			if err != nil {
+				return ""
+			}
+			sb.WriteRune(rune(i))
+		}
+		return sb.String()
+	}
+
+	if s := strings.TrimPrefix(name, "u"); len(s) != len(name) && len(s) >= 4 && len(s) <= 6 {
+		if i, err := strconv.ParseUint(s, 16, 32); err == nil {
+			return string(rune(i))
+		}
+	}
+
+	return ""
+}
+
+// baseEncoding returns the text for all codes of a predefined simple font encoding.
+func baseEncoding(name string) map[uint32]string {
+	m := map[uint32]string{}
+
+	switch name {
+
+	case "MacRomanEncoding":
+		for c := 0; c < 256; c++ {
+			m[uint32(c)] = string(charmap.Macintosh.DecodeByte(byte(c)))
+		}
+
+	case "StandardEncoding":
+		for c := 32; c < 127; c++ {
+			m[uint32(c)] = string(rune(c))
+		}
+		m[0x27], m[0x60] = "’", "‘"
+
+	default:
+		for c := 0; c < 256; c++ {
+			m[uint32(c)] = string(charmap.Windows1252.DecodeByte(byte(c)))
+		}
+	}
+
+	return m
+}
+
+func (tf *textFont) parseEncoding(ctx *model.Context, o types.Object) error {
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return err
+	}
+
+	switch o := o.(type) {
+
+	case types.Name:
+		tf.encoding = baseEncoding(o.Value())
+
+	case types.Dict:
+		baseEnc := ""
+		if s := o.NameEntry("BaseEncoding"); s != nil {
+			baseEnc = *s
+		}
+		tf.encoding = baseEncoding(baseEnc)
+		diffs, err := ctx.DereferenceArray(o["Differences"])
+		if err != nil {
+			return err
+		}
+		c := 0
+		for _, o1 := range diffs {
+			switch o1 := o1.(type) {
+			case types.Integer:
+				c = o1.Value()
+			case types.Name:
+				if s := glyphNameText(o1.Value()); s != "" {
+					tf.encoding[uint32(c)] = s
+				}
+				c++
+			}
+		}
+
+	default:
+		tf.encoding = baseEncoding("")
+	}
+
+	return nil
+}
+
+// cmapInt returns the integer value of a CMap hex string.
+func cmapInt(o types.Object) (uint32, []byte, bool) {
+	hl, ok := o.(types.HexLiteral)
+	if !ok {
+		return 0, nil, false
+	}
+	bb, err := hl.Bytes()
+	if err != nil || len(bb) == 0 || len(bb) > 4 {
+		return 0, nil, false
+	}
+	var i uint32
+	for _, b := range bb {
+		i = i<<8 | uint32(b)
+	}
+	return i, bb, true
+}
+
+// cmapText returns the text of a UTF-16BE encoded CMap hex string.
+func cmapText(o types.Object) (string, bool) {
+	hl, ok := o.(types.HexLiteral)
+	if !ok {
+		return "", false
+	}
+	bb, err := hl.Bytes()
+	if err != nil || len(bb)%2 != 0 {
+		return "", false
+	}
+	rr := make([]uint16, len(bb)/2)
+	for i := range rr {
+		rr[i] = uint16(bb[2*i])<<8 | uint16(bb[2*i+1])
+	}
+	return string(utf16.Decode(rr)), true
+}
+
+// incrementText increments the last code point of s by i as required for bfrange entries.
+func incrementText(s string, i uint32) string {
+	rr := []rune(s)
+	if len(rr) == 0 {
+		return s
+	}
+	rr[len(rr)-1] += rune(i)
+	return string(rr)
+}
+
+// parseCMap parses codespace ranges and Unicode mappings from an embedded CMap.
+func parseCMap(bb []byte) ([]codespaceRange, map[uint32]string, error) {
+	// Procedures are of no interest here and not supported by the content stream parser.
+	s := strings.NewReplacer("{", " ", "}", " ").Replace(string(bb))
+
+	ops, err := ParseContentStream([]byte(s))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var crs []codespaceRange
+	m := map[uint32]string{}
+
+	for _, op := range ops {
+		oo := op.Operands
+
+		switch op.Operator {
+
+		case "endcodespacerange":
+			for i := 0; i+1 < len(oo); i += 2 {
+				_, lo, ok1 := cmapInt(oo[i])
+				_, hi, ok2 := cmapInt(oo[i+1])
+				if ok1 && ok2 && len(lo) == len(hi) {
+					crs = append(crs, codespaceRange{lo: lo, hi: hi})
+				}
+			}
+
+		case "endbfchar":
+			for i := 0; i+1 < len(oo); i += 2 {
+				c, _, ok1 := cmapInt(oo[i])
+				s, ok2 := cmapText(oo[i+1])
+				if ok1 && ok2 {
+					m[c] = s
+				}
+			}
+
+		case "endbfrange":
+			for i := 0; i+2 < len(oo); i += 3 {
+				lo, _, ok1 := cmapInt(oo[i])
+				hi, _, ok2 := cmapInt(oo[i+1])
+				if !ok1 || !ok2 || hi < lo || hi-lo > 0xFFFF {
+					continue
+				}
+				switch dst := oo[i+2].(type) {
+				case types.HexLiteral:
+					s, ok := cmapText(dst)
+					if !ok {
+						continue
+					}
+					for c := lo; c <= hi; c++ {
+						m[c] = incrementText(s, c-lo)
+					}
+				case types.Array:
+					for j, o := range dst {
+						if s, ok := cmapText(o); ok && lo+uint32(j) <= hi {
+							m[lo+uint32(j)] = s
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return crs, m, nil
+}
+
+func streamContent(ctx *model.Context, o types.Object) ([]byte, error) {
+	if o == nil {
+		return nil, nil
+	}
+	sd, _, err := ctx.DereferenceStreamDict(o)
+	if err != nil || sd == nil {
+		return nil, err
+	}
+	if err := sd.Decode(); err != nil {
+		return nil, err
+	}
+	return sd.Content, nil
+}
+
+func (tf *textFont) parseToUnicode(ctx *model.Context, d types.Dict) error {
+	bb, err := streamContent(ctx, d["ToUnicode"])
+	if err != nil || bb == nil {
+		return err
+	}
+
+	crs, m, err := parseCMap(bb)
+	if err != nil {
+		// Fall back to the font encoding.
+		return nil
+	}
+
+	tf.toUnicode = m
+	if len(tf.codespace) == 0 {
+		tf.codespace = crs
+	}
+
+	return nil
+}
+
+// cidWidths parses the W array of a CIDFont.
+func cidWidths(ctx *model.Context, o types.Object) (map[uint32]float64, error) {
+	a, err := ctx.DereferenceArray(o)
+	if err != nil || a == nil {
+		return nil, err
+	}
+
+	m := map[uint32]float64{}
+
+	for i := 0; i < len(a); {
+		c1, ok := numberValue(a[i])
+		if !ok || i+1 >= len(a) {
+			break
+		}
+
+		o, err := ctx.Dereference(a[i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		if ww, ok := o.(types.Array); ok {
+			for j, w := range ww {
+				if f, ok := numberValue(w); ok {
+					m[uint32(c1)+uint32(j)] = f
+				}
+			}
+			i += 2
+			continue
+		}
+
+		if i+2 >= len(a) {
+			break
+		}
+		c2, ok1 := numberValue(o)
+		w, ok2 := numberValue(a[i+2])
+		if ok1 && ok2 && c2 >= c1 && c2-c1 <= 0xFFFF {
+			for c := uint32(c1); c <= uint32(c2); c++ {
+				m[c] = w
+			}
+		}
+		i += 3
+	}
+
+	return m, nil
+}
+
+func (tf *textFont) parseType0(ctx *model.Context, d types.Dict) error {
+	tf.type0 = true
+	tf.missWidth = 1000
+
+	o, err := ctx.Dereference(d["Encoding"])
+	if err != nil {
+		return err
+	}
+
+	switch o := o.(type) {
+	case types.Name:
+		tf.ucs2 = strings.Contains(o.Value(), "UCS2") || strings.Contains(o.Value(), "UTF16")
+	case types.StreamDict:
+		if err := o.Decode(); err == nil {
+			if crs, _, err := parseCMap(o.Content); err == nil {
+				tf.codespace = crs
+			}
+		}
+	}
+
+	a, err := ctx.DereferenceArray(d["DescendantFonts"])
+	if err != nil || len(a) == 0 {
+		return err
+	}
+
+	df, err := ctx.DereferenceDict(a[0])
+	if err != nil || df == nil {
+		return err
+	}
+
+	if dw, ok := numberValue(df["DW"]); ok {
+		tf.missWidth = dw
+	}
+
+	tf.widths, err = cidWidths(ctx, df["W"])
+
+	return err
+}
+
+func (tf *textFont) parseSimple(ctx *model.Context, d types.Dict) error {
+	if err := tf.parseEncoding(ctx, d["Encoding"]); err != nil {
+		return err
+	}
+
+	if tf.type3 {
+		if a, err := ctx.DereferenceArray(d["FontMatrix"]); err == nil && len(a) == 6 {
+			if f, ok := numberValue(a[0]); ok {
+				tf.scale = f
+			}
+		}
+	}
+
+	if fd, err := ctx.DereferenceDict(d["FontDescriptor"]); err == nil && fd != nil {
+		if mw, ok := numberValue(fd["MissingWidth"]); ok {
+			tf.missWidth = mw
+		}
+	}
+
+	ww, err := ctx.DereferenceArray(d["Widths"])
+	if err != nil {
+		return err
+	}
+
+	if ww == nil {
+		baseFont := d.NameEntry("BaseFont")
+		if baseFont != nil {
+			if s := *baseFont; font.IsCoreFont(s) {
+				tf.coreFont = s
+			}
+		}
+		return nil
+	}
+
+	first := 0
+	if i := d.IntEntry("FirstChar"); i != nil {
+		first = *i
+	}
+
+	tf.widths = map[uint32]float64{}
+	for i, o := range ww {
+		o, err := ctx.Dereference(o)
+		if err != nil {
+			return err
+		}
+		if w, ok := numberValue(o); ok {
+			tf.widths[uint32(first+i)] = w
+		}
+	}
+
+	return nil
+}
+
+// newTextFont returns a textFont for the font dict d.
+func newTextFont(ctx *model.Context, d types.Dict) (*textFont, error) {
+	tf := &textFont{scale: 0.001}
+
+	subType := d.Subtype()
+
+	var err error
+
+	switch {
+	case subType != nil && *subType == "Type0":
+		err = tf.parseType0(ctx, d)
+	default:
+		tf.type3 = subType != nil && *subType == "Type3"
+		err = tf.parseSimple(ctx, d)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tf.parseToUnicode(ctx, d); err != nil {
+		return nil, err
+	}
+
+	return tf, nil
+}
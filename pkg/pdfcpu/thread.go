@@ -0,0 +1,355 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// Article threads live in the Threads array of the document catalog (see 12.4.3).
+// A thread points to the first of a circular, doubly linked list of beads.
+// A bead is a rectangular region of a page, each page lists its beads in its B array.
+
+// Bead represents a rectangular region of a page belonging to an article.
+type Bead struct {
+	PageNr int
+	Rect   *types.Rectangle
+}
+
+// Article represents an article thread defining the reading flow of an article across regions of pages.
+type Article struct {
+	Title   string
+	Author  string
+	Subject string
+	Beads   []Bead
+}
+
+// String returns a multi line representation of a.
+func (a Article) String() string {
+	s := fmt.Sprintf("%q: %d beads", a.Title, len(a.Beads))
+	for _, b := range a.Beads {
+		s += fmt.Sprintf("\n  page %d: %s", b.PageNr, b.Rect)
+	}
+	return s
+}
+
+func pageNrsByObjNr(ctx *model.Context) (map[int]int, error) {
+	m := map[int]int{}
+	for i := 1; i <= ctx.PageCount; i++ {
+		ir, err := ctx.PageDictIndRef(i)
+		if err != nil {
+			return nil, err
+		}
+		if ir != nil {
+			m[ir.ObjectNumber.Value()] = i
+		}
+	}
+	return m, nil
+}
+
+func articleInfo(ctx *model.Context, d types.Dict, a *Article) error {
+	o, found := d.Find("I")
+	if !found || o == nil {
+		return nil
+	}
+
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return err
+	}
+
+	for k, p := range map[string]*string{"Title": &a.Title, "Author": &a.Author, "Subject": &a.Subject} {
+		if o, found := d.Find(k); found {
+			if *p, err = ctx.DereferenceText(o); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func articleBeads(ctx *model.Context, first types.IndirectRef, pageNrs map[int]int) ([]Bead, error) {
+	bb := []Bead{}
+	visited := map[int]bool{}
+
+	ir := &first
+	for ir != nil && !visited[ir.ObjectNumber.Value()] {
+		objNr := ir.ObjectNumber.Value()
+		visited[objNr] = true
+
+		d, err := ctx.DereferenceDict(*ir)
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			return nil, errors.Errorf("pdfcpu: corrupt bead obj#%d", objNr)
+		}
+
+		var b Bead
+
+		if pageIndRef := d.IndirectRefEntry("P"); pageIndRef != nil {
+			b.PageNr = pageNrs[pageIndRef.ObjectNumber.Value()]
+		}
+
+		a, err := ctx.DereferenceArray(d["R"])
+		if err != nil {
+			return nil, err
+		}
+		if b.Rect, err = types.RectForArray(a); err != nil {
+			return nil, errors.Errorf("pdfcpu: corrupt bead obj#%d: %v", objNr, err)
+		}
+
+		bb = append(bb, b)
+
+		ir = d.IndirectRefEntry("N")
+	}
+
+	return bb, nil
+}
+
+// Articles returns all article threads of ctx.
+func Articles(ctx *model.Context) ([]Article, error) {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	o, found := rootDict.Find("Threads")
+	if !found {
+		return nil, nil
+	}
+
+	threads, err := ctx.DereferenceArray(o)
+	if err != nil || len(threads) == 0 {
+		return nil, err
+	}
+
+	pageNrs, err := pageNrsByObjNr(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aa := []Article{}
+
+	for _, o := range threads {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			continue
+		}
+
+		var a Article
+
+		if err := articleInfo(ctx, d, &a); err != nil {
+			return nil, err
+		}
+
+		if first := d.IndirectRefEntry("F"); first != nil {
+			if a.Beads, err = articleBeads(ctx, *first, pageNrs); err != nil {
+				return nil, err
+			}
+		}
+
+		aa = append(aa, a)
+	}
+
+	return aa, nil
+}
+
+func articleInfoDict(a Article) (types.Dict, error) {
+	d := types.NewDict()
+	for _, e := range []struct{ k, v string }{{"Title", a.Title}, {"Author", a.Author}, {"Subject", a.Subject}} {
+		if e.v == "" {
+			continue
+		}
+		s, err := types.EscapeUTF16String(e.v)
+		if err != nil {
+			return nil, err
+		}
+		d.InsertString(e.k, *s)
+	}
+	return d, nil
+}
+
+func addBeadToPage(ctx *model.Context, pageDict types.Dict, ir types.IndirectRef) error {
+	o, found := pageDict.Find("B")
+	if !found {
+		pageDict["B"] = types.Array{ir}
+		return nil
+	}
+
+	a, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return err
+	}
+
+	pageDict["B"] = append(a, ir)
+
+	return nil
+}
+
+func addArticle(ctx *model.Context, a Article) (*types.IndirectRef, error) {
+	if len(a.Beads) == 0 {
+		return nil, errors.Errorf("pdfcpu: article %q: missing beads", a.Title)
+	}
+
+	n := len(a.Beads)
+	dd := make([]types.Dict, n)
+	irs := make([]types.IndirectRef, n)
+
+	for i, b := range a.Beads {
+		if b.PageNr < 1 || b.PageNr > ctx.PageCount {
+			return nil, errors.Errorf("pdfcpu: article %q: invalid page number: %d", a.Title, b.PageNr)
+		}
+		if b.Rect == nil {
+			return nil, errors.Errorf("pdfcpu: article %q: missing bead rectangle", a.Title)
+		}
+
+		pageDict, pageIndRef, _, err := ctx.PageDict(b.PageNr, false)
+		if err != nil {
+			return nil, err
+		}
+
+		d := types.Dict(map[string]types.Object{
+			"Type": types.Name("Bead"),
+			"P":    *pageIndRef,
+			"R":    b.Rect.Array(),
+		})
+
+		ir, err := ctx.IndRefForNewObject(d)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addBeadToPage(ctx, pageDict, *ir); err != nil {
+			return nil, err
+		}
+
+		dd[i], irs[i] = d, *ir
+	}
+
+	threadDict := types.Dict(map[string]types.Object{
+		"Type": types.Name("Thread"),
+		"F":    irs[0],
+	})
+
+	infoDict, err := articleInfoDict(a)
+	if err != nil {
+		return nil, err
+	}
+	if len(infoDict) > 0 {
+		threadDict["I"] = infoDict
+	}
+
+	threadIndRef, err := ctx.IndRefForNewObject(threadDict)
+	if err != nil {
+		return nil, err
+	}
+
+	// Beads form a circular list, the first bead refers back to its thread.
+	dd[0]["T"] = *threadIndRef
+	for i := range dd {
+		dd[i]["N"] = irs[(i+1)%n]
+		dd[i]["V"] = irs[(i+n-1)%n]
+	}
+
+	return threadIndRef, nil
+}
+
+// AddArticles appends article threads aa to ctx.
+func AddArticles(ctx *model.Context, aa []Article) error {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return err
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+
+	var threads types.Array
+
+	o, found := rootDict.Find("Threads")
+	if found {
+		if threads, err = ctx.DereferenceArray(o); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range aa {
+		ir, err := addArticle(ctx, a)
+		if err != nil {
+			return err
+		}
+		threads = append(threads, *ir)
+	}
+
+	if ir, ok := o.(types.IndirectRef); ok {
+		if entry, ok := ctx.FindTableEntryForIndRef(&ir); ok {
+			entry.Object = threads
+			return nil
+		}
+	}
+
+	ir, err := ctx.IndRefForNewObject(threads)
+	if err != nil {
+		return err
+	}
+	rootDict["Threads"] = *ir
+
+	return nil
+}
+
+// RemoveArticles removes all article threads and their beads from ctx.
+func RemoveArticles(ctx *model.Context) (bool, error) {
+	if err := ctx.EnsurePageCount(); err != nil {
+		return false, err
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return false, err
+	}
+
+	if _, found := rootDict.Find("Threads"); !found {
+		return false, nil
+	}
+	rootDict.Delete("Threads")
+
+	for i := 1; i <= ctx.PageCount; i++ {
+		pageDict, _, _, err := ctx.PageDict(i, false)
+		if err != nil {
+			return false, err
+		}
+		if pageDict != nil {
+			pageDict.Delete("B")
+		}
+	}
+
+	return true, nil
+}
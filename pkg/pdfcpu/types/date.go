@@ -49,6 +49,11 @@ func prevalidateDate(s string, relaxed bool) (string, bool) {
 		s = utf16s
 	}
 
+	// PDF 2.0 utf8 text string.
+	if IsUTF8([]byte(s)) {
+		s = decodeUTF8String([]byte(s))
+	}
+
 	// Remove trailing 0x00
 	s = strings.TrimRight(s, "\x00")
 
@@ -126,3 +126,34 @@ func TestDecodeName(t *testing.T) {
 		})
 	}
 }
+
+func TestTextStringUTF8(t *testing.T) {
+	tests := []struct {
+		input    Object
+		expected string
+	}{
+		{
+			StringLiteral("\xEF\xBB\xBFGr\xC3\xBC\xC3\x9Fe"),
+			"Grüße",
+		},
+		{
+			NewHexLiteral([]byte("\xEF\xBB\xBF\xE2\x82\xAC 5")),
+			"€ 5",
+		},
+		{
+			StringLiteral("\xFE\xFF\x00\xFC"),
+			"ü",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			actual, err := StringOrHexLiteral(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *actual != test.expected {
+				t.Errorf("got %s; want %s", *actual, test.expected)
+			}
+		})
+	}
+}
@@ -46,6 +46,20 @@ func IsUTF16BE(b []byte) bool {
 	return b[0] == 0xFE && b[1] == 0xFF
 }
 
+// IsUTF8 checks for the UTF-8 byte order mark introduced with PDF 2.0.
+func IsUTF8(b []byte) bool {
+	return len(b) >= 3 && b[0] == 0xEF && b[1] == 0xBB && b[2] == 0xBF
+}
+
+func decodeUTF8String(b []byte) string {
+	// Strip BOM.
+	s := string(b[3:])
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	return s
+}
+
 func decodeUTF16String(b []byte) (string, error) {
 	// Convert UTF-16 to UTF-8
 	// We only accept big endian byte order.
@@ -133,6 +147,9 @@ func StringLiteralToString(sl StringLiteral) (string, error) {
 	if IsUTF16BE(bb) {
 		return decodeUTF16String(bb)
 	}
+	if IsUTF8(bb) {
+		return decodeUTF8String(bb), nil
+	}
 	// if no acceptable UTF16 encoding found, ensure utf8 encoding.
 	s := string(bb)
 	if !utf8.ValidString(s) {
@@ -150,6 +167,9 @@ func HexLiteralToString(hl HexLiteral) (string, error) {
 	if IsUTF16BE(bb) {
 		return decodeUTF16String(bb)
 	}
+	if IsUTF8(bb) {
+		return decodeUTF8String(bb), nil
+	}
 	return string(bb), nil
 }
 
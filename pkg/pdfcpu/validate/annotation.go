@@ -616,7 +616,7 @@ func validateEntryIT(xRefTable *model.XRefTable, d types.Dict, dictName string,
 			return s == "PolygonCloud"
 		}
 
-		if xRefTable.Version() == model.V17 {
+		if xRefTable.Version() >= model.V17 {
 			if types.MemberOf(s, []string{"PolygonCloud", "PolyLineDimension", "PolygonDimension"}) {
 				return true
 			}
@@ -1191,6 +1191,21 @@ func validateRichMediaAnnotation(xRefTable *model.XRefTable, d types.Dict, dictN
 	return nil
 }
 
+func validateAnnotationDictProjection(xRefTable *model.XRefTable, d types.Dict, dictName string) error {
+
+	// see 12.5.6.24
+
+	// ExData, optional, 3D measurement data dict
+	d1, err := validateDictEntry(xRefTable, d, dictName, "ExData", OPTIONAL, model.V20, nil)
+	if err != nil || d1 == nil {
+		return err
+	}
+
+	_, err = validateNameEntry(xRefTable, d1, "ExData", "Subtype", REQUIRED, model.V20, func(s string) bool { return s == "3DM" || s == "Markup3D" })
+
+	return err
+}
+
 func validateExDataDict(xRefTable *model.XRefTable, d types.Dict) error {
 
 	dictName := "ExData"
@@ -1558,6 +1573,7 @@ func validateAnnotationDictConcrete(xRefTable *model.XRefTable, d types.Dict, di
 		"3D":             {validateAnnotationDict3D, model.V16, false},
 		"Redact":         {validateAnnotationDictRedact, model.V17, true},
 		"RichMedia":      {validateRichMediaAnnotation, model.V17, false},
+		"Projection":     {validateAnnotationDictProjection, model.V20, true},
 	} {
 		if subtype.Value() == k {
 
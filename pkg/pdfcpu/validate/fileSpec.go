@@ -387,10 +387,90 @@ func validateFileSpecDict(xRefTable *model.XRefTable, d types.Dict) error {
 
 	// CI, optional, collection item dict, since V1.7
 	_, err = validateDictEntry(xRefTable, d, dictName, "CI", OPTIONAL, model.V17, nil)
+	if err != nil {
+		return err
+	}
+
+	// AFRelationship, optional, name, since V2.0
+	sinceVersion = model.V20
+	if xRefTable.ValidationMode == model.ValidationRelaxed {
+		// PDF/A-3
+		sinceVersion = model.V17
+	}
+	validateAFRelationship := func(s string) bool {
+		return types.MemberOf(s, []string{"Source", "Data", "Alternative", "Supplement", "EncryptedPayload", "FormData", "Schema", "Unspecified"})
+	}
+	_, err = validateNameEntry(xRefTable, d, dictName, "AFRelationship", OPTIONAL, sinceVersion, validateAFRelationship)
+	if err != nil {
+		return err
+	}
+
+	// EP, optional, encrypted payload dict, since V2.0
+	d1, err := validateDictEntry(xRefTable, d, dictName, "EP", OPTIONAL, model.V20, nil)
+	if err != nil || d1 == nil {
+		return err
+	}
+
+	return validateEncryptedPayloadDict(xRefTable, d1)
+}
+
+func validateEncryptedPayloadDict(xRefTable *model.XRefTable, d types.Dict) error {
+
+	// see 7.6.7 Unencrypted wrapper document
+
+	dictName := "encryptedPayloadDict"
+
+	// Type, optional, name
+	_, err := validateNameEntry(xRefTable, d, dictName, "Type", OPTIONAL, model.V20, func(s string) bool { return s == "EncryptedPayload" })
+	if err != nil {
+		return err
+	}
+
+	// Subtype, required, name of the cryptographic filter used to encrypt the payload document.
+	_, err = validateNameEntry(xRefTable, d, dictName, "Subtype", REQUIRED, model.V20, nil)
+	if err != nil {
+		return err
+	}
+
+	// Version, optional, text string
+	_, err = validateStringEntry(xRefTable, d, dictName, "Version", OPTIONAL, model.V20, nil)
 
 	return err
 }
 
+func validateAssociatedFiles(xRefTable *model.XRefTable, d types.Dict, dictName string, required bool, sinceVersion model.Version) error {
+
+	// see 14.13 Associated files
+
+	if xRefTable.ValidationMode == model.ValidationRelaxed {
+		// PDF/A-3
+		sinceVersion = model.V17
+	}
+
+	a, err := validateArrayEntry(xRefTable, d, dictName, "AF", required, sinceVersion, nil)
+	if err != nil || a == nil {
+		return err
+	}
+
+	for _, o := range a {
+
+		d1, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+
+		if d1 == nil {
+			continue
+		}
+
+		if err = validateFileSpecDict(xRefTable, d1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func validateFileSpecification(xRefTable *model.XRefTable, o types.Object) (types.Object, error) {
 
 	// See 7.11.4
@@ -515,6 +515,20 @@ func validatePageEntryUserUnit(xRefTable *model.XRefTable, d types.Dict, require
 	return err
 }
 
+func validatePageEntryAF(xRefTable *model.XRefTable, d types.Dict, required bool, sinceVersion model.Version) error {
+
+	// AF, optional, array of file spec dicts, since V2.0
+	return validateAssociatedFiles(xRefTable, d, "pagesDict", required, sinceVersion)
+}
+
+func validatePageEntryDPart(xRefTable *model.XRefTable, d types.Dict, required bool, sinceVersion model.Version) error {
+
+	// DPart, optional, indRef of leaf DPart dict, since V2.0
+	_, err := validateIndRefEntry(xRefTable, d, "pagesDict", "DPart", required, sinceVersion)
+
+	return err
+}
+
 func validateNumberFormatDict(xRefTable *model.XRefTable, d types.Dict, sinceVersion model.Version) error {
 
 	dictName := "numberFormatDict"
@@ -846,6 +860,8 @@ func validatePageDict(xRefTable *model.XRefTable, d types.Dict, objNumber int, h
 		{validatePageEntryPresSteps, OPTIONAL, model.V15},
 		{validatePageEntryUserUnit, OPTIONAL, model.V16},
 		{validatePageEntryVP, OPTIONAL, model.V16},
+		{validatePageEntryAF, OPTIONAL, model.V20},
+		{validatePageEntryDPart, OPTIONAL, model.V20},
 	} {
 		err = f.validate(xRefTable, d, f.required, f.sinceVersion)
 		if err != nil {
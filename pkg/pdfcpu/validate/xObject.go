@@ -392,24 +392,63 @@ func validateAlternateImageStreamDicts(xRefTable *model.XRefTable, d types.Dict,
 
 	for _, o := range a {
 
-		sd, err := validateStreamDict(xRefTable, o)
+		o, err := xRefTable.Dereference(o)
 		if err != nil {
 			return err
 		}
 
-		if sd == nil {
+		switch o := o.(type) {
+
+		case nil:
 			continue
-		}
 
-		err = validateImageStreamDict(xRefTable, sd, isAlternateImageStreamDict)
-		if err != nil {
-			return err
+		case types.Dict:
+			if err = validateAlternateImageDict(xRefTable, o); err != nil {
+				return err
+			}
+
+		case types.StreamDict:
+			// Some writers list the alternate image streams themselves.
+			if xRefTable.ValidationMode != model.ValidationRelaxed {
+				return errors.Errorf("pdfcpu: validateAlternateImageStreamDicts: dict=%s entry \"%s\" expecting alternate image dict", dictName, entryName)
+			}
+			if err = validateImageStreamDict(xRefTable, &o, isAlternateImageStreamDict); err != nil {
+				return err
+			}
+
+		default:
+			return errors.Errorf("pdfcpu: validateAlternateImageStreamDicts: dict=%s entry \"%s\" invalid type", dictName, entryName)
 		}
 	}
 
 	return nil
 }
 
+func validateAlternateImageDict(xRefTable *model.XRefTable, d types.Dict) error {
+
+	// see 8.9.5.4 Alternate Images
+
+	dictName := "alternateImageDict"
+
+	// Image, stream, required
+	sd, err := validateStreamDictEntry(xRefTable, d, dictName, "Image", REQUIRED, model.V13, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = validateImageStreamDict(xRefTable, sd, isAlternateImageStreamDict); err != nil {
+		return err
+	}
+
+	// DefaultForPrinting, boolean, optional
+	if _, err = validateBooleanEntry(xRefTable, d, dictName, "DefaultForPrinting", OPTIONAL, model.V13, nil); err != nil {
+		return err
+	}
+
+	// OC, dict, optional since V1.5
+	return validateEntryOC(xRefTable, d, dictName, "OC", OPTIONAL, model.V15)
+}
+
 func validateImageStreamDictPart1(xRefTable *model.XRefTable, sd *types.StreamDict, dictName string) (isImageMask bool, err error) {
 
 	// Width, integer, required
@@ -892,6 +892,140 @@ func validateNeedsRendering(xRefTable *model.XRefTable, rootDict types.Dict, req
 	return err
 }
 
+func validateDSS(xRefTable *model.XRefTable, rootDict types.Dict, required bool, sinceVersion model.Version) error {
+	// => 12.8.4.3 Document Security Store
+
+	d, err := validateDictEntry(xRefTable, rootDict, "rootDict", "DSS", required, sinceVersion, nil)
+	if err != nil || d == nil {
+		return err
+	}
+
+	dictName := "DSS"
+
+	_, err = validateNameEntry(xRefTable, d, dictName, "Type", OPTIONAL, sinceVersion, func(s string) bool { return s == "DSS" })
+	if err != nil {
+		return err
+	}
+
+	// VRI, optional, dict of validation-related information dicts
+	if _, err = validateDictEntry(xRefTable, d, dictName, "VRI", OPTIONAL, sinceVersion, nil); err != nil {
+		return err
+	}
+
+	// Certs, CRLs, OCSPs: optional, arrays of indirect references to streams
+	for _, entryName := range []string{"Certs", "CRLs", "OCSPs"} {
+		if _, err = validateIndRefArrayEntry(xRefTable, d, dictName, entryName, OPTIONAL, sinceVersion, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateRootAF(xRefTable *model.XRefTable, rootDict types.Dict, required bool, sinceVersion model.Version) error {
+	// => 14.13 Associated Files
+	return validateAssociatedFiles(xRefTable, rootDict, "rootDict", required, sinceVersion)
+}
+
+func validateDPart(xRefTable *model.XRefTable, d types.Dict, sinceVersion model.Version, level int) error {
+	// => 14.12 Document parts
+
+	if level > 32 {
+		return errors.New("pdfcpu: validateDPart: document part hierarchy too deep")
+	}
+
+	dictName := "DPart"
+
+	_, err := validateNameEntry(xRefTable, d, dictName, "Type", OPTIONAL, sinceVersion, func(s string) bool { return s == "DPart" })
+	if err != nil {
+		return err
+	}
+
+	// DPM, optional, document part metadata dict
+	if _, err = validateDictEntry(xRefTable, d, dictName, "DPM", OPTIONAL, sinceVersion, nil); err != nil {
+		return err
+	}
+
+	// DParts, array of arrays of DPart dicts, not allowed for leaf nodes.
+	a, err := validateArrayEntry(xRefTable, d, dictName, "DParts", OPTIONAL, sinceVersion, nil)
+	if err != nil {
+		return err
+	}
+
+	if a == nil {
+
+		// Start, required for leaf nodes, indRef of page dict
+		if _, err = validateIndRefEntry(xRefTable, d, dictName, "Start", REQUIRED, sinceVersion); err != nil {
+			return err
+		}
+
+		// End, optional, indRef of page dict
+		_, err = validateIndRefEntry(xRefTable, d, dictName, "End", OPTIONAL, sinceVersion)
+
+		return err
+	}
+
+	for _, o := range a {
+
+		a1, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+
+		for _, o1 := range a1 {
+
+			d1, err := xRefTable.DereferenceDict(o1)
+			if err != nil {
+				return err
+			}
+
+			if d1 == nil {
+				continue
+			}
+
+			if err = validateDPart(xRefTable, d1, sinceVersion, level+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateDPartRoot(xRefTable *model.XRefTable, rootDict types.Dict, required bool, sinceVersion model.Version) error {
+	// => 14.12 Document parts
+
+	d, err := validateDictEntry(xRefTable, rootDict, "rootDict", "DPartRoot", required, sinceVersion, nil)
+	if err != nil || d == nil {
+		return err
+	}
+
+	dictName := "DPartRoot"
+
+	_, err = validateNameEntry(xRefTable, d, dictName, "Type", OPTIONAL, sinceVersion, func(s string) bool { return s == "DPartRoot" })
+	if err != nil {
+		return err
+	}
+
+	// NodeNameList, optional, array of names
+	if _, err = validateNameArrayEntry(xRefTable, d, dictName, "NodeNameList", OPTIONAL, sinceVersion, nil); err != nil {
+		return err
+	}
+
+	// RecordLevel, optional, integer
+	if _, err = validateIntegerEntry(xRefTable, d, dictName, "RecordLevel", OPTIONAL, sinceVersion, func(i int) bool { return i >= 0 }); err != nil {
+		return err
+	}
+
+	// DPartRootNode, required, DPart dict
+	d1, err := validateDictEntry(xRefTable, d, dictName, "DPartRootNode", REQUIRED, sinceVersion, nil)
+	if err != nil {
+		return err
+	}
+
+	return validateDPart(xRefTable, d1, sinceVersion, 0)
+}
+
 func logURIError(xRefTable *model.XRefTable, pages []int) {
 	fmt.Println()
 	for _, page := range pages {
@@ -1005,6 +1139,9 @@ func validateRootObject(xRefTable *model.XRefTable) error {
 	// Requirements         y   1.7         array           => 12.10 Document Requirements
 	// Collection           y   1.7         dict            => 12.3.5 Collections
 	// NeedsRendering       y   1.7         boolean         => XML Forms Architecture (XFA) Spec.
+	// DSS                  y   2.0         dict            => 12.8.4.3 Document Security Store
+	// AF                   y   2.0         array           => 14.13 Associated Files
+	// DPartRoot            y   2.0         dict            => 14.12 Document Parts
 
 	d, err := xRefTable.Catalog()
 	if err != nil {
@@ -1055,6 +1192,9 @@ func validateRootObject(xRefTable *model.XRefTable) error {
 		{validateRequirements, OPTIONAL, model.V17},
 		{validateCollection, OPTIONAL, model.V17},
 		{validateNeedsRendering, OPTIONAL, model.V17},
+		{validateDSS, OPTIONAL, model.V20},
+		{validateRootAF, OPTIONAL, model.V20},
+		{validateDPartRoot, OPTIONAL, model.V20},
 	} {
 		if !f.required && xRefTable.Version() < f.sinceVersion {
 			// Ignore optional fields if currentVersion < sinceVersion
@@ -94,16 +94,37 @@ func Write(ctx *model.Context) (err error) {
 
 	}
 
-	if err = prepareContextForWriting(ctx); err != nil {
+	if ctx.WriteLinearized && !ctx.Write.Streamed && !ctx.Write.Increment && len(ctx.Write.SelectedPages) == 0 {
+		err = writeLinearized(ctx)
+	} else {
+		err = writeSections(ctx)
+	}
+
+	if err != nil {
 		return err
 	}
 
-	// Since we support PDF Collections (since V1.7) for file attachments
-	// we need to generate V1.7 PDF files.
-	if err = writeHeader(ctx.Write, model.V17); err != nil {
+	if err = setFileSizeOfWrittenFile(ctx.Write); err != nil {
 		return err
 	}
 
+	if ctx.Read != nil {
+		ctx.Write.BinaryImageSize = ctx.Read.BinaryImageSize
+		ctx.Write.BinaryFontSize = ctx.Read.BinaryFontSize
+		logWriteStats(ctx)
+	}
+
+	return nil
+}
+
+// writeSections writes header, body, cross reference section and trailer.
+func writeSections(ctx *model.Context) (err error) {
+	if !ctx.Write.Streamed {
+		if err = BeginWrite(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Ensure there is no root version.
 	if ctx.RootVersion != nil {
 		ctx.RootDict.Delete("Version")
@@ -130,16 +151,58 @@ func Write(ctx *model.Context) (err error) {
 		return err
 	}
 
-	if err = setFileSizeOfWrittenFile(ctx.Write); err != nil {
+	return nil
+}
+
+// BeginWrite prepares ctx for writing and writes the PDF header.
+// Final objects may be streamed out via FlushStreamDict before the remaining objects get written by Write.
+func BeginWrite(ctx *model.Context) error {
+	if err := prepareContextForWriting(ctx); err != nil {
 		return err
 	}
 
-	if ctx.Read != nil {
-		ctx.Write.BinaryImageSize = ctx.Read.BinaryImageSize
-		ctx.Write.BinaryFontSize = ctx.Read.BinaryFontSize
-		logWriteStats(ctx)
+	// Since we support PDF Collections (since V1.7) for file attachments
+	// we need to generate V1.7 PDF files.
+	// PDF 2.0 files are written as PDF 2.0 files.
+	v := model.V17
+	if ctx.HeaderVersion != nil && ctx.Version() == model.V20 {
+		v = model.V20
 	}
 
+	if err := writeHeader(ctx.Write, v); err != nil {
+		return err
+	}
+
+	ctx.Write.Streamed = true
+
+	return nil
+}
+
+// FlushStreamDict writes the stream object ir including all objects it references right away and releases its stream data.
+// These objects must not be modified afterwards.
+func FlushStreamDict(ctx *model.Context, ir types.IndirectRef) error {
+	if ctx.Write.HasWriteOffset(ir.ObjectNumber.Value()) {
+		return nil
+	}
+
+	entry, found := ctx.FindTableEntryForIndRef(&ir)
+	if !found || entry.Free {
+		return nil
+	}
+
+	sd, ok := entry.Object.(types.StreamDict)
+	if !ok {
+		return nil
+	}
+
+	if err := writeIndirectObject(ctx, ir); err != nil {
+		return err
+	}
+
+	// Keep the dict for validation.
+	sd.Raw, sd.Content = nil, nil
+	entry.Object = sd
+
 	return nil
 }
 
@@ -243,11 +306,18 @@ func writePages(ctx *model.Context, rootDict types.Dict) error {
 		return errors.New("pdfcpu: writePages: missing indirect obj for pages dict")
 	}
 
+	p := 0
+
+	if ctx.WriteHybridXref {
+		// Keep the page tree accessible for pre V1.5 readers.
+		_, _, err := writePagesDict(ctx, indRef, &p)
+		return err
+	}
+
 	// Embed all page tree objects into objects stream.
 	ctx.Write.WriteToObjectStream = true
 
 	// Write page tree.
-	p := 0
 	if _, _, err := writePagesDict(ctx, indRef, &p); err != nil {
 		return err
 	}
@@ -421,6 +491,10 @@ func writeTrailerDict(ctx *model.Context) error {
 		d.Insert("Prev", types.Integer(*ctx.Write.OffsetPrevXRef))
 	}
 
+	if ctx.Write.OffsetXRefStm != nil {
+		d.Insert("XRefStm", types.Integer(*ctx.Write.OffsetXRefStm))
+	}
+
 	if _, err := w.WriteString(d.PDFString()); err != nil {
 		return err
 	}
@@ -432,6 +506,9 @@ func writeTrailerDict(ctx *model.Context) error {
 	return nil
 }
 
+// maxXRefTableOffset is the largest offset fitting into the 10 digit offset field of a cross reference table entry.
+const maxXRefTableOffset = 9999999999
+
 func writeXRefSubsection(ctx *model.Context, start int, size int) error {
 	if log.WriteEnabled() {
 		log.Write.Printf("writeXRefSubsection: start=%d size=%d\n", start, size)
@@ -463,6 +540,9 @@ func writeXRefSubsection(ctx *model.Context, start int, size int) error {
 			if found {
 				off = writeOffset
 			}
+			if off > maxXRefTableOffset {
+				return errors.Errorf("pdfcpu: writeXRefSubsection: offset %d of obj #%d exceeds xref table limit", off, i)
+			}
 			s = fmt.Sprintf("%010d %05d n%2s", off, *entry.Generation, w.Eol)
 		}
 
@@ -586,8 +666,10 @@ func sortedWritableKeys(ctx *model.Context) []int {
 
 // After inserting the last object write the cross reference table to disk.
 func writeXRefTable(ctx *model.Context) error {
-	keys := sortedWritableKeys(ctx)
+	return writeXRefTableForKeys(ctx, sortedWritableKeys(ctx))
+}
 
+func writeXRefTableForKeys(ctx *model.Context, keys []int) error {
 	objCount := len(keys)
 	if log.WriteEnabled() {
 		log.Write.Printf("xref has %d entries\n", objCount)
@@ -647,6 +729,15 @@ func writeXRefTable(ctx *model.Context) error {
 	return ctx.Write.WriteEol()
 }
 
+// byteCount returns the number of bytes needed to represent the non negative integer i.
+func byteCount(i int64) (c int) {
+	for i > 0 {
+		i >>= 8
+		c++
+	}
+	return c
+}
+
 // int64ToBuf returns a byte slice with length byteCount representing integer i.
 func int64ToBuf(i int64, byteCount int) (buf []byte) {
 	j := 0
@@ -742,6 +833,10 @@ func createXRefStream(ctx *model.Context, i1, i2, i3 int, objNrs []int) ([]byte,
 			log.Write.Printf("createXRefStream: written: %x %x %x \n", s1, s2, s3)
 		}
 
+		if len(s1) != i1 || len(s2) != i2 || len(s3) != i3 {
+			return nil, nil, errors.Errorf("pdfcpu: createXRefStream: entry for obj #%d exceeds field widths [%d %d %d]", j, i1, i2, i3)
+		}
+
 		buf = append(buf, s1...)
 		buf = append(buf, s2...)
 		buf = append(buf, s3...)
@@ -819,13 +914,7 @@ func writeXRefStream(ctx *model.Context) error {
 
 	i1 := 1 // 0, 1 or 2 always fit into 1 byte.
 
-	i2 := func(i int64) (byteCount int) {
-		for i > 0 {
-			i >>= 8
-			byteCount++
-		}
-		return byteCount
-	}(i2Base)
+	i2 := byteCount(i2Base)
 
 	i3 := 2 // scale for max objectstream index <= 0x ff ff
 
@@ -880,6 +969,79 @@ func writeXRefStream(ctx *model.Context) error {
 	return nil
 }
 
+// writeHybridXRef writes a cross reference stream for all compressed objects
+// followed by a cross reference table for all remaining objects referring to this stream via "XRefStm".
+func writeHybridXRef(ctx *model.Context) error {
+	if log.WriteEnabled() {
+		log.Write.Println("writeHybridXRef begin")
+	}
+
+	var compressed []int
+	for _, objNr := range sortedWritableKeys(ctx) {
+		if entry := ctx.Table[objNr]; !entry.Free && entry.Compressed {
+			compressed = append(compressed, objNr)
+		}
+	}
+
+	if len(compressed) == 0 {
+		return writeXRefTable(ctx)
+	}
+
+	xRefTable := ctx.XRefTable
+	xRefStreamDict := newXRefStreamDict(ctx)
+
+	// Any previous xref section is taken from the trailer.
+	xRefStreamDict.Delete("Prev")
+
+	xRefTableEntry := model.NewXRefTableEntryGen0(*xRefStreamDict)
+
+	objNumber, err := xRefTable.InsertAndUseRecycled(*xRefTableEntry)
+	if err != nil {
+		return err
+	}
+
+	xRefStreamDict.Insert("Size", types.Integer(*xRefTable.Size))
+
+	offset := ctx.Write.Offset
+
+	// The second field holds object stream numbers.
+	i2 := byteCount(int64(*xRefTable.Size))
+
+	xRefStreamDict.Insert("W", types.Array{types.Integer(1), types.Integer(i2), types.Integer(2)})
+
+	content, indArr, err := createXRefStream(ctx, 1, i2, 2, compressed)
+	if err != nil {
+		return err
+	}
+
+	xRefStreamDict.Content = content
+	xRefStreamDict.Insert("Index", *indArr)
+
+	if err = xRefStreamDict.StreamDict.Encode(); err != nil {
+		return err
+	}
+
+	if err = writeStreamDictObject(ctx, objNumber, 0, xRefStreamDict.StreamDict); err != nil {
+		return err
+	}
+
+	ctx.Write.OffsetXRefStm = &offset
+
+	// Compressed objects are hidden from pre V1.5 readers.
+	var keys []int
+	for _, objNr := range sortedWritableKeys(ctx) {
+		if entry := ctx.Table[objNr]; entry.Free || !entry.Compressed {
+			keys = append(keys, objNr)
+		}
+	}
+
+	if log.WriteEnabled() {
+		log.Write.Println("writeHybridXRef end")
+	}
+
+	return writeXRefTableForKeys(ctx, keys)
+}
+
 func writeEncryptDict(ctx *model.Context) error {
 	// Bail out unless we really have to write encrypted.
 	if ctx.Encrypt == nil || ctx.EncKey == nil {
@@ -909,6 +1071,7 @@ func setupEncryption(ctx *model.Context) error {
 		ctx.EncryptUsingAES,
 		ctx.EncryptKeyLength,
 		ctx.Permissions,
+		ctx.HeaderVersion != nil && ctx.Version() == model.V20,
 	)
 
 	if ctx.E, err = supportedEncryption(ctx, d); err != nil {
@@ -923,7 +1086,7 @@ func setupEncryption(ctx *model.Context) error {
 		return err
 	}
 
-	if err = calcOAndU(ctx, d); err != nil {
+	if err = calcOAndU(ctx, d, ctx.OwnerPW, ctx.UserPW); err != nil {
 		return err
 	}
 
@@ -963,19 +1126,22 @@ func updateEncryption(ctx *model.Context) error {
 
 	// ctx.Cmd == CHANGEUPW or CHANGE OPW
 
+	// Start out with the passwords used for decryption.
+	userpw, ownerpw := ctx.Read.UserPW, ctx.Read.OwnerPW
+
 	if ctx.UserPWNew != nil {
-		//fmt.Printf("change upw from <%s> to <%s>\n", ctx.UserPW, *ctx.UserPWNew)
-		ctx.UserPW = *ctx.UserPWNew
+		//fmt.Printf("change upw from <%s> to <%s>\n", userpw, *ctx.UserPWNew)
+		userpw = *ctx.UserPWNew
 	}
 
 	if ctx.OwnerPWNew != nil {
-		//fmt.Printf("change opw from <%s> to <%s>\n", ctx.OwnerPW, *ctx.OwnerPWNew)
-		ctx.OwnerPW = *ctx.OwnerPWNew
+		//fmt.Printf("change opw from <%s> to <%s>\n", ownerpw, *ctx.OwnerPWNew)
+		ownerpw = *ctx.OwnerPWNew
 	}
 
-	if ctx.E.R == 5 {
+	if ctx.E.R >= 5 {
 
-		if err = calcOAndU(ctx, d); err != nil {
+		if err = calcOAndU(ctx, d, ownerpw, userpw); err != nil {
 			return err
 		}
 
@@ -983,14 +1149,14 @@ func updateEncryption(ctx *model.Context) error {
 	}
 
 	//fmt.Printf("opw before: length:%d <%s>\n", len(ctx.E.O), ctx.E.O)
-	if ctx.E.O, err = o(ctx); err != nil {
+	if ctx.E.O, err = o(ctx, ownerpw, userpw); err != nil {
 		return err
 	}
 	//fmt.Printf("opw after: length:%d <%s> %0X\n", len(ctx.E.O), ctx.E.O, ctx.E.O)
 	d.Update("O", types.HexLiteral(hex.EncodeToString(ctx.E.O)))
 
 	//fmt.Printf("upw before: length:%d <%s>\n", len(ctx.E.U), ctx.E.U)
-	if ctx.E.U, ctx.EncKey, err = u(ctx); err != nil {
+	if ctx.E.U, ctx.EncKey, err = u(ctx, userpw); err != nil {
 		return err
 	}
 	//fmt.Printf("upw after: length:%d <%s> %0X\n", len(ctx.E.U), ctx.E.U, ctx.E.U)
@@ -1032,7 +1198,7 @@ func handleEncryption(ctx *model.Context) error {
 	}
 
 	// write xrefstream if using xrefstream only.
-	if ctx.Encrypt != nil && ctx.EncKey != nil && !ctx.Read.UsingXRefStreams {
+	if ctx.Encrypt != nil && ctx.EncKey != nil && !ctx.Read.UsingXRefStreams && !ctx.EncryptUsingObjectStreams {
 		ctx.WriteObjectStream = false
 		ctx.WriteXRefStream = false
 	}
@@ -1041,7 +1207,16 @@ func handleEncryption(ctx *model.Context) error {
 }
 
 func writeXRef(ctx *model.Context) error {
+	if ctx.Write.Offset > maxXRefTableOffset {
+		// Offsets beyond the reach of a cross reference table require a cross reference stream.
+		return writeXRefStream(ctx)
+	}
+
 	if ctx.WriteXRefStream {
+		if ctx.WriteHybridXref {
+			// Write cross reference table and a cross reference stream for compressed objects.
+			return writeHybridXRef(ctx)
+		}
 		// Write cross reference stream and generate objectstreams.
 		return writeXRefStream(ctx)
 	}
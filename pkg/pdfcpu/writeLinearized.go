@@ -0,0 +1,915 @@
+/*
+Copyright 2023 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/mjuen/pdfcpu/pkg/filter"
+	"github.com/mjuen/pdfcpu/pkg/log"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/model"
+	"github.com/mjuen/pdfcpu/pkg/pdfcpu/types"
+	"github.com/pkg/errors"
+)
+
+// A linearized file is organized in parts, see Annex F.3:
+//
+//	1 Header
+//	2 Linearization parameter dictionary
+//	3 First-page cross-reference table and trailer
+//	4 Document catalog and document-level objects
+//	5 Primary hint stream
+//	6 First-page section
+//	7 Remaining pages
+//	8 Shared objects
+//	9 Other objects
+//	11 Main cross-reference table and trailer
+//
+// Objects of parts 2 to 6 are numbered first and make up the first-page cross-reference table.
+// No overflow hint stream (part 10) is written.
+
+type linearizer struct {
+	ctx *model.Context
+	eol string
+
+	pages []int        // Page dict obj#s in page order.
+	stop  types.IntSet // Page dicts and page tree nodes.
+
+	assigned types.IntSet
+
+	openDoc   []int   // Part 4
+	firstPage []int   // Part 6
+	pageObjs  [][]int // Part 7, page dict first.
+	shared    []int   // Part 8
+	other     []int   // Part 9
+
+	pageShared [][]int // Shared object identifiers referenced by each page.
+
+	lookup map[int]int // Maps obj#s to new obj#s.
+	hintNr int
+	size   int
+
+	binarySize int64
+}
+
+// collectPages walks the page tree rooted at ir.
+func (l *linearizer) collectPages(ir types.IndirectRef) error {
+	objNr := ir.ObjectNumber.Value()
+	if l.stop[objNr] {
+		return errors.Errorf("pdfcpu: linearize: corrupt page tree at obj #%d", objNr)
+	}
+	l.stop[objNr] = true
+
+	d, err := l.ctx.DereferenceDict(ir)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return errors.Errorf("pdfcpu: linearize: missing page tree node obj #%d", objNr)
+	}
+
+	kids := d.ArrayEntry("Kids")
+	if t := d.Type(); (t != nil && *t == "Page") || kids == nil {
+		l.pages = append(l.pages, objNr)
+		return nil
+	}
+
+	for _, o := range kids {
+		kid, ok := o.(types.IndirectRef)
+		if !ok {
+			return errors.Errorf("pdfcpu: linearize: corrupt page tree node obj #%d", objNr)
+		}
+		if err := l.collectPages(kid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedDictKeys(d types.Dict) []string {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// reach appends all objects reachable from o in depth first order to objNrs.
+// Objects in stop do not get visited.
+// Stream lengths get written as direct objects and are skipped.
+func (l *linearizer) reach(o types.Object, stop, visited types.IntSet, objNrs []int, skipParent bool) ([]int, error) {
+	var err error
+
+	switch o := o.(type) {
+
+	case types.IndirectRef:
+		objNr := o.ObjectNumber.Value()
+		if visited[objNr] || stop[objNr] {
+			return objNrs, nil
+		}
+		visited[objNr] = true
+		objNrs = append(objNrs, objNr)
+		o1, err := l.ctx.Dereference(o)
+		if err != nil {
+			return nil, err
+		}
+		return l.reach(o1, stop, visited, objNrs, skipParent)
+
+	case types.Dict:
+		for _, k := range sortedDictKeys(o) {
+			if skipParent && k == "Parent" {
+				continue
+			}
+			if objNrs, err = l.reach(o[k], stop, visited, objNrs, skipParent); err != nil {
+				return nil, err
+			}
+		}
+
+	case types.StreamDict:
+		for _, k := range sortedDictKeys(o.Dict) {
+			if k == "Length" || (skipParent && k == "Parent") {
+				continue
+			}
+			if objNrs, err = l.reach(o.Dict[k], stop, visited, objNrs, skipParent); err != nil {
+				return nil, err
+			}
+		}
+
+	case types.Array:
+		for _, v := range o {
+			if objNrs, err = l.reach(v, stop, visited, objNrs, skipParent); err != nil {
+				return nil, err
+			}
+		}
+
+	}
+
+	return objNrs, nil
+}
+
+func (l *linearizer) assign(objNrs []int) {
+	for _, objNr := range objNrs {
+		l.assigned[objNr] = true
+	}
+}
+
+// collectOpenDocument collects the objects needed for opening the document (part 4), see F.3.4.
+func (l *linearizer) collectOpenDocument() error {
+	ctx := l.ctx
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+
+	catalog := ctx.Root.ObjectNumber.Value()
+	visited := types.IntSet{catalog: true}
+	l.openDoc = []int{catalog}
+
+	if ctx.Encrypt != nil && ctx.EncKey != nil {
+		objNr := ctx.Encrypt.ObjectNumber.Value()
+		visited[objNr] = true
+		l.openDoc = append(l.openDoc, objNr)
+	}
+
+	keys := []string{"ViewerPreferences", "PageMode", "Threads", "OpenAction", "AcroForm"}
+	if pm := rootDict.NameEntry("PageMode"); pm != nil && *pm == "UseOutlines" {
+		keys = append(keys, "Outlines")
+	}
+
+	for _, k := range keys {
+		if l.openDoc, err = l.reach(rootDict[k], l.stop, visited, l.openDoc, true); err != nil {
+			return err
+		}
+	}
+
+	l.assign(l.openDoc)
+
+	return nil
+}
+
+func (l *linearizer) collectPageObjects() error {
+	ctx := l.ctx
+
+	// Objects reachable by each page.
+	reached := make([][]int, len(l.pages))
+	owners := map[int]int{}
+
+	for i, objNr := range l.pages {
+		o, err := ctx.Dereference(*types.NewIndirectRef(objNr, 0))
+		if err != nil {
+			return err
+		}
+		if reached[i], err = l.reach(o, l.stop, types.IntSet{objNr: true}, []int{objNr}, true); err != nil {
+			return err
+		}
+		if i == 0 {
+			continue
+		}
+		for _, objNr := range reached[i] {
+			owners[objNr]++
+		}
+	}
+
+	for _, objNr := range reached[0] {
+		if !l.assigned[objNr] {
+			l.firstPage = append(l.firstPage, objNr)
+		}
+	}
+	l.assign(l.firstPage)
+
+	l.pageObjs = make([][]int, len(l.pages)-1)
+	for i := 1; i < len(l.pages); i++ {
+		for _, objNr := range reached[i] {
+			if !l.assigned[objNr] && owners[objNr] == 1 {
+				l.pageObjs[i-1] = append(l.pageObjs[i-1], objNr)
+			}
+		}
+		l.assign(l.pageObjs[i-1])
+	}
+
+	for i := 1; i < len(l.pages); i++ {
+		for _, objNr := range reached[i] {
+			if !l.assigned[objNr] {
+				l.shared = append(l.shared, objNr)
+				l.assigned[objNr] = true
+			}
+		}
+	}
+
+	// The shared object hint table starts with entries for all objects of the first page.
+	ids := map[int]int{}
+	for i, objNr := range l.firstPage {
+		ids[objNr] = i
+	}
+	for i, objNr := range l.shared {
+		ids[objNr] = len(l.firstPage) + i
+	}
+
+	l.pageShared = make([][]int, len(l.pages))
+	for i := 1; i < len(l.pages); i++ {
+		for _, objNr := range reached[i] {
+			if id, ok := ids[objNr]; ok {
+				l.pageShared[i] = append(l.pageShared[i], id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectOther collects all remaining objects (part 9).
+func (l *linearizer) collectOther() error {
+	ctx := l.ctx
+
+	roots := []types.Object{*ctx.Root}
+	if ctx.Info != nil {
+		roots = append(roots, *ctx.Info)
+	}
+
+	var (
+		objNrs []int
+		err    error
+	)
+
+	visited := types.IntSet{}
+	for _, o := range roots {
+		if objNrs, err = l.reach(o, nil, visited, objNrs, false); err != nil {
+			return err
+		}
+	}
+
+	for _, objNr := range objNrs {
+		if !l.assigned[objNr] {
+			l.other = append(l.other, objNr)
+		}
+	}
+	l.assign(l.other)
+
+	return nil
+}
+
+// renumber assigns new object numbers in file order.
+// The linearization dict is obj #1, the primary hint stream follows the document-level objects.
+func (l *linearizer) renumber() {
+	l.lookup = map[int]int{}
+
+	objNr := 2
+
+	add := func(objNrs []int) {
+		for _, i := range objNrs {
+			l.lookup[i] = objNr
+			objNr++
+		}
+	}
+
+	add(l.openDoc)
+	l.hintNr = objNr
+	objNr++
+	add(l.firstPage)
+	for _, objNrs := range l.pageObjs {
+		add(objNrs)
+	}
+	add(l.shared)
+	add(l.other)
+
+	l.size = objNr
+}
+
+func (l *linearizer) firstSectionSize() int {
+	return 2 + len(l.openDoc) + len(l.firstPage)
+}
+
+func (l *linearizer) indRef(objNr int) string {
+	return fmt.Sprintf("%d 0 R", l.lookup[objNr])
+}
+
+// objectBytes returns the serialization of o as obj #objNr.
+func (l *linearizer) objectBytes(objNr int, o types.Object, encrypt bool) ([]byte, error) {
+	ctx := l.ctx
+
+	var bb bytes.Buffer
+	fmt.Fprintf(&bb, "%d 0 obj%s", objNr, l.eol)
+
+	switch o := o.(type) {
+
+	case nil:
+		bb.WriteString("null")
+
+	case types.StreamDict:
+		if o.Raw == nil && o.Content != nil {
+			if err := o.Encode(); err != nil {
+				return nil, err
+			}
+		}
+		if encrypt {
+			if _, err := encryptDeepObject(o, objNr, 0, ctx.EncKey, ctx.AES4Strings, ctx.E.R); err != nil {
+				return nil, err
+			}
+			// Unless the "Identity" crypt filter is used we have to encrypt.
+			if !(len(o.FilterPipeline) == 1 && o.FilterPipeline[0].Name == "Crypt") {
+				raw, err := encryptStream(o.Raw, objNr, 0, ctx.EncKey, ctx.AES4Streams, ctx.E.R)
+				if err != nil {
+					return nil, err
+				}
+				o.Raw = raw
+			}
+		}
+		o.Update("Length", types.Integer(len(o.Raw)))
+		l.binarySize += int64(len(o.Raw))
+		bb.WriteString(o.PDFString())
+		fmt.Fprintf(&bb, "%sstream%s", l.eol, l.eol)
+		bb.Write(o.Raw)
+		fmt.Fprintf(&bb, "%sendstream", l.eol)
+
+	case types.StringLiteral:
+		if encrypt {
+			s, err := encryptString(o.Value(), objNr, 0, ctx.EncKey, ctx.AES4Strings, ctx.E.R)
+			if err != nil {
+				return nil, err
+			}
+			o = types.StringLiteral(*s)
+		}
+		bb.WriteString(o.PDFString())
+
+	case types.HexLiteral:
+		if encrypt {
+			s, err := encryptString(o.Value(), objNr, 0, ctx.EncKey, ctx.AES4Strings, ctx.E.R)
+			if err != nil {
+				return nil, err
+			}
+			o = types.HexLiteral(*s)
+		}
+		bb.WriteString(o.PDFString())
+
+	default:
+		if encrypt {
+			if _, err := encryptDeepObject(o, objNr, 0, ctx.EncKey, ctx.AES4Strings, ctx.E.R); err != nil {
+				return nil, err
+			}
+		}
+		bb.WriteString(o.PDFString())
+
+	}
+
+	fmt.Fprintf(&bb, "%sendobj%s", l.eol, l.eol)
+
+	return bb.Bytes(), nil
+}
+
+// serialize returns the renumbered and encrypted serializations of objNrs.
+func (l *linearizer) serialize(objNrs []int) ([][]byte, error) {
+	ctx := l.ctx
+
+	var encNr int
+	if ctx.Encrypt != nil {
+		encNr = ctx.Encrypt.ObjectNumber.Value()
+	}
+
+	bbs := make([][]byte, len(objNrs))
+
+	for i, objNr := range objNrs {
+		o, err := ctx.Dereference(*types.NewIndirectRef(objNr, 0))
+		if err != nil {
+			return nil, err
+		}
+		if o != nil {
+			o = patchObject(o.Clone(), l.lookup)
+		}
+		encrypt := ctx.EncKey != nil && objNr != encNr
+		if bbs[i], err = l.objectBytes(l.lookup[objNr], o, encrypt); err != nil {
+			return nil, err
+		}
+	}
+
+	return bbs, nil
+}
+
+func totalLength(bbs [][]byte) (n int64) {
+	for _, bb := range bbs {
+		n += int64(len(bb))
+	}
+	return n
+}
+
+// bitWriter packs unsigned integers into a bit stream, most significant bit first.
+type bitWriter struct {
+	buf  []byte
+	cur  byte
+	bits uint
+}
+
+func (w *bitWriter) write(v int64, nbits int) {
+	for i := nbits - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte(v>>uint(i)&1)
+		w.bits++
+		if w.bits == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.bits = 0, 0
+		}
+	}
+}
+
+// flush pads the bit stream to the next byte boundary.
+func (w *bitWriter) flush() {
+	if w.bits > 0 {
+		w.buf = append(w.buf, w.cur<<(8-w.bits))
+		w.cur, w.bits = 0, 0
+	}
+}
+
+// nbits returns the number of bits needed to represent v.
+func nbits(v int64) (n int) {
+	for v > 0 {
+		v >>= 1
+		n++
+	}
+	return n
+}
+
+func minMax(vv []int64) (min, max int64) {
+	for i, v := range vv {
+		if i == 0 || v < min {
+			min = v
+		}
+		if i == 0 || v > max {
+			max = v
+		}
+	}
+	return min, max
+}
+
+// hintLayout holds the offsets of all parts following the primary hint stream
+// as if the hint stream was not present, see F.4.
+type hintLayout struct {
+	firstPageOffset int64   // Offset of the first page's page object.
+	pageLengths     []int64 // Length of each page section.
+	sharedOffset    int64   // Offset of the first shared object.
+	sharedLengths   []int64 // Length of each first page object followed by the length of each shared object.
+}
+
+func (l *linearizer) writePageOffsetHintTable(w *bitWriter, hl hintLayout) {
+	n := len(l.pages)
+
+	nobjects := make([]int64, n)
+	nobjects[0] = int64(len(l.firstPage))
+	for i, objNrs := range l.pageObjs {
+		nobjects[i+1] = int64(len(objNrs))
+	}
+
+	nshared := make([]int64, n)
+	var maxID int64
+	for i, ids := range l.pageShared {
+		nshared[i] = int64(len(ids))
+		for _, id := range ids {
+			if int64(id) > maxID {
+				maxID = int64(id)
+			}
+		}
+	}
+
+	minObjs, maxObjs := minMax(nobjects)
+	minLen, maxLen := minMax(hl.pageLengths)
+	_, maxShared := minMax(nshared)
+
+	nbitsObjs := nbits(maxObjs - minObjs)
+	nbitsLen := nbits(maxLen - minLen)
+	nbitsShared := nbits(maxShared)
+	nbitsID := nbits(maxID)
+
+	// Header, see Table F.3
+	w.write(minObjs, 32)
+	w.write(hl.firstPageOffset, 32)
+	w.write(int64(nbitsObjs), 16)
+	w.write(minLen, 32)
+	w.write(int64(nbitsLen), 16)
+	w.write(0, 32) // Least offset to the start of the content stream.
+	w.write(0, 16)
+	w.write(minLen, 32) // Content stream lengths are approximated by page lengths.
+	w.write(int64(nbitsLen), 16)
+	w.write(int64(nbitsShared), 16)
+	w.write(int64(nbitsID), 16)
+	w.write(0, 16) // No fractional positions of shared object references.
+	w.write(1, 16)
+
+	// Per page entries, see Table F.4
+	for _, v := range nobjects {
+		w.write(v-minObjs, nbitsObjs)
+	}
+	w.flush()
+
+	for _, v := range hl.pageLengths {
+		w.write(v-minLen, nbitsLen)
+	}
+	w.flush()
+
+	for _, v := range nshared {
+		w.write(v, nbitsShared)
+	}
+	w.flush()
+
+	for _, ids := range l.pageShared {
+		for _, id := range ids {
+			w.write(int64(id), nbitsID)
+		}
+	}
+	w.flush()
+
+	// Numerators and content stream offsets take 0 bits.
+	w.flush()
+	w.flush()
+
+	for _, v := range hl.pageLengths {
+		w.write(v-minLen, nbitsLen)
+	}
+	w.flush()
+}
+
+func (l *linearizer) writeSharedObjectHintTable(w *bitWriter, hl hintLayout) {
+	var firstSharedNr, firstSharedOffset int64
+	if len(l.shared) > 0 {
+		firstSharedNr = int64(l.lookup[l.shared[0]])
+		firstSharedOffset = hl.sharedOffset
+	}
+
+	minLen, maxLen := minMax(hl.sharedLengths)
+	nbitsLen := nbits(maxLen - minLen)
+
+	// Header, see Table F.5
+	w.write(firstSharedNr, 32)
+	w.write(firstSharedOffset, 32)
+	w.write(int64(len(l.firstPage)), 32)
+	w.write(int64(len(hl.sharedLengths)), 32)
+	w.write(0, 16) // Each group consists of a single object.
+	w.write(minLen, 32)
+	w.write(int64(nbitsLen), 16)
+
+	// Per group entries, see Table F.6
+	for _, v := range hl.sharedLengths {
+		w.write(v-minLen, nbitsLen)
+	}
+	w.flush()
+
+	// No MD5 signatures.
+	for range hl.sharedLengths {
+		w.write(0, 1)
+	}
+	w.flush()
+
+	// Object counts take 0 bits.
+	w.flush()
+}
+
+// hintStream returns the serialized primary hint stream.
+func (l *linearizer) hintStream(hl hintLayout) ([]byte, error) {
+	w := &bitWriter{}
+
+	l.writePageOffsetHintTable(w, hl)
+	s := len(w.buf)
+	l.writeSharedObjectHintTable(w, hl)
+
+	sd := types.StreamDict{
+		Dict:           types.NewDict(),
+		Content:        w.buf,
+		FilterPipeline: []types.PDFFilter{{Name: filter.Flate, DecodeParms: nil}},
+	}
+	sd.InsertName("Filter", filter.Flate)
+	sd.InsertInt("S", s)
+
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+
+	return l.objectBytes(l.hintNr, sd, l.ctx.EncKey != nil)
+}
+
+func (l *linearizer) xRefEntries(offsets []int64) (string, error) {
+	var s string
+	for _, off := range offsets {
+		if off > maxXRefTableOffset {
+			return "", errors.Errorf("pdfcpu: linearize: offset %d exceeds xref table limit", off)
+		}
+		s += fmt.Sprintf("%010d 00000 n%2s", off, l.eol)
+	}
+	return s, nil
+}
+
+func (l *linearizer) trailerEntries() string {
+	ctx := l.ctx
+
+	s := fmt.Sprintf("/Size %d/Root %s", l.size, l.indRef(ctx.Root.ObjectNumber.Value()))
+	if ctx.Info != nil {
+		s += "/Info " + l.indRef(ctx.Info.ObjectNumber.Value())
+	}
+	if ctx.Encrypt != nil && ctx.EncKey != nil {
+		s += "/Encrypt " + l.indRef(ctx.Encrypt.ObjectNumber.Value())
+	}
+	if ctx.ID != nil {
+		s += "/ID " + ctx.ID.PDFString()
+	}
+
+	return s
+}
+
+// Reserved width of offsets which are not known until the complete file has been laid out.
+const linOffsetFormat = "%10d"
+
+func (l *linearizer) linearizationDict(length, hintOffset, hintLength, endFirstPage, mainXRefEntries int64) []byte {
+	f := linOffsetFormat
+	s := fmt.Sprintf("<</Linearized 1/L "+f+"/H ["+f+" "+f+"]/O %d/E "+f+"/N %d/T "+f+">>",
+		length, hintOffset, hintLength, l.lookup[l.pages[0]], endFirstPage, len(l.pages), mainXRefEntries)
+	return []byte(fmt.Sprintf("1 0 obj%s%s%sendobj%s", l.eol, s, l.eol, l.eol))
+}
+
+func (l *linearizer) firstPageXRef(offsets []int64, mainXRefOffset int64) ([]byte, error) {
+	entries, err := l.xRefEntries(offsets)
+	if err != nil {
+		return nil, err
+	}
+
+	s := fmt.Sprintf("xref%s1 %d%s%s", l.eol, len(offsets), l.eol, entries)
+	s += fmt.Sprintf("trailer%s<<%s/Prev "+linOffsetFormat+">>%s", l.eol, l.trailerEntries(), mainXRefOffset, l.eol)
+	s += fmt.Sprintf("startxref%s0%s%%%%EOF%s", l.eol, l.eol, l.eol)
+
+	return []byte(s), nil
+}
+
+func (l *linearizer) mainXRef(offsets []int64, firstPageXRefOffset int64) ([]byte, error) {
+	entries, err := l.xRefEntries(offsets)
+	if err != nil {
+		return nil, err
+	}
+
+	s := fmt.Sprintf("xref%s0 1%s%010d %05d f%2s", l.eol, l.eol, 0, types.FreeHeadGeneration, l.eol)
+	if len(offsets) > 0 {
+		s += fmt.Sprintf("%d %d%s%s", l.firstSectionSize()+1, len(offsets), l.eol, entries)
+	}
+	s += fmt.Sprintf("trailer%s<<%s>>%s", l.eol, l.trailerEntries(), l.eol)
+	s += fmt.Sprintf("startxref%s%d%s%%%%EOF%s", l.eol, firstPageXRefOffset, l.eol, l.eol)
+
+	return []byte(s), nil
+}
+
+// mainXRefEntriesOffset returns the offset of the white-space character preceding the first entry of the main xref table at off.
+func (l *linearizer) mainXRefEntriesOffset(off int64) int64 {
+	return off + int64(len(fmt.Sprintf("xref%s0 1%s", l.eol, l.eol))) - 1
+}
+
+func (l *linearizer) header() []byte {
+	// See BeginWrite.
+	v := model.V17
+	if l.ctx.HeaderVersion != nil && l.ctx.Version() == model.V20 {
+		v = model.V20
+	}
+	return []byte(fmt.Sprintf("%%PDF-%s%s%%\xe2\xe3\xcf\xD3%s", v, l.eol, l.eol))
+}
+
+func (l *linearizer) layout() ([][]byte, error) {
+	openDoc, err := l.serialize(l.openDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	firstPage, err := l.serialize(l.firstPage)
+	if err != nil {
+		return nil, err
+	}
+
+	var rest [][]byte
+	pageLengths := []int64{totalLength(firstPage)}
+	for _, objNrs := range l.pageObjs {
+		bbs, err := l.serialize(objNrs)
+		if err != nil {
+			return nil, err
+		}
+		pageLengths = append(pageLengths, totalLength(bbs))
+		rest = append(rest, bbs...)
+	}
+
+	shared, err := l.serialize(l.shared)
+	if err != nil {
+		return nil, err
+	}
+	rest = append(rest, shared...)
+
+	other, err := l.serialize(l.other)
+	if err != nil {
+		return nil, err
+	}
+	rest = append(rest, other...)
+
+	// Parts 1 to 4, the sizes of the linearization dict and the first page xref table are fixed.
+	header := l.header()
+	linDict := l.linearizationDict(0, 0, 0, 0, 0)
+	xRef1, err := l.firstPageXRef(make([]int64, l.firstSectionSize()), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	xRef1Offset := int64(len(header) + len(linDict))
+	off := xRef1Offset + int64(len(xRef1))
+
+	offsets1 := []int64{int64(len(header))}
+	for _, bb := range openDoc {
+		offsets1 = append(offsets1, off)
+		off += int64(len(bb))
+	}
+
+	// Offsets following the hint stream excluding the hint stream.
+	hintOffset := off
+	firstPageOffset := off
+
+	var firstPageOffsets []int64
+	for _, bb := range firstPage {
+		firstPageOffsets = append(firstPageOffsets, off)
+		off += int64(len(bb))
+	}
+	endFirstPage := off
+
+	var offsets []int64
+	for _, bb := range rest {
+		offsets = append(offsets, off)
+		off += int64(len(bb))
+	}
+	mainXRefOffset := off
+
+	var sharedOffset int64
+	var sharedLengths []int64
+	for _, bb := range firstPage {
+		sharedLengths = append(sharedLengths, int64(len(bb)))
+	}
+	if len(shared) > 0 {
+		sharedOffset = offsets[len(rest)-len(other)-len(shared)]
+		for _, bb := range shared {
+			sharedLengths = append(sharedLengths, int64(len(bb)))
+		}
+	}
+
+	hint, err := l.hintStream(hintLayout{
+		firstPageOffset: firstPageOffset,
+		pageLengths:     pageLengths,
+		sharedOffset:    sharedOffset,
+		sharedLengths:   sharedLengths,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Shift everything following the hint stream.
+	hintLength := int64(len(hint))
+	offsets1 = append(offsets1, hintOffset)
+	for _, off := range firstPageOffsets {
+		offsets1 = append(offsets1, off+hintLength)
+	}
+	for i := range offsets {
+		offsets[i] += hintLength
+	}
+	mainXRefOffset += hintLength
+	endFirstPage += hintLength
+
+	xRef2, err := l.mainXRef(offsets, xRef1Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	length := mainXRefOffset + int64(len(xRef2))
+
+	linDict = l.linearizationDict(length, hintOffset, hintLength, endFirstPage, l.mainXRefEntriesOffset(mainXRefOffset))
+
+	if xRef1, err = l.firstPageXRef(offsets1, mainXRefOffset); err != nil {
+		return nil, err
+	}
+
+	bbs := [][]byte{header, linDict, xRef1}
+	bbs = append(bbs, openDoc...)
+	bbs = append(bbs, hint)
+	bbs = append(bbs, firstPage...)
+	bbs = append(bbs, rest...)
+	bbs = append(bbs, xRef2)
+
+	return bbs, nil
+}
+
+// writeLinearized writes ctx as linearized file, see Annex F.
+func writeLinearized(ctx *model.Context) error {
+	if log.CLIEnabled() {
+		log.CLI.Println("linearizing...")
+	}
+
+	if err := prepareContextForWriting(ctx); err != nil {
+		return err
+	}
+
+	// Ensure there is no root version.
+	if ctx.RootVersion != nil {
+		ctx.RootDict.Delete("Version")
+	}
+
+	l := &linearizer{ctx: ctx, eol: ctx.Write.Eol, stop: types.IntSet{}, assigned: types.IntSet{}}
+
+	ir, err := ctx.Pages()
+	if err != nil {
+		return err
+	}
+
+	if err := l.collectPages(*ir); err != nil {
+		return err
+	}
+
+	if len(l.pages) == 0 {
+		return errors.New("pdfcpu: linearize: missing pages")
+	}
+
+	if err := l.collectOpenDocument(); err != nil {
+		return err
+	}
+
+	if err := l.collectPageObjects(); err != nil {
+		return err
+	}
+
+	if err := l.collectOther(); err != nil {
+		return err
+	}
+
+	l.renumber()
+
+	bbs, err := l.layout()
+	if err != nil {
+		return err
+	}
+
+	w := ctx.Write
+	for _, bb := range bbs {
+		if _, err := w.Write(bb); err != nil {
+			return err
+		}
+		w.Offset += int64(len(bb))
+	}
+
+	w.BinaryTotalSize += l.binarySize
+
+	if log.WriteEnabled() {
+		log.Write.Printf("writeLinearized: %d objects, %d bytes\n", l.size-1, w.Offset)
+	}
+
+	return nil
+}